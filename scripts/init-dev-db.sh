@@ -101,7 +101,9 @@ fi
 echo -n "  SSOT HR... "
 cd "$PROJECT_ROOT/services/ssot-hr"
 if [ -d "migrations" ] && [ "$(ls -A migrations/*.sql 2>/dev/null)" ]; then
-    PGPASSWORD=$POSTGRES_PASSWORD psql -h $POSTGRES_HOST -p $POSTGRES_PORT -U $POSTGRES_USER -d ssp_hr -f migrations/001_init.sql 2>&1 | grep -v "NOTICE" || true
+    for f in migrations/*.sql; do
+        PGPASSWORD=$POSTGRES_PASSWORD psql -h $POSTGRES_HOST -p $POSTGRES_PORT -U $POSTGRES_USER -d ssp_hr -f "$f" 2>&1 | grep -v "NOTICE" || true
+    done
     echo -e "${GREEN}done${NC}"
 else
     echo -e "${YELLOW}no migrations${NC}"
@@ -10,9 +10,11 @@ import (
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/api"
+	"github.com/edvirons/ssp/ims/internal/audit"
 	"github.com/edvirons/ssp/ims/internal/config"
 	"github.com/edvirons/ssp/ims/internal/jobs"
 	"github.com/edvirons/ssp/ims/internal/logging"
+	"github.com/edvirons/ssp/ims/internal/ssot"
 	"github.com/edvirons/ssp/ims/internal/ssotcache"
 	"github.com/edvirons/ssp/ims/internal/store"
 )
@@ -33,19 +35,31 @@ func main() {
 	defer pg.Close()
 
 	// SSOT consumer: listen to ssot.*.changed events and cache latest SSOT exports in IMS DB
-	if err := ssotcache.Start(ctx, logger, pg.RawPool(), ssotcache.ConfigFromEnv()); err != nil {
+	cacheCfg := ssotcache.ConfigFromEnv()
+	cacheCfg.OnDeviceTransitions = jobs.DeviceLifecycleReactor(logger, pg, audit.NewLogger(audit.NewStore(pg.AuditStorePool())))
+	if err := ssotcache.Start(ctx, logger, pg.RawPool(), cacheCfg); err != nil {
 		logger.Error("ssotcache failed to start", logging.Err(err))
 	}
 
 	rdb := store.NewValkey(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
 	defer func() { _ = rdb.Close() }()
 
+	srv := api.NewServer(cfg, logger, pg, rdb)
+
 	// Background jobs
-	j := jobs.NewScheduler(logger, pg)
+	j := jobs.NewScheduler(logger, pg, srv.WSHub(), ssot.NewClient(cfg.DeviceSSOTBaseURL))
 	j.Start(ctx)
 	defer j.Stop()
 
-	srv := api.NewServer(cfg, logger, pg, rdb)
+	// Outbox relay: publish domain events written by the API to NATS
+	if cfg.NATSURL == "" {
+		logger.Info("outbox relay disabled (NATS_URL not set); events stay queued in event_outbox")
+	} else if relay, err := jobs.NewOutboxRelay(logger, pg, cfg.NATSURL); err != nil {
+		logger.Error("outbox relay failed to start", logging.Err(err))
+	} else {
+		relay.Start(ctx)
+		defer relay.Stop()
+	}
 
 	httpServer := &http.Server{
 		Addr:              cfg.HTTPAddr,
@@ -8,7 +8,7 @@ import (
 )
 
 // mountAdminRoutes registers routes that require admin privileges.
-func (s *Server) mountAdminRoutes(r chi.Router, auditLogs *handlers.AuditLogsHandler, sch *handlers.SchoolHandler, contacts *handlers.SchoolContactsHandler, att *handlers.AttachmentHandler, tel *handlers.TelemetryHandler) {
+func (s *Server) mountAdminRoutes(r chi.Router, auditLogs *handlers.AuditLogsHandler, sch *handlers.SchoolHandler, contacts *handlers.SchoolContactsHandler, att *handlers.AttachmentHandler) {
 	// Audit logs - admin only
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequireRole("ssp_admin", s.logger))
@@ -52,13 +52,6 @@ func (s *Server) mountAdminRoutes(r chi.Router, auditLogs *handlers.AuditLogsHan
 		r.Post("/attachments", att.Create)
 		r.Post("/attachments/{id}/upload-url", att.UploadURL)
 	})
-
-	// Telemetry - ingest
-	r.Group(func(r chi.Router) {
-		r.Use(s.writeRateLimitMiddleware())
-		r.Use(middleware.RequirePermission(auth.PermTelemetryIngest, s.logger))
-		r.Post("/telemetry/events", tel.Ingest)
-	})
 }
 
 // mountLeadTechDashboardRoutes registers lead tech dashboard routes.
@@ -20,6 +20,8 @@ func RegisterAIChatRoutes(r chi.Router, log *zap.Logger, pg *store.Postgres, hub
 		r.Post("/sessions", livechatHandler.StartSession)
 		r.Get("/sessions/{id}/queue", livechatHandler.GetQueuePosition)
 		r.Post("/sessions/{id}/end", livechatHandler.EndSession)
+		r.Get("/sessions/{id}/survey", livechatHandler.GetSurvey)
+		r.Post("/sessions/{id}/survey", livechatHandler.RespondToSurvey)
 
 		// AI Chat routes - school contacts can send messages to AI
 		r.Route("/ai", func(r chi.Router) {
@@ -45,12 +47,26 @@ func RegisterAIChatRoutes(r chi.Router, log *zap.Logger, pg *store.Postgres, hub
 			// Availability
 			r.Put("/availability", livechatHandler.SetAvailability)
 			r.Get("/availability", livechatHandler.GetAvailability)
+
+			// Canned responses
+			r.Get("/canned-responses", livechatHandler.ListCannedResponses)
+			r.Post("/sessions/{id}/canned-responses/{responseId}/render", livechatHandler.RenderCannedResponse)
 		})
 
-		// Admin only - metrics
+		// Admin only - metrics, routing and canned responses
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireRoles("ssp_admin"))
 			r.Get("/metrics", livechatHandler.GetChatMetrics)
+
+			// Routing rules and decision log
+			r.Get("/routing/settings", livechatHandler.GetRoutingSettings)
+			r.Put("/routing/settings", livechatHandler.UpdateRoutingSettings)
+			r.Get("/routing/decisions", livechatHandler.ListRoutingDecisions)
+
+			// Canned response management
+			r.Post("/canned-responses", livechatHandler.CreateCannedResponse)
+			r.Patch("/canned-responses/{responseId}", livechatHandler.UpdateCannedResponse)
+			r.Delete("/canned-responses/{responseId}", livechatHandler.DeleteCannedResponse)
 		})
 	})
 }
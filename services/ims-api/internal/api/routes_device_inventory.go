@@ -8,11 +8,16 @@ import (
 )
 
 // mountDeviceInventoryRoutes registers device inventory routes for school contacts and admins.
-func (s *Server) mountDeviceInventoryRoutes(r chi.Router, inv *handlers.DeviceInventoryHandler) {
+func (s *Server) mountDeviceInventoryRoutes(r chi.Router, inv *handlers.DeviceInventoryHandler, nd *handlers.NetworkDiscoveryHandler) {
 	// School Inventory - read operations (for school contacts)
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequirePermission(auth.PermDeviceInventory, s.logger))
 		r.Get("/schools/{schoolId}/inventory", inv.GetSchoolInventory)
+		r.Get("/schools/{schoolId}/device-registrations", inv.ListDeviceRegistrations)
+		r.Get("/device-registrations/{id}", inv.GetDeviceRegistration)
+		r.Get("/schools/{schoolId}/network-scans", nd.ListScans)
+		r.Get("/schools/{schoolId}/unknown-macs", nd.ListUnknownMACs)
+		r.Get("/schools/{schoolId}/devices/unseen", nd.ListUnseenDevices)
 	})
 
 	// Locations - read operations
@@ -51,6 +56,7 @@ func (s *Server) mountDeviceInventoryRoutes(r chi.Router, inv *handlers.DeviceIn
 		r.Get("/schools/{schoolId}/groups", inv.ListGroups)
 		r.Get("/groups/{id}", inv.GetGroup)
 		r.Get("/groups/{id}/devices", inv.GetGroupDevices)
+		r.Get("/groups/{id}/history", inv.GetGroupHistory)
 	})
 
 	// Device Groups - write operations
@@ -58,6 +64,8 @@ func (s *Server) mountDeviceInventoryRoutes(r chi.Router, inv *handlers.DeviceIn
 		r.Use(s.writeRateLimitMiddleware())
 		r.Use(middleware.RequirePermission(auth.PermGroupWrite, s.logger))
 		r.Post("/groups", inv.CreateGroup)
+		r.Post("/groups/preview", inv.PreviewGroup)
+		r.Post("/groups/{id}/evaluate", inv.EvaluateGroup)
 		r.Post("/groups/{id}/members", inv.AddGroupMembers)
 		r.Delete("/groups/{id}/members", inv.RemoveGroupMembers)
 	})
@@ -67,5 +75,14 @@ func (s *Server) mountDeviceInventoryRoutes(r chi.Router, inv *handlers.DeviceIn
 		r.Use(s.writeRateLimitMiddleware())
 		r.Use(middleware.RequirePermission(auth.PermDeviceCreate, s.logger))
 		r.Post("/schools/{schoolId}/devices", inv.RegisterDevice)
+		r.Post("/device-registrations/{id}/resubmit", inv.ResubmitDeviceRegistration)
+	})
+
+	// Network discovery - scan uploads and unknown MAC triage
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermNetworkScan, s.logger))
+		r.Post("/schools/{schoolId}/network-scans", nd.CreateScan)
+		r.Patch("/unknown-macs/{mac}", nd.UpdateUnknownMAC)
 	})
 }
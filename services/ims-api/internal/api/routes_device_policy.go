@@ -0,0 +1,35 @@
+package api
+
+import (
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// mountDevicePolicyRoutes registers group policy, effective policy and MDM
+// bundle routes.
+func (s *Server) mountDevicePolicyRoutes(r chi.Router, pol *handlers.DevicePolicyHandler) {
+	// Group policies - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermGroupRead, s.logger))
+		r.Get("/groups/{id}/policy", pol.GetGroupPolicy)
+		r.Get("/groups/{id}/policy/versions", pol.ListGroupPolicyVersions)
+		r.Get("/devices/{deviceId}/effective-policy", pol.GetEffectivePolicy)
+	})
+
+	// Group policies - write operations
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermGroupWrite, s.logger))
+		r.Put("/groups/{id}/policy", pol.SetGroupPolicy)
+		r.Delete("/groups/{id}/policy", pol.ClearGroupPolicy)
+	})
+
+	// MDM - signed policy bundles for agents
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermMDMPolicyPull, s.logger))
+		r.Get("/mdm/devices/{deviceId}/policy-bundle", pol.GetPolicyBundle)
+		r.Get("/mdm/policy-signing-key", pol.GetSigningKey)
+	})
+}
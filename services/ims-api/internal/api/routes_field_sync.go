@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// mountFieldSyncRoutes registers the offline field technician sync routes.
+// Both are scoped to work orders assigned to the caller, across schools.
+func (s *Server) mountFieldSyncRoutes(r chi.Router, fs *handlers.FieldSyncHandler) {
+	// Field sync - pull
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermWorkOrderRead, s.logger))
+		r.Get("/field-sync", fs.Pull)
+	})
+
+	// Field sync - push queued mutations
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermWorkOrderUpdate, s.logger))
+		r.Post("/field-sync/mutations", fs.Push)
+	})
+}
@@ -13,6 +13,7 @@ func (s *Server) mountIncidentRoutes(r chi.Router, inc *handlers.IncidentHandler
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequirePermission(auth.PermIncidentRead, s.logger))
 		r.Get("/incidents/{id}", inc.GetByID)
+		r.Get("/incidents/{id}/sla", inc.GetSLA)
 		r.Get("/incidents", inc.List)
 	})
 
@@ -17,6 +17,7 @@ func (s *Server) mountKBRoutes(r chi.Router, kb *handlers.KBArticlesHandler) {
 		r.Get("/kb/articles/slug/{slug}", kb.GetBySlug)
 		r.Get("/kb/search", kb.Search)
 		r.Get("/kb/stats", kb.GetStats)
+		r.Get("/kb/ai-usage", kb.GetAIUsage)
 	})
 
 	// KB Articles - create operations (admin only)
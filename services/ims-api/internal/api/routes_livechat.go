@@ -18,6 +18,8 @@ func RegisterLivechatRoutes(r chi.Router, log *zap.Logger, pg *store.Postgres, h
 		r.Post("/sessions", h.StartSession)
 		r.Get("/sessions/{id}/queue", h.GetQueuePosition)
 		r.Post("/sessions/{id}/end", h.EndSession)
+		r.Get("/sessions/{id}/survey", h.GetSurvey)
+		r.Post("/sessions/{id}/survey", h.RespondToSurvey)
 
 		// Agent operations
 		r.Group(func(r chi.Router) {
@@ -31,12 +33,26 @@ func RegisterLivechatRoutes(r chi.Router, log *zap.Logger, pg *store.Postgres, h
 			// Availability
 			r.Put("/availability", h.SetAvailability)
 			r.Get("/availability", h.GetAvailability)
+
+			// Canned responses
+			r.Get("/canned-responses", h.ListCannedResponses)
+			r.Post("/sessions/{id}/canned-responses/{responseId}/render", h.RenderCannedResponse)
 		})
 
-		// Admin only - metrics
+		// Admin only - metrics, routing and canned responses
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireRoles("ssp_admin"))
 			r.Get("/metrics", h.GetChatMetrics)
+
+			// Routing rules and decision log
+			r.Get("/routing/settings", h.GetRoutingSettings)
+			r.Put("/routing/settings", h.UpdateRoutingSettings)
+			r.Get("/routing/decisions", h.ListRoutingDecisions)
+
+			// Canned response management
+			r.Post("/canned-responses", h.CreateCannedResponse)
+			r.Patch("/canned-responses/{responseId}", h.UpdateCannedResponse)
+			r.Delete("/canned-responses/{responseId}", h.DeleteCannedResponse)
 		})
 	})
 }
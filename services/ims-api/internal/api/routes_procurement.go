@@ -0,0 +1,49 @@
+package api
+
+import (
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// mountProcurementRoutes registers reorder policy, reorder proposal and
+// purchase order routes.
+func (s *Server) mountProcurementRoutes(r chi.Router, proc *handlers.ProcurementHandler) {
+	// Procurement - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermProcurementRead, s.logger))
+		r.Get("/procurement/reorder-policies", proc.ListPolicies)
+		r.Get("/procurement/proposals", proc.ListProposals)
+		r.Get("/procurement/purchase-orders", proc.ListPurchaseOrders)
+		r.Get("/procurement/purchase-orders/{id}", proc.GetPurchaseOrder)
+	})
+
+	// Procurement - policies, drafting, sending and receiving
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermProcurementManage, s.logger))
+		r.Put("/procurement/reorder-policies", proc.UpsertPolicy)
+		r.Delete("/procurement/reorder-policies/{shopId}/{partId}", proc.DeletePolicy)
+		r.Post("/procurement/proposals/apply", proc.ApplyProposals)
+		r.Post("/procurement/purchase-orders", proc.CreatePurchaseOrder)
+		r.Put("/procurement/purchase-orders/{id}/lines", proc.ReplacePurchaseOrderLines)
+		r.Post("/procurement/purchase-orders/{id}/send", proc.SendPurchaseOrder)
+		r.Post("/procurement/purchase-orders/{id}/receive", proc.ReceivePurchaseOrder)
+		r.Post("/procurement/purchase-orders/{id}/close", proc.ClosePurchaseOrder)
+	})
+
+	// Procurement - approval
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermProcurementApprove, s.logger))
+		r.Post("/procurement/purchase-orders/{id}/approve", proc.ApprovePurchaseOrder)
+	})
+
+	// Procurement - cancellation (drafter or approver)
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequireAnyPermission(s.logger, auth.PermProcurementManage, auth.PermProcurementApprove))
+		r.Post("/procurement/purchase-orders/{id}/cancel", proc.CancelPurchaseOrder)
+	})
+}
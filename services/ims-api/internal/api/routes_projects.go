@@ -7,8 +7,8 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-// mountProjectRoutes registers all project-related routes including phases, surveys, BOQ, team, activities, and work orders.
-func (s *Server) mountProjectRoutes(r chi.Router, proj *handlers.ProjectsHandler, ph *handlers.PhasesHandler, surv *handlers.SurveysHandler, boq *handlers.BOQHandler, team *handlers.ProjectTeamHandler, activities *handlers.ProjectActivitiesHandler, projectWO *handlers.ProjectWorkOrdersHandler) {
+// mountProjectRoutes registers all project-related routes including project types, phases, surveys, BOQ, team, activities, and work orders.
+func (s *Server) mountProjectRoutes(r chi.Router, proj *handlers.ProjectsHandler, tpl *handlers.ProjectTemplatesHandler, ph *handlers.PhasesHandler, surv *handlers.SurveysHandler, boq *handlers.BOQHandler, team *handlers.ProjectTeamHandler, activities *handlers.ProjectActivitiesHandler, projectWO *handlers.ProjectWorkOrdersHandler) {
 	// Projects - read operations
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequirePermission(auth.PermProjectRead, s.logger))
@@ -18,6 +18,24 @@ func (s *Server) mountProjectRoutes(r chi.Router, proj *handlers.ProjectsHandler
 		r.Get("/projects", proj.List)
 	})
 
+	// Project types - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermProjectRead, s.logger))
+		r.Get("/project-templates", tpl.List)
+		r.Get("/project-templates/{projectType}", tpl.Get)
+		r.Get("/project-templates/{projectType}/versions", tpl.ListVersions)
+		r.Get("/projects/{id}/template", proj.GetTemplate)
+	})
+
+	// Project types - manage operations
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermProjectTemplateManage, s.logger))
+		r.Post("/project-templates", tpl.Create)
+		r.Put("/project-templates/{projectType}", tpl.Update)
+		r.Delete("/project-templates/{projectType}", tpl.Retire)
+	})
+
 	// Projects - create operations
 	r.Group(func(r chi.Router) {
 		r.Use(s.writeRateLimitMiddleware())
@@ -29,6 +47,10 @@ func (s *Server) mountProjectRoutes(r chi.Router, proj *handlers.ProjectsHandler
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequirePermission(auth.PermPhaseRead, s.logger))
 		r.Get("/projects/{id}/phases", ph.List)
+		r.Get("/projects/{id}/checklist-progress", ph.GetProjectChecklistProgress)
+		r.Get("/phases/{phaseId}/checklist", ph.GetChecklist)
+		r.Get("/projects/{id}/schedule", ph.GetProjectSchedule)
+		r.Get("/phases/{phaseId}/dependencies", ph.ListDependencies)
 	})
 
 	// Phases - create operations
@@ -43,6 +65,12 @@ func (s *Server) mountProjectRoutes(r chi.Router, proj *handlers.ProjectsHandler
 		r.Use(s.writeRateLimitMiddleware())
 		r.Use(middleware.RequirePermission(auth.PermPhaseUpdate, s.logger))
 		r.Patch("/phases/{phaseId}/status", ph.UpdateStatus)
+		r.Post("/phases/{phaseId}/checklist", ph.AddChecklistItem)
+		r.Post("/checklist-items/{itemId}/complete", ph.CompleteChecklistItem)
+		r.Post("/checklist-items/{itemId}/reopen", ph.ReopenChecklistItem)
+		r.Patch("/phases/{phaseId}/schedule", ph.UpdateSchedule)
+		r.Post("/phases/{phaseId}/dependencies", ph.AddDependency)
+		r.Delete("/phase-dependencies/{dependencyId}", ph.DeleteDependency)
 	})
 
 	// Surveys - read operations
@@ -65,6 +93,9 @@ func (s *Server) mountProjectRoutes(r chi.Router, proj *handlers.ProjectsHandler
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequirePermission(auth.PermBOQRead, s.logger))
 		r.Get("/projects/{id}/boq", boq.List)
+		r.Get("/projects/{id}/boq/cost", boq.GetCost)
+		r.Get("/projects/{id}/boq/versions", boq.ListVersions)
+		r.Get("/projects/{id}/boq/versions/{version}", boq.GetVersion)
 	})
 
 	// BOQ - create operations
@@ -74,6 +105,31 @@ func (s *Server) mountProjectRoutes(r chi.Router, proj *handlers.ProjectsHandler
 		r.Post("/projects/{id}/boq/items", boq.AddItem)
 	})
 
+	// BOQ - update operations
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermBOQUpdate, s.logger))
+		r.Patch("/projects/{id}/boq/items/{itemId}", boq.UpdateItem)
+		r.Delete("/projects/{id}/boq/items/{itemId}", boq.DeleteItem)
+		r.Post("/projects/{id}/boq/submit", boq.Submit)
+		r.Post("/projects/{id}/boq/revise", boq.Revise)
+	})
+
+	// BOQ - approval
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermBOQApprove, s.logger))
+		r.Post("/projects/{id}/boq/approve", boq.Approve)
+		r.Post("/projects/{id}/boq/reject", boq.Reject)
+	})
+
+	// BOQ - conversion to work order BOM (approver or BOM manager)
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequireAnyPermission(s.logger, auth.PermBOQApprove, auth.PermBOMUpdate))
+		r.Post("/projects/{id}/boq/convert", boq.Convert)
+	})
+
 	// Project Team - read operations
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequirePermission(auth.PermProjectTeamRead, s.logger))
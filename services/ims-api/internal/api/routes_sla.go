@@ -0,0 +1,35 @@
+package api
+
+import (
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// mountSLARoutes registers SLA policy and business-hours calendar routes.
+func (s *Server) mountSLARoutes(r chi.Router, sla *handlers.SLAPoliciesHandler) {
+	// SLA - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermSLARead, s.logger))
+		r.Get("/sla/policies", sla.ListPolicies)
+		r.Get("/sla/policies/{id}", sla.GetPolicy)
+		r.Get("/sla/calendars", sla.ListCalendars)
+		r.Get("/sla/calendars/{id}", sla.GetCalendar)
+		r.Post("/sla/preview", sla.Preview)
+	})
+
+	// SLA - manage operations
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermSLAManage, s.logger))
+		r.Post("/sla/policies", sla.CreatePolicy)
+		r.Patch("/sla/policies/{id}", sla.UpdatePolicy)
+		r.Delete("/sla/policies/{id}", sla.DeletePolicy)
+		r.Post("/sla/calendars", sla.CreateCalendar)
+		r.Patch("/sla/calendars/{id}", sla.UpdateCalendar)
+		r.Delete("/sla/calendars/{id}", sla.DeleteCalendar)
+		r.Post("/sla/calendars/{id}/holidays", sla.AddHoliday)
+		r.Delete("/sla/calendars/{id}/holidays/{holidayId}", sla.DeleteHoliday)
+	})
+}
@@ -23,15 +23,32 @@ func (s *Server) mountSSOTRoutes(r chi.Router, sync *handlers.SSOTSyncHandler, s
 		r.Post("/ssot/sync/team-memberships", sync.SyncTeamMemberships)
 	})
 
-	// SSOT Webhooks (admin/system operations)
+	// SSOT Webhooks (signed by the SSOT services; verified per tenant by HMAC,
+	// so these bypass JWT auth - see setupMiddleware)
 	r.Group(func(r chi.Router) {
 		r.Use(s.writeRateLimitMiddleware())
-		r.Use(middleware.RequirePermission(auth.PermSSOTWebhook, s.logger))
 		r.Post("/ssot/events/schools", wh.Schools)
 		r.Post("/ssot/events/devices", wh.Devices)
 		r.Post("/ssot/events/parts", wh.Parts)
 	})
 
+	// SSOT webhook secrets and delivery log - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermSSOTWebhook, s.logger))
+		r.Get("/ssot/webhook-secrets", wh.ListSecrets)
+		r.Get("/ssot/webhook-deliveries", wh.ListDeliveries)
+		r.Get("/ssot/webhook-deliveries/{id}", wh.GetDelivery)
+	})
+
+	// SSOT webhook secrets and delivery log - manage operations
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermSSOTWebhook, s.logger))
+		r.Post("/ssot/webhook-secrets", wh.CreateSecret)
+		r.Delete("/ssot/webhook-secrets/{id}", wh.RevokeSecret)
+		r.Post("/ssot/webhook-deliveries/{id}/rollback", wh.RollbackDelivery)
+	})
+
 	// SSOT List (browse snapshot data)
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RequirePermission(auth.PermSSOTRead, s.logger))
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// mountStockRoutes registers stock ledger routes: movements, receipts,
+// adjustments, transfers and cycle counts.
+func (s *Server) mountStockRoutes(r chi.Router, stock *handlers.StockHandler) {
+	// Stock - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermInventoryRead, s.logger))
+		r.Get("/inventory/movements", stock.ListMovements)
+		r.Get("/inventory/balances", stock.ListBalances)
+		r.Get("/inventory/transfers", stock.ListTransfers)
+		r.Get("/inventory/transfers/{id}", stock.GetTransfer)
+		r.Get("/inventory/counts", stock.ListCounts)
+		r.Get("/inventory/counts/{id}", stock.GetCount)
+	})
+
+	// Stock - goods receipts
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequireAnyPermission(s.logger, auth.PermInventoryCreate, auth.PermInventoryUpdate))
+		r.Post("/inventory/receipts", stock.CreateReceipt)
+	})
+
+	// Stock - adjustments
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermInventoryAdjust, s.logger))
+		r.Post("/inventory/adjustments", stock.CreateAdjustment)
+	})
+
+	// Stock - transfers
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermInventoryTransfer, s.logger))
+		r.Post("/inventory/transfers", stock.CreateTransfer)
+		r.Post("/inventory/transfers/{id}/receive", stock.ReceiveTransfer)
+		r.Post("/inventory/transfers/{id}/cancel", stock.CancelTransfer)
+	})
+
+	// Stock - cycle counting
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequireAnyPermission(s.logger, auth.PermInventoryAdjust, auth.PermInventoryAudit))
+		r.Post("/inventory/counts", stock.CreateCount)
+		r.Put("/inventory/counts/{id}/lines", stock.RecordCount)
+		r.Post("/inventory/counts/{id}/submit", stock.SubmitCount)
+	})
+
+	// Stock - count variance approval
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermInventoryAudit, s.logger))
+		r.Post("/inventory/counts/{id}/approve", stock.ApproveCount)
+		r.Post("/inventory/counts/{id}/reject", stock.RejectCount)
+	})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// mountTelemetryRoutes registers MDM telemetry ingest, event and rule routes.
+func (s *Server) mountTelemetryRoutes(r chi.Router, tel *handlers.TelemetryHandler) {
+	// Telemetry - ingest
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermTelemetryIngest, s.logger))
+		r.Post("/telemetry/events", tel.Ingest)
+		r.Post("/telemetry/events/batch", tel.IngestBatch)
+	})
+
+	// Telemetry - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermTelemetryRead, s.logger))
+		r.Get("/telemetry/events", tel.ListEvents)
+		r.Get("/telemetry/incidents/{incidentId}", tel.GetIncidentTelemetry)
+		r.Get("/telemetry/rules", tel.ListRules)
+	})
+
+	// Telemetry - rule management
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermTelemetryManage, s.logger))
+		r.Put("/telemetry/rules/{eventType}", tel.PutRule)
+		r.Delete("/telemetry/rules/{eventType}", tel.DeleteRule)
+	})
+}
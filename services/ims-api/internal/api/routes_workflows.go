@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// mountWorkflowRoutes registers tenant incident/work order workflow routes.
+func (s *Server) mountWorkflowRoutes(r chi.Router, wf *handlers.WorkflowsHandler) {
+	// Workflows - read operations
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequirePermission(auth.PermWorkflowRead, s.logger))
+		r.Get("/workflows/{entity}", wf.Get)
+	})
+
+	// Workflows - manage operations
+	r.Group(func(r chi.Router) {
+		r.Use(s.writeRateLimitMiddleware())
+		r.Use(middleware.RequirePermission(auth.PermWorkflowManage, s.logger))
+		r.Put("/workflows/{entity}", wf.Put)
+		r.Delete("/workflows/{entity}", wf.Delete)
+	})
+}
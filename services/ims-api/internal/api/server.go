@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/edvirons/ssp/ims/internal/admin"
 	"github.com/edvirons/ssp/ims/internal/audit"
@@ -12,6 +13,7 @@ import (
 	"github.com/edvirons/ssp/ims/internal/handlers"
 	"github.com/edvirons/ssp/ims/internal/metrics"
 	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/ssot"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/edvirons/ssp/ims/internal/ws"
 	"github.com/go-chi/chi/v5"
@@ -82,12 +84,19 @@ func (s *Server) setupMiddleware() {
 
 	if s.cfg.AuthEnabled {
 		s.authVerifier = auth.NewVerifier(s.cfg.AuthIssuer, s.cfg.AuthJWKSURL, s.cfg.AuthAudience)
-		s.r.Use(middleware.AuthJWT(s.authVerifier, s.logger))
+		// SSOT webhooks are machine-to-machine and authenticated by a
+		// per-tenant HMAC signature instead of a user JWT.
+		s.r.Use(middleware.SkipPrefixes(middleware.AuthJWT(s.authVerifier, s.logger), "/v1/ssot/events/"))
 	}
 	s.r.Use(middleware.Tenancy(s.cfg))
 
 	// Audit middleware - captures request context for audit logging
 	s.r.Use(audit.Middleware())
+
+	// Remember users' roles so jobs can find, e.g., the ops managers
+	s.r.Use(middleware.RecordRoles(s.logger, func(ctx context.Context, tenantID, userID string, roles []string) error {
+		return s.pg.UserRoles().Record(ctx, tenantID, userID, roles, time.Now().UTC())
+	}, 15*time.Minute))
 }
 
 // setupHealthAndMetrics configures health check and metrics endpoints.
@@ -138,7 +147,8 @@ func (s *Server) setupAPIRoutes(blobClient *blob.MinIO) {
 		inc := handlers.NewIncidentHandler(s.cfg, s.logger, s.pg, s.rdb, auditLogger)
 		wo := handlers.NewWorkOrderHandler(s.logger, s.pg, s.rdb, auditLogger)
 		att := handlers.NewAttachmentHandler(s.cfg, s.logger, s.pg, blobClient)
-		tel := handlers.NewTelemetryHandler(s.cfg, s.logger, s.pg)
+		deviceSSOT := ssot.NewClient(s.cfg.DeviceSSOTBaseURL)
+		tel := handlers.NewTelemetryHandler(s.cfg, s.logger, s.pg, auditLogger, deviceSSOT)
 
 		sch := handlers.NewSchoolHandler(s.logger, s.pg)
 		contacts := handlers.NewSchoolContactsHandler(s.logger, s.pg)
@@ -147,18 +157,22 @@ func (s *Server) setupAPIRoutes(blobClient *blob.MinIO) {
 		parts := handlers.NewPartsHandler(s.logger, s.pg)
 		inv := handlers.NewInventoryHandler(s.logger, s.pg)
 		whDash := handlers.NewWarehouseDashboardHandler(s.logger, s.pg)
+		stock := handlers.NewStockHandler(s.logger, s.pg, auditLogger)
+		proc := handlers.NewProcurementHandler(s.logger, s.pg, auditLogger)
 		ltDash := handlers.NewLeadTechDashboardHandler(s.logger, s.pg)
 		saDash := handlers.NewSupportAgentDashboardHandler(s.logger, s.pg)
 		bom := handlers.NewBOMHandler(s.logger, s.pg)
 		woops := handlers.NewWorkOrderOpsHandler(s.logger, s.pg)
+		fieldSync := handlers.NewFieldSyncHandler(s.logger, s.pg, auditLogger)
 		sync := handlers.NewSSOTSyncHandler(s.cfg, s.logger, s.pg)
 		ssotList := handlers.NewSSOTListHandler(s.cfg, s.logger, s.pg)
-		wh := handlers.NewSSOTWebhookHandler(s.cfg, s.logger, s.pg)
+		wh := handlers.NewSSOTWebhookHandler(s.cfg, s.logger, s.pg, auditLogger)
 
 		proj := handlers.NewProjectsHandler(s.logger, s.pg)
+		projTemplates := handlers.NewProjectTemplatesHandler(s.logger, s.pg, auditLogger)
 		ph := handlers.NewPhasesHandler(s.logger, s.pg)
 		surv := handlers.NewSurveysHandler(s.logger, s.pg)
-		boq := handlers.NewBOQHandler(s.logger, s.pg)
+		boq := handlers.NewBOQHandler(s.logger, s.pg, auditLogger)
 		auditLogs := handlers.NewAuditLogsHandler(s.logger, auditStore)
 
 		// Project collaboration handlers
@@ -190,7 +204,15 @@ func (s *Server) setupAPIRoutes(blobClient *blob.MinIO) {
 		marketingKB := handlers.NewMarketingKBHandler(s.logger, s.pg)
 
 		// Device inventory handler
-		deviceInv := handlers.NewDeviceInventoryHandler(s.logger, s.pg, auditLogger)
+		deviceInv := handlers.NewDeviceInventoryHandler(s.logger, s.pg, auditLogger, deviceSSOT)
+		netDisc := handlers.NewNetworkDiscoveryHandler(s.cfg, s.logger, s.pg, auditLogger, deviceSSOT)
+		devicePolicy := handlers.NewDevicePolicyHandler(s.cfg, s.logger, s.pg, auditLogger)
+
+		// SLA policies handler
+		slaPolicies := handlers.NewSLAPoliciesHandler(s.logger, s.pg, auditLogger)
+
+		// Workflow (state machine) handler
+		workflows := handlers.NewWorkflowsHandler(s.logger, s.pg, auditLogger)
 
 		// Impersonation handler
 		impersonation := handlers.NewImpersonationHandler(s.logger, s.pg)
@@ -202,20 +224,27 @@ func (s *Server) setupAPIRoutes(blobClient *blob.MinIO) {
 		s.mountIncidentRoutes(r, inc)
 		s.mountWorkOrderRoutes(r, wo, woops, woUpdate, woRework, woBulk)
 		s.mountBOMRoutes(r, bom)
+		s.mountFieldSyncRoutes(r, fieldSync)
 		s.mountSSOTRoutes(r, sync, ssotList, wh)
-		s.mountProjectRoutes(r, proj, ph, surv, boq, projectTeam, projectActivities, projectWorkOrders)
+		s.mountProjectRoutes(r, proj, projTemplates, ph, surv, boq, projectTeam, projectActivities, projectWorkOrders)
 		s.mountServiceShopRoutes(r, shops, staff, parts, inv, whDash)
+		s.mountStockRoutes(r, stock)
+		s.mountProcurementRoutes(r, proc)
 		s.mountLeadTechDashboardRoutes(r, ltDash)
 		s.mountSupportAgentDashboardRoutes(r, saDash)
-		s.mountAdminRoutes(r, auditLogs, sch, contacts, att, tel)
+		s.mountAdminRoutes(r, auditLogs, sch, contacts, att)
 		s.mountNotificationRoutes(r, userNotifications)
 		s.mountReportRoutes(r, rpt)
 		s.mountEdTechRoutes(r, edtech)
 		s.mountSalesRoutes(r, demoPipeline, presentations, salesMetrics)
 		s.mountKBRoutes(r, kbArticles)
 		s.mountMarketingKBRoutes(r, marketingKB)
-		s.mountDeviceInventoryRoutes(r, deviceInv)
+		s.mountDeviceInventoryRoutes(r, deviceInv, netDisc)
+		s.mountDevicePolicyRoutes(r, devicePolicy)
 		s.mountImpersonationRoutes(r, impersonation)
+		s.mountSLARoutes(r, slaPolicies)
+		s.mountWorkflowRoutes(r, workflows)
+		s.mountTelemetryRoutes(r, tel)
 
 		// Messaging routes
 		RegisterMessagingRoutes(r, s.logger, s.pg, s.wsHub)
@@ -50,6 +50,12 @@ func GetAuditContext(ctx context.Context) AuditContext {
 	return AuditContext{}
 }
 
+// WithContext returns ctx carrying auditCtx, for changes made outside an
+// HTTP request such as background jobs
+func WithContext(ctx context.Context, auditCtx AuditContext) context.Context {
+	return context.WithValue(ctx, ctxAuditKey, auditCtx)
+}
+
 // extractUserID extracts the user ID from JWT claims or headers
 func extractUserID(r *http.Request) string {
 	// Try to get from X-User-Id header (set by auth middleware)
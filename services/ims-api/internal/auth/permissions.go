@@ -67,6 +67,11 @@ const (
 	PermInventoryTransfer = "inventory:transfer"
 	PermInventoryAudit    = "inventory:audit"
 
+	// Procurement permissions (reorder policies, purchase orders)
+	PermProcurementRead    = "procurement:read"
+	PermProcurementManage  = "procurement:manage"
+	PermProcurementApprove = "procurement:approve"
+
 	// Device Inventory permissions (school device tracking)
 	PermLocationRead    = "location:read"
 	PermLocationWrite   = "location:write"
@@ -75,6 +80,7 @@ const (
 	PermGroupRead       = "group:read"
 	PermGroupWrite      = "group:write"
 	PermDeviceInventory = "device:inventory" // View school device inventory
+	PermNetworkScan     = "network:scan"     // Upload network scans, triage unknown MACs
 
 	// Reporting permissions
 	PermReportInventory = "report:inventory"
@@ -84,6 +90,9 @@ const (
 	PermProjectRead   = "project:read"
 	PermProjectUpdate = "project:update"
 
+	// Project type permissions
+	PermProjectTemplateManage = "project:template:manage" // Define project types, phase sequences and defaults
+
 	// Phase permissions
 	PermPhaseCreate = "phase:create"
 	PermPhaseRead   = "phase:read"
@@ -95,9 +104,10 @@ const (
 	PermSurveyUpdate = "survey:update"
 
 	// BOQ permissions
-	PermBOQCreate = "boq:create"
-	PermBOQRead   = "boq:read"
-	PermBOQUpdate = "boq:update"
+	PermBOQCreate  = "boq:create"
+	PermBOQRead    = "boq:read"
+	PermBOQUpdate  = "boq:update"
+	PermBOQApprove = "boq:approve" // Approve or send back submitted BOQs and convert them to work order BOMs
 
 	// Project Team permissions
 	PermProjectTeamRead   = "project:team:read"
@@ -120,6 +130,11 @@ const (
 
 	// Telemetry permissions
 	PermTelemetryIngest = "telemetry:ingest"
+	PermTelemetryRead   = "telemetry:read"   // View raw telemetry events and rules
+	PermTelemetryManage = "telemetry:manage" // Edit telemetry severity and correlation rules
+
+	// MDM permissions
+	PermMDMPolicyPull = "mdm:policy:pull" // Download signed device policy bundles
 
 	// Messaging permissions
 	PermMessagesRead   = "messages:read"
@@ -142,6 +157,14 @@ const (
 	PermKBUpdate = "kb:update"
 	PermKBDelete = "kb:delete"
 
+	// SLA policy permissions
+	PermSLARead   = "sla:read"
+	PermSLAManage = "sla:manage"
+
+	// Workflow (incident/work order state machine) permissions
+	PermWorkflowRead   = "workflow:read"
+	PermWorkflowManage = "workflow:manage"
+
 	// Impersonation permission (ops managers can act on behalf of school contacts)
 	PermImpersonate = "impersonate:user"
 
@@ -209,6 +232,7 @@ var RolePermissions = map[string][]string{
 		PermPartsRead,
 		PermInventoryRead,
 		PermTelemetryIngest,
+		PermMDMPolicyPull,
 		PermProjectTeamRead,
 		PermProjectTeamUpdate,
 		PermActivityCreate,
@@ -247,9 +271,30 @@ var RolePermissions = map[string][]string{
 		PermAssignmentWrite,
 		PermGroupRead,
 		PermGroupWrite,
+		PermNetworkScan,
 
 		// Impersonation - can act on behalf of school contacts
 		PermImpersonate,
+
+		// SLA policies and business-hours calendars
+		PermSLARead,
+		PermSLAManage,
+
+		// Tenant workflows
+		PermWorkflowRead,
+		PermWorkflowManage,
+
+		// Telemetry rules
+		PermTelemetryRead,
+		PermTelemetryManage,
+
+		// Purchase order approval
+		PermProcurementRead,
+		PermProcurementApprove,
+
+		// BOQ approval
+		PermBOQRead,
+		PermBOQApprove,
 	},
 
 	// Support agent - tickets/dispatch
@@ -283,6 +328,9 @@ var RolePermissions = map[string][]string{
 		PermChatAccept,
 		PermChatTransfer,
 		PermKBRead,
+		PermSLARead,
+		PermWorkflowRead,
+		PermTelemetryRead,
 	},
 
 	// Field tech - work orders + deliverables (RESTRICTED - no project/activity access)
@@ -302,6 +350,7 @@ var RolePermissions = map[string][]string{
 		PermMessagesRead,
 		PermMessagesCreate,
 		PermKBRead,
+		PermWorkflowRead,
 	},
 
 	// Lead tech - scheduling + approval requests + team management
@@ -324,6 +373,7 @@ var RolePermissions = map[string][]string{
 		PermPartsRead,
 		PermInventoryRead,
 		PermTelemetryIngest,
+		PermNetworkScan,
 		PermProjectTeamRead,
 		PermProjectTeamUpdate,
 		PermActivityCreate,
@@ -335,6 +385,8 @@ var RolePermissions = map[string][]string{
 		PermMessagesCreate,
 		PermMessagesManage,
 		PermKBRead,
+		PermSLARead,
+		PermWorkflowRead,
 	},
 
 	// Demo team - demos, surveys, pipeline
@@ -436,6 +488,7 @@ var RolePermissions = map[string][]string{
 		PermGroupRead,
 		PermGroupWrite,   // Create/manage device groups
 		PermDeviceCreate, // Register new devices
+		PermNetworkScan,  // Upload DHCP/ARP scans
 	},
 
 	// Supplier - parts catalog + fulfillment visibility
@@ -477,6 +530,10 @@ var RolePermissions = map[string][]string{
 		PermInventoryTransfer,
 		PermInventoryAudit,
 
+		// Procurement - reorder policies, draft/send/receive purchase orders
+		PermProcurementRead,
+		PermProcurementManage,
+
 		// Parts - full catalog management
 		PermPartsCreate,
 		PermPartsRead,
@@ -0,0 +1,209 @@
+// Package chatrouting assigns waiting livechat sessions to support agents.
+// Agents are scored by service.RouteChat; the router applies the choice,
+// tells the chat and the agents, and logs every decision.
+package chatrouting
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/ws"
+	"go.uber.org/zap"
+)
+
+// DefaultAgentName is shown for agents missing from the HR people snapshot.
+const DefaultAgentName = "Support Agent"
+
+// Router routes waiting sessions for one deployment.
+type Router struct {
+	log *zap.Logger
+	pg  *store.Postgres
+	hub *ws.Hub
+}
+
+// New creates a router. hub may be nil.
+func New(log *zap.Logger, pg *store.Postgres, hub *ws.Hub) *Router {
+	return &Router{log: log, pg: pg, hub: hub}
+}
+
+// Route assigns a waiting session to the best eligible agent, if any.
+// Assignments are always logged; a session left in the queue is logged only
+// when recordQueued is set, so queue sweeps do not log it on every pass. A
+// session taken by an agent meanwhile returns a zero decision.
+func (r *Router) Route(ctx context.Context, session models.ChatSession, recordQueued bool) models.ChatRoutingDecision {
+	now := time.Now().UTC()
+	tenantID := session.TenantID
+
+	settings, err := r.pg.ChatRouting().GetSettings(ctx, tenantID)
+	if err != nil {
+		r.log.Warn("chat routing: load settings failed", zap.String("tenant_id", tenantID), zap.Error(err))
+		settings = models.DefaultChatRoutingSettings(tenantID)
+	}
+	agents, err := r.pg.ChatSessions().ListRoutableAgents(ctx, tenantID)
+	if err != nil {
+		r.log.Warn("chat routing: list agents failed", zap.String("tenant_id", tenantID), zap.Error(err))
+		return models.ChatRoutingDecision{}
+	}
+	schoolSessions, err := r.pg.ChatSessions().CountSchoolSessionsByAgent(ctx, tenantID, session.SchoolID, now.AddDate(0, 0, -settings.AffinityDays))
+	if err != nil {
+		r.log.Warn("chat routing: count school sessions failed", zap.String("session_id", session.ID), zap.Error(err))
+	}
+
+	queuedAt := session.StartedAt
+	if session.QueuedAt != nil {
+		queuedAt = *session.QueuedAt
+	}
+	category := ""
+	if session.IssueCategory != nil {
+		category = strings.ToLower(strings.TrimSpace(*session.IssueCategory))
+	}
+	route := service.RouteChat(service.ChatRouteRequest{
+		Category:       category,
+		Waiting:        now.Sub(queuedAt),
+		Agents:         agents,
+		SchoolSessions: schoolSessions,
+		Settings:       settings,
+		Now:            now,
+	})
+
+	d := models.ChatRoutingDecision{
+		ID:            store.NewID("route"),
+		TenantID:      tenantID,
+		SessionID:     session.ID,
+		SchoolID:      session.SchoolID,
+		IssueCategory: category,
+		Outcome:       route.Outcome,
+		AgentID:       route.AgentID,
+		Score:         route.Score,
+		Reason:        route.Reason,
+		WaitSeconds:   int(now.Sub(queuedAt).Seconds()),
+		Candidates:    route.Candidates,
+		CreatedAt:     now,
+	}
+	if route.AgentID != "" {
+		d.AgentName = r.AgentName(ctx, tenantID, route.AgentID)
+		if !r.assign(ctx, session, d.AgentID, d.AgentName) {
+			return models.ChatRoutingDecision{}
+		}
+	}
+	if d.Outcome != models.ChatRouteQueued || recordQueued {
+		if err := r.pg.ChatRouting().RecordDecision(ctx, d); err != nil {
+			r.log.Warn("chat routing: record decision failed", zap.String("session_id", session.ID), zap.Error(err))
+		}
+	}
+	return d
+}
+
+// DrainQueue routes a tenant's waiting sessions, oldest first, until no
+// agent is available, and returns how many were assigned. It runs when an
+// agent frees up and on a schedule so overflow rules take effect.
+func (r *Router) DrainQueue(ctx context.Context, tenantID string) int {
+	sessions, err := r.pg.ChatSessions().GetWaitingSessions(ctx, tenantID)
+	if err != nil {
+		r.log.Warn("chat routing: list waiting sessions failed", zap.String("tenant_id", tenantID), zap.Error(err))
+		return 0
+	}
+	assigned := 0
+	for _, s := range sessions {
+		d := r.Route(ctx, s, false)
+		if d.AgentID != "" {
+			assigned++
+			continue
+		}
+		if d.SessionID != "" && len(d.Candidates) == 0 {
+			break
+		}
+	}
+	return assigned
+}
+
+// AgentName resolves an agent's display name from the HR people snapshot,
+// by person ID or, for IDs that are email addresses, by email.
+func (r *Router) AgentName(ctx context.Context, tenantID, userID string) string {
+	p, err := r.pg.PeopleSnapshot().Get(ctx, tenantID, userID)
+	if err != nil && strings.Contains(userID, "@") {
+		p, err = r.pg.PeopleSnapshot().GetByEmail(ctx, tenantID, userID)
+	}
+	if err != nil {
+		return DefaultAgentName
+	}
+	if name := strings.TrimSpace(p.FullName); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(p.GivenName + " " + p.FamilyName); name != "" {
+		return name
+	}
+	return DefaultAgentName
+}
+
+// assign gives the session to the agent and tells the chat. It reports
+// false when the session was no longer waiting.
+func (r *Router) assign(ctx context.Context, session models.ChatSession, agentID, agentName string) bool {
+	tenantID := session.TenantID
+	ok, err := r.pg.ChatSessions().AssignAgent(ctx, tenantID, session.ID, agentID, agentName)
+	if err != nil {
+		r.log.Warn("chat routing: assign failed", zap.String("session_id", session.ID), zap.Error(err))
+		return false
+	}
+	if !ok {
+		return false
+	}
+	_ = r.pg.ChatSessions().IncrementAgentChatCount(ctx, tenantID, agentID)
+
+	now := time.Now().UTC()
+	_ = r.pg.Messaging().AddThreadParticipant(ctx, models.ThreadParticipant{
+		ThreadID: session.ThreadID,
+		UserID:   agentID,
+		UserName: agentName,
+		UserRole: "ssp_support_agent",
+		JoinedAt: now,
+	})
+	_ = r.pg.ChatSessions().UpdateQueuePositions(ctx, tenantID)
+
+	if r.hub != nil {
+		r.hub.Broadcast(tenantID, &ws.Message{
+			Type: ws.MessageTypeChatSessionUpdate,
+			Payload: map[string]any{
+				"sessionId": session.ID,
+				"status":    models.ChatStatusActive,
+				"agentId":   agentID,
+				"agentName": agentName,
+			},
+		})
+	}
+	r.sendSystemMessage(ctx, tenantID, session.ThreadID, agentName+" has joined the chat")
+	return true
+}
+
+func (r *Router) sendSystemMessage(ctx context.Context, tenantID, threadID, content string) {
+	message := models.Message{
+		ID:          store.NewID("msg"),
+		TenantID:    tenantID,
+		ThreadID:    threadID,
+		SenderID:    "system",
+		SenderName:  "System",
+		SenderRole:  "system",
+		Content:     content,
+		ContentType: models.ContentTypeSystem,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := r.pg.Messaging().CreateMessage(ctx, message); err != nil {
+		r.log.Warn("chat routing: system message failed", zap.Error(err))
+		return
+	}
+	_ = r.pg.Messaging().UpdateThreadLastMessage(ctx, tenantID, threadID, false)
+
+	if r.hub != nil {
+		r.hub.Broadcast(tenantID, &ws.Message{
+			Type: ws.MessageTypeChatMessage,
+			Payload: map[string]any{
+				"threadId": threadID,
+				"message":  message,
+			},
+		})
+	}
+}
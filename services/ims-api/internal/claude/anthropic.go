@@ -0,0 +1,134 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	anthropicBaseURL    = "https://api.anthropic.com"
+	anthropicAPIVersion = "2023-06-01"
+)
+
+// AnthropicConfig holds configuration for the Anthropic Messages API
+type AnthropicConfig struct {
+	APIKey         string
+	Model          string
+	MaxTokens      int
+	TimeoutSeconds int
+	BaseURL        string // empty uses the public API; set for a proxy or gateway
+}
+
+// AnthropicProvider calls the Anthropic Messages API
+type AnthropicProvider struct {
+	apiKey     string
+	model      string
+	maxTokens  int
+	url        string
+	httpClient *http.Client
+	log        *zap.Logger
+}
+
+// NewAnthropicProvider creates a provider for the Messages API at
+// cfg.BaseURL.
+func NewAnthropicProvider(cfg AnthropicConfig, log *zap.Logger) *AnthropicProvider {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	base := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if base == "" {
+		base = anthropicBaseURL
+	}
+
+	return &AnthropicProvider{
+		apiKey:    cfg.APIKey,
+		model:     cfg.Model,
+		maxTokens: cfg.MaxTokens,
+		url:       base + "/v1/messages",
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		log: log,
+	}
+}
+
+func (p *AnthropicProvider) Name() string { return ProviderAnthropic }
+
+// Enabled returns true if an API key is configured
+func (p *AnthropicProvider) Enabled() bool { return p.apiKey != "" }
+
+// Complete sends the conversation to the Messages API
+func (p *AnthropicProvider) Complete(ctx context.Context, systemPrompt string, messages []Message) (*AIResponse, error) {
+	startTime := time.Now()
+
+	req := ChatRequest{
+		Model:       p.model,
+		MaxTokens:   p.maxTokens,
+		System:      systemPrompt,
+		Messages:    messages,
+		Temperature: 0.7,
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		p.log.Error("Claude API error",
+			zap.Int("status", resp.StatusCode),
+			zap.String("body", string(respBody)),
+		)
+		return nil, fmt.Errorf("Claude API error: %s (status %d)", string(respBody), resp.StatusCode)
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	// Extract text content
+	var content string
+	for _, block := range chatResp.Content {
+		if block.Type == "text" {
+			content = block.Text
+			break
+		}
+	}
+
+	return &AIResponse{
+		Content:      content,
+		InputTokens:  chatResp.Usage.InputTokens,
+		OutputTokens: chatResp.Usage.OutputTokens,
+		ResponseTime: time.Since(startTime),
+	}, nil
+}
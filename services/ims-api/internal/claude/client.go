@@ -1,132 +1,94 @@
 package claude
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-const (
-	anthropicAPIURL     = "https://api.anthropic.com/v1/messages"
-	anthropicAPIVersion = "2023-06-01"
-)
-
-// Client handles communication with Claude API
+// Client handles communication with the configured language model provider
 type Client struct {
-	apiKey     string
-	model      string
-	maxTokens  int
-	timeout    time.Duration
-	httpClient *http.Client
-	log        *zap.Logger
+	provider Provider
+	log      *zap.Logger
 }
 
 // ClientConfig holds configuration for the Claude client
 type ClientConfig struct {
+	Provider       string // "anthropic" (default) or "stub"
 	APIKey         string
 	Model          string
 	MaxTokens      int
 	TimeoutSeconds int
+	BaseURL        string // Anthropic API base URL; empty uses the public API
+	StubScript     string // JSON Lines of StubExchange replayed by the stub provider
+	RecordPath     string // append live exchanges here, in the stub script format
 }
 
-// NewClient creates a new Claude API client
+// NewClient creates a client for the configured provider. A stub script
+// that cannot be read is logged and the stub falls back to its default
+// reply.
 func NewClient(cfg ClientConfig, log *zap.Logger) *Client {
-	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
-	if timeout == 0 {
-		timeout = 30 * time.Second
+	var p Provider
+	switch cfg.Provider {
+	case ProviderStub:
+		var exchanges []StubExchange
+		if cfg.StubScript != "" {
+			var err error
+			if exchanges, err = LoadStubScript(cfg.StubScript); err != nil {
+				log.Error("failed to load AI stub script", zap.String("path", cfg.StubScript), zap.Error(err))
+			}
+		}
+		p = NewStubProvider(exchanges)
+	default:
+		if cfg.Provider != "" && cfg.Provider != ProviderAnthropic {
+			log.Warn("unknown AI provider, using anthropic", zap.String("provider", cfg.Provider))
+		}
+		p = NewAnthropicProvider(AnthropicConfig{
+			APIKey:         cfg.APIKey,
+			Model:          cfg.Model,
+			MaxTokens:      cfg.MaxTokens,
+			TimeoutSeconds: cfg.TimeoutSeconds,
+			BaseURL:        cfg.BaseURL,
+		}, log)
 	}
-
-	return &Client{
-		apiKey:    cfg.APIKey,
-		model:     cfg.Model,
-		maxTokens: cfg.MaxTokens,
-		timeout:   timeout,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		log: log,
+	if cfg.RecordPath != "" {
+		p = NewRecordingProvider(p, cfg.RecordPath, log)
 	}
+	return NewClientWithProvider(p, log)
 }
 
-// Chat sends a message to Claude and returns the response
-func (c *Client) Chat(ctx context.Context, systemPrompt string, messages []Message) (*AIResponse, error) {
-	startTime := time.Now()
-
-	req := ChatRequest{
-		Model:       c.model,
-		MaxTokens:   c.maxTokens,
-		System:      systemPrompt,
-		Messages:    messages,
-		Temperature: 0.7,
-	}
-
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", anthropicAPIURL, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+// NewClientWithProvider creates a client for a provider built by the caller.
+func NewClientWithProvider(p Provider, log *zap.Logger) *Client {
+	return &Client{provider: p, log: log}
+}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+// Provider returns the name of the provider the client uses.
+func (c *Client) Provider() string {
+	return c.provider.Name()
+}
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+// Chat sends a message to the model and returns the response
+func (c *Client) Chat(ctx context.Context, systemPrompt string, messages []Message) (*AIResponse, error) {
+	startTime := time.Now()
 
-	respBody, err := io.ReadAll(resp.Body)
+	resp, err := c.provider.Complete(ctx, systemPrompt, messages)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		c.log.Error("Claude API error",
-			zap.Int("status", resp.StatusCode),
-			zap.String("body", string(respBody)),
-		)
-		return nil, fmt.Errorf("Claude API error: %s (status %d)", string(respBody), resp.StatusCode)
-	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if resp.ResponseTime == 0 {
+		resp.ResponseTime = time.Since(startTime)
 	}
 
-	// Extract text content
-	var content string
-	for _, block := range chatResp.Content {
-		if block.Type == "text" {
-			content = block.Text
-			break
-		}
-	}
-
-	responseTime := time.Since(startTime)
-
-	c.log.Debug("Claude API call completed",
-		zap.Duration("response_time", responseTime),
-		zap.Int("input_tokens", chatResp.Usage.InputTokens),
-		zap.Int("output_tokens", chatResp.Usage.OutputTokens),
+	c.log.Debug("AI call completed",
+		zap.String("provider", c.provider.Name()),
+		zap.Duration("response_time", resp.ResponseTime),
+		zap.Int("input_tokens", resp.InputTokens),
+		zap.Int("output_tokens", resp.OutputTokens),
 	)
 
-	return &AIResponse{
-		Content:      content,
-		InputTokens:  chatResp.Usage.InputTokens,
-		OutputTokens: chatResp.Usage.OutputTokens,
-		ResponseTime: responseTime,
-	}, nil
+	return resp, nil
 }
 
 // ChatWithRetry sends a message with retry logic for transient failures
@@ -150,7 +112,8 @@ func (c *Client) ChatWithRetry(ctx context.Context, systemPrompt string, message
 		}
 
 		lastErr = err
-		c.log.Warn("Claude API call failed, retrying",
+		c.log.Warn("AI call failed, retrying",
+			zap.String("provider", c.provider.Name()),
 			zap.Int("attempt", attempt+1),
 			zap.Int("max_retries", maxRetries),
 			zap.Error(err),
@@ -160,7 +123,8 @@ func (c *Client) ChatWithRetry(ctx context.Context, systemPrompt string, message
 	return nil, fmt.Errorf("all retries exhausted: %w", lastErr)
 }
 
-// IsEnabled returns true if the client has a valid API key configured
+// IsEnabled returns true if the provider is configured to answer, for
+// example when Anthropic has an API key
 func (c *Client) IsEnabled() bool {
-	return c.apiKey != ""
+	return c.provider.Enabled()
 }
@@ -0,0 +1,126 @@
+package claude
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// KBArticleRef is a published KB article retrieved for an AI turn. Marker
+// is how the prompt labels it ("KB1", "KB2", ...) so the reply can cite it.
+type KBArticleRef struct {
+	ID          string  `json:"id"`
+	Marker      string  `json:"marker"`
+	Title       string  `json:"title"`
+	Slug        string  `json:"slug"`
+	ContentType string  `json:"content_type"`
+	Module      string  `json:"module"`
+	Excerpt     string  `json:"excerpt"`
+	Score       float64 `json:"score"`
+}
+
+// kbExcerptChars bounds how much of each article goes into the prompt.
+const kbExcerptChars = 1200
+
+// maxKBSearchTerms bounds the terms taken from a conversation for retrieval.
+const maxKBSearchTerms = 16
+
+// kbIssueModules maps the AI's issue categories to the KB modules likely to
+// hold the answer. Unknown categories search every module.
+var kbIssueModules = map[string][]string{
+	"hardware": {"devices", "general"},
+	"software": {"mdm", "learning_portal", "devices", "general"},
+	"network":  {"devices", "mdm", "general"},
+	"account":  {"sso", "learning_portal", "general"},
+}
+
+// KBModulesForIssue returns the KB modules to search for an issue category,
+// or nil to search all of them.
+func KBModulesForIssue(category string) []string {
+	return kbIssueModules[strings.ToLower(strings.TrimSpace(category))]
+}
+
+var kbWordPattern = regexp.MustCompile(`[a-z0-9]+`)
+
+// kbStopWords are conversational words that say nothing about the problem.
+var kbStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true, "have": true,
+	"has": true, "not": true, "but": true, "are": true, "was": true, "its": true, "can": true,
+	"cant": true, "cannot": true, "our": true, "you": true, "your": true, "there": true, "when": true,
+	"what": true, "how": true, "why": true, "from": true, "all": true, "any": true, "been": true,
+	"just": true, "now": true, "some": true, "they": true, "them": true, "then": true, "into": true,
+	"please": true, "help": true, "hello": true, "thanks": true, "thank": true, "also": true,
+	"does": true, "doesnt": true, "dont": true, "get": true, "got": true, "working": true, "work": true,
+	"issue": true, "problem": true, "still": true, "again": true, "would": true, "could": true,
+}
+
+// KBSearchTerms extracts distinct search terms from the user's messages,
+// newest text first, dropping short and conversational words.
+func KBSearchTerms(texts ...string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range texts {
+		for _, w := range kbWordPattern.FindAllString(strings.ToLower(t), -1) {
+			if len(w) < 3 || kbStopWords[w] || seen[w] {
+				continue
+			}
+			seen[w] = true
+			out = append(out, w)
+			if len(out) == maxKBSearchTerms {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// KBExcerpt shortens article content for the prompt, cutting at a word
+// boundary.
+func KBExcerpt(content string) string {
+	content = strings.TrimSpace(content)
+	if utf8.RuneCountInString(content) <= kbExcerptChars {
+		return content
+	}
+	runes := []rune(content)[:kbExcerptChars]
+	cut := string(runes)
+	if i := strings.LastIndexAny(cut, " \n"); i > kbExcerptChars/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// LabelKBRefs assigns prompt markers in rank order.
+func LabelKBRefs(refs []KBArticleRef) []KBArticleRef {
+	for i := range refs {
+		refs[i].Marker = fmt.Sprintf("KB%d", i+1)
+	}
+	return refs
+}
+
+// CitedKBRefs returns the articles whose marker appears in the reply, in
+// rank order.
+func CitedKBRefs(reply string, refs []KBArticleRef) []KBArticleRef {
+	var out []KBArticleRef
+	for _, ref := range refs {
+		if ref.Marker != "" && strings.Contains(reply, "["+ref.Marker+"]") {
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+// AppendKBSources adds a sources list naming the cited articles to the
+// reply shown to the user.
+func AppendKBSources(reply string, cited []KBArticleRef) string {
+	if len(cited) == 0 {
+		return reply
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(reply, "\n "))
+	b.WriteString("\n\nSources:")
+	for _, ref := range cited {
+		fmt.Fprintf(&b, "\n[%s] %s (/kb/%s)", ref.Marker, ref.Title, ref.Slug)
+	}
+	return b.String()
+}
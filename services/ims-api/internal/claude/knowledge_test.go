@@ -0,0 +1,60 @@
+package claude
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKBSearchTerms(t *testing.T) {
+	got := KBSearchTerms("Hello, the Chromebook won't connect to WiFi!", "WiFi keeps dropping and chromebook is slow")
+	want := []string{"chromebook", "won", "connect", "wifi", "keeps", "dropping", "slow"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("terms = %v, want %v", got, want)
+	}
+	if len(KBSearchTerms(strings.Repeat("alpha beta gamma delta epsilon zeta eta theta iota kappa lambda mu1 nu1 xi1 omicron pi1 rho1 ", 2))) != maxKBSearchTerms {
+		t.Error("terms should be capped")
+	}
+}
+
+func TestCitedKBRefsAndSources(t *testing.T) {
+	refs := LabelKBRefs([]KBArticleRef{
+		{ID: "kb_a", Title: "Reset Wi-Fi", Slug: "reset-wifi"},
+		{ID: "kb_b", Title: "Enroll in MDM", Slug: "enroll-mdm"},
+		{ID: "kb_c", Title: "SSO login loop", Slug: "sso-loop"},
+	})
+	reply := "Try forgetting the network first [KB1], then re-login per [KB3]."
+	cited := CitedKBRefs(reply, refs)
+	if len(cited) != 2 || cited[0].ID != "kb_a" || cited[1].ID != "kb_c" {
+		t.Fatalf("cited = %+v", cited)
+	}
+	out := AppendKBSources(reply, cited)
+	if !strings.HasSuffix(out, "Sources:\n[KB1] Reset Wi-Fi (/kb/reset-wifi)\n[KB3] SSO login loop (/kb/sso-loop)") {
+		t.Errorf("sources = %q", out)
+	}
+	if AppendKBSources(reply, nil) != reply {
+		t.Error("reply without citations should be unchanged")
+	}
+}
+
+func TestKBExcerpt(t *testing.T) {
+	long := strings.Repeat("word ", 400)
+	got := KBExcerpt(long)
+	if !strings.HasSuffix(got, "…") || len([]rune(got)) > kbExcerptChars+1 {
+		t.Errorf("excerpt length %d", len([]rune(got)))
+	}
+	if KBExcerpt("  short  ") != "short" {
+		t.Error("short content should be trimmed only")
+	}
+}
+
+func TestBuildSystemPromptIncludesKB(t *testing.T) {
+	prompt, err := BuildSystemPrompt(PromptData{KnowledgeBase: LabelKBRefs([]KBArticleRef{
+		{Title: "Reset Wi-Fi", ContentType: "troubleshooting", Module: "devices", Excerpt: "Forget the network."},
+	})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "[KB1] Reset Wi-Fi (troubleshooting, devices)\nForget the network.") {
+		t.Errorf("prompt missing article:\n%s", prompt)
+	}
+}
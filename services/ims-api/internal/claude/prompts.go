@@ -28,6 +28,14 @@ CONTEXT INFORMATION:
 {{if .Context.History.CommonIssues}}- Common Issues: {{range .Context.History.CommonIssues}}{{.}}, {{end}}{{end}}
 {{end}}
 {{end}}
+{{if .KnowledgeBase}}
+KNOWLEDGE BASE ARTICLES:
+These published ESSP articles matched the conversation. Prefer their steps over general advice. When you rely on one, cite its marker in your reply, for example [KB1]. Do not cite articles you did not use.
+{{range .KnowledgeBase}}
+[{{.Marker}}] {{.Title}} ({{.ContentType}}, {{.Module}})
+{{.Excerpt}}
+{{end}}
+{{end}}
 
 YOUR ROLE:
 1. Greet the user warmly and ask how you can help
@@ -55,7 +63,8 @@ At the END of each response, include a JSON block with your assessment:
     "device_serial": "",
     "issue_description": "",
     "when_started": "",
-    "steps_tried": ""
+    "steps_tried": "",
+    "needs_repair": false
   },
   "resolved": false,
   "needs_more_info": true
@@ -72,6 +81,8 @@ ESCALATION TRIGGERS (set escalate=true):
 - Issue cannot be resolved with standard troubleshooting
 - After collecting all relevant info, route to appropriate team
 
+Set needs_repair=true only when the device has a hardware fault a technician must fix in person (broken screen, dead battery, damaged port, liquid damage, no power after basic checks). A support ticket is then logged for repair, so tell the user a technician will follow up.
+
 When escalating, provide a brief summary in escalate_reason explaining why a human agent is needed.
 
 Remember: Be helpful, patient, and professional. If you can resolve the issue with guidance, do so. Only escalate when truly necessary.`
@@ -93,11 +104,12 @@ If your issue is resolved, feel free to close this chat. Have a great day!`
 
 // PromptData contains data for template rendering
 type PromptData struct {
-	Context    *SSOTContext
-	TurnNumber int
-	MaxTurns   int
-	UserName   string
-	SessionID  string
+	Context       *SSOTContext
+	KnowledgeBase []KBArticleRef
+	TurnNumber    int
+	MaxTurns      int
+	UserName      string
+	SessionID     string
 }
 
 // BuildSystemPrompt renders the system prompt with context
@@ -0,0 +1,74 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Provider names accepted by ClientConfig.Provider.
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderStub      = "stub"
+)
+
+// Provider answers one chat request from a language model. Client adds
+// retries and logging on top.
+type Provider interface {
+	Name() string
+	// Enabled reports whether the provider is configured to answer.
+	Enabled() bool
+	Complete(ctx context.Context, systemPrompt string, messages []Message) (*AIResponse, error)
+}
+
+// RecordingProvider passes requests to another provider and appends each
+// successful exchange to a file as a StubExchange, so a live session can be
+// replayed offline by the stub provider.
+type RecordingProvider struct {
+	next Provider
+	path string
+	log  *zap.Logger
+	mu   sync.Mutex
+}
+
+func NewRecordingProvider(next Provider, path string, log *zap.Logger) *RecordingProvider {
+	return &RecordingProvider{next: next, path: path, log: log}
+}
+
+func (p *RecordingProvider) Name() string  { return p.next.Name() }
+func (p *RecordingProvider) Enabled() bool { return p.next.Enabled() }
+
+// Complete records the latest user message and the reply. Recording
+// failures are logged and do not fail the call.
+func (p *RecordingProvider) Complete(ctx context.Context, systemPrompt string, messages []Message) (*AIResponse, error) {
+	resp, err := p.next.Complete(ctx, systemPrompt, messages)
+	if err != nil {
+		return nil, err
+	}
+	line, _ := json.Marshal(StubExchange{Match: lastUserMessage(messages), Reply: resp.Content})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		p.log.Warn("failed to record AI exchange", zap.String("path", p.path), zap.Error(err))
+		return resp, nil
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		p.log.Warn("failed to record AI exchange", zap.String("path", p.path), zap.Error(err))
+	}
+	return resp, nil
+}
+
+func lastUserMessage(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
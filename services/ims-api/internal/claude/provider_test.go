@@ -0,0 +1,108 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestStubProviderScript(t *testing.T) {
+	p := NewStubProvider([]StubExchange{
+		{Match: "printer", Reply: "Try turning the printer off and on."},
+		{Match: "SCREEN", Reply: "That needs a technician.", Decision: &AIDecisionData{
+			Category: "hardware", Severity: "high", Escalate: true, EscalateReason: "cracked screen",
+			CollectedInfo: map[string]any{"device_serial": "SN1", "needs_repair": true},
+		}},
+		{Match: "outage", Error: "upstream unavailable"},
+	})
+	ctx := context.Background()
+
+	resp, err := p.Complete(ctx, "", []Message{{Role: "user", Content: "the screen is cracked"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision, clean := ParseAIDecision(resp.Content)
+	if decision == nil || !decision.Escalate || decision.Category != "hardware" || decision.CollectedInfo["device_serial"] != "SN1" {
+		t.Fatalf("decision = %+v from %q", decision, resp.Content)
+	}
+	if clean != "That needs a technician." || resp.OutputTokens == 0 {
+		t.Errorf("clean = %q, tokens = %d", clean, resp.OutputTokens)
+	}
+
+	// Only the latest user message is matched.
+	resp, _ = p.Complete(ctx, "", []Message{
+		{Role: "user", Content: "my printer jams"},
+		{Role: "assistant", Content: "Try turning the printer off and on."},
+		{Role: "user", Content: "hello?"},
+	})
+	if decision, _ := ParseAIDecision(resp.Content); decision == nil || !decision.NeedsMoreInfo || decision.Escalate {
+		t.Errorf("default reply decision = %+v", decision)
+	}
+
+	if _, err := p.Complete(ctx, "", []Message{{Role: "user", Content: "network outage"}}); err == nil || err.Error() != "upstream unavailable" {
+		t.Errorf("scripted error = %v", err)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	live := NewStubProvider([]StubExchange{{Reply: "live reply"}})
+	rec := NewClientWithProvider(NewRecordingProvider(live, path, zap.NewNop()), zap.NewNop())
+	if _, err := rec.Chat(context.Background(), "system", []Message{{Role: "user", Content: "Laptop won't charge"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path+".script", append([]byte("# replayed\n\n"), mustRead(t, path)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exchanges, err := LoadStubScript(path + ".script")
+	if err != nil || len(exchanges) != 1 || exchanges[0].Match != "Laptop won't charge" {
+		t.Fatalf("loaded %+v, %v", exchanges, err)
+	}
+	replay := NewClient(ClientConfig{Provider: ProviderStub, StubScript: path + ".script"}, zap.NewNop())
+	if !replay.IsEnabled() || replay.Provider() != ProviderStub {
+		t.Fatalf("stub client enabled=%v provider=%s", replay.IsEnabled(), replay.Provider())
+	}
+	resp, err := replay.ChatWithRetry(context.Background(), "", []Message{{Role: "user", Content: "Laptop won't charge"}}, 2)
+	if err != nil || resp.Content != "live reply" {
+		t.Errorf("replayed %+v, %v", resp, err)
+	}
+}
+
+func TestAnthropicProviderBaseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(ChatResponse{
+			Content: []ContentBlock{{Type: "text", Text: "hi"}},
+			Usage:   Usage{InputTokens: 3, OutputTokens: 1},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewClient(ClientConfig{APIKey: "key", Model: "m", MaxTokens: 10, BaseURL: srv.URL + "/"}, zap.NewNop())
+	resp, err := c.Chat(context.Background(), "", []Message{{Role: "user", Content: "hello"}})
+	if err != nil || resp.Content != "hi" || resp.InputTokens != 3 {
+		t.Fatalf("chat = %+v, %v", resp, err)
+	}
+	if NewClient(ClientConfig{}, zap.NewNop()).IsEnabled() {
+		t.Error("anthropic without an API key should be disabled")
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,111 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StubExchange is one canned reply of the stub provider. It answers when
+// Match is a case-insensitive substring of the latest user message; an
+// empty Match answers anything. Decision, when set, is appended to Reply as
+// the JSON decision block the support prompt asks for. Error makes the call
+// fail instead, to exercise fallbacks.
+type StubExchange struct {
+	Match    string          `json:"match,omitempty"`
+	Reply    string          `json:"reply,omitempty"`
+	Decision *AIDecisionData `json:"decision,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// stubDefaultReply answers messages no exchange matches. It keeps the
+// conversation in AI mode and asks for more detail.
+var stubDefaultReply = StubReply("Thanks, I can help with that. Could you tell me which device is affected and what happens when you try to use it?",
+	AIDecisionData{Category: "other", Severity: "low", CollectedInfo: map[string]any{}, NeedsMoreInfo: true})
+
+// StubProvider returns scripted replies without network access, for
+// offline CI and demo environments. The same conversation always gets the
+// same reply.
+type StubProvider struct {
+	exchanges []StubExchange
+}
+
+// NewStubProvider creates a stub answering from exchanges, in order, then
+// with a default reply.
+func NewStubProvider(exchanges []StubExchange) *StubProvider {
+	return &StubProvider{exchanges: exchanges}
+}
+
+// LoadStubScript reads exchanges from a JSON Lines file, one StubExchange
+// per line, as written by RecordingProvider. Blank lines and lines starting
+// with # are skipped.
+func LoadStubScript(path string) ([]StubExchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []StubExchange
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var ex StubExchange
+		if err := json.Unmarshal([]byte(line), &ex); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		out = append(out, ex)
+	}
+	return out, sc.Err()
+}
+
+func (p *StubProvider) Name() string  { return ProviderStub }
+func (p *StubProvider) Enabled() bool { return true }
+
+// Complete answers with the first exchange matching the latest user
+// message. Token counts are estimated from text length.
+func (p *StubProvider) Complete(ctx context.Context, systemPrompt string, messages []Message) (*AIResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	last := strings.ToLower(lastUserMessage(messages))
+	reply := stubDefaultReply
+	for _, ex := range p.exchanges {
+		if !strings.Contains(last, strings.ToLower(ex.Match)) {
+			continue
+		}
+		if ex.Error != "" {
+			return nil, errors.New(ex.Error)
+		}
+		reply = ex.Reply
+		if ex.Decision != nil {
+			reply = StubReply(ex.Reply, *ex.Decision)
+		}
+		break
+	}
+
+	input := len(systemPrompt)
+	for _, m := range messages {
+		input += len(m.Content)
+	}
+	return &AIResponse{
+		Content:      reply,
+		InputTokens:  input / 4,
+		OutputTokens: len(reply) / 4,
+	}, nil
+}
+
+// StubReply formats a reply followed by its JSON decision block, as
+// ParseAIDecision expects.
+func StubReply(text string, decision AIDecisionData) string {
+	block, _ := json.MarshalIndent(decision, "", "  ")
+	return strings.TrimSpace(text) + "\n\n```json\n" + string(block) + "\n```"
+}
@@ -3,19 +3,27 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/edvirons/ssp/ims/internal/chatrouting"
 	"github.com/edvirons/ssp/ims/internal/claude"
 	"github.com/edvirons/ssp/ims/internal/config"
+	"github.com/edvirons/ssp/ims/internal/lookups"
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/edvirons/ssp/ims/internal/ws"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
+// errThreadHasIncident rolls back an incident filed from a chat whose thread
+// was linked to another incident meanwhile.
+var errThreadHasIncident = errors.New("thread already linked to an incident")
+
 // AIChatHandler handles AI chat endpoints
 type AIChatHandler struct {
 	log            *zap.Logger
@@ -24,18 +32,28 @@ type AIChatHandler struct {
 	claude         *claude.Client
 	contextBuilder *claude.ContextBuilder
 	escalation     *claude.EscalationAnalyzer
+	router         *chatrouting.Router
 	cfg            config.Config
 }
 
-// NewAIChatHandler creates a new AI chat handler
-func NewAIChatHandler(log *zap.Logger, pg *store.Postgres, hub *ws.Hub, cfg config.Config) *AIChatHandler {
-	// Create Claude client
-	claudeClient := claude.NewClient(claude.ClientConfig{
+// newAIClient creates a client for the AI provider in cfg.
+func newAIClient(cfg config.Config, log *zap.Logger) *claude.Client {
+	return claude.NewClient(claude.ClientConfig{
+		Provider:       cfg.AIProvider,
 		APIKey:         cfg.ClaudeAPIKey,
 		Model:          cfg.ClaudeModel,
 		MaxTokens:      cfg.ClaudeMaxTokens,
 		TimeoutSeconds: cfg.ClaudeTimeoutSeconds,
+		BaseURL:        cfg.ClaudeBaseURL,
+		StubScript:     cfg.AIStubScript,
+		RecordPath:     cfg.AIRecordPath,
 	}, log)
+}
+
+// NewAIChatHandler creates a new AI chat handler
+func NewAIChatHandler(log *zap.Logger, pg *store.Postgres, hub *ws.Hub, cfg config.Config) *AIChatHandler {
+	// Create client for the configured AI provider
+	claudeClient := newAIClient(cfg, log)
 
 	// Create context builder
 	contextBuilder := claude.NewContextBuilder(pg.RawPool(), log)
@@ -50,6 +68,7 @@ func NewAIChatHandler(log *zap.Logger, pg *store.Postgres, hub *ws.Hub, cfg conf
 		claude:         claudeClient,
 		contextBuilder: contextBuilder,
 		escalation:     escalation,
+		router:         chatrouting.New(log, pg, hub),
 		cfg:            cfg,
 	}
 }
@@ -130,13 +149,17 @@ func (h *AIChatHandler) HandleAIMessage(w http.ResponseWriter, r *http.Request)
 	// Build SSOT context
 	ssotContext, _ := h.contextBuilder.BuildContext(ctx, tenantID, session.SchoolID, req.DeviceSerial)
 
+	// Retrieve published KB articles matching the conversation
+	kbRefs := h.retrieveKB(ctx, tenantID, session, req.Content, history, ssotContext)
+
 	// Build system prompt
 	promptData := claude.PromptData{
-		Context:    ssotContext,
-		TurnNumber: session.AITurns + 1,
-		MaxTurns:   h.cfg.AIMaxTurns,
-		UserName:   userName,
-		SessionID:  sessionID,
+		Context:       ssotContext,
+		KnowledgeBase: kbRefs,
+		TurnNumber:    session.AITurns + 1,
+		MaxTurns:      h.cfg.AIMaxTurns,
+		UserName:      userName,
+		SessionID:     sessionID,
 	}
 	systemPrompt, err := claude.BuildSystemPrompt(promptData)
 	if err != nil {
@@ -165,6 +188,10 @@ func (h *AIChatHandler) HandleAIMessage(w http.ResponseWriter, r *http.Request)
 	// Parse AI decision from response
 	decision, cleanContent := claude.ParseAIDecision(aiResponse.Content)
 
+	// List the KB articles the reply cited
+	cited := claude.CitedKBRefs(cleanContent, kbRefs)
+	cleanContent = claude.AppendKBSources(cleanContent, cited)
+
 	// Check for escalation
 	shouldEscalate, escalationReason := h.escalation.ShouldEscalate(signals, session.AITurns+1, decision)
 
@@ -191,9 +218,14 @@ func (h *AIChatHandler) HandleAIMessage(w http.ResponseWriter, r *http.Request)
 	// Broadcast AI message
 	h.broadcastMessage(tenantID, session.ThreadID, aiMessage)
 
-	// Handle escalation
+	// Handle escalation, or file a ticket for a fault that needs repair
+	var ticket *models.AIChatTicket
 	if shouldEscalate {
-		h.handleEscalation(ctx, tenantID, sessionID, escalationReason, signals, decision, conversationHistory)
+		ticket = h.handleEscalation(ctx, tenantID, sessionID, escalationReason, signals, decision, conversationHistory)
+	} else if decision != nil && service.IsChatRepairFault(&decision.Category, decision.CollectedInfo) {
+		if current, err := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID); err == nil {
+			ticket = h.fileChatIncident(ctx, current, "")
+		}
 	}
 
 	// Check if AI resolved the issue
@@ -202,8 +234,9 @@ func (h *AIChatHandler) HandleAIMessage(w http.ResponseWriter, r *http.Request)
 		_ = h.pg.ChatSessions().UpdateAIResolution(ctx, tenantID, sessionID, resolved)
 	}
 
-	// Log conversation turn
-	h.logConversationTurn(ctx, tenantID, sessionID, session.AITurns+1, req.Content, aiResponse, signals, ssotContext)
+	// Log conversation turn and the KB articles it used
+	turnID := h.logConversationTurn(ctx, tenantID, sessionID, session.AITurns+1, req.Content, aiResponse, signals, ssotContext)
+	h.logKBUsage(ctx, tenantID, sessionID, turnID, kbRefs, cited)
 
 	// Get updated session status
 	updatedSession, _ := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID)
@@ -214,6 +247,8 @@ func (h *AIChatHandler) HandleAIMessage(w http.ResponseWriter, r *http.Request)
 		ShouldEscalate: shouldEscalate,
 		EscalateReason: &escalationReason,
 		SessionStatus:  updatedSession.Status,
+		Citations:      kbCitations(cited),
+		Ticket:         ticket,
 	})
 }
 
@@ -257,11 +292,11 @@ func (h *AIChatHandler) RequestEscalation(w http.ResponseWriter, r *http.Request
 		reason = req.Reason
 	}
 
-	h.handleEscalation(ctx, tenantID, sessionID, reason, claude.EscalationSignals{ExplicitRequest: true}, nil, conversationHistory)
-
 	// Send system message
 	h.sendSystemMessage(ctx, tenantID, session.ThreadID, "Connecting you with a support agent...")
 
+	ticket := h.handleEscalation(ctx, tenantID, sessionID, reason, claude.EscalationSignals{ExplicitRequest: true}, nil, conversationHistory)
+
 	// Get updated session
 	updatedSession, _ := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID)
 	position, _ := h.pg.ChatSessions().GetQueuePosition(ctx, tenantID, sessionID)
@@ -269,6 +304,7 @@ func (h *AIChatHandler) RequestEscalation(w http.ResponseWriter, r *http.Request
 	writeJSON(w, http.StatusOK, models.AIEscalationResponse{
 		Session:       updatedSession,
 		QueuePosition: &position,
+		Ticket:        ticket,
 	})
 }
 
@@ -292,14 +328,16 @@ func (h *AIChatHandler) GetConversationContext(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Get conversation history
+	// Get conversation history and the incident filed for it
 	messages, _ := h.pg.Messaging().GetThreadMessages(ctx, tenantID, session.ThreadID, 0, 100)
+	thread, _ := h.pg.Messaging().GetThreadByID(ctx, tenantID, session.ThreadID)
 
 	// Build SSOT context
 	ssotContext, _ := h.contextBuilder.BuildContext(ctx, tenantID, session.SchoolID, nil)
 
 	context := models.AIConversationContext{
 		SessionID:           sessionID,
+		IncidentID:          thread.IncidentID,
 		TurnCount:           session.AITurns,
 		Category:            session.IssueCategory,
 		Severity:            session.IssueSeverity,
@@ -351,7 +389,10 @@ func (h *AIChatHandler) buildClaudeHistory(messages []models.Message) []claude.M
 	return history
 }
 
-func (h *AIChatHandler) handleEscalation(ctx context.Context, tenantID, sessionID, reason string, signals claude.EscalationSignals, decision *claude.AIDecisionData, history []claude.Message) {
+// handleEscalation hands the session to the agent queue, files an incident
+// from what the assistant collected and routes the session to an agent. It
+// returns the ticket filed, if any.
+func (h *AIChatHandler) handleEscalation(ctx context.Context, tenantID, sessionID, reason string, signals claude.EscalationSignals, decision *claude.AIDecisionData, history []claude.Message) *models.AIChatTicket {
 	// Build escalation summary
 	summary := claude.BuildEscalationSummary(signals, decision, 0, history)
 
@@ -361,25 +402,127 @@ func (h *AIChatHandler) handleEscalation(ctx context.Context, tenantID, sessionI
 	// Update queue positions
 	_ = h.pg.ChatSessions().UpdateQueuePositions(ctx, tenantID)
 
-	// Get session for thread ID
-	session, _ := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID)
+	// Get session for thread ID and collected info
+	session, err := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID)
+	var ticket *models.AIChatTicket
+	if err == nil {
+		ticket = h.fileChatIncident(ctx, session, reason)
+	}
 
 	// Notify agents of new chat waiting
 	if h.hub != nil {
+		payload := map[string]any{
+			"sessionId":         sessionID,
+			"schoolContactName": session.SchoolContactName,
+			"escalatedFromAI":   true,
+			"escalationReason":  reason,
+			"aiSummary":         summary,
+		}
+		if ticket != nil {
+			payload["incidentId"] = ticket.IncidentID
+		}
 		h.hub.Broadcast(tenantID, &ws.Message{
-			Type: ws.MessageTypeNewChatWaiting,
-			Payload: map[string]any{
-				"sessionId":         sessionID,
-				"schoolContactName": session.SchoolContactName,
-				"escalatedFromAI":   true,
-				"escalationReason":  reason,
-				"aiSummary":         summary,
-			},
+			Type:    ws.MessageTypeNewChatWaiting,
+			Payload: payload,
 		})
 	}
 
 	// Broadcast status update
 	h.broadcastSessionUpdate(tenantID, sessionID, models.ChatStatusWaiting)
+
+	// Route to the best available agent; the session stays queued otherwise
+	if err == nil && session.Status == models.ChatStatusWaiting {
+		h.router.Route(ctx, session, true)
+	}
+	return ticket
+}
+
+// fileChatIncident files an incident from the category, severity and
+// details the assistant collected on a session and links it to the chat
+// thread, so agents do not re-key it. A thread already linked to an
+// incident, such as one the chat was started from, gets no second one. The
+// reported serial is resolved to a device at the session's school. Faults
+// that need physical repair are routed to a service shop. Failures are
+// logged and the chat carries on without a ticket.
+func (h *AIChatHandler) fileChatIncident(ctx context.Context, session models.ChatSession, reason string) *models.AIChatTicket {
+	if !h.cfg.AIAutoIncidents {
+		return nil
+	}
+	thread, err := h.pg.Messaging().GetThreadByID(ctx, session.TenantID, session.ThreadID)
+	if err != nil || thread.IncidentID != nil {
+		return nil
+	}
+	draft := service.DraftChatIncident(session.ID, session.IssueCategory, session.IssueSeverity, session.CollectedInfo, reason)
+
+	// Enrich from SSOT snapshots (best-effort)
+	lk := lookups.New(h.pg.RawPool())
+	sc, _ := lk.SchoolByID(ctx, session.TenantID, session.SchoolID)
+	pc, _ := lk.PrimaryContactBySchoolID(ctx, session.TenantID, session.SchoolID)
+	var dv *lookups.DeviceSummary
+	if draft.DeviceSerial != "" {
+		d, err := lk.DeviceBySerial(ctx, session.TenantID, draft.DeviceSerial)
+		switch {
+		case err != nil:
+			h.log.Info("chat device serial not resolved", zap.String("session_id", session.ID), zap.String("serial", draft.DeviceSerial), zap.Error(err))
+		case d.SchoolID != "" && d.SchoolID != session.SchoolID:
+			h.log.Info("chat device serial belongs to another school", zap.String("session_id", session.ID), zap.String("device_id", d.ID))
+		default:
+			dv = d
+		}
+	}
+
+	now := time.Now().UTC()
+	inc := models.Incident{
+		ID:          store.NewID("inc"),
+		TenantID:    session.TenantID,
+		SchoolID:    session.SchoolID,
+		Category:    draft.Category,
+		Severity:    draft.Severity,
+		Status:      models.IncidentNew,
+		Title:       draft.Title,
+		Description: draft.Description,
+		ReportedBy:  session.SchoolContactName,
+		SLABreached: false,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if dv != nil {
+		inc.DeviceID = dv.ID
+	}
+	schoolLevel := enrichIncident(&inc, sc, pc, dv)
+	applySLA(ctx, h.log, h.pg, &inc, schoolLevel, now)
+
+	err = h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		if err := store.CreateIncidentTx(ctx, tx, inc); err != nil {
+			return err
+		}
+		linked, err := store.LinkThreadIncidentTx(ctx, tx, inc.TenantID, session.ThreadID, inc.ID)
+		if err != nil {
+			return err
+		}
+		if !linked {
+			return errThreadHasIncident
+		}
+		return store.EnqueueEventTx(ctx, tx, incidentEvent(models.EventIncidentCreated, inc, session.SchoolContactID, inc))
+	})
+	if errors.Is(err, errThreadHasIncident) {
+		return nil
+	}
+	if err != nil {
+		h.log.Error("failed to file incident from chat", zap.String("session_id", session.ID), zap.Error(err))
+		return nil
+	}
+
+	ticket := &models.AIChatTicket{IncidentID: inc.ID}
+	notice := "Support ticket " + inc.ID + " has been logged for this issue."
+	if draft.NeedsRepair && h.cfg.AutoRouteWorkOrders {
+		if wo, ok := autoRouteWorkOrder(ctx, h.cfg, h.pg, inc, session.SchoolContactID); ok {
+			ticket.WorkOrderID = wo.ID
+			notice += " A technician from your service centre will follow up on the repair."
+		}
+	}
+	h.sendSystemMessage(ctx, session.TenantID, session.ThreadID, notice)
+	return ticket
 }
 
 func (h *AIChatHandler) updateSessionAIData(ctx context.Context, tenantID, sessionID string, decision *claude.AIDecisionData, turns int) {
@@ -391,7 +534,8 @@ func (h *AIChatHandler) updateSessionAIData(ctx context.Context, tenantID, sessi
 	_ = h.pg.ChatSessions().UpdateAISessionData(ctx, tenantID, sessionID, turns, decision.Category, decision.Severity, decision.CollectedInfo)
 }
 
-func (h *AIChatHandler) logConversationTurn(ctx context.Context, tenantID, sessionID string, turnNumber int, userMsg string, aiResp *claude.AIResponse, signals claude.EscalationSignals, ssotCtx *claude.SSOTContext) {
+// logConversationTurn logs a turn for analytics and returns its ID.
+func (h *AIChatHandler) logConversationTurn(ctx context.Context, tenantID, sessionID string, turnNumber int, userMsg string, aiResp *claude.AIResponse, signals claude.EscalationSignals, ssotCtx *claude.SSOTContext) string {
 	turn := claude.ConversationTurn{
 		ID:                    store.NewID("turn"),
 		TenantID:              tenantID,
@@ -409,6 +553,90 @@ func (h *AIChatHandler) logConversationTurn(ctx context.Context, tenantID, sessi
 	}
 
 	_ = h.pg.ChatSessions().LogAIConversationTurn(ctx, turn)
+	return turn.ID
+}
+
+// retrieveKB finds published KB articles for the conversation: the current
+// message's terms first, then earlier user messages. The issue category
+// chosen on earlier turns limits the search to related KB modules, and
+// articles tagged with the device's category rank higher. Retrieval
+// failures only lose the articles.
+func (h *AIChatHandler) retrieveKB(ctx context.Context, tenantID string, session models.ChatSession, content string, history []models.Message, ssotCtx *claude.SSOTContext) []claude.KBArticleRef {
+	if h.cfg.AIKBArticles <= 0 {
+		return nil
+	}
+	texts := []string{content}
+	for i := len(history) - 1; i >= 0; i-- {
+		if m := history[i]; m.SenderRole != "ai" && m.SenderRole != "system" && m.SenderID != "ai_assistant" && m.Content != content {
+			texts = append(texts, m.Content)
+		}
+	}
+	p := models.KBRetrievalParams{
+		TenantID: tenantID,
+		Terms:    claude.KBSearchTerms(texts...),
+		Limit:    h.cfg.AIKBArticles,
+	}
+	if session.IssueCategory != nil {
+		p.Modules = claude.KBModulesForIssue(*session.IssueCategory)
+	}
+	if ssotCtx != nil && ssotCtx.Device != nil {
+		p.DeviceCategory = ssotCtx.Device.DeviceType
+	}
+	matches, err := h.pg.KBArticles().Retrieve(ctx, p)
+	if err != nil {
+		h.log.Warn("failed to retrieve kb articles", zap.Error(err))
+		return nil
+	}
+	refs := make([]claude.KBArticleRef, 0, len(matches))
+	for _, m := range matches {
+		refs = append(refs, claude.KBArticleRef{
+			ID:          m.Article.ID,
+			Title:       m.Article.Title,
+			Slug:        m.Article.Slug,
+			ContentType: string(m.Article.ContentType),
+			Module:      string(m.Article.Module),
+			Excerpt:     claude.KBExcerpt(m.Article.Content),
+			Score:       m.Score,
+		})
+	}
+	return claude.LabelKBRefs(refs)
+}
+
+// logKBUsage records which articles the turn was given and which it cited.
+func (h *AIChatHandler) logKBUsage(ctx context.Context, tenantID, sessionID, turnID string, refs, cited []claude.KBArticleRef) {
+	if len(refs) == 0 {
+		return
+	}
+	citedIDs := map[string]bool{}
+	for _, c := range cited {
+		citedIDs[c.ID] = true
+	}
+	now := time.Now().UTC()
+	usage := make([]models.KBAIUsage, 0, len(refs))
+	for i, ref := range refs {
+		usage = append(usage, models.KBAIUsage{
+			ID:        store.NewID("kbu"),
+			TenantID:  tenantID,
+			SessionID: sessionID,
+			TurnID:    turnID,
+			ArticleID: ref.ID,
+			Rank:      i + 1,
+			Score:     ref.Score,
+			Cited:     citedIDs[ref.ID],
+			CreatedAt: now,
+		})
+	}
+	if err := h.pg.KBArticles().LogAIUsage(ctx, usage); err != nil {
+		h.log.Warn("failed to log kb usage", zap.Error(err))
+	}
+}
+
+func kbCitations(cited []claude.KBArticleRef) []models.AIKBCitation {
+	out := make([]models.AIKBCitation, 0, len(cited))
+	for _, c := range cited {
+		out = append(out, models.AIKBCitation{ArticleID: c.ID, Marker: c.Marker, Title: c.Title, Slug: c.Slug})
+	}
+	return out
 }
 
 func (h *AIChatHandler) broadcastMessage(tenantID, threadID string, message models.Message) {
@@ -7,6 +7,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
@@ -99,18 +100,20 @@ func (h *BOMHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:     now,
 	}
 
-	// Transaction: reserve inventory + create BOM item
+	// Transaction: reserve stock on the ledger + create BOM item
 	err = h.pgTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
-		if _, err := tx.Exec(ctx, `
-			UPDATE inventory
-			SET qty_reserved = qty_reserved + $4, updated_at = $5
-			WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
-			  AND (qty_available - qty_reserved) >= $4
-		`, tenant, wo.ServiceShopID, item.PartID, item.QtyPlanned, now); err != nil {
+		if _, err := store.PostStockMovementTx(ctx, tx, bomMovement(ctx, item, models.StockReservation, item.QtyPlanned)); err != nil {
 			return err
 		}
-		return store.CreateWorkOrderPartTx(ctx, tx, item)
+		if err := store.CreateWorkOrderPartTx(ctx, tx, item); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, bomChangedEvent(r.Context(), item, "added", item.QtyPlanned))
 	})
+	if errors.Is(err, store.ErrInsufficientStock) {
+		http.Error(w, "insufficient stock to reserve", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to reserve inventory or create bom item", http.StatusConflict)
 		return
@@ -153,6 +156,33 @@ func (h *BOMHandler) pgTx(ctx context.Context, fn func(context.Context, store.Tx
 	return tx.Commit(ctx)
 }
 
+// bomMovement builds the ledger movement for a change to a BOM item's stock.
+func bomMovement(ctx context.Context, item models.WorkOrderPart, typ models.StockMovementType, qty int64) models.StockMovement {
+	return models.StockMovement{
+		TenantID:      item.TenantID,
+		Type:          typ,
+		ServiceShopID: item.ServiceShopID,
+		PartID:        item.PartID,
+		Qty:           qty,
+		ReferenceType: "work_order_part",
+		ReferenceID:   item.ID,
+		Notes:         "work order " + item.WorkOrderID,
+		ActorID:       middleware.UserID(ctx),
+	}
+}
+
+// bomChangedEvent builds the outbox event for a BOM line change.
+func bomChangedEvent(ctx context.Context, item models.WorkOrderPart, action string, qty int64) models.DomainEvent {
+	return workOrderEvent(models.EventWorkOrderBOMChanged, item.TenantID, item.SchoolID, item.WorkOrderID, middleware.UserID(ctx),
+		map[string]any{
+			"action":        action, // added|consumed|released
+			"itemId":        item.ID,
+			"partId":        item.PartID,
+			"serviceShopId": item.ServiceShopID,
+			"qty":           qty,
+		})
+}
+
 // enforceCompatibility returns whether BOM compatibility enforcement is enabled.
 func enforceCompatibility() bool {
 	v := strings.ToLower(strings.TrimSpace(os.Getenv("BOM_ENFORCE_COMPATIBILITY")))
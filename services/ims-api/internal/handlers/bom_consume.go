@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/go-chi/chi/v5"
 )
@@ -51,15 +52,11 @@ func (h *BOMHandler) Consume(w http.ResponseWriter, r *http.Request) {
 		if err := store.UpdateWorkOrderPartUsedTx(ctx, tx, tenant, school, itemID, req.QtyUsed, now); err != nil {
 			return err
 		}
-		// consume inventory: reduce reserved and available
-		_, err := tx.Exec(ctx, `
-			UPDATE inventory
-			SET qty_reserved = GREATEST(qty_reserved - $4, 0),
-			    qty_available = GREATEST(qty_available - $4, 0),
-			    updated_at=$5
-			WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
-		`, tenant, item.ServiceShopID, item.PartID, req.QtyUsed, now)
-		return err
+		// issue reserved stock to the work order
+		if _, err := store.PostStockMovementTx(ctx, tx, bomMovement(ctx, item, models.StockIssue, req.QtyUsed)); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, bomChangedEvent(ctx, item, "consumed", req.QtyUsed))
 	})
 	if err != nil {
 		http.Error(w, "failed to consume", http.StatusConflict)
@@ -110,13 +107,11 @@ func (h *BOMHandler) Release(w http.ResponseWriter, r *http.Request) {
 		if err := store.UpdateWorkOrderPartPlannedTx(ctx, tx, tenant, school, itemID, newPlanned, now); err != nil {
 			return err
 		}
-		// release inventory reserved by qty
-		_, err := tx.Exec(ctx, `
-			UPDATE inventory
-			SET qty_reserved = GREATEST(qty_reserved - $4, 0), updated_at=$5
-			WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
-		`, tenant, item.ServiceShopID, item.PartID, req.Qty, now)
-		return err
+		// return reserved stock to available
+		if _, err := store.PostStockMovementTx(ctx, tx, bomMovement(ctx, item, models.StockRelease, req.Qty)); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, bomChangedEvent(ctx, item, "released", req.Qty))
 	})
 	if err != nil {
 		http.Error(w, "failed to release", http.StatusConflict)
@@ -1,25 +1,41 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/lookups"
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
+// errInvalidBOQItem is returned from an item update that leaves the line
+// invalid.
+var errInvalidBOQItem = errors.New("category and description required; qty and estimatedCostCents must not be negative")
+
+// BOQHandler manages a project's bill of quantities: its lines, and the
+// draft, submitted, approved and revised versions they go through before
+// being converted into work order BOM lines.
 type BOQHandler struct {
-	log *zap.Logger
-	pg  *store.Postgres
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
 }
 
-func NewBOQHandler(log *zap.Logger, pg *store.Postgres) *BOQHandler {
-	return &BOQHandler{log: log, pg: pg}
+func NewBOQHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *BOQHandler {
+	return &BOQHandler{log: log, pg: pg, audit: auditLogger}
 }
 
 type addBOQReq struct {
@@ -28,10 +44,10 @@ type addBOQReq struct {
 	PartID             string `json:"partId"`
 	Qty                int64  `json:"qty"`
 	Unit               string `json:"unit"`
-	EstimatedCostCents int64  `json:"estimatedCostCents"`
-	Approved           bool   `json:"approved"`
+	EstimatedCostCents int64  `json:"estimatedCostCents"` // for the whole line
 }
 
+// AddItem adds a line to the project's draft BOQ.
 func (h *BOQHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "id")
 	var req addBOQReq
@@ -43,6 +59,10 @@ func (h *BOQHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "category and description required", http.StatusBadRequest)
 		return
 	}
+	if req.Qty < 0 || req.EstimatedCostCents < 0 {
+		http.Error(w, "qty and estimatedCostCents must not be negative", http.StatusBadRequest)
+		return
+	}
 	tenant := middleware.TenantID(r.Context())
 	now := time.Now().UTC()
 	b := models.BOQItem{
@@ -55,17 +75,136 @@ func (h *BOQHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		Qty:                req.Qty,
 		Unit:               strings.TrimSpace(req.Unit),
 		EstimatedCostCents: req.EstimatedCostCents,
-		Approved:           req.Approved,
 		CreatedAt:          now,
 		UpdatedAt:          now,
 	}
-	if err := h.pg.BOQ().Create(r.Context(), b); err != nil {
-		http.Error(w, "failed to add boq item", http.StatusInternalServerError)
+	err := h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if _, err := h.draftVersion(ctx, tx, projectID, now); err != nil {
+			return err
+		}
+		return store.CreateBOQItemTx(ctx, tx, b)
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, "add boq item")
 		return
 	}
 	writeJSON(w, http.StatusCreated, b)
 }
 
+type updateBOQItemReq struct {
+	Category           *string `json:"category"`
+	Description        *string `json:"description"`
+	PartID             *string `json:"partId"`
+	Qty                *int64  `json:"qty"`
+	Unit               *string `json:"unit"`
+	EstimatedCostCents *int64  `json:"estimatedCostCents"`
+}
+
+// UpdateItem changes a line of the project's draft BOQ.
+func (h *BOQHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	var req updateBOQItemReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	var before, b models.BOQItem
+	err := h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if _, err := h.draftVersion(ctx, tx, projectID, now); err != nil {
+			return err
+		}
+		var err error
+		b, err = store.GetBOQItemTx(ctx, tx, middleware.TenantID(ctx), projectID, chi.URLParam(r, "itemId"))
+		if err != nil {
+			return err
+		}
+		before = b
+		if req.Category != nil {
+			b.Category = strings.TrimSpace(*req.Category)
+		}
+		if req.Description != nil {
+			b.Description = strings.TrimSpace(*req.Description)
+		}
+		if req.PartID != nil {
+			b.PartID = strings.TrimSpace(*req.PartID)
+		}
+		if req.Qty != nil {
+			b.Qty = *req.Qty
+		}
+		if req.Unit != nil {
+			b.Unit = strings.TrimSpace(*req.Unit)
+		}
+		if req.EstimatedCostCents != nil {
+			b.EstimatedCostCents = *req.EstimatedCostCents
+		}
+		if b.Category == "" || b.Description == "" || b.Qty < 0 || b.EstimatedCostCents < 0 {
+			return errInvalidBOQItem
+		}
+		b.UpdatedAt = now
+		return store.UpdateBOQItemTx(ctx, tx, b)
+	})
+	if errors.Is(err, errInvalidBOQItem) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeLedgerError(h.log, w, err, "update boq item")
+		return
+	}
+	if err := h.audit.LogUpdate(r.Context(), "boq_item", b.ID, before, b); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// DeleteItem removes a line from the project's draft BOQ.
+func (h *BOQHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	var b models.BOQItem
+	err := h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if _, err := h.draftVersion(ctx, tx, projectID, time.Now().UTC()); err != nil {
+			return err
+		}
+		var err error
+		tenant := middleware.TenantID(ctx)
+		if b, err = store.GetBOQItemTx(ctx, tx, tenant, projectID, chi.URLParam(r, "itemId")); err != nil {
+			return err
+		}
+		return store.DeleteBOQItemTx(ctx, tx, tenant, projectID, b.ID)
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, "delete boq item")
+		return
+	}
+	if err := h.audit.LogDelete(r.Context(), "boq_item", b.ID, b); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// draftVersion locks the project's current BOQ version and requires it to
+// be a draft, so lines cannot change under a submitted or approved version.
+func (h *BOQHandler) draftVersion(ctx context.Context, tx store.Tx, projectID string, now time.Time) (models.BOQVersion, error) {
+	v, err := store.CurrentBOQVersionTx(ctx, tx, middleware.TenantID(ctx), projectID, middleware.UserID(ctx), now)
+	if err != nil {
+		return v, err
+	}
+	if err := requireBOQStatus(&v, models.BOQDraft); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+func requireBOQStatus(v *models.BOQVersion, allowed ...models.BOQStatus) error {
+	for _, s := range allowed {
+		if v.Status == s {
+			return nil
+		}
+	}
+	return stockStateError("boq version " + strconv.Itoa(v.Version) + " is " + string(v.Status))
+}
+
 func (h *BOQHandler) List(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "id")
 	tenant := middleware.TenantID(r.Context())
@@ -90,3 +229,420 @@ func (h *BOQHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"items": items, "nextCursor": next})
 }
+
+// GetCost rolls the project's current BOQ lines up by category, priced from
+// the parts catalog's vendor SKUs where a line has a part.
+func (h *BOQHandler) GetCost(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	items, err := h.pg.BOQ().Items(r.Context(), tenant, chi.URLParam(r, "id"))
+	if err != nil {
+		h.log.Error("failed to list boq items", zap.Error(err))
+		http.Error(w, "failed to cost boq", http.StatusInternalServerError)
+		return
+	}
+	skus, err := h.vendorSKUs(r.Context(), tenant)
+	if err != nil {
+		h.log.Error("failed to load vendor skus", zap.Error(err))
+		http.Error(w, "failed to cost boq", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, service.RollupBOQCost(items, skus))
+}
+
+// vendorSKUs loads vendor pricing from the parts catalog. Without a parts
+// snapshot every line is costed at its estimate.
+func (h *BOQHandler) vendorSKUs(ctx context.Context, tenant string) (map[string][]lookups.VendorSKU, error) {
+	skus, err := lookups.New(h.pg.RawPool()).VendorSKUsByPart(ctx, tenant)
+	if errors.Is(err, lookups.ErrSnapshotMissing) {
+		return map[string][]lookups.VendorSKU{}, nil
+	}
+	return skus, err
+}
+
+// ListVersions returns the project's BOQ versions, newest first. A project
+// with none is on an implicit draft version 1.
+func (h *BOQHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	items, err := h.pg.BOQ().ListVersions(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		h.log.Error("failed to list boq versions", zap.Error(err))
+		http.Error(w, "failed to list boq versions", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (h *BOQHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		http.Error(w, "invalid version", http.StatusBadRequest)
+		return
+	}
+	v, err := h.pg.BOQ().GetVersion(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"), version)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+type boqDecisionReq struct {
+	Notes string `json:"notes"`
+}
+
+// Submit sends the draft BOQ for approval, recording its lines and costing
+// as they stand.
+func (h *BOQHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	var req boqDecisionReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	skus, err := h.vendorSKUs(r.Context(), middleware.TenantID(r.Context()))
+	if err != nil {
+		h.log.Error("failed to load vendor skus", zap.Error(err))
+		http.Error(w, "failed to submit boq", http.StatusInternalServerError)
+		return
+	}
+	v, ok := h.updateVersion(w, r, "submit boq", func(ctx context.Context, tx store.Tx, v *models.BOQVersion, now time.Time) error {
+		if err := requireBOQStatus(v, models.BOQDraft); err != nil {
+			return err
+		}
+		items, err := store.ListBOQItemsTx(ctx, tx, v.TenantID, v.ProjectID)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return stockStateError("boq has no lines")
+		}
+		cost := service.RollupBOQCost(items, skus)
+		v.Status = models.BOQSubmitted
+		v.Items = items
+		v.Cost = &cost
+		v.Notes = strings.TrimSpace(req.Notes)
+		v.DecisionNotes = ""
+		v.SubmittedBy = middleware.UserID(ctx)
+		v.SubmittedAt = &now
+		return nil
+	})
+	if ok {
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+// Approve approves a submitted BOQ and marks its lines approved. The
+// submitter cannot approve their own BOQ.
+func (h *BOQHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	var req boqDecisionReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	v, ok := h.updateVersion(w, r, "approve boq", func(ctx context.Context, tx store.Tx, v *models.BOQVersion, now time.Time) error {
+		if err := requireBOQStatus(v, models.BOQSubmitted); err != nil {
+			return err
+		}
+		actor := middleware.UserID(ctx)
+		if actor != "" && actor == v.SubmittedBy {
+			return stockStateError("a boq cannot be approved by its submitter")
+		}
+		v.Status = models.BOQApproved
+		v.DecisionNotes = strings.TrimSpace(req.Notes)
+		v.ApprovedBy = actor
+		v.ApproverRole = boqApproverRole(middleware.Roles(ctx))
+		v.ApprovedAt = &now
+		for i := range v.Items {
+			v.Items[i].Approved = true
+		}
+		return store.SetBOQItemsApprovedTx(ctx, tx, v.TenantID, v.ProjectID, true, now)
+	})
+	if ok {
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+// Reject sends a submitted BOQ back to draft with the approver's notes.
+func (h *BOQHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	var req boqDecisionReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	if strings.TrimSpace(req.Notes) == "" {
+		http.Error(w, "notes required", http.StatusBadRequest)
+		return
+	}
+	v, ok := h.updateVersion(w, r, "reject boq", func(ctx context.Context, tx store.Tx, v *models.BOQVersion, now time.Time) error {
+		if err := requireBOQStatus(v, models.BOQSubmitted); err != nil {
+			return err
+		}
+		v.Status = models.BOQDraft
+		v.DecisionNotes = strings.TrimSpace(req.Notes)
+		v.SubmittedBy = ""
+		v.SubmittedAt = nil
+		return nil
+	})
+	if ok {
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+// Revise supersedes an approved BOQ with a new draft version holding the
+// same lines. A BOQ already converted to work order BOM lines cannot be
+// revised; change the work order's BOM instead.
+func (h *BOQHandler) Revise(w http.ResponseWriter, r *http.Request) {
+	var req boqDecisionReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	var next models.BOQVersion
+	if _, ok := h.updateVersion(w, r, "revise boq", func(ctx context.Context, tx store.Tx, v *models.BOQVersion, now time.Time) error {
+		if err := requireBOQStatus(v, models.BOQApproved); err != nil {
+			return err
+		}
+		if v.ConvertedAt != nil {
+			return stockStateError("boq was converted to work order " + v.WorkOrderID + "; change its BOM instead")
+		}
+		v.Status = models.BOQRevised
+		v.RevisedAt = &now
+		next = models.BOQVersion{
+			ID:        store.NewID("boqv"),
+			TenantID:  v.TenantID,
+			ProjectID: v.ProjectID,
+			Version:   v.Version + 1,
+			Status:    models.BOQDraft,
+			Items:     []models.BOQItem{},
+			Notes:     strings.TrimSpace(req.Notes),
+			CreatedBy: middleware.UserID(ctx),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := store.CreateBOQVersionTx(ctx, tx, next); err != nil {
+			return err
+		}
+		return store.SetBOQItemsApprovedTx(ctx, tx, v.TenantID, v.ProjectID, false, now)
+	}); ok {
+		writeJSON(w, http.StatusCreated, next)
+	}
+}
+
+// updateVersion applies fn to the project's current BOQ version in a
+// transaction and saves it. On failure the error response is written and ok
+// is false.
+func (h *BOQHandler) updateVersion(w http.ResponseWriter, r *http.Request, action string, fn func(ctx context.Context, tx store.Tx, v *models.BOQVersion, now time.Time) error) (models.BOQVersion, bool) {
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	projectID := chi.URLParam(r, "id")
+	if _, err := h.pg.Projects().GetByID(ctx, tenant, projectID); err != nil {
+		http.Error(w, "project not found", http.StatusNotFound)
+		return models.BOQVersion{}, false
+	}
+	var before, v models.BOQVersion
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		now := time.Now().UTC()
+		var err error
+		v, err = store.CurrentBOQVersionTx(ctx, tx, tenant, projectID, middleware.UserID(ctx), now)
+		if err != nil {
+			return err
+		}
+		before = v
+		if err := fn(ctx, tx, &v, now); err != nil {
+			return err
+		}
+		v.UpdatedAt = now
+		return store.UpdateBOQVersionTx(ctx, tx, v)
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, action)
+		return v, false
+	}
+	if err := h.audit.LogUpdate(ctx, "boq_version", v.ID, before, v); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	return v, true
+}
+
+// boqApproverRole returns the first of the approver's roles that grants BOQ
+// approval, recorded on the version as the capacity it was approved in.
+func boqApproverRole(roles []string) string {
+	for _, role := range roles {
+		if auth.HasPermission(role, auth.PermBOQApprove) {
+			return role
+		}
+	}
+	return ""
+}
+
+type convertBOQReq struct {
+	WorkOrderID string `json:"workOrderId"`
+	PhaseID     string `json:"phaseId"`
+}
+
+// Convert turns the approved BOQ into BOM lines on a work order in the
+// project's install phase, reserving stock for each in the work order's
+// service shop. Lines without a part are skipped. Nothing is reserved
+// unless every line can be.
+func (h *BOQHandler) Convert(w http.ResponseWriter, r *http.Request) {
+	var req convertBOQReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	projectID := chi.URLParam(r, "id")
+	project, err := h.pg.Projects().GetByID(ctx, tenant, projectID)
+	if err != nil {
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	}
+	wo, ok := h.installWorkOrder(w, r, project, req)
+	if !ok {
+		return
+	}
+
+	lk := lookups.New(h.pg.RawPool())
+	out := models.BOQConversion{WorkOrderID: wo.ID, Parts: []models.WorkOrderPart{}, SkippedItems: []string{}}
+	var before models.BOQVersion
+	err = h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		now := time.Now().UTC()
+		v, err := store.CurrentBOQVersionTx(ctx, tx, tenant, projectID, middleware.UserID(ctx), now)
+		if err != nil {
+			return err
+		}
+		before = v
+		if err := requireBOQStatus(&v, models.BOQApproved); err != nil {
+			return err
+		}
+		if v.ConvertedAt != nil {
+			return stockStateError("boq was already converted to work order " + v.WorkOrderID)
+		}
+		for _, it := range v.Items {
+			if it.PartID == "" || it.Qty <= 0 {
+				out.SkippedItems = append(out.SkippedItems, it.ID)
+				continue
+			}
+			part, err := lk.PartByID(ctx, tenant, it.PartID)
+			switch {
+			case errors.Is(err, lookups.ErrNotFound):
+				return stockStateError("part " + it.PartID + " is not in the parts catalog")
+			case errors.Is(err, lookups.ErrSnapshotMissing):
+				return stockStateError("parts catalog not synced; sync ssot-parts first")
+			case err != nil:
+				return fmt.Errorf("look up part %s: %w", it.PartID, err)
+			}
+			item := models.WorkOrderPart{
+				ID:            store.NewID("bom"),
+				TenantID:      tenant,
+				SchoolID:      wo.SchoolID,
+				WorkOrderID:   wo.ID,
+				ServiceShopID: wo.ServiceShopID,
+				PartID:        it.PartID,
+				PartName:      getPartName(part),
+				PartPUK:       getPartPUK(part),
+				PartCategory:  getPartCategory(part),
+				DeviceModelID: wo.DeviceModelID,
+				IsCompatible:  true,
+				QtyPlanned:    it.Qty,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			mv := bomMovement(ctx, item, models.StockReservation, item.QtyPlanned)
+			mv.Notes = "boq " + projectID + " v" + strconv.Itoa(v.Version) + ", work order " + wo.ID
+			if _, err := store.PostStockMovementTx(ctx, tx, mv); err != nil {
+				return err
+			}
+			if err := store.CreateWorkOrderPartTx(ctx, tx, item); err != nil {
+				return err
+			}
+			if err := store.EnqueueEventTx(ctx, tx, bomChangedEvent(ctx, item, "added", item.QtyPlanned)); err != nil {
+				return err
+			}
+			out.Parts = append(out.Parts, item)
+		}
+		v.WorkOrderID = wo.ID
+		v.ConvertedAt = &now
+		v.UpdatedAt = now
+		out.Version = v
+		return store.UpdateBOQVersionTx(ctx, tx, v)
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, "convert boq")
+		return
+	}
+	if err := h.audit.LogUpdate(ctx, "boq_version", out.Version.ID, before, out.Version); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusCreated, out)
+}
+
+// installWorkOrder picks the work order a BOQ is converted onto: the one
+// requested, or the only work order in the project's install phase (or the
+// phase requested). It must have a service shop to reserve stock in.
+func (h *BOQHandler) installWorkOrder(w http.ResponseWriter, r *http.Request, project models.SchoolServiceProject, req convertBOQReq) (models.WorkOrder, bool) {
+	ctx := r.Context()
+	phaseID := strings.TrimSpace(req.PhaseID)
+	if phaseID == "" {
+		phases, _, err := h.pg.Phases().List(ctx, store.PhaseListParams{
+			TenantID: project.TenantID, ProjectID: project.ID, PhaseType: string(models.PhaseInstall), Limit: 2,
+		})
+		if err != nil {
+			h.log.Error("failed to list phases", zap.Error(err))
+			http.Error(w, "failed to convert boq", http.StatusInternalServerError)
+			return models.WorkOrder{}, false
+		}
+		if len(phases) != 1 {
+			http.Error(w, "project has no single install phase; phaseId required", http.StatusBadRequest)
+			return models.WorkOrder{}, false
+		}
+		phaseID = phases[0].ID
+	}
+
+	wos, _, err := h.pg.WorkOrders().ListByProject(ctx, store.ProjectWOListParams{
+		TenantID: project.TenantID, ProjectID: project.ID, PhaseID: phaseID, Limit: 200,
+	})
+	if err != nil {
+		h.log.Error("failed to list project work orders", zap.Error(err))
+		http.Error(w, "failed to convert boq", http.StatusInternalServerError)
+		return models.WorkOrder{}, false
+	}
+	var wo models.WorkOrder
+	if id := strings.TrimSpace(req.WorkOrderID); id != "" {
+		found := false
+		for _, x := range wos {
+			if x.ID == id {
+				wo, found = x, true
+			}
+		}
+		if !found {
+			http.Error(w, "work order not found in the install phase", http.StatusNotFound)
+			return wo, false
+		}
+	} else {
+		switch len(wos) {
+		case 0:
+			http.Error(w, "no work order in the install phase; create one first", http.StatusConflict)
+			return wo, false
+		case 1:
+			wo = wos[0]
+		default:
+			http.Error(w, "install phase has several work orders; workOrderId required", http.StatusBadRequest)
+			return wo, false
+		}
+	}
+	if strings.TrimSpace(wo.ServiceShopID) == "" {
+		http.Error(w, "work order has no serviceShopId", http.StatusBadRequest)
+		return wo, false
+	}
+	return wo, true
+}
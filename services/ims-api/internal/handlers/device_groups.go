@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Membership engine endpoints for dynamic and location groups. Membership of
+// those groups is computed from devices_snapshot and current assignments;
+// the scheduler re-evaluates them when devices, assignments or locations
+// change, including SSOT syncs.
+
+// previewGroupLimit caps the devices listed by a group preview.
+const previewGroupLimit = 200
+
+// reevaluateDevice re-checks a device against the tenant's automatic groups
+// after it moved or was registered. Failures are logged; the scheduler will
+// catch up on the next pass.
+func (h *DeviceInventoryHandler) reevaluateDevice(ctx context.Context, tenant, deviceID, reason string) {
+	groups, err := h.pg.Groups().ListAutomatic(ctx, tenant, "")
+	if err == nil {
+		_, err = service.EvaluateDevice(ctx, h.pg.Groups(), groups, tenant, deviceID, reason, time.Now().UTC())
+	}
+	if err != nil {
+		h.log.Warn("failed to re-evaluate device groups", zap.String("deviceId", deviceID), zap.Error(err))
+	}
+}
+
+// PreviewGroup shows which devices a group definition would match without
+// saving it.
+// POST /v1/groups/preview
+func (h *DeviceInventoryHandler) PreviewGroup(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+
+	var req createGroupReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	g := models.DeviceGroup{
+		TenantID:   tenant,
+		SchoolID:   req.SchoolID,
+		GroupType:  models.GroupType(strings.TrimSpace(req.GroupType)),
+		LocationID: req.LocationID,
+		Selector:   req.Selector,
+		Active:     true,
+	}
+	if !g.IsAutomatic() {
+		http.Error(w, "groupType must be dynamic or location", http.StatusBadRequest)
+		return
+	}
+	if err := service.ValidateGroup(g); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schoolID := ""
+	if g.SchoolID != nil {
+		schoolID = *g.SchoolID
+	}
+	candidates, err := h.pg.Groups().GroupCandidates(r.Context(), tenant, schoolID, nil)
+	if err != nil {
+		h.log.Error("failed to load group candidates", zap.Error(err))
+		http.Error(w, "failed to preview group", http.StatusInternalServerError)
+		return
+	}
+	matched := service.ResolveGroupMembers(g, candidates)
+	count := len(matched)
+	if len(matched) > previewGroupLimit {
+		matched = matched[:previewGroupLimit]
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": count, "items": matched})
+}
+
+// EvaluateGroup recomputes an automatic group's membership now.
+// POST /v1/groups/{id}/evaluate
+func (h *DeviceInventoryHandler) EvaluateGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	tenant := middleware.TenantID(r.Context())
+
+	group, err := h.pg.Groups().Get(r.Context(), tenant, id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if !group.IsAutomatic() {
+		http.Error(w, "only dynamic and location groups are evaluated", http.StatusConflict)
+		return
+	}
+	added, removed, err := service.EvaluateGroup(r.Context(), h.pg.Groups(), group, "manual_evaluate", time.Now().UTC())
+	if err != nil {
+		h.log.Error("failed to evaluate group", zap.String("groupId", id), zap.Error(err))
+		http.Error(w, "failed to evaluate group", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "added": added, "removed": removed})
+}
+
+// GetGroupHistory returns membership changes made by the engine.
+// GET /v1/groups/{id}/history
+func (h *DeviceInventoryHandler) GetGroupHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	tenant := middleware.TenantID(r.Context())
+	limit := parseLimit(r.URL.Query().Get("limit"), 50, 200)
+	offset := parseOffset(r.URL.Query().Get("offset"))
+
+	items, err := h.pg.Groups().ListMembershipEvents(r.Context(), tenant, id, limit, offset)
+	if err != nil {
+		h.log.Error("failed to get group history", zap.Error(err))
+		http.Error(w, "failed to get group history", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "limit": limit, "offset": offset})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -9,19 +10,21 @@ import (
 	"github.com/edvirons/ssp/ims/internal/audit"
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
 type DeviceInventoryHandler struct {
-	log   *zap.Logger
-	pg    *store.Postgres
-	audit audit.AuditLogger
+	log      *zap.Logger
+	pg       *store.Postgres
+	audit    audit.AuditLogger
+	registry service.DeviceRegistry // ssot-devices, for school registrations
 }
 
-func NewDeviceInventoryHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *DeviceInventoryHandler {
-	return &DeviceInventoryHandler{log: log, pg: pg, audit: auditLogger}
+func NewDeviceInventoryHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger, registry service.DeviceRegistry) *DeviceInventoryHandler {
+	return &DeviceInventoryHandler{log: log, pg: pg, audit: auditLogger, registry: registry}
 }
 
 // ---------- School Inventory ----------
@@ -404,6 +407,7 @@ func (h *DeviceInventoryHandler) AssignDevice(w http.ResponseWriter, r *http.Req
 	if err := h.audit.LogCreate(r.Context(), "device_assignment", assignment.ID, assignment); err != nil {
 		h.log.Error("failed to log device assignment audit", zap.Error(err))
 	}
+	h.reevaluateDevice(r.Context(), tenant, deviceID, "device_assigned")
 
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "assignment": assignment})
 }
@@ -432,6 +436,7 @@ func (h *DeviceInventoryHandler) UnassignDevice(w http.ResponseWriter, r *http.R
 			h.log.Error("failed to log device unassignment audit", zap.Error(err))
 		}
 	}
+	h.reevaluateDevice(r.Context(), tenant, deviceID, "device_unassigned")
 
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
@@ -498,6 +503,9 @@ func (h *DeviceInventoryHandler) CreateGroup(w http.ResponseWriter, r *http.Requ
 	if gType == "" {
 		gType = models.GroupTypeManual
 	}
+	if gType != models.GroupTypeDynamic {
+		req.Selector = nil
+	}
 
 	now := time.Now().UTC()
 	group := models.DeviceGroup{
@@ -513,6 +521,10 @@ func (h *DeviceInventoryHandler) CreateGroup(w http.ResponseWriter, r *http.Requ
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
+	if err := service.ValidateGroup(group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if err := h.pg.Groups().Create(r.Context(), group); err != nil {
 		h.log.Error("failed to create group", zap.Error(err))
@@ -525,6 +537,18 @@ func (h *DeviceInventoryHandler) CreateGroup(w http.ResponseWriter, r *http.Requ
 		h.log.Error("failed to log group creation audit", zap.Error(err))
 	}
 
+	// Populate automatic groups straight away rather than on the next
+	// scheduler pass.
+	if group.IsAutomatic() {
+		added, _, err := service.EvaluateGroup(r.Context(), h.pg.Groups(), group, "group_created", now)
+		if err != nil {
+			h.log.Warn("failed to evaluate new group", zap.String("groupId", group.ID), zap.Error(err))
+		} else {
+			group.EvaluatedAt = &now
+			group.MemberCount = len(added)
+		}
+	}
+
 	writeJSON(w, http.StatusCreated, group)
 }
 
@@ -578,6 +602,10 @@ func (h *DeviceInventoryHandler) AddGroupMembers(w http.ResponseWriter, r *http.
 	tenant := middleware.TenantID(r.Context())
 	userID := middleware.UserID(r.Context())
 
+	if !h.requireManualGroup(w, r, tenant, groupID) {
+		return
+	}
+
 	var req addGroupMembersReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -617,6 +645,10 @@ func (h *DeviceInventoryHandler) RemoveGroupMembers(w http.ResponseWriter, r *ht
 	groupID := chi.URLParam(r, "id")
 	tenant := middleware.TenantID(r.Context())
 
+	if !h.requireManualGroup(w, r, tenant, groupID) {
+		return
+	}
+
 	var req removeGroupMembersReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -647,6 +679,21 @@ func (h *DeviceInventoryHandler) RemoveGroupMembers(w http.ResponseWriter, r *ht
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "removed": count})
 }
 
+// requireManualGroup rejects hand edits to groups whose membership is
+// computed by the membership engine.
+func (h *DeviceInventoryHandler) requireManualGroup(w http.ResponseWriter, r *http.Request, tenant, groupID string) bool {
+	group, err := h.pg.Groups().Get(r.Context(), tenant, groupID)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return false
+	}
+	if group.IsAutomatic() {
+		http.Error(w, "membership of dynamic and location groups is computed", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
 // GetGroupDevices returns devices in a group
 // GET /v1/groups/{id}/devices
 func (h *DeviceInventoryHandler) GetGroupDevices(w http.ResponseWriter, r *http.Request) {
@@ -674,12 +721,12 @@ type registerDeviceReq struct {
 	LocationID *string `json:"locationId"`
 }
 
-// RegisterDevice registers a new device for a school
+// RegisterDevice registers a new device for a school. The device is
+// written to ssot-devices, which assigns its ID; see device_registrations.go.
 // POST /v1/schools/{schoolId}/devices
 func (h *DeviceInventoryHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
 	schoolID := chi.URLParam(r, "schoolId")
 	tenant := middleware.TenantID(r.Context())
-	userID := middleware.UserID(r.Context())
 
 	var req registerDeviceReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -698,88 +745,38 @@ func (h *DeviceInventoryHandler) RegisterDevice(w http.ResponseWriter, r *http.R
 		http.Error(w, "model is required", http.StatusBadRequest)
 		return
 	}
+	if req.LocationID != nil && *req.LocationID == "" {
+		req.LocationID = nil
+	}
 
-	// Create device ID
-	deviceID := store.NewID("dev")
 	now := time.Now().UTC()
-
-	// Create device in snapshot (school-registered device)
-	device := models.DeviceSnapshot{
-		DeviceID:  deviceID,
-		TenantID:  tenant,
-		SchoolID:  schoolID,
-		Serial:    serial,
-		AssetTag:  strings.TrimSpace(req.AssetTag),
-		Model:     model,
-		Status:    "active",
-		UpdatedAt: now,
+	reg := models.DeviceRegistration{
+		ID:          store.NewID("dreg"),
+		TenantID:    tenant,
+		SchoolID:    schoolID,
+		Serial:      serial,
+		AssetTag:    strings.TrimSpace(req.AssetTag),
+		Make:        strings.TrimSpace(req.Make),
+		Model:       model,
+		LocationID:  req.LocationID,
+		Notes:       strings.TrimSpace(req.Notes),
+		Status:      models.DeviceRegistrationPending,
+		RequestedBy: middleware.UserID(r.Context()),
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
-
-	if err := h.pg.DevicesSnapshot().Upsert(r.Context(), device); err != nil {
+	if err := h.pg.DeviceRegistrations().Create(r.Context(), reg); err != nil {
+		if errors.Is(err, store.ErrRegistrationPending) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		h.log.Error("failed to register device", zap.Error(err))
 		http.Error(w, "failed to register device", http.StatusInternalServerError)
 		return
 	}
-
-	// Audit log device creation
-	if err := h.audit.LogCreate(r.Context(), "device", deviceID, map[string]any{
-		"serial":    serial,
-		"assetTag":  req.AssetTag,
-		"model":     model,
-		"make":      req.Make,
-		"schoolId":  schoolID,
-		"source":    "school_registered",
-		"createdBy": userID,
-	}); err != nil {
-		h.log.Error("failed to log device creation audit", zap.Error(err))
-	}
-
-	// If location provided, create assignment
-	var assignment *models.DeviceAssignment
-	if req.LocationID != nil && *req.LocationID != "" {
-		assignment = &models.DeviceAssignment{
-			ID:             store.NewID("asn"),
-			TenantID:       tenant,
-			DeviceID:       deviceID,
-			LocationID:     req.LocationID,
-			AssignmentType: models.AssignmentTypePermanent,
-			Notes:          strings.TrimSpace(req.Notes),
-			CreatedBy:      userID,
-		}
-		if err := h.pg.Assignments().AssignDevice(r.Context(), *assignment); err != nil {
-			h.log.Error("failed to create initial assignment", zap.Error(err))
-			// Don't fail - device was created successfully
-		} else {
-			// Audit log assignment
-			if err := h.audit.LogCreate(r.Context(), "device_assignment", assignment.ID, assignment); err != nil {
-				h.log.Error("failed to log assignment audit", zap.Error(err))
-			}
-		}
-	}
-
-	// Build response
-	resp := models.InventoryDevice{
-		ID:        deviceID,
-		TenantID:  tenant,
-		Serial:    serial,
-		AssetTag:  strings.TrimSpace(req.AssetTag),
-		Model:     model,
-		Make:      strings.TrimSpace(req.Make),
-		SchoolID:  schoolID,
-		Lifecycle: "active",
-		UpdatedAt: now,
-	}
-
-	// Add location if assigned
-	if assignment != nil && req.LocationID != nil {
-		loc, err := h.pg.Locations().Get(r.Context(), tenant, *req.LocationID)
-		if err == nil {
-			resp.Location = &loc
-			if path, err := h.pg.Locations().GetPath(r.Context(), tenant, loc.ID); err == nil {
-				resp.LocationPath = path
-			}
-		}
+	if err := h.audit.LogCreate(r.Context(), "device_registration", reg.ID, reg); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
 	}
 
-	writeJSON(w, http.StatusCreated, resp)
+	h.submitRegistration(w, r, reg)
 }
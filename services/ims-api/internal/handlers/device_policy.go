@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/config"
+	"github.com/edvirons/ssp/ims/internal/mdm"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// DevicePolicyHandler manages group policies, resolves the effective policy
+// of a device and serves signed policy bundles to MDM agents.
+type DevicePolicyHandler struct {
+	log       *zap.Logger
+	pg        *store.Postgres
+	audit     audit.AuditLogger
+	signer    *mdm.Signer // nil when no signing key is configured
+	bundleTTL time.Duration
+}
+
+func NewDevicePolicyHandler(cfg config.Config, log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *DevicePolicyHandler {
+	h := &DevicePolicyHandler{
+		log:       log,
+		pg:        pg,
+		audit:     auditLogger,
+		bundleTTL: time.Duration(cfg.MDMPolicyBundleTTLMinutes) * time.Minute,
+	}
+	if cfg.MDMPolicySigningKey != "" {
+		signer, err := mdm.NewSigner(cfg.MDMPolicyKeyID, cfg.MDMPolicySigningKey)
+		if err != nil {
+			log.Error("invalid MDM_POLICY_SIGNING_KEY; policy bundles disabled", zap.Error(err))
+		} else {
+			h.signer = signer
+		}
+	} else {
+		log.Warn("MDM_POLICY_SIGNING_KEY not set; policy bundles disabled")
+	}
+	return h
+}
+
+// GetGroupPolicy returns a group's policy and its version.
+// GET /v1/groups/{id}/policy
+func (h *DevicePolicyHandler) GetGroupPolicy(w http.ResponseWriter, r *http.Request) {
+	g, err := h.pg.Groups().Get(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"groupId": g.ID, "policyVersion": g.PolicyVersion, "policy": g.Policies})
+}
+
+// SetGroupPolicy replaces a group's policy.
+// PUT /v1/groups/{id}/policy
+func (h *DevicePolicyHandler) SetGroupPolicy(w http.ResponseWriter, r *http.Request) {
+	var p models.DevicePolicy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	p, err := service.NormalizeDevicePolicy(p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.IsEmpty() {
+		http.Error(w, "policy is empty; use DELETE to clear it", http.StatusBadRequest)
+		return
+	}
+	h.savePolicy(w, r, &p)
+}
+
+// ClearGroupPolicy removes a group's policy. The cleared state is kept as a
+// new version.
+// DELETE /v1/groups/{id}/policy
+func (h *DevicePolicyHandler) ClearGroupPolicy(w http.ResponseWriter, r *http.Request) {
+	h.savePolicy(w, r, nil)
+}
+
+func (h *DevicePolicyHandler) savePolicy(w http.ResponseWriter, r *http.Request, p *models.DevicePolicy) {
+	id := chi.URLParam(r, "id")
+	tenant := middleware.TenantID(r.Context())
+
+	before, err := h.pg.Groups().Get(r.Context(), tenant, id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	g, err := h.pg.Groups().SetPolicy(r.Context(), tenant, id, p, middleware.UserID(r.Context()), time.Now().UTC())
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("failed to save group policy", zap.String("groupId", id), zap.Error(err))
+		http.Error(w, "failed to save group policy", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.audit.LogUpdate(r.Context(), "device_group_policy", id, before.Policies, g.Policies); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"groupId": g.ID, "policyVersion": g.PolicyVersion, "policy": g.Policies})
+}
+
+// ListGroupPolicyVersions returns a group's policy history.
+// GET /v1/groups/{id}/policy/versions
+func (h *DevicePolicyHandler) ListGroupPolicyVersions(w http.ResponseWriter, r *http.Request) {
+	limit := parseLimit(r.URL.Query().Get("limit"), 50, 200)
+	offset := parseOffset(r.URL.Query().Get("offset"))
+
+	items, err := h.pg.Groups().ListPolicyVersions(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"), limit, offset)
+	if err != nil {
+		h.log.Error("failed to list group policy versions", zap.Error(err))
+		http.Error(w, "failed to list group policy versions", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "limit": limit, "offset": offset})
+}
+
+// GetEffectivePolicy returns the merged policy of every group a device is
+// in, with the groups that contributed and any conflicts between them.
+// GET /v1/devices/{deviceId}/effective-policy
+func (h *DevicePolicyHandler) GetEffectivePolicy(w http.ResponseWriter, r *http.Request) {
+	ep, ok := h.resolve(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, ep)
+}
+
+// GetPolicyBundle returns the device's effective policy as a signed bundle
+// for the MDM agent.
+// GET /v1/mdm/devices/{deviceId}/policy-bundle
+func (h *DevicePolicyHandler) GetPolicyBundle(w http.ResponseWriter, r *http.Request) {
+	if h.signer == nil {
+		http.Error(w, "policy signing not configured", http.StatusServiceUnavailable)
+		return
+	}
+	ep, ok := h.resolve(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	payload, err := json.Marshal(models.PolicyBundle{
+		TenantID:   middleware.TenantID(r.Context()),
+		DeviceID:   ep.DeviceID,
+		IssuedAt:   now,
+		ExpiresAt:  now.Add(h.bundleTTL),
+		PolicyHash: ep.Hash,
+		Policy:     ep.Policy,
+		Sources:    ep.Sources,
+	})
+	if err != nil {
+		h.log.Error("failed to encode policy bundle", zap.Error(err))
+		http.Error(w, "failed to build policy bundle", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.signer.Sign(payload))
+}
+
+// GetSigningKey returns the public key agents verify bundles with.
+// GET /v1/mdm/policy-signing-key
+func (h *DevicePolicyHandler) GetSigningKey(w http.ResponseWriter, r *http.Request) {
+	if h.signer == nil {
+		http.Error(w, "policy signing not configured", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keyId":     h.signer.KeyID(),
+		"alg":       mdm.Algorithm,
+		"publicKey": h.signer.PublicKey(),
+	})
+}
+
+func (h *DevicePolicyHandler) resolve(w http.ResponseWriter, r *http.Request) (models.EffectivePolicy, bool) {
+	deviceID := chi.URLParam(r, "deviceId")
+	tenant := middleware.TenantID(r.Context())
+
+	if _, err := h.pg.DevicesSnapshot().Get(r.Context(), tenant, deviceID); err != nil {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return models.EffectivePolicy{}, false
+	}
+	groups, err := h.pg.Groups().GetGroupsForDevice(r.Context(), tenant, deviceID)
+	if err != nil {
+		h.log.Error("failed to load device groups", zap.String("deviceId", deviceID), zap.Error(err))
+		http.Error(w, "failed to resolve policy", http.StatusInternalServerError)
+		return models.EffectivePolicy{}, false
+	}
+	return service.ResolveEffectivePolicy(deviceID, groups, time.Now().UTC()), true
+}
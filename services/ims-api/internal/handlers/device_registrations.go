@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// School-registered devices are written back to ssot-devices, which owns
+// device IDs, serial uniqueness and the model catalog. Registration waits
+// briefly for ssot-devices: a confirmed device is added to devices_snapshot
+// under its SSOT ID, a rejection is returned to the school contact, and an
+// unreachable SSOT leaves the registration pending for the scheduler to
+// retry.
+
+// registrationSubmitTimeout bounds how long a request waits for ssot-devices.
+const registrationSubmitTimeout = 10 * time.Second
+
+// submitRegistration sends a pending registration to ssot-devices and
+// writes the response: 201 with the device when confirmed, 422 with the
+// registration when rejected, 202 when it is still pending.
+func (h *DeviceInventoryHandler) submitRegistration(w http.ResponseWriter, r *http.Request, reg models.DeviceRegistration) {
+	ctx, cancel := context.WithTimeout(r.Context(), registrationSubmitTimeout)
+	defer cancel()
+
+	out, err := service.SubmitDeviceRegistration(ctx, h.pg.DeviceRegistrations(), h.registry, reg, time.Now().UTC())
+	if err != nil {
+		// The registration stays pending and is retried by the scheduler.
+		h.log.Error("failed to record device registration outcome", zap.String("registrationId", reg.ID), zap.Error(err))
+		writeJSON(w, http.StatusAccepted, reg)
+		return
+	}
+
+	switch out.Status {
+	case models.DeviceRegistrationConfirmed:
+		h.registrationConfirmed(r.Context(), out)
+		writeJSON(w, http.StatusCreated, h.registeredDevice(r.Context(), out))
+	case models.DeviceRegistrationRejected:
+		writeJSON(w, http.StatusUnprocessableEntity, out)
+	default:
+		writeJSON(w, http.StatusAccepted, out)
+	}
+}
+
+// registrationConfirmed audits the new device and places it in automatic
+// groups.
+func (h *DeviceInventoryHandler) registrationConfirmed(ctx context.Context, reg models.DeviceRegistration) {
+	if err := h.audit.LogCreate(ctx, "device", reg.DeviceID, map[string]any{
+		"serial":         reg.Serial,
+		"assetTag":       reg.AssetTag,
+		"model":          reg.Model,
+		"make":           reg.Make,
+		"schoolId":       reg.SchoolID,
+		"source":         "school_registered",
+		"registrationId": reg.ID,
+		"createdBy":      reg.RequestedBy,
+	}); err != nil {
+		h.log.Error("failed to log device creation audit", zap.Error(err))
+	}
+	h.reevaluateDevice(ctx, reg.TenantID, reg.DeviceID, "device_registered")
+}
+
+func (h *DeviceInventoryHandler) registeredDevice(ctx context.Context, reg models.DeviceRegistration) models.InventoryDevice {
+	d := models.InventoryDevice{
+		ID:        reg.DeviceID,
+		TenantID:  reg.TenantID,
+		Serial:    reg.Serial,
+		AssetTag:  reg.AssetTag,
+		Model:     reg.Model,
+		Make:      reg.Make,
+		SchoolID:  reg.SchoolID,
+		Lifecycle: "deployed",
+		UpdatedAt: reg.UpdatedAt,
+	}
+	if snap, err := h.pg.DevicesSnapshot().Get(ctx, reg.TenantID, reg.DeviceID); err == nil {
+		d.Model, d.Lifecycle, d.UpdatedAt = snap.Model, snap.Status, snap.UpdatedAt
+	}
+	if reg.LocationID != nil {
+		if loc, err := h.pg.Locations().Get(ctx, reg.TenantID, *reg.LocationID); err == nil {
+			d.Location = &loc
+			if path, err := h.pg.Locations().GetPath(ctx, reg.TenantID, loc.ID); err == nil {
+				d.LocationPath = path
+			}
+		}
+	}
+	return d
+}
+
+// ListDeviceRegistrations returns a school's device registrations, so
+// pending and rejected ones can be followed up.
+// GET /v1/schools/{schoolId}/device-registrations?status=
+func (h *DeviceInventoryHandler) ListDeviceRegistrations(w http.ResponseWriter, r *http.Request) {
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	switch models.DeviceRegistrationStatus(status) {
+	case "", models.DeviceRegistrationPending, models.DeviceRegistrationConfirmed, models.DeviceRegistrationRejected:
+	default:
+		http.Error(w, "status must be pending, confirmed or rejected", http.StatusBadRequest)
+		return
+	}
+	limit := parseLimit(r.URL.Query().Get("limit"), 50, 200)
+	offset := parseOffset(r.URL.Query().Get("offset"))
+
+	items, err := h.pg.DeviceRegistrations().ListBySchool(r.Context(), middleware.TenantID(r.Context()),
+		chi.URLParam(r, "schoolId"), status, limit, offset)
+	if err != nil {
+		h.log.Error("failed to list device registrations", zap.Error(err))
+		http.Error(w, "failed to list device registrations", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "limit": limit, "offset": offset})
+}
+
+// GetDeviceRegistration returns one registration.
+// GET /v1/device-registrations/{id}
+func (h *DeviceInventoryHandler) GetDeviceRegistration(w http.ResponseWriter, r *http.Request) {
+	reg, err := h.pg.DeviceRegistrations().Get(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, reg)
+}
+
+type resubmitRegistrationReq struct {
+	Serial   *string `json:"serial"`
+	AssetTag *string `json:"assetTag"`
+	Make     *string `json:"make"`
+	Model    *string `json:"model"`
+}
+
+// ResubmitDeviceRegistration corrects a rejected registration and sends it
+// to ssot-devices again.
+// POST /v1/device-registrations/{id}/resubmit
+func (h *DeviceInventoryHandler) ResubmitDeviceRegistration(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	reg, err := h.pg.DeviceRegistrations().Get(r.Context(), tenant, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if reg.Status != models.DeviceRegistrationRejected {
+		http.Error(w, "only rejected registrations can be resubmitted", http.StatusConflict)
+		return
+	}
+
+	var req resubmitRegistrationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Serial != nil {
+		reg.Serial = strings.TrimSpace(*req.Serial)
+	}
+	if req.AssetTag != nil {
+		reg.AssetTag = strings.TrimSpace(*req.AssetTag)
+	}
+	if req.Make != nil {
+		reg.Make = strings.TrimSpace(*req.Make)
+	}
+	if req.Model != nil {
+		reg.Model = strings.TrimSpace(*req.Model)
+	}
+	if reg.Serial == "" || reg.Model == "" {
+		http.Error(w, "serial and model are required", http.StatusBadRequest)
+		return
+	}
+
+	before := reg
+	reg, err = h.pg.DeviceRegistrations().Resubmit(r.Context(), reg, time.Now().UTC())
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrRegistrationPending):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err.Error() == "not found":
+			http.Error(w, "only rejected registrations can be resubmitted", http.StatusConflict)
+		default:
+			h.log.Error("failed to resubmit device registration", zap.Error(err))
+			http.Error(w, "failed to resubmit device registration", http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := h.audit.LogUpdate(r.Context(), "device_registration", reg.ID, before, reg); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+
+	h.submitRegistration(w, r, reg)
+}
@@ -25,20 +25,10 @@ type EdTechProfilesHandler struct {
 
 // NewEdTechProfilesHandler creates a new handler
 func NewEdTechProfilesHandler(log *zap.Logger, pg *store.Postgres, cfg config.Config) *EdTechProfilesHandler {
-	var claudeClient *claude.Client
-	if cfg.ClaudeAPIKey != "" {
-		claudeClient = claude.NewClient(claude.ClientConfig{
-			APIKey:         cfg.ClaudeAPIKey,
-			Model:          cfg.ClaudeModel,
-			MaxTokens:      cfg.ClaudeMaxTokens,
-			TimeoutSeconds: cfg.ClaudeTimeoutSeconds,
-		}, log)
-	}
-
 	return &EdTechProfilesHandler{
 		log:    log,
 		pg:     pg,
-		claude: claudeClient,
+		claude: newAIClient(cfg, log),
 		cfg:    cfg,
 	}
 }
@@ -173,7 +163,7 @@ func (h *EdTechProfilesHandler) GenerateAI(w http.ResponseWriter, r *http.Reques
 	tenantID := middleware.TenantID(r.Context())
 	profileID := chi.URLParam(r, "id")
 
-	if h.claude == nil || !h.claude.IsEnabled() {
+	if !h.claude.IsEnabled() {
 		http.Error(w, "AI is not available", http.StatusServiceUnavailable)
 		return
 	}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+)
+
+// incidentEvent builds an outbox event for an incident.
+func incidentEvent(t models.EventType, inc models.Incident, actorID string, data any) models.DomainEvent {
+	return models.DomainEvent{
+		Type:          t,
+		TenantID:      inc.TenantID,
+		SchoolID:      inc.SchoolID,
+		AggregateType: "incident",
+		AggregateID:   inc.ID,
+		ActorID:       actorID,
+		Data:          data,
+	}
+}
+
+// workOrderEvent builds an outbox event for a work order.
+func workOrderEvent(t models.EventType, tenantID, schoolID, workOrderID, actorID string, data any) models.DomainEvent {
+	return models.DomainEvent{
+		Type:          t,
+		TenantID:      tenantID,
+		SchoolID:      schoolID,
+		AggregateType: "work_order",
+		AggregateID:   workOrderID,
+		ActorID:       actorID,
+		Data:          data,
+	}
+}
+
+// createWorkOrderWithEvent inserts a work order and its created event atomically.
+func createWorkOrderWithEvent(ctx context.Context, pg *store.Postgres, wo models.WorkOrder, actorID string) error {
+	return pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		if err := store.CreateWorkOrderTx(ctx, tx, wo); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, workOrderEvent(models.EventWorkOrderCreated, wo.TenantID, wo.SchoolID, wo.ID, actorID, wo))
+	})
+}
+
+// createIncidentWithEvent inserts an incident and its created event atomically.
+func createIncidentWithEvent(ctx context.Context, pg *store.Postgres, inc models.Incident, actorID string) error {
+	return pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		if err := store.CreateIncidentTx(ctx, tx, inc); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, incidentEvent(models.EventIncidentCreated, inc, actorID, inc))
+	})
+}
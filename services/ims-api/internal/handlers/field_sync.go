@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"go.uber.org/zap"
+)
+
+// maxFieldMutations caps the number of mutations in one push.
+const maxFieldMutations = 200
+
+// errFieldMutationNotApplied rolls back a mutation transaction whose result
+// is a conflict or rejection; the result is then recorded on its own.
+var errFieldMutationNotApplied = errors.New("field mutation not applied")
+
+// errFieldMutationDuplicate rolls back a mutation whose idempotency key was
+// recorded by a concurrent push.
+var errFieldMutationDuplicate = errors.New("field mutation already recorded")
+
+// FieldSyncHandler serves the offline-first field technician API: a pull of
+// the caller's assigned work orders and a push of mutations queued offline.
+type FieldSyncHandler struct {
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
+}
+
+func NewFieldSyncHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *FieldSyncHandler {
+	return &FieldSyncHandler{log: log, pg: pg, audit: auditLogger}
+}
+
+// Pull returns the caller's open work orders with their BOM, schedules,
+// deliverables and the school and device snapshots they reference. Pass the
+// previous response's cursor as since to receive only changes.
+func (h *FieldSyncHandler) Pull(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if raw := strings.TrimSpace(r.URL.Query().Get("since")); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	now := time.Now().UTC()
+	repo := h.pg.FieldSync()
+
+	assigned, err := repo.AssignedWorkOrders(ctx, tenant, middleware.UserID(ctx))
+	if err != nil {
+		h.log.Error("failed to load assigned work orders", zap.Error(err))
+		http.Error(w, "failed to sync", http.StatusInternalServerError)
+		return
+	}
+
+	out := models.FieldSyncPull{
+		ServerTime:  now,
+		Cursor:      now.Format(time.RFC3339Nano),
+		AssignedIDs: []string{},
+		WorkOrders:  []models.WorkOrder{},
+	}
+	// Work orders changed since the cursor, including newly assigned ones,
+	// are sent in full along with all of their children and snapshots.
+	var changedIDs, schoolIDs, deviceIDs, changedSchools, changedDevices []string
+	seenSchool, seenDevice := map[string]bool{}, map[string]bool{}
+	for _, wo := range assigned {
+		out.AssignedIDs = append(out.AssignedIDs, wo.ID)
+		changed := wo.UpdatedAt.After(since)
+		if changed {
+			out.WorkOrders = append(out.WorkOrders, wo)
+			changedIDs = append(changedIDs, wo.ID)
+			changedSchools = append(changedSchools, wo.SchoolID)
+			if wo.DeviceID != "" {
+				changedDevices = append(changedDevices, wo.DeviceID)
+			}
+		}
+		if !seenSchool[wo.SchoolID] {
+			seenSchool[wo.SchoolID] = true
+			schoolIDs = append(schoolIDs, wo.SchoolID)
+		}
+		if wo.DeviceID != "" && !seenDevice[wo.DeviceID] {
+			seenDevice[wo.DeviceID] = true
+			deviceIDs = append(deviceIDs, wo.DeviceID)
+		}
+	}
+
+	if err := h.loadChildren(ctx, tenant, since, &out, changedIDs, schoolIDs, changedSchools, deviceIDs, changedDevices); err != nil {
+		h.log.Error("failed to load field sync data", zap.Error(err))
+		http.Error(w, "failed to sync", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// loadChildren fills in the BOM, schedules, deliverables and snapshots for
+// the assigned work orders in out.
+func (h *FieldSyncHandler) loadChildren(ctx context.Context, tenant string, since time.Time, out *models.FieldSyncPull,
+	changedIDs, schoolIDs, changedSchools, deviceIDs, changedDevices []string) error {
+	repo := h.pg.FieldSync()
+	var err error
+	if out.BOM, err = repo.PartsByWorkOrders(ctx, tenant, out.AssignedIDs, changedIDs, since); err != nil {
+		return err
+	}
+	if out.Schedules, err = repo.SchedulesByWorkOrders(ctx, tenant, out.AssignedIDs, changedIDs, since); err != nil {
+		return err
+	}
+	if out.Deliverables, err = repo.DeliverablesByWorkOrders(ctx, tenant, out.AssignedIDs, changedIDs, since); err != nil {
+		return err
+	}
+	if out.Schools, err = repo.SchoolsByIDs(ctx, tenant, schoolIDs, changedSchools, since); err != nil {
+		return err
+	}
+	out.Devices, err = repo.DevicesByIDs(ctx, tenant, deviceIDs, changedDevices, since)
+	return err
+}
+
+type fieldPushReq struct {
+	DeviceID  string                 `json:"deviceId"`
+	Mutations []models.FieldMutation `json:"mutations"`
+}
+
+// Push applies a batch of offline mutations in order and returns a result
+// per mutation. Mutations are independent: a conflict or rejection does not
+// stop the rest of the batch.
+func (h *FieldSyncHandler) Push(w http.ResponseWriter, r *http.Request) {
+	var req fieldPushReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if len(req.Mutations) == 0 || len(req.Mutations) > maxFieldMutations {
+		http.Error(w, "mutations must contain 1 to 200 items", http.StatusBadRequest)
+		return
+	}
+
+	results := make([]models.FieldMutationResult, 0, len(req.Mutations))
+	for _, m := range req.Mutations {
+		results = append(results, h.applyMutation(r.Context(), strings.TrimSpace(req.DeviceID), m))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": results, "serverTime": time.Now().UTC()})
+}
+
+// fieldChange describes an applied mutation for the audit trail.
+type fieldChange struct {
+	entityType string
+	entityID   string
+	before     any
+}
+
+func (h *FieldSyncHandler) applyMutation(ctx context.Context, deviceID string, m models.FieldMutation) models.FieldMutationResult {
+	tenant := middleware.TenantID(ctx)
+	userID := middleware.UserID(ctx)
+	m.IdempotencyKey = strings.TrimSpace(m.IdempotencyKey)
+	m.WorkOrderID = strings.TrimSpace(m.WorkOrderID)
+	key := m.IdempotencyKey
+
+	if err := service.ValidateFieldMutation(m); err != nil {
+		return models.FieldMutationResult{IdempotencyKey: key, Status: models.FieldMutationRejected, Error: err.Error()}
+	}
+	if res, ok := h.replay(ctx, tenant, userID, key); ok {
+		return res
+	}
+
+	now := time.Now().UTC()
+	var res models.FieldMutationResult
+	var change fieldChange
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		wo, err := store.GetAssignedWorkOrderTx(ctx, tx, tenant, userID, m.WorkOrderID)
+		if err != nil {
+			if err.Error() != "not found" {
+				return err
+			}
+			res = fieldRejected("work order is not assigned to you")
+			return errFieldMutationNotApplied
+		}
+		switch m.Type {
+		case models.FieldMutationWorkOrderStatus:
+			res, change, err = h.applyStatus(ctx, tx, wo, m, now)
+		case models.FieldMutationBOMConsume:
+			res, change, err = h.applyConsume(ctx, tx, wo, m, now)
+		case models.FieldMutationDeliverableSubmit:
+			res, change, err = h.applyDeliverable(ctx, tx, wo, m, now)
+		}
+		if err != nil {
+			return err
+		}
+		if res.Status != models.FieldMutationApplied {
+			return errFieldMutationNotApplied
+		}
+		res.IdempotencyKey = key
+		recorded, err := store.RecordFieldMutationTx(ctx, tx, tenant, userID, deviceID, m, res, now)
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			return errFieldMutationDuplicate
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		if err := h.audit.LogUpdate(ctx, change.entityType, change.entityID, change.before, res.Entity); err != nil {
+			h.log.Error("failed to write audit log", zap.Error(err))
+		}
+		return res
+	case errors.Is(err, errFieldMutationDuplicate):
+		if res, ok := h.replay(ctx, tenant, userID, key); ok {
+			return res
+		}
+	case errors.Is(err, errFieldMutationNotApplied):
+		res.IdempotencyKey = key
+		recorded, rerr := store.RecordFieldMutationTx(ctx, h.pg.RawPool(), tenant, userID, deviceID, m, res, now)
+		if rerr != nil {
+			err = rerr
+			break
+		}
+		if recorded {
+			return res
+		}
+		if prev, ok := h.replay(ctx, tenant, userID, key); ok {
+			return prev
+		}
+	}
+	h.log.Error("failed to apply field mutation", zap.String("idempotencyKey", key), zap.Error(err))
+	return models.FieldMutationResult{IdempotencyKey: key, Status: models.FieldMutationFailed, Error: "temporary failure, retry"}
+}
+
+// replay returns the stored result for a key the user already processed.
+func (h *FieldSyncHandler) replay(ctx context.Context, tenant, userID, key string) (models.FieldMutationResult, bool) {
+	res, err := h.pg.FieldSync().GetFieldMutationResult(ctx, tenant, userID, key)
+	if err != nil {
+		if err.Error() != "not found" {
+			h.log.Warn("failed to load field mutation result", zap.String("idempotencyKey", key), zap.Error(err))
+		}
+		return models.FieldMutationResult{}, false
+	}
+	res.Replayed = true
+	return res, true
+}
+
+func fieldRejected(msg string) models.FieldMutationResult {
+	return models.FieldMutationResult{Status: models.FieldMutationRejected, Error: msg}
+}
+
+func fieldConflict(current any) models.FieldMutationResult {
+	return models.FieldMutationResult{Status: models.FieldMutationConflict, Error: "changed on the server since it was last synced", Entity: current}
+}
+
+func (h *FieldSyncHandler) applyStatus(ctx context.Context, tx store.Tx, wo models.WorkOrder, m models.FieldMutation, now time.Time) (models.FieldMutationResult, fieldChange, error) {
+	var p models.FieldStatusPayload
+	_ = json.Unmarshal(m.Payload, &p) // validated
+	if service.FieldSyncConflict(m.BaseUpdatedAt, wo.UpdatedAt) {
+		return fieldConflict(wo), fieldChange{}, nil
+	}
+	change := fieldChange{entityType: "work_order", entityID: wo.ID, before: wo}
+	if wo.Status == p.Status {
+		return models.FieldMutationResult{Status: models.FieldMutationApplied, Entity: wo}, change, nil
+	}
+
+	wf := loadWorkflow(ctx, h.log, h.pg, wo.TenantID, models.WorkflowWorkOrder)
+	if err := service.CheckTransition(wf, string(wo.Status), string(p.Status), middleware.Roles(ctx), false, workOrderGuards(ctx, h.pg, wo)); err != nil {
+		var te *service.TransitionError
+		if errors.As(err, &te) {
+			return fieldRejected(te.Message), fieldChange{}, nil
+		}
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	if err := store.UpdateWorkOrderStatusTx(ctx, tx, wo.TenantID, wo.SchoolID, wo.ID, p.Status, now); err != nil {
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	if err := store.EnqueueEventTx(ctx, tx, workOrderEvent(models.EventWorkOrderStatusChanged, wo.TenantID, wo.SchoolID, wo.ID,
+		middleware.UserID(ctx), models.StatusChangedData{From: string(wo.Status), To: string(p.Status)})); err != nil {
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	updated := wo
+	updated.Status = p.Status
+	updated.UpdatedAt = now
+	return models.FieldMutationResult{Status: models.FieldMutationApplied, Entity: updated}, change, nil
+}
+
+func (h *FieldSyncHandler) applyConsume(ctx context.Context, tx store.Tx, wo models.WorkOrder, m models.FieldMutation, now time.Time) (models.FieldMutationResult, fieldChange, error) {
+	var p models.FieldConsumePayload
+	_ = json.Unmarshal(m.Payload, &p) // validated
+	item, err := store.GetWorkOrderPartTx(ctx, tx, wo.TenantID, wo.SchoolID, strings.TrimSpace(p.ItemID))
+	if err != nil {
+		if err.Error() == "not found" {
+			return fieldRejected("bom item not found"), fieldChange{}, nil
+		}
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	if item.WorkOrderID != wo.ID {
+		return fieldRejected("bom item does not belong to work order"), fieldChange{}, nil
+	}
+	if service.FieldSyncConflict(m.BaseUpdatedAt, item.UpdatedAt) {
+		return fieldConflict(item), fieldChange{}, nil
+	}
+	if item.QtyPlanned-item.QtyUsed < p.QtyUsed {
+		return fieldRejected("qtyUsed exceeds the remaining planned quantity"), fieldChange{}, nil
+	}
+
+	if err := store.UpdateWorkOrderPartUsedTx(ctx, tx, item.TenantID, item.SchoolID, item.ID, p.QtyUsed, now); err != nil {
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	if _, err := store.PostStockMovementTx(ctx, tx, bomMovement(ctx, item, models.StockIssue, p.QtyUsed)); err != nil {
+		if errors.Is(err, store.ErrInsufficientStock) || errors.Is(err, store.ErrInvalidMovement) {
+			return fieldRejected(err.Error()), fieldChange{}, nil
+		}
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	if err := store.EnqueueEventTx(ctx, tx, bomChangedEvent(ctx, item, "consumed", p.QtyUsed)); err != nil {
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	updated := item
+	updated.QtyUsed += p.QtyUsed
+	updated.UpdatedAt = now
+	change := fieldChange{entityType: "work_order_part", entityID: item.ID, before: item}
+	return models.FieldMutationResult{Status: models.FieldMutationApplied, Entity: updated}, change, nil
+}
+
+func (h *FieldSyncHandler) applyDeliverable(ctx context.Context, tx store.Tx, wo models.WorkOrder, m models.FieldMutation, now time.Time) (models.FieldMutationResult, fieldChange, error) {
+	var p models.FieldDeliverablePayload
+	_ = json.Unmarshal(m.Payload, &p) // validated
+	d, err := store.GetDeliverableTx(ctx, tx, wo.TenantID, wo.SchoolID, strings.TrimSpace(p.DeliverableID))
+	if err != nil {
+		if err.Error() == "not found" {
+			return fieldRejected("deliverable not found"), fieldChange{}, nil
+		}
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	if d.WorkOrderID != wo.ID {
+		return fieldRejected("deliverable does not belong to work order"), fieldChange{}, nil
+	}
+	if service.FieldSyncConflict(m.BaseUpdatedAt, d.UpdatedAt) {
+		return fieldConflict(d), fieldChange{}, nil
+	}
+	if d.Status == models.DeliverableApproved {
+		return fieldRejected("deliverable already approved"), fieldChange{}, nil
+	}
+
+	userID := middleware.UserID(ctx)
+	evidence, notes := strings.TrimSpace(p.EvidenceAttachmentID), strings.TrimSpace(p.Notes)
+	if err := store.MarkDeliverableSubmittedTx(ctx, tx, d.TenantID, d.SchoolID, d.ID, userID, evidence, notes, now); err != nil {
+		return models.FieldMutationResult{}, fieldChange{}, err
+	}
+	updated := d
+	updated.Status = models.DeliverableSubmitted
+	updated.EvidenceAttachmentID = evidence
+	updated.SubmittedByUserID = userID
+	updated.SubmittedAt = &now
+	updated.Description = notes
+	updated.UpdatedAt = now
+	change := fieldChange{entityType: "work_order_deliverable", entityID: d.ID, before: d}
+	return models.FieldMutationResult{Status: models.FieldMutationApplied, Entity: updated}, change, nil
+}
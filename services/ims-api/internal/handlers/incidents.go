@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -62,107 +63,27 @@ func (h *IncidentHandler) Create(w http.ResponseWriter, r *http.Request) {
 	dv, _ := lk.DeviceByID(r.Context(), tenant, strings.TrimSpace(req.DeviceID))
 
 	inc := models.Incident{
-		ID:       store.NewID("inc"),
-		TenantID: tenant,
-		SchoolID: school,
-		DeviceID: strings.TrimSpace(req.DeviceID),
-		SchoolName: func() string {
-			if sc != nil {
-				return sc.Name
-			}
-			return ""
-		}(),
-		CountyID: func() string {
-			if sc != nil {
-				return sc.CountyID
-			}
-			return ""
-		}(),
-		CountyName: func() string {
-			if sc != nil {
-				return sc.CountyName
-			}
-			return ""
-		}(),
-		SubCountyID: func() string {
-			if sc != nil {
-				return sc.SubCountyID
-			}
-			return ""
-		}(),
-		SubCountyName: func() string {
-			if sc != nil {
-				return sc.SubCountyName
-			}
-			return ""
-		}(),
-		ContactName: func() string {
-			if pc != nil {
-				return pc.Name
-			}
-			return ""
-		}(),
-		ContactPhone: func() string {
-			if pc != nil {
-				return pc.Phone
-			}
-			return ""
-		}(),
-		ContactEmail: func() string {
-			if pc != nil {
-				return pc.Email
-			}
-			return ""
-		}(),
-		DeviceSerial: func() string {
-			if dv != nil {
-				return dv.Serial
-			}
-			return ""
-		}(),
-		DeviceAssetTag: func() string {
-			if dv != nil {
-				return dv.AssetTag
-			}
-			return ""
-		}(),
-		DeviceModelID: func() string {
-			if dv != nil {
-				return dv.ModelID
-			}
-			return ""
-		}(),
-		DeviceMake: func() string {
-			if dv != nil {
-				return dv.Make
-			}
-			return ""
-		}(),
-		DeviceModel: func() string {
-			if dv != nil {
-				return dv.Model
-			}
-			return ""
-		}(),
-		DeviceCategory: func() string {
-			if dv != nil {
-				return dv.Category
-			}
-			return ""
-		}(),
+		ID:          store.NewID("inc"),
+		TenantID:    tenant,
+		SchoolID:    school,
+		DeviceID:    strings.TrimSpace(req.DeviceID),
 		Category:    strings.TrimSpace(req.Category),
 		Severity:    req.Severity,
 		Status:      models.IncidentNew,
 		Title:       strings.TrimSpace(req.Title),
 		Description: strings.TrimSpace(req.Description),
 		ReportedBy:  strings.TrimSpace(req.ReportedBy),
-		SLADueAt:    service.SLADue(req.Severity, now),
 		SLABreached: false,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 
-	if err := h.pg.Incidents().Create(r.Context(), inc); err != nil {
+	schoolLevel := enrichIncident(&inc, sc, pc, dv)
+
+	// Resolve SLA deadlines from tenant policy (falls back to built-in defaults)
+	applySLA(r.Context(), h.log, h.pg, &inc, schoolLevel, now)
+
+	if err := createIncidentWithEvent(r.Context(), h.pg, inc, middleware.UserID(r.Context())); err != nil {
 		http.Error(w, "failed to create incident", http.StatusInternalServerError)
 		return
 	}
@@ -173,53 +94,88 @@ func (h *IncidentHandler) Create(w http.ResponseWriter, r *http.Request) {
 		// Don't fail the request if audit logging fails
 	}
 
-	// Optional: auto-route to service shop (sub-county first, fallback to county) -> create work order
+	// Optional: auto-route to service shop -> create work order
 	if h.cfg.AutoRouteWorkOrders {
-		// Resolve school's geography from SSOT snapshot cache
-		sp, err := h.pg.SchoolsSnapshot().Get(r.Context(), tenant, school)
-		if err == nil {
-			var shop models.ServiceShop
-			// Try sub-county coverage first
-			if strings.TrimSpace(sp.SubCountyCode) != "" && strings.TrimSpace(sp.CountyCode) != "" {
-				shop, err = h.pg.ServiceShops().GetBySubCounty(r.Context(), tenant, strings.TrimSpace(sp.CountyCode), strings.TrimSpace(sp.SubCountyCode))
-			}
-			// Fallback to county coverage
-			if err != nil || shop.ID == "" {
-				if strings.TrimSpace(sp.CountyCode) != "" {
-					shop, err = h.pg.ServiceShops().GetByCounty(r.Context(), tenant, strings.TrimSpace(sp.CountyCode))
-				}
-			}
-			if err == nil && shop.ID != "" {
-				// Assign to lead technician if available
-				lead, _ := h.pg.ServiceStaff().GetLeadByShop(r.Context(), tenant, shop.ID)
-				rl := models.RepairLocation(h.cfg.DefaultRepairLocation)
-				if rl == "" {
-					rl = models.RepairLocationServiceShop
-				}
-				wo := models.WorkOrder{
-					ID:                store.NewID("wo"),
-					IncidentID:        inc.ID,
-					TenantID:          tenant,
-					SchoolID:          school,
-					DeviceID:          inc.DeviceID,
-					Status:            models.WorkOrderDraft,
-					ServiceShopID:     shop.ID,
-					AssignedStaffID:   lead.ID,
-					RepairLocation:    rl,
-					AssignedTo:        lead.UserID,
-					TaskType:          "triage",
-					CostEstimateCents: 0,
-					Notes:             "Auto-created from incident " + inc.ID,
-					CreatedAt:         now,
-					UpdatedAt:         now,
-				}
-				_ = h.pg.WorkOrders().Create(r.Context(), wo)
-			}
-		}
+		_, _ = autoRouteWorkOrder(r.Context(), h.cfg, h.pg, inc, middleware.UserID(r.Context()))
 	}
 	writeJSON(w, http.StatusOK, inc)
 }
 
+// enrichIncident copies the SSOT snapshot details of the school, its
+// primary contact and the device onto an incident, skipping any that were
+// not found. It returns the school's level for SLA resolution.
+func enrichIncident(inc *models.Incident, sc *lookups.SchoolSummary, pc *lookups.ContactSummary, dv *lookups.DeviceSummary) string {
+	level := ""
+	if sc != nil {
+		inc.SchoolName = sc.Name
+		inc.CountyID, inc.CountyName = sc.CountyID, sc.CountyName
+		inc.SubCountyID, inc.SubCountyName = sc.SubCountyID, sc.SubCountyName
+		level = sc.Level
+	}
+	if pc != nil {
+		inc.ContactName, inc.ContactPhone, inc.ContactEmail = pc.Name, pc.Phone, pc.Email
+	}
+	if dv != nil {
+		inc.DeviceSerial, inc.DeviceAssetTag, inc.DeviceModelID = dv.Serial, dv.AssetTag, dv.ModelID
+		inc.DeviceMake, inc.DeviceModel, inc.DeviceCategory = dv.Make, dv.Model, dv.Category
+	}
+	return level
+}
+
+// autoRouteWorkOrder opens a triage work order for an incident at the
+// service shop covering its school (sub-county first, fallback to county),
+// assigned to the shop's lead technician if it has one. ok is false when no
+// shop covers the school or the work order could not be created.
+func autoRouteWorkOrder(ctx context.Context, cfg config.Config, pg *store.Postgres, inc models.Incident, actorID string) (models.WorkOrder, bool) {
+	// Resolve school's geography from SSOT snapshot cache
+	sp, err := pg.SchoolsSnapshot().Get(ctx, inc.TenantID, inc.SchoolID)
+	if err != nil {
+		return models.WorkOrder{}, false
+	}
+	var shop models.ServiceShop
+	// Try sub-county coverage first
+	if strings.TrimSpace(sp.SubCountyCode) != "" && strings.TrimSpace(sp.CountyCode) != "" {
+		shop, err = pg.ServiceShops().GetBySubCounty(ctx, inc.TenantID, strings.TrimSpace(sp.CountyCode), strings.TrimSpace(sp.SubCountyCode))
+	}
+	// Fallback to county coverage
+	if err != nil || shop.ID == "" {
+		if strings.TrimSpace(sp.CountyCode) != "" {
+			shop, err = pg.ServiceShops().GetByCounty(ctx, inc.TenantID, strings.TrimSpace(sp.CountyCode))
+		}
+	}
+	if err != nil || shop.ID == "" {
+		return models.WorkOrder{}, false
+	}
+	// Assign to lead technician if available
+	lead, _ := pg.ServiceStaff().GetLeadByShop(ctx, inc.TenantID, shop.ID)
+	rl := models.RepairLocation(cfg.DefaultRepairLocation)
+	if rl == "" {
+		rl = models.RepairLocationServiceShop
+	}
+	now := time.Now().UTC()
+	wo := models.WorkOrder{
+		ID:                store.NewID("wo"),
+		IncidentID:        inc.ID,
+		TenantID:          inc.TenantID,
+		SchoolID:          inc.SchoolID,
+		DeviceID:          inc.DeviceID,
+		Status:            models.WorkOrderDraft,
+		ServiceShopID:     shop.ID,
+		AssignedStaffID:   lead.ID,
+		RepairLocation:    rl,
+		AssignedTo:        lead.UserID,
+		TaskType:          "triage",
+		CostEstimateCents: 0,
+		Notes:             "Auto-created from incident " + inc.ID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := createWorkOrderWithEvent(ctx, pg, wo, actorID); err != nil {
+		return wo, false
+	}
+	return wo, true
+}
+
 func (h *IncidentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	tenant := middleware.TenantID(r.Context())
@@ -287,16 +243,31 @@ func (h *IncidentHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
-	if !service.CanTransitionIncident(cur.Status, req.Status) {
-		http.Error(w, "invalid status transition", http.StatusBadRequest)
+	wf := loadWorkflow(r.Context(), h.log, h.pg, tenant, models.WorkflowIncident)
+	if err := service.CheckTransition(wf, string(cur.Status), string(req.Status), middleware.Roles(r.Context()), false, nil); err != nil {
+		writeTransitionError(w, h.log, err)
 		return
 	}
 
-	updated, err := h.pg.Incidents().UpdateStatus(r.Context(), tenant, school, id, req.Status, time.Now().UTC())
+	now := time.Now().UTC()
+	actorID := middleware.UserID(r.Context())
+	err = h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if err := store.UpdateIncidentStatusTx(ctx, tx, tenant, school, id, req.Status, now); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, incidentEvent(models.EventIncidentStatusChanged, cur, actorID,
+			models.StatusChangedData{From: string(cur.Status), To: string(req.Status)}))
+	})
 	if err != nil {
 		http.Error(w, "failed to update status", http.StatusInternalServerError)
 		return
 	}
+	updated, err := h.pg.Incidents().GetByID(r.Context(), tenant, school, id)
+	if err != nil {
+		http.Error(w, "failed to update status", http.StatusInternalServerError)
+		return
+	}
+	updated = syncSLAClock(r.Context(), h.log, h.pg, wf, updated, actorID, now)
 
 	// Log the update in audit trail
 	if err := h.audit.LogUpdate(r.Context(), "incident", id, cur, updated); err != nil {
@@ -306,3 +277,108 @@ func (h *IncidentHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, updated)
 }
+
+// syncSLAClock pauses or resumes the SLA clock to match the incident's
+// workflow state. Clock failures are logged and never fail the status update.
+func syncSLAClock(ctx context.Context, log *zap.Logger, pg *store.Postgres, wf models.Workflow, inc models.Incident, actorID string, now time.Time) models.Incident {
+	st, _ := wf.State(string(inc.Status))
+	pause := st.PausesSLA
+	paused := inc.SLAPausedAt != nil
+	if pause == paused || inc.SLABreached {
+		return inc
+	}
+
+	cal, err := pg.SLACalendars().ForPolicy(ctx, inc.TenantID, inc.SLAPolicyID)
+	if err != nil {
+		log.Warn("failed to load sla calendar, counting wall-clock time", zap.String("incidentId", inc.ID), zap.Error(err))
+	}
+
+	ev := models.IncidentSLAEvent{
+		ID:          store.NewID("slaev"),
+		TenantID:    inc.TenantID,
+		IncidentID:  inc.ID,
+		ActorUserID: actorID,
+		Note:        "status " + string(inc.Status),
+		CreatedAt:   now,
+	}
+	if pause {
+		remaining := service.BusinessDuration(cal, now, inc.SLADueAt)
+		if err := pg.Incidents().PauseSLA(ctx, inc.TenantID, inc.ID, remaining, now); err != nil {
+			log.Error("failed to pause sla clock", zap.String("incidentId", inc.ID), zap.Error(err))
+			return inc
+		}
+		ev.EventType = models.SLAEventPaused
+		ev.DueAt = &inc.SLADueAt
+		inc.SLAPausedAt = &now
+		inc.SLARemainingSeconds = int64(remaining.Seconds())
+	} else {
+		remaining := time.Duration(inc.SLARemainingSeconds) * time.Second
+		due := service.AddBusinessTime(cal, now, remaining)
+		if err := pg.Incidents().ResumeSLA(ctx, inc.TenantID, inc.ID, due, now); err != nil {
+			log.Error("failed to resume sla clock", zap.String("incidentId", inc.ID), zap.Error(err))
+			return inc
+		}
+		ev.EventType = models.SLAEventResumed
+		ev.DueAt = &due
+		inc.SLAPausedAt = nil
+		inc.SLARemainingSeconds = 0
+		inc.SLADueAt = due
+	}
+
+	if err := pg.SLAEvents().Create(ctx, ev); err != nil {
+		log.Error("failed to record sla event", zap.String("incidentId", inc.ID), zap.Error(err))
+	}
+	return inc
+}
+
+// GetSLA returns the SLA clock state and timeline of an incident
+// GET /v1/incidents/{id}/sla
+func (h *IncidentHandler) GetSLA(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	school := middleware.SchoolID(r.Context())
+
+	inc, err := h.pg.Incidents().GetByID(r.Context(), tenant, school, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	events, err := h.pg.SLAEvents().ListByIncident(r.Context(), tenant, inc.ID)
+	if err != nil {
+		h.log.Error("failed to list sla events", zap.Error(err))
+		http.Error(w, "failed to load sla timeline", http.StatusInternalServerError)
+		return
+	}
+
+	cal, err := h.pg.SLACalendars().ForPolicy(r.Context(), tenant, inc.SLAPolicyID)
+	if err != nil {
+		h.log.Warn("failed to load sla calendar, counting wall-clock time", zap.String("incidentId", inc.ID), zap.Error(err))
+	}
+
+	var remaining time.Duration
+	switch {
+	case inc.SLAPausedAt != nil:
+		remaining = time.Duration(inc.SLARemainingSeconds) * time.Second
+	case inc.Status == models.IncidentResolved || inc.Status == models.IncidentClosed:
+		remaining = service.BusinessDuration(cal, inc.UpdatedAt, inc.SLADueAt)
+	default:
+		remaining = service.BusinessDuration(cal, time.Now().UTC(), inc.SLADueAt)
+	}
+	budget := time.Duration(inc.SLABudgetSeconds) * time.Second
+
+	writeJSON(w, http.StatusOK, models.IncidentSLATimeline{
+		IncidentID:       inc.ID,
+		Status:           inc.Status,
+		PolicyID:         inc.SLAPolicyID,
+		OpenedAt:         inc.CreatedAt,
+		DueAt:            inc.SLADueAt,
+		ResponseDueAt:    inc.SLAResponseDueAt,
+		Breached:         inc.SLABreached,
+		Paused:           inc.SLAPausedAt != nil,
+		PausedAt:         inc.SLAPausedAt,
+		BudgetSeconds:    inc.SLABudgetSeconds,
+		RemainingSeconds: int64(remaining.Seconds()),
+		ElapsedPct:       service.SLAElapsedPct(budget, remaining),
+		Events:           events,
+	})
+}
@@ -1,10 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
@@ -28,6 +29,8 @@ type upsertInventoryReq struct {
 	ReorderThreshold int64  `json:"reorderThreshold"`
 }
 
+// Upsert sets a shop's stock level for a part. The difference from the
+// current level is posted to the ledger as a correction adjustment.
 func (h *InventoryHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 	var req upsertInventoryReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -38,18 +41,25 @@ func (h *InventoryHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "serviceShopId and partId are required", http.StatusBadRequest)
 		return
 	}
-	tenant := middleware.TenantID(r.Context())
-	item := models.InventoryItem{
-		ID:               store.NewID("inv"),
-		TenantID:         tenant,
-		ServiceShopID:    strings.TrimSpace(req.ServiceShopID),
-		PartID:           strings.TrimSpace(req.PartID),
-		QtyAvailable:     req.QtyAvailable,
-		QtyReserved:      0,
-		ReorderThreshold: req.ReorderThreshold,
-		UpdatedAt:        time.Now().UTC(),
+	if req.QtyAvailable < 0 {
+		http.Error(w, "qtyAvailable must be >= 0", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	var item models.InventoryItem
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		item, err = store.SetStockLevelTx(ctx, tx, tenant, strings.TrimSpace(req.ServiceShopID), strings.TrimSpace(req.PartID),
+			req.QtyAvailable, req.ReorderThreshold, middleware.UserID(ctx))
+		return err
+	})
+	if errors.Is(err, store.ErrInsufficientStock) {
+		http.Error(w, "qtyAvailable is below reserved stock", http.StatusConflict)
+		return
 	}
-	if err := h.pg.Inventory().Upsert(r.Context(), item); err != nil {
+	if err != nil {
+		h.log.Error("failed to upsert inventory", zap.Error(err))
 		http.Error(w, "failed to upsert inventory", http.StatusInternalServerError)
 		return
 	}
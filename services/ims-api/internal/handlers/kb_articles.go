@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -399,3 +400,27 @@ func isValidLifecycleStage(ls models.KBLifecycleStage) bool {
 	}
 	return false
 }
+
+// GetAIUsage shows KB authors which articles the AI assistant was given
+// and cited over the last `days` days, and how the chats that cited them
+// ended. GET /v1/kb/ai-usage?days=&limit=
+func (h *KBArticlesHandler) GetAIUsage(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if v := strings.TrimSpace(r.URL.Query().Get("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 365 {
+			http.Error(w, "days must be between 1 and 365", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	limit := parseLimit(r.URL.Query().Get("limit"), 50, 200)
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	items, err := h.pg.KBArticles().AIStats(r.Context(), middleware.TenantID(r.Context()), since, limit)
+	if err != nil {
+		h.log.Error("failed to get kb ai usage", zap.Error(err))
+		http.Error(w, "failed to get kb ai usage", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "since": since})
+}
@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/edvirons/ssp/ims/internal/chatrouting"
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/edvirons/ssp/ims/internal/ws"
 	"github.com/go-chi/chi/v5"
@@ -16,14 +18,15 @@ import (
 
 // LivechatHandler handles livechat endpoints
 type LivechatHandler struct {
-	log *zap.Logger
-	pg  *store.Postgres
-	hub *ws.Hub
+	log    *zap.Logger
+	pg     *store.Postgres
+	hub    *ws.Hub
+	router *chatrouting.Router
 }
 
 // NewLivechatHandler creates a new livechat handler
 func NewLivechatHandler(log *zap.Logger, pg *store.Postgres, hub *ws.Hub) *LivechatHandler {
-	return &LivechatHandler{log: log, pg: pg, hub: hub}
+	return &LivechatHandler{log: log, pg: pg, hub: hub, router: chatrouting.New(log, pg, hub)}
 }
 
 // StartSession handles POST /v1/chat/sessions
@@ -240,11 +243,6 @@ func (h *LivechatHandler) EndSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Decrement agent chat count if assigned
-	if session.AssignedAgentID != nil {
-		_ = h.pg.ChatSessions().DecrementAgentChatCount(ctx, tenantID, *session.AssignedAgentID)
-	}
-
 	// Close the thread
 	_ = h.pg.Messaging().UpdateThreadStatus(ctx, tenantID, session.ThreadID, models.ThreadStatusClosed)
 
@@ -254,6 +252,15 @@ func (h *LivechatHandler) EndSession(w http.ResponseWriter, r *http.Request) {
 	// Broadcast session ended
 	h.broadcastSessionUpdate(tenantID, session.ID, models.ChatStatusEnded, nil, nil)
 
+	// Ask the school contact how the chat went
+	h.sendChatSurvey(ctx, session, req.Rating, req.Feedback, session.SchoolContactID == userID)
+
+	// Free the agent's slot and hand it the next waiting chat
+	if session.AssignedAgentID != nil {
+		_ = h.pg.ChatSessions().DecrementAgentChatCount(ctx, tenantID, *session.AssignedAgentID)
+		h.router.DrainQueue(ctx, tenantID)
+	}
+
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
@@ -286,11 +293,16 @@ func (h *LivechatHandler) AcceptChat(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Assign agent to session
-	if err := h.pg.ChatSessions().AssignAgent(ctx, tenantID, session.ID, userID, userName); err != nil {
+	assigned, err := h.pg.ChatSessions().AssignAgent(ctx, tenantID, session.ID, userID, userName)
+	if err != nil {
 		h.log.Error("failed to assign agent", zap.Error(err))
 		http.Error(w, "failed to accept chat", http.StatusInternalServerError)
 		return
 	}
+	if !assigned {
+		http.Error(w, "chat was taken by another agent", http.StatusConflict)
+		return
+	}
 
 	// Increment agent chat count
 	_ = h.pg.ChatSessions().IncrementAgentChatCount(ctx, tenantID, userID)
@@ -373,8 +385,8 @@ func (h *LivechatHandler) TransferChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get target agent name (in production, this would come from user service)
-	targetAgentName := "Support Agent"
+	// Get target agent name from the HR people snapshot
+	targetAgentName := h.router.AgentName(ctx, tenantID, req.TargetAgentID)
 
 	// Transfer the session
 	if err := h.pg.ChatSessions().TransferSession(ctx, tenantID, sessionID, req.TargetAgentID, targetAgentName); err != nil {
@@ -429,18 +441,20 @@ func (h *LivechatHandler) SetAvailability(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Keep existing max chats and skills unless the request changes them
+	existing, _ := h.pg.ChatSessions().GetAgentAvailability(ctx, tenantID, userID)
 	maxChats := 3
 	if req.MaxConcurrentChats != nil {
 		maxChats = *req.MaxConcurrentChats
-	} else {
-		// Get existing max chats setting
-		existing, _ := h.pg.ChatSessions().GetAgentAvailability(ctx, tenantID, userID)
-		if existing.MaxConcurrentChats > 0 {
-			maxChats = existing.MaxConcurrentChats
-		}
+	} else if existing.MaxConcurrentChats > 0 {
+		maxChats = existing.MaxConcurrentChats
+	}
+	skills := existing.Skills
+	if req.Skills != nil {
+		skills = service.NormalizeSkills(*req.Skills)
 	}
 
-	if err := h.pg.ChatSessions().SetAgentAvailability(ctx, tenantID, userID, req.Available, maxChats); err != nil {
+	if err := h.pg.ChatSessions().SetAgentAvailability(ctx, tenantID, userID, req.Available, maxChats, skills); err != nil {
 		h.log.Error("failed to set availability", zap.Error(err))
 		http.Error(w, "failed to set availability", http.StatusInternalServerError)
 		return
@@ -449,6 +463,11 @@ func (h *LivechatHandler) SetAvailability(w http.ResponseWriter, r *http.Request
 	// Broadcast presence update
 	h.broadcastPresenceUpdate(tenantID, userID, req.Available)
 
+	// An agent coming online can take waiting chats
+	if req.Available {
+		h.router.DrainQueue(ctx, tenantID)
+	}
+
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "available": req.Available})
 }
 
@@ -631,46 +650,6 @@ func (h *LivechatHandler) getPrimaryRole(roles []string) string {
 	return "unknown"
 }
 
-//nolint:unused // reserved for future auto-assignment feature
-func (h *LivechatHandler) tryAutoAssign(ctx context.Context, tenantID, sessionID string) {
-	agents, err := h.pg.ChatSessions().GetAvailableAgents(ctx, tenantID)
-	if err != nil || len(agents) == 0 {
-		return
-	}
-
-	// Pick the first available agent (least busy)
-	agent := agents[0]
-
-	// In production, we'd get the agent name from user service
-	agentName := "Support Agent"
-
-	if err := h.pg.ChatSessions().AssignAgent(ctx, tenantID, sessionID, agent.UserID, agentName); err != nil {
-		h.log.Warn("auto-assign failed", zap.Error(err))
-		return
-	}
-
-	_ = h.pg.ChatSessions().IncrementAgentChatCount(ctx, tenantID, agent.UserID)
-
-	// Get session for thread ID
-	session, _ := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID)
-
-	// Add agent as participant
-	now := time.Now().UTC()
-	_ = h.pg.Messaging().AddThreadParticipant(ctx, models.ThreadParticipant{
-		ThreadID: session.ThreadID,
-		UserID:   agent.UserID,
-		UserName: agentName,
-		UserRole: "ssp_support_agent",
-		JoinedAt: now,
-	})
-
-	// Broadcast session update
-	h.broadcastSessionUpdate(tenantID, sessionID, models.ChatStatusActive, &agent.UserID, &agentName)
-
-	// Send system message
-	h.sendSystemMessage(ctx, tenantID, session.ThreadID, agentName+" has joined the chat")
-}
-
 //nolint:unused // reserved for future notification feature
 func (h *LivechatHandler) notifyAgentsNewChat(tenantID string, session models.ChatSession, thread models.MessageThread) {
 	if h.hub == nil {
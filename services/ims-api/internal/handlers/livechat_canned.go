@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/lookups"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+var cannedShortcutPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+// ListCannedResponses handles GET /v1/chat/canned-responses
+func (h *LivechatHandler) ListCannedResponses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	p := store.CannedResponseListParams{
+		TenantID: middleware.TenantID(ctx),
+		Query:    strings.TrimSpace(q.Get("q")),
+		Category: strings.ToLower(strings.TrimSpace(q.Get("category"))),
+		Limit:    parseLimit(q.Get("limit"), 100, 500),
+	}
+	// Agents only see active responses; admins may list inactive ones
+	active := true
+	p.Active = &active
+	if h.isAdmin(middleware.Roles(ctx)) {
+		switch q.Get("active") {
+		case "":
+		case "all":
+			p.Active = nil
+		default:
+			v, err := strconv.ParseBool(q.Get("active"))
+			if err != nil {
+				http.Error(w, "invalid active", http.StatusBadRequest)
+				return
+			}
+			p.Active = &v
+		}
+	}
+
+	items, err := h.pg.CannedResponses().List(ctx, p)
+	if err != nil {
+		h.log.Error("failed to list canned responses", zap.Error(err))
+		http.Error(w, "failed to list canned responses", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// CreateCannedResponse handles POST /v1/chat/canned-responses
+func (h *LivechatHandler) CreateCannedResponse(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.CannedResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	c := models.CannedResponse{
+		ID:        store.NewID("cnr"),
+		TenantID:  middleware.TenantID(ctx),
+		Active:    true,
+		CreatedBy: middleware.UserID(ctx),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if msg := applyCannedResponseRequest(&c, req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pg.CannedResponses().Create(ctx, c); err != nil {
+		if errors.Is(err, store.ErrCannedShortcutTaken) {
+			http.Error(w, "shortcut already in use", http.StatusConflict)
+			return
+		}
+		h.log.Error("failed to create canned response", zap.Error(err))
+		http.Error(w, "failed to create canned response", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// UpdateCannedResponse handles PATCH /v1/chat/canned-responses/{responseId}
+func (h *LivechatHandler) UpdateCannedResponse(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := middleware.TenantID(ctx)
+
+	var req models.CannedResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.pg.CannedResponses().GetByID(ctx, tenantID, chi.URLParam(r, "responseId"))
+	if err != nil {
+		http.Error(w, "canned response not found", http.StatusNotFound)
+		return
+	}
+	if msg := applyCannedResponseRequest(&c, req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	c.UpdatedAt = time.Now().UTC()
+
+	if err := h.pg.CannedResponses().Update(ctx, c); err != nil {
+		switch {
+		case errors.Is(err, store.ErrCannedShortcutTaken):
+			http.Error(w, "shortcut already in use", http.StatusConflict)
+		case err.Error() == "not found":
+			http.Error(w, "canned response not found", http.StatusNotFound)
+		default:
+			h.log.Error("failed to update canned response", zap.Error(err))
+			http.Error(w, "failed to update canned response", http.StatusInternalServerError)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// DeleteCannedResponse handles DELETE /v1/chat/canned-responses/{responseId}
+func (h *LivechatHandler) DeleteCannedResponse(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := h.pg.CannedResponses().Delete(ctx, middleware.TenantID(ctx), chi.URLParam(r, "responseId")); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "canned response not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("failed to delete canned response", zap.Error(err))
+		http.Error(w, "failed to delete canned response", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RenderCannedResponse handles POST /v1/chat/sessions/{id}/canned-responses/{responseId}/render
+func (h *LivechatHandler) RenderCannedResponse(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := middleware.TenantID(ctx)
+
+	session, err := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	c, err := h.pg.CannedResponses().GetByID(ctx, tenantID, chi.URLParam(r, "responseId"))
+	if err != nil || !c.Active {
+		http.Error(w, "canned response not found", http.StatusNotFound)
+		return
+	}
+
+	agentName := middleware.UserName(ctx)
+	if agentName == "" {
+		agentName = h.router.AgentName(ctx, tenantID, middleware.UserID(ctx))
+	}
+	content, missing := service.RenderCannedResponse(c.Content, h.chatTemplateVars(ctx, session, agentName))
+
+	if err := h.pg.CannedResponses().RecordUse(ctx, tenantID, c.ID, time.Now().UTC()); err != nil {
+		h.log.Warn("failed to record canned response use", zap.String("response_id", c.ID), zap.Error(err))
+	}
+
+	if missing == nil {
+		missing = []string{}
+	}
+	writeJSON(w, http.StatusOK, models.RenderedCannedResponse{ResponseID: c.ID, Content: content, Missing: missing})
+}
+
+// applyCannedResponseRequest copies the set fields of req onto c and
+// returns a validation message, or "" when c is valid.
+func applyCannedResponseRequest(c *models.CannedResponse, req models.CannedResponseRequest) string {
+	if req.Title != nil {
+		c.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Shortcut != nil {
+		c.Shortcut = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(*req.Shortcut)), "/")
+	}
+	if req.Category != nil {
+		c.Category = strings.ToLower(strings.TrimSpace(*req.Category))
+	}
+	if req.Content != nil {
+		c.Content = strings.TrimSpace(*req.Content)
+	}
+	if req.Active != nil {
+		c.Active = *req.Active
+	}
+
+	if c.Title == "" {
+		return "title is required"
+	}
+	if c.Content == "" {
+		return "content is required"
+	}
+	if c.Shortcut != "" && !cannedShortcutPattern.MatchString(c.Shortcut) {
+		return "shortcut must be 1-40 lowercase letters, digits, '-' or '_'"
+	}
+	if unknown := service.UnknownCannedVariables(c.Content); len(unknown) > 0 {
+		return "unknown variables: " + strings.Join(unknown, ", ")
+	}
+	return ""
+}
+
+// chatTemplateVars collects canned response variables for a session from
+// the session itself and the SSOT snapshots (best-effort).
+func (h *LivechatHandler) chatTemplateVars(ctx context.Context, session models.ChatSession, agentName string) map[string]string {
+	vars := map[string]string{
+		"agent.name":   agentName,
+		"contact.name": session.SchoolContactName,
+	}
+	if session.IssueCategory != nil {
+		vars["issue.category"] = *session.IssueCategory
+	}
+
+	lk := lookups.New(h.pg.RawPool())
+	if sc, err := lk.SchoolByID(ctx, session.TenantID, session.SchoolID); err == nil && sc != nil {
+		vars["school.name"] = sc.Name
+		vars["school.code"] = sc.Code
+		vars["school.county"] = sc.CountyName
+		vars["school.sub_county"] = sc.SubCountyName
+	}
+
+	if serial, _ := session.CollectedInfo["device_serial"].(string); strings.TrimSpace(serial) != "" {
+		serial = strings.TrimSpace(serial)
+		vars["device.serial"] = serial
+		// Only trust snapshot details for a device at the chat's school
+		if d, err := lk.DeviceBySerial(ctx, session.TenantID, serial); err == nil && d != nil && (d.SchoolID == "" || d.SchoolID == session.SchoolID) {
+			vars["device.asset_tag"] = d.AssetTag
+			vars["device.make"] = d.Make
+			vars["device.model"] = d.Model
+		}
+	}
+
+	if thread, err := h.pg.Messaging().GetThreadByID(ctx, session.TenantID, session.ThreadID); err == nil && thread.IncidentID != nil {
+		vars["incident.id"] = *thread.IncidentID
+	}
+	return vars
+}
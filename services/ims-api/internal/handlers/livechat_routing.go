@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"go.uber.org/zap"
+)
+
+// GetRoutingSettings handles GET /v1/chat/routing/settings
+func (h *LivechatHandler) GetRoutingSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	settings, err := h.pg.ChatRouting().GetSettings(ctx, middleware.TenantID(ctx))
+	if err != nil {
+		h.log.Error("failed to get routing settings", zap.Error(err))
+		http.Error(w, "failed to get routing settings", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// UpdateRoutingSettings handles PUT /v1/chat/routing/settings
+func (h *LivechatHandler) UpdateRoutingSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := middleware.TenantID(ctx)
+
+	var req models.UpdateChatRoutingSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.pg.ChatRouting().GetSettings(ctx, tenantID)
+	if err != nil {
+		h.log.Error("failed to get routing settings", zap.Error(err))
+		http.Error(w, "failed to update routing settings", http.StatusInternalServerError)
+		return
+	}
+	if req.RequireSkillMatch != nil {
+		settings.RequireSkillMatch = *req.RequireSkillMatch
+	}
+	if req.OverflowAfterSeconds != nil {
+		if *req.OverflowAfterSeconds < 0 || *req.OverflowAfterSeconds > 3600 {
+			http.Error(w, "overflowAfterSeconds must be between 0 and 3600", http.StatusBadRequest)
+			return
+		}
+		settings.OverflowAfterSeconds = *req.OverflowAfterSeconds
+	}
+	if req.OverflowExtraChats != nil {
+		if *req.OverflowExtraChats < 0 || *req.OverflowExtraChats > 10 {
+			http.Error(w, "overflowExtraChats must be between 0 and 10", http.StatusBadRequest)
+			return
+		}
+		settings.OverflowExtraChats = *req.OverflowExtraChats
+	}
+	if req.AffinityDays != nil {
+		if *req.AffinityDays < 1 || *req.AffinityDays > 365 {
+			http.Error(w, "affinityDays must be between 1 and 365", http.StatusBadRequest)
+			return
+		}
+		settings.AffinityDays = *req.AffinityDays
+	}
+	settings.TenantID = tenantID
+	settings.UpdatedBy = middleware.UserID(ctx)
+	settings.UpdatedAt = time.Now().UTC()
+
+	if err := h.pg.ChatRouting().UpsertSettings(ctx, settings); err != nil {
+		h.log.Error("failed to save routing settings", zap.Error(err))
+		http.Error(w, "failed to update routing settings", http.StatusInternalServerError)
+		return
+	}
+
+	// Looser rules may place sessions that are waiting now
+	h.router.DrainQueue(ctx, tenantID)
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// ListRoutingDecisions handles GET /v1/chat/routing/decisions
+func (h *LivechatHandler) ListRoutingDecisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	outcome := q.Get("outcome")
+	switch models.ChatRouteOutcome(outcome) {
+	case "", models.ChatRouteAssigned, models.ChatRouteOverflow, models.ChatRouteQueued:
+	default:
+		http.Error(w, "invalid outcome", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.pg.ChatRouting().ListDecisions(ctx, store.ChatRoutingDecisionListParams{
+		TenantID:  middleware.TenantID(ctx),
+		SessionID: q.Get("sessionId"),
+		AgentID:   q.Get("agentId"),
+		Outcome:   outcome,
+		Limit:     parseLimit(q.Get("limit"), 50, 200),
+	})
+	if err != nil {
+		h.log.Error("failed to list routing decisions", zap.Error(err))
+		http.Error(w, "failed to list routing decisions", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
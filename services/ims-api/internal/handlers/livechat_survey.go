@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/ws"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// chatSurveyTTL is how long a post-chat survey can be answered.
+	chatSurveyTTL = 7 * 24 * time.Hour
+	// maxSurveyComment bounds the free-text survey comment.
+	maxSurveyComment = 2000
+)
+
+// sendChatSurvey asks the school contact to rate an ended chat. A rating
+// the contact gave when ending the chat completes the survey straight away.
+// Failures are logged and do not fail the request.
+func (h *LivechatHandler) sendChatSurvey(ctx context.Context, session models.ChatSession, rating *int, feedback *string, ratedByContact bool) {
+	now := time.Now().UTC()
+	sv := models.ChatSurvey{
+		ID:              store.NewID("csv"),
+		TenantID:        session.TenantID,
+		SessionID:       session.ID,
+		SchoolID:        session.SchoolID,
+		SchoolContactID: session.SchoolContactID,
+		Status:          models.ChatSurveyPending,
+		SentAt:          now,
+		ExpiresAt:       now.Add(chatSurveyTTL),
+	}
+	if session.AssignedAgentID != nil {
+		sv.AgentID = *session.AssignedAgentID
+	}
+	if ratedByContact && rating != nil && *rating >= 1 && *rating <= 5 {
+		sv.Status = models.ChatSurveyCompleted
+		sv.CSATScore = rating
+		sv.RespondedAt = &now
+		if feedback != nil {
+			sv.Comment = strings.TrimSpace(*feedback)
+		}
+	}
+
+	created, err := h.pg.ChatSessions().CreateSurvey(ctx, sv)
+	if err != nil {
+		h.log.Warn("failed to create chat survey", zap.String("session_id", session.ID), zap.Error(err))
+		return
+	}
+	if !created || sv.Status == models.ChatSurveyCompleted {
+		return
+	}
+
+	message := models.Message{
+		ID:          store.NewID("msg"),
+		TenantID:    session.TenantID,
+		ThreadID:    session.ThreadID,
+		SenderID:    "system",
+		SenderName:  "System",
+		SenderRole:  "system",
+		Content:     "How did we do? Please rate this chat from 1 to 5 and tell us whether your issue was resolved.",
+		ContentType: models.ContentTypeSystem,
+		Visibility:  models.MessageVisibilityPublic,
+		Metadata: map[string]any{
+			"kind":      "chat_survey",
+			"surveyId":  sv.ID,
+			"sessionId": session.ID,
+			"expiresAt": sv.ExpiresAt,
+		},
+		CreatedAt: now,
+	}
+	if err := h.pg.Messaging().CreateMessage(ctx, message); err != nil {
+		h.log.Warn("failed to send chat survey", zap.String("session_id", session.ID), zap.Error(err))
+		return
+	}
+	_ = h.pg.Messaging().UpdateThreadLastMessage(ctx, session.TenantID, session.ThreadID, false)
+
+	if h.hub != nil {
+		h.hub.Broadcast(session.TenantID, &ws.Message{
+			Type: ws.MessageTypeChatMessage,
+			Payload: map[string]any{
+				"threadId": session.ThreadID,
+				"message":  message,
+			},
+		})
+	}
+}
+
+// GetSurvey handles GET /v1/chat/sessions/{id}/survey
+func (h *LivechatHandler) GetSurvey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := middleware.TenantID(ctx)
+	sessionID := chi.URLParam(r, "id")
+
+	session, err := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID)
+	if err != nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	roles := middleware.Roles(ctx)
+	if session.SchoolContactID != middleware.UserID(ctx) && !h.isSupportAgent(roles) && !h.isAdmin(roles) {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+
+	survey, err := h.pg.ChatSessions().GetSurveyBySession(ctx, tenantID, sessionID)
+	if err != nil {
+		http.Error(w, "survey not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, survey)
+}
+
+// RespondToSurvey handles POST /v1/chat/sessions/{id}/survey
+func (h *LivechatHandler) RespondToSurvey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := middleware.TenantID(ctx)
+	sessionID := chi.URLParam(r, "id")
+
+	var req models.ChatSurveyResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.CSATScore < 1 || req.CSATScore > 5 {
+		http.Error(w, "csatScore must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+	comment := ""
+	if req.Comment != nil {
+		comment = strings.TrimSpace(*req.Comment)
+	}
+	if len([]rune(comment)) > maxSurveyComment {
+		http.Error(w, "comment is too long", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.pg.ChatSessions().GetSessionByID(ctx, tenantID, sessionID)
+	if err != nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	// Only the school contact who chatted can answer
+	if session.SchoolContactID != middleware.UserID(ctx) {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+
+	survey, err := h.pg.ChatSessions().GetSurveyBySession(ctx, tenantID, sessionID)
+	if err != nil {
+		http.Error(w, "survey not found", http.StatusNotFound)
+		return
+	}
+	now := time.Now().UTC()
+	if survey.Status == models.ChatSurveyCompleted {
+		http.Error(w, "survey already answered", http.StatusConflict)
+		return
+	}
+	if !now.Before(survey.ExpiresAt) {
+		http.Error(w, "survey has expired", http.StatusGone)
+		return
+	}
+
+	completed, err := h.pg.ChatSessions().CompleteSurvey(ctx, tenantID, survey.ID, req.CSATScore, req.Resolved, comment, now)
+	if err != nil {
+		h.log.Error("failed to save survey response", zap.Error(err))
+		http.Error(w, "failed to save survey response", http.StatusInternalServerError)
+		return
+	}
+	if !completed {
+		http.Error(w, "survey already answered", http.StatusConflict)
+		return
+	}
+
+	survey, _ = h.pg.ChatSessions().GetSurveyBySession(ctx, tenantID, sessionID)
+	writeJSON(w, http.StatusOK, survey)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -74,7 +75,7 @@ func (h *MessagingHandler) ListThreads(w http.ResponseWriter, r *http.Request) {
 	// Fetch last message for each thread
 	for i := range threads {
 		if threads[i].MessageCount > 0 {
-			lastMsg, err := h.pg.Messaging().GetLastMessage(ctx, threads[i].ID)
+			lastMsg, err := h.pg.Messaging().GetLastMessage(ctx, threads[i].ID, !h.isSchoolContact(roles))
 			if err == nil {
 				threads[i].LastMessage = &lastMsg
 			}
@@ -111,8 +112,9 @@ func (h *MessagingHandler) GetThread(w http.ResponseWriter, r *http.Request) {
 
 	// Get messages
 	messages, _, err := h.pg.Messaging().ListMessages(ctx, store.MessageListParams{
-		ThreadID: threadID,
-		Limit:    100,
+		ThreadID:        threadID,
+		Limit:           100,
+		IncludeInternal: !h.isSchoolContact(roles),
 	})
 	if err != nil {
 		h.log.Error("failed to list messages", zap.Error(err))
@@ -322,6 +324,22 @@ func (h *MessagingHandler) CreateMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Internal notes are written and read by support staff only
+	visibility := req.Visibility
+	switch visibility {
+	case "", models.MessageVisibilityPublic:
+		visibility = models.MessageVisibilityPublic
+	case models.MessageVisibilityInternal:
+		if h.isSchoolContact(roles) {
+			http.Error(w, "school contacts cannot post internal notes", http.StatusForbidden)
+			return
+		}
+	default:
+		http.Error(w, "visibility must be public or internal", http.StatusBadRequest)
+		return
+	}
+	internal := visibility == models.MessageVisibilityInternal
+
 	// Get thread to check access
 	thread, err := h.pg.Messaging().GetThreadByID(ctx, tenantID, threadID)
 	if err != nil {
@@ -356,6 +374,7 @@ func (h *MessagingHandler) CreateMessage(w http.ResponseWriter, r *http.Request)
 		SenderRole:  primaryRole,
 		Content:     strings.TrimSpace(req.Content),
 		ContentType: models.ContentTypeText,
+		Visibility:  visibility,
 		CreatedAt:   now,
 	}
 
@@ -365,12 +384,6 @@ func (h *MessagingHandler) CreateMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Update thread stats
-	isSchoolSender := h.isSchoolContact(roles)
-	if err := h.pg.Messaging().UpdateThreadLastMessage(ctx, tenantID, threadID, isSchoolSender); err != nil {
-		h.log.Warn("failed to update thread stats", zap.Error(err))
-	}
-
 	// Add sender as participant if not already
 	_ = h.pg.Messaging().AddThreadParticipant(ctx, models.ThreadParticipant{
 		ThreadID: threadID,
@@ -380,6 +393,22 @@ func (h *MessagingHandler) CreateMessage(w http.ResponseWriter, r *http.Request)
 		JoinedAt: now,
 	})
 
+	// Internal notes leave thread stats, unread counts and chat metrics alone
+	// and are only pushed to the thread's support participants
+	if internal {
+		h.broadcastInternalMessage(ctx, tenantID, threadID, message)
+		writeJSON(w, http.StatusOK, models.CreateMessageResponse{
+			Message: message,
+		})
+		return
+	}
+
+	// Update thread stats
+	isSchoolSender := h.isSchoolContact(roles)
+	if err := h.pg.Messaging().UpdateThreadLastMessage(ctx, tenantID, threadID, isSchoolSender); err != nil {
+		h.log.Warn("failed to update thread stats", zap.Error(err))
+	}
+
 	// Update chat session message count if this is a livechat thread
 	if thread.ThreadType == models.ThreadTypeLivechat {
 		session, err := h.pg.ChatSessions().GetSessionByThreadID(ctx, tenantID, threadID)
@@ -494,7 +523,7 @@ func (h *MessagingHandler) SearchMessages(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	results, err := h.pg.Messaging().SearchMessages(ctx, tenantID, schoolID, query, limit)
+	results, err := h.pg.Messaging().SearchMessages(ctx, tenantID, schoolID, query, limit, !h.isSchoolContact(roles))
 	if err != nil {
 		h.log.Error("failed to search messages", zap.Error(err))
 		http.Error(w, "failed to search", http.StatusInternalServerError)
@@ -595,6 +624,34 @@ func (h *MessagingHandler) broadcastNewMessage(tenantID, threadID string, messag
 	})
 }
 
+// broadcastInternalMessage pushes an agent-only note to the thread's
+// participants other than school contacts.
+func (h *MessagingHandler) broadcastInternalMessage(ctx context.Context, tenantID, threadID string, message models.Message) {
+	if h.hub == nil {
+		return
+	}
+
+	participants, err := h.pg.Messaging().GetThreadParticipants(ctx, threadID)
+	if err != nil {
+		h.log.Warn("failed to get thread participants", zap.Error(err))
+		return
+	}
+	var userIDs []string
+	for _, p := range participants {
+		if p.UserRole != "ssp_school_contact" {
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+
+	h.hub.BroadcastToUsers(tenantID, userIDs, &ws.Message{
+		Type: ws.MessageTypeChatMessage,
+		Payload: map[string]any{
+			"threadId": threadID,
+			"message":  message,
+		},
+	})
+}
+
 func (h *MessagingHandler) broadcastReadReceipt(tenantID, threadID, userID, lastMessageID string) {
 	if h.hub == nil {
 		return
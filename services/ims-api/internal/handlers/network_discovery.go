@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/config"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Devices are resolved by MAC address through the device_network_snapshot
+// cache, then ssot-devices, whose answers are cached. Resolved sightings
+// update when a device was last seen; unknown MACs are kept per school as
+// candidate unregistered devices.
+
+// networkScanLimit caps the sightings accepted by one scan upload.
+const networkScanLimit = 5000
+
+// networkScanLookupTimeout bounds the ssot-devices lookups for one scan.
+// MACs not looked up in time are reported as lookupFailed, not unknown.
+const networkScanLookupTimeout = 20 * time.Second
+
+type NetworkDiscoveryHandler struct {
+	cfg   config.Config
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
+	macs  service.MACDirectory
+}
+
+func NewNetworkDiscoveryHandler(cfg config.Config, log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger, macs service.MACDirectory) *NetworkDiscoveryHandler {
+	return &NetworkDiscoveryHandler{cfg: cfg, log: log, pg: pg, audit: auditLogger, macs: macs}
+}
+
+// resolveMACs maps MAC addresses to devices. failed lists the MACs whose
+// lookup could not be answered; any MAC in neither result is unknown.
+func resolveMACs(ctx context.Context, log *zap.Logger, pg *store.Postgres, dir service.MACDirectory, tenant string, macs []string) (found map[string]string, failed map[string]bool) {
+	failed = map[string]bool{}
+	found, err := pg.NetworkDiscovery().LookupMACs(ctx, tenant, macs)
+	if err != nil {
+		log.Error("failed to look up cached MAC addresses", zap.Error(err))
+		found = map[string]string{}
+	}
+	now := time.Now().UTC()
+	for _, mac := range macs {
+		if _, ok := found[mac]; ok {
+			continue
+		}
+		if dir == nil || ctx.Err() != nil {
+			failed[mac] = true
+			continue
+		}
+		snap, ok, err := dir.LookupMAC(ctx, tenant, mac)
+		if err != nil {
+			log.Warn("ssot mac lookup failed", zap.String("mac", mac), zap.Error(err))
+			failed[mac] = true
+			continue
+		}
+		if !ok {
+			continue
+		}
+		found[mac] = snap.DeviceID
+		snap.TenantID, snap.MACAddress, snap.SyncedAt = tenant, mac, now
+		if snap.InterfaceType == "" {
+			snap.InterfaceType = "unknown"
+		}
+		if err := pg.NetworkSnapshot().Upsert(ctx, snap); err != nil {
+			log.Error("failed to cache MAC address", zap.String("mac", mac), zap.Error(err))
+		}
+	}
+	return found, failed
+}
+
+type networkScanEntry struct {
+	MACAddress string     `json:"macAddress"`
+	IPAddress  string     `json:"ipAddress"`
+	Hostname   string     `json:"hostname"`
+	SeenAt     *time.Time `json:"seenAt"`
+}
+
+type networkScanReq struct {
+	Source    string             `json:"source"`    // dhcp, arp, other
+	ScannedAt *time.Time         `json:"scannedAt"` // defaults to now
+	Entries   []networkScanEntry `json:"entries"`
+	Text      string             `json:"text"` // raw lease/ARP table, parsed line by line
+}
+
+type networkScanElsewhere struct {
+	DeviceID   string `json:"deviceId"`
+	SchoolID   string `json:"schoolId"`
+	MACAddress string `json:"macAddress"`
+}
+
+// CreateScan imports a DHCP lease or ARP table seen on a school network.
+// POST /v1/schools/{schoolId}/network-scans
+func (h *NetworkDiscoveryHandler) CreateScan(w http.ResponseWriter, r *http.Request) {
+	var req networkScanReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	source := models.NetworkScanSource(strings.ToLower(strings.TrimSpace(req.Source)))
+	switch source {
+	case "":
+		source = models.NetworkScanOther
+	case models.NetworkScanDHCP, models.NetworkScanARP, models.NetworkScanOther:
+	default:
+		http.Error(w, "source must be dhcp, arp or other", http.StatusBadRequest)
+		return
+	}
+
+	tenant := middleware.TenantID(r.Context())
+	schoolID := chi.URLParam(r, "schoolId")
+	if _, err := h.pg.SchoolsSnapshot().Get(r.Context(), tenant, schoolID); err != nil {
+		http.Error(w, "school not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().UTC()
+	scannedAt := now
+	if req.ScannedAt != nil && !req.ScannedAt.IsZero() && req.ScannedAt.Before(now) {
+		scannedAt = req.ScannedAt.UTC()
+	}
+
+	sightings, invalid := service.ParseNetworkScanText(req.Text, scannedAt)
+	for _, e := range req.Entries {
+		mac, ok := service.NormalizeMAC(e.MACAddress)
+		if !ok {
+			invalid++
+			continue
+		}
+		seenAt := scannedAt
+		if e.SeenAt != nil && !e.SeenAt.IsZero() && e.SeenAt.Before(now) {
+			seenAt = e.SeenAt.UTC()
+		}
+		sightings = append(sightings, models.NetworkSighting{
+			MACAddress: mac,
+			IPAddress:  strings.TrimSpace(e.IPAddress),
+			Hostname:   strings.TrimSpace(e.Hostname),
+			SeenAt:     seenAt,
+		})
+	}
+	if len(sightings)+invalid == 0 {
+		http.Error(w, "entries or text required", http.StatusBadRequest)
+		return
+	}
+	if len(sightings) > networkScanLimit {
+		http.Error(w, "at most 5000 entries per scan", http.StatusRequestEntityTooLarge)
+		return
+	}
+	sightings = service.MergeSightings(sightings)
+
+	macs := make([]string, len(sightings))
+	for i, s := range sightings {
+		macs[i] = s.MACAddress
+	}
+	lookupCtx, cancel := context.WithTimeout(r.Context(), networkScanLookupTimeout)
+	found, failed := resolveMACs(lookupCtx, h.log, h.pg, h.macs, tenant, macs)
+	cancel()
+
+	scan := models.NetworkScan{
+		ID:        store.NewID("nscan"),
+		TenantID:  tenant,
+		SchoolID:  schoolID,
+		Source:    source,
+		ScannedAt: scannedAt,
+		Entries:   len(sightings),
+		Invalid:   invalid,
+		CreatedBy: middleware.UserID(r.Context()),
+		CreatedAt: now,
+	}
+	unknown := []string{}
+	elsewhere := []networkScanElsewhere{}
+	for _, s := range sightings {
+		deviceID, ok := found[s.MACAddress]
+		switch {
+		case ok:
+			scan.Resolved++
+			if err := h.pg.NetworkDiscovery().MarkSeen(r.Context(), tenant, deviceID, s.MACAddress, s.SeenAt); err != nil {
+				h.log.Error("failed to mark device seen", zap.String("deviceId", deviceID), zap.Error(err))
+			}
+			if d, err := h.pg.DevicesSnapshot().Get(r.Context(), tenant, deviceID); err == nil && d.SchoolID != "" && d.SchoolID != schoolID {
+				scan.Elsewhere++
+				elsewhere = append(elsewhere, networkScanElsewhere{DeviceID: deviceID, SchoolID: d.SchoolID, MACAddress: s.MACAddress})
+			}
+		case failed[s.MACAddress]:
+			scan.LookupFailed++
+		default:
+			scan.Unknown++
+			unknown = append(unknown, s.MACAddress)
+			if err := h.pg.NetworkDiscovery().RecordUnknown(r.Context(), tenant, schoolID, string(source), s); err != nil {
+				h.log.Error("failed to record unknown MAC", zap.String("mac", s.MACAddress), zap.Error(err))
+			}
+		}
+	}
+
+	if err := h.pg.NetworkDiscovery().CreateScan(r.Context(), scan); err != nil {
+		h.log.Error("failed to save network scan", zap.Error(err))
+		http.Error(w, "failed to save network scan", http.StatusInternalServerError)
+		return
+	}
+	if err := h.audit.LogCreate(r.Context(), "network_scan", scan.ID, scan); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"scan": scan, "unknown": unknown, "elsewhere": elsewhere})
+}
+
+// ListScans returns a school's uploaded scans.
+// GET /v1/schools/{schoolId}/network-scans?limit=&offset=
+func (h *NetworkDiscoveryHandler) ListScans(w http.ResponseWriter, r *http.Request) {
+	limit := parseLimit(r.URL.Query().Get("limit"), 50, 200)
+	offset := parseOffset(r.URL.Query().Get("offset"))
+	items, err := h.pg.NetworkDiscovery().ListScans(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "schoolId"), limit, offset)
+	if err != nil {
+		h.log.Error("failed to list network scans", zap.Error(err))
+		http.Error(w, "failed to list network scans", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "limit": limit, "offset": offset})
+}
+
+// ListUnknownMACs returns MAC addresses seen at a school that match no
+// registered device.
+// GET /v1/schools/{schoolId}/unknown-macs?status=open|ignored|resolved
+func (h *NetworkDiscoveryHandler) ListUnknownMACs(w http.ResponseWriter, r *http.Request) {
+	status := models.UnknownMACStatus(strings.TrimSpace(r.URL.Query().Get("status")))
+	switch status {
+	case "", models.UnknownMACOpen, models.UnknownMACIgnored, models.UnknownMACResolved:
+	default:
+		http.Error(w, "status must be open, ignored or resolved", http.StatusBadRequest)
+		return
+	}
+	limit := parseLimit(r.URL.Query().Get("limit"), 50, 200)
+	offset := parseOffset(r.URL.Query().Get("offset"))
+	items, err := h.pg.NetworkDiscovery().ListUnknown(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "schoolId"), status, limit, offset)
+	if err != nil {
+		h.log.Error("failed to list unknown MACs", zap.Error(err))
+		http.Error(w, "failed to list unknown MACs", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "limit": limit, "offset": offset})
+}
+
+type unknownMACStatusReq struct {
+	Status models.UnknownMACStatus `json:"status"` // open or ignored
+}
+
+// UpdateUnknownMAC ignores an unknown MAC that is not a school device, or
+// reopens it.
+// PATCH /v1/unknown-macs/{mac}
+func (h *NetworkDiscoveryHandler) UpdateUnknownMAC(w http.ResponseWriter, r *http.Request) {
+	var req unknownMACStatusReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Status != models.UnknownMACOpen && req.Status != models.UnknownMACIgnored {
+		http.Error(w, "status must be open or ignored", http.StatusBadRequest)
+		return
+	}
+	mac, ok := service.NormalizeMAC(chi.URLParam(r, "mac"))
+	if !ok {
+		http.Error(w, "invalid MAC address", http.StatusBadRequest)
+		return
+	}
+	x, err := h.pg.NetworkDiscovery().SetUnknownStatus(r.Context(), middleware.TenantID(r.Context()), mac, req.Status)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("failed to update unknown MAC", zap.Error(err))
+		http.Error(w, "failed to update unknown MAC", http.StatusInternalServerError)
+		return
+	}
+	if err := h.audit.LogUpdate(r.Context(), "unknown_mac", mac, nil, x); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, x)
+}
+
+// ListUnseenDevices reports a school's devices not seen on the network or
+// in telemetry for at least `days` days, including devices never seen.
+// Devices in stock, in repair, lost or decommissioned are left out.
+// GET /v1/schools/{schoolId}/devices/unseen?days=&limit=&offset=
+func (h *NetworkDiscoveryHandler) ListUnseenDevices(w http.ResponseWriter, r *http.Request) {
+	days := h.cfg.NetworkUnseenDays
+	if v := strings.TrimSpace(r.URL.Query().Get("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 3650 {
+			http.Error(w, "days must be between 1 and 3650", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	limit := parseLimit(r.URL.Query().Get("limit"), 100, 500)
+	offset := parseOffset(r.URL.Query().Get("offset"))
+
+	now := time.Now().UTC()
+	cutoff := now.AddDate(0, 0, -days)
+	items, total, err := h.pg.NetworkDiscovery().ListUnseen(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "schoolId"), cutoff, limit, offset)
+	if err != nil {
+		h.log.Error("failed to list unseen devices", zap.Error(err))
+		http.Error(w, "failed to list unseen devices", http.StatusInternalServerError)
+		return
+	}
+	for i := range items {
+		items[i].DaysUnseen = service.DaysUnseen(items[i].LastSeenAt, now)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": items, "total": total, "days": days, "cutoff": cutoff, "limit": limit, "offset": offset,
+	})
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// GetChecklist returns a phase's checklist items and progress.
+func (h *PhasesHandler) GetChecklist(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	phase, err := h.pg.Phases().GetByID(r.Context(), tenant, chi.URLParam(r, "phaseId"))
+	if err != nil {
+		http.Error(w, "phase not found", http.StatusNotFound)
+		return
+	}
+	items, err := h.pg.PhaseChecklists().ListItems(r.Context(), tenant, phase.ID)
+	if err != nil {
+		http.Error(w, "failed to load checklist", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":    items,
+		"progress": service.SummarizeChecklist(phase, items),
+	})
+}
+
+type addChecklistItemReq struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Required    *bool  `json:"required"` // defaults to true
+}
+
+// AddChecklistItem adds an item to one phase's checklist without changing
+// the tenant's templates.
+func (h *PhasesHandler) AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	var req addChecklistItemReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		http.Error(w, "title required", http.StatusBadRequest)
+		return
+	}
+	phase, err := h.pg.Phases().GetByID(r.Context(), tenant, chi.URLParam(r, "phaseId"))
+	if err != nil {
+		http.Error(w, "phase not found", http.StatusNotFound)
+		return
+	}
+	if phase.Status == models.PhaseDone {
+		http.Error(w, "phase is done", http.StatusConflict)
+		return
+	}
+	required := true
+	if req.Required != nil {
+		required = *req.Required
+	}
+	item, err := h.pg.PhaseChecklists().AddItem(r.Context(), models.PhaseChecklistItem{
+		ID:          store.NewID("pci"),
+		TenantID:    tenant,
+		ProjectID:   phase.ProjectID,
+		PhaseID:     phase.ID,
+		Title:       title,
+		Description: strings.TrimSpace(req.Description),
+		Required:    required,
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		h.log.Error("failed to add checklist item", zap.Error(err))
+		http.Error(w, "failed to add checklist item", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+type completeChecklistItemReq struct {
+	EvidenceAttachmentID string `json:"evidenceAttachmentId"`
+	Notes                string `json:"notes"`
+}
+
+// CompleteChecklistItem marks an item done by the calling user. Evidence,
+// when given, must be an attachment on the same project.
+func (h *PhasesHandler) CompleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	var req completeChecklistItemReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	item, ok := h.openChecklistItem(w, r)
+	if !ok {
+		return
+	}
+	evidenceID := strings.TrimSpace(req.EvidenceAttachmentID)
+	if evidenceID != "" {
+		att, err := h.pg.ProjectActivities().GetAttachment(r.Context(), tenant, evidenceID)
+		if err != nil || att.ProjectID != item.ProjectID {
+			http.Error(w, "evidence attachment not found on this project", http.StatusBadRequest)
+			return
+		}
+	}
+	item, err := h.pg.PhaseChecklists().CompleteItem(r.Context(), tenant, item.ID,
+		middleware.UserID(r.Context()), middleware.UserName(r.Context()), evidenceID, strings.TrimSpace(req.Notes), time.Now().UTC())
+	if err != nil {
+		h.log.Error("failed to complete checklist item", zap.Error(err))
+		http.Error(w, "failed to complete checklist item", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// ReopenChecklistItem clears an item's completion.
+func (h *PhasesHandler) ReopenChecklistItem(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.openChecklistItem(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.pg.PhaseChecklists().ReopenItem(r.Context(), item.TenantID, item.ID, time.Now().UTC())
+	if err != nil {
+		h.log.Error("failed to reopen checklist item", zap.Error(err))
+		http.Error(w, "failed to reopen checklist item", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// openChecklistItem loads the {itemId} item, refusing items on a phase that
+// is already done: its checklist is the record of how it was completed.
+func (h *PhasesHandler) openChecklistItem(w http.ResponseWriter, r *http.Request) (models.PhaseChecklistItem, bool) {
+	tenant := middleware.TenantID(r.Context())
+	item, err := h.pg.PhaseChecklists().GetItem(r.Context(), tenant, chi.URLParam(r, "itemId"))
+	if err != nil {
+		http.Error(w, "checklist item not found", http.StatusNotFound)
+		return item, false
+	}
+	phase, err := h.pg.Phases().GetByID(r.Context(), tenant, item.PhaseID)
+	if err != nil {
+		http.Error(w, "phase not found", http.StatusNotFound)
+		return item, false
+	}
+	if phase.Status == models.PhaseDone {
+		http.Error(w, "phase is done", http.StatusConflict)
+		return item, false
+	}
+	return item, true
+}
+
+// GetProjectChecklistProgress reports checklist progress for each phase of
+// a project and across the whole project.
+func (h *PhasesHandler) GetProjectChecklistProgress(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	projectID := chi.URLParam(r, "id")
+	phases, _, err := h.pg.Phases().List(r.Context(), store.PhaseListParams{TenantID: tenant, ProjectID: projectID, Limit: 200})
+	if err != nil {
+		http.Error(w, "failed to list phases", http.StatusInternalServerError)
+		return
+	}
+	items, err := h.pg.PhaseChecklists().ListItemsByProject(r.Context(), tenant, projectID)
+	if err != nil {
+		http.Error(w, "failed to load checklists", http.StatusInternalServerError)
+		return
+	}
+	byPhase := map[string][]models.PhaseChecklistItem{}
+	for _, it := range items {
+		byPhase[it.PhaseID] = append(byPhase[it.PhaseID], it)
+	}
+
+	out := make([]models.PhaseChecklistProgress, 0, len(phases))
+	total := models.PhaseChecklistProgress{CanComplete: true}
+	for i := len(phases) - 1; i >= 0; i-- { // oldest phase first
+		p := service.SummarizeChecklist(phases[i], byPhase[phases[i].ID])
+		out = append(out, p)
+		total.Total += p.Total
+		total.Completed += p.Completed
+		total.RequiredTotal += p.RequiredTotal
+		total.RequiredCompleted += p.RequiredCompleted
+	}
+	total.Percent = 100
+	if total.Total > 0 {
+		total.Percent = total.Completed * 100 / total.Total
+	}
+	total.CanComplete = total.RequiredCompleted == total.RequiredTotal
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"projectId": projectID,
+		"phases":    out,
+		"total":     total,
+	})
+}
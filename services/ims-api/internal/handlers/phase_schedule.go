@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type updatePhaseScheduleReq struct {
+	PlannedStart string `json:"plannedStart"`
+	PlannedEnd   string `json:"plannedEnd"`
+	DurationDays int    `json:"durationDays"`
+}
+
+// UpdateSchedule replaces a phase's planned dates. Any two of start, end and
+// duration determine the third.
+func (h *PhasesHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	var req updatePhaseScheduleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	start, end, dur, err := service.PlanPhaseDates(strings.TrimSpace(req.PlannedStart), strings.TrimSpace(req.PlannedEnd), req.DurationDays)
+	if err != nil {
+		http.Error(w, "invalid schedule: dates are YYYY-MM-DD, end not before start, duration matching the dates", http.StatusBadRequest)
+		return
+	}
+	phase, err := h.pg.Phases().GetByID(r.Context(), tenant, chi.URLParam(r, "phaseId"))
+	if err != nil {
+		http.Error(w, "phase not found", http.StatusNotFound)
+		return
+	}
+	phase, err = h.pg.Phases().UpdateSchedule(r.Context(), tenant, phase.ID, start, end, dur)
+	if err != nil {
+		h.log.Error("failed to update phase schedule", zap.Error(err))
+		http.Error(w, "failed to update phase schedule", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, phase)
+}
+
+// ListDependencies returns a phase's predecessors and successors.
+func (h *PhasesHandler) ListDependencies(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	phaseID := chi.URLParam(r, "phaseId")
+	preds, succs, err := h.pg.PhaseDependencies().ListForPhase(r.Context(), tenant, phaseID)
+	if err != nil {
+		http.Error(w, "failed to list dependencies", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"predecessors": preds, "successors": succs})
+}
+
+type addPhaseDependencyReq struct {
+	PredecessorPhaseID string `json:"predecessorPhaseId"`
+	LagDays            int    `json:"lagDays"`
+}
+
+// AddDependency makes the {phaseId} phase wait for a predecessor, which may
+// be in another project. The phase is blocked at once if the predecessor is
+// not done.
+func (h *PhasesHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	var req addPhaseDependencyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	predID := strings.TrimSpace(req.PredecessorPhaseID)
+	if predID == "" {
+		http.Error(w, "predecessorPhaseId required", http.StatusBadRequest)
+		return
+	}
+	if req.LagDays < 0 {
+		http.Error(w, "lagDays must not be negative", http.StatusBadRequest)
+		return
+	}
+	succ, err := h.pg.Phases().GetByID(r.Context(), tenant, chi.URLParam(r, "phaseId"))
+	if err != nil {
+		http.Error(w, "phase not found", http.StatusNotFound)
+		return
+	}
+	if predID == succ.ID {
+		http.Error(w, "a phase cannot depend on itself", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.pg.Phases().GetByID(r.Context(), tenant, predID); err != nil {
+		http.Error(w, "predecessor phase not found", http.StatusBadRequest)
+		return
+	}
+	cycle, err := h.pg.PhaseDependencies().WouldCycle(r.Context(), tenant, predID, succ.ID)
+	if err != nil {
+		h.log.Error("failed to check dependency cycle", zap.Error(err))
+		http.Error(w, "failed to add dependency", http.StatusInternalServerError)
+		return
+	}
+	if cycle {
+		http.Error(w, "dependency would create a cycle", http.StatusConflict)
+		return
+	}
+
+	d := models.PhaseDependency{
+		ID:                 store.NewID("pdep"),
+		TenantID:           tenant,
+		PredecessorPhaseID: predID,
+		SuccessorPhaseID:   succ.ID,
+		LagDays:            req.LagDays,
+		CreatedBy:          middleware.UserID(r.Context()),
+		CreatedAt:          time.Now().UTC(),
+	}
+	if err := h.pg.PhaseDependencies().Create(r.Context(), d); err != nil {
+		if errors.Is(err, store.ErrDependencyExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.log.Error("failed to add dependency", zap.Error(err))
+		http.Error(w, "failed to add dependency", http.StatusInternalServerError)
+		return
+	}
+	h.refreshDependencyStatus(r.Context(), tenant, succ.ID)
+
+	created, err := h.pg.PhaseDependencies().Get(r.Context(), tenant, d.ID)
+	if err != nil {
+		writeJSON(w, http.StatusCreated, d)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// DeleteDependency removes a link, releasing the successor if it was
+// blocked only by it.
+func (h *PhasesHandler) DeleteDependency(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	d, err := h.pg.PhaseDependencies().Get(r.Context(), tenant, chi.URLParam(r, "dependencyId"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err := h.pg.PhaseDependencies().Delete(r.Context(), tenant, d.ID); err != nil {
+		h.log.Error("failed to delete dependency", zap.Error(err))
+		http.Error(w, "failed to delete dependency", http.StatusInternalServerError)
+		return
+	}
+	h.refreshDependencyStatus(r.Context(), tenant, d.SuccessorPhaseID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProjectSchedule returns the project's phases with planned, actual and
+// forecast dates, slippage and the critical path.
+func (h *PhasesHandler) GetProjectSchedule(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	project, err := h.pg.Projects().GetByID(r.Context(), tenant, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	}
+	phases, err := h.pg.Phases().ListAllForProject(r.Context(), tenant, project.ID)
+	if err != nil {
+		http.Error(w, "failed to list phases", http.StatusInternalServerError)
+		return
+	}
+	deps, err := h.pg.PhaseDependencies().ListForProject(r.Context(), tenant, project.ID)
+	if err != nil {
+		http.Error(w, "failed to list dependencies", http.StatusInternalServerError)
+		return
+	}
+	var externalIDs []string
+	for _, d := range deps {
+		if d.PredecessorProjectID != project.ID && d.SuccessorProjectID == project.ID {
+			externalIDs = append(externalIDs, d.PredecessorPhaseID)
+		}
+	}
+	external, err := h.pg.Phases().ListByIDs(r.Context(), tenant, externalIDs)
+	if err != nil {
+		http.Error(w, "failed to load predecessor phases", http.StatusInternalServerError)
+		return
+	}
+
+	tpl, err := projectTemplateFor(r.Context(), h.pg, project)
+	if err != nil {
+		h.log.Error("failed to load project template", zap.Error(err))
+		http.Error(w, "failed to load project template", http.StatusInternalServerError)
+		return
+	}
+
+	sched, err := service.BuildSchedule(project.ID, phases, external, deps, tpl.PhaseTypes(), time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, http.StatusOK, sched)
+}
+
+// incompletePredecessors returns the phase's predecessors that are not done.
+func (h *PhasesHandler) incompletePredecessors(ctx context.Context, tenant, phaseID string) ([]models.PhaseDependency, error) {
+	preds, _, err := h.pg.PhaseDependencies().ListForPhase(ctx, tenant, phaseID)
+	if err != nil {
+		return nil, err
+	}
+	var out []models.PhaseDependency
+	for _, d := range preds {
+		if d.PredecessorStatus != models.PhaseDone {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// refreshDependencyStatus blocks or releases a phase to match its
+// predecessors. Failures are logged: the status is corrected the next time
+// any of its dependencies change.
+func (h *PhasesHandler) refreshDependencyStatus(ctx context.Context, tenant, phaseID string) {
+	phase, err := h.pg.Phases().GetByID(ctx, tenant, phaseID)
+	if err != nil {
+		h.log.Warn("failed to load phase for dependency status", zap.String("phase_id", phaseID), zap.Error(err))
+		return
+	}
+	open, err := h.incompletePredecessors(ctx, tenant, phaseID)
+	if err != nil {
+		h.log.Warn("failed to load phase predecessors", zap.String("phase_id", phaseID), zap.Error(err))
+		return
+	}
+	status, blocked, changed := service.DependencyStatus(phase, len(open) > 0)
+	if !changed {
+		return
+	}
+	if err := h.pg.Phases().SetDependencyStatus(ctx, tenant, phaseID, status, blocked); err != nil {
+		h.log.Warn("failed to update phase dependency status", zap.String("phase_id", phaseID), zap.Error(err))
+	}
+}
+
+// refreshSuccessors re-evaluates every phase waiting on phaseID, in any
+// project.
+func (h *PhasesHandler) refreshSuccessors(ctx context.Context, tenant, phaseID string) {
+	_, succs, err := h.pg.PhaseDependencies().ListForPhase(ctx, tenant, phaseID)
+	if err != nil {
+		h.log.Warn("failed to load phase successors", zap.String("phase_id", phaseID), zap.Error(err))
+		return
+	}
+	for _, d := range succs {
+		h.refreshDependencyStatus(ctx, tenant, d.SuccessorPhaseID)
+	}
+}
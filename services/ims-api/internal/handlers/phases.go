@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
@@ -23,11 +25,14 @@ func NewPhasesHandler(log *zap.Logger, pg *store.Postgres) *PhasesHandler {
 }
 
 type createPhaseReq struct {
-	PhaseType models.PhaseType `json:"phaseType"`
-	OwnerRole string           `json:"ownerRole"`
-	StartDate string           `json:"startDate"`
-	EndDate   string           `json:"endDate"`
-	Notes     string           `json:"notes"`
+	PhaseType    models.PhaseType `json:"phaseType"`
+	OwnerRole    string           `json:"ownerRole"`
+	StartDate    string           `json:"startDate"`
+	EndDate      string           `json:"endDate"`
+	PlannedStart string           `json:"plannedStart"`
+	PlannedEnd   string           `json:"plannedEnd"`
+	DurationDays int              `json:"durationDays"`
+	Notes        string           `json:"notes"`
 }
 
 func (h *PhasesHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -41,22 +46,79 @@ func (h *PhasesHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "phaseType required", http.StatusBadRequest)
 		return
 	}
+	plannedStart, plannedEnd, duration, err := service.PlanPhaseDates(strings.TrimSpace(req.PlannedStart), strings.TrimSpace(req.PlannedEnd), req.DurationDays)
+	if err != nil {
+		http.Error(w, "invalid planned dates", http.StatusBadRequest)
+		return
+	}
 	tenant := middleware.TenantID(r.Context())
+	project, err := h.pg.Projects().GetByID(r.Context(), tenant, projectID)
+	if err != nil {
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	}
+	tpl, err := projectTemplateFor(r.Context(), h.pg, project)
+	if err != nil {
+		h.log.Error("failed to load project template", zap.Error(err))
+		http.Error(w, "failed to create phase", http.StatusInternalServerError)
+		return
+	}
+	def, ok := tpl.Phase(req.PhaseType)
+	if !ok {
+		http.Error(w, "phaseType is not part of this project's type", http.StatusBadRequest)
+		return
+	}
+	ownerRole := strings.TrimSpace(req.OwnerRole)
+	if ownerRole == "" {
+		ownerRole = def.OwnerRole
+	}
+	if duration == 0 {
+		if plannedStart, plannedEnd, duration, err = service.PlanPhaseDates(plannedStart, plannedEnd, def.DurationDays); err != nil {
+			http.Error(w, "invalid planned dates", http.StatusBadRequest)
+			return
+		}
+	}
+
 	now := time.Now().UTC()
 	p := models.ServicePhase{
-		ID:        store.NewID("phase"),
-		TenantID:  tenant,
-		ProjectID: projectID,
-		PhaseType: req.PhaseType,
-		Status:    models.PhasePending,
-		OwnerRole: strings.TrimSpace(req.OwnerRole),
-		StartDate: strings.TrimSpace(req.StartDate),
-		EndDate:   strings.TrimSpace(req.EndDate),
-		Notes:     strings.TrimSpace(req.Notes),
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-	if err := h.pg.Phases().Create(r.Context(), p); err != nil {
+		ID:           store.NewID("phase"),
+		TenantID:     tenant,
+		ProjectID:    projectID,
+		PhaseType:    req.PhaseType,
+		Status:       models.PhasePending,
+		OwnerRole:    ownerRole,
+		StartDate:    strings.TrimSpace(req.StartDate),
+		EndDate:      strings.TrimSpace(req.EndDate),
+		PlannedStart: plannedStart,
+		PlannedEnd:   plannedEnd,
+		DurationDays: duration,
+		Notes:        strings.TrimSpace(req.Notes),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	// The phase starts with a copy of its checklist: from the project's
+	// template for tenant-defined types, else the tenant's phase checklist
+	// templates.
+	templates := service.TemplateChecklist(tpl)
+	if tpl.Builtin {
+		if templates, err = h.pg.PhaseChecklists().List(r.Context(), tenant, string(p.PhaseType)); err != nil {
+			http.Error(w, "failed to load checklist templates", http.StatusInternalServerError)
+			return
+		}
+	}
+	items := service.ChecklistItemsForPhase(templates, project.ProjectType, p, now)
+	for i := range items {
+		items[i].ID = store.NewID("pci")
+	}
+
+	err = h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if err := store.CreatePhaseTx(ctx, tx, p); err != nil {
+			return err
+		}
+		return store.CreatePhaseChecklistItemsTx(ctx, tx, items)
+	})
+	if err != nil {
 		http.Error(w, "failed to create phase", http.StatusInternalServerError)
 		return
 	}
@@ -101,6 +163,28 @@ func (h *PhasesHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	phase, err := h.pg.Phases().GetByID(r.Context(), tenant, phaseID)
+	if err != nil {
+		http.Error(w, "phase not found", http.StatusNotFound)
+		return
+	}
+
+	// Gate: a phase cannot start or finish before its predecessors are done.
+	if req.Status == models.PhaseInProgress || req.Status == models.PhaseDone {
+		open, err := h.incompletePredecessors(r.Context(), tenant, phaseID)
+		if err != nil {
+			http.Error(w, "failed to load predecessors", http.StatusInternalServerError)
+			return
+		}
+		if len(open) > 0 {
+			writeJSON(w, http.StatusConflict, map[string]any{
+				"error":        "phase blocked: predecessors incomplete",
+				"predecessors": open,
+			})
+			return
+		}
+	}
+
 	// Gate: if moving to done, ensure all WOs under this phase have:
 	// - all deliverables approved
 	// - approval_status is approved or not_required
@@ -112,6 +196,19 @@ func (h *PhasesHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		checklist, err := h.pg.PhaseChecklists().ListItems(r.Context(), tenant, phaseID)
+		if err != nil {
+			http.Error(w, "failed to load checklist", http.StatusInternalServerError)
+			return
+		}
+		if outstanding := service.OutstandingRequired(checklist); len(outstanding) > 0 {
+			writeJSON(w, http.StatusConflict, map[string]any{
+				"error":       "phase blocked: required checklist items incomplete",
+				"outstanding": outstanding,
+			})
+			return
+		}
+
 		for _, wo := range wos {
 			cnt, err := h.pg.WorkOrderDeliverables().CountNotApprovedByWorkOrder(r.Context(), tenant, wo.SchoolID, wo.ID)
 			if err != nil {
@@ -134,14 +231,19 @@ func (h *PhasesHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update phase status
-	_, err := h.pg.RawPool().Exec(r.Context(), `
-		UPDATE service_phases SET status=$3, updated_at=$4
-		WHERE tenant_id=$1 AND id=$2
-	`, tenant, phaseID, req.Status, time.Now().UTC())
+	err = h.pg.Phases().UpdateStatus(r.Context(), tenant, phaseID, req.Status,
+		middleware.UserID(r.Context()), middleware.UserName(r.Context()))
 	if err != nil {
 		http.Error(w, "failed to update phase", http.StatusInternalServerError)
 		return
 	}
 
+	// A phase set back to pending waits again if its predecessors are open,
+	// and finishing (or reopening) a phase releases (or blocks) its successors.
+	h.refreshDependencyStatus(r.Context(), tenant, phaseID)
+	if (phase.Status == models.PhaseDone) != (req.Status == models.PhaseDone) {
+		h.refreshSuccessors(r.Context(), tenant, phaseID)
+	}
+
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
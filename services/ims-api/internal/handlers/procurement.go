@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/lookups"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// ProcurementHandler manages reorder policies, reorder proposals and
+// purchase orders. Vendor SKUs come from the ssot-parts export snapshot.
+type ProcurementHandler struct {
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
+}
+
+func NewProcurementHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *ProcurementHandler {
+	return &ProcurementHandler{log: log, pg: pg, audit: auditLogger}
+}
+
+func (h *ProcurementHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	items, err := h.pg.ReorderPolicies().List(r.Context(), middleware.TenantID(r.Context()),
+		strings.TrimSpace(r.URL.Query().Get("serviceShopId")))
+	if err != nil {
+		writeLedgerError(h.log, w, err, "list reorder policies")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+type reorderPolicyReq struct {
+	ServiceShopID string `json:"serviceShopId"`
+	PartID        string `json:"partId"`
+	ReorderPoint  int64  `json:"reorderPoint"`
+	SafetyStock   int64  `json:"safetyStock"`
+	MinOrderQty   int64  `json:"minOrderQty"`
+}
+
+// UpsertPolicy sets the reorder point and safety stock for a shop and part.
+func (h *ProcurementHandler) UpsertPolicy(w http.ResponseWriter, r *http.Request) {
+	var req reorderPolicyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	p := models.ReorderPolicy{
+		TenantID:      middleware.TenantID(r.Context()),
+		ServiceShopID: strings.TrimSpace(req.ServiceShopID),
+		PartID:        strings.TrimSpace(req.PartID),
+		ReorderPoint:  req.ReorderPoint,
+		SafetyStock:   req.SafetyStock,
+		MinOrderQty:   req.MinOrderQty,
+		UpdatedAt:     time.Now().UTC(),
+	}
+	if p.ServiceShopID == "" || p.PartID == "" {
+		http.Error(w, "serviceShopId and partId are required", http.StatusBadRequest)
+		return
+	}
+	if p.ReorderPoint < 0 || p.SafetyStock < 0 || p.MinOrderQty < 0 {
+		http.Error(w, "reorderPoint, safetyStock and minOrderQty must be >= 0", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.pg.ServiceShops().GetByID(r.Context(), p.TenantID, p.ServiceShopID); err != nil {
+		http.Error(w, "service shop not found", http.StatusBadRequest)
+		return
+	}
+	if err := h.pg.ReorderPolicies().Upsert(r.Context(), p); err != nil {
+		writeLedgerError(h.log, w, err, "save reorder policy")
+		return
+	}
+	if err := h.audit.LogUpdate(r.Context(), "reorder_policy", p.ServiceShopID+"/"+p.PartID, nil, p); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (h *ProcurementHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	shopID, partID := chi.URLParam(r, "shopId"), chi.URLParam(r, "partId")
+	if err := h.pg.ReorderPolicies().Delete(r.Context(), middleware.TenantID(r.Context()), shopID, partID); err != nil {
+		writeLedgerError(h.log, w, err, "delete reorder policy")
+		return
+	}
+	if err := h.audit.LogDelete(r.Context(), "reorder_policy", shopID+"/"+partID, nil); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// proposals computes reorder proposals for a tenant, optionally limited to a
+// shop and a set of parts.
+func (h *ProcurementHandler) proposals(ctx context.Context, tenant, shopID string, strategy models.VendorStrategy, partIDs []string) ([]models.ReorderProposal, error) {
+	candidates, err := h.pg.ReorderPolicies().ReorderCandidates(ctx, tenant, shopID)
+	if err != nil {
+		return nil, err
+	}
+	if len(partIDs) > 0 {
+		want := map[string]bool{}
+		for _, id := range partIDs {
+			want[strings.TrimSpace(id)] = true
+		}
+		kept := candidates[:0]
+		for _, c := range candidates {
+			if want[c.PartID] {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+	// Without a parts snapshot every proposal is flagged noVendor.
+	skus, err := lookups.New(h.pg.RawPool()).VendorSKUsByPart(ctx, tenant)
+	if err != nil && !errors.Is(err, lookups.ErrSnapshotMissing) {
+		return nil, fmt.Errorf("load vendor skus: %w", err)
+	}
+	return service.ProposeReorders(candidates, skus, strategy), nil
+}
+
+func parseVendorStrategy(raw string) (models.VendorStrategy, bool) {
+	switch s := models.VendorStrategy(strings.TrimSpace(raw)); s {
+	case "":
+		return models.VendorCheapest, true
+	case models.VendorCheapest, models.VendorFastest:
+		return s, true
+	}
+	return "", false
+}
+
+// ListProposals returns parts at or below their reorder point with a
+// suggested quantity and vendor SKU.
+func (h *ProcurementHandler) ListProposals(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	strategy, ok := parseVendorStrategy(q.Get("strategy"))
+	if !ok {
+		http.Error(w, "strategy must be cheapest or fastest", http.StatusBadRequest)
+		return
+	}
+	items, err := h.proposals(r.Context(), middleware.TenantID(r.Context()), strings.TrimSpace(q.Get("serviceShopId")), strategy, nil)
+	if err != nil {
+		writeLedgerError(h.log, w, err, "compute reorder proposals")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "strategy": strategy})
+}
+
+type applyProposalsReq struct {
+	ServiceShopID string   `json:"serviceShopId"`
+	Strategy      string   `json:"strategy"`
+	PartIDs       []string `json:"partIds"`
+	Notes         string   `json:"notes"`
+}
+
+// ApplyProposals turns the current proposals into draft purchase orders,
+// one per shop, vendor and currency. Proposals without a vendor are skipped.
+func (h *ProcurementHandler) ApplyProposals(w http.ResponseWriter, r *http.Request) {
+	var req applyProposalsReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	strategy, ok := parseVendorStrategy(req.Strategy)
+	if !ok {
+		http.Error(w, "strategy must be cheapest or fastest", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	props, err := h.proposals(ctx, tenant, strings.TrimSpace(req.ServiceShopID), strategy, req.PartIDs)
+	if err != nil {
+		writeLedgerError(h.log, w, err, "compute reorder proposals")
+		return
+	}
+
+	now := time.Now().UTC()
+	type poKey struct{ shop, vendor, currency string }
+	byKey := map[poKey]*models.PurchaseOrder{}
+	leadDays := map[poKey]int{}
+	var order []poKey
+	skipped := []models.ReorderProposal{}
+	for _, p := range props {
+		if p.NoVendor {
+			skipped = append(skipped, p)
+			continue
+		}
+		k := poKey{p.ServiceShopID, p.VendorID, p.Currency}
+		po, ok := byKey[k]
+		if !ok {
+			po = &models.PurchaseOrder{
+				ID:            store.NewID("po"),
+				TenantID:      tenant,
+				ServiceShopID: p.ServiceShopID,
+				VendorID:      p.VendorID,
+				Status:        models.PODraft,
+				Currency:      p.Currency,
+				Notes:         strings.TrimSpace(req.Notes),
+				CreatedBy:     middleware.UserID(ctx),
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			byKey[k] = po
+			order = append(order, k)
+		}
+		po.Lines = append(po.Lines, models.PurchaseOrderLine{
+			PartID:         p.PartID,
+			VendorSKUID:    p.VendorSKUID,
+			SKU:            p.SKU,
+			QtyOrdered:     p.ProposedQty,
+			UnitPriceCents: p.UnitPriceCents,
+		})
+		if p.LeadTimeDays > leadDays[k] {
+			leadDays[k] = p.LeadTimeDays
+		}
+	}
+
+	created := make([]models.PurchaseOrder, 0, len(order))
+	err = h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		for _, k := range order {
+			po := byKey[k]
+			po.TotalCents = store.PurchaseOrderTotal(po.Lines)
+			expected := now.AddDate(0, 0, leadDays[k])
+			po.ExpectedAt = &expected
+			if err := store.CreatePurchaseOrderTx(ctx, tx, *po); err != nil {
+				return err
+			}
+			created = append(created, *po)
+		}
+		return nil
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, "create purchase orders")
+		return
+	}
+	for _, po := range created {
+		if err := h.audit.LogCreate(ctx, "purchase_order", po.ID, po); err != nil {
+			h.log.Error("failed to write audit log", zap.Error(err))
+		}
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"purchaseOrders": created, "skipped": skipped})
+}
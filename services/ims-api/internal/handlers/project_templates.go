@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	errUnknownProjectType = errors.New("unknown project type")
+	errRetiredProjectType = errors.New("project type is retired")
+)
+
+// activeProjectTemplate returns the version of a project type new projects
+// are created from: the tenant's latest version, else the built-in type.
+func activeProjectTemplate(ctx context.Context, pg *store.Postgres, tenant string, projectType models.ProjectType) (models.ProjectTemplate, error) {
+	t, err := pg.ProjectTemplates().Latest(ctx, tenant, projectType)
+	switch {
+	case err == nil && t.RetiredAt != nil:
+		return t, errRetiredProjectType
+	case err == nil:
+		return t, nil
+	case err.Error() != "not found":
+		return t, err
+	}
+	if b, ok := models.BuiltinProjectTemplate(projectType); ok {
+		return b, nil
+	}
+	return t, errUnknownProjectType
+}
+
+// projectTemplateFor returns the template version a project was created
+// from, which later edits to its type do not change.
+func projectTemplateFor(ctx context.Context, pg *store.Postgres, p models.SchoolServiceProject) (models.ProjectTemplate, error) {
+	if p.TemplateID != "" {
+		return pg.ProjectTemplates().GetByID(ctx, p.TenantID, p.TemplateID)
+	}
+	if b, ok := models.BuiltinProjectTemplate(p.ProjectType); ok {
+		return b, nil
+	}
+	return models.ProjectTemplate{}, errUnknownProjectType
+}
+
+// tenantProjectTemplates lists the latest version of each project type the
+// tenant can use: its own types, then built-in types it has not redefined.
+func tenantProjectTemplates(ctx context.Context, pg *store.Postgres, tenant string, includeRetired bool) ([]models.ProjectTemplate, error) {
+	own, err := pg.ProjectTemplates().ListLatest(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defined := map[models.ProjectType]bool{}
+	out := make([]models.ProjectTemplate, 0, len(own)+len(models.ProjectTypeConfigs))
+	for _, t := range own {
+		defined[t.ProjectType] = true
+		if t.RetiredAt == nil || includeRetired {
+			out = append(out, t)
+		}
+	}
+	for _, pt := range models.ValidProjectTypes() {
+		if !defined[pt] {
+			b, _ := models.BuiltinProjectTemplate(pt)
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// ProjectTemplatesHandler manages tenant-defined project types.
+type ProjectTemplatesHandler struct {
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
+}
+
+func NewProjectTemplatesHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *ProjectTemplatesHandler {
+	return &ProjectTemplatesHandler{log: log, pg: pg, audit: auditLogger}
+}
+
+// List returns the latest version of every project type available to the
+// tenant.
+func (h *ProjectTemplatesHandler) List(w http.ResponseWriter, r *http.Request) {
+	includeRetired := r.URL.Query().Get("includeRetired") == "true"
+	items, err := tenantProjectTemplates(r.Context(), h.pg, middleware.TenantID(r.Context()), includeRetired)
+	if err != nil {
+		h.log.Error("failed to list project templates", zap.Error(err))
+		http.Error(w, "failed to list project templates", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// Get returns a project type's latest version, or ?version=N. Version 0 is
+// the built-in definition.
+func (h *ProjectTemplatesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	projectType := models.ProjectType(chi.URLParam(r, "projectType"))
+
+	if v := strings.TrimSpace(r.URL.Query().Get("version")); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 0 {
+			http.Error(w, "invalid version", http.StatusBadRequest)
+			return
+		}
+		if version == 0 {
+			if b, ok := models.BuiltinProjectTemplate(projectType); ok {
+				writeJSON(w, http.StatusOK, b)
+				return
+			}
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		t, err := h.pg.ProjectTemplates().GetVersion(r.Context(), tenant, projectType, version)
+		if err != nil {
+			if err.Error() == "not found" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			h.log.Error("failed to get project template", zap.Error(err))
+			http.Error(w, "failed to get project template", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+		return
+	}
+
+	t, err := activeProjectTemplate(r.Context(), h.pg, tenant, projectType)
+	switch {
+	case errors.Is(err, errUnknownProjectType):
+		http.Error(w, "not found", http.StatusNotFound)
+	case err != nil && !errors.Is(err, errRetiredProjectType):
+		h.log.Error("failed to load project template", zap.Error(err))
+		http.Error(w, "failed to load project template", http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, t)
+	}
+}
+
+// ListVersions returns every stored version of a project type, newest
+// first.
+func (h *ProjectTemplatesHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	items, err := h.pg.ProjectTemplates().ListVersions(r.Context(), middleware.TenantID(r.Context()), models.ProjectType(chi.URLParam(r, "projectType")))
+	if err != nil {
+		http.Error(w, "failed to list versions", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// Create defines a new project type, or redefines a built-in one for the
+// tenant. Creating a retired type restores it with a new version.
+func (h *ProjectTemplatesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.ProjectTemplate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	tenant := middleware.TenantID(r.Context())
+	if existing, err := h.pg.ProjectTemplates().Latest(r.Context(), tenant, models.ProjectType(strings.TrimSpace(string(req.ProjectType)))); err == nil && existing.RetiredAt == nil {
+		http.Error(w, "project type already exists; PUT to add a version", http.StatusConflict)
+		return
+	}
+	t, ok := h.saveVersion(w, r, req)
+	if !ok {
+		return
+	}
+	if err := h.audit.LogCreate(r.Context(), "project_template", t.ID, t); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// Update stores a new version of a project type. Projects already created
+// keep the version they started with.
+func (h *ProjectTemplatesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req models.ProjectTemplate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.ProjectType = models.ProjectType(chi.URLParam(r, "projectType"))
+	before, err := activeProjectTemplate(r.Context(), h.pg, middleware.TenantID(r.Context()), req.ProjectType)
+	switch {
+	case errors.Is(err, errUnknownProjectType):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errRetiredProjectType):
+		http.Error(w, "project type is retired; POST to restore it", http.StatusConflict)
+		return
+	case err != nil:
+		h.log.Error("failed to load project template", zap.Error(err))
+		http.Error(w, "failed to update project template", http.StatusInternalServerError)
+		return
+	}
+	t, ok := h.saveVersion(w, r, req)
+	if !ok {
+		return
+	}
+	if err := h.audit.LogUpdate(r.Context(), "project_template", t.ID, before, t); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *ProjectTemplatesHandler) saveVersion(w http.ResponseWriter, r *http.Request, req models.ProjectTemplate) (models.ProjectTemplate, bool) {
+	t, err := service.NormalizeProjectTemplate(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return t, false
+	}
+	t.ID = store.NewID("ptt")
+	t.TenantID = middleware.TenantID(r.Context())
+	t.Builtin = false
+	t.RetiredAt = nil
+	t.CreatedBy = middleware.UserID(r.Context())
+	t.CreatedAt = time.Now().UTC()
+	t, err = h.pg.ProjectTemplates().CreateVersion(r.Context(), t)
+	if err != nil {
+		if errors.Is(err, store.ErrTemplateVersionConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return t, false
+		}
+		h.log.Error("failed to save project template", zap.Error(err))
+		http.Error(w, "failed to save project template", http.StatusInternalServerError)
+		return t, false
+	}
+	return t, true
+}
+
+// Retire stops offering a project type for new projects. A built-in type is
+// retired by storing its definition as the tenant's first version.
+func (h *ProjectTemplatesHandler) Retire(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	projectType := models.ProjectType(chi.URLParam(r, "projectType"))
+	t, err := activeProjectTemplate(r.Context(), h.pg, tenant, projectType)
+	switch {
+	case errors.Is(err, errUnknownProjectType), errors.Is(err, errRetiredProjectType):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case err != nil:
+		h.log.Error("failed to load project template", zap.Error(err))
+		http.Error(w, "failed to retire project template", http.StatusInternalServerError)
+		return
+	}
+	if t.Builtin {
+		var ok bool
+		if t, ok = h.saveVersion(w, r, t); !ok {
+			return
+		}
+	}
+	if _, err := h.pg.ProjectTemplates().Retire(r.Context(), tenant, projectType, time.Now().UTC()); err != nil {
+		h.log.Error("failed to retire project template", zap.Error(err))
+		http.Error(w, "failed to retire project template", http.StatusInternalServerError)
+		return
+	}
+	if err := h.audit.LogDelete(r.Context(), "project_template", t.ID, t); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -119,7 +119,7 @@ func (h *ProjectWorkOrdersHandler) CreateFromProject(w http.ResponseWriter, r *h
 		UpdatedAt: now,
 	}
 
-	if err := h.pg.WorkOrders().Create(r.Context(), wo); err != nil {
+	if err := createWorkOrderWithEvent(r.Context(), h.pg, wo, userID); err != nil {
 		h.log.Error("failed to create work order from project", zap.Error(err))
 		http.Error(w, "failed to create work order", http.StatusInternalServerError)
 		return
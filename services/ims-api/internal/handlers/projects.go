@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
@@ -48,14 +51,19 @@ func (h *ProjectsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		projectType = models.ProjectTypeFullInstallation
 	}
 
-	// Validate project type and get config
-	config, ok := models.ProjectTypeConfigs[projectType]
-	if !ok {
+	// Resolve the project type's current version; the project keeps it.
+	tenant := middleware.TenantID(r.Context())
+	tpl, err := activeProjectTemplate(r.Context(), h.pg, tenant, projectType)
+	switch {
+	case errors.Is(err, errUnknownProjectType), errors.Is(err, errRetiredProjectType):
 		http.Error(w, "invalid projectType", http.StatusBadRequest)
 		return
+	case err != nil:
+		h.log.Error("failed to load project template", zap.Error(err))
+		http.Error(w, "failed to create project", http.StatusInternalServerError)
+		return
 	}
 
-	tenant := middleware.TenantID(r.Context())
 	now := time.Now().UTC()
 	p := models.SchoolServiceProject{
 		ID:                   store.NewID("proj"),
@@ -63,15 +71,32 @@ func (h *ProjectsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		SchoolID:             strings.TrimSpace(req.SchoolID),
 		ProjectType:          projectType,
 		Status:               models.ProjectActive,
-		CurrentPhase:         config.DefaultPhase,
+		CurrentPhase:         tpl.DefaultPhase,
 		StartDate:            strings.TrimSpace(req.StartDate),
 		GoLiveDate:           strings.TrimSpace(req.GoLiveDate),
 		AccountManagerUserID: strings.TrimSpace(req.AccountManagerUserID),
 		Notes:                strings.TrimSpace(req.Notes),
+		TemplateID:           tpl.ID,
+		TemplateVersion:      tpl.Version,
 		CreatedAt:            now,
 		UpdatedAt:            now,
 	}
-	if err := h.pg.Projects().Create(r.Context(), p); err != nil {
+	boq := service.TemplateBOQItems(tpl, p, now)
+	for i := range boq {
+		boq[i].ID = store.NewID("boq")
+	}
+	err = h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if err := store.CreateProjectTx(ctx, tx, p); err != nil {
+			return err
+		}
+		for _, b := range boq {
+			if err := store.CreateBOQItemTx(ctx, tx, b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		http.Error(w, "failed to create project", http.StatusInternalServerError)
 		return
 	}
@@ -114,15 +139,39 @@ func (h *ProjectsHandler) List(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"items": items, "nextCursor": next})
 }
 
-// GetProjectTypes returns all available project type configurations.
+// GetProjectTypes returns the project types the tenant can create:
+// built-in types and its own.
 func (h *ProjectsHandler) GetProjectTypes(w http.ResponseWriter, r *http.Request) {
-	configs := make([]models.ProjectTypeConfig, 0, len(models.ProjectTypeConfigs))
-	for _, pt := range models.ValidProjectTypes() {
-		configs = append(configs, models.ProjectTypeConfigs[pt])
+	templates, err := tenantProjectTemplates(r.Context(), h.pg, middleware.TenantID(r.Context()), false)
+	if err != nil {
+		h.log.Error("failed to list project types", zap.Error(err))
+		http.Error(w, "failed to list project types", http.StatusInternalServerError)
+		return
+	}
+	configs := make([]models.ProjectTypeConfig, 0, len(templates))
+	for _, t := range templates {
+		configs = append(configs, t.Config())
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"items": configs})
 }
 
+// GetTemplate returns the project type version the project was created
+// from.
+func (h *ProjectsHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	p, err := h.pg.Projects().GetByID(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	t, err := projectTemplateFor(r.Context(), h.pg, p)
+	if err != nil {
+		h.log.Error("failed to load project template", zap.Error(err))
+		http.Error(w, "failed to load project template", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
 // GetProjectTypeCounts returns the count of projects for each project type.
 func (h *ProjectsHandler) GetProjectTypeCounts(w http.ResponseWriter, r *http.Request) {
 	tenant := middleware.TenantID(r.Context())
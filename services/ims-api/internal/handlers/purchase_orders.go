@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/lookups"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type poLineReq struct {
+	PartID         string `json:"partId"`
+	Qty            int64  `json:"qty"`
+	UnitPriceCents int64  `json:"unitPriceCents"` // defaults to the vendor SKU price
+}
+
+type createPurchaseOrderReq struct {
+	ServiceShopID string      `json:"serviceShopId"`
+	VendorID      string      `json:"vendorId"`
+	Currency      string      `json:"currency"`
+	Notes         string      `json:"notes"`
+	ExpectedAt    *time.Time  `json:"expectedAt"`
+	Lines         []poLineReq `json:"lines"`
+}
+
+// buildPOLines validates request lines and fills SKU and price from the
+// vendor's SKUs in ssot-parts. A line needs a vendor SKU unless it gives
+// unitPriceCents > 0. SKU prices must be in the PO currency; when currency
+// is empty it is taken from the first SKU. It returns the lines, the
+// currency, a validation message and any lookup error.
+func (h *ProcurementHandler) buildPOLines(ctx context.Context, tenant, vendorID, currency string, reqLines []poLineReq) ([]models.PurchaseOrderLine, string, string, error) {
+	if len(reqLines) == 0 {
+		return nil, "", "at least one line is required", nil
+	}
+	// Without a parts snapshot there are no SKUs, so every line needs a price.
+	skus, err := lookups.New(h.pg.RawPool()).VendorSKUsByPart(ctx, tenant)
+	if err != nil && !errors.Is(err, lookups.ErrSnapshotMissing) {
+		return nil, "", "", fmt.Errorf("load vendor skus: %w", err)
+	}
+	seen := map[string]bool{}
+	lines := make([]models.PurchaseOrderLine, 0, len(reqLines))
+	for _, l := range reqLines {
+		partID := strings.TrimSpace(l.PartID)
+		if partID == "" || l.Qty <= 0 || l.UnitPriceCents < 0 {
+			return nil, "", "each line needs a partId, qty > 0 and unitPriceCents >= 0", nil
+		}
+		if seen[partID] {
+			return nil, "", "part " + partID + " appears more than once", nil
+		}
+		seen[partID] = true
+		line := models.PurchaseOrderLine{PartID: partID, QtyOrdered: l.Qty, UnitPriceCents: l.UnitPriceCents}
+		var sku *lookups.VendorSKU
+		for i := range skus[partID] {
+			if skus[partID][i].VendorID == vendorID {
+				sku = &skus[partID][i]
+				break
+			}
+		}
+		switch {
+		case sku != nil:
+			line.VendorSKUID, line.SKU = sku.ID, sku.SKU
+			if line.UnitPriceCents == 0 {
+				skuCurrency := strings.ToUpper(sku.Currency)
+				if currency == "" {
+					currency = skuCurrency
+				} else if skuCurrency != "" && skuCurrency != currency {
+					return nil, "", "part " + partID + " is priced in " + skuCurrency + ", not " + currency, nil
+				}
+				line.UnitPriceCents = sku.UnitPriceCents
+			}
+		case line.UnitPriceCents == 0:
+			return nil, "", "part " + partID + " has no SKU from this vendor; give unitPriceCents", nil
+		}
+		lines = append(lines, line)
+	}
+	return lines, currency, "", nil
+}
+
+// CreatePurchaseOrder creates a draft purchase order.
+func (h *ProcurementHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req createPurchaseOrderReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	shopID, vendorID := strings.TrimSpace(req.ServiceShopID), strings.TrimSpace(req.VendorID)
+	if shopID == "" || vendorID == "" {
+		http.Error(w, "serviceShopId and vendorId are required", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.pg.ServiceShops().GetByID(ctx, tenant, shopID); err != nil {
+		http.Error(w, "service shop not found", http.StatusBadRequest)
+		return
+	}
+	lines, currency, problem, err := h.buildPOLines(ctx, tenant, vendorID, strings.ToUpper(strings.TrimSpace(req.Currency)), req.Lines)
+	if err != nil {
+		h.log.Error("failed to build purchase order lines", zap.Error(err))
+		http.Error(w, "failed to create purchase order", http.StatusInternalServerError)
+		return
+	}
+	if problem != "" {
+		http.Error(w, problem, http.StatusBadRequest)
+		return
+	}
+	if currency == "" {
+		currency = "KES"
+	}
+
+	now := time.Now().UTC()
+	po := models.PurchaseOrder{
+		ID:            store.NewID("po"),
+		TenantID:      tenant,
+		ServiceShopID: shopID,
+		VendorID:      vendorID,
+		Status:        models.PODraft,
+		Currency:      currency,
+		TotalCents:    store.PurchaseOrderTotal(lines),
+		Notes:         strings.TrimSpace(req.Notes),
+		CreatedBy:     middleware.UserID(ctx),
+		ExpectedAt:    req.ExpectedAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Lines:         lines,
+	}
+	err = h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		return store.CreatePurchaseOrderTx(ctx, tx, po)
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, "create purchase order")
+		return
+	}
+	if err := h.audit.LogCreate(ctx, "purchase_order", po.ID, po); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusCreated, po)
+}
+
+func (h *ProcurementHandler) ListPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	items, err := h.pg.PurchaseOrders().List(r.Context(), store.PurchaseOrderListParams{
+		TenantID: middleware.TenantID(r.Context()),
+		ShopID:   strings.TrimSpace(q.Get("serviceShopId")),
+		VendorID: strings.TrimSpace(q.Get("vendorId")),
+		Status:   strings.TrimSpace(q.Get("status")),
+		Limit:    parseLimit(q.Get("limit"), 50, 200),
+		Offset:   parseOffset(q.Get("offset")),
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, "list purchase orders")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (h *ProcurementHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	po, err := h.pg.PurchaseOrders().Get(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		writeLedgerError(h.log, w, err, "get purchase order")
+		return
+	}
+	writeJSON(w, http.StatusOK, po)
+}
+
+// updatePurchaseOrder loads a purchase order under lock, applies fn and saves
+// it, writing the change to the audit log.
+func (h *ProcurementHandler) updatePurchaseOrder(w http.ResponseWriter, r *http.Request, action string, fn func(ctx context.Context, tx store.Tx, po *models.PurchaseOrder) error) {
+	ctx := r.Context()
+	var before, po models.PurchaseOrder
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		po, err = store.GetPurchaseOrderTx(ctx, tx, middleware.TenantID(ctx), chi.URLParam(r, "id"))
+		if err != nil {
+			return err
+		}
+		before = po
+		if err := fn(ctx, tx, &po); err != nil {
+			return err
+		}
+		po.UpdatedAt = time.Now().UTC()
+		return store.UpdatePurchaseOrderTx(ctx, tx, po)
+	})
+	if err != nil {
+		writeLedgerError(h.log, w, err, action)
+		return
+	}
+	if err := h.audit.LogUpdate(ctx, "purchase_order", po.ID, before, po); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, po)
+}
+
+func requirePOStatus(po *models.PurchaseOrder, allowed ...models.PurchaseOrderStatus) error {
+	for _, s := range allowed {
+		if po.Status == s {
+			return nil
+		}
+	}
+	return stockStateError("purchase order is " + string(po.Status))
+}
+
+// ReplacePurchaseOrderLines replaces the lines of a draft purchase order.
+func (h *ProcurementHandler) ReplacePurchaseOrderLines(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Lines []poLineReq `json:"lines"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	h.updatePurchaseOrder(w, r, "update purchase order", func(ctx context.Context, tx store.Tx, po *models.PurchaseOrder) error {
+		if err := requirePOStatus(po, models.PODraft); err != nil {
+			return err
+		}
+		lines, _, problem, err := h.buildPOLines(ctx, po.TenantID, po.VendorID, po.Currency, req.Lines)
+		if err != nil {
+			return err
+		}
+		if problem != "" {
+			return stockStateError(problem)
+		}
+		po.Lines = lines
+		po.TotalCents = store.PurchaseOrderTotal(lines)
+		return store.ReplacePurchaseOrderLinesTx(ctx, tx, *po)
+	})
+}
+
+// ApprovePurchaseOrder approves a draft. The creator cannot approve their own
+// purchase order.
+func (h *ProcurementHandler) ApprovePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.updatePurchaseOrder(w, r, "approve purchase order", func(ctx context.Context, tx store.Tx, po *models.PurchaseOrder) error {
+		if err := requirePOStatus(po, models.PODraft); err != nil {
+			return err
+		}
+		actor := middleware.UserID(ctx)
+		if actor != "" && actor == po.CreatedBy {
+			return stockStateError("a purchase order cannot be approved by its creator")
+		}
+		now := time.Now().UTC()
+		po.Status = models.POApproved
+		po.ApprovedBy = actor
+		po.ApprovedAt = &now
+		return nil
+	})
+}
+
+// SendPurchaseOrder marks an approved purchase order as sent to the vendor.
+func (h *ProcurementHandler) SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.updatePurchaseOrder(w, r, "send purchase order", func(ctx context.Context, tx store.Tx, po *models.PurchaseOrder) error {
+		if err := requirePOStatus(po, models.POApproved); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		po.Status = models.POSent
+		po.SentAt = &now
+		return nil
+	})
+}
+
+type receivePurchaseOrderReq struct {
+	Reference string `json:"reference"` // delivery note / invoice number
+	Lines     []struct {
+		PartID string `json:"partId"`
+		Qty    int64  `json:"qty"`
+	} `json:"lines"`
+}
+
+// ReceivePurchaseOrder books delivered quantities into the shop as ledger
+// receipts. The order closes once every line is fully received.
+func (h *ProcurementHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req receivePurchaseOrderReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if len(req.Lines) == 0 {
+		http.Error(w, "at least one line is required", http.StatusBadRequest)
+		return
+	}
+	for _, rl := range req.Lines {
+		if strings.TrimSpace(rl.PartID) == "" || rl.Qty <= 0 {
+			http.Error(w, "each line needs a partId and qty > 0", http.StatusBadRequest)
+			return
+		}
+	}
+	h.updatePurchaseOrder(w, r, "receive purchase order", func(ctx context.Context, tx store.Tx, po *models.PurchaseOrder) error {
+		if err := requirePOStatus(po, models.POSent, models.POPartiallyReceived); err != nil {
+			return err
+		}
+		for _, rl := range req.Lines {
+			partID := strings.TrimSpace(rl.PartID)
+			idx := -1
+			for i := range po.Lines {
+				if po.Lines[i].PartID == partID {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				return stockStateError("part " + partID + " is not on this purchase order")
+			}
+			line := &po.Lines[idx]
+			if line.QtyReceived+rl.Qty > line.QtyOrdered {
+				return stockStateError("received more than ordered for part " + partID)
+			}
+			line.QtyReceived += rl.Qty
+			if _, err := store.PostStockMovementTx(ctx, tx, models.StockMovement{
+				TenantID:      po.TenantID,
+				Type:          models.StockReceipt,
+				ServiceShopID: po.ServiceShopID,
+				PartID:        partID,
+				Qty:           rl.Qty,
+				ReferenceType: "purchase_order",
+				ReferenceID:   po.ID,
+				Notes:         strings.TrimSpace(req.Reference),
+				ActorID:       middleware.UserID(ctx),
+			}); err != nil {
+				return err
+			}
+		}
+
+		po.Status = models.POClosed
+		for _, l := range po.Lines {
+			if l.QtyReceived < l.QtyOrdered {
+				po.Status = models.POPartiallyReceived
+				break
+			}
+		}
+		if po.Status == models.POClosed {
+			now := time.Now().UTC()
+			po.ClosedAt = &now
+		}
+		return nil
+	})
+}
+
+// ClosePurchaseOrder short-closes a sent or partially received order; the
+// outstanding quantity is no longer counted as on order.
+func (h *ProcurementHandler) ClosePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.updatePurchaseOrder(w, r, "close purchase order", func(ctx context.Context, tx store.Tx, po *models.PurchaseOrder) error {
+		if err := requirePOStatus(po, models.POSent, models.POPartiallyReceived); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		po.Status = models.POClosed
+		po.ClosedAt = &now
+		return nil
+	})
+}
+
+// CancelPurchaseOrder cancels an order before anything has been received.
+func (h *ProcurementHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.updatePurchaseOrder(w, r, "cancel purchase order", func(ctx context.Context, tx store.Tx, po *models.PurchaseOrder) error {
+		if err := requirePOStatus(po, models.PODraft, models.POApproved, models.POSent); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		po.Status = models.POCancelled
+		po.ClosedAt = &now
+		return nil
+	})
+}
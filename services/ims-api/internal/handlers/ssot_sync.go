@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/config"
 	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
 	"github.com/edvirons/ssp/ims/internal/ssot"
 	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
+	"github.com/edvirons/ssp/shared/pkg/cursor"
 	"go.uber.org/zap"
 )
 
@@ -184,119 +190,164 @@ func (h *SSOTSyncHandler) syncHR(w http.ResponseWriter, r *http.Request, res sto
 	})
 }
 
+// ssotFeed describes how one SSOT change feed maps onto a snapshot table.
+type ssotFeed[T any] struct {
+	fetch  func(since time.Time, cursor string, limit int) (changefeed.Page[T], error)
+	key    func(T) (time.Time, string)
+	upsert func(ctx context.Context, tx store.Tx, tenantID string, item T) error
+	remove func(ctx context.Context, tx store.Tx, tenantID, id string) error
+}
+
+// ssotSyncResult counts what one sync applied.
+type ssotSyncResult struct {
+	upserted, deleted int
+}
+
 func (h *SSOTSyncHandler) sync(w http.ResponseWriter, r *http.Request, res store.SSOTResource) {
 	tenant := middleware.TenantID(r.Context())
-	limit := h.cfg.SSOTSyncPageSize
 
-	// Load checkpoint
+	var base string
+	switch res {
+	case store.SSOTSchools:
+		base = h.cfg.SchoolSSOTBaseURL
+	case store.SSOTDevices:
+		base = h.cfg.DeviceSSOTBaseURL
+	case store.SSOTParts:
+		base = h.cfg.PartsSSOTBaseURL
+	default:
+		http.Error(w, "unknown resource", http.StatusBadRequest)
+		return
+	}
+	client := ssot.NewClient(base).WithTenant(tenant)
+	if client.BaseURL == "" {
+		http.Error(w, "ssot base url not configured", http.StatusBadRequest)
+		return
+	}
+
+	// Load checkpoint. A non-empty cursor means a previous run stopped mid-feed.
 	state, err := h.pg.SSOTState().Get(r.Context(), tenant, res)
 	if err != nil {
 		state = store.NewSSOTSyncState(tenant, res)
-		_ = h.pg.SSOTState().Upsert(r.Context(), state)
 	}
 
-	cursor := state.LastCursor
-	since := state.LastUpdatedSince
-	total := 0
-	maxSeen := since
-
-	var client *ssot.Client
+	var out ssotSyncResult
 	switch res {
 	case store.SSOTSchools:
-		client = ssot.NewClient(h.cfg.SchoolSSOTBaseURL).WithTenant(tenant)
+		out, err = syncSSOTFeed(r.Context(), h.pg, &state, h.cfg.SSOTSyncPageSize, ssotFeed[models.SchoolSnapshot]{
+			fetch: client.ListSchools,
+			key:   func(it models.SchoolSnapshot) (time.Time, string) { return it.UpdatedAt, it.SchoolID },
+			upsert: func(ctx context.Context, tx store.Tx, tenantID string, it models.SchoolSnapshot) error {
+				it.TenantID = tenantID
+				return store.UpsertSchoolSnapshotTx(ctx, tx, it)
+			},
+			remove: store.DeleteSchoolSnapshotTx,
+		})
 	case store.SSOTDevices:
-		client = ssot.NewClient(h.cfg.DeviceSSOTBaseURL).WithTenant(tenant)
+		out, err = syncSSOTFeed(r.Context(), h.pg, &state, h.cfg.SSOTSyncPageSize, ssotFeed[models.DeviceSnapshot]{
+			fetch: client.ListDevices,
+			key:   func(it models.DeviceSnapshot) (time.Time, string) { return it.UpdatedAt, it.DeviceID },
+			upsert: func(ctx context.Context, tx store.Tx, tenantID string, it models.DeviceSnapshot) error {
+				it.TenantID = tenantID
+				return store.UpsertDeviceSnapshotTx(ctx, tx, it)
+			},
+			remove: store.DeleteDeviceSnapshotTx,
+		})
 	case store.SSOTParts:
-		client = ssot.NewClient(h.cfg.PartsSSOTBaseURL).WithTenant(tenant)
-	default:
-		http.Error(w, "unknown resource", http.StatusBadRequest)
+		out, err = syncSSOTFeed(r.Context(), h.pg, &state, h.cfg.SSOTSyncPageSize, ssotFeed[models.PartSnapshot]{
+			fetch: client.ListParts,
+			key:   func(it models.PartSnapshot) (time.Time, string) { return it.UpdatedAt, it.PartID },
+			upsert: func(ctx context.Context, tx store.Tx, tenantID string, it models.PartSnapshot) error {
+				it.TenantID = tenantID
+				return store.UpsertPartSnapshotTx(ctx, tx, it)
+			},
+			remove: store.DeletePartSnapshotTx,
+		})
+	}
+	if err != nil {
+		var fetchErr *ssotFetchError
+		if errors.As(err, &fetchErr) {
+			http.Error(w, string(res)+" ssot sync failed: "+fetchErr.Error(), http.StatusBadGateway)
+			return
+		}
+		h.log.Error("ssot sync failed", zap.String("resource", string(res)), zap.String("tenantId", tenant), zap.Error(err))
+		http.Error(w, string(res)+" ssot sync failed", http.StatusInternalServerError)
 		return
 	}
 
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":              true,
+		"resource":        string(res),
+		"synced":          out.upserted,
+		"deleted":         out.deleted,
+		"newUpdatedSince": state.LastUpdatedSince,
+	})
+}
+
+// ssotFetchError is a failure to read the SSOT feed, as opposed to a
+// failure to apply it.
+type ssotFetchError struct{ err error }
+
+func (e *ssotFetchError) Error() string { return e.err.Error() }
+func (e *ssotFetchError) Unwrap() error { return e.err }
+
+// syncSSOTFeed pages through a change feed from state's checkpoint. Each page
+// is applied together with its checkpoint in one transaction, so on error
+// the checkpoint stays at the last page that was fully applied.
+func syncSSOTFeed[T any](ctx context.Context, pg *store.Postgres, state *store.SSOTSyncState, limit int, feed ssotFeed[T]) (ssotSyncResult, error) {
+	var res ssotSyncResult
+	maxSeen := state.LastUpdatedSince
+	if at, _, ok := cursor.Decode(state.LastCursor); ok && at.After(maxSeen) {
+		maxSeen = at
+	}
+
 	for {
-		if client.BaseURL == "" {
-			http.Error(w, "ssot base url not configured", http.StatusBadRequest)
-			return
+		page, err := feed.fetch(state.LastUpdatedSince, state.LastCursor, limit)
+		if err != nil {
+			return res, &ssotFetchError{err: err}
 		}
 
-		switch res {
-		case store.SSOTSchools:
-			page, err := client.ListSchools(since, cursor, limit)
-			if err != nil {
-				http.Error(w, "school ssot sync failed: "+err.Error(), http.StatusBadGateway)
-				return
-			}
-			for _, it := range page.Items {
-				it.TenantID = tenant
-				_ = h.pg.SchoolsSnapshot().Upsert(r.Context(), it)
-				if it.UpdatedAt.After(maxSeen) {
-					maxSeen = it.UpdatedAt
-				}
-				total++
-			}
-			if page.Next == "" {
-				cursor = ""
-				break
+		next := *state
+		pageMax := maxSeen
+		seen := func(t time.Time) {
+			if t.After(pageMax) {
+				pageMax = t
 			}
-			cursor = page.Next
-			continue
-
-		case store.SSOTDevices:
-			page, err := client.ListDevices(since, cursor, limit)
+		}
+		err = pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+			err := changefeed.Walk(page, feed.key,
+				func(it T) error {
+					at, _ := feed.key(it)
+					seen(at)
+					return feed.upsert(ctx, tx, state.TenantID, it)
+				},
+				func(d changefeed.Tombstone) error {
+					seen(d.DeletedAt)
+					return feed.remove(ctx, tx, state.TenantID, d.ID)
+				})
 			if err != nil {
-				http.Error(w, "device ssot sync failed: "+err.Error(), http.StatusBadGateway)
-				return
-			}
-			for _, it := range page.Items {
-				it.TenantID = tenant
-				_ = h.pg.DevicesSnapshot().Upsert(r.Context(), it)
-				if it.UpdatedAt.After(maxSeen) {
-					maxSeen = it.UpdatedAt
-				}
-				total++
+				return err
 			}
-			if page.Next == "" {
-				cursor = ""
-				break
-			}
-			cursor = page.Next
-			continue
 
-		case store.SSOTParts:
-			page, err := client.ListParts(since, cursor, limit)
-			if err != nil {
-				http.Error(w, "parts ssot sync failed: "+err.Error(), http.StatusBadGateway)
-				return
-			}
-			for _, it := range page.Items {
-				it.TenantID = tenant
-				_ = h.pg.PartsSnapshot().Upsert(r.Context(), it)
-				if it.UpdatedAt.After(maxSeen) {
-					maxSeen = it.UpdatedAt
-				}
-				total++
-			}
-			if page.Next == "" {
-				cursor = ""
-				break
+			if page.NextCursor != "" {
+				next.LastCursor = page.NextCursor
+			} else {
+				// Advance checkpoint: set updatedSince to maxSeen, clear cursor
+				next.LastUpdatedSince = pageMax
+				next.LastCursor = ""
 			}
-			cursor = page.Next
-			continue
+			next.UpdatedAt = time.Now().UTC()
+			return store.UpsertSSOTStateTx(ctx, tx, next)
+		})
+		if err != nil {
+			return res, fmt.Errorf("apply %s page: %w", state.Resource, err)
 		}
 
-		break
+		*state = next
+		maxSeen = pageMax
+		res.upserted += len(page.Items)
+		res.deleted += len(page.Deleted)
+		if page.NextCursor == "" {
+			return res, nil
+		}
 	}
-
-	// Advance checkpoint: set updatedSince to maxSeen, clear cursor
-	state.LastUpdatedSince = maxSeen
-	state.LastCursor = cursor
-	state.UpdatedAt = time.Now().UTC()
-	_ = h.pg.SSOTState().Upsert(r.Context(), state)
-
-	writeJSON(w, http.StatusOK, map[string]any{
-		"ok":              true,
-		"resource":        string(res),
-		"synced":          total,
-		"newUpdatedSince": state.LastUpdatedSince,
-	})
 }
@@ -1,25 +1,39 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/edvirons/ssp/ims/internal/audit"
 	"github.com/edvirons/ssp/ims/internal/config"
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/ssot"
 	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
+// maxWebhookBody bounds the size of a single SSOT webhook delivery.
+const maxWebhookBody = 5 << 20
+
 type SSOTWebhookHandler struct {
-	cfg config.Config
-	log *zap.Logger
-	pg  *store.Postgres
+	cfg   config.Config
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
 }
 
-func NewSSOTWebhookHandler(cfg config.Config, log *zap.Logger, pg *store.Postgres) *SSOTWebhookHandler {
-	return &SSOTWebhookHandler{cfg: cfg, log: log, pg: pg}
+func NewSSOTWebhookHandler(cfg config.Config, log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *SSOTWebhookHandler {
+	return &SSOTWebhookHandler{cfg: cfg, log: log, pg: pg, audit: auditLogger}
 }
 
 // Generic envelope supports either a single item or a batch
@@ -28,10 +42,147 @@ type envelope[T any] struct {
 	Items []T `json:"items,omitempty"`
 }
 
+// webhookResource describes how one snapshot type is validated, applied and
+// rolled back.
+type webhookResource[T any] struct {
+	name      store.SSOTResource
+	key       func(T) string
+	updatedAt func(T) time.Time
+	setTenant func(*T, string)
+	validate  func(T) string
+	get       func(ctx context.Context, tenantID, id string) (T, error)
+	upsert    func(ctx context.Context, item T) error
+	remove    func(ctx context.Context, tenantID, id string) error
+}
+
+func (h *SSOTWebhookHandler) schools() webhookResource[models.SchoolSnapshot] {
+	repo := h.pg.SchoolsSnapshot()
+	return webhookResource[models.SchoolSnapshot]{
+		name:      store.SSOTSchools,
+		key:       func(s models.SchoolSnapshot) string { return s.SchoolID },
+		updatedAt: func(s models.SchoolSnapshot) time.Time { return s.UpdatedAt },
+		setTenant: func(s *models.SchoolSnapshot, tenant string) { s.TenantID = tenant },
+		validate: func(s models.SchoolSnapshot) string {
+			return requireFields("schoolId", s.SchoolID, "name", s.Name)
+		},
+		get:    repo.Get,
+		upsert: repo.Upsert,
+		remove: repo.Delete,
+	}
+}
+
+func (h *SSOTWebhookHandler) devices() webhookResource[models.DeviceSnapshot] {
+	repo := h.pg.DevicesSnapshot()
+	return webhookResource[models.DeviceSnapshot]{
+		name:      store.SSOTDevices,
+		key:       func(d models.DeviceSnapshot) string { return d.DeviceID },
+		updatedAt: func(d models.DeviceSnapshot) time.Time { return d.UpdatedAt },
+		setTenant: func(d *models.DeviceSnapshot, tenant string) { d.TenantID = tenant },
+		validate: func(d models.DeviceSnapshot) string {
+			return requireFields("deviceId", d.DeviceID)
+		},
+		get:    repo.Get,
+		upsert: repo.Upsert,
+		remove: repo.Delete,
+	}
+}
+
+func (h *SSOTWebhookHandler) parts() webhookResource[models.PartSnapshot] {
+	repo := h.pg.PartsSnapshot()
+	return webhookResource[models.PartSnapshot]{
+		name:      store.SSOTParts,
+		key:       func(p models.PartSnapshot) string { return p.PartID },
+		updatedAt: func(p models.PartSnapshot) time.Time { return p.UpdatedAt },
+		setTenant: func(p *models.PartSnapshot, tenant string) { p.TenantID = tenant },
+		validate: func(p models.PartSnapshot) string {
+			return requireFields("partId", p.PartID, "name", p.Name)
+		},
+		get:    repo.Get,
+		upsert: repo.Upsert,
+		remove: repo.Delete,
+	}
+}
+
+// requireFields takes name/value pairs and reports the first empty one.
+func requireFields(pairs ...string) string {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if strings.TrimSpace(pairs[i+1]) == "" {
+			return pairs[i] + " is required"
+		}
+	}
+	return ""
+}
+
 func (h *SSOTWebhookHandler) Schools(w http.ResponseWriter, r *http.Request) {
-	tenant := middleware.TenantID(r.Context())
-	var env envelope[models.SchoolSnapshot]
-	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+	receiveWebhook(h, w, r, h.schools())
+}
+
+func (h *SSOTWebhookHandler) Devices(w http.ResponseWriter, r *http.Request) {
+	receiveWebhook(h, w, r, h.devices())
+}
+
+func (h *SSOTWebhookHandler) Parts(w http.ResponseWriter, r *http.Request) {
+	receiveWebhook(h, w, r, h.parts())
+}
+
+func (h *SSOTWebhookHandler) tolerance() time.Duration {
+	return time.Duration(h.cfg.SSOTWebhookToleranceSeconds) * time.Second
+}
+
+// verifySignature checks the delivery against the tenant's active secrets and
+// returns the signature and the id of the secret that matched.
+func (h *SSOTWebhookHandler) verifySignature(w http.ResponseWriter, r *http.Request, tenant string, body []byte) (ssot.Signature, string, bool) {
+	sig, err := ssot.ParseSignature(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return ssot.Signature{}, "", false
+	}
+	secrets, err := h.pg.SSOTWebhooks().ActiveSecrets(r.Context(), tenant)
+	if err != nil {
+		h.log.Error("load webhook secrets failed", zap.Error(err))
+		http.Error(w, "failed to verify signature", http.StatusInternalServerError)
+		return ssot.Signature{}, "", false
+	}
+
+	now := time.Now().UTC()
+	for _, s := range secrets {
+		if sig.KeyID != "" && sig.KeyID != s.ID {
+			continue
+		}
+		err := sig.Verify(s.Secret, body, now, h.tolerance())
+		if err == nil {
+			return sig, s.ID, true
+		}
+		if errors.Is(err, ssot.ErrStaleTimestamp) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return ssot.Signature{}, "", false
+		}
+	}
+	h.log.Warn("ssot webhook signature rejected",
+		zap.String("tenant_id", tenant), zap.String("path", r.URL.Path), zap.String("key_id", sig.KeyID))
+	http.Error(w, "invalid signature", http.StatusUnauthorized)
+	return ssot.Signature{}, "", false
+}
+
+// receiveWebhook verifies a signed delivery, records it, then validates and
+// applies each item independently. Items that fail validation, or that are
+// older than the stored snapshot, are rejected without affecting the rest.
+func receiveWebhook[T any](h *SSOTWebhookHandler, w http.ResponseWriter, r *http.Request, res webhookResource[T]) {
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	sig, keyID, ok := h.verifySignature(w, r, tenant, body)
+	if !ok {
+		return
+	}
+
+	var env envelope[T]
+	if err := json.Unmarshal(body, &env); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
@@ -39,93 +190,322 @@ func (h *SSOTWebhookHandler) Schools(w http.ResponseWriter, r *http.Request) {
 	if env.Item != nil {
 		items = append(items, *env.Item)
 	}
-	maxSeen := time.Unix(0, 0).UTC()
-	for _, it := range items {
-		it.TenantID = tenant
-		_ = h.pg.SchoolsSnapshot().Upsert(r.Context(), it)
-		if it.UpdatedAt.After(maxSeen) {
-			maxSeen = it.UpdatedAt
+	if len(items) == 0 {
+		http.Error(w, "no items", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	d := models.SSOTWebhookDelivery{
+		ID:         store.NewID("whd"),
+		TenantID:   tenant,
+		Resource:   string(res.name),
+		Nonce:      sig.Nonce,
+		KeyID:      keyID,
+		SignedAt:   sig.Timestamp,
+		Status:     models.SSOTDeliveryReceived,
+		Payload:    body,
+		ReceivedAt: now,
+	}
+	if err := h.pg.SSOTWebhooks().CreateDelivery(ctx, d); err != nil {
+		if errors.Is(err, store.ErrDuplicateNonce) {
+			http.Error(w, "nonce already used", http.StatusConflict)
+			return
 		}
+		h.log.Error("record webhook delivery failed", zap.Error(err))
+		http.Error(w, "failed to record delivery", http.StatusInternalServerError)
+		return
 	}
-	if len(items) > 0 {
-		st, err := h.pg.SSOTState().Get(r.Context(), tenant, store.SSOTSchools)
-		if err != nil {
-			st = store.NewSSOTSyncState(tenant, store.SSOTSchools)
+
+	// Pushes only touch snapshots; the pull checkpoint stays with the change
+	// feed so a push can never cause feed rows to be skipped.
+	notAfter := now.Add(h.tolerance())
+	d.Results = make([]models.SSOTItemResult, 0, len(items))
+	for i, it := range items {
+		res.setTenant(&it, tenant)
+		result := models.SSOTItemResult{Index: i, ID: res.key(it), UpdatedAt: res.updatedAt(it)}
+
+		problem := res.validate(it)
+		switch {
+		case problem != "":
+		case result.UpdatedAt.IsZero():
+			problem = "updatedAt is required"
+		case result.UpdatedAt.After(notAfter):
+			problem = "updatedAt is in the future"
+		}
+		if problem == "" {
+			if prev, err := res.get(ctx, tenant, result.ID); err == nil {
+				if res.updatedAt(prev).After(result.UpdatedAt) {
+					problem = "stale: snapshot updated at " + res.updatedAt(prev).Format(time.RFC3339Nano)
+				} else {
+					result.Previous, _ = json.Marshal(prev)
+				}
+			}
 		}
-		if maxSeen.After(st.LastUpdatedSince) {
-			st.LastUpdatedSince = maxSeen
+		if problem == "" {
+			if err := res.upsert(ctx, it); err != nil {
+				h.log.Error("apply webhook item failed", zap.Error(err),
+					zap.String("resource", string(res.name)), zap.String("id", result.ID))
+				problem = "apply failed"
+			}
 		}
-		st.LastCursor = "" // push updates invalidate cursor
-		st.UpdatedAt = time.Now().UTC()
-		_ = h.pg.SSOTState().Upsert(r.Context(), st)
+
+		if problem != "" {
+			result.Status = models.SSOTItemRejected
+			result.Error = problem
+			result.Previous = nil
+			d.Rejected++
+		} else {
+			result.Status = models.SSOTItemApplied
+			d.Accepted++
+		}
+		d.Results = append(d.Results, result)
+	}
+
+	switch {
+	case d.Rejected == 0:
+		d.Status = models.SSOTDeliveryApplied
+	case d.Accepted == 0:
+		d.Status = models.SSOTDeliveryRejected
+	default:
+		d.Status = models.SSOTDeliveryPartial
+	}
+	if err := h.pg.SSOTWebhooks().SaveResults(ctx, d); err != nil {
+		h.log.Error("save webhook delivery results failed", zap.Error(err), zap.String("delivery_id", d.ID))
+	}
+
+	// Prior snapshots are kept for rollback but not echoed to the sender.
+	echo := make([]models.SSOTItemResult, len(d.Results))
+	for i, it := range d.Results {
+		it.Previous = nil
+		echo[i] = it
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "upserts": len(items)})
+	status := http.StatusOK
+	if d.Accepted == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, map[string]any{
+		"ok":         d.Rejected == 0,
+		"deliveryId": d.ID,
+		"status":     d.Status,
+		"accepted":   d.Accepted,
+		"rejected":   d.Rejected,
+		"items":      echo,
+	})
 }
 
-func (h *SSOTWebhookHandler) Devices(w http.ResponseWriter, r *http.Request) {
-	tenant := middleware.TenantID(r.Context())
-	var env envelope[models.DeviceSnapshot]
-	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+// Secrets
+
+func (h *SSOTWebhookHandler) ListSecrets(w http.ResponseWriter, r *http.Request) {
+	items, err := h.pg.SSOTWebhooks().ListSecrets(r.Context(), middleware.TenantID(r.Context()))
+	if err != nil {
+		h.log.Error("list webhook secrets failed", zap.Error(err))
+		http.Error(w, "failed to list secrets", http.StatusInternalServerError)
 		return
 	}
-	items := env.Items
-	if env.Item != nil {
-		items = append(items, *env.Item)
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// CreateSecret generates a new signing secret. The value is only returned in
+// this response.
+func (h *SSOTWebhookHandler) CreateSecret(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Label string `json:"label"`
 	}
-	maxSeen := time.Unix(0, 0).UTC()
-	for _, it := range items {
-		it.TenantID = tenant
-		_ = h.pg.DevicesSnapshot().Upsert(r.Context(), it)
-		if it.UpdatedAt.After(maxSeen) {
-			maxSeen = it.UpdatedAt
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
 		}
 	}
-	if len(items) > 0 {
-		st, err := h.pg.SSOTState().Get(r.Context(), tenant, store.SSOTDevices)
-		if err != nil {
-			st = store.NewSSOTSyncState(tenant, store.SSOTDevices)
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		http.Error(w, "failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+	s := models.SSOTWebhookSecret{
+		ID:        store.NewID("whk"),
+		TenantID:  middleware.TenantID(r.Context()),
+		Label:     strings.TrimSpace(req.Label),
+		Secret:    "whsec_" + hex.EncodeToString(raw),
+		CreatedBy: middleware.UserID(r.Context()),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.pg.SSOTWebhooks().CreateSecret(r.Context(), s); err != nil {
+		h.log.Error("create webhook secret failed", zap.Error(err))
+		http.Error(w, "failed to create secret", http.StatusInternalServerError)
+		return
+	}
+
+	logged := s
+	logged.Secret = ""
+	if err := h.audit.LogCreate(r.Context(), "ssot_webhook_secret", s.ID, logged); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusCreated, s)
+}
+
+func (h *SSOTWebhookHandler) RevokeSecret(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.pg.SSOTWebhooks().RevokeSecret(r.Context(), middleware.TenantID(r.Context()), id, time.Now().UTC()); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
 		}
-		if maxSeen.After(st.LastUpdatedSince) {
-			st.LastUpdatedSince = maxSeen
+		h.log.Error("revoke webhook secret failed", zap.Error(err))
+		http.Error(w, "failed to revoke secret", http.StatusInternalServerError)
+		return
+	}
+	if err := h.audit.LogDelete(r.Context(), "ssot_webhook_secret", id, map[string]any{"id": id}); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// Deliveries
+
+func (h *SSOTWebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	items, total, err := h.pg.SSOTWebhooks().ListDeliveries(r.Context(), store.SSOTDeliveryListParams{
+		TenantID: middleware.TenantID(r.Context()),
+		Resource: q.Get("resource"),
+		Status:   q.Get("status"),
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		h.log.Error("list webhook deliveries failed", zap.Error(err))
+		http.Error(w, "failed to list deliveries", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "total": total})
+}
+
+func (h *SSOTWebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	d, err := h.pg.SSOTWebhooks().GetDelivery(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
 		}
-		st.LastCursor = ""
-		st.UpdatedAt = time.Now().UTC()
-		_ = h.pg.SSOTState().Upsert(r.Context(), st)
+		h.log.Error("get webhook delivery failed", zap.Error(err))
+		http.Error(w, "failed to get delivery", http.StatusInternalServerError)
+		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "upserts": len(items)})
+	writeJSON(w, http.StatusOK, d)
 }
 
-func (h *SSOTWebhookHandler) Parts(w http.ResponseWriter, r *http.Request) {
-	tenant := middleware.TenantID(r.Context())
-	var env envelope[models.PartSnapshot]
-	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+// RollbackDelivery restores the snapshots a delivery replaced. Items changed
+// again since the delivery are left alone and reported as conflicts.
+func (h *SSOTWebhookHandler) RollbackDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	id := chi.URLParam(r, "id")
+
+	before, err := h.pg.SSOTWebhooks().GetDelivery(ctx, tenant, id)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("get webhook delivery failed", zap.Error(err))
+		http.Error(w, "failed to get delivery", http.StatusInternalServerError)
 		return
 	}
-	items := env.Items
-	if env.Item != nil {
-		items = append(items, *env.Item)
+
+	now := time.Now().UTC()
+	user := middleware.UserID(ctx)
+	if err := h.pg.SSOTWebhooks().ClaimRollback(ctx, tenant, id, user, now); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "delivery cannot be rolled back from status "+string(before.Status), http.StatusConflict)
+			return
+		}
+		h.log.Error("claim webhook rollback failed", zap.Error(err))
+		http.Error(w, "failed to roll back delivery", http.StatusInternalServerError)
+		return
+	}
+
+	d := before
+	d.Results = append([]models.SSOTItemResult(nil), before.Results...)
+	switch store.SSOTResource(d.Resource) {
+	case store.SSOTSchools:
+		err = rollbackWebhook(ctx, d, h.schools())
+	case store.SSOTDevices:
+		err = rollbackWebhook(ctx, d, h.devices())
+	case store.SSOTParts:
+		err = rollbackWebhook(ctx, d, h.parts())
+	default:
+		err = errors.New("unknown resource " + d.Resource)
 	}
-	maxSeen := time.Unix(0, 0).UTC()
-	for _, it := range items {
-		it.TenantID = tenant
-		_ = h.pg.PartsSnapshot().Upsert(r.Context(), it)
-		if it.UpdatedAt.After(maxSeen) {
-			maxSeen = it.UpdatedAt
+
+	if err != nil {
+		// Keep the original status so the remaining items can be retried.
+		h.log.Error("webhook rollback failed", zap.Error(err), zap.String("delivery_id", id))
+		if serr := h.pg.SSOTWebhooks().SaveResults(ctx, d); serr != nil {
+			h.log.Error("save webhook delivery results failed", zap.Error(serr), zap.String("delivery_id", id))
 		}
+		http.Error(w, "rollback incomplete", http.StatusInternalServerError)
+		return
+	}
+
+	d.Status = models.SSOTDeliveryRolledBack
+	d.RolledBackAt = &now
+	d.RolledBackBy = user
+	if err := h.pg.SSOTWebhooks().SaveResults(ctx, d); err != nil {
+		h.log.Error("save webhook delivery results failed", zap.Error(err), zap.String("delivery_id", id))
 	}
-	if len(items) > 0 {
-		st, err := h.pg.SSOTState().Get(r.Context(), tenant, store.SSOTParts)
-		if err != nil {
-			st = store.NewSSOTSyncState(tenant, store.SSOTParts)
+
+	before.Payload, d.Payload = nil, nil
+	if err := h.audit.LogUpdate(ctx, "ssot_webhook_delivery", id, before, d); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// rollbackWebhook walks the applied items newest first, so an id delivered
+// twice ends up at its pre-delivery state. It updates d.Results in place.
+func rollbackWebhook[T any](ctx context.Context, d models.SSOTWebhookDelivery, res webhookResource[T]) error {
+	for i := len(d.Results) - 1; i >= 0; i-- {
+		item := &d.Results[i]
+		if item.Status != models.SSOTItemApplied {
+			continue
+		}
+		hadPrevious := len(item.Previous) > 0 && string(item.Previous) != "null"
+
+		cur, err := res.get(ctx, d.TenantID, item.ID)
+		exists := err == nil
+		switch {
+		case exists && !sameInstant(res.updatedAt(cur), item.UpdatedAt):
+			item.Status, item.Error = models.SSOTItemConflict, "changed since delivery"
+			continue
+		case !exists && hadPrevious:
+			item.Status, item.Error = models.SSOTItemConflict, "deleted since delivery"
+			continue
 		}
-		if maxSeen.After(st.LastUpdatedSince) {
-			st.LastUpdatedSince = maxSeen
+
+		if hadPrevious {
+			var prev T
+			if err := json.Unmarshal(item.Previous, &prev); err != nil {
+				return err
+			}
+			res.setTenant(&prev, d.TenantID)
+			if err := res.upsert(ctx, prev); err != nil {
+				return err
+			}
+		} else if exists {
+			if err := res.remove(ctx, d.TenantID, item.ID); err != nil {
+				return err
+			}
 		}
-		st.LastCursor = ""
-		st.UpdatedAt = time.Now().UTC()
-		_ = h.pg.SSOTState().Upsert(r.Context(), st)
+		item.Status = models.SSOTItemRolledBack
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "upserts": len(items)})
+	return nil
+}
+
+// sameInstant compares timestamps at Postgres (microsecond) precision.
+func sameInstant(a, b time.Time) bool {
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
 }
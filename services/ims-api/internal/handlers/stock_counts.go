@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type createCountReq struct {
+	ServiceShopID string   `json:"serviceShopId"`
+	PartIDs       []string `json:"partIds"` // empty counts every part the shop stocks
+	Notes         string   `json:"notes"`
+}
+
+// CreateCount opens a cycle count for a shop.
+func (h *StockHandler) CreateCount(w http.ResponseWriter, r *http.Request) {
+	var req createCountReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	shopID := strings.TrimSpace(req.ServiceShopID)
+	if !h.requireShop(ctx, w, tenant, shopID) {
+		return
+	}
+
+	c := models.StockCount{
+		ID:            store.NewID("cnt"),
+		TenantID:      tenant,
+		ServiceShopID: shopID,
+		Status:        models.CountOpen,
+		Notes:         strings.TrimSpace(req.Notes),
+		CreatedBy:     middleware.UserID(ctx),
+		CreatedAt:     stockTime(),
+	}
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		partIDs := req.PartIDs
+		if len(partIDs) == 0 {
+			var err error
+			if partIDs, err = store.ShopPartIDsTx(ctx, tx, tenant, shopID); err != nil {
+				return err
+			}
+		}
+		seen := map[string]bool{}
+		for _, id := range partIDs {
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			c.Lines = append(c.Lines, models.StockCountLine{PartID: id})
+		}
+		if len(c.Lines) == 0 {
+			return stockStateError("no parts to count in this shop")
+		}
+		return store.CreateStockCountTx(ctx, tx, c)
+	})
+	if err != nil {
+		h.writeStockError(w, err, "create count")
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func (h *StockHandler) ListCounts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	items, err := h.pg.StockCounts().List(r.Context(), store.StockCountListParams{
+		TenantID: middleware.TenantID(r.Context()),
+		ShopID:   strings.TrimSpace(q.Get("serviceShopId")),
+		Status:   strings.TrimSpace(q.Get("status")),
+		Limit:    parseLimit(q.Get("limit"), 50, 200),
+		Offset:   parseOffset(q.Get("offset")),
+	})
+	if err != nil {
+		h.writeStockError(w, err, "list counts")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (h *StockHandler) GetCount(w http.ResponseWriter, r *http.Request) {
+	c, err := h.pg.StockCounts().Get(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeStockError(w, err, "get count")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+type recordCountReq struct {
+	Lines []struct {
+		PartID     string `json:"partId"`
+		QtyCounted int64  `json:"qtyCounted"`
+	} `json:"lines"`
+}
+
+// RecordCount sets counted quantities on an open count. Parts not already on
+// the count are added.
+func (h *StockHandler) RecordCount(w http.ResponseWriter, r *http.Request) {
+	var req recordCountReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	for _, l := range req.Lines {
+		if strings.TrimSpace(l.PartID) == "" || l.QtyCounted < 0 {
+			http.Error(w, "each line needs a partId and qtyCounted >= 0", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	var c models.StockCount
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		c, err = store.GetStockCountTx(ctx, tx, middleware.TenantID(ctx), chi.URLParam(r, "id"))
+		if err != nil {
+			return err
+		}
+		if c.Status != models.CountOpen {
+			return stockStateError("count is " + string(c.Status))
+		}
+		for _, l := range req.Lines {
+			qty := l.QtyCounted
+			partID := strings.TrimSpace(l.PartID)
+			found := false
+			for i := range c.Lines {
+				if c.Lines[i].PartID == partID {
+					c.Lines[i].QtyCounted = &qty
+					found = true
+					break
+				}
+			}
+			if !found {
+				c.Lines = append(c.Lines, models.StockCountLine{PartID: partID, QtyCounted: &qty})
+			}
+		}
+		return store.UpdateStockCountTx(ctx, tx, c)
+	})
+	if err != nil {
+		h.writeStockError(w, err, "record count")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// SubmitCount freezes the count for approval. Expected quantities are taken
+// from the ledger now, so variances reflect stock at the time of counting.
+func (h *StockHandler) SubmitCount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	var c models.StockCount
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		c, err = store.GetStockCountTx(ctx, tx, tenant, chi.URLParam(r, "id"))
+		if err != nil {
+			return err
+		}
+		if c.Status != models.CountOpen {
+			return stockStateError("count is " + string(c.Status))
+		}
+		for i, l := range c.Lines {
+			if l.QtyCounted == nil {
+				return stockStateError("part " + l.PartID + " has not been counted")
+			}
+			bal, err := store.StockBalanceTx(ctx, tx, tenant, c.ServiceShopID, l.PartID)
+			if err != nil {
+				return err
+			}
+			c.Lines[i].QtyExpected = bal.OnHand
+			c.Lines[i].Variance = *l.QtyCounted - bal.OnHand
+		}
+		now := stockTime()
+		c.Status = models.CountSubmitted
+		c.SubmittedBy = middleware.UserID(ctx)
+		c.SubmittedAt = &now
+		return store.UpdateStockCountTx(ctx, tx, c)
+	})
+	if err != nil {
+		h.writeStockError(w, err, "submit count")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// ApproveCount posts each non-zero variance to the ledger. The approver must
+// not be the person who submitted the count.
+func (h *StockHandler) ApproveCount(w http.ResponseWriter, r *http.Request) {
+	h.decideCount(w, r, models.CountApproved)
+}
+
+// RejectCount closes a submitted count without touching stock.
+func (h *StockHandler) RejectCount(w http.ResponseWriter, r *http.Request) {
+	h.decideCount(w, r, models.CountRejected)
+}
+
+func (h *StockHandler) decideCount(w http.ResponseWriter, r *http.Request, decision models.StockCountStatus) {
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	actor := middleware.UserID(ctx)
+	var c models.StockCount
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		c, err = store.GetStockCountTx(ctx, tx, tenant, chi.URLParam(r, "id"))
+		if err != nil {
+			return err
+		}
+		if c.Status != models.CountSubmitted {
+			return stockStateError("count is " + string(c.Status))
+		}
+		if decision == models.CountApproved && actor != "" && actor == c.SubmittedBy {
+			return stockStateError("a count cannot be approved by the person who submitted it")
+		}
+		now := stockTime()
+		c.Status = decision
+		c.DecidedBy = actor
+		c.DecidedAt = &now
+		if decision == models.CountApproved {
+			for _, l := range c.Lines {
+				if l.Variance == 0 {
+					continue
+				}
+				if _, err := store.PostStockMovementTx(ctx, tx, models.StockMovement{
+					TenantID:      tenant,
+					Type:          models.StockCycleCount,
+					ServiceShopID: c.ServiceShopID,
+					PartID:        l.PartID,
+					Qty:           l.Variance,
+					ReferenceType: "stock_count",
+					ReferenceID:   c.ID,
+					ActorID:       actor,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		return store.UpdateStockCountTx(ctx, tx, c)
+	})
+	if err != nil {
+		h.writeStockError(w, err, "decide count")
+		return
+	}
+	if err := h.audit.LogUpdate(ctx, "stock_count", c.ID, map[string]any{"status": models.CountSubmitted}, c); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, c)
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"go.uber.org/zap"
+)
+
+// StockHandler exposes the stock ledger: receipts, adjustments, transfers
+// and cycle counts. Every quantity change is a ledger movement; the inventory
+// table is only updated as a side effect of posting one.
+type StockHandler struct {
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
+}
+
+func NewStockHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *StockHandler {
+	return &StockHandler{log: log, pg: pg, audit: auditLogger}
+}
+
+// stockStateError is returned from a transaction when a document is not in
+// a state that allows the requested action.
+type stockStateError string
+
+func (e stockStateError) Error() string { return string(e) }
+
+// writeStockError maps ledger and state errors to HTTP responses.
+func (h *StockHandler) writeStockError(w http.ResponseWriter, err error, action string) {
+	writeLedgerError(h.log, w, err, action)
+}
+
+// writeLedgerError maps stock ledger and document state errors to HTTP
+// responses for any handler that posts stock movements.
+func writeLedgerError(log *zap.Logger, w http.ResponseWriter, err error, action string) {
+	var stateErr stockStateError
+	switch {
+	case errors.As(err, &stateErr):
+		http.Error(w, stateErr.Error(), http.StatusConflict)
+	case errors.Is(err, store.ErrInsufficientStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, store.ErrInvalidMovement):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err.Error() == "not found":
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		log.Error(action+" failed", zap.Error(err))
+		http.Error(w, "failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+// requireShop checks that a service shop exists in the tenant.
+func (h *StockHandler) requireShop(ctx context.Context, w http.ResponseWriter, tenant, shopID string) bool {
+	if shopID == "" {
+		http.Error(w, "serviceShopId is required", http.StatusBadRequest)
+		return false
+	}
+	if _, err := h.pg.ServiceShops().GetByID(ctx, tenant, shopID); err != nil {
+		http.Error(w, "service shop not found: "+shopID, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+type stockLineReq struct {
+	PartID string `json:"partId"`
+	Qty    int64  `json:"qty"`
+}
+
+// validateStockLines requires at least one line, positive quantities and no
+// repeated parts.
+func validateStockLines(lines []stockLineReq) string {
+	if len(lines) == 0 {
+		return "at least one line is required"
+	}
+	seen := map[string]bool{}
+	for _, l := range lines {
+		id := strings.TrimSpace(l.PartID)
+		if id == "" {
+			return "partId is required on every line"
+		}
+		if l.Qty <= 0 {
+			return "qty must be > 0 for part " + id
+		}
+		if seen[id] {
+			return "part " + id + " appears more than once"
+		}
+		seen[id] = true
+	}
+	return ""
+}
+
+// ListMovements returns the movement history, newest first.
+func (h *StockHandler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	curT, curID, hasCur := decodeCursor(strings.TrimSpace(q.Get("cursor")))
+	items, next, err := h.pg.StockLedger().ListMovements(r.Context(), store.StockMovementListParams{
+		TenantID:        middleware.TenantID(r.Context()),
+		ShopID:          strings.TrimSpace(q.Get("serviceShopId")),
+		PartID:          strings.TrimSpace(q.Get("partId")),
+		Type:            strings.TrimSpace(q.Get("type")),
+		ReferenceType:   strings.TrimSpace(q.Get("referenceType")),
+		ReferenceID:     strings.TrimSpace(q.Get("referenceId")),
+		Limit:           parseLimit(q.Get("limit"), 50, 200),
+		HasCursor:       hasCur,
+		CursorCreatedAt: curT,
+		CursorID:        curID,
+	})
+	if err != nil {
+		h.writeStockError(w, err, "list movements")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "nextCursor": next})
+}
+
+// ListBalances returns on-hand, reserved, available and in-transit
+// quantities summed from the ledger.
+func (h *StockHandler) ListBalances(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	items, err := h.pg.StockLedger().Balances(r.Context(), middleware.TenantID(r.Context()),
+		strings.TrimSpace(q.Get("serviceShopId")), strings.TrimSpace(q.Get("partId")))
+	if err != nil {
+		h.writeStockError(w, err, "list balances")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+type receiptReq struct {
+	ServiceShopID string         `json:"serviceShopId"`
+	Reference     string         `json:"reference"` // supplier invoice / delivery note
+	Notes         string         `json:"notes"`
+	Lines         []stockLineReq `json:"lines"`
+}
+
+// CreateReceipt books goods received from a supplier into a shop.
+func (h *StockHandler) CreateReceipt(w http.ResponseWriter, r *http.Request) {
+	var req receiptReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	shopID := strings.TrimSpace(req.ServiceShopID)
+	if !h.requireShop(ctx, w, tenant, shopID) {
+		return
+	}
+	if problem := validateStockLines(req.Lines); problem != "" {
+		http.Error(w, problem, http.StatusBadRequest)
+		return
+	}
+
+	receiptID := store.NewID("rcpt")
+	notes := strings.TrimSpace(req.Notes)
+	if ref := strings.TrimSpace(req.Reference); ref != "" {
+		notes = strings.TrimSpace(ref + " " + notes)
+	}
+	movements := make([]models.StockMovement, 0, len(req.Lines))
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		for _, l := range req.Lines {
+			m, err := store.PostStockMovementTx(ctx, tx, models.StockMovement{
+				TenantID:      tenant,
+				Type:          models.StockReceipt,
+				ServiceShopID: shopID,
+				PartID:        strings.TrimSpace(l.PartID),
+				Qty:           l.Qty,
+				ReferenceType: "receipt",
+				ReferenceID:   receiptID,
+				Notes:         notes,
+				ActorID:       middleware.UserID(ctx),
+			})
+			if err != nil {
+				return err
+			}
+			movements = append(movements, m)
+		}
+		return nil
+	})
+	if err != nil {
+		h.writeStockError(w, err, "record receipt")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"receiptId": receiptID, "movements": movements})
+}
+
+type adjustmentReq struct {
+	ServiceShopID string `json:"serviceShopId"`
+	PartID        string `json:"partId"`
+	Qty           int64  `json:"qty"` // signed change to available stock
+	ReasonCode    string `json:"reasonCode"`
+	Notes         string `json:"notes"`
+}
+
+// CreateAdjustment posts a reason-coded correction to available stock.
+func (h *StockHandler) CreateAdjustment(w http.ResponseWriter, r *http.Request) {
+	var req adjustmentReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	shopID := strings.TrimSpace(req.ServiceShopID)
+	if !h.requireShop(ctx, w, tenant, shopID) {
+		return
+	}
+	if strings.TrimSpace(req.PartID) == "" || req.Qty == 0 {
+		http.Error(w, "partId and a non-zero qty are required", http.StatusBadRequest)
+		return
+	}
+	reason := models.StockAdjustmentReason(strings.TrimSpace(req.ReasonCode))
+	sign, ok := reason.Sign()
+	if !ok {
+		http.Error(w, "unknown reasonCode", http.StatusBadRequest)
+		return
+	}
+	if (sign < 0 && req.Qty > 0) || (sign > 0 && req.Qty < 0) {
+		http.Error(w, "qty sign does not match reasonCode "+string(reason), http.StatusBadRequest)
+		return
+	}
+
+	var m models.StockMovement
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		m, err = store.PostStockMovementTx(ctx, tx, models.StockMovement{
+			TenantID:      tenant,
+			Type:          models.StockAdjustment,
+			ServiceShopID: shopID,
+			PartID:        strings.TrimSpace(req.PartID),
+			Qty:           req.Qty,
+			ReasonCode:    string(reason),
+			Notes:         strings.TrimSpace(req.Notes),
+			ActorID:       middleware.UserID(ctx),
+		})
+		return err
+	})
+	if err != nil {
+		h.writeStockError(w, err, "post adjustment")
+		return
+	}
+	if err := h.audit.LogCreate(ctx, "stock_adjustment", m.ID, m); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+// stockTime returns the current time as stored on stock documents.
+func stockTime() time.Time { return time.Now().UTC() }
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type createTransferReq struct {
+	FromShopID string         `json:"fromShopId"`
+	ToShopID   string         `json:"toShopId"`
+	Notes      string         `json:"notes"`
+	Lines      []stockLineReq `json:"lines"`
+}
+
+// CreateTransfer ships parts from one shop to another. Stock leaves the
+// origin immediately and is held in transit against the destination.
+func (h *StockHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req createTransferReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	from, to := strings.TrimSpace(req.FromShopID), strings.TrimSpace(req.ToShopID)
+	if from == to {
+		http.Error(w, "fromShopId and toShopId must differ", http.StatusBadRequest)
+		return
+	}
+	if !h.requireShop(ctx, w, tenant, from) || !h.requireShop(ctx, w, tenant, to) {
+		return
+	}
+	if problem := validateStockLines(req.Lines); problem != "" {
+		http.Error(w, problem, http.StatusBadRequest)
+		return
+	}
+
+	t := models.StockTransfer{
+		ID:         store.NewID("xfer"),
+		TenantID:   tenant,
+		FromShopID: from,
+		ToShopID:   to,
+		Status:     models.TransferInTransit,
+		Notes:      strings.TrimSpace(req.Notes),
+		CreatedBy:  middleware.UserID(ctx),
+		CreatedAt:  stockTime(),
+	}
+	for _, l := range req.Lines {
+		t.Lines = append(t.Lines, models.StockTransferLine{PartID: strings.TrimSpace(l.PartID), QtyShipped: l.Qty})
+	}
+
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		if err := store.CreateStockTransferTx(ctx, tx, t); err != nil {
+			return err
+		}
+		for _, l := range t.Lines {
+			if _, err := store.PostStockMovementTx(ctx, tx, h.transferMovement(t, models.StockTransferOut, l.PartID, l.QtyShipped, t.CreatedBy)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.writeStockError(w, err, "create transfer")
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// transferMovement builds a ledger movement for a transfer line. Receipts and
+// losses are booked at the destination; shipping and cancellation at the origin.
+func (h *StockHandler) transferMovement(t models.StockTransfer, typ models.StockMovementType, partID string, qty int64, actorID string) models.StockMovement {
+	shop, counter := t.FromShopID, t.ToShopID
+	if typ == models.StockTransferIn || typ == models.StockTransferLoss {
+		shop, counter = t.ToShopID, t.FromShopID
+	}
+	return models.StockMovement{
+		TenantID:      t.TenantID,
+		Type:          typ,
+		ServiceShopID: shop,
+		CounterShopID: counter,
+		PartID:        partID,
+		Qty:           qty,
+		ReferenceType: "transfer",
+		ReferenceID:   t.ID,
+		ActorID:       actorID,
+	}
+}
+
+func (h *StockHandler) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	items, err := h.pg.StockTransfers().List(r.Context(), store.StockTransferListParams{
+		TenantID: middleware.TenantID(r.Context()),
+		ShopID:   strings.TrimSpace(q.Get("serviceShopId")),
+		Status:   strings.TrimSpace(q.Get("status")),
+		Limit:    parseLimit(q.Get("limit"), 50, 200),
+		Offset:   parseOffset(q.Get("offset")),
+	})
+	if err != nil {
+		h.writeStockError(w, err, "list transfers")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (h *StockHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	t, err := h.pg.StockTransfers().Get(r.Context(), middleware.TenantID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeStockError(w, err, "get transfer")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+type receiveTransferReq struct {
+	Lines []struct {
+		PartID      string `json:"partId"`
+		QtyReceived int64  `json:"qtyReceived"`
+	} `json:"lines"` // lines not listed are received in full
+}
+
+// ReceiveTransfer books a transfer into the destination shop. Any shortfall
+// against the shipped quantity is written off as a transit loss.
+func (h *StockHandler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	var req receiveTransferReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	received := map[string]int64{}
+	for _, l := range req.Lines {
+		if l.QtyReceived < 0 {
+			http.Error(w, "qtyReceived must be >= 0", http.StatusBadRequest)
+			return
+		}
+		received[strings.TrimSpace(l.PartID)] = l.QtyReceived
+	}
+
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	var t models.StockTransfer
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		t, err = store.GetStockTransferTx(ctx, tx, tenant, chi.URLParam(r, "id"))
+		if err != nil {
+			return err
+		}
+		if t.Status != models.TransferInTransit {
+			return stockStateError("transfer is " + string(t.Status))
+		}
+		for partID := range received {
+			if !transferHasPart(t, partID) {
+				return stockStateError("part " + partID + " is not on this transfer")
+			}
+		}
+
+		now := stockTime()
+		t.Status = models.TransferReceived
+		t.ReceivedBy = middleware.UserID(ctx)
+		t.ReceivedAt = &now
+		for i, l := range t.Lines {
+			qty, ok := received[l.PartID]
+			if !ok {
+				qty = l.QtyShipped
+			}
+			if qty > l.QtyShipped {
+				return stockStateError("received more than shipped for part " + l.PartID)
+			}
+			t.Lines[i].QtyReceived = qty
+			if qty > 0 {
+				if _, err := store.PostStockMovementTx(ctx, tx, h.transferMovement(t, models.StockTransferIn, l.PartID, qty, t.ReceivedBy)); err != nil {
+					return err
+				}
+			}
+			if short := l.QtyShipped - qty; short > 0 {
+				if _, err := store.PostStockMovementTx(ctx, tx, h.transferMovement(t, models.StockTransferLoss, l.PartID, short, t.ReceivedBy)); err != nil {
+					return err
+				}
+			}
+		}
+		return store.UpdateStockTransferTx(ctx, tx, t)
+	})
+	if err != nil {
+		h.writeStockError(w, err, "receive transfer")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// CancelTransfer returns in-transit stock to the origin shop.
+func (h *StockHandler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenant := middleware.TenantID(ctx)
+	var t models.StockTransfer
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		t, err = store.GetStockTransferTx(ctx, tx, tenant, chi.URLParam(r, "id"))
+		if err != nil {
+			return err
+		}
+		if t.Status != models.TransferInTransit {
+			return stockStateError("transfer is " + string(t.Status))
+		}
+		now := stockTime()
+		t.Status = models.TransferCancelled
+		t.CancelledAt = &now
+		for _, l := range t.Lines {
+			m := h.transferMovement(t, models.StockTransferCancel, l.PartID, l.QtyShipped, middleware.UserID(ctx))
+			if _, err := store.PostStockMovementTx(ctx, tx, m); err != nil {
+				return err
+			}
+		}
+		return store.UpdateStockTransferTx(ctx, tx, t)
+	})
+	if err != nil {
+		h.writeStockError(w, err, "cancel transfer")
+		return
+	}
+	if err := h.audit.LogUpdate(ctx, "stock_transfer", t.ID, map[string]any{"status": models.TransferInTransit}, t); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func transferHasPart(t models.StockTransfer, partID string) bool {
+	for _, l := range t.Lines {
+		if l.PartID == partID {
+			return true
+		}
+	}
+	return false
+}
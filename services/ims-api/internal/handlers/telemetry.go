@@ -1,87 +1,630 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/edvirons/ssp/ims/internal/audit"
 	"github.com/edvirons/ssp/ims/internal/config"
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
 	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
+// Telemetry from MDM agents is stored as raw events. Problem events of one
+// type from one device are correlated into a single open incident: the
+// first opens it, later ones add to its occurrence count, and repeats
+// within the dedupe window are only recorded. A recovery event resolves
+// the incident. Tenant rules set the severity and behaviour per event type.
+// Events may name the device by MAC address instead of ID; events from an
+// unknown MAC are stored and the MAC is flagged as a candidate unregistered
+// device.
+
+// telemetryBatchLimit caps the events accepted by one batch request.
+const telemetryBatchLimit = 500
+
+// telemetryFutureSkew is how far ahead of the server clock a sender's
+// occurredAt may be before it is replaced by the receive time.
+const telemetryFutureSkew = 5 * time.Minute
+
+// telemetryRoles are the roles automatic resolution acts with when it walks
+// an incident through the workflow.
+var telemetryRoles = []string{"ssp_admin"}
+
+// errTelemetryLookup is returned when an event's MAC address could not be
+// resolved because ssot-devices did not answer; the sender should retry.
+var errTelemetryLookup = errors.New("device lookup unavailable")
+
 type TelemetryHandler struct {
-	cfg config.Config
-	log *zap.Logger
-	pg  *store.Postgres
+	cfg   config.Config
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
+	macs  service.MACDirectory
 }
 
-func NewTelemetryHandler(cfg config.Config, log *zap.Logger, pg *store.Postgres) *TelemetryHandler {
-	return &TelemetryHandler{cfg: cfg, log: log, pg: pg}
+func NewTelemetryHandler(cfg config.Config, log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger, macs service.MACDirectory) *TelemetryHandler {
+	return &TelemetryHandler{cfg: cfg, log: log, pg: pg, audit: auditLogger, macs: macs}
 }
 
 type telemetryEvent struct {
-	DeviceID   string `json:"deviceId"`
-	Type       string `json:"type"`       // e.g. "policy_breach", "crash", "offline"
-	Message    string `json:"message"`    // details
-	Severity   string `json:"severity"`   // low|medium|high|criticalgritical (typos tolerated)
-	ReportedBy string `json:"reportedBy"` // e.g. "nexus-mdm"
+	EventID    string         `json:"eventId"`    // sender's event ID; repeats are not processed again
+	DeviceID   string         `json:"deviceId"`   // or macAddress
+	MACAddress string         `json:"macAddress"` // resolves the device when deviceId is empty
+	SchoolID   string         `json:"schoolId"`   // defaults to the caller's school, then the device's
+	Type       string         `json:"type"`       // e.g. "policy_breach", "crash", "offline"
+	State      string         `json:"state"`      // "problem" (default) or "recovered"
+	Message    string         `json:"message"`    // details
+	Severity   string         `json:"severity"`   // used when no tenant rule sets one
+	ReportedBy string         `json:"reportedBy"` // e.g. "nexus-mdm"
+	OccurredAt *time.Time     `json:"occurredAt"` // defaults to the receive time
+	Attributes map[string]any `json:"attributes"`
+}
+
+func (e *telemetryEvent) normalize() error {
+	e.EventID = strings.TrimSpace(e.EventID)
+	e.DeviceID = strings.TrimSpace(e.DeviceID)
+	if strings.TrimSpace(e.MACAddress) != "" {
+		mac, ok := service.NormalizeMAC(e.MACAddress)
+		if !ok {
+			return errors.New("invalid macAddress")
+		}
+		e.MACAddress = mac
+	}
+	e.SchoolID = strings.TrimSpace(e.SchoolID)
+	e.Type = strings.ToLower(strings.TrimSpace(e.Type))
+	e.State = strings.ToLower(strings.TrimSpace(e.State))
+	e.Message = strings.TrimSpace(e.Message)
+	e.ReportedBy = strings.TrimSpace(e.ReportedBy)
+	if (e.DeviceID == "" && e.MACAddress == "") || e.Type == "" {
+		return errors.New("deviceId or macAddress, and type, are required")
+	}
+	if e.Type == models.TelemetryRuleWildcard {
+		return errors.New("type must not be *")
+	}
+	if e.State != "" && e.State != "problem" && e.State != "recovered" {
+		return errors.New("state must be problem or recovered")
+	}
+	return nil
+}
+
+type telemetryResult struct {
+	EventID       string                  `json:"eventId"`
+	Outcome       models.TelemetryOutcome `json:"outcome"`
+	DeviceID      string                  `json:"deviceId,omitempty"`
+	IncidentID    string                  `json:"incidentId,omitempty"`
+	CorrelationID string                  `json:"correlationId,omitempty"`
+	Occurrences   int                     `json:"occurrences,omitempty"`
+	Severity      string                  `json:"severity,omitempty"`
+	Replayed      bool                    `json:"replayed,omitempty"`
+}
+
+func telemetryResultOf(ev models.TelemetryEvent) telemetryResult {
+	return telemetryResult{EventID: ev.ID, Outcome: ev.Outcome, DeviceID: ev.DeviceID, IncidentID: ev.IncidentID,
+		CorrelationID: ev.CorrelationID, Severity: ev.Severity}
 }
 
+// Ingest processes one event.
+// POST /v1/telemetry/events
+// Responds 201 when the event opened an incident, otherwise 200.
 func (h *TelemetryHandler) Ingest(w http.ResponseWriter, r *http.Request) {
 	var e telemetryEvent
 	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	if strings.TrimSpace(e.DeviceID) == "" || strings.TrimSpace(e.Type) == "" {
-		http.Error(w, "deviceId and type are required", http.StatusBadRequest)
+	if err := e.normalize(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tenant := middleware.TenantID(r.Context())
+	rules, err := h.pg.Telemetry().ListRules(r.Context(), tenant)
+	if err != nil {
+		h.log.Error("failed to load telemetry rules", zap.Error(err))
+		http.Error(w, "failed to ingest telemetry", http.StatusInternalServerError)
+		return
+	}
+	res, err := h.ingest(r.Context(), tenant, middleware.SchoolID(r.Context()), rules, e, time.Now().UTC())
+	if errors.Is(err, errTelemetryLookup) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		h.log.Error("failed to ingest telemetry", zap.String("deviceId", e.DeviceID), zap.Error(err))
+		http.Error(w, "failed to ingest telemetry", http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusOK
+	if res.Outcome == models.TelemetryOpened && !res.Replayed {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, res)
+}
+
+type telemetryBatchReq struct {
+	Events []json.RawMessage `json:"events"`
+}
+
+type telemetryBatchItem struct {
+	Index int `json:"index"`
+	telemetryResult
+	Error string `json:"error,omitempty"`
+}
+
+// IngestBatch processes up to telemetryBatchLimit events in order. Each
+// event is handled on its own, so one bad event does not fail the rest.
+// POST /v1/telemetry/events/batch
+func (h *TelemetryHandler) IngestBatch(w http.ResponseWriter, r *http.Request) {
+	var req telemetryBatchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if len(req.Events) == 0 {
+		http.Error(w, "events required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Events) > telemetryBatchLimit {
+		http.Error(w, "at most 500 events per batch", http.StatusRequestEntityTooLarge)
 		return
 	}
 
 	tenant := middleware.TenantID(r.Context())
 	school := middleware.SchoolID(r.Context())
+	rules, err := h.pg.Telemetry().ListRules(r.Context(), tenant)
+	if err != nil {
+		h.log.Error("failed to load telemetry rules", zap.Error(err))
+		http.Error(w, "failed to ingest telemetry", http.StatusInternalServerError)
+		return
+	}
 
-	sev := parseSeverity(e.Severity)
 	now := time.Now().UTC()
+	items := make([]telemetryBatchItem, 0, len(req.Events))
+	summary := map[models.TelemetryOutcome]int{}
+	failed := 0
+	for i, raw := range req.Events {
+		item := telemetryBatchItem{Index: i}
+		var e telemetryEvent
+		if err := json.Unmarshal(raw, &e); err != nil {
+			item.Error = "invalid event"
+		} else if err := e.normalize(); err != nil {
+			item.Error = err.Error()
+		} else if res, err := h.ingest(r.Context(), tenant, school, rules, e, now); errors.Is(err, errTelemetryLookup) {
+			item.Error = err.Error()
+		} else if err != nil {
+			h.log.Error("failed to ingest telemetry", zap.String("deviceId", e.DeviceID), zap.Error(err))
+			item.Error = "failed to ingest"
+		} else {
+			item.telemetryResult = res
+			summary[res.Outcome]++
+		}
+		if item.Error != "" {
+			failed++
+		}
+		items = append(items, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": items, "summary": summary, "failed": failed})
+}
+
+// ingest stores one event and applies it to its correlation, all in one
+// transaction serialized per device and event type.
+func (h *TelemetryHandler) ingest(ctx context.Context, tenant, school string, rules []models.TelemetryRule, e telemetryEvent, now time.Time) (telemetryResult, error) {
+	if e.EventID != "" {
+		if prev, err := h.pg.Telemetry().GetEventByExternalID(ctx, tenant, e.EventID); err == nil {
+			res := telemetryResultOf(prev)
+			res.Replayed = true
+			return res, nil
+		}
+	}
+
+	occurredAt := now
+	if e.OccurredAt != nil && !e.OccurredAt.IsZero() && e.OccurredAt.Before(now.Add(telemetryFutureSkew)) {
+		occurredAt = e.OccurredAt.UTC()
+	}
+	if e.DeviceID == "" {
+		found, failed := resolveMACs(ctx, h.log, h.pg, h.macs, tenant, []string{e.MACAddress})
+		if failed[e.MACAddress] {
+			return telemetryResult{}, errTelemetryLookup
+		}
+		e.DeviceID = found[e.MACAddress]
+	}
+	school = firstNonEmpty(e.SchoolID, school)
+	if school == "" && e.DeviceID != "" {
+		if d, err := h.pg.DevicesSnapshot().Get(ctx, tenant, e.DeviceID); err == nil {
+			school = d.SchoolID
+		}
+	}
+
+	ev := models.TelemetryEvent{
+		ID:         store.NewID("tev"),
+		TenantID:   tenant,
+		SchoolID:   school,
+		DeviceID:   e.DeviceID,
+		MACAddress: e.MACAddress,
+		EventType:  e.Type,
+		Severity:   strings.ToLower(strings.TrimSpace(e.Severity)),
+		Message:    e.Message,
+		ReportedBy: firstNonEmpty(e.ReportedBy, "nexus-mdm"),
+		ExternalID: e.EventID,
+		Attributes: e.Attributes,
+		OccurredAt: occurredAt,
+		ReceivedAt: now,
+	}
+	res := telemetryResult{EventID: ev.ID}
+
+	var wf models.Workflow
+	cleared := service.RecoveredTypes(rules, e.Type, e.State == "recovered")
+	if ev.DeviceID != "" && len(cleared) > 0 {
+		wf = loadWorkflow(ctx, h.log, h.pg, tenant, models.WorkflowIncident)
+	}
+	var resolved []telemetryResolution
+	err := h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		resolved = nil
+		if ev.DeviceID == "" {
+			ev.Outcome = models.TelemetryUnknown
+		} else if len(cleared) > 0 {
+			ev.Recovery = true
+			ev.Outcome = models.TelemetryUnmatched
+			for _, problemType := range cleared {
+				if err := h.applyRecovery(ctx, tx, rules, wf, &ev, problemType, now, &resolved); err != nil {
+					return err
+				}
+			}
+		} else if err := h.applyProblem(ctx, tx, rules, &ev, &res, now); err != nil {
+			return err
+		}
+		return store.InsertTelemetryEventTx(ctx, tx, ev)
+	})
+	if errors.Is(err, store.ErrTelemetryReplay) {
+		prev, err := h.pg.Telemetry().GetEventByExternalID(ctx, tenant, e.EventID)
+		if err != nil {
+			return res, err
+		}
+		res = telemetryResultOf(prev)
+		res.Replayed = true
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	for _, r := range resolved {
+		h.afterTelemetryResolve(ctx, wf, r, now)
+	}
+
+	if ev.DeviceID == "" {
+		sighting := models.NetworkSighting{MACAddress: ev.MACAddress, SeenAt: occurredAt}
+		if err := h.pg.NetworkDiscovery().RecordUnknown(ctx, tenant, school, models.NetworkSourceTelemetry, sighting); err != nil {
+			h.log.Error("failed to record unknown MAC", zap.String("mac", ev.MACAddress), zap.Error(err))
+		}
+	} else if err := h.pg.NetworkDiscovery().MarkSeen(ctx, tenant, ev.DeviceID, ev.MACAddress, occurredAt); err != nil {
+		h.log.Error("failed to mark device seen", zap.String("deviceId", ev.DeviceID), zap.Error(err))
+	}
+
+	occurrences := res.Occurrences
+	res = telemetryResultOf(ev)
+	res.Occurrences = occurrences
+	return res, nil
+}
+
+// applyProblem opens, counts or suppresses a problem event.
+func (h *TelemetryHandler) applyProblem(ctx context.Context, tx store.Tx, rules []models.TelemetryRule, ev *models.TelemetryEvent, res *telemetryResult, now time.Time) error {
+	rule, matched := service.MatchTelemetryRule(rules, ev.EventType)
+	sev := service.TelemetrySeverity(rule, matched, ev.Severity)
+	ev.Severity = string(sev)
+
+	if err := store.LockTelemetryKeyTx(ctx, tx, ev.TenantID, ev.DeviceID, ev.EventType); err != nil {
+		return err
+	}
+	open, err := store.GetOpenTelemetryCorrelationTx(ctx, tx, ev.TenantID, ev.DeviceID, ev.EventType)
+	if err != nil {
+		return err
+	}
+	if open != nil {
+		// An incident resolved or closed by hand ends its correlation; the
+		// next problem opens a new incident.
+		_, status, err := store.IncidentStatusForUpdateTx(ctx, tx, ev.TenantID, open.IncidentID)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+		if err != nil || status == models.IncidentResolved || status == models.IncidentClosed {
+			if err := store.ResolveTelemetryCorrelationTx(ctx, tx, ev.TenantID, open.ID, now); err != nil {
+				return err
+			}
+			open = nil
+		}
+	}
+
+	window := service.TelemetryDedupeWindow(rule, time.Duration(h.cfg.TelemetryDedupeWindowSeconds)*time.Second)
+	ev.Outcome = service.DecideTelemetryProblem(rule, open, ev.OccurredAt, window)
+	switch ev.Outcome {
+	case models.TelemetryOpened:
+		inc := h.telemetryIncident(ctx, *ev, sev, now)
+		if err := store.CreateIncidentTx(ctx, tx, inc); err != nil {
+			return err
+		}
+		if err := store.EnqueueEventTx(ctx, tx, incidentEvent(models.EventIncidentCreated, inc, "", inc)); err != nil {
+			return err
+		}
+		corr := models.TelemetryCorrelation{
+			ID:              store.NewID("tcor"),
+			TenantID:        ev.TenantID,
+			DeviceID:        ev.DeviceID,
+			EventType:       ev.EventType,
+			IncidentID:      inc.ID,
+			Occurrences:     1,
+			FirstOccurredAt: ev.OccurredAt,
+			LastOccurredAt:  ev.OccurredAt,
+		}
+		if err := store.CreateTelemetryCorrelationTx(ctx, tx, corr); err != nil {
+			return err
+		}
+		ev.CorrelationID, ev.IncidentID, res.Occurrences = corr.ID, inc.ID, 1
+	case models.TelemetryCorrelated:
+		n, err := store.CountTelemetryOccurrenceTx(ctx, tx, ev.TenantID, open.ID, ev.OccurredAt)
+		if err != nil {
+			return err
+		}
+		ev.CorrelationID, ev.IncidentID, res.Occurrences = open.ID, open.IncidentID, n
+	case models.TelemetryDuplicate:
+		if err := store.CountTelemetryDuplicateTx(ctx, tx, ev.TenantID, open.ID); err != nil {
+			return err
+		}
+		ev.CorrelationID, ev.IncidentID, res.Occurrences = open.ID, open.IncidentID, open.Occurrences
+	}
+	return nil
+}
+
+// applyRecovery resolves the open incident for problemType, when its rule
+// and the incident workflow allow it. Incidents it moves are added to
+// resolved.
+func (h *TelemetryHandler) applyRecovery(ctx context.Context, tx store.Tx, rules []models.TelemetryRule, wf models.Workflow, ev *models.TelemetryEvent, problemType string, now time.Time, resolved *[]telemetryResolution) error {
+	rule, _ := service.MatchTelemetryRule(rules, problemType)
+	if err := store.LockTelemetryKeyTx(ctx, tx, ev.TenantID, ev.DeviceID, problemType); err != nil {
+		return err
+	}
+	open, err := store.GetOpenTelemetryCorrelationTx(ctx, tx, ev.TenantID, ev.DeviceID, problemType)
+	if err != nil {
+		return err
+	}
+
+	switch service.DecideTelemetryRecovery(rule, open) {
+	case models.TelemetryResolved:
+		r, settled, err := resolveTelemetryIncident(ctx, tx, wf, ev.TenantID, open.IncidentID, "telemetry recovered: "+ev.EventType, now)
+		if err != nil {
+			return err
+		}
+		if !settled {
+			// The workflow keeps the incident open, so the correlation
+			// stays open with it and the recovery is only recorded
+			h.log.Warn("incident workflow does not allow resolving, leaving open",
+				zap.String("incidentId", open.IncidentID))
+			if ev.Outcome == models.TelemetryUnmatched {
+				ev.Outcome, ev.CorrelationID, ev.IncidentID = models.TelemetryIgnored, open.ID, open.IncidentID
+			}
+			return nil
+		}
+		if err := store.ResolveTelemetryCorrelationTx(ctx, tx, ev.TenantID, open.ID, now); err != nil {
+			return err
+		}
+		if r != nil {
+			*resolved = append(*resolved, *r)
+		}
+		ev.Outcome, ev.CorrelationID, ev.IncidentID = models.TelemetryResolved, open.ID, open.IncidentID
+	case models.TelemetryIgnored:
+		if ev.Outcome == models.TelemetryUnmatched {
+			ev.Outcome, ev.CorrelationID, ev.IncidentID = models.TelemetryIgnored, open.ID, open.IncidentID
+		}
+	}
+	return nil
+}
+
+// telemetryResolution is an incident a recovery event resolved.
+type telemetryResolution struct {
+	TenantID, SchoolID, IncidentID string
+	From                           models.IncidentStatus
+}
+
+// resolveTelemetryIncident moves an open incident to resolved through the
+// tenant's workflow. settled reports whether the incident is now resolved
+// or closed (or gone); it is false when the workflow cannot take it to
+// resolved, and the incident is left open. The resolution is returned when
+// this call moved the incident.
+func resolveTelemetryIncident(ctx context.Context, tx store.Tx, wf models.Workflow, tenant, incidentID, reason string, now time.Time) (*telemetryResolution, bool, error) {
+	school, status, err := store.IncidentStatusForUpdateTx(ctx, tx, tenant, incidentID)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+	if status == models.IncidentResolved || status == models.IncidentClosed {
+		return nil, true, nil
+	}
+	if service.TransitionPath(wf, string(status), string(models.IncidentResolved), telemetryRoles) == nil {
+		return nil, false, nil
+	}
+	if err := store.UpdateIncidentStatusTx(ctx, tx, tenant, school, incidentID, models.IncidentResolved, now); err != nil {
+		return nil, false, err
+	}
+	inc := models.Incident{ID: incidentID, TenantID: tenant, SchoolID: school}
+	if err := store.EnqueueEventTx(ctx, tx, incidentEvent(models.EventIncidentStatusChanged, inc, "",
+		models.StatusChangedData{From: string(status), To: string(models.IncidentResolved), Reason: reason})); err != nil {
+		return nil, false, err
+	}
+	return &telemetryResolution{TenantID: tenant, SchoolID: school, IncidentID: incidentID, From: status}, true, nil
+}
+
+// afterTelemetryResolve syncs the SLA clock of an incident a recovery
+// resolved and audits the change. Failures are logged.
+func (h *TelemetryHandler) afterTelemetryResolve(ctx context.Context, wf models.Workflow, r telemetryResolution, now time.Time) {
+	inc, err := h.pg.Incidents().GetByID(ctx, r.TenantID, r.SchoolID, r.IncidentID)
+	if err != nil {
+		h.log.Error("failed to load resolved incident", zap.String("incidentId", r.IncidentID), zap.Error(err))
+		return
+	}
+	before := inc
+	before.Status = r.From
+	inc = syncSLAClock(ctx, h.log, h.pg, wf, inc, "", now)
+	if err := h.audit.LogUpdate(ctx, "incident", inc.ID, before, inc); err != nil {
+		h.log.Error("failed to log incident update audit", zap.Error(err))
+	}
+}
+
+func (h *TelemetryHandler) telemetryIncident(ctx context.Context, ev models.TelemetryEvent, sev models.Severity, now time.Time) models.Incident {
 	inc := models.Incident{
 		ID:          store.NewID("inc"),
-		TenantID:    tenant,
-		SchoolID:    school,
-		DeviceID:    strings.TrimSpace(e.DeviceID),
-		Category:    "telemetry:" + strings.TrimSpace(e.Type),
+		TenantID:    ev.TenantID,
+		SchoolID:    ev.SchoolID,
+		DeviceID:    ev.DeviceID,
+		Category:    "telemetry:" + ev.EventType,
 		Severity:    sev,
 		Status:      models.IncidentNew,
-		Title:       "Telemetry: " + strings.TrimSpace(e.Type),
-		Description: strings.TrimSpace(e.Message),
-		ReportedBy:  firstNonEmpty(strings.TrimSpace(e.ReportedBy), "nexus-mdm"),
-		SLADueAt:    service.SLADue(sev, now),
+		Title:       "Telemetry: " + ev.EventType,
+		Description: ev.Message,
+		ReportedBy:  ev.ReportedBy,
 		SLABreached: false,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
-	if err := h.pg.Incidents().Create(r.Context(), inc); err != nil {
-		http.Error(w, "failed to create incident", http.StatusInternalServerError)
+	applySLA(ctx, h.log, h.pg, &inc, "", now)
+	return inc
+}
+
+// ListEvents returns raw telemetry events, newest first.
+// GET /v1/telemetry/events?deviceId=&type=&incidentId=&limit=&offset=
+func (h *TelemetryHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := store.TelemetryEventFilter{
+		DeviceID:   strings.TrimSpace(q.Get("deviceId")),
+		EventType:  strings.ToLower(strings.TrimSpace(q.Get("type"))),
+		IncidentID: strings.TrimSpace(q.Get("incidentId")),
+		Limit:      parseLimit(q.Get("limit"), 50, 500),
+		Offset:     parseOffset(q.Get("offset")),
+	}
+	items, err := h.pg.Telemetry().ListEvents(r.Context(), middleware.TenantID(r.Context()), f)
+	if err != nil {
+		h.log.Error("failed to list telemetry events", zap.Error(err))
+		http.Error(w, "failed to list telemetry events", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, http.StatusCreated, inc)
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "limit": f.Limit, "offset": f.Offset})
 }
 
-func parseSeverity(s string) models.Severity {
-	switch strings.ToLower(strings.TrimSpace(s)) {
-	case "critical", "critial", "crit":
-		return models.SeverityCritical
-	case "high":
-		return models.SeverityHigh
-	case "medium", "med":
-		return models.SeverityMedium
-	default:
-		return models.SeverityLow
+// GetIncidentTelemetry returns the correlation behind a telemetry incident
+// and its latest events.
+// GET /v1/telemetry/incidents/{incidentId}
+func (h *TelemetryHandler) GetIncidentTelemetry(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantID(r.Context())
+	id := chi.URLParam(r, "incidentId")
+	corr, err := h.pg.Telemetry().GetCorrelationByIncident(r.Context(), tenant, id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	events, err := h.pg.Telemetry().ListEvents(r.Context(), tenant, store.TelemetryEventFilter{IncidentID: id, Limit: 50})
+	if err != nil {
+		h.log.Error("failed to list telemetry events", zap.Error(err))
+		http.Error(w, "failed to list telemetry events", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"correlation": corr, "events": events})
+}
+
+// ListRules returns the tenant's telemetry rules.
+// GET /v1/telemetry/rules
+func (h *TelemetryHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.pg.Telemetry().ListRules(r.Context(), middleware.TenantID(r.Context()))
+	if err != nil {
+		h.log.Error("failed to list telemetry rules", zap.Error(err))
+		http.Error(w, "failed to list telemetry rules", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": rules})
+}
+
+type telemetryRuleReq struct {
+	Severity            string `json:"severity"`
+	DedupeWindowSeconds *int   `json:"dedupeWindowSeconds"`
+	RecoveryEventType   string `json:"recoveryEventType"`
+	AutoResolve         *bool  `json:"autoResolve"`
+	CreateIncident      *bool  `json:"createIncident"`
+}
+
+// PutRule creates or replaces the rule for an event type; "*" sets the
+// fallback for types without their own rule.
+// PUT /v1/telemetry/rules/{eventType}
+func (h *TelemetryHandler) PutRule(w http.ResponseWriter, r *http.Request) {
+	var req telemetryRuleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	eventType := strings.ToLower(strings.TrimSpace(chi.URLParam(r, "eventType")))
+	sev, ok := service.ParseSeverity(req.Severity)
+	if !ok {
+		http.Error(w, "severity must be low, medium, high or critical", http.StatusBadRequest)
+		return
+	}
+	if req.DedupeWindowSeconds != nil && (*req.DedupeWindowSeconds < 0 || *req.DedupeWindowSeconds > 86400) {
+		http.Error(w, "dedupeWindowSeconds must be between 0 and 86400", http.StatusBadRequest)
+		return
+	}
+	recovery := strings.ToLower(strings.TrimSpace(req.RecoveryEventType))
+	if recovery == eventType || recovery == models.TelemetryRuleWildcard {
+		http.Error(w, "recoveryEventType must name another event type", http.StatusBadRequest)
+		return
+	}
+
+	tenant := middleware.TenantID(r.Context())
+	now := time.Now().UTC()
+	rule := models.TelemetryRule{
+		ID:                  store.NewID("trule"),
+		TenantID:            tenant,
+		EventType:           eventType,
+		Severity:            sev,
+		DedupeWindowSeconds: req.DedupeWindowSeconds,
+		RecoveryEventType:   recovery,
+		AutoResolve:         req.AutoResolve == nil || *req.AutoResolve,
+		CreateIncident:      req.CreateIncident == nil || *req.CreateIncident,
+		UpdatedAt:           now,
+	}
+	saved, err := h.pg.Telemetry().UpsertRule(r.Context(), rule)
+	if err != nil {
+		h.log.Error("failed to save telemetry rule", zap.Error(err))
+		http.Error(w, "failed to save telemetry rule", http.StatusInternalServerError)
+		return
+	}
+	if err := h.audit.LogUpdate(r.Context(), "telemetry_rule", saved.ID, nil, saved); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// DeleteRule removes the rule for an event type.
+// DELETE /v1/telemetry/rules/{eventType}
+func (h *TelemetryHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	eventType := strings.ToLower(strings.TrimSpace(chi.URLParam(r, "eventType")))
+	if err := h.pg.Telemetry().DeleteRule(r.Context(), middleware.TenantID(r.Context()), eventType); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("failed to delete telemetry rule", zap.Error(err))
+		http.Error(w, "failed to delete telemetry rule", http.StatusInternalServerError)
+		return
+	}
+	if err := h.audit.LogDelete(r.Context(), "telemetry_rule", eventType, nil); err != nil {
+		h.log.Error("failed to write audit log", zap.Error(err))
 	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func firstNonEmpty(a, b string) string {
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"go.uber.org/zap"
 )
@@ -44,7 +46,7 @@ type PendingPartIssue struct {
 // InventoryActivity represents a stock movement event
 type InventoryActivity struct {
 	ID          string `json:"id"`
-	Type        string `json:"type"` // receipt, issue, adjustment, transfer
+	Type        string `json:"type"` // stock movement type, e.g. receipt, issue, adjustment, transfer_out
 	Description string `json:"description"`
 	PartName    string `json:"partName"`
 	ActorName   string `json:"actorName"`
@@ -155,16 +157,9 @@ func (h *WarehouseDashboardHandler) GetDashboardSummary(w http.ResponseWriter, r
 		summary.PendingWorkOrders = len(summary.PendingPartIssues)
 	}
 
-	// Get today's inventory movements from audit logs
+	// Get today's stock movements from the ledger
 	today := time.Now().UTC().Truncate(24 * time.Hour)
-	var todayMovements int
-	err = pool.QueryRow(ctx, `
-		SELECT COUNT(*)
-		FROM audit_logs
-		WHERE tenant_id = $1
-			AND entity_type = 'inventory'
-			AND created_at >= $2
-	`, tenant, today).Scan(&todayMovements)
+	todayMovements, err := h.pg.StockLedger().CountMovements(ctx, tenant, today)
 	if err != nil {
 		h.log.Error("failed to count today movements", zap.Error(err))
 	}
@@ -192,52 +187,13 @@ func (h *WarehouseDashboardHandler) GetDashboardSummary(w http.ResponseWriter, r
 		}
 	}
 
-	// Get recent activity from audit logs
-	activityRows, err := pool.Query(ctx, `
-		SELECT
-			a.id,
-			a.action,
-			COALESCE(a.actor_name, 'System') as actor_name,
-			a.entity_id,
-			a.created_at
-		FROM audit_logs a
-		WHERE a.tenant_id = $1
-			AND a.entity_type IN ('inventory', 'parts', 'bom_item')
-		ORDER BY a.created_at DESC
-		LIMIT 10
-	`, tenant)
+	// Get recent activity from the stock ledger
+	recent, _, err := h.pg.StockLedger().ListMovements(ctx, store.StockMovementListParams{TenantID: tenant, Limit: 10})
 	if err != nil {
 		h.log.Error("failed to query recent activity", zap.Error(err))
-	} else {
-		defer activityRows.Close()
-		for activityRows.Next() {
-			var activity InventoryActivity
-			var createdAt time.Time
-			var entityID string
-			if err := activityRows.Scan(
-				&activity.ID, &activity.Type, &activity.ActorName,
-				&entityID, &createdAt,
-			); err != nil {
-				continue
-			}
-			// Map action to type
-			switch activity.Type {
-			case "create":
-				activity.Type = "receipt"
-				activity.Description = "Added to inventory"
-			case "update":
-				activity.Type = "adjustment"
-				activity.Description = "Stock adjusted"
-			case "delete":
-				activity.Type = "issue"
-				activity.Description = "Removed from inventory"
-			default:
-				activity.Description = "Inventory updated"
-			}
-			activity.PartName = entityID[:12] + "..."
-			activity.CreatedAt = createdAt.UTC().Format(time.RFC3339)
-			summary.RecentActivity = append(summary.RecentActivity, activity)
-		}
+	}
+	for _, m := range recent {
+		summary.RecentActivity = append(summary.RecentActivity, movementActivity(m))
 	}
 
 	writeJSON(w, http.StatusOK, summary)
@@ -345,59 +301,66 @@ func (h *WarehouseDashboardHandler) GetPendingPartIssues(w http.ResponseWriter,
 
 // GetStockMovements returns recent stock movement history
 func (h *WarehouseDashboardHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
-	tenant := middleware.TenantID(r.Context())
-	ctx := r.Context()
-	pool := h.pg.RawPool()
-	limit := parseLimit(r.URL.Query().Get("limit"), 20, 100)
-
-	rows, err := pool.Query(ctx, `
-		SELECT
-			a.id,
-			a.action,
-			COALESCE(a.actor_name, 'System') as actor_name,
-			a.entity_id,
-			a.created_at
-		FROM audit_logs a
-		WHERE a.tenant_id = $1
-			AND a.entity_type IN ('inventory', 'parts', 'bom_item')
-		ORDER BY a.created_at DESC
-		LIMIT $2
-	`, tenant, limit)
+	q := r.URL.Query()
+	movements, _, err := h.pg.StockLedger().ListMovements(r.Context(), store.StockMovementListParams{
+		TenantID: middleware.TenantID(r.Context()),
+		ShopID:   strings.TrimSpace(q.Get("serviceShopId")),
+		Limit:    parseLimit(q.Get("limit"), 20, 100),
+	})
 	if err != nil {
 		h.log.Error("failed to query stock movements", zap.Error(err))
 		http.Error(w, "failed to query stock movements", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	items := []InventoryActivity{}
-	for rows.Next() {
-		var activity InventoryActivity
-		var createdAt time.Time
-		var entityID string
-		if err := rows.Scan(
-			&activity.ID, &activity.Type, &activity.ActorName,
-			&entityID, &createdAt,
-		); err != nil {
-			continue
-		}
-		switch activity.Type {
-		case "create":
-			activity.Type = "receipt"
-			activity.Description = "Added to inventory"
-		case "update":
-			activity.Type = "adjustment"
-			activity.Description = "Stock adjusted"
-		case "delete":
-			activity.Type = "issue"
-			activity.Description = "Removed from inventory"
-		default:
-			activity.Description = "Inventory updated"
-		}
-		activity.PartName = entityID[:12] + "..."
-		activity.CreatedAt = createdAt.UTC().Format(time.RFC3339)
-		items = append(items, activity)
+	items := make([]InventoryActivity, 0, len(movements))
+	for _, m := range movements {
+		items = append(items, movementActivity(m))
 	}
-
 	writeJSON(w, http.StatusOK, map[string]any{"items": items})
 }
+
+// movementActivity summarises a ledger movement for the dashboard. QtyChange
+// is the change to on-hand stock at the movement's shop.
+func movementActivity(m models.StockMovement) InventoryActivity {
+	a := InventoryActivity{
+		ID:        m.ID,
+		Type:      string(m.Type),
+		PartName:  m.PartName,
+		ActorName: m.ActorID,
+		CreatedAt: m.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if a.PartName == "" {
+		a.PartName = m.PartID
+	}
+	if a.ActorName == "" {
+		a.ActorName = "System"
+	}
+	switch m.Type {
+	case models.StockOpening:
+		a.Description, a.QtyChange = "Opening balance", m.Qty
+	case models.StockReceipt:
+		a.Description, a.QtyChange = "Received from supplier", m.Qty
+	case models.StockReservation:
+		a.Description = "Reserved for work order"
+	case models.StockRelease:
+		a.Description = "Reservation released"
+	case models.StockIssue:
+		a.Description, a.QtyChange = "Issued to work order", -m.Qty
+	case models.StockTransferOut:
+		a.Description, a.QtyChange = "Shipped to "+m.CounterShopID, -m.Qty
+	case models.StockTransferIn:
+		a.Description, a.QtyChange = "Received from "+m.CounterShopID, m.Qty
+	case models.StockTransferLoss:
+		a.Description = "Lost in transit from " + m.CounterShopID
+	case models.StockTransferCancel:
+		a.Description, a.QtyChange = "Transfer cancelled", m.Qty
+	case models.StockAdjustment:
+		a.Description, a.QtyChange = "Stock adjusted ("+m.ReasonCode+")", m.Qty
+	case models.StockCycleCount:
+		a.Description, a.QtyChange = "Cycle count variance", m.Qty
+	default:
+		a.Description = "Inventory updated"
+	}
+	return a
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -140,7 +141,7 @@ func (h *WorkOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:         now,
 	}
 
-	if err := h.pg.WorkOrders().Create(r.Context(), wo); err != nil {
+	if err := createWorkOrderWithEvent(r.Context(), h.pg, wo, middleware.UserID(r.Context())); err != nil {
 		http.Error(w, "failed to create work order", http.StatusInternalServerError)
 		return
 	}
@@ -216,12 +217,24 @@ func (h *WorkOrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
-	if !service.CanTransitionWorkOrder(cur.Status, req.Status) {
-		http.Error(w, "invalid status transition", http.StatusBadRequest)
+	wf := loadWorkflow(r.Context(), h.log, h.pg, tenant, models.WorkflowWorkOrder)
+	if err := service.CheckTransition(wf, string(cur.Status), string(req.Status), middleware.Roles(r.Context()), false, workOrderGuards(r.Context(), h.pg, cur)); err != nil {
+		writeTransitionError(w, h.log, err)
 		return
 	}
 
-	updated, err := h.pg.WorkOrders().UpdateStatus(r.Context(), tenant, school, id, req.Status, time.Now().UTC())
+	err = h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if err := store.UpdateWorkOrderStatusTx(ctx, tx, tenant, school, id, req.Status, time.Now().UTC()); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, workOrderEvent(models.EventWorkOrderStatusChanged, tenant, school, id,
+			middleware.UserID(r.Context()), models.StatusChangedData{From: string(cur.Status), To: string(req.Status)}))
+	})
+	if err != nil {
+		http.Error(w, "failed to update status", http.StatusInternalServerError)
+		return
+	}
+	updated, err := h.pg.WorkOrders().GetByID(r.Context(), tenant, school, id)
 	if err != nil {
 		http.Error(w, "failed to update status", http.StatusInternalServerError)
 		return
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// loadWorkflow returns the tenant's workflow for an entity, or the built-in
+// one when the tenant has none or it cannot be loaded.
+func loadWorkflow(ctx context.Context, log *zap.Logger, pg *store.Postgres, tenantID string, entity models.WorkflowEntity) models.Workflow {
+	wf, err := pg.Workflows().Get(ctx, tenantID, entity)
+	if err == nil {
+		return wf
+	}
+	if err.Error() != "not found" {
+		log.Warn("failed to load workflow, using built-in", zap.String("entity", string(entity)), zap.Error(err))
+	}
+	def, _ := service.DefaultWorkflow(entity)
+	def.TenantID = tenantID
+	return def
+}
+
+// workOrderGuards evaluates workflow guards against a work order.
+func workOrderGuards(ctx context.Context, pg *store.Postgres, wo models.WorkOrder) service.GuardFunc {
+	return func(g models.WorkflowGuard) (bool, error) {
+		switch g {
+		case models.GuardDeliverablesApproved:
+			n, err := pg.WorkOrderDeliverables().CountNotApprovedByWorkOrder(ctx, wo.TenantID, wo.SchoolID, wo.ID)
+			return n == 0, err
+		case models.GuardApprovalGranted:
+			return wo.ApprovalStatus == "approved" || wo.ApprovalStatus == "not_required", nil
+		case models.GuardAssigned:
+			return wo.ServiceShopID != "" || wo.AssignedStaffID != "", nil
+		}
+		return false, nil
+	}
+}
+
+// writeTransitionError maps a workflow rejection to an HTTP response.
+func writeTransitionError(w http.ResponseWriter, log *zap.Logger, err error) {
+	var te *service.TransitionError
+	if !errors.As(err, &te) {
+		log.Error("failed to evaluate workflow transition", zap.Error(err))
+		http.Error(w, "failed to evaluate transition", http.StatusInternalServerError)
+		return
+	}
+	switch te.Code {
+	case service.TransitionForbidden:
+		http.Error(w, te.Message, http.StatusForbidden)
+	case service.TransitionGuard:
+		http.Error(w, te.Message, http.StatusConflict)
+	default:
+		http.Error(w, te.Message, http.StatusBadRequest)
+	}
+}
+
+type WorkflowsHandler struct {
+	log   *zap.Logger
+	pg    *store.Postgres
+	audit audit.AuditLogger
+}
+
+func NewWorkflowsHandler(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) *WorkflowsHandler {
+	return &WorkflowsHandler{log: log, pg: pg, audit: auditLogger}
+}
+
+func workflowEntityParam(r *http.Request) (models.WorkflowEntity, bool) {
+	entity := models.WorkflowEntity(strings.ReplaceAll(chi.URLParam(r, "entity"), "-", "_"))
+	_, ok := service.DefaultWorkflow(entity)
+	return entity, ok
+}
+
+// Get returns the workflow graph (states and transitions) for the dashboard
+// GET /v1/workflows/{entity}
+func (h *WorkflowsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	entity, ok := workflowEntityParam(r)
+	if !ok {
+		http.Error(w, "unknown workflow entity", http.StatusNotFound)
+		return
+	}
+	wf := loadWorkflow(r.Context(), h.log, h.pg, middleware.TenantID(r.Context()), entity)
+	writeJSON(w, http.StatusOK, wf)
+}
+
+type putWorkflowReq struct {
+	Name        string                      `json:"name"`
+	States      []models.WorkflowState      `json:"states"`
+	Transitions []models.WorkflowTransition `json:"transitions"`
+}
+
+// Put replaces the tenant's workflow for an entity
+// PUT /v1/workflows/{entity}
+func (h *WorkflowsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	entity, ok := workflowEntityParam(r)
+	if !ok {
+		http.Error(w, "unknown workflow entity", http.StatusNotFound)
+		return
+	}
+	var req putWorkflowReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	tenant := middleware.TenantID(r.Context())
+	before := loadWorkflow(r.Context(), h.log, h.pg, tenant, entity)
+
+	now := time.Now().UTC()
+	wf := models.Workflow{
+		ID:          store.NewID("wf"),
+		TenantID:    tenant,
+		Entity:      entity,
+		Name:        strings.TrimSpace(req.Name),
+		States:      req.States,
+		Transitions: req.Transitions,
+		UpdatedBy:   middleware.UserID(r.Context()),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := service.ValidateWorkflow(wf); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := h.pg.Workflows().Upsert(r.Context(), wf)
+	if err != nil {
+		h.log.Error("failed to save workflow", zap.Error(err))
+		http.Error(w, "failed to save workflow", http.StatusInternalServerError)
+		return
+	}
+
+	saved := loadWorkflow(r.Context(), h.log, h.pg, tenant, entity)
+	saved.Version = version
+
+	if err := h.audit.LogUpdate(r.Context(), "workflow", string(entity), before, saved); err != nil {
+		h.log.Error("failed to log workflow update audit", zap.Error(err))
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// Delete resets the tenant to the built-in workflow
+// DELETE /v1/workflows/{entity}
+func (h *WorkflowsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	entity, ok := workflowEntityParam(r)
+	if !ok {
+		http.Error(w, "unknown workflow entity", http.StatusNotFound)
+		return
+	}
+	tenant := middleware.TenantID(r.Context())
+	before := loadWorkflow(r.Context(), h.log, h.pg, tenant, entity)
+
+	if err := h.pg.Workflows().Delete(r.Context(), tenant, entity); err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.audit.LogDelete(r.Context(), "workflow", string(entity), before); err != nil {
+		h.log.Error("failed to log workflow delete audit", zap.Error(err))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/audit"
 	"github.com/edvirons/ssp/ims/internal/middleware"
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
 	"go.uber.org/zap"
 )
@@ -96,6 +99,8 @@ func (h *WorkOrderBulkHandler) BulkStatusUpdate(w http.ResponseWriter, r *http.R
 
 	succeeded := []string{}
 	failed := []models.BulkOperationError{}
+	wf := loadWorkflow(r.Context(), h.log, h.pg, tenant, models.WorkflowWorkOrder)
+	roles := middleware.Roles(r.Context())
 
 	// Validate each work order
 	validIDs := []string{}
@@ -110,12 +115,17 @@ func (h *WorkOrderBulkHandler) BulkStatusUpdate(w http.ResponseWriter, r *http.R
 			continue
 		}
 
-		// Check if status transition is valid (forward transitions only for bulk)
-		if !isValidForwardTransition(wo.Status, req.Status) {
+		// Check the tenant workflow (transitions marked bulk:false are single-item only)
+		if err := service.CheckTransition(wf, string(wo.Status), string(req.Status), roles, true, workOrderGuards(r.Context(), h.pg, wo)); err != nil {
+			code := "transition_error"
+			var te *service.TransitionError
+			if errors.As(err, &te) {
+				code = te.Code
+			}
 			failed = append(failed, models.BulkOperationError{
 				ID:      id,
-				Message: "invalid status transition from " + string(wo.Status) + " to " + string(req.Status),
-				Code:    "invalid_transition",
+				Message: err.Error(),
+				Code:    code,
 			})
 			continue
 		}
@@ -125,7 +135,7 @@ func (h *WorkOrderBulkHandler) BulkStatusUpdate(w http.ResponseWriter, r *http.R
 
 	// Perform bulk update for valid IDs
 	if len(validIDs) > 0 {
-		if err := h.pg.WorkOrders().BulkUpdateStatus(r.Context(), tenant, school, validIDs, req.Status, now); err != nil {
+		if err := h.bulkUpdateStatus(r.Context(), tenant, school, woMap, validIDs, req.Status, userID, "", now); err != nil {
 			h.log.Error("failed to bulk update status", zap.Error(err))
 			// Mark all as failed
 			for _, id := range validIDs {
@@ -416,7 +426,7 @@ func (h *WorkOrderBulkHandler) BulkApproval(w http.ResponseWriter, r *http.Reque
 
 	// Perform bulk status update
 	if len(validIDs) > 0 {
-		if err := h.pg.WorkOrders().BulkUpdateStatus(r.Context(), tenant, school, validIDs, targetStatus, now); err != nil {
+		if err := h.bulkUpdateStatus(r.Context(), tenant, school, woMap, validIDs, targetStatus, userID, "bulk_approval:"+req.Decision, now); err != nil {
 			h.log.Error("failed to bulk update approval", zap.Error(err))
 			for _, id := range validIDs {
 				failed = append(failed, models.BulkOperationError{
@@ -463,24 +473,21 @@ func (h *WorkOrderBulkHandler) BulkApproval(w http.ResponseWriter, r *http.Reque
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-// isValidForwardTransition checks if a status transition is valid (forward only).
-func isValidForwardTransition(from, to models.WorkOrderStatus) bool {
-	validTransitions := map[models.WorkOrderStatus][]models.WorkOrderStatus{
-		models.WorkOrderDraft:     {models.WorkOrderAssigned},
-		models.WorkOrderAssigned:  {models.WorkOrderInRepair},
-		models.WorkOrderInRepair:  {models.WorkOrderQA},
-		models.WorkOrderQA:        {models.WorkOrderCompleted},
-		models.WorkOrderCompleted: {models.WorkOrderApproved},
-	}
-
-	allowed, exists := validTransitions[from]
-	if !exists {
-		return false
-	}
-	for _, s := range allowed {
-		if s == to {
-			return true
+// bulkUpdateStatus updates the status of several work orders and enqueues a
+// status_changed event for each, all in one transaction.
+func (h *WorkOrderBulkHandler) bulkUpdateStatus(ctx context.Context, tenant, school string, woMap map[string]models.WorkOrder, ids []string, status models.WorkOrderStatus, actorID, reason string, now time.Time) error {
+	return h.pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		if err := store.BulkUpdateWorkOrderStatusTx(ctx, tx, tenant, school, ids, status, now); err != nil {
+			return err
 		}
-	}
-	return false
+		for _, id := range ids {
+			wo := woMap[id]
+			ev := workOrderEvent(models.EventWorkOrderStatusChanged, tenant, school, id, actorID,
+				models.StatusChangedData{From: string(wo.Status), To: string(status), Reason: reason, Bulk: true})
+			if err := store.EnqueueEventTx(ctx, tx, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
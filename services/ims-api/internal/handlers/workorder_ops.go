@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -257,11 +258,19 @@ func (h *WorkOrderOpsHandler) RequestApproval(w http.ResponseWriter, r *http.Req
 		RequestedAt:       now,
 		Status:            models.ApprovalPending,
 	}
-	if err := h.pg.WorkOrderApprovals().Request(r.Context(), a); err != nil {
+	err = h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if err := store.RequestWorkOrderApprovalTx(ctx, tx, a); err != nil {
+			return err
+		}
+		if err := store.SetWorkOrderApprovalStatusTx(ctx, tx, tenant, school, woID, "pending"); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, workOrderEvent(models.EventWorkOrderApprovalRequested, tenant, school, woID, a.RequestedByUserID, a))
+	})
+	if err != nil {
 		http.Error(w, "failed to request approval", http.StatusInternalServerError)
 		return
 	}
-	_ = h.pg.WorkOrders().SetApprovalStatus(r.Context(), tenant, school, woID, "pending")
 	writeJSON(w, http.StatusCreated, a)
 }
 
@@ -282,12 +291,22 @@ func (h *WorkOrderOpsHandler) DecideApproval(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := h.pg.WorkOrderApprovals().Decide(r.Context(), tenant, school, approvalID, strings.TrimSpace(req.DecidedByUserID), strings.TrimSpace(req.Status), strings.TrimSpace(req.Notes)); err != nil {
+	st := strings.TrimSpace(req.Status)
+	deciderID := strings.TrimSpace(req.DecidedByUserID)
+	err := h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if err := store.DecideWorkOrderApprovalTx(ctx, tx, tenant, school, approvalID, deciderID, st, strings.TrimSpace(req.Notes)); err != nil {
+			return err
+		}
+		if err := store.SetWorkOrderApprovalStatusTx(ctx, tx, tenant, school, woID, st); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, workOrderEvent(models.EventWorkOrderApprovalDecided, tenant, school, woID, deciderID,
+			map[string]any{"approvalId": approvalID, "status": st, "notes": strings.TrimSpace(req.Notes)}))
+	})
+	if err != nil {
 		http.Error(w, "failed to decide", http.StatusBadRequest)
 		return
 	}
-	st := strings.TrimSpace(req.Status)
-	_ = h.pg.WorkOrders().SetApprovalStatus(r.Context(), tenant, school, woID, st)
 	updated, _ := h.pg.WorkOrderApprovals().GetByID(r.Context(), tenant, school, approvalID)
 	writeJSON(w, http.StatusOK, updated)
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -123,12 +124,24 @@ func (h *WorkOrderReworkHandler) Reject(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Update work order status
-	updated, err := h.pg.WorkOrders().UpdateStatusWithRework(r.Context(), tenant, school, id, req.TargetStatus, req.Reason, now)
+	err = h.pg.WithTx(r.Context(), func(ctx context.Context, tx store.Tx) error {
+		if err := store.UpdateWorkOrderStatusWithReworkTx(ctx, tx, tenant, school, id, req.TargetStatus, req.Reason, now); err != nil {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, workOrderEvent(models.EventWorkOrderStatusChanged, tenant, school, id, userID,
+			models.StatusChangedData{From: string(current.Status), To: string(req.TargetStatus), Reason: req.Reason}))
+	})
 	if err != nil {
 		h.log.Error("failed to update work order", zap.Error(err))
 		http.Error(w, "failed to update work order status", http.StatusInternalServerError)
 		return
 	}
+	updated, err := h.pg.WorkOrders().GetByID(r.Context(), tenant, school, id)
+	if err != nil {
+		h.log.Error("failed to reload work order", zap.Error(err))
+		http.Error(w, "failed to update work order status", http.StatusInternalServerError)
+		return
+	}
 
 	// Audit log
 	if err := h.audit.LogUpdate(r.Context(), "work_order", id,
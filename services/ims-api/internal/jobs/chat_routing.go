@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/chatrouting"
+	"github.com/edvirons/ssp/ims/internal/logging"
+	"go.uber.org/zap"
+)
+
+// runChatRouting re-routes waiting livechat sessions, so sessions queued for
+// want of a skilled or free agent are placed once overflow rules apply.
+func (s *Scheduler) runChatRouting(ctx context.Context, now time.Time) {
+	tenants, err := s.pg.ChatSessions().ListTenantsWithWaitingSessions(ctx)
+	if err != nil {
+		s.log.Warn("jobs: list waiting chat tenants failed", logging.Err(err))
+		return
+	}
+	router := chatrouting.New(s.log, s.pg, s.hub)
+	for _, tenantID := range tenants {
+		if n := router.DrainQueue(ctx, tenantID); n > 0 {
+			s.log.Info("jobs: waiting chats assigned", zap.String("tenantId", tenantID), zap.Int("count", n))
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/audit"
+	"github.com/edvirons/ssp/ims/internal/logging"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"go.uber.org/zap"
+)
+
+// deviceLifecycleActor is recorded on changes IMS makes in response to
+// ssot-devices lifecycle transitions.
+const deviceLifecycleActor = "ssot-devices"
+
+// deviceLifecycleRoles are the roles the reactor acts with when it walks
+// an incident through the workflow.
+var deviceLifecycleRoles = []string{"ssp_admin"}
+
+// deviceClosure is the audit record of an incident closed by the reactor.
+type deviceClosure struct {
+	models.Incident
+	LifecycleReason string   `json:"lifecycleReason"`
+	WorkflowPath    []string `json:"workflowPath"`
+}
+
+// DeviceLifecycleReactor returns the ssotcache hook for device lifecycle
+// transitions. Open incidents on a device that is retired or disposed can
+// no longer be worked, so they are closed. Each close must be reachable in
+// the tenant's incident workflow without guards; incidents that cannot be
+// closed that way are left open. Closes are audited as ssot-devices.
+func DeviceLifecycleReactor(log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger) func(ctx context.Context, tenantID string, transitions []models.DeviceLifecycleTransition) {
+	return func(ctx context.Context, tenantID string, transitions []models.DeviceLifecycleTransition) {
+		var wf *models.Workflow
+		for _, t := range transitions {
+			if !models.DeviceDecommissioned(t.To) {
+				continue
+			}
+			open, err := pg.Incidents().ListOpenForDevice(ctx, tenantID, t.DeviceID)
+			if err != nil {
+				log.Error("jobs: list incidents for decommissioned device failed", zap.String("tenantId", tenantID),
+					zap.String("deviceId", t.DeviceID), logging.Err(err))
+				continue
+			}
+			if len(open) == 0 {
+				continue
+			}
+			if wf == nil {
+				w := incidentWorkflow(ctx, log, pg, tenantID)
+				wf = &w
+			}
+
+			reason := "device " + t.To
+			if t.Reason != "" {
+				reason += ": " + t.Reason
+			}
+			var closed []string
+			for _, inc := range open {
+				if closeForDevice(ctx, log, pg, auditLogger, *wf, inc, reason) {
+					closed = append(closed, inc.ID)
+				}
+			}
+			if len(closed) > 0 {
+				log.Info("jobs: closed incidents for decommissioned device", zap.String("tenantId", tenantID),
+					zap.String("deviceId", t.DeviceID), zap.String("lifecycle", t.To), zap.Strings("incidentIds", closed))
+			}
+		}
+	}
+}
+
+// incidentWorkflow loads a tenant's incident workflow, falling back to the
+// built-in one.
+func incidentWorkflow(ctx context.Context, log *zap.Logger, pg *store.Postgres, tenantID string) models.Workflow {
+	wf, err := pg.Workflows().Get(ctx, tenantID, models.WorkflowIncident)
+	if err == nil {
+		return wf
+	}
+	if err.Error() != "not found" {
+		log.Warn("jobs: failed to load incident workflow, using built-in", zap.String("tenantId", tenantID), logging.Err(err))
+	}
+	def, _ := service.DefaultWorkflow(models.WorkflowIncident)
+	def.TenantID = tenantID
+	return def
+}
+
+// closeForDevice closes one incident through the workflow, queues its
+// status change event and records an audit entry. It reports whether the
+// incident was closed.
+func closeForDevice(ctx context.Context, log *zap.Logger, pg *store.Postgres, auditLogger audit.AuditLogger, wf models.Workflow, inc models.Incident, reason string) bool {
+	path := service.TransitionPath(wf, string(inc.Status), string(models.IncidentClosed), deviceLifecycleRoles)
+	if path == nil {
+		log.Warn("jobs: incident workflow does not allow closing, leaving open", zap.String("tenantId", inc.TenantID),
+			zap.String("incidentId", inc.ID), zap.String("status", string(inc.Status)))
+		return false
+	}
+
+	now := time.Now().UTC()
+	changed := false
+	err := pg.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		var err error
+		changed, err = store.ChangeIncidentStatusFromTx(ctx, tx, inc.TenantID, inc.ID, inc.Status, models.IncidentClosed, now)
+		if err != nil || !changed {
+			return err
+		}
+		return store.EnqueueEventTx(ctx, tx, models.DomainEvent{
+			Type:          models.EventIncidentStatusChanged,
+			TenantID:      inc.TenantID,
+			SchoolID:      inc.SchoolID,
+			AggregateType: "incident",
+			AggregateID:   inc.ID,
+			ActorID:       deviceLifecycleActor,
+			Data:          models.StatusChangedData{From: string(inc.Status), To: string(models.IncidentClosed), Reason: reason},
+		})
+	})
+	if err != nil {
+		log.Error("jobs: close incident for decommissioned device failed", zap.String("tenantId", inc.TenantID),
+			zap.String("incidentId", inc.ID), logging.Err(err))
+		return false
+	}
+	if !changed {
+		// Someone else moved the incident first; leave it to them
+		return false
+	}
+
+	after := deviceClosure{Incident: inc, LifecycleReason: reason, WorkflowPath: path}
+	after.Status = models.IncidentClosed
+	after.UpdatedAt = now
+	actx := audit.WithContext(ctx, audit.AuditContext{TenantID: inc.TenantID, UserID: deviceLifecycleActor})
+	if err := auditLogger.LogUpdate(actx, "incident", inc.ID, inc, after); err != nil {
+		log.Warn("jobs: audit log failed", zap.String("incidentId", inc.ID), logging.Err(err))
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/logging"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"go.uber.org/zap"
+)
+
+// deviceRegistrationBatch bounds how many pending registrations are retried
+// per tick.
+const deviceRegistrationBatch = 50
+
+// runDeviceRegistrations retries school-registered devices that could not
+// reach ssot-devices when they were submitted. The requester is notified
+// once a registration is confirmed or rejected; confirmed devices join
+// automatic groups through the stale-group evaluation that follows.
+func (s *Scheduler) runDeviceRegistrations(ctx context.Context, now time.Time) {
+	if s.registry == nil {
+		return
+	}
+	regs, err := s.pg.DeviceRegistrations().ListDue(ctx, now, deviceRegistrationBatch)
+	if err != nil {
+		s.log.Warn("jobs: list due device registrations failed", logging.Err(err))
+		return
+	}
+	for _, reg := range regs {
+		out, err := service.SubmitDeviceRegistration(ctx, s.pg.DeviceRegistrations(), s.registry, reg, now)
+		if err != nil {
+			s.log.Warn("jobs: device registration failed", zap.String("registrationId", reg.ID), logging.Err(err))
+			continue
+		}
+		if out.Status != models.DeviceRegistrationPending {
+			s.notifyRegistrationOutcome(ctx, out, now)
+		}
+	}
+}
+
+func (s *Scheduler) notifyRegistrationOutcome(ctx context.Context, reg models.DeviceRegistration, now time.Time) {
+	if reg.RequestedBy == "" {
+		return
+	}
+	title := "Device registered"
+	body := fmt.Sprintf("Device %s was registered as %s", reg.Serial, reg.DeviceID)
+	if reg.Status == models.DeviceRegistrationRejected {
+		title = "Device registration rejected"
+		body = fmt.Sprintf("Device %s was rejected: %s", reg.Serial, reg.RejectionReason)
+	}
+	n := models.UserNotification{
+		ID:               store.NewID("ntf"),
+		TenantID:         reg.TenantID,
+		UserID:           reg.RequestedBy,
+		NotificationType: models.ProjectNotificationDeviceRegistration,
+		EntityType:       "device_registration",
+		EntityID:         reg.ID,
+		Title:            title,
+		Body:             body,
+		Metadata: map[string]any{
+			"schoolId":      reg.SchoolID,
+			"status":        reg.Status,
+			"deviceId":      reg.DeviceID,
+			"rejectionCode": reg.RejectionCode,
+		},
+		CreatedAt: now,
+	}
+	if err := s.pg.UserNotifications().CreateBulkNotifications(ctx, []models.UserNotification{n}); err != nil {
+		s.log.Warn("jobs: device registration notification failed", zap.String("registrationId", reg.ID), logging.Err(err))
+	}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/logging"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"go.uber.org/zap"
+)
+
+// groupEvaluationBatch bounds how many stale groups are re-evaluated per tick.
+const groupEvaluationBatch = 50
+
+// runGroupEvaluation recomputes dynamic and location groups whose devices,
+// assignments or locations changed since their last evaluation. This is how
+// SSOT device syncs, from the API or the sync worker, reach group membership.
+func (s *Scheduler) runGroupEvaluation(ctx context.Context, now time.Time) {
+	groups, err := s.pg.Groups().ListStale(ctx, groupEvaluationBatch)
+	if err != nil {
+		s.log.Warn("jobs: list stale device groups failed", logging.Err(err))
+		return
+	}
+	for _, g := range groups {
+		added, removed, err := service.EvaluateGroup(ctx, s.pg.Groups(), g, "scheduled", now)
+		if err != nil {
+			s.log.Warn("jobs: evaluate device group failed", zap.String("groupId", g.ID), logging.Err(err))
+			continue
+		}
+		if len(added)+len(removed) > 0 {
+			s.log.Info("jobs: device group membership updated", zap.String("groupId", g.ID),
+				zap.Int("added", len(added)), zap.Int("removed", len(removed)))
+		}
+	}
+}
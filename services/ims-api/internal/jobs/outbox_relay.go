@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/logging"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/shared/pkg/eventbus"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+const (
+	outboxPollInterval  = 2 * time.Second
+	outboxBatchSize     = 100
+	outboxMaxAttempts   = 20
+	outboxMaxBackoff    = 5 * time.Minute
+	outboxRetention     = 7 * 24 * time.Hour
+	outboxPurgeInterval = time.Hour
+)
+
+// IMSEventStream stores every ims.> domain event published by the relay.
+var IMSEventStream = eventbus.StreamConfig{
+	Name:       "IMS_EVENTS",
+	Subjects:   []string{"ims.>"},
+	MaxAge:     7 * 24 * time.Hour,
+	Duplicates: 10 * time.Minute,
+}
+
+// outboxBackoff doubles the retry delay per attempt, capped at outboxMaxBackoff.
+func outboxBackoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempts && d < outboxMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > outboxMaxBackoff {
+		d = outboxMaxBackoff
+	}
+	return d
+}
+
+// eventPublisher delivers one outbox event; a nil error means it is stored
+// by the broker. eventbus.JetStreamPublisher implements it.
+type eventPublisher interface {
+	Publish(subject, msgID string, data []byte) error
+}
+
+// OutboxRelay publishes event_outbox rows to NATS JetStream. Several API
+// replicas can run a relay; rows are claimed with SKIP LOCKED.
+type OutboxRelay struct {
+	log *zap.Logger
+	pg  *store.Postgres
+	nc  *nats.Conn
+	pub eventPublisher
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+// NewOutboxRelay connects to NATS and makes sure the IMS event stream exists.
+func NewOutboxRelay(log *zap.Logger, pg *store.Postgres, natsURL string) (*OutboxRelay, error) {
+	nc, err := nats.Connect(natsURL, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	pub, err := eventbus.NewJetStreamPublisher(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if err := eventbus.EnsureStream(pub.JetStream(), IMSEventStream); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return &OutboxRelay{log: log, pg: pg, nc: nc, pub: pub, stop: make(chan struct{})}, nil
+}
+
+func (o *OutboxRelay) Start(ctx context.Context) {
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		t := time.NewTicker(outboxPollInterval)
+		defer t.Stop()
+		lastPurge := time.Time{}
+
+		for {
+			select {
+			case <-ctx.Done():
+				o.log.Info("outbox relay: context done")
+				return
+			case <-o.stop:
+				o.log.Info("outbox relay: stopped")
+				return
+			case <-t.C:
+				o.drain(ctx)
+				if time.Since(lastPurge) > outboxPurgeInterval {
+					lastPurge = time.Now()
+					n, err := o.pg.Outbox().PurgePublished(ctx, time.Now().UTC().Add(-outboxRetention))
+					if err != nil {
+						o.log.Warn("outbox relay: purge failed", logging.Err(err))
+					} else if n > 0 {
+						o.log.Info("outbox relay: purged published events", zap.Int("count", n))
+					}
+				}
+			}
+		}
+	}()
+}
+
+// drain publishes batches until the backlog of due events is empty.
+func (o *OutboxRelay) drain(ctx context.Context) {
+	retry := store.OutboxRetryPolicy{MaxAttempts: outboxMaxAttempts, Backoff: outboxBackoff}
+	for {
+		published, failed, err := o.pg.Outbox().ProcessPending(ctx, outboxBatchSize, retry, func(e models.OutboxEvent) error {
+			return o.pub.Publish(e.Subject, e.ID, e.Payload)
+		})
+		if err != nil {
+			o.log.Warn("outbox relay: batch failed", logging.Err(err))
+			return
+		}
+		if failed > 0 {
+			o.log.Warn("outbox relay: publish failures, will retry", zap.Int("failed", failed), zap.Int("published", published))
+		}
+		if published+failed < outboxBatchSize || failed > 0 {
+			return
+		}
+	}
+}
+
+func (o *OutboxRelay) Stop() {
+	close(o.stop)
+	o.wg.Wait()
+	_ = o.nc.Drain()
+}
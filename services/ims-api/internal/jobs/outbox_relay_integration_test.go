@@ -0,0 +1,159 @@
+//go:build integration
+// +build integration
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakePublisher fails events listed in failing and records, for each
+// delivered event, the row status it saw at publish time.
+type fakePublisher struct {
+	db *store.Postgres
+
+	mu        sync.Mutex
+	failing   map[string]bool
+	published []string
+	statusAt  map[string]string
+}
+
+func (f *fakePublisher) Publish(subject, msgID string, data []byte) error {
+	var status string
+	if err := f.db.RawPool().QueryRow(context.Background(),
+		`SELECT status FROM event_outbox WHERE id=$1`, msgID).Scan(&status); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing[msgID] {
+		return errors.New("nats: no responders available for request")
+	}
+	f.published = append(f.published, msgID)
+	f.statusAt[msgID] = status
+	return nil
+}
+
+func (f *fakePublisher) setFailing(ids ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = map[string]bool{}
+	for _, id := range ids {
+		f.failing[id] = true
+	}
+}
+
+type outboxRow struct {
+	Status      string
+	Attempts    int
+	LastError   string
+	NextAttempt time.Time
+	Published   bool
+}
+
+func loadOutboxRow(t *testing.T, db *store.Postgres, id string) outboxRow {
+	t.Helper()
+	var r outboxRow
+	require.NoError(t, db.RawPool().QueryRow(context.Background(), `
+		SELECT status, attempts, last_error, next_attempt_at, published_at IS NOT NULL
+		FROM event_outbox WHERE id=$1
+	`, id).Scan(&r.Status, &r.Attempts, &r.LastError, &r.NextAttempt, &r.Published))
+	return r
+}
+
+func TestOutboxRelay_PublishFailureRetried(t *testing.T) {
+	testutil.SkipIfShort(t)
+	ctx := context.Background()
+	db := testutil.SetupTestDB(t)
+	testutil.TruncateTables(t, db.RawPool(), "event_outbox")
+
+	ids := []string{store.NewID("evt"), store.NewID("evt"), store.NewID("evt")}
+	require.NoError(t, db.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		for _, id := range ids {
+			if err := store.EnqueueEventTx(ctx, tx, models.DomainEvent{
+				ID: id, Type: models.EventIncidentCreated, TenantID: "tenant-1",
+				AggregateType: "incident", AggregateID: store.NewID("inc"),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	pub := &fakePublisher{db: db, statusAt: map[string]string{}}
+	pub.setFailing(ids[1])
+	relay := &OutboxRelay{log: zap.NewNop(), pg: db, pub: pub, stop: make(chan struct{})}
+
+	// A failed publish leaves its row pending with a backoff; the others
+	// are marked published, and only after the broker accepted them
+	before := time.Now().UTC()
+	relay.drain(ctx)
+	assert.Equal(t, []string{ids[0], ids[2]}, pub.published)
+	for _, id := range []string{ids[0], ids[2]} {
+		assert.Equal(t, string(models.OutboxPending), pub.statusAt[id], "status while publishing %s", id)
+		r := loadOutboxRow(t, db, id)
+		assert.Equal(t, string(models.OutboxPublished), r.Status)
+		assert.Equal(t, 1, r.Attempts)
+		assert.True(t, r.Published)
+	}
+	failed := loadOutboxRow(t, db, ids[1])
+	assert.Equal(t, string(models.OutboxPending), failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Contains(t, failed.LastError, "no responders")
+	assert.False(t, failed.Published)
+	assert.False(t, failed.NextAttempt.Before(before.Add(outboxBackoff(1))), "next attempt %v is not backed off", failed.NextAttempt)
+
+	// Not due yet, so a second pass publishes nothing
+	pub.setFailing()
+	relay.drain(ctx)
+	assert.Len(t, pub.published, 2)
+
+	// Once due it is retried with the same message id and published
+	_, err := db.RawPool().Exec(ctx, `UPDATE event_outbox SET next_attempt_at=now() - interval '1 second' WHERE id=$1`, ids[1])
+	require.NoError(t, err)
+	relay.drain(ctx)
+	assert.Equal(t, []string{ids[0], ids[2], ids[1]}, pub.published)
+	retried := loadOutboxRow(t, db, ids[1])
+	assert.Equal(t, string(models.OutboxPublished), retried.Status)
+	assert.Equal(t, 2, retried.Attempts)
+	assert.Empty(t, retried.LastError)
+	assert.True(t, retried.Published)
+}
+
+func TestOutboxRelay_GivesUpAfterMaxAttempts(t *testing.T) {
+	testutil.SkipIfShort(t)
+	ctx := context.Background()
+	db := testutil.SetupTestDB(t)
+	testutil.TruncateTables(t, db.RawPool(), "event_outbox")
+
+	id := store.NewID("evt")
+	require.NoError(t, db.WithTx(ctx, func(ctx context.Context, tx store.Tx) error {
+		return store.EnqueueEventTx(ctx, tx, models.DomainEvent{
+			ID: id, Type: models.EventIncidentCreated, TenantID: "tenant-1",
+			AggregateType: "incident", AggregateID: store.NewID("inc"),
+		})
+	}))
+	_, err := db.RawPool().Exec(ctx, `UPDATE event_outbox SET attempts=$2 WHERE id=$1`, id, outboxMaxAttempts-1)
+	require.NoError(t, err)
+
+	pub := &fakePublisher{db: db, statusAt: map[string]string{}}
+	pub.setFailing(id)
+	relay := &OutboxRelay{log: zap.NewNop(), pg: db, pub: pub, stop: make(chan struct{})}
+	relay.drain(ctx)
+
+	r := loadOutboxRow(t, db, id)
+	assert.Equal(t, string(models.OutboxFailed), r.Status)
+	assert.Equal(t, outboxMaxAttempts, r.Attempts)
+	assert.False(t, r.Published)
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{9, 256 * time.Second},
+		{10, outboxMaxBackoff},
+		{outboxMaxAttempts, outboxMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempts); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -5,21 +5,27 @@ import (
 	"sync"
 	"time"
 
-	"github.com/edvirons/ssp/ims/internal/logging"
+	"github.com/edvirons/ssp/ims/internal/service"
 	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/ws"
 	"go.uber.org/zap"
 )
 
 type Scheduler struct {
 	log *zap.Logger
 	pg  *store.Postgres
+	hub *ws.Hub
+
+	// registry receives school-registered devices that are awaiting a
+	// retry; nil disables the retry job.
+	registry service.DeviceRegistry
 
 	wg   sync.WaitGroup
 	stop chan struct{}
 }
 
-func NewScheduler(log *zap.Logger, pg *store.Postgres) *Scheduler {
-	return &Scheduler{log: log, pg: pg, stop: make(chan struct{})}
+func NewScheduler(log *zap.Logger, pg *store.Postgres, hub *ws.Hub, registry service.DeviceRegistry) *Scheduler {
+	return &Scheduler{log: log, pg: pg, hub: hub, registry: registry, stop: make(chan struct{})}
 }
 
 func (s *Scheduler) Start(ctx context.Context) {
@@ -38,14 +44,11 @@ func (s *Scheduler) Start(ctx context.Context) {
 				s.log.Info("jobs: stopped")
 				return
 			case <-t.C:
-				n, err := s.pg.Incidents().MarkSLABreaches(ctx, time.Now().UTC())
-				if err != nil {
-					s.log.Warn("jobs: mark sla breaches failed", logging.Err(err))
-					continue
-				}
-				if n > 0 {
-					s.log.Info("jobs: sla breaches updated", zap.Int("count", n))
-				}
+				now := time.Now().UTC()
+				s.runSLAChecks(ctx, now)
+				s.runDeviceRegistrations(ctx, now)
+				s.runGroupEvaluation(ctx, now)
+				s.runChatRouting(ctx, now)
 			}
 		}
 	}()
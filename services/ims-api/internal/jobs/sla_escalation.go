@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/logging"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/service"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/ws"
+	"go.uber.org/zap"
+)
+
+// slaWarningScanLimit bounds how many running SLA clocks are evaluated per tick.
+const slaWarningScanLimit = 500
+
+// opsManagerRole is escalated to when a tenant configures no SLA recipients.
+const opsManagerRole = "ssp_ops_manager"
+
+// opsManagerSeenWithin is how recently a user must have made a request with
+// opsManagerRole to be escalated to.
+const opsManagerSeenWithin = 90 * 24 * time.Hour
+
+// runSLAChecks flags breaches and raises pre-breach warnings.
+func (s *Scheduler) runSLAChecks(ctx context.Context, now time.Time) {
+	breached, err := s.pg.Incidents().BreachOverdue(ctx, now)
+	if err != nil {
+		s.log.Warn("jobs: mark sla breaches failed", logging.Err(err))
+	} else {
+		for _, inc := range breached {
+			s.escalateSLA(ctx, inc, models.SLAEventBreached, 0, now)
+		}
+		if len(breached) > 0 {
+			s.log.Info("jobs: sla breaches updated", zap.Int("count", len(breached)))
+		}
+	}
+
+	responded, err := s.pg.Incidents().MarkResponseBreaches(ctx, now)
+	if err != nil {
+		s.log.Warn("jobs: mark sla response breaches failed", logging.Err(err))
+	} else {
+		for _, inc := range responded {
+			s.recordSLAEvent(ctx, inc, models.SLAEventResponseBreached, 0, now)
+		}
+		if len(responded) > 0 {
+			s.log.Info("jobs: sla response breaches updated", zap.Int("count", len(responded)))
+		}
+	}
+
+	s.raiseSLAWarnings(ctx, now)
+}
+
+// raiseSLAWarnings notifies when an open incident crosses one of its tenant's
+// warning thresholds. Each threshold is raised at most once per incident.
+func (s *Scheduler) raiseSLAWarnings(ctx context.Context, now time.Time) {
+	running, err := s.pg.Incidents().ListSLARunning(ctx, slaWarningScanLimit)
+	if err != nil {
+		s.log.Warn("jobs: list running sla clocks failed", logging.Err(err))
+		return
+	}
+
+	configs := map[string]models.SLAWarningConfig{}
+	calendars := map[string]*models.SLACalendar{}
+	for _, inc := range running {
+		cfg, ok := configs[inc.TenantID]
+		if !ok {
+			cfg, err = s.pg.FeatureConfig().GetSLAWarningConfig(ctx, inc.TenantID)
+			if err != nil {
+				s.log.Warn("jobs: load sla warning config failed", zap.String("tenantId", inc.TenantID), logging.Err(err))
+				continue
+			}
+			configs[inc.TenantID] = cfg
+		}
+		if len(cfg.ThresholdsPct) == 0 {
+			continue
+		}
+
+		calKey := inc.TenantID + "/" + inc.SLAPolicyID
+		cal, ok := calendars[calKey]
+		if !ok {
+			cal, err = s.pg.SLACalendars().ForPolicy(ctx, inc.TenantID, inc.SLAPolicyID)
+			if err != nil {
+				s.log.Warn("jobs: load sla calendar failed", zap.String("incidentId", inc.ID), logging.Err(err))
+			}
+			calendars[calKey] = cal
+		}
+
+		budget := time.Duration(inc.SLABudgetSeconds) * time.Second
+		remaining := service.BusinessDuration(cal, now, inc.SLADueAt)
+		pct := service.NextSLAWarning(cfg.ThresholdsPct, inc.SLAWarnedPct, service.SLAElapsedPct(budget, remaining))
+		if pct == 0 {
+			continue
+		}
+
+		ok, err = s.pg.Incidents().SetSLAWarnedPct(ctx, inc.TenantID, inc.ID, pct, now)
+		if err != nil {
+			s.log.Warn("jobs: record sla warning failed", zap.String("incidentId", inc.ID), logging.Err(err))
+			continue
+		}
+		if ok {
+			s.escalateSLA(ctx, inc, models.SLAEventWarning, pct, now)
+		}
+	}
+}
+
+// recordSLAEvent adds an entry to the incident's SLA timeline.
+func (s *Scheduler) recordSLAEvent(ctx context.Context, inc models.Incident, evType models.SLAEventType, pct int, now time.Time) {
+	if err := s.pg.SLAEvents().Create(ctx, models.IncidentSLAEvent{
+		ID:           store.NewID("slaev"),
+		TenantID:     inc.TenantID,
+		IncidentID:   inc.ID,
+		EventType:    evType,
+		ThresholdPct: pct,
+		DueAt:        &inc.SLADueAt,
+		CreatedAt:    now,
+	}); err != nil {
+		s.log.Warn("jobs: record sla event failed", zap.String("incidentId", inc.ID), logging.Err(err))
+	}
+}
+
+// escalateSLA records an SLA event and notifies the lead technician of the
+// shop working the incident plus the tenant's ops managers: the configured
+// ones, or else every user recently seen with the ops manager role.
+func (s *Scheduler) escalateSLA(ctx context.Context, inc models.Incident, evType models.SLAEventType, pct int, now time.Time) {
+	s.recordSLAEvent(ctx, inc, evType, pct, now)
+
+	recipients := []string{}
+	seen := map[string]bool{}
+	if lead, err := s.pg.ServiceStaff().GetLeadByIncident(ctx, inc.TenantID, inc.ID); err == nil && lead.UserID != "" {
+		recipients = append(recipients, lead.UserID)
+		seen[lead.UserID] = true
+	}
+	cfg, err := s.pg.FeatureConfig().GetSLAWarningConfig(ctx, inc.TenantID)
+	if err != nil {
+		s.log.Warn("jobs: load sla warning config failed", zap.String("tenantId", inc.TenantID), logging.Err(err))
+	}
+	managers := cfg.NotifyUserIDs
+	if len(managers) == 0 {
+		managers, err = s.pg.UserRoles().ListUserIDsByRole(ctx, inc.TenantID, opsManagerRole, now.Add(-opsManagerSeenWithin))
+		if err != nil {
+			s.log.Warn("jobs: list ops managers failed", zap.String("tenantId", inc.TenantID), logging.Err(err))
+		}
+	}
+	for _, id := range managers {
+		if id != "" && !seen[id] {
+			recipients = append(recipients, id)
+			seen[id] = true
+		}
+	}
+
+	notifType := models.ProjectNotificationSLAWarning
+	title := fmt.Sprintf("SLA %d%% elapsed: %s", pct, inc.Title)
+	action := "sla_warning"
+	if evType == models.SLAEventBreached {
+		notifType = models.ProjectNotificationSLABreach
+		title = "SLA breached: " + inc.Title
+		action = "sla_breached"
+	}
+	body := fmt.Sprintf("%s incident %s is due %s", inc.Severity, inc.ID, inc.SLADueAt.Format(time.RFC3339))
+	metadata := map[string]any{
+		"incidentId":   inc.ID,
+		"schoolId":     inc.SchoolID,
+		"severity":     inc.Severity,
+		"thresholdPct": pct,
+		"dueAt":        inc.SLADueAt,
+	}
+
+	notifications := make([]models.UserNotification, 0, len(recipients))
+	for _, userID := range recipients {
+		notifications = append(notifications, models.UserNotification{
+			ID:               store.NewID("ntf"),
+			TenantID:         inc.TenantID,
+			UserID:           userID,
+			NotificationType: notifType,
+			EntityType:       "incident",
+			EntityID:         inc.ID,
+			Title:            title,
+			Body:             body,
+			Metadata:         metadata,
+			CreatedAt:        now,
+		})
+	}
+	if err := s.pg.UserNotifications().CreateBulkNotifications(ctx, notifications); err != nil {
+		s.log.Warn("jobs: create sla notifications failed", zap.String("incidentId", inc.ID), logging.Err(err))
+	}
+
+	if s.hub != nil && len(recipients) > 0 {
+		s.hub.BroadcastToUsers(inc.TenantID, recipients, &ws.Message{
+			Type: ws.MessageTypeNotification,
+			Payload: ws.NotificationPayload{
+				ID:        inc.ID,
+				Type:      "incident",
+				Action:    action,
+				Actor:     "system",
+				Target:    inc.ID,
+				Summary:   title,
+				Timestamp: now.Format(time.RFC3339),
+				Metadata:  metadata,
+			},
+		})
+	}
+}
@@ -3,8 +3,11 @@ package lookups
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // Parts export types
@@ -53,6 +56,9 @@ type PartSummary struct {
 // LoadPartsExport loads the parts export snapshot
 func (s *Store) LoadPartsExport(ctx context.Context, tenant string) (*PartsExport, error) {
 	snap, err := s.GetSnapshot(ctx, tenant, KindParts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSnapshotMissing
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +73,7 @@ func (s *Store) LoadPartsExport(ctx context.Context, tenant string) (*PartsExpor
 func (s *Store) PartByID(ctx context.Context, tenant, partID string) (*PartSummary, error) {
 	ex, err := s.LoadPartsExport(ctx, tenant)
 	if err != nil {
-		return nil, ErrSnapshotMissing
+		return nil, err
 	}
 	for _, p := range ex.Parts {
 		if p.ID == partID {
@@ -81,7 +87,7 @@ func (s *Store) PartByID(ctx context.Context, tenant, partID string) (*PartSumma
 func (s *Store) PartByPUK(ctx context.Context, tenant, puk string) (*PartSummary, error) {
 	ex, err := s.LoadPartsExport(ctx, tenant)
 	if err != nil {
-		return nil, ErrSnapshotMissing
+		return nil, err
 	}
 	for _, p := range ex.Parts {
 		if p.PUK != "" && p.PUK == puk {
@@ -95,7 +101,7 @@ func (s *Store) PartByPUK(ctx context.Context, tenant, puk string) (*PartSummary
 func (s *Store) IsPartCompatibleWithDeviceModel(ctx context.Context, tenant, partID, deviceModelID string) (bool, error) {
 	ex, err := s.LoadPartsExport(ctx, tenant)
 	if err != nil {
-		return false, ErrSnapshotMissing
+		return false, err
 	}
 	for _, c := range ex.Compatibility {
 		if c.PartID == partID && c.DeviceModelID == deviceModelID {
@@ -104,3 +110,16 @@ func (s *Store) IsPartCompatibleWithDeviceModel(ctx context.Context, tenant, par
 	}
 	return false, nil
 }
+
+// VendorSKUsByPart returns the vendor SKUs in the parts export, keyed by part ID.
+func (s *Store) VendorSKUsByPart(ctx context.Context, tenant string) (map[string][]VendorSKU, error) {
+	ex, err := s.LoadPartsExport(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]VendorSKU{}
+	for _, v := range ex.VendorSKUs {
+		out[v.PartID] = append(out[v.PartID], v)
+	}
+	return out, nil
+}
@@ -0,0 +1,86 @@
+// Package mdm signs the policy bundles MDM agents pull for devices.
+package mdm
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Bundles are signed with Ed25519 so agents only need the public key:
+//
+//	signature = base64url(Ed25519(privateKey, payload))
+//
+// where payload is the exact bundle JSON, itself sent base64url-encoded so
+// no re-serialization can change the signed bytes.
+const Algorithm = "Ed25519"
+
+var (
+	ErrInvalidKey       = errors.New("signing key must be a base64 Ed25519 seed or private key")
+	ErrBadSignature     = errors.New("signature mismatch")
+	ErrMalformedPayload = errors.New("malformed bundle")
+)
+
+// SignedBundle is what agents download.
+type SignedBundle struct {
+	Payload   string `json:"payload"`   // base64url bundle JSON
+	Signature string `json:"signature"` // base64url signature over the decoded payload
+	KeyID     string `json:"keyId"`
+	Algorithm string `json:"alg"`
+}
+
+// Signer signs bundles with one key.
+type Signer struct {
+	keyID string
+	key   ed25519.PrivateKey
+}
+
+// NewSigner parses a standard base64 Ed25519 key: a 32-byte seed or a
+// 64-byte private key.
+func NewSigner(keyID, encodedKey string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return &Signer{keyID: keyID, key: ed25519.NewKeyFromSeed(raw)}, nil
+	case ed25519.PrivateKeySize:
+		return &Signer{keyID: keyID, key: ed25519.PrivateKey(raw)}, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// KeyID names the key so agents can pick the right public key during
+// rotation.
+func (s *Signer) KeyID() string { return s.keyID }
+
+// PublicKey returns the standard base64 public key agents verify with.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Sign signs payload.
+func (s *Signer) Sign(payload []byte) SignedBundle {
+	return SignedBundle{
+		Payload:   base64.RawURLEncoding.EncodeToString(payload),
+		Signature: base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.key, payload)),
+		KeyID:     s.keyID,
+		Algorithm: Algorithm,
+	}
+}
+
+// Verify checks a bundle against a public key and returns the payload. It is
+// what agents are expected to do and is used in tests.
+func Verify(publicKey ed25519.PublicKey, b SignedBundle) ([]byte, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(b.Payload)
+	if err != nil {
+		return nil, ErrMalformedPayload
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(b.Signature)
+	if err != nil || b.Algorithm != Algorithm || !ed25519.Verify(publicKey, payload, sig) {
+		return nil, ErrBadSignature
+	}
+	return payload, nil
+}
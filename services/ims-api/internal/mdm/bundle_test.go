@@ -0,0 +1,49 @@
+package mdm
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	s, err := NewSigner("k1", base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	pub, _ := base64.StdEncoding.DecodeString(s.PublicKey())
+	payload := []byte(`{"deviceId":"dev1"}`)
+
+	b := s.Sign(payload)
+	if b.KeyID != "k1" || b.Algorithm != Algorithm {
+		t.Errorf("bundle = %+v", b)
+	}
+	got, err := Verify(pub, b)
+	if err != nil || string(got) != string(payload) {
+		t.Fatalf("Verify() = %q, %v", got, err)
+	}
+
+	tampered := b
+	tampered.Payload = base64.RawURLEncoding.EncodeToString([]byte(`{"deviceId":"dev2"}`))
+	if _, err := Verify(pub, tampered); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered payload: err = %v", err)
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, err := Verify(other, b); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong key: err = %v", err)
+	}
+}
+
+func TestNewSignerInvalidKey(t *testing.T) {
+	for _, k := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := NewSigner("k1", k); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("NewSigner(%q) err = %v", k, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RoleRecorder stores the roles a user holds now.
+type RoleRecorder func(ctx context.Context, tenantID, userID string, roles []string) error
+
+// RecordRoles remembers the roles each signed-in user holds so background
+// jobs can find users by role. A user is recorded when their roles change
+// and otherwise at most once per interval; failures are logged and never
+// fail the request.
+func RecordRoles(logger *zap.Logger, record RoleRecorder, interval time.Duration) func(http.Handler) http.Handler {
+	type seen struct {
+		roles string
+		at    time.Time
+	}
+	var mu sync.Mutex
+	last := map[string]seen{}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			tenant, user := TenantID(ctx), UserID(ctx)
+			if tenant != "" && user != "" {
+				roles := append([]string{}, Roles(ctx)...)
+				sort.Strings(roles)
+				key := tenant + "/" + user
+				now := time.Now()
+				cur := seen{roles: strings.Join(roles, ","), at: now}
+
+				mu.Lock()
+				prev, ok := last[key]
+				due := !ok || prev.roles != cur.roles || now.Sub(prev.at) >= interval
+				if due {
+					last[key] = cur
+				}
+				mu.Unlock()
+
+				if due {
+					if err := record(ctx, tenant, user, roles); err != nil {
+						logger.Warn("failed to record user roles", zap.String("userId", user), zap.Error(err))
+						mu.Lock()
+						delete(last, key)
+						mu.Unlock()
+					}
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRecordRoles(t *testing.T) {
+	var calls []string
+	fail := false
+	record := func(_ context.Context, tenantID, userID string, roles []string) error {
+		if fail {
+			return errors.New("db down")
+		}
+		calls = append(calls, tenantID+"/"+userID+":"+strings.Join(roles, ","))
+		return nil
+	}
+	h := RecordRoles(zap.NewNop(), record, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	do := func(tenant, user string, roles ...string) {
+		ctx := WithUserID(WithTenantID(context.Background(), tenant), user)
+		ctx = WithRoles(ctx, roles)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d", rec.Code)
+		}
+	}
+
+	do("t1", "u1", "ssp_ops_manager", "ssp_admin")
+	do("t1", "u1", "ssp_admin", "ssp_ops_manager") // same roles, within the interval
+	do("t1", "u1", "ssp_admin")                    // roles changed
+	do("t1", "", "ssp_admin")                      // anonymous
+	fail = true
+	do("t2", "u1", "ssp_lead_tech")
+	fail = false
+	do("t2", "u1", "ssp_lead_tech") // retried after the failure
+
+	want := []string{
+		"t1/u1:ssp_admin,ssp_ops_manager",
+		"t1/u1:ssp_admin",
+		"t2/u1:ssp_lead_tech",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d = %q, want %q", i, calls[i], want[i])
+		}
+	}
+}
@@ -39,3 +39,84 @@ type BOQItem struct {
 	CreatedAt          time.Time `json:"createdAt"`
 	UpdatedAt          time.Time `json:"updatedAt"`
 }
+
+// BOQStatus is the state of a version of a project's bill of quantities.
+type BOQStatus string
+
+const (
+	BOQDraft     BOQStatus = "draft"     // lines can be edited
+	BOQSubmitted BOQStatus = "submitted" // waiting for approval
+	BOQApproved  BOQStatus = "approved"  // can be converted to work order BOM lines
+	BOQRevised   BOQStatus = "revised"   // superseded by a later version
+)
+
+// BOQVersion is one version of a project's bill of quantities. The project's
+// boq_items are the lines of its latest version; Items and Cost are a copy
+// taken when the version is submitted, so earlier versions stay readable
+// after the lines change.
+type BOQVersion struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenantId"`
+	ProjectID     string          `json:"projectId"`
+	Version       int             `json:"version"`
+	Status        BOQStatus       `json:"status"`
+	Items         []BOQItem       `json:"items"`
+	Cost          *BOQCostSummary `json:"cost,omitempty"`
+	Notes         string          `json:"notes,omitempty"`
+	DecisionNotes string          `json:"decisionNotes,omitempty"`
+	CreatedBy     string          `json:"createdBy,omitempty"`
+	SubmittedBy   string          `json:"submittedBy,omitempty"`
+	ApprovedBy    string          `json:"approvedBy,omitempty"`
+	ApproverRole  string          `json:"approverRole,omitempty"`
+	WorkOrderID   string          `json:"workOrderId,omitempty"` // work order the version was converted onto
+	SubmittedAt   *time.Time      `json:"submittedAt,omitempty"`
+	ApprovedAt    *time.Time      `json:"approvedAt,omitempty"`
+	RevisedAt     *time.Time      `json:"revisedAt,omitempty"`
+	ConvertedAt   *time.Time      `json:"convertedAt,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+// BOQLineCost is the priced cost of one BOQ line. Lines with a part that
+// has a vendor SKU in the parts catalog are priced at the cheapest SKU;
+// other lines keep their estimate.
+type BOQLineCost struct {
+	ItemID         string `json:"itemId"`
+	Category       string `json:"category"`
+	PartID         string `json:"partId,omitempty"`
+	Qty            int64  `json:"qty"`
+	VendorID       string `json:"vendorId,omitempty"`
+	VendorSKUID    string `json:"vendorSkuId,omitempty"`
+	UnitPriceCents int64  `json:"unitPriceCents"`
+	EstimatedCents int64  `json:"estimatedCents"`
+	CostCents      int64  `json:"costCents"`
+	Source         string `json:"source"` // vendor|estimate
+}
+
+// BOQCategoryCost totals the lines of one category.
+type BOQCategoryCost struct {
+	Category       string `json:"category"`
+	Lines          int    `json:"lines"`
+	EstimatedCents int64  `json:"estimatedCents"`
+	CostCents      int64  `json:"costCents"`
+}
+
+// BOQCostSummary rolls a BOQ's lines up by category.
+type BOQCostSummary struct {
+	Currency       string            `json:"currency,omitempty"`
+	MixedCurrency  bool              `json:"mixedCurrency,omitempty"` // vendor prices are in more than one currency
+	EstimatedCents int64             `json:"estimatedCents"`
+	CostCents      int64             `json:"costCents"`
+	UnpricedLines  int               `json:"unpricedLines"` // lines costed at their estimate
+	Categories     []BOQCategoryCost `json:"categories"`
+	Lines          []BOQLineCost     `json:"lines"`
+}
+
+// BOQConversion is the result of converting an approved BOQ into work order
+// BOM lines. Lines without a part, such as labour, are skipped.
+type BOQConversion struct {
+	Version      BOQVersion      `json:"version"`
+	WorkOrderID  string          `json:"workOrderId"`
+	Parts        []WorkOrderPart `json:"parts"`
+	SkippedItems []string        `json:"skippedItems"`
+}
@@ -24,6 +24,7 @@ type ChatSession struct {
 	AssignedAgentName    *string           `json:"assignedAgentName,omitempty"`
 	Status               ChatSessionStatus `json:"status"`
 	QueuePosition        *int              `json:"queuePosition,omitempty"`
+	QueuedAt             *time.Time        `json:"queuedAt,omitempty"` // when the session joined the agent queue
 	StartedAt            time.Time         `json:"startedAt"`
 	AgentJoinedAt        *time.Time        `json:"agentJoinedAt,omitempty"`
 	EndedAt              *time.Time        `json:"endedAt,omitempty"`
@@ -50,13 +51,15 @@ type ChatSession struct {
 
 // AgentAvailability represents an agent's availability status
 type AgentAvailability struct {
-	TenantID           string    `json:"tenantId"`
-	UserID             string    `json:"userId"`
-	IsAvailable        bool      `json:"isAvailable"`
-	MaxConcurrentChats int       `json:"maxConcurrentChats"`
-	CurrentChatCount   int       `json:"currentChatCount"`
-	LastSeenAt         time.Time `json:"lastSeenAt"`
-	UpdatedAt          time.Time `json:"updatedAt"`
+	TenantID           string     `json:"tenantId"`
+	UserID             string     `json:"userId"`
+	IsAvailable        bool       `json:"isAvailable"`
+	MaxConcurrentChats int        `json:"maxConcurrentChats"`
+	CurrentChatCount   int        `json:"currentChatCount"`
+	Skills             []string   `json:"skills"` // issue categories the agent handles
+	LastSeenAt         time.Time  `json:"lastSeenAt"`
+	LastAssignedAt     *time.Time `json:"lastAssignedAt,omitempty"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
 
 	// Denormalized for display
 	UserName string `json:"userName,omitempty"`
@@ -101,8 +104,9 @@ type TransferChatRequest struct {
 
 // SetAvailabilityRequest represents the request to set agent availability
 type SetAvailabilityRequest struct {
-	Available          bool `json:"available"`
-	MaxConcurrentChats *int `json:"maxConcurrentChats,omitempty"`
+	Available          bool      `json:"available"`
+	MaxConcurrentChats *int      `json:"maxConcurrentChats,omitempty"`
+	Skills             *[]string `json:"skills,omitempty"` // replaces the agent's skill tags when set
 }
 
 // ChatQueueItem represents an item in the chat queue
@@ -147,6 +151,15 @@ type ChatMetrics struct {
 	ActiveSessions      int     `json:"activeSessions"`
 	WaitingSessions     int     `json:"waitingSessions"`
 	EndedSessions       int     `json:"endedSessions"`
+
+	// Post-chat surveys
+	SurveysSent                int     `json:"surveysSent"`
+	SurveysCompleted           int     `json:"surveysCompleted"`
+	SurveyResponseRate         float64 `json:"surveyResponseRate"`         // percent of surveys answered
+	CSATScore                  float64 `json:"csatScore"`                  // percent of scores that are 4 or 5
+	AverageCSAT                float64 `json:"averageCsat"`                // mean score, 1-5
+	FirstContactResolutionRate float64 `json:"firstContactResolutionRate"` // percent resolved with no repeat chat
+	FCRResponses               int     `json:"fcrResponses"`               // surveys answering the resolved question
 }
 
 // AI Chat types
@@ -164,6 +177,24 @@ type AIChatMessageResponse struct {
 	ShouldEscalate bool              `json:"shouldEscalate"`
 	EscalateReason *string           `json:"escalateReason,omitempty"`
 	SessionStatus  ChatSessionStatus `json:"sessionStatus"`
+	Citations      []AIKBCitation    `json:"citations,omitempty"`
+	Ticket         *AIChatTicket     `json:"ticket,omitempty"` // filed on this turn
+}
+
+// AIChatTicket is the incident filed from an AI chat, and the work order it
+// was routed to when the fault needs physical repair.
+type AIChatTicket struct {
+	IncidentID  string `json:"incidentId"`
+	WorkOrderID string `json:"workOrderId,omitempty"`
+}
+
+// AIKBCitation is a KB article the AI's reply relied on. Marker is how the
+// reply refers to it, for example "KB1".
+type AIKBCitation struct {
+	ArticleID string `json:"articleId"`
+	Marker    string `json:"marker"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
 }
 
 // AIEscalationRequest represents a request to escalate to human agent
@@ -173,13 +204,15 @@ type AIEscalationRequest struct {
 
 // AIEscalationResponse represents the response after escalation
 type AIEscalationResponse struct {
-	Session       ChatSession `json:"session"`
-	QueuePosition *int        `json:"queuePosition,omitempty"`
+	Session       ChatSession   `json:"session"`
+	QueuePosition *int          `json:"queuePosition,omitempty"`
+	Ticket        *AIChatTicket `json:"ticket,omitempty"`
 }
 
 // AIConversationContext represents the context for agent handoff
 type AIConversationContext struct {
 	SessionID           string         `json:"sessionId"`
+	IncidentID          *string        `json:"incidentId,omitempty"` // incident linked to the chat thread
 	TurnCount           int            `json:"turnCount"`
 	Category            *string        `json:"category,omitempty"`
 	Severity            *string        `json:"severity,omitempty"`
@@ -190,3 +223,137 @@ type AIConversationContext struct {
 	DeviceContext       map[string]any `json:"deviceContext,omitempty"`
 	SchoolContext       map[string]any `json:"schoolContext,omitempty"`
 }
+
+// ChatRouteOutcome is the result of routing a waiting session
+type ChatRouteOutcome string
+
+const (
+	ChatRouteAssigned ChatRouteOutcome = "assigned" // best-scoring agent within normal rules
+	ChatRouteOverflow ChatRouteOutcome = "overflow" // assigned after skill or capacity limits relaxed
+	ChatRouteQueued   ChatRouteOutcome = "queued"   // no eligible agent; session stays waiting
+)
+
+// ChatRoutingSettings are a tenant's livechat queue overflow rules
+type ChatRoutingSettings struct {
+	TenantID             string    `json:"tenantId"`
+	RequireSkillMatch    bool      `json:"requireSkillMatch"`
+	OverflowAfterSeconds int       `json:"overflowAfterSeconds"`
+	OverflowExtraChats   int       `json:"overflowExtraChats"`
+	AffinityDays         int       `json:"affinityDays"`
+	UpdatedBy            string    `json:"updatedBy,omitempty"`
+	UpdatedAt            time.Time `json:"updatedAt"`
+}
+
+// DefaultChatRoutingSettings returns the rules used until a tenant saves its own
+func DefaultChatRoutingSettings(tenantID string) ChatRoutingSettings {
+	return ChatRoutingSettings{
+		TenantID:             tenantID,
+		OverflowAfterSeconds: 120,
+		OverflowExtraChats:   1,
+		AffinityDays:         90,
+	}
+}
+
+// UpdateChatRoutingSettingsRequest represents the request to change routing rules
+type UpdateChatRoutingSettingsRequest struct {
+	RequireSkillMatch    *bool `json:"requireSkillMatch,omitempty"`
+	OverflowAfterSeconds *int  `json:"overflowAfterSeconds,omitempty"`
+	OverflowExtraChats   *int  `json:"overflowExtraChats,omitempty"`
+	AffinityDays         *int  `json:"affinityDays,omitempty"`
+}
+
+// ChatRouteCandidate is one agent's score in a routing decision
+type ChatRouteCandidate struct {
+	AgentID        string  `json:"agentId"`
+	Score          float64 `json:"score"`
+	Skill          float64 `json:"skill"`
+	Load           float64 `json:"load"`
+	Affinity       float64 `json:"affinity"`
+	Idle           float64 `json:"idle"`
+	SchoolSessions int     `json:"schoolSessions"`
+	Eligible       bool    `json:"eligible"`
+	Excluded       string  `json:"excluded,omitempty"` // why an ineligible agent was passed over
+}
+
+// ChatRoutingDecision records how a waiting session was routed
+type ChatRoutingDecision struct {
+	ID            string               `json:"id"`
+	TenantID      string               `json:"tenantId"`
+	SessionID     string               `json:"sessionId"`
+	SchoolID      string               `json:"schoolId"`
+	IssueCategory string               `json:"issueCategory"`
+	Outcome       ChatRouteOutcome     `json:"outcome"`
+	AgentID       string               `json:"agentId,omitempty"`
+	AgentName     string               `json:"agentName,omitempty"`
+	Score         float64              `json:"score"`
+	Reason        string               `json:"reason"`
+	WaitSeconds   int                  `json:"waitSeconds"`
+	Candidates    []ChatRouteCandidate `json:"candidates"`
+	CreatedAt     time.Time            `json:"createdAt"`
+}
+
+// CannedResponse is a tenant-managed reply agents insert into chats. Content
+// may use {{variables}} filled from the chat's school and device.
+type CannedResponse struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenantId"`
+	Title      string     `json:"title"`
+	Shortcut   string     `json:"shortcut,omitempty"`
+	Category   string     `json:"category,omitempty"` // issue category it suits; empty for any
+	Content    string     `json:"content"`
+	Active     bool       `json:"active"`
+	UseCount   int        `json:"useCount"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// CannedResponseRequest represents the request to create or update a canned response
+type CannedResponseRequest struct {
+	Title    *string `json:"title,omitempty"`
+	Shortcut *string `json:"shortcut,omitempty"`
+	Category *string `json:"category,omitempty"`
+	Content  *string `json:"content,omitempty"`
+	Active   *bool   `json:"active,omitempty"`
+}
+
+// RenderedCannedResponse is a canned response filled in for one chat
+type RenderedCannedResponse struct {
+	ResponseID string   `json:"responseId"`
+	Content    string   `json:"content"`
+	Missing    []string `json:"missing"` // variables left for the agent to fill in
+}
+
+// ChatSurveyStatus represents the status of a post-chat survey
+type ChatSurveyStatus string
+
+const (
+	ChatSurveyPending   ChatSurveyStatus = "pending"
+	ChatSurveyCompleted ChatSurveyStatus = "completed"
+)
+
+// ChatSurvey is the satisfaction survey sent to the school contact when a
+// chat ends
+type ChatSurvey struct {
+	ID              string           `json:"id"`
+	TenantID        string           `json:"tenantId"`
+	SessionID       string           `json:"sessionId"`
+	SchoolID        string           `json:"schoolId"`
+	SchoolContactID string           `json:"schoolContactId"`
+	AgentID         string           `json:"agentId,omitempty"`
+	Status          ChatSurveyStatus `json:"status"`
+	CSATScore       *int             `json:"csatScore,omitempty"` // 1 (very dissatisfied) to 5 (very satisfied)
+	Resolved        *bool            `json:"resolved,omitempty"`  // issue resolved in this chat
+	Comment         string           `json:"comment,omitempty"`
+	SentAt          time.Time        `json:"sentAt"`
+	ExpiresAt       time.Time        `json:"expiresAt"`
+	RespondedAt     *time.Time       `json:"respondedAt,omitempty"`
+}
+
+// ChatSurveyResponseRequest represents a school contact's survey answers
+type ChatSurveyResponseRequest struct {
+	CSATScore int     `json:"csatScore"`
+	Resolved  *bool   `json:"resolved,omitempty"`
+	Comment   *string `json:"comment,omitempty"`
+}
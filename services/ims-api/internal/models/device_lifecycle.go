@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Device lifecycle states owned by ssot-devices. devices_snapshot.status
+// carries one of these.
+const (
+	DeviceLifecycleProcured = "procured"
+	DeviceLifecycleInStock  = "in_stock"
+	DeviceLifecycleDeployed = "deployed"
+	DeviceLifecycleInRepair = "in_repair"
+	DeviceLifecycleLoaned   = "loaned"
+	DeviceLifecycleLost     = "lost"
+	DeviceLifecycleRetired  = "retired"
+	DeviceLifecycleDisposed = "disposed"
+)
+
+// DeviceDecommissioned reports whether a device in this lifecycle state is
+// permanently out of service.
+func DeviceDecommissioned(lifecycle string) bool {
+	return lifecycle == DeviceLifecycleRetired || lifecycle == DeviceLifecycleDisposed
+}
+
+// DeviceLifecycleTransition is one lifecycle change carried by the
+// ssot.devices.changed event. From is empty for a newly created device.
+type DeviceLifecycleTransition struct {
+	ID         string    `json:"id"`
+	DeviceID   string    `json:"deviceId"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason"`
+	Source     string    `json:"source"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
@@ -0,0 +1,143 @@
+package models
+
+import "time"
+
+// DevicePolicySchemaVersion is the policy schema this server understands.
+// Stored policies carry the version they were written with so agents and
+// later migrations can tell formats apart.
+const DevicePolicySchemaVersion = 1
+
+// DevicePolicy is the control policy attached to a device group. A device in
+// several groups gets the merge of their policies; see EffectivePolicy.
+type DevicePolicy struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// Priority orders groups of the same scope when their settings
+	// conflict; higher wins.
+	Priority   int               `json:"priority"`
+	ExamMode   *ExamModePolicy   `json:"examMode,omitempty"`
+	Apps       *AppPolicy        `json:"apps,omitempty"`
+	WiFi       []WiFiProfile     `json:"wifi,omitempty"`
+	ScreenTime *ScreenTimePolicy `json:"screenTime,omitempty"`
+}
+
+// IsEmpty reports whether the policy configures nothing.
+func (p DevicePolicy) IsEmpty() bool {
+	return p.ExamMode == nil && p.Apps == nil && len(p.WiFi) == 0 && p.ScreenTime == nil
+}
+
+// ExamModePolicy locks devices down during exam windows: only AllowedApps
+// may run and, with BlockInternet, only exam traffic is allowed.
+type ExamModePolicy struct {
+	Windows       []ExamWindow `json:"windows"`
+	AllowedApps   []string     `json:"allowedApps,omitempty"`
+	BlockInternet bool         `json:"blockInternet"`
+}
+
+// ExamWindow is one scheduled exam period.
+type ExamWindow struct {
+	Name  string    `json:"name,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// AppPolicy lists app identifiers (package or bundle IDs) that are allowed
+// or blocked.
+type AppPolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Block []string `json:"block,omitempty"`
+}
+
+// WiFiSecurity is the security type of a Wi-Fi profile.
+type WiFiSecurity string
+
+const (
+	WiFiOpen           WiFiSecurity = "open"
+	WiFiWPA2           WiFiSecurity = "wpa2"
+	WiFiWPA3           WiFiSecurity = "wpa3"
+	WiFiWPA2Enterprise WiFiSecurity = "wpa2-enterprise"
+)
+
+// WiFiProfile is a network pushed to devices.
+type WiFiProfile struct {
+	SSID       string       `json:"ssid"`
+	Security   WiFiSecurity `json:"security"`
+	Passphrase string       `json:"passphrase,omitempty"` // wpa2/wpa3 only
+	Hidden     bool         `json:"hidden"`
+	AutoJoin   bool         `json:"autoJoin"`
+}
+
+// ScreenTimePolicy limits device use. DailyLimitMinutes of 0 means no limit.
+type ScreenTimePolicy struct {
+	DailyLimitMinutes int              `json:"dailyLimitMinutes,omitempty"`
+	Downtime          []DowntimeWindow `json:"downtime,omitempty"`
+}
+
+// DowntimeWindow locks the device on the given days between Start and End,
+// as HH:MM in device local time. An End before Start runs past midnight.
+type DowntimeWindow struct {
+	Days  []string `json:"days"` // mon, tue, wed, thu, fri, sat, sun
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// PolicyScope is how widely a group applies; narrower scopes take
+// precedence over wider ones.
+type PolicyScope string
+
+const (
+	PolicyScopeTenant   PolicyScope = "tenant"
+	PolicyScopeSchool   PolicyScope = "school"
+	PolicyScopeLocation PolicyScope = "location"
+)
+
+// PolicySource is a group whose policy contributed to an effective policy.
+type PolicySource struct {
+	GroupID       string      `json:"groupId"`
+	GroupName     string      `json:"groupName"`
+	Scope         PolicyScope `json:"scope"`
+	Priority      int         `json:"priority"`
+	PolicyVersion int         `json:"policyVersion"`
+}
+
+// PolicyConflict records a setting that overlapping groups disagreed on and
+// how it was resolved. Key names the app or SSID for per-item settings.
+type PolicyConflict struct {
+	Field      string   `json:"field"`
+	Key        string   `json:"key,omitempty"`
+	Resolution string   `json:"resolution"` // block_wins, most_restrictive, precedence
+	GroupIDs   []string `json:"groupIds"`
+	Winner     string   `json:"winner,omitempty"`
+}
+
+// EffectivePolicy is the merged policy for one device. Sources are listed
+// from lowest to highest precedence. Hash identifies the merged policy and
+// changes whenever the device's effective settings do.
+type EffectivePolicy struct {
+	DeviceID  string           `json:"deviceId"`
+	Policy    DevicePolicy     `json:"policy"`
+	Sources   []PolicySource   `json:"sources"`
+	Conflicts []PolicyConflict `json:"conflicts,omitempty"`
+	Hash      string           `json:"hash"`
+}
+
+// GroupPolicyVersion is a saved revision of a group's policy. A nil Policy
+// records that the policy was cleared.
+type GroupPolicyVersion struct {
+	GroupID   string        `json:"groupId"`
+	Version   int           `json:"version"`
+	Policy    *DevicePolicy `json:"policy"`
+	ChangedBy string        `json:"changedBy"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// PolicyBundle is the payload an MDM agent pulls for a device. It is signed
+// as serialized; agents verify the signature over the exact payload bytes.
+type PolicyBundle struct {
+	TenantID   string         `json:"tenantId"`
+	DeviceID   string         `json:"deviceId"`
+	IssuedAt   time.Time      `json:"issuedAt"`
+	ExpiresAt  time.Time      `json:"expiresAt"`
+	PolicyHash string         `json:"policyHash"`
+	Policy     DevicePolicy   `json:"policy"`
+	Sources    []PolicySource `json:"sources"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// DeviceRegistrationStatus tracks a school-registered device on its way to
+// ssot-devices, which owns device IDs, serial uniqueness and the model
+// catalog.
+type DeviceRegistrationStatus string
+
+const (
+	// DeviceRegistrationPending has not been confirmed by ssot-devices yet,
+	// usually because it was unreachable; it is retried with backoff.
+	DeviceRegistrationPending DeviceRegistrationStatus = "pending"
+	// DeviceRegistrationConfirmed was accepted; DeviceID is the SSOT ID.
+	DeviceRegistrationConfirmed DeviceRegistrationStatus = "confirmed"
+	// DeviceRegistrationRejected was refused by ssot-devices, for example a
+	// duplicate serial or a model missing from the catalog. It can be
+	// corrected and resubmitted.
+	DeviceRegistrationRejected DeviceRegistrationStatus = "rejected"
+)
+
+// DeviceRegistration is a device a school contact registered. The device
+// only enters devices_snapshot once ssot-devices confirms it.
+type DeviceRegistration struct {
+	ID              string                   `json:"id"`
+	TenantID        string                   `json:"tenantId"`
+	SchoolID        string                   `json:"schoolId"`
+	Serial          string                   `json:"serial"`
+	AssetTag        string                   `json:"assetTag,omitempty"`
+	Make            string                   `json:"make,omitempty"`
+	Model           string                   `json:"model"`
+	LocationID      *string                  `json:"locationId,omitempty"` // Assigned once confirmed
+	Notes           string                   `json:"notes,omitempty"`
+	Status          DeviceRegistrationStatus `json:"status"`
+	DeviceID        string                   `json:"deviceId,omitempty"`
+	RejectionCode   string                   `json:"rejectionCode,omitempty"` // duplicate_serial, unknown_model, ambiguous_model, invalid
+	RejectionReason string                   `json:"rejectionReason,omitempty"`
+	Attempts        int                      `json:"attempts"`
+	LastError       string                   `json:"lastError,omitempty"`
+	NextAttemptAt   *time.Time               `json:"nextAttemptAt,omitempty"`
+	RequestedBy     string                   `json:"requestedBy"`
+	CreatedAt       time.Time                `json:"createdAt"`
+	UpdatedAt       time.Time                `json:"updatedAt"`
+	ConfirmedAt     *time.Time               `json:"confirmedAt,omitempty"`
+}
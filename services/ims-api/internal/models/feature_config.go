@@ -13,6 +13,7 @@ const (
 	FeatureWorkOrderRework         FeatureKey = "work_order_rework"
 	FeatureWorkOrderBulkOperations FeatureKey = "work_order_bulk_operations"
 	FeatureWorkOrderUpdate         FeatureKey = "work_order_update"
+	FeatureSLAWarnings             FeatureKey = "sla_warnings"
 )
 
 // FeatureConfig represents a feature flag configuration.
@@ -79,3 +80,25 @@ func ParseBulkConfig(data json.RawMessage) BulkOperationConfig {
 	}
 	return cfg
 }
+
+// SLAWarningConfig holds configuration for pre-breach SLA warnings.
+type SLAWarningConfig struct {
+	ThresholdsPct []int    `json:"thresholds_pct"`
+	NotifyUserIDs []string `json:"notify_user_ids"` // Ops managers escalated to alongside the lead tech; empty means every ssp_ops_manager
+}
+
+// DefaultSLAWarningConfig returns default SLA warning configuration.
+func DefaultSLAWarningConfig() SLAWarningConfig {
+	return SLAWarningConfig{
+		ThresholdsPct: []int{50, 75, 90},
+	}
+}
+
+// ParseSLAWarningConfig parses SLA warning configuration from JSON.
+func ParseSLAWarningConfig(data json.RawMessage) SLAWarningConfig {
+	cfg := DefaultSLAWarningConfig()
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FieldSyncPull is what a field technician's device downloads: the work
+// orders assigned to the technician and everything needed to work them
+// offline. With a since cursor only rows changed after it are returned;
+// AssignedIDs always lists every open assignment so the device can drop
+// work orders that were reassigned or closed.
+type FieldSyncPull struct {
+	ServerTime   time.Time              `json:"serverTime"`
+	Cursor       string                 `json:"cursor"`
+	AssignedIDs  []string               `json:"assignedIds"`
+	WorkOrders   []WorkOrder            `json:"workOrders"`
+	BOM          []WorkOrderPart        `json:"bom"`
+	Schedules    []WorkOrderSchedule    `json:"schedules"`
+	Deliverables []WorkOrderDeliverable `json:"deliverables"`
+	Schools      []SchoolSnapshot       `json:"schools"`
+	Devices      []DeviceSnapshot       `json:"devices"`
+}
+
+// FieldMutationType is the kind of change queued on a device.
+type FieldMutationType string
+
+const (
+	FieldMutationWorkOrderStatus   FieldMutationType = "work_order.status"
+	FieldMutationBOMConsume        FieldMutationType = "bom.consume"
+	FieldMutationDeliverableSubmit FieldMutationType = "deliverable.submit"
+)
+
+// FieldMutation is one change made offline. BaseUpdatedAt is the updatedAt
+// of the entity the device last saw; if the server copy has changed since,
+// the mutation is reported as a conflict and not applied.
+type FieldMutation struct {
+	IdempotencyKey  string            `json:"idempotencyKey"`
+	Type            FieldMutationType `json:"type"`
+	WorkOrderID     string            `json:"workOrderId"`
+	ClientTimestamp time.Time         `json:"clientTimestamp"`
+	BaseUpdatedAt   *time.Time        `json:"baseUpdatedAt,omitempty"`
+	Payload         json.RawMessage   `json:"payload"`
+}
+
+// FieldMutationStatus is the outcome of a pushed mutation.
+type FieldMutationStatus string
+
+const (
+	FieldMutationApplied  FieldMutationStatus = "applied"
+	FieldMutationConflict FieldMutationStatus = "conflict"
+	FieldMutationRejected FieldMutationStatus = "rejected"
+	// FieldMutationFailed is a transient server error. It is not recorded,
+	// so the device should retry with the same idempotency key.
+	FieldMutationFailed FieldMutationStatus = "failed"
+)
+
+// FieldMutationResult reports what happened to one mutation. Entity holds
+// the server copy after applying, or the current copy on conflict. Replayed
+// is set when the idempotency key had already been processed.
+type FieldMutationResult struct {
+	IdempotencyKey string              `json:"idempotencyKey"`
+	Status         FieldMutationStatus `json:"status"`
+	Error          string              `json:"error,omitempty"`
+	Entity         any                 `json:"entity,omitempty"`
+	Replayed       bool                `json:"replayed"`
+}
+
+// FieldStatusPayload moves a work order to a new status.
+type FieldStatusPayload struct {
+	Status WorkOrderStatus `json:"status"`
+}
+
+// FieldConsumePayload records parts used from a BOM item.
+type FieldConsumePayload struct {
+	ItemID  string `json:"itemId"`
+	QtyUsed int64  `json:"qtyUsed"`
+}
+
+// FieldDeliverablePayload submits evidence for a deliverable.
+type FieldDeliverablePayload struct {
+	DeliverableID        string `json:"deliverableId"`
+	EvidenceAttachmentID string `json:"evidenceAttachmentId"`
+	Notes                string `json:"notes"`
+}
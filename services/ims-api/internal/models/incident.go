@@ -10,6 +10,7 @@ const (
 	IncidentAcknowledged IncidentStatus = "acknowledged"
 	IncidentInProgress   IncidentStatus = "in_progress"
 	IncidentEscalated    IncidentStatus = "escalated"
+	IncidentAwaiting     IncidentStatus = "awaiting_customer" // Waiting on the school; SLA clock paused
 	IncidentResolved     IncidentStatus = "resolved"
 	IncidentClosed       IncidentStatus = "closed"
 )
@@ -56,6 +57,17 @@ type Incident struct {
 
 	SLADueAt    time.Time `json:"slaDueAt"`
 	SLABreached bool      `json:"slaBreached"`
+	SLAPolicyID string    `json:"slaPolicyId,omitempty"` // Policy that set the due dates; empty = built-in defaults
+
+	// Response SLA (time to acknowledge), only set when a policy defines one
+	SLAResponseDueAt    *time.Time `json:"slaResponseDueAt,omitempty"`
+	SLAResponseBreached bool       `json:"slaResponseBreached"`
+
+	// SLA clock state
+	SLAPausedAt         *time.Time `json:"slaPausedAt,omitempty"`
+	SLARemainingSeconds int64      `json:"-"` // Time left when paused
+	SLABudgetSeconds    int64      `json:"slaBudgetSeconds"`
+	SLAWarnedPct        int        `json:"slaWarnedPct"`
 
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -75,34 +75,75 @@ const (
 	GroupTypeDynamic  GroupType = "dynamic"  // Auto-membership by selector criteria
 )
 
-// GroupSelector defines criteria for dynamic group membership.
+// GroupSelector defines criteria for dynamic group membership. All set
+// criteria must match.
 type GroupSelector struct {
 	Model        string   `json:"model,omitempty"`        // Device model contains
 	Lifecycle    []string `json:"lifecycle,omitempty"`    // Match any lifecycle status
 	LocationType string   `json:"locationType,omitempty"` // Match location type
-	LocationID   string   `json:"locationId,omitempty"`   // Match specific location
+	LocationID   string   `json:"locationId,omitempty"`   // Match location or any location inside it
 	SchoolID     string   `json:"schoolId,omitempty"`     // Match specific school
 }
 
+// IsEmpty reports whether the selector has no criteria.
+func (s GroupSelector) IsEmpty() bool {
+	return s.Model == "" && len(s.Lifecycle) == 0 && s.LocationType == "" && s.LocationID == "" && s.SchoolID == ""
+}
+
 // DeviceGroup represents a group of devices for control/policy purposes.
 type DeviceGroup struct {
-	ID          string          `json:"id"`
-	TenantID    string          `json:"tenantId"`
-	SchoolID    *string         `json:"schoolId,omitempty"` // nil = tenant-wide
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	GroupType   GroupType       `json:"groupType"`
-	LocationID  *string         `json:"locationId,omitempty"` // For location-based groups
-	Selector    *GroupSelector  `json:"selector,omitempty"`   // For dynamic groups
-	Policies    json.RawMessage `json:"policies,omitempty"`   // Future: exam_mode, restrictions
-	Active      bool            `json:"active"`
-	CreatedAt   time.Time       `json:"createdAt"`
-	UpdatedAt   time.Time       `json:"updatedAt"`
+	ID            string         `json:"id"`
+	TenantID      string         `json:"tenantId"`
+	SchoolID      *string        `json:"schoolId,omitempty"` // nil = tenant-wide
+	Name          string         `json:"name"`
+	Description   string         `json:"description,omitempty"`
+	GroupType     GroupType      `json:"groupType"`
+	LocationID    *string        `json:"locationId,omitempty"` // For location-based groups
+	Selector      *GroupSelector `json:"selector,omitempty"`   // For dynamic groups
+	Policies      *DevicePolicy  `json:"policies,omitempty"`
+	PolicyVersion int            `json:"policyVersion"` // Bumped on every policy change
+	Active        bool           `json:"active"`
+	EvaluatedAt   *time.Time     `json:"evaluatedAt,omitempty"` // Last membership evaluation (dynamic and location groups)
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
 
 	// Computed fields
 	MemberCount int `json:"memberCount,omitempty"`
 }
 
+// IsAutomatic reports whether membership is computed by the membership
+// engine rather than managed by hand.
+func (g DeviceGroup) IsAutomatic() bool {
+	return g.GroupType == GroupTypeDynamic || g.GroupType == GroupTypeLocation
+}
+
+// GroupCandidate is a device as seen by the membership engine: its snapshot
+// fields and current location. LocationPath holds the current location and
+// all of its ancestors.
+type GroupCandidate struct {
+	DeviceID     string   `json:"deviceId"`
+	SchoolID     string   `json:"schoolId"`
+	Model        string   `json:"model"`
+	Serial       string   `json:"serial"`
+	AssetTag     string   `json:"assetTag"`
+	Lifecycle    string   `json:"lifecycle"`
+	LocationID   string   `json:"locationId,omitempty"`
+	LocationType string   `json:"locationType,omitempty"`
+	LocationPath []string `json:"-"`
+}
+
+// GroupMembershipEvent records a device joining or leaving a group through
+// the membership engine.
+type GroupMembershipEvent struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenantId"`
+	GroupID   string    `json:"groupId"`
+	DeviceID  string    `json:"deviceId"`
+	Action    string    `json:"action"` // added, removed
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 // GroupMember represents a device's membership in a group.
 type GroupMember struct {
 	ID       string    `json:"id"`
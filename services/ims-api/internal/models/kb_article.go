@@ -123,3 +123,51 @@ func ValidKBLifecycleStages() []KBLifecycleStage {
 		KBLifecycleSupport,
 	}
 }
+
+// KBRetrievalParams selects published articles for the AI assistant.
+// Articles matching any term are ranked; those tagged with DeviceCategory
+// rank higher. Modules, when set, limits the search to those modules.
+type KBRetrievalParams struct {
+	TenantID       string
+	Terms          []string
+	Modules        []string
+	DeviceCategory string
+	Limit          int
+}
+
+// KBArticleMatch is an article retrieved for the AI assistant with its
+// relevance score.
+type KBArticleMatch struct {
+	Article KBArticle `json:"article"`
+	Score   float64   `json:"score"`
+}
+
+// KBAIUsage records that an article was given to the AI assistant on a
+// turn, and whether the reply cited it.
+type KBAIUsage struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenantId"`
+	SessionID string    `json:"sessionId"`
+	TurnID    string    `json:"turnId"`
+	ArticleID string    `json:"articleId"`
+	Rank      int       `json:"rank"`
+	Score     float64   `json:"score"`
+	Cited     bool      `json:"cited"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// KBArticleAIStats shows KB authors how an article performs in AI chats.
+// Session counts are of chats where the article was cited.
+type KBArticleAIStats struct {
+	ArticleID         string        `json:"articleId"`
+	Title             string        `json:"title"`
+	Slug              string        `json:"slug"`
+	ContentType       KBContentType `json:"contentType"`
+	Module            KBModule      `json:"module"`
+	RetrievedTurns    int           `json:"retrievedTurns"`
+	CitedTurns        int           `json:"citedTurns"`
+	CitedSessions     int           `json:"citedSessions"`
+	ResolvedSessions  int           `json:"resolvedSessions"`  // the AI resolved the chat
+	EscalatedSessions int           `json:"escalatedSessions"` // handed to a human agent
+	LastCitedAt       *time.Time    `json:"lastCitedAt,omitempty"`
+}
@@ -32,6 +32,14 @@ const (
 	ContentTypeAttachment ContentType = "attachment"
 )
 
+// MessageVisibility controls who can read a message
+type MessageVisibility string
+
+const (
+	MessageVisibilityPublic   MessageVisibility = "public"   // everyone in the thread
+	MessageVisibilityInternal MessageVisibility = "internal" // support agents only, e.g. whisper notes in a livechat
+)
+
 // MessageThread represents a conversation thread
 type MessageThread struct {
 	ID                 string       `json:"id"`
@@ -67,6 +75,7 @@ type Message struct {
 	SenderRole  string              `json:"senderRole"`
 	Content     string              `json:"content"`
 	ContentType ContentType         `json:"contentType"`
+	Visibility  MessageVisibility   `json:"visibility"`
 	Metadata    map[string]any      `json:"metadata,omitempty"`
 	EditedAt    *time.Time          `json:"editedAt,omitempty"`
 	DeletedAt   *time.Time          `json:"deletedAt,omitempty"`
@@ -126,8 +135,9 @@ type CreateThreadRequest struct {
 
 // CreateMessageRequest represents the request to send a message
 type CreateMessageRequest struct {
-	Content     string   `json:"content"`
-	Attachments []string `json:"attachments,omitempty"`
+	Content     string            `json:"content"`
+	Attachments []string          `json:"attachments,omitempty"`
+	Visibility  MessageVisibility `json:"visibility,omitempty"` // internal notes are for support agents only
 }
 
 // UpdateThreadStatusRequest represents the request to update thread status
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// NetworkScanSource is where an uploaded network scan came from.
+type NetworkScanSource string
+
+const (
+	NetworkScanDHCP  NetworkScanSource = "dhcp"  // DHCP lease table
+	NetworkScanARP   NetworkScanSource = "arp"   // ARP / neighbour table
+	NetworkScanOther NetworkScanSource = "other" // anything else listing MACs
+
+	// NetworkSourceTelemetry marks unknown MACs first reported by telemetry.
+	NetworkSourceTelemetry = "telemetry"
+)
+
+// NetworkSighting is one MAC address seen on a school network.
+type NetworkSighting struct {
+	MACAddress string    `json:"macAddress"`
+	IPAddress  string    `json:"ipAddress,omitempty"`
+	Hostname   string    `json:"hostname,omitempty"`
+	SeenAt     time.Time `json:"seenAt"`
+}
+
+// NetworkScan records an uploaded scan and what it matched.
+type NetworkScan struct {
+	ID           string            `json:"id"`
+	TenantID     string            `json:"tenantId"`
+	SchoolID     string            `json:"schoolId"`
+	Source       NetworkScanSource `json:"source"`
+	ScannedAt    time.Time         `json:"scannedAt"`
+	Entries      int               `json:"entries"`
+	Invalid      int               `json:"invalid"`
+	Resolved     int               `json:"resolved"`
+	Unknown      int               `json:"unknown"`
+	LookupFailed int               `json:"lookupFailed"`
+	Elsewhere    int               `json:"elsewhere"` // resolved to a device registered at another school
+	CreatedBy    string            `json:"createdBy,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+}
+
+// UnknownMACStatus is the triage state of an unknown MAC address.
+type UnknownMACStatus string
+
+const (
+	UnknownMACOpen     UnknownMACStatus = "open"
+	UnknownMACIgnored  UnknownMACStatus = "ignored"  // not a school device (phones, printers, ...)
+	UnknownMACResolved UnknownMACStatus = "resolved" // later matched a registered device
+)
+
+// UnknownMAC is a MAC address seen on a school network that matches no
+// registered device: a candidate unregistered device.
+type UnknownMAC struct {
+	TenantID     string           `json:"tenantId"`
+	MACAddress   string           `json:"macAddress"`
+	SchoolID     string           `json:"schoolId"`
+	Status       UnknownMACStatus `json:"status"`
+	DeviceID     string           `json:"deviceId,omitempty"`
+	FirstSeenAt  time.Time        `json:"firstSeenAt"`
+	LastSeenAt   time.Time        `json:"lastSeenAt"`
+	Sightings    int              `json:"sightings"`
+	LastIP       string           `json:"lastIp,omitempty"`
+	LastHostname string           `json:"lastHostname,omitempty"`
+	LastSource   string           `json:"lastSource"`
+	ResolvedAt   *time.Time       `json:"resolvedAt,omitempty"`
+}
+
+// UnseenDevice is a school device not seen on the network or in telemetry
+// since the report's cutoff. LastSeenAt is nil when it was never seen.
+type UnseenDevice struct {
+	DeviceID   string     `json:"deviceId"`
+	SchoolID   string     `json:"schoolId"`
+	Serial     string     `json:"serial"`
+	AssetTag   string     `json:"assetTag"`
+	Model      string     `json:"model"`
+	Status     string     `json:"status"`
+	LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+	DaysUnseen *int       `json:"daysUnseen,omitempty"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType is the NATS subject an IMS domain event is published on.
+type EventType string
+
+const (
+	EventIncidentCreated            EventType = "ims.incident.created"
+	EventIncidentStatusChanged      EventType = "ims.incident.status_changed"
+	EventWorkOrderCreated           EventType = "ims.workorder.created"
+	EventWorkOrderStatusChanged     EventType = "ims.workorder.status_changed"
+	EventWorkOrderApprovalRequested EventType = "ims.workorder.approval_requested"
+	EventWorkOrderApprovalDecided   EventType = "ims.workorder.approval_decided"
+	EventWorkOrderBOMChanged        EventType = "ims.workorder.bom_changed"
+)
+
+// EventSchemaVersion is bumped when an event payload changes incompatibly.
+const EventSchemaVersion = 1
+
+// DomainEvent is the envelope published for every IMS domain event.
+type DomainEvent struct {
+	ID            string    `json:"id"`
+	Type          EventType `json:"type"`
+	Version       int       `json:"version"`
+	TenantID      string    `json:"tenantId"`
+	SchoolID      string    `json:"schoolId,omitempty"`
+	AggregateType string    `json:"aggregateType"` // incident|work_order
+	AggregateID   string    `json:"aggregateId"`
+	ActorID       string    `json:"actorId,omitempty"`
+	OccurredAt    time.Time `json:"occurredAt"`
+	Data          any       `json:"data"`
+}
+
+// StatusChangedData is the payload of *.status_changed events.
+type StatusChangedData struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+	Bulk   bool   `json:"bulk,omitempty"`
+}
+
+// OutboxStatus is the delivery state of an outbox row.
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	OutboxFailed    OutboxStatus = "failed" // gave up after max attempts
+)
+
+// OutboxEvent is a domain event waiting to be relayed to NATS.
+type OutboxEvent struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenantId"`
+	Subject       string          `json:"subject"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        OutboxStatus    `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     string          `json:"lastError,omitempty"`
+	PublishedAt   *time.Time      `json:"publishedAt,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// ReorderPolicy sets when and how much to reorder a part for a shop. A part
+// is proposed for reorder when its projected stock (available + in transit +
+// still to arrive on open purchase orders) is at or below ReorderPoint. The
+// proposal brings stock back up to ReorderPoint + SafetyStock, ordering at
+// least MinOrderQty.
+type ReorderPolicy struct {
+	TenantID      string    `json:"tenantId"`
+	ServiceShopID string    `json:"serviceShopId"`
+	PartID        string    `json:"partId"`
+	ReorderPoint  int64     `json:"reorderPoint"`
+	SafetyStock   int64     `json:"safetyStock"`
+	MinOrderQty   int64     `json:"minOrderQty"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// VendorStrategy picks between vendor SKUs for a reorder proposal.
+type VendorStrategy string
+
+const (
+	VendorCheapest VendorStrategy = "cheapest" // lowest unit price, then shortest lead time
+	VendorFastest  VendorStrategy = "fastest"  // shortest lead time, then lowest unit price
+)
+
+// ReorderProposal is a suggested purchase for one part at one shop.
+type ReorderProposal struct {
+	ServiceShopID  string `json:"serviceShopId"`
+	PartID         string `json:"partId"`
+	PartName       string `json:"partName,omitempty"`
+	Available      int64  `json:"available"`
+	InTransit      int64  `json:"inTransit"`
+	OnOrder        int64  `json:"onOrder"`
+	ReorderPoint   int64  `json:"reorderPoint"`
+	SafetyStock    int64  `json:"safetyStock"`
+	MinOrderQty    int64  `json:"minOrderQty"`
+	ProposedQty    int64  `json:"proposedQty"`
+	VendorID       string `json:"vendorId,omitempty"`
+	VendorSKUID    string `json:"vendorSkuId,omitempty"`
+	SKU            string `json:"sku,omitempty"`
+	UnitPriceCents int64  `json:"unitPriceCents"`
+	Currency       string `json:"currency,omitempty"`
+	LeadTimeDays   int    `json:"leadTimeDays"`
+	// NoVendor is set when ssot-parts has no vendor SKU for the part.
+	NoVendor bool `json:"noVendor,omitempty"`
+}
+
+// PurchaseOrderStatus is the state of a purchase order.
+type PurchaseOrderStatus string
+
+const (
+	PODraft             PurchaseOrderStatus = "draft"
+	POApproved          PurchaseOrderStatus = "approved"
+	POSent              PurchaseOrderStatus = "sent"
+	POPartiallyReceived PurchaseOrderStatus = "partially_received"
+	POClosed            PurchaseOrderStatus = "closed"
+	POCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+// PurchaseOrder is an order for parts from one vendor, delivered to one shop.
+type PurchaseOrder struct {
+	ID            string              `json:"id"`
+	TenantID      string              `json:"tenantId"`
+	ServiceShopID string              `json:"serviceShopId"`
+	VendorID      string              `json:"vendorId"`
+	Status        PurchaseOrderStatus `json:"status"`
+	Currency      string              `json:"currency"`
+	TotalCents    int64               `json:"totalCents"`
+	Notes         string              `json:"notes,omitempty"`
+	CreatedBy     string              `json:"createdBy,omitempty"`
+	ApprovedBy    string              `json:"approvedBy,omitempty"`
+	ExpectedAt    *time.Time          `json:"expectedAt,omitempty"`
+	ApprovedAt    *time.Time          `json:"approvedAt,omitempty"`
+	SentAt        *time.Time          `json:"sentAt,omitempty"`
+	ClosedAt      *time.Time          `json:"closedAt,omitempty"`
+	CreatedAt     time.Time           `json:"createdAt"`
+	UpdatedAt     time.Time           `json:"updatedAt"`
+	Lines         []PurchaseOrderLine `json:"lines"`
+}
+
+type PurchaseOrderLine struct {
+	PartID         string `json:"partId"`
+	VendorSKUID    string `json:"vendorSkuId,omitempty"`
+	SKU            string `json:"sku,omitempty"`
+	QtyOrdered     int64  `json:"qtyOrdered"`
+	QtyReceived    int64  `json:"qtyReceived"`
+	UnitPriceCents int64  `json:"unitPriceCents"`
+}
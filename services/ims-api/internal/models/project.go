@@ -67,7 +67,8 @@ type ProjectTypeConfig struct {
 	DefaultPhase PhaseType   `json:"defaultPhase"`
 }
 
-// ProjectTypeConfigs contains the configuration for all project types.
+// ProjectTypeConfigs contains the built-in project types. Tenants can
+// define their own, or override these, as ProjectTemplates.
 var ProjectTypeConfigs = map[ProjectType]ProjectTypeConfig{
 	ProjectTypeFullInstallation: {
 		Type:         ProjectTypeFullInstallation,
@@ -117,7 +118,8 @@ func ValidProjectTypes() []ProjectType {
 	}
 }
 
-// IsValidPhaseForType checks if a phase is valid for a given project type.
+// IsValidPhaseForType checks if a phase is valid for a built-in project type.
+// Tenant-defined types are checked with ProjectTemplate.Phase.
 func IsValidPhaseForType(projectType ProjectType, phase PhaseType) bool {
 	config, ok := ProjectTypeConfigs[projectType]
 	if !ok {
@@ -153,6 +155,8 @@ type SchoolServiceProject struct {
 	GoLiveDate           string        `json:"goLiveDate"` // YYYY-MM-DD
 	AccountManagerUserID string        `json:"accountManagerUserId"`
 	Notes                string        `json:"notes"`
+	TemplateID           string        `json:"templateId,omitempty"` // project type version the project was created from; empty for built-in types
+	TemplateVersion      int           `json:"templateVersion"`
 	CreatedAt            time.Time     `json:"createdAt"`
 	UpdatedAt            time.Time     `json:"updatedAt"`
 }
@@ -169,6 +173,12 @@ type ServicePhase struct {
 	OwnerUserName           string      `json:"ownerUserName"`
 	StartDate               string      `json:"startDate"`
 	EndDate                 string      `json:"endDate"`
+	PlannedStart            string      `json:"plannedStart,omitempty"`
+	PlannedEnd              string      `json:"plannedEnd,omitempty"`
+	DurationDays            int         `json:"durationDays"`
+	ActualStart             string      `json:"actualStart,omitempty"` // set when the phase first moves to in_progress or done
+	ActualEnd               string      `json:"actualEnd,omitempty"`   // set when the phase moves to done
+	DependencyBlocked       bool        `json:"dependencyBlocked"`     // blocked automatically by incomplete predecessors
 	Notes                   string      `json:"notes"`
 	StatusChangedAt         *time.Time  `json:"statusChangedAt,omitempty"`
 	StatusChangedByUserID   string      `json:"statusChangedByUserId"`
@@ -189,3 +199,94 @@ type PhaseChecklistTemplate struct {
 	CreatedAt   time.Time   `json:"createdAt"`
 	UpdatedAt   time.Time   `json:"updatedAt"`
 }
+
+// PhaseChecklistItem is a checklist template copied onto a phase, or an
+// item added to the phase directly.
+type PhaseChecklistItem struct {
+	ID                   string     `json:"id"`
+	TenantID             string     `json:"tenantId"`
+	ProjectID            string     `json:"projectId"`
+	PhaseID              string     `json:"phaseId"`
+	TemplateID           string     `json:"templateId,omitempty"`
+	Title                string     `json:"title"`
+	Description          string     `json:"description"`
+	Required             bool       `json:"required"`
+	Position             int        `json:"position"`
+	CompletedAt          *time.Time `json:"completedAt,omitempty"`
+	CompletedByUserID    string     `json:"completedByUserId,omitempty"`
+	CompletedByUserName  string     `json:"completedByUserName,omitempty"`
+	EvidenceAttachmentID string     `json:"evidenceAttachmentId,omitempty"`
+	Notes                string     `json:"notes"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	UpdatedAt            time.Time  `json:"updatedAt"`
+}
+
+// Done reports whether the item has been completed.
+func (i PhaseChecklistItem) Done() bool { return i.CompletedAt != nil }
+
+// PhaseChecklistProgress summarizes a phase's checklist.
+type PhaseChecklistProgress struct {
+	PhaseID           string      `json:"phaseId"`
+	PhaseType         PhaseType   `json:"phaseType"`
+	Status            PhaseStatus `json:"status"`
+	Total             int         `json:"total"`
+	Completed         int         `json:"completed"`
+	RequiredTotal     int         `json:"requiredTotal"`
+	RequiredCompleted int         `json:"requiredCompleted"`
+	Percent           int         `json:"percent"`     // completed items, 0-100; 100 when there are none
+	CanComplete       bool        `json:"canComplete"` // all required items are done
+}
+
+// PhaseDependency is a finish-to-start link: the successor phase cannot
+// start until the predecessor is done, plus LagDays. The phases may belong
+// to different projects.
+type PhaseDependency struct {
+	ID                   string      `json:"id"`
+	TenantID             string      `json:"tenantId"`
+	PredecessorPhaseID   string      `json:"predecessorPhaseId"`
+	SuccessorPhaseID     string      `json:"successorPhaseId"`
+	LagDays              int         `json:"lagDays"`
+	CreatedBy            string      `json:"createdBy,omitempty"`
+	CreatedAt            time.Time   `json:"createdAt"`
+	PredecessorProjectID string      `json:"predecessorProjectId,omitempty"`
+	PredecessorPhaseType PhaseType   `json:"predecessorPhaseType,omitempty"`
+	PredecessorStatus    PhaseStatus `json:"predecessorStatus,omitempty"`
+	SuccessorProjectID   string      `json:"successorProjectId,omitempty"`
+}
+
+// ScheduledPhase is one bar of a project Gantt chart. Forecast dates start
+// from actual dates where known and otherwise from the later of the planned
+// start and the predecessors' forecast finish.
+type ScheduledPhase struct {
+	PhaseID           string      `json:"phaseId"`
+	ProjectID         string      `json:"projectId"`
+	PhaseType         PhaseType   `json:"phaseType"`
+	Status            PhaseStatus `json:"status"`
+	DependencyBlocked bool        `json:"dependencyBlocked"`
+	PlannedStart      string      `json:"plannedStart,omitempty"`
+	PlannedEnd        string      `json:"plannedEnd,omitempty"`
+	DurationDays      int         `json:"durationDays"`
+	ActualStart       string      `json:"actualStart,omitempty"`
+	ActualEnd         string      `json:"actualEnd,omitempty"`
+	ForecastStart     string      `json:"forecastStart"`
+	ForecastEnd       string      `json:"forecastEnd"`
+	SlipDays          int         `json:"slipDays"`       // forecast end minus planned end; positive is late
+	TotalFloatDays    int         `json:"totalFloatDays"` // days the phase can slip without delaying the project
+	Critical          bool        `json:"critical"`       // on the critical path
+	Predecessors      []string    `json:"predecessors"`   // phase IDs, possibly in other projects
+	External          bool        `json:"external,omitempty"`
+}
+
+// ProjectSchedule is a project's phases with forecast dates, slippage and
+// critical path, for a Gantt view.
+type ProjectSchedule struct {
+	ProjectID    string            `json:"projectId"`
+	AsOf         string            `json:"asOf"`
+	PlannedEnd   string            `json:"plannedEnd,omitempty"`
+	ForecastEnd  string            `json:"forecastEnd,omitempty"`
+	SlipDays     int               `json:"slipDays"`
+	CriticalPath []string          `json:"criticalPath"` // phase IDs in order
+	Phases       []ScheduledPhase  `json:"phases"`
+	External     []ScheduledPhase  `json:"external"` // predecessors in other projects
+	Dependencies []PhaseDependency `json:"dependencies"`
+}
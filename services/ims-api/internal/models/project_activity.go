@@ -91,11 +91,14 @@ type ProjectAttachment struct {
 type ProjectNotificationType string
 
 const (
-	ProjectNotificationAssignment   ProjectNotificationType = "assignment"
-	ProjectNotificationMention      ProjectNotificationType = "mention"
-	ProjectNotificationStatusChange ProjectNotificationType = "status_change"
-	ProjectNotificationComment      ProjectNotificationType = "comment"
-	ProjectNotificationWorkOrder    ProjectNotificationType = "work_order"
+	ProjectNotificationAssignment         ProjectNotificationType = "assignment"
+	ProjectNotificationMention            ProjectNotificationType = "mention"
+	ProjectNotificationStatusChange       ProjectNotificationType = "status_change"
+	ProjectNotificationComment            ProjectNotificationType = "comment"
+	ProjectNotificationWorkOrder          ProjectNotificationType = "work_order"
+	ProjectNotificationSLAWarning         ProjectNotificationType = "sla_warning"
+	ProjectNotificationSLABreach          ProjectNotificationType = "sla_breach"
+	ProjectNotificationDeviceRegistration ProjectNotificationType = "device_registration"
 )
 
 // UserNotification represents a notification for a user.
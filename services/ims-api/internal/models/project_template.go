@@ -0,0 +1,121 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ProjectTemplatePhase is one phase in a project type's sequence.
+type ProjectTemplatePhase struct {
+	PhaseType    PhaseType `json:"phaseType"`
+	Label        string    `json:"label"`
+	OwnerRole    string    `json:"ownerRole"`              // default owner role for new phases
+	DurationDays int       `json:"durationDays,omitempty"` // default planned duration
+}
+
+// ProjectTemplateChecklistItem is a checklist item copied onto phases of
+// PhaseType in projects created from the template.
+type ProjectTemplateChecklistItem struct {
+	PhaseType   PhaseType `json:"phaseType"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Required    bool      `json:"required"`
+}
+
+// ProjectTemplateBOQItem is a bill-of-quantities line added to new projects.
+type ProjectTemplateBOQItem struct {
+	Category           string `json:"category"`
+	Description        string `json:"description"`
+	PartID             string `json:"partId"`
+	Qty                int64  `json:"qty"`
+	Unit               string `json:"unit"`
+	EstimatedCostCents int64  `json:"estimatedCostCents"`
+}
+
+// ProjectTemplateTeamRole is a role a project of this type is staffed with.
+type ProjectTemplateTeamRole struct {
+	Role           TeamMemberRole `json:"role"`
+	Responsibility string         `json:"responsibility"`
+	Phases         []PhaseType    `json:"phases"`
+}
+
+// ProjectTemplate is one version of a project type: its phase sequence and
+// the checklists, BOQ lines and team roles new projects start with.
+// Versions are immutable; projects keep the version they were created from.
+// Built-in types from ProjectTypeConfigs have version 0 and no ID.
+type ProjectTemplate struct {
+	ID           string                         `json:"id,omitempty"`
+	TenantID     string                         `json:"tenantId,omitempty"`
+	ProjectType  ProjectType                    `json:"projectType"`
+	Version      int                            `json:"version"`
+	Label        string                         `json:"label"`
+	Description  string                         `json:"description"`
+	DefaultPhase PhaseType                      `json:"defaultPhase"`
+	Phases       []ProjectTemplatePhase         `json:"phases"`
+	Checklist    []ProjectTemplateChecklistItem `json:"checklist"`
+	BOQItems     []ProjectTemplateBOQItem       `json:"boqItems"`
+	TeamRoles    []ProjectTemplateTeamRole      `json:"teamRoles"`
+	Builtin      bool                           `json:"builtin"`
+	RetiredAt    *time.Time                     `json:"retiredAt,omitempty"`
+	CreatedBy    string                         `json:"createdBy,omitempty"`
+	CreatedAt    time.Time                      `json:"createdAt"`
+}
+
+// PhaseTypes returns the template's phases in order.
+func (t ProjectTemplate) PhaseTypes() []PhaseType {
+	out := make([]PhaseType, 0, len(t.Phases))
+	for _, p := range t.Phases {
+		out = append(out, p.PhaseType)
+	}
+	return out
+}
+
+// Phase returns the template's definition of a phase type.
+func (t ProjectTemplate) Phase(pt PhaseType) (ProjectTemplatePhase, bool) {
+	for _, p := range t.Phases {
+		if p.PhaseType == pt {
+			return p, true
+		}
+	}
+	return ProjectTemplatePhase{}, false
+}
+
+// Config returns the template in the shape of a built-in ProjectTypeConfig.
+func (t ProjectTemplate) Config() ProjectTypeConfig {
+	return ProjectTypeConfig{
+		Type:         t.ProjectType,
+		Label:        t.Label,
+		Description:  t.Description,
+		Phases:       t.PhaseTypes(),
+		DefaultPhase: t.DefaultPhase,
+	}
+}
+
+// BuiltinProjectTemplate returns a built-in project type as a template.
+// Checklists for built-in types come from the tenant's phase checklist
+// templates, so Checklist is empty.
+func BuiltinProjectTemplate(pt ProjectType) (ProjectTemplate, bool) {
+	c, ok := ProjectTypeConfigs[pt]
+	if !ok {
+		return ProjectTemplate{}, false
+	}
+	t := ProjectTemplate{
+		ProjectType:  c.Type,
+		Label:        c.Label,
+		Description:  c.Description,
+		DefaultPhase: c.DefaultPhase,
+		Phases:       make([]ProjectTemplatePhase, 0, len(c.Phases)),
+		Checklist:    []ProjectTemplateChecklistItem{},
+		BOQItems:     []ProjectTemplateBOQItem{},
+		TeamRoles:    []ProjectTemplateTeamRole{},
+		Builtin:      true,
+	}
+	for _, p := range c.Phases {
+		t.Phases = append(t.Phases, ProjectTemplatePhase{
+			PhaseType: p,
+			Label:     strings.ToUpper(string(p[:1])) + string(p[1:]),
+			OwnerRole: DefaultPhaseOwnerRole(p),
+		})
+	}
+	return t, true
+}
@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// BusinessHours is a single working window on a weekday, in the calendar's timezone.
+type BusinessHours struct {
+	Weekday time.Weekday `json:"weekday"` // 0 = Sunday
+	Start   string       `json:"start"`   // "08:00"
+	End     string       `json:"end"`     // "17:00"
+}
+
+// SLAHoliday is a public holiday on which the SLA clock does not run.
+type SLAHoliday struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenantId"`
+	CalendarID string    `json:"calendarId"`
+	Date       string    `json:"date"` // YYYY-MM-DD
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// SLACalendar defines when the SLA clock runs for a tenant.
+type SLACalendar struct {
+	ID        string          `json:"id"`
+	TenantID  string          `json:"tenantId"`
+	Name      string          `json:"name"`
+	Timezone  string          `json:"timezone"`
+	Hours     []BusinessHours `json:"hours"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+
+	// Joined fields
+	Holidays []SLAHoliday `json:"holidays,omitempty"`
+}
+
+// SLAPolicy defines response and resolution targets for incidents.
+// Empty Category, CountyID and SchoolLevel match any value.
+type SLAPolicy struct {
+	ID                string    `json:"id"`
+	TenantID          string    `json:"tenantId"`
+	Name              string    `json:"name"`
+	Severity          Severity  `json:"severity"`
+	Category          string    `json:"category,omitempty"`
+	CountyID          string    `json:"countyId,omitempty"`
+	SchoolLevel       string    `json:"schoolLevel,omitempty"`
+	ResponseMinutes   int       `json:"responseMinutes"`
+	ResolutionMinutes int       `json:"resolutionMinutes"`
+	CalendarID        *string   `json:"calendarId,omitempty"` // nil = 24x7
+	Priority          int       `json:"priority"`
+	Active            bool      `json:"active"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// Specificity returns how many optional match fields the policy constrains.
+func (p SLAPolicy) Specificity() int {
+	n := 0
+	if p.Category != "" {
+		n++
+	}
+	if p.CountyID != "" {
+		n++
+	}
+	if p.SchoolLevel != "" {
+		n++
+	}
+	return n
+}
+
+// SLAContext carries the incident attributes used to select an SLA policy.
+type SLAContext struct {
+	Severity    Severity
+	Category    string
+	CountyID    string
+	SchoolLevel string
+}
+
+// SLAEventType is the kind of entry on an incident's SLA timeline.
+type SLAEventType string
+
+const (
+	SLAEventPaused           SLAEventType = "paused"
+	SLAEventResumed          SLAEventType = "resumed"
+	SLAEventWarning          SLAEventType = "warning"
+	SLAEventBreached         SLAEventType = "breached"
+	SLAEventResponseBreached SLAEventType = "response_breached"
+)
+
+// IncidentSLAEvent is a single entry on an incident's SLA timeline.
+type IncidentSLAEvent struct {
+	ID           string       `json:"id"`
+	TenantID     string       `json:"tenantId"`
+	IncidentID   string       `json:"incidentId"`
+	EventType    SLAEventType `json:"eventType"`
+	ThresholdPct int          `json:"thresholdPct,omitempty"`
+	DueAt        *time.Time   `json:"dueAt,omitempty"`
+	ActorUserID  string       `json:"actorUserId,omitempty"`
+	Note         string       `json:"note,omitempty"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
+
+// IncidentSLATimeline is the SLA view of an incident returned by the API.
+type IncidentSLATimeline struct {
+	IncidentID       string             `json:"incidentId"`
+	Status           IncidentStatus     `json:"status"`
+	PolicyID         string             `json:"policyId,omitempty"`
+	OpenedAt         time.Time          `json:"openedAt"`
+	DueAt            time.Time          `json:"dueAt"`
+	ResponseDueAt    *time.Time         `json:"responseDueAt,omitempty"`
+	Breached         bool               `json:"breached"`
+	Paused           bool               `json:"paused"`
+	PausedAt         *time.Time         `json:"pausedAt,omitempty"`
+	BudgetSeconds    int64              `json:"budgetSeconds"`
+	RemainingSeconds int64              `json:"remainingSeconds"`
+	ElapsedPct       int                `json:"elapsedPct"`
+	Events           []IncidentSLAEvent `json:"events"`
+}
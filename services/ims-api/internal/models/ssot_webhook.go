@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SSOTWebhookSecret is a per-tenant key used to verify SSOT webhook
+// signatures. Secret is only returned when the key is created.
+type SSOTWebhookSecret struct {
+	ID        string     `json:"id"`
+	TenantID  string     `json:"tenantId"`
+	Label     string     `json:"label"`
+	Secret    string     `json:"secret,omitempty"`
+	CreatedBy string     `json:"createdBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// SSOTDeliveryStatus is the outcome of a webhook delivery.
+type SSOTDeliveryStatus string
+
+const (
+	SSOTDeliveryReceived   SSOTDeliveryStatus = "received" // persisted, items not yet applied
+	SSOTDeliveryApplied    SSOTDeliveryStatus = "applied"
+	SSOTDeliveryPartial    SSOTDeliveryStatus = "partial" // some items rejected
+	SSOTDeliveryRejected   SSOTDeliveryStatus = "rejected"
+	SSOTDeliveryRolledBack SSOTDeliveryStatus = "rolled_back"
+)
+
+// SSOTItemStatus is the outcome for one item in a delivery.
+type SSOTItemStatus string
+
+const (
+	SSOTItemApplied    SSOTItemStatus = "applied"
+	SSOTItemRejected   SSOTItemStatus = "rejected"
+	SSOTItemRolledBack SSOTItemStatus = "rolled_back"
+	SSOTItemConflict   SSOTItemStatus = "conflict" // changed after the delivery; left as is on rollback
+)
+
+// SSOTItemResult records what happened to one item. Previous is the snapshot
+// the item replaced (null if it was new) and is what rollback restores.
+type SSOTItemResult struct {
+	Index     int             `json:"index"`
+	ID        string          `json:"id,omitempty"`
+	Status    SSOTItemStatus  `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	UpdatedAt time.Time       `json:"updatedAt,omitempty"`
+	Previous  json.RawMessage `json:"previous,omitempty"`
+}
+
+// SSOTWebhookDelivery is a verified webhook request kept for audit and rollback.
+type SSOTWebhookDelivery struct {
+	ID           string             `json:"id"`
+	TenantID     string             `json:"tenantId"`
+	Resource     string             `json:"resource"`
+	Nonce        string             `json:"nonce"`
+	KeyID        string             `json:"keyId"`
+	SignedAt     time.Time          `json:"signedAt"`
+	Status       SSOTDeliveryStatus `json:"status"`
+	Payload      json.RawMessage    `json:"payload,omitempty"`
+	Results      []SSOTItemResult   `json:"results,omitempty"`
+	Accepted     int                `json:"accepted"`
+	Rejected     int                `json:"rejected"`
+	ReceivedAt   time.Time          `json:"receivedAt"`
+	RolledBackAt *time.Time         `json:"rolledBackAt,omitempty"`
+	RolledBackBy string             `json:"rolledBackBy,omitempty"`
+}
@@ -0,0 +1,171 @@
+package models
+
+import "time"
+
+// StockMovementType is the business event behind a ledger posting.
+type StockMovementType string
+
+const (
+	StockOpening        StockMovementType = "opening"         // balance carried over from the old inventory table
+	StockReceipt        StockMovementType = "receipt"         // goods received from a supplier
+	StockReservation    StockMovementType = "reservation"     // earmarked for a work order BOM
+	StockRelease        StockMovementType = "release"         // reservation returned to available
+	StockIssue          StockMovementType = "issue"           // reserved stock consumed by a work order
+	StockTransferOut    StockMovementType = "transfer_out"    // shipped to another shop
+	StockTransferIn     StockMovementType = "transfer_in"     // received from another shop
+	StockTransferLoss   StockMovementType = "transfer_loss"   // shipped but not received
+	StockTransferCancel StockMovementType = "transfer_cancel" // in-transit stock returned to the origin
+	StockAdjustment     StockMovementType = "adjustment"
+	StockCycleCount     StockMovementType = "cycle_count" // approved count variance
+)
+
+// StockAccount is a ledger account. available, reserved and in_transit hold
+// stock a shop owns; the others are the outside world each movement balances
+// against.
+type StockAccount string
+
+const (
+	AccountAvailable  StockAccount = "available"
+	AccountReserved   StockAccount = "reserved"
+	AccountInTransit  StockAccount = "in_transit" // keyed by the destination shop
+	AccountSupplier   StockAccount = "supplier"
+	AccountConsumed   StockAccount = "consumed"
+	AccountAdjustment StockAccount = "adjustment"
+	AccountOpening    StockAccount = "opening"
+)
+
+// StockAdjustmentReason is the reason code required on manual adjustments.
+type StockAdjustmentReason string
+
+const (
+	ReasonDamaged          StockAdjustmentReason = "damaged"
+	ReasonLost             StockAdjustmentReason = "lost"
+	ReasonExpired          StockAdjustmentReason = "expired"
+	ReasonWriteOff         StockAdjustmentReason = "write_off"
+	ReasonReturnToSupplier StockAdjustmentReason = "return_to_supplier"
+	ReasonFound            StockAdjustmentReason = "found"
+	ReasonCorrection       StockAdjustmentReason = "correction"
+)
+
+// Sign returns -1 if the reason only removes stock, 1 if it only adds stock
+// and 0 if it may do either. ok is false for unknown reasons.
+func (r StockAdjustmentReason) Sign() (sign int, ok bool) {
+	switch r {
+	case ReasonDamaged, ReasonLost, ReasonExpired, ReasonWriteOff, ReasonReturnToSupplier:
+		return -1, true
+	case ReasonFound:
+		return 1, true
+	case ReasonCorrection:
+		return 0, true
+	}
+	return 0, false
+}
+
+// StockMovement is one posting to the stock ledger for a single part.
+// Qty is positive except for adjustments and cycle counts, where the sign is
+// the change in available stock. CounterShopID is the other shop of a transfer.
+type StockMovement struct {
+	ID            string            `json:"id"`
+	TenantID      string            `json:"tenantId"`
+	Type          StockMovementType `json:"type"`
+	ServiceShopID string            `json:"serviceShopId"`
+	CounterShopID string            `json:"counterShopId,omitempty"`
+	PartID        string            `json:"partId"`
+	PartName      string            `json:"partName,omitempty"`
+	Qty           int64             `json:"qty"`
+	ReasonCode    string            `json:"reasonCode,omitempty"`
+	ReferenceType string            `json:"referenceType,omitempty"` // receipt, transfer, stock_count, work_order_part
+	ReferenceID   string            `json:"referenceId,omitempty"`
+	Notes         string            `json:"notes,omitempty"`
+	ActorID       string            `json:"actorId,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+
+	Entries []StockLedgerEntry `json:"entries,omitempty"`
+}
+
+// StockLedgerEntry is one side of a movement. The entries of a movement sum
+// to zero.
+type StockLedgerEntry struct {
+	ServiceShopID string       `json:"serviceShopId"`
+	PartID        string       `json:"partId"`
+	Account       StockAccount `json:"account"`
+	Qty           int64        `json:"qty"`
+}
+
+// StockBalance is a shop's position in a part, summed from the ledger.
+type StockBalance struct {
+	ServiceShopID string `json:"serviceShopId"`
+	PartID        string `json:"partId"`
+	OnHand        int64  `json:"onHand"` // available + reserved
+	Reserved      int64  `json:"reserved"`
+	Available     int64  `json:"available"`
+	InTransit     int64  `json:"inTransit"` // shipped to this shop, not yet received
+}
+
+// StockTransferStatus is the state of an inter-shop transfer.
+type StockTransferStatus string
+
+const (
+	TransferInTransit StockTransferStatus = "in_transit"
+	TransferReceived  StockTransferStatus = "received"
+	TransferCancelled StockTransferStatus = "cancelled"
+)
+
+// StockTransfer moves parts between service shops. Stock leaves the origin
+// when the transfer is created and reaches the destination when received.
+type StockTransfer struct {
+	ID          string              `json:"id"`
+	TenantID    string              `json:"tenantId"`
+	FromShopID  string              `json:"fromShopId"`
+	ToShopID    string              `json:"toShopId"`
+	Status      StockTransferStatus `json:"status"`
+	Notes       string              `json:"notes,omitempty"`
+	CreatedBy   string              `json:"createdBy,omitempty"`
+	ReceivedBy  string              `json:"receivedBy,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	ReceivedAt  *time.Time          `json:"receivedAt,omitempty"`
+	CancelledAt *time.Time          `json:"cancelledAt,omitempty"`
+	Lines       []StockTransferLine `json:"lines"`
+}
+
+type StockTransferLine struct {
+	PartID      string `json:"partId"`
+	QtyShipped  int64  `json:"qtyShipped"`
+	QtyReceived int64  `json:"qtyReceived"`
+}
+
+// StockCountStatus is the state of a cycle count.
+type StockCountStatus string
+
+const (
+	CountOpen      StockCountStatus = "open"
+	CountSubmitted StockCountStatus = "submitted"
+	CountApproved  StockCountStatus = "approved"
+	CountRejected  StockCountStatus = "rejected"
+)
+
+// StockCount is a cycle count of some or all parts in a shop. Variances are
+// only posted to the ledger once approved.
+type StockCount struct {
+	ID            string           `json:"id"`
+	TenantID      string           `json:"tenantId"`
+	ServiceShopID string           `json:"serviceShopId"`
+	Status        StockCountStatus `json:"status"`
+	Notes         string           `json:"notes,omitempty"`
+	CreatedBy     string           `json:"createdBy,omitempty"`
+	SubmittedBy   string           `json:"submittedBy,omitempty"`
+	DecidedBy     string           `json:"decidedBy,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	SubmittedAt   *time.Time       `json:"submittedAt,omitempty"`
+	DecidedAt     *time.Time       `json:"decidedAt,omitempty"`
+	Lines         []StockCountLine `json:"lines"`
+}
+
+// StockCountLine compares the counted quantity with on-hand stock. Expected
+// is taken when the count is submitted.
+type StockCountLine struct {
+	PartID      string `json:"partId"`
+	QtyExpected int64  `json:"qtyExpected"`
+	QtyCounted  *int64 `json:"qtyCounted,omitempty"` // nil until counted
+	Variance    int64  `json:"variance"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// TelemetryOutcome is what happened to an ingested telemetry event.
+type TelemetryOutcome string
+
+const (
+	TelemetryOpened     TelemetryOutcome = "opened"         // opened a new incident
+	TelemetryCorrelated TelemetryOutcome = "correlated"     // counted against the open incident
+	TelemetryDuplicate  TelemetryOutcome = "duplicate"      // repeated within the dedupe window
+	TelemetryResolved   TelemetryOutcome = "resolved"       // recovery that resolved the open incident
+	TelemetryUnmatched  TelemetryOutcome = "unmatched"      // recovery with nothing open
+	TelemetryIgnored    TelemetryOutcome = "ignored"        // stored only; the rule creates no incident or does not auto-resolve
+	TelemetryUnknown    TelemetryOutcome = "unknown_device" // sent by a MAC address that matches no device
+)
+
+// TelemetryRuleWildcard is the event type of a tenant's fallback rule.
+const TelemetryRuleWildcard = "*"
+
+// TelemetryRule maps a telemetry event type to incident handling.
+type TelemetryRule struct {
+	ID        string   `json:"id"`
+	TenantID  string   `json:"tenantId"`
+	EventType string   `json:"eventType"`
+	Severity  Severity `json:"severity"`
+	// DedupeWindowSeconds overrides TELEMETRY_DEDUPE_WINDOW_SECONDS.
+	DedupeWindowSeconds *int `json:"dedupeWindowSeconds,omitempty"`
+	// RecoveryEventType is the event type that recovers this one, such as
+	// "online" for "offline". Events with state "recovered" always do.
+	RecoveryEventType string    `json:"recoveryEventType,omitempty"`
+	AutoResolve       bool      `json:"autoResolve"`
+	CreateIncident    bool      `json:"createIncident"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// TelemetryEvent is a raw MDM event as received, with what it did.
+type TelemetryEvent struct {
+	ID            string           `json:"id"`
+	TenantID      string           `json:"tenantId"`
+	SchoolID      string           `json:"schoolId"`
+	DeviceID      string           `json:"deviceId"`
+	MACAddress    string           `json:"macAddress,omitempty"`
+	EventType     string           `json:"eventType"`
+	Recovery      bool             `json:"recovery"`
+	Severity      string           `json:"severity"`
+	Message       string           `json:"message"`
+	ReportedBy    string           `json:"reportedBy"`
+	ExternalID    string           `json:"externalId,omitempty"`
+	Attributes    map[string]any   `json:"attributes,omitempty"`
+	Outcome       TelemetryOutcome `json:"outcome"`
+	CorrelationID string           `json:"correlationId,omitempty"`
+	IncidentID    string           `json:"incidentId,omitempty"`
+	OccurredAt    time.Time        `json:"occurredAt"`
+	ReceivedAt    time.Time        `json:"receivedAt"`
+}
+
+// TelemetryCorrelation groups a device's events of one type into one
+// incident until the problem recovers.
+type TelemetryCorrelation struct {
+	ID              string     `json:"id"`
+	TenantID        string     `json:"tenantId"`
+	DeviceID        string     `json:"deviceId"`
+	EventType       string     `json:"eventType"`
+	IncidentID      string     `json:"incidentId"`
+	Occurrences     int        `json:"occurrences"`
+	Duplicates      int        `json:"duplicates"`
+	FirstOccurredAt time.Time  `json:"firstOccurredAt"`
+	LastOccurredAt  time.Time  `json:"lastOccurredAt"`
+	ResolvedAt      *time.Time `json:"resolvedAt,omitempty"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// WorkflowEntity is the kind of record a workflow governs.
+type WorkflowEntity string
+
+const (
+	WorkflowIncident  WorkflowEntity = "incident"
+	WorkflowWorkOrder WorkflowEntity = "work_order"
+)
+
+// WorkflowGuard is a named condition checked before a transition is allowed.
+type WorkflowGuard string
+
+const (
+	GuardDeliverablesApproved WorkflowGuard = "all_deliverables_approved" // work orders: every deliverable approved
+	GuardApprovalGranted      WorkflowGuard = "approval_granted"          // work orders: approval approved or not required
+	GuardAssigned             WorkflowGuard = "assigned"                  // work orders: shop or staff assigned
+)
+
+// WorkflowState is a status in a workflow.
+type WorkflowState struct {
+	Key       string `json:"key"`
+	Label     string `json:"label"`
+	Initial   bool   `json:"initial,omitempty"`
+	Terminal  bool   `json:"terminal,omitempty"`
+	PausesSLA bool   `json:"pausesSla,omitempty"` // incidents: SLA clock stops in this state
+}
+
+// WorkflowTransition is an allowed move between two states. Empty Roles and
+// Permissions allow anyone who may update the record.
+type WorkflowTransition struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Label       string          `json:"label,omitempty"`
+	Roles       []string        `json:"roles,omitempty"`       // any of
+	Permissions []string        `json:"permissions,omitempty"` // all of
+	Guards      []WorkflowGuard `json:"guards,omitempty"`      // all of
+	Bulk        *bool           `json:"bulk,omitempty"`        // nil = allowed in bulk updates
+}
+
+// AllowsBulk reports whether the transition may run from a bulk update.
+func (t WorkflowTransition) AllowsBulk() bool {
+	return t.Bulk == nil || *t.Bulk
+}
+
+// Workflow is a tenant's state machine for incidents or work orders.
+type Workflow struct {
+	ID          string               `json:"id,omitempty"`
+	TenantID    string               `json:"tenantId"`
+	Entity      WorkflowEntity       `json:"entity"`
+	Name        string               `json:"name"`
+	Version     int                  `json:"version"`
+	States      []WorkflowState      `json:"states"`
+	Transitions []WorkflowTransition `json:"transitions"`
+	IsDefault   bool                 `json:"isDefault"` // built-in workflow, no tenant override
+	UpdatedBy   string               `json:"updatedBy,omitempty"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt"`
+}
+
+// State returns the state with the given key.
+func (w Workflow) State(key string) (WorkflowState, bool) {
+	for _, s := range w.States {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return WorkflowState{}, false
+}
+
+// Transition returns the transition between two states.
+func (w Workflow) Transition(from, to string) (WorkflowTransition, bool) {
+	for _, t := range w.Transitions {
+		if t.From == from && t.To == to {
+			return t, true
+		}
+	}
+	return WorkflowTransition{}, false
+}
+
+// Next returns the transitions leaving a state.
+func (w Workflow) Next(from string) []WorkflowTransition {
+	out := []WorkflowTransition{}
+	for _, t := range w.Transitions {
+		if t.From == from {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -285,7 +285,7 @@ func GenerateSeedData(tenantID string) *SeedData {
 			Model:     dm.Make + " " + dm.Model,
 			Serial:    "SN" + string(rune('A'+i)) + "2024" + padInt(i+1, 4),
 			AssetTag:  "ASSET-" + padInt(i+1, 5),
-			Status:    "active",
+			Status:    "deployed",
 			UpdatedAt: now,
 		})
 	}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -93,6 +96,7 @@ func (s *Seeder) Clean(ctx context.Context) error {
 
 	// Tables to clean in reverse dependency order
 	tables := []string{
+		"field_sync_mutations",
 		"service_phases",
 		"school_service_projects",
 		"work_order_deliverables",
@@ -101,6 +105,12 @@ func (s *Seeder) Clean(ctx context.Context) error {
 		"work_orders",
 		"incidents",
 		"school_contacts",
+		"purchase_orders",
+		"reorder_policies",
+		"stock_counts",
+		"stock_transfers",
+		"stock_ledger_entries",
+		"stock_movements",
 		"inventory",
 		"service_staff",
 		"service_shops",
@@ -253,19 +263,27 @@ func (s *Seeder) seedDevices(ctx context.Context, data *SeedData) error {
 func (s *Seeder) seedInventory(ctx context.Context, data *SeedData) error {
 	s.log("  Seeding %d inventory items...", len(data.Inventory))
 
-	query := `
-		INSERT INTO inventory
-		(id, tenant_id, service_shop_id, part_id, qty_available, qty_reserved, reorder_threshold, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (id) DO NOTHING
-	`
-
+	// Stock goes through the ledger as opening balances so that inventory
+	// and stock_balances agree.
 	for _, item := range data.Inventory {
-		_, err := s.pool.Exec(ctx, query,
-			item.ID, item.TenantID, item.ServiceShopID, item.PartID,
-			item.QtyAvailable, item.QtyReserved, item.ReorderThreshold,
-			item.UpdatedAt,
-		)
+		err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			if _, err := store.PostStockMovementTx(ctx, tx, models.StockMovement{
+				TenantID:      item.TenantID,
+				Type:          models.StockOpening,
+				ServiceShopID: item.ServiceShopID,
+				PartID:        item.PartID,
+				Qty:           item.QtyAvailable,
+				Notes:         "seed",
+				CreatedAt:     item.UpdatedAt,
+			}); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `
+				UPDATE inventory SET reorder_threshold = $4
+				WHERE tenant_id = $1 AND service_shop_id = $2 AND part_id = $3
+			`, item.TenantID, item.ServiceShopID, item.PartID, item.ReorderThreshold)
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("failed to seed inventory: %w", err)
 		}
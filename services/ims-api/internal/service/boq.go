@@ -0,0 +1,60 @@
+package service
+
+import (
+	"github.com/edvirons/ssp/ims/internal/lookups"
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// RollupBOQCost prices BOQ lines and totals them by category, in order of
+// first appearance. A line whose part has vendor SKUs is priced at the
+// cheapest SKU times its quantity; any other line is costed at its
+// estimate, which covers the whole line. The summary currency is that of the
+// first vendor price used.
+func RollupBOQCost(items []models.BOQItem, skusByPart map[string][]lookups.VendorSKU) models.BOQCostSummary {
+	sum := models.BOQCostSummary{
+		Categories: []models.BOQCategoryCost{},
+		Lines:      make([]models.BOQLineCost, 0, len(items)),
+	}
+	catIndex := map[string]int{}
+	for _, it := range items {
+		line := models.BOQLineCost{
+			ItemID:         it.ID,
+			Category:       it.Category,
+			PartID:         it.PartID,
+			Qty:            it.Qty,
+			EstimatedCents: it.EstimatedCostCents,
+			CostCents:      it.EstimatedCostCents,
+			Source:         "estimate",
+		}
+		if sku, ok := PickVendorSKU(skusByPart[it.PartID], models.VendorCheapest); it.PartID != "" && ok {
+			line.VendorID = sku.VendorID
+			line.VendorSKUID = sku.ID
+			line.UnitPriceCents = sku.UnitPriceCents
+			line.CostCents = sku.UnitPriceCents * it.Qty
+			line.Source = "vendor"
+			switch {
+			case sum.Currency == "":
+				sum.Currency = sku.Currency
+			case sku.Currency != "" && sku.Currency != sum.Currency:
+				sum.MixedCurrency = true
+			}
+		} else {
+			sum.UnpricedLines++
+		}
+		sum.Lines = append(sum.Lines, line)
+
+		i, ok := catIndex[it.Category]
+		if !ok {
+			i = len(sum.Categories)
+			catIndex[it.Category] = i
+			sum.Categories = append(sum.Categories, models.BOQCategoryCost{Category: it.Category})
+		}
+		c := &sum.Categories[i]
+		c.Lines++
+		c.EstimatedCents += line.EstimatedCents
+		c.CostCents += line.CostCents
+		sum.EstimatedCents += line.EstimatedCents
+		sum.CostCents += line.CostCents
+	}
+	return sum
+}
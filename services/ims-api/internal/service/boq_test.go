@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/edvirons/ssp/ims/internal/lookups"
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestRollupBOQCost(t *testing.T) {
+	items := []models.BOQItem{
+		{ID: "b1", Category: "switches", PartID: "sw24", Qty: 2, EstimatedCostCents: 50000},
+		{ID: "b2", Category: "cabling", Description: "Cat6 run", Qty: 40, EstimatedCostCents: 20000},
+		{ID: "b3", Category: "switches", PartID: "sfp", Qty: 4, EstimatedCostCents: 8000},
+		{ID: "b4", Category: "labor", Qty: 1, EstimatedCostCents: 30000},
+	}
+	skus := map[string][]lookups.VendorSKU{
+		"sw24": {
+			{ID: "v1", VendorID: "acme", UnitPriceCents: 30000, Currency: "KES"},
+			{ID: "v2", VendorID: "cheap", UnitPriceCents: 22000, Currency: "KES"},
+		},
+		"sfp": {{ID: "v3", VendorID: "acme", UnitPriceCents: 2500, Currency: "KES"}},
+	}
+
+	got := RollupBOQCost(items, skus)
+	if got.Currency != "KES" || got.MixedCurrency {
+		t.Errorf("currency = %q mixed=%v", got.Currency, got.MixedCurrency)
+	}
+	if got.EstimatedCents != 108000 || got.CostCents != 44000+20000+10000+30000 {
+		t.Errorf("totals estimated=%d cost=%d", got.EstimatedCents, got.CostCents)
+	}
+	if got.UnpricedLines != 2 {
+		t.Errorf("unpriced = %d", got.UnpricedLines)
+	}
+	if len(got.Categories) != 3 || got.Categories[0].Category != "switches" {
+		t.Fatalf("categories = %+v", got.Categories)
+	}
+	if sw := got.Categories[0]; sw.Lines != 2 || sw.CostCents != 54000 || sw.EstimatedCents != 58000 {
+		t.Errorf("switches = %+v", sw)
+	}
+	if l := got.Lines[0]; l.Source != "vendor" || l.VendorSKUID != "v2" || l.UnitPriceCents != 22000 {
+		t.Errorf("line 0 = %+v", l)
+	}
+	if l := got.Lines[1]; l.Source != "estimate" || l.CostCents != 20000 {
+		t.Errorf("line 1 = %+v", l)
+	}
+
+	skus["sfp"][0].Currency = "USD"
+	if !RollupBOQCost(items, skus).MixedCurrency {
+		t.Error("expected mixed currency")
+	}
+}
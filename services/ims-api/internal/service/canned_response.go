@@ -0,0 +1,66 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CannedResponseVariables are the {{variables}} a canned response may use,
+// filled from the chat session and the school and device snapshots.
+var CannedResponseVariables = []string{
+	"agent.name",
+	"contact.name",
+	"school.name",
+	"school.code",
+	"school.county",
+	"school.sub_county",
+	"device.serial",
+	"device.asset_tag",
+	"device.make",
+	"device.model",
+	"issue.category",
+	"incident.id",
+}
+
+var cannedVariablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_.]+)\s*\}\}`)
+
+// RenderCannedResponse replaces {{variable}} placeholders in content with
+// values from vars. Placeholders with no value are left in place, so the
+// agent sees what to fill in, and are returned sorted in missing.
+func RenderCannedResponse(content string, vars map[string]string) (rendered string, missing []string) {
+	seen := map[string]bool{}
+	rendered = cannedVariablePattern.ReplaceAllStringFunc(content, func(m string) string {
+		name := strings.ToLower(cannedVariablePattern.FindStringSubmatch(m)[1])
+		if v := strings.TrimSpace(vars[name]); v != "" {
+			return v
+		}
+		if !seen[name] {
+			seen[name] = true
+			missing = append(missing, name)
+		}
+		return m
+	})
+	sort.Strings(missing)
+	return rendered, missing
+}
+
+// UnknownCannedVariables lists placeholders in content that are not in
+// CannedResponseVariables, sorted and without duplicates.
+func UnknownCannedVariables(content string) []string {
+	known := map[string]bool{}
+	for _, v := range CannedResponseVariables {
+		known[v] = true
+	}
+	seen := map[string]bool{}
+	var unknown []string
+	for _, m := range cannedVariablePattern.FindAllStringSubmatch(content, -1) {
+		name := strings.ToLower(m[1])
+		if !known[name] && !seen[name] {
+			seen[name] = true
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderCannedResponse(t *testing.T) {
+	content := "Hi {{contact.name}}, {{ Agent.Name }} here. Is {{device.serial}} at {{school.name}}? Serial again: {{device.serial}}"
+	got, missing := RenderCannedResponse(content, map[string]string{
+		"contact.name": "Jane",
+		"agent.name":   "Wanjiru Kamau",
+		"school.name":  "Kibera Primary",
+		"device.model": "unused",
+	})
+	want := "Hi Jane, Wanjiru Kamau here. Is {{device.serial}} at Kibera Primary? Serial again: {{device.serial}}"
+	if got != want {
+		t.Errorf("rendered = %q", got)
+	}
+	if strings.Join(missing, ",") != "device.serial" {
+		t.Errorf("missing = %v", missing)
+	}
+
+	if got, missing := RenderCannedResponse("No variables {here}", nil); got != "No variables {here}" || missing != nil {
+		t.Errorf("plain = %q, %v", got, missing)
+	}
+}
+
+func TestUnknownCannedVariables(t *testing.T) {
+	got := UnknownCannedVariables("{{school.name}} {{school.principal}} {{ticket}} {{School.Principal}}")
+	if strings.Join(got, ",") != "school.principal,ticket" {
+		t.Errorf("unknown = %v", got)
+	}
+	if got := UnknownCannedVariables("{{incident.id}}"); len(got) != 0 {
+		t.Errorf("unknown = %v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// maxChatIncidentTitle bounds the title taken from the user's description.
+const maxChatIncidentTitle = 80
+
+// ChatIncidentDraft is what an AI support chat learned about a fault, in
+// the shape of an incident.
+type ChatIncidentDraft struct {
+	Category     string
+	Severity     models.Severity
+	Title        string
+	Description  string
+	DeviceSerial string
+	NeedsRepair  bool
+}
+
+// DraftChatIncident builds an incident from the category, severity and
+// collected_info the assistant recorded on a chat session. Unknown
+// severities become medium and a missing category becomes "other".
+// reason, when set, is why the chat was escalated.
+func DraftChatIncident(sessionID string, category, severity *string, collected map[string]any, reason string) ChatIncidentDraft {
+	d := ChatIncidentDraft{Category: "other", Severity: models.SeverityMedium}
+	if category != nil && strings.TrimSpace(*category) != "" {
+		d.Category = strings.ToLower(strings.TrimSpace(*category))
+	}
+	if severity != nil {
+		if sev, ok := ParseSeverity(*severity); ok {
+			d.Severity = sev
+		}
+	}
+	d.DeviceSerial = collectedString(collected, "device_serial")
+	d.NeedsRepair = collectedBool(collected, "needs_repair")
+
+	issue := collectedString(collected, "issue_description")
+	if issue == "" {
+		d.Title = "Support chat: " + d.Category + " issue"
+	} else {
+		d.Title = truncateWords(firstLine(issue), maxChatIncidentTitle)
+	}
+
+	var b strings.Builder
+	if issue != "" {
+		b.WriteString(issue)
+		b.WriteString("\n\n")
+	}
+	for _, f := range []struct{ key, label string }{
+		{"when_started", "Started"},
+		{"steps_tried", "Steps tried"},
+		{"device_serial", "Device serial"},
+	} {
+		if v := collectedString(collected, f.key); v != "" {
+			fmt.Fprintf(&b, "%s: %s\n", f.label, v)
+		}
+	}
+	if d.NeedsRepair {
+		b.WriteString("The assistant assessed that the device needs physical repair.\n")
+	}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		fmt.Fprintf(&b, "Escalation reason: %s\n", reason)
+	}
+	fmt.Fprintf(&b, "Reported through support chat session %s.", sessionID)
+	d.Description = b.String()
+	return d
+}
+
+// IsChatRepairFault reports whether the assistant diagnosed a hardware
+// fault that needs a technician, which is filed without waiting for the
+// chat to escalate.
+func IsChatRepairFault(category *string, collected map[string]any) bool {
+	return category != nil && strings.EqualFold(strings.TrimSpace(*category), "hardware") &&
+		collectedBool(collected, "needs_repair")
+}
+
+func collectedString(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// collectedBool reads a flag the model may have written as a boolean or a
+// string.
+func collectedBool(m map[string]any, key string) bool {
+	switch v := m[key].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(strings.TrimSpace(v), "true") || strings.EqualFold(strings.TrimSpace(v), "yes")
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+// truncateWords cuts s to at most n runes, at a word boundary when one is
+// reasonably close.
+func truncateWords(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	cut := string(runes[:n])
+	if i := strings.LastIndexByte(cut, ' '); i > n/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestDraftChatIncident(t *testing.T) {
+	cat, sev := "Hardware", "HIGH"
+	collected := map[string]any{
+		"device_serial":     " SN123 ",
+		"issue_description": "Screen stays black after the laptop was dropped in the lab this morning\nfans still spin",
+		"when_started":      "this morning",
+		"needs_repair":      true,
+	}
+	d := DraftChatIncident("chs_1", &cat, &sev, collected, "needs physical diagnosis")
+	if d.Category != "hardware" || d.Severity != models.SeverityHigh || d.DeviceSerial != "SN123" || !d.NeedsRepair {
+		t.Fatalf("draft = %+v", d)
+	}
+	if len([]rune(d.Title)) > maxChatIncidentTitle+1 || !strings.HasPrefix(d.Title, "Screen stays black") || strings.Contains(d.Title, "fans") {
+		t.Errorf("title = %q", d.Title)
+	}
+	for _, want := range []string{"Started: this morning", "Device serial: SN123", "Escalation reason: needs physical diagnosis", "session chs_1"} {
+		if !strings.Contains(d.Description, want) {
+			t.Errorf("description missing %q:\n%s", want, d.Description)
+		}
+	}
+}
+
+func TestDraftChatIncidentDefaults(t *testing.T) {
+	sev := "urgent"
+	d := DraftChatIncident("chs_2", nil, &sev, nil, "")
+	if d.Category != "other" || d.Severity != models.SeverityMedium || d.Title != "Support chat: other issue" || d.NeedsRepair {
+		t.Errorf("draft = %+v", d)
+	}
+}
+
+func TestIsChatRepairFault(t *testing.T) {
+	hw, sw := "hardware", "software"
+	if !IsChatRepairFault(&hw, map[string]any{"needs_repair": "yes"}) {
+		t.Error("hardware fault needing repair not detected")
+	}
+	if IsChatRepairFault(&hw, map[string]any{"needs_repair": false}) || IsChatRepairFault(&sw, map[string]any{"needs_repair": true}) || IsChatRepairFault(nil, nil) {
+		t.Error("non-repair fault detected")
+	}
+}
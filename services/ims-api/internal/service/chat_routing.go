@@ -0,0 +1,211 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// Routing score weights, out of 100. A skill match outweighs everything
+// else; spare capacity, familiarity with the school and idle time order
+// agents with the same skills.
+const (
+	routeWeightSkill    = 40.0
+	routeWeightLoad     = 30.0
+	routeWeightAffinity = 20.0
+	routeWeightIdle     = 10.0
+)
+
+const (
+	// routeAffinityCap is the number of prior sessions with a school that
+	// earns the full affinity score.
+	routeAffinityCap = 5
+	// routeIdleCap is the time since an agent's last chat that earns the
+	// full idle score. Agents never given a chat count as fully idle.
+	routeIdleCap = time.Hour
+)
+
+// ChatRouteRequest is a waiting session and the agents it could go to.
+type ChatRouteRequest struct {
+	Category string        // session issue category; empty when the assistant did not classify it
+	Waiting  time.Duration // time the session has been queued
+	// Agents are the agents marked available, including those at capacity.
+	Agents []models.AgentAvailability
+	// SchoolSessions counts each agent's recent sessions with the session's school.
+	SchoolSessions map[string]int
+	Settings       models.ChatRoutingSettings
+	Now            time.Time
+}
+
+// ChatRoute is where RouteChat sends a session.
+type ChatRoute struct {
+	Outcome    models.ChatRouteOutcome
+	AgentID    string
+	Score      float64
+	Reason     string
+	Candidates []models.ChatRouteCandidate // eligible agents first, best first
+}
+
+// RouteChat scores every available agent for a waiting session and picks
+// the best eligible one. An agent is eligible while under their
+// MaxConcurrentChats and, when the tenant requires it, tagged with the
+// session's category. Once the session has waited OverflowAfterSeconds the
+// skill requirement is dropped and agents may take OverflowExtraChats over
+// their maximum; a zero or negative OverflowAfterSeconds disables overflow.
+// With no eligible agent the session stays queued.
+func RouteChat(req ChatRouteRequest) ChatRoute {
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	overflow := req.Settings.OverflowAfterSeconds > 0 &&
+		req.Waiting >= time.Duration(req.Settings.OverflowAfterSeconds)*time.Second
+
+	type scored struct {
+		c        models.ChatRouteCandidate
+		skilled  bool
+		relaxed  bool // eligible only under overflow rules
+		spare    int
+		maxChats int
+		current  int
+		idle     time.Duration
+	}
+	var all []scored
+	var skillExcluded, capacityExcluded int
+	for _, a := range req.Agents {
+		if !a.IsAvailable {
+			continue
+		}
+		s := scored{
+			c:        models.ChatRouteCandidate{AgentID: a.UserID, SchoolSessions: req.SchoolSessions[a.UserID]},
+			maxChats: a.MaxConcurrentChats,
+			current:  a.CurrentChatCount,
+			spare:    a.MaxConcurrentChats - a.CurrentChatCount,
+			idle:     routeIdleCap,
+		}
+		if category != "" && hasSkill(a.Skills, category) {
+			s.skilled = true
+			s.c.Skill = routeWeightSkill
+		}
+		if a.MaxConcurrentChats > 0 {
+			free := 1 - float64(a.CurrentChatCount)/float64(a.MaxConcurrentChats)
+			s.c.Load = roundScore(routeWeightLoad * math.Max(0, math.Min(1, free)))
+		}
+		s.c.Affinity = roundScore(routeWeightAffinity * float64(min(s.c.SchoolSessions, routeAffinityCap)) / routeAffinityCap)
+		if a.LastAssignedAt != nil {
+			s.idle = min(max(req.Now.Sub(*a.LastAssignedAt), 0), routeIdleCap)
+		}
+		s.c.Idle = roundScore(routeWeightIdle * float64(s.idle) / float64(routeIdleCap))
+		s.c.Score = roundScore(s.c.Skill + s.c.Load + s.c.Affinity + s.c.Idle)
+
+		s.c.Eligible = true
+		switch {
+		case a.CurrentChatCount < a.MaxConcurrentChats:
+		case overflow && a.CurrentChatCount < a.MaxConcurrentChats+req.Settings.OverflowExtraChats:
+			s.relaxed = true
+		default:
+			s.c.Eligible = false
+			s.c.Excluded = "at capacity"
+			capacityExcluded++
+		}
+		if s.c.Eligible && req.Settings.RequireSkillMatch && category != "" && !s.skilled {
+			if overflow {
+				s.relaxed = true
+			} else {
+				s.c.Eligible = false
+				s.c.Excluded = "no skill match"
+				skillExcluded++
+			}
+		}
+		all = append(all, s)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.c.Eligible != b.c.Eligible {
+			return a.c.Eligible
+		}
+		if a.c.Score != b.c.Score {
+			return a.c.Score > b.c.Score
+		}
+		if a.spare != b.spare {
+			return a.spare > b.spare
+		}
+		return a.c.AgentID < b.c.AgentID
+	})
+
+	route := ChatRoute{Outcome: models.ChatRouteQueued, Candidates: make([]models.ChatRouteCandidate, 0, len(all))}
+	for _, s := range all {
+		route.Candidates = append(route.Candidates, s.c)
+	}
+
+	if len(all) == 0 || !all[0].c.Eligible {
+		switch {
+		case len(all) == 0:
+			route.Reason = "no agents available"
+		case skillExcluded > 0 && req.Settings.OverflowAfterSeconds > 0:
+			route.Reason = fmt.Sprintf("no available agent skilled in %s; overflow after %ds", category, req.Settings.OverflowAfterSeconds)
+		case skillExcluded > 0:
+			route.Reason = fmt.Sprintf("no available agent skilled in %s", category)
+		default:
+			route.Reason = "all agents at capacity"
+		}
+		return route
+	}
+
+	best := all[0]
+	route.AgentID = best.c.AgentID
+	route.Score = best.c.Score
+	route.Outcome = models.ChatRouteAssigned
+	if best.relaxed {
+		route.Outcome = models.ChatRouteOverflow
+	}
+
+	var parts []string
+	if best.relaxed {
+		parts = append(parts, fmt.Sprintf("overflow after %s wait", req.Waiting.Truncate(time.Second)))
+	}
+	switch {
+	case best.skilled:
+		parts = append(parts, "skill match "+category)
+	case category != "":
+		parts = append(parts, "no skill match "+category)
+	}
+	parts = append(parts, fmt.Sprintf("load %d/%d", best.current, best.maxChats))
+	if best.c.SchoolSessions > 0 {
+		parts = append(parts, fmt.Sprintf("%d prior sessions with school", best.c.SchoolSessions))
+	}
+	parts = append(parts, fmt.Sprintf("idle %s", best.idle.Truncate(time.Minute)))
+	route.Reason = strings.Join(parts, "; ")
+	return route
+}
+
+func hasSkill(skills []string, category string) bool {
+	for _, s := range skills {
+		if strings.EqualFold(strings.TrimSpace(s), category) {
+			return true
+		}
+	}
+	return false
+}
+
+func roundScore(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// NormalizeSkills trims, lower-cases and de-duplicates skill tags, keeping
+// their order.
+func NormalizeSkills(skills []string) []string {
+	out := make([]string, 0, len(skills))
+	seen := map[string]bool{}
+	for _, s := range skills {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
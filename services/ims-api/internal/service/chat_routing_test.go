@@ -0,0 +1,105 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func routeAgent(id string, current, maxChats int, skills ...string) models.AgentAvailability {
+	return models.AgentAvailability{UserID: id, IsAvailable: true, CurrentChatCount: current, MaxConcurrentChats: maxChats, Skills: skills}
+}
+
+func TestRouteChatScoring(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	recent := now.Add(-6 * time.Minute)
+	busy := routeAgent("busy-network", 2, 3, "network")
+	busy.LastAssignedAt = &recent
+	route := RouteChat(ChatRouteRequest{
+		Category: "Hardware",
+		Agents: []models.AgentAvailability{
+			busy,
+			routeAgent("idle-network", 0, 3, "network"),
+			routeAgent("skilled", 2, 3, "HARDWARE"),
+			{UserID: "away", IsAvailable: false, MaxConcurrentChats: 3, Skills: []string{"hardware"}},
+		},
+		SchoolSessions: map[string]int{"skilled": 1},
+		Settings:       models.DefaultChatRoutingSettings("t1"),
+		Now:            now,
+	})
+	if route.Outcome != models.ChatRouteAssigned || route.AgentID != "skilled" {
+		t.Fatalf("route = %+v", route)
+	}
+	// 40 skill + 10 load + 4 affinity + 10 idle
+	if route.Score != 64 || len(route.Candidates) != 3 {
+		t.Errorf("score = %v, candidates = %+v", route.Score, route.Candidates)
+	}
+	if route.Candidates[1].AgentID != "idle-network" || route.Candidates[2].Idle != 1 {
+		t.Errorf("candidates = %+v", route.Candidates)
+	}
+	if !strings.Contains(route.Reason, "skill match hardware") || !strings.Contains(route.Reason, "load 2/3") {
+		t.Errorf("reason = %q", route.Reason)
+	}
+}
+
+func TestRouteChatAffinityBreaksTies(t *testing.T) {
+	route := RouteChat(ChatRouteRequest{
+		Category:       "software",
+		Agents:         []models.AgentAvailability{routeAgent("a", 1, 2), routeAgent("b", 1, 2)},
+		SchoolSessions: map[string]int{"b": 9},
+		Settings:       models.DefaultChatRoutingSettings("t1"),
+		Now:            time.Now(),
+	})
+	if route.AgentID != "b" || route.Candidates[0].Affinity != routeWeightAffinity {
+		t.Errorf("route = %+v", route)
+	}
+}
+
+func TestRouteChatOverflow(t *testing.T) {
+	settings := models.ChatRoutingSettings{RequireSkillMatch: true, OverflowAfterSeconds: 120, OverflowExtraChats: 1}
+	agents := []models.AgentAvailability{routeAgent("full-skilled", 2, 2, "network"), routeAgent("generalist", 0, 2)}
+
+	route := RouteChat(ChatRouteRequest{Category: "network", Waiting: 30 * time.Second, Agents: agents, Settings: settings, Now: time.Now()})
+	if route.Outcome != models.ChatRouteQueued || route.AgentID != "" || !strings.Contains(route.Reason, "overflow after 120s") {
+		t.Fatalf("before overflow = %+v", route)
+	}
+	for _, c := range route.Candidates {
+		if c.Eligible || c.Excluded == "" {
+			t.Errorf("candidate %+v should be excluded", c)
+		}
+	}
+
+	// Past the overflow wait the skilled agent may go one over their max and
+	// still outscores the generalist.
+	route = RouteChat(ChatRouteRequest{Category: "network", Waiting: 3 * time.Minute, Agents: agents, Settings: settings, Now: time.Now()})
+	if route.Outcome != models.ChatRouteOverflow || route.AgentID != "full-skilled" || !strings.HasPrefix(route.Reason, "overflow after 3m0s wait") {
+		t.Errorf("after overflow = %+v", route)
+	}
+
+	// Without overflow, an unskilled agent under capacity is still used.
+	settings.RequireSkillMatch = false
+	route = RouteChat(ChatRouteRequest{Category: "network", Agents: agents, Settings: settings, Now: time.Now()})
+	if route.Outcome != models.ChatRouteAssigned || route.AgentID != "generalist" {
+		t.Errorf("no skill requirement = %+v", route)
+	}
+}
+
+func TestRouteChatQueued(t *testing.T) {
+	if route := RouteChat(ChatRouteRequest{Settings: models.DefaultChatRoutingSettings("t1")}); route.Outcome != models.ChatRouteQueued || route.Reason != "no agents available" {
+		t.Errorf("no agents = %+v", route)
+	}
+	settings := models.ChatRoutingSettings{OverflowAfterSeconds: 0, OverflowExtraChats: 5}
+	route := RouteChat(ChatRouteRequest{Waiting: time.Hour, Agents: []models.AgentAvailability{routeAgent("a", 3, 3)}, Settings: settings})
+	if route.Outcome != models.ChatRouteQueued || route.Reason != "all agents at capacity" {
+		t.Errorf("overflow disabled = %+v", route)
+	}
+}
+
+func TestNormalizeSkills(t *testing.T) {
+	got := NormalizeSkills([]string{" Network", "hardware", "", "NETWORK"})
+	if strings.Join(got, ",") != "network,hardware" {
+		t.Errorf("skills = %v", got)
+	}
+}
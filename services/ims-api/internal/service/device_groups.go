@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// ValidateGroup checks that a group carries what its type needs: a location
+// for location groups and at least one selector criterion for dynamic ones.
+func ValidateGroup(g models.DeviceGroup) error {
+	switch g.GroupType {
+	case models.GroupTypeManual:
+		return nil
+	case models.GroupTypeLocation:
+		if g.LocationID == nil || strings.TrimSpace(*g.LocationID) == "" {
+			return errors.New("locationId required for location groups")
+		}
+		return nil
+	case models.GroupTypeDynamic:
+		if g.Selector == nil || g.Selector.IsEmpty() {
+			return errors.New("selector with at least one criterion required for dynamic groups")
+		}
+		return nil
+	}
+	return errors.New("groupType must be manual, location or dynamic")
+}
+
+// MatchesGroup reports whether a device belongs in an automatic group. A
+// school-scoped group only ever matches devices of that school. Location
+// groups and location selectors match devices anywhere inside the location.
+func MatchesGroup(g models.DeviceGroup, c models.GroupCandidate) bool {
+	if g.SchoolID != nil && *g.SchoolID != "" && c.SchoolID != *g.SchoolID {
+		return false
+	}
+	switch g.GroupType {
+	case models.GroupTypeLocation:
+		return g.LocationID != nil && inPath(c.LocationPath, *g.LocationID)
+	case models.GroupTypeDynamic:
+		if g.Selector == nil || g.Selector.IsEmpty() {
+			return false
+		}
+		return matchesSelector(*g.Selector, c)
+	}
+	return false
+}
+
+func matchesSelector(s models.GroupSelector, c models.GroupCandidate) bool {
+	if s.SchoolID != "" && c.SchoolID != s.SchoolID {
+		return false
+	}
+	if s.Model != "" && !strings.Contains(strings.ToLower(c.Model), strings.ToLower(strings.TrimSpace(s.Model))) {
+		return false
+	}
+	if len(s.Lifecycle) > 0 {
+		ok := false
+		for _, l := range s.Lifecycle {
+			if strings.EqualFold(strings.TrimSpace(l), c.Lifecycle) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if s.LocationType != "" && !strings.EqualFold(s.LocationType, c.LocationType) {
+		return false
+	}
+	if s.LocationID != "" && !inPath(c.LocationPath, s.LocationID) {
+		return false
+	}
+	return true
+}
+
+func inPath(path []string, locationID string) bool {
+	for _, id := range path {
+		if id == locationID {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveGroupMembers returns the candidates that belong in the group.
+func ResolveGroupMembers(g models.DeviceGroup, candidates []models.GroupCandidate) []models.GroupCandidate {
+	out := []models.GroupCandidate{}
+	for _, c := range candidates {
+		if MatchesGroup(g, c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestValidateGroup(t *testing.T) {
+	loc := "loc1"
+	empty := ""
+	tests := []struct {
+		name    string
+		g       models.DeviceGroup
+		wantErr bool
+	}{
+		{"manual", models.DeviceGroup{GroupType: models.GroupTypeManual}, false},
+		{"location", models.DeviceGroup{GroupType: models.GroupTypeLocation, LocationID: &loc}, false},
+		{"location without id", models.DeviceGroup{GroupType: models.GroupTypeLocation, LocationID: &empty}, true},
+		{"dynamic", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Model: "Chromebook"}}, false},
+		{"dynamic with empty selector", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{}}, true},
+		{"unknown type", models.DeviceGroup{GroupType: "smart"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateGroup(tt.g); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateGroup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchesGroup(t *testing.T) {
+	school, other := "sch1", "sch2"
+	block, lab := "block-a", "lab-101"
+	inLab := models.GroupCandidate{DeviceID: "d1", SchoolID: school, Model: "HP Chromebook 11", Lifecycle: "active",
+		LocationID: lab, LocationType: "lab", LocationPath: []string{lab, block}}
+	unassigned := models.GroupCandidate{DeviceID: "d2", SchoolID: school, Model: "Dell Latitude", Lifecycle: "in_repair"}
+
+	tests := []struct {
+		name string
+		g    models.DeviceGroup
+		c    models.GroupCandidate
+		want bool
+	}{
+		{"location group matches sub-location", models.DeviceGroup{GroupType: models.GroupTypeLocation, LocationID: &block}, inLab, true},
+		{"location group skips unassigned", models.DeviceGroup{GroupType: models.GroupTypeLocation, LocationID: &block}, unassigned, false},
+		{"model contains, case-insensitive", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Model: "chromebook"}}, inLab, true},
+		{"lifecycle any of", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Lifecycle: []string{"retired", "in_repair"}}}, unassigned, true},
+		{"lifecycle mismatch", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Lifecycle: []string{"retired"}}}, inLab, false},
+		{"location type", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{LocationType: "lab"}}, inLab, true},
+		{"selector location includes children", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{LocationID: block}}, inLab, true},
+		{"all criteria must match", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Model: "Chromebook", LocationType: "office"}}, inLab, false},
+		{"selector school", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{SchoolID: other}}, inLab, false},
+		{"group school scope", models.DeviceGroup{SchoolID: &other, GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Model: "Chromebook"}}, inLab, false},
+		{"empty selector never matches", models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{}}, inLab, false},
+		{"manual groups are not evaluated", models.DeviceGroup{GroupType: models.GroupTypeManual}, inLab, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesGroup(tt.g, tt.c); got != tt.want {
+				t.Errorf("MatchesGroup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+var policyDays = map[string]bool{"mon": true, "tue": true, "wed": true, "thu": true, "fri": true, "sat": true, "sun": true}
+
+// NormalizeDevicePolicy validates a group policy and returns it in canonical
+// form: current schema version, trimmed and de-duplicated lists, exam
+// windows in start order and empty sections dropped.
+func NormalizeDevicePolicy(p models.DevicePolicy) (models.DevicePolicy, error) {
+	switch p.SchemaVersion {
+	case 0:
+		p.SchemaVersion = models.DevicePolicySchemaVersion
+	case models.DevicePolicySchemaVersion:
+	default:
+		return p, fmt.Errorf("unsupported schemaVersion %d", p.SchemaVersion)
+	}
+
+	if p.ExamMode != nil {
+		e := *p.ExamMode
+		if len(e.Windows) == 0 {
+			return p, errors.New("examMode requires at least one window")
+		}
+		e.Windows = append([]models.ExamWindow(nil), e.Windows...)
+		for i, w := range e.Windows {
+			if w.Start.IsZero() || !w.End.After(w.Start) {
+				return p, fmt.Errorf("examMode window %d: end must be after start", i)
+			}
+			e.Windows[i].Name = strings.TrimSpace(w.Name)
+		}
+		sortExamWindows(e.Windows)
+		e.AllowedApps = normalizeIDs(e.AllowedApps)
+		p.ExamMode = &e
+	}
+
+	if p.Apps != nil {
+		a := models.AppPolicy{Allow: normalizeIDs(p.Apps.Allow), Block: normalizeIDs(p.Apps.Block)}
+		for _, id := range a.Allow {
+			if containsID(a.Block, id) {
+				return p, fmt.Errorf("app %q is both allowed and blocked", id)
+			}
+		}
+		p.Apps = &a
+		if len(a.Allow) == 0 && len(a.Block) == 0 {
+			p.Apps = nil
+		}
+	}
+
+	if len(p.WiFi) > 0 {
+		seen := map[string]bool{}
+		wifi := make([]models.WiFiProfile, 0, len(p.WiFi))
+		for _, w := range p.WiFi {
+			w.SSID = strings.TrimSpace(w.SSID)
+			if w.SSID == "" || len(w.SSID) > 32 {
+				return p, errors.New("wifi ssid must be 1-32 bytes")
+			}
+			if seen[w.SSID] {
+				return p, fmt.Errorf("wifi ssid %q listed twice", w.SSID)
+			}
+			seen[w.SSID] = true
+			switch w.Security {
+			case models.WiFiWPA2, models.WiFiWPA3:
+				if len(w.Passphrase) < 8 || len(w.Passphrase) > 63 {
+					return p, fmt.Errorf("wifi %q: passphrase must be 8-63 characters", w.SSID)
+				}
+			case models.WiFiOpen, models.WiFiWPA2Enterprise:
+				if w.Passphrase != "" {
+					return p, fmt.Errorf("wifi %q: passphrase only applies to wpa2 and wpa3", w.SSID)
+				}
+			default:
+				return p, fmt.Errorf("wifi %q: security must be open, wpa2, wpa3 or wpa2-enterprise", w.SSID)
+			}
+			wifi = append(wifi, w)
+		}
+		p.WiFi = wifi
+	}
+
+	if p.ScreenTime != nil {
+		st := models.ScreenTimePolicy{DailyLimitMinutes: p.ScreenTime.DailyLimitMinutes}
+		if st.DailyLimitMinutes < 0 || st.DailyLimitMinutes > 24*60 {
+			return p, errors.New("screenTime dailyLimitMinutes must be between 0 and 1440")
+		}
+		for i, d := range p.ScreenTime.Downtime {
+			var days []string
+			for _, day := range d.Days {
+				days = append(days, strings.ToLower(day))
+			}
+			days = normalizeIDs(days)
+			if len(days) == 0 {
+				return p, fmt.Errorf("screenTime downtime %d: days required", i)
+			}
+			for _, day := range days {
+				if !policyDays[day] {
+					return p, fmt.Errorf("screenTime downtime %d: unknown day %q", i, day)
+				}
+			}
+			start, err1 := time.Parse("15:04", d.Start)
+			end, err2 := time.Parse("15:04", d.End)
+			if err1 != nil || err2 != nil {
+				return p, fmt.Errorf("screenTime downtime %d: start and end must be HH:MM", i)
+			}
+			if start.Equal(end) {
+				return p, fmt.Errorf("screenTime downtime %d: start and end must differ", i)
+			}
+			st.Downtime = append(st.Downtime, models.DowntimeWindow{Days: days, Start: d.Start, End: d.End})
+		}
+		p.ScreenTime = &st
+		if st.DailyLimitMinutes == 0 && len(st.Downtime) == 0 {
+			p.ScreenTime = nil
+		}
+	}
+	return p, nil
+}
+
+// PolicyScopeOf returns how widely a group applies. Location groups and
+// dynamic groups selecting a location are the narrowest, then groups of one
+// school, then tenant-wide groups.
+func PolicyScopeOf(g models.DeviceGroup) models.PolicyScope {
+	if g.GroupType == models.GroupTypeLocation || (g.GroupType == models.GroupTypeDynamic && g.Selector != nil && g.Selector.LocationID != "") {
+		return models.PolicyScopeLocation
+	}
+	if g.SchoolID != nil && *g.SchoolID != "" {
+		return models.PolicyScopeSchool
+	}
+	return models.PolicyScopeTenant
+}
+
+var scopeRank = map[models.PolicyScope]int{models.PolicyScopeTenant: 0, models.PolicyScopeSchool: 1, models.PolicyScopeLocation: 2}
+
+// ResolveEffectivePolicy merges the policies of the groups a device belongs
+// to. Groups are ordered by precedence: narrower scope first, then higher
+// priority, then group ID so the result is stable.
+//
+// Restrictions combine so the most restrictive wins: blocked apps are the
+// union of all block lists and beat any allow, the smallest daily limit
+// applies, and downtime and exam windows add up. Settings that can only
+// have one value — a Wi-Fi profile per SSID and the exam allowed apps —
+// come from the highest-precedence group that sets them. Exam windows that
+// ended before now are dropped. Every disagreement is reported as a
+// conflict.
+func ResolveEffectivePolicy(deviceID string, groups []models.DeviceGroup, now time.Time) models.EffectivePolicy {
+	applied := make([]models.DeviceGroup, 0, len(groups))
+	for _, g := range groups {
+		if g.Active && g.Policies != nil && !g.Policies.IsEmpty() {
+			applied = append(applied, g)
+		}
+	}
+	sort.SliceStable(applied, func(i, j int) bool {
+		a, b := applied[i], applied[j]
+		if ra, rb := scopeRank[PolicyScopeOf(a)], scopeRank[PolicyScopeOf(b)]; ra != rb {
+			return ra < rb
+		}
+		if a.Policies.Priority != b.Policies.Priority {
+			return a.Policies.Priority < b.Policies.Priority
+		}
+		return a.ID < b.ID
+	})
+
+	out := models.EffectivePolicy{
+		DeviceID: deviceID,
+		Policy:   models.DevicePolicy{SchemaVersion: models.DevicePolicySchemaVersion},
+		Sources:  []models.PolicySource{},
+	}
+	for _, g := range applied {
+		out.Sources = append(out.Sources, models.PolicySource{
+			GroupID:       g.ID,
+			GroupName:     g.Name,
+			Scope:         PolicyScopeOf(g),
+			Priority:      g.Policies.Priority,
+			PolicyVersion: g.PolicyVersion,
+		})
+	}
+
+	mergeApps(&out, applied)
+	mergeExamMode(&out, applied, now)
+	mergeWiFi(&out, applied)
+	mergeScreenTime(&out, applied)
+	out.Hash = PolicyHash(out.Policy)
+	return out
+}
+
+func mergeApps(out *models.EffectivePolicy, groups []models.DeviceGroup) {
+	allowedBy, blockedBy := map[string][]string{}, map[string][]string{}
+	for _, g := range groups {
+		if g.Policies.Apps == nil {
+			continue
+		}
+		for _, id := range g.Policies.Apps.Allow {
+			allowedBy[id] = append(allowedBy[id], g.ID)
+		}
+		for _, id := range g.Policies.Apps.Block {
+			blockedBy[id] = append(blockedBy[id], g.ID)
+		}
+	}
+	if len(allowedBy) == 0 && len(blockedBy) == 0 {
+		return
+	}
+	apps := &models.AppPolicy{}
+	for id := range blockedBy {
+		apps.Block = append(apps.Block, id)
+	}
+	sort.Strings(apps.Block)
+	for id := range allowedBy {
+		if blockedBy[id] == nil {
+			apps.Allow = append(apps.Allow, id)
+		}
+	}
+	sort.Strings(apps.Allow)
+	for _, id := range apps.Block {
+		if allowedBy[id] == nil {
+			continue
+		}
+		blockers := blockedBy[id]
+		out.Conflicts = append(out.Conflicts, models.PolicyConflict{
+			Field:      "apps",
+			Key:        id,
+			Resolution: "block_wins",
+			GroupIDs:   appendUnique(append([]string(nil), allowedBy[id]...), blockers...),
+			Winner:     blockers[len(blockers)-1],
+		})
+	}
+	out.Policy.Apps = apps
+}
+
+func mergeExamMode(out *models.EffectivePolicy, groups []models.DeviceGroup, now time.Time) {
+	var exam *models.ExamModePolicy
+	var defining []string
+	var winner models.DeviceGroup
+	for _, g := range groups {
+		e := g.Policies.ExamMode
+		if e == nil {
+			continue
+		}
+		if exam == nil {
+			exam = &models.ExamModePolicy{}
+		}
+		for _, w := range e.Windows {
+			if w.End.After(now) && !containsWindow(exam.Windows, w) {
+				exam.Windows = append(exam.Windows, w)
+			}
+		}
+		exam.BlockInternet = exam.BlockInternet || e.BlockInternet
+		if len(e.AllowedApps) > 0 {
+			defining = append(defining, g.ID)
+			winner = g
+		}
+	}
+	if exam == nil || len(exam.Windows) == 0 {
+		return
+	}
+	sortExamWindows(exam.Windows)
+	if len(defining) > 0 {
+		exam.AllowedApps = winner.Policies.ExamMode.AllowedApps
+		if differingAllowedApps(groups, defining) {
+			out.Conflicts = append(out.Conflicts, models.PolicyConflict{
+				Field:      "examMode.allowedApps",
+				Resolution: "precedence",
+				GroupIDs:   defining,
+				Winner:     winner.ID,
+			})
+		}
+	}
+	out.Policy.ExamMode = exam
+}
+
+func differingAllowedApps(groups []models.DeviceGroup, ids []string) bool {
+	var first []string
+	for _, g := range groups {
+		if !containsID(ids, g.ID) {
+			continue
+		}
+		apps := g.Policies.ExamMode.AllowedApps
+		if first == nil {
+			first = apps
+		} else if strings.Join(first, "\n") != strings.Join(apps, "\n") {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeWiFi(out *models.EffectivePolicy, groups []models.DeviceGroup) {
+	profiles := map[string]models.WiFiProfile{}
+	definedBy := map[string][]string{}
+	differs := map[string]bool{}
+	for _, g := range groups {
+		for _, w := range g.Policies.WiFi {
+			if prev, ok := profiles[w.SSID]; ok && prev != w {
+				differs[w.SSID] = true
+			}
+			profiles[w.SSID] = w
+			definedBy[w.SSID] = append(definedBy[w.SSID], g.ID)
+		}
+	}
+	ssids := make([]string, 0, len(profiles))
+	for ssid := range profiles {
+		ssids = append(ssids, ssid)
+	}
+	sort.Strings(ssids)
+	for _, ssid := range ssids {
+		out.Policy.WiFi = append(out.Policy.WiFi, profiles[ssid])
+		if differs[ssid] {
+			by := definedBy[ssid]
+			out.Conflicts = append(out.Conflicts, models.PolicyConflict{
+				Field:      "wifi",
+				Key:        ssid,
+				Resolution: "precedence",
+				GroupIDs:   by,
+				Winner:     by[len(by)-1],
+			})
+		}
+	}
+}
+
+func mergeScreenTime(out *models.EffectivePolicy, groups []models.DeviceGroup) {
+	var st *models.ScreenTimePolicy
+	var limitedBy []string
+	winner := ""
+	differs := false
+	for _, g := range groups {
+		s := g.Policies.ScreenTime
+		if s == nil {
+			continue
+		}
+		if st == nil {
+			st = &models.ScreenTimePolicy{}
+		}
+		if s.DailyLimitMinutes > 0 {
+			if st.DailyLimitMinutes > 0 && s.DailyLimitMinutes != st.DailyLimitMinutes {
+				differs = true
+			}
+			limitedBy = append(limitedBy, g.ID)
+			if st.DailyLimitMinutes == 0 || s.DailyLimitMinutes <= st.DailyLimitMinutes {
+				st.DailyLimitMinutes = s.DailyLimitMinutes
+				winner = g.ID
+			}
+		}
+		for _, d := range s.Downtime {
+			if !containsDowntime(st.Downtime, d) {
+				st.Downtime = append(st.Downtime, d)
+			}
+		}
+	}
+	if st == nil {
+		return
+	}
+	if differs {
+		out.Conflicts = append(out.Conflicts, models.PolicyConflict{
+			Field:      "screenTime.dailyLimitMinutes",
+			Resolution: "most_restrictive",
+			GroupIDs:   limitedBy,
+			Winner:     winner,
+		})
+	}
+	out.Policy.ScreenTime = st
+}
+
+// PolicyHash returns a hex SHA-256 of the policy's JSON encoding. Policies
+// produced by ResolveEffectivePolicy are in canonical order, so equal
+// policies hash equally.
+func PolicyHash(p models.DevicePolicy) string {
+	b, _ := json.Marshal(p)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeIDs(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !containsID(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func sortExamWindows(ws []models.ExamWindow) {
+	sort.SliceStable(ws, func(i, j int) bool {
+		if !ws[i].Start.Equal(ws[j].Start) {
+			return ws[i].Start.Before(ws[j].Start)
+		}
+		return ws[i].End.Before(ws[j].End)
+	})
+}
+
+func containsWindow(ws []models.ExamWindow, w models.ExamWindow) bool {
+	for _, x := range ws {
+		if x.Start.Equal(w.Start) && x.End.Equal(w.End) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDowntime(ds []models.DowntimeWindow, d models.DowntimeWindow) bool {
+	for _, x := range ds {
+		if x.Start == d.Start && x.End == d.End && strings.Join(x.Days, ",") == strings.Join(d.Days, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, s := range items {
+		if !containsID(list, s) {
+			list = append(list, s)
+		}
+	}
+	return list
+}
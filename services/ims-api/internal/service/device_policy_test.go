@@ -0,0 +1,177 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestNormalizeDevicePolicy(t *testing.T) {
+	start := time.Date(2026, 11, 2, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		p       models.DevicePolicy
+		wantErr bool
+	}{
+		{"empty", models.DevicePolicy{}, false},
+		{"future schema", models.DevicePolicy{SchemaVersion: 99}, true},
+		{"exam without windows", models.DevicePolicy{ExamMode: &models.ExamModePolicy{}}, true},
+		{"exam window ends before start", models.DevicePolicy{ExamMode: &models.ExamModePolicy{
+			Windows: []models.ExamWindow{{Start: start, End: start.Add(-time.Hour)}}}}, true},
+		{"app allowed and blocked", models.DevicePolicy{Apps: &models.AppPolicy{Allow: []string{"a"}, Block: []string{" a "}}}, true},
+		{"wpa2 short passphrase", models.DevicePolicy{WiFi: []models.WiFiProfile{{SSID: "school", Security: models.WiFiWPA2, Passphrase: "short"}}}, true},
+		{"open with passphrase", models.DevicePolicy{WiFi: []models.WiFiProfile{{SSID: "guest", Security: models.WiFiOpen, Passphrase: "secret123"}}}, true},
+		{"duplicate ssid", models.DevicePolicy{WiFi: []models.WiFiProfile{
+			{SSID: "guest", Security: models.WiFiOpen}, {SSID: "guest", Security: models.WiFiOpen}}}, true},
+		{"unknown security", models.DevicePolicy{WiFi: []models.WiFiProfile{{SSID: "x", Security: "wep"}}}, true},
+		{"daily limit too large", models.DevicePolicy{ScreenTime: &models.ScreenTimePolicy{DailyLimitMinutes: 2000}}, true},
+		{"downtime bad day", models.DevicePolicy{ScreenTime: &models.ScreenTimePolicy{
+			Downtime: []models.DowntimeWindow{{Days: []string{"funday"}, Start: "21:00", End: "06:00"}}}}, true},
+		{"downtime bad time", models.DevicePolicy{ScreenTime: &models.ScreenTimePolicy{
+			Downtime: []models.DowntimeWindow{{Days: []string{"mon"}, Start: "9pm", End: "06:00"}}}}, true},
+		{"valid", models.DevicePolicy{
+			ExamMode:   &models.ExamModePolicy{Windows: []models.ExamWindow{{Start: start, End: start.Add(2 * time.Hour)}}},
+			Apps:       &models.AppPolicy{Block: []string{"com.game"}},
+			WiFi:       []models.WiFiProfile{{SSID: "school", Security: models.WiFiWPA2, Passphrase: "correct horse"}},
+			ScreenTime: &models.ScreenTimePolicy{Downtime: []models.DowntimeWindow{{Days: []string{"Mon", "mon"}, Start: "21:00", End: "06:00"}}},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NormalizeDevicePolicy(tt.p); (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeDevicePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, err := NormalizeDevicePolicy(models.DevicePolicy{
+		Apps:       &models.AppPolicy{Allow: []string{" ", ""}},
+		ScreenTime: &models.ScreenTimePolicy{Downtime: []models.DowntimeWindow{{Days: []string{"Mon", "mon"}, Start: "21:00", End: "06:00"}}},
+	})
+	if err != nil {
+		t.Fatalf("NormalizeDevicePolicy() error = %v", err)
+	}
+	if got.SchemaVersion != models.DevicePolicySchemaVersion {
+		t.Errorf("SchemaVersion = %d", got.SchemaVersion)
+	}
+	if got.Apps != nil {
+		t.Errorf("Apps = %+v, want dropped", got.Apps)
+	}
+	if days := got.ScreenTime.Downtime[0].Days; !reflect.DeepEqual(days, []string{"mon"}) {
+		t.Errorf("Days = %v", days)
+	}
+}
+
+func TestPolicyScopeOf(t *testing.T) {
+	school, loc := "sch1", "lab"
+	tests := []struct {
+		g    models.DeviceGroup
+		want models.PolicyScope
+	}{
+		{models.DeviceGroup{GroupType: models.GroupTypeManual}, models.PolicyScopeTenant},
+		{models.DeviceGroup{GroupType: models.GroupTypeManual, SchoolID: &school}, models.PolicyScopeSchool},
+		{models.DeviceGroup{GroupType: models.GroupTypeLocation, SchoolID: &school, LocationID: &loc}, models.PolicyScopeLocation},
+		{models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{LocationID: loc}}, models.PolicyScopeLocation},
+		{models.DeviceGroup{GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Model: "HP"}}, models.PolicyScopeTenant},
+	}
+	for _, tt := range tests {
+		if got := PolicyScopeOf(tt.g); got != tt.want {
+			t.Errorf("PolicyScopeOf(%+v) = %s, want %s", tt.g, got, tt.want)
+		}
+	}
+}
+
+func TestResolveEffectivePolicy(t *testing.T) {
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	school, loc := "sch1", "lab"
+	past := models.ExamWindow{Start: now.Add(-48 * time.Hour), End: now.Add(-46 * time.Hour)}
+	upcoming := models.ExamWindow{Name: "Maths", Start: now.Add(24 * time.Hour), End: now.Add(26 * time.Hour)}
+
+	tenantWide := models.DeviceGroup{ID: "g-tenant", Name: "All devices", GroupType: models.GroupTypeManual, Active: true, PolicyVersion: 3,
+		Policies: &models.DevicePolicy{
+			Priority:   100,
+			Apps:       &models.AppPolicy{Allow: []string{"com.browser", "com.game"}},
+			WiFi:       []models.WiFiProfile{{SSID: "edu", Security: models.WiFiWPA2, Passphrase: "tenant-pass"}},
+			ScreenTime: &models.ScreenTimePolicy{DailyLimitMinutes: 240},
+		}}
+	schoolGroup := models.DeviceGroup{ID: "g-school", Name: "Sch1", GroupType: models.GroupTypeManual, SchoolID: &school, Active: true, PolicyVersion: 1,
+		Policies: &models.DevicePolicy{
+			Apps:       &models.AppPolicy{Block: []string{"com.game"}},
+			ExamMode:   &models.ExamModePolicy{Windows: []models.ExamWindow{past, upcoming}, AllowedApps: []string{"com.exam"}},
+			ScreenTime: &models.ScreenTimePolicy{DailyLimitMinutes: 120, Downtime: []models.DowntimeWindow{{Days: []string{"mon"}, Start: "21:00", End: "06:00"}}},
+		}}
+	labGroup := models.DeviceGroup{ID: "g-lab", Name: "Lab", GroupType: models.GroupTypeLocation, SchoolID: &school, LocationID: &loc, Active: true, PolicyVersion: 2,
+		Policies: &models.DevicePolicy{
+			WiFi:       []models.WiFiProfile{{SSID: "edu", Security: models.WiFiWPA3, Passphrase: "lab-password"}},
+			ExamMode:   &models.ExamModePolicy{Windows: []models.ExamWindow{upcoming}, AllowedApps: []string{"com.exam", "com.calc"}, BlockInternet: true},
+			ScreenTime: &models.ScreenTimePolicy{Downtime: []models.DowntimeWindow{{Days: []string{"mon"}, Start: "21:00", End: "06:00"}}},
+		}}
+	noPolicy := models.DeviceGroup{ID: "g-none", GroupType: models.GroupTypeManual, Active: true}
+	inactive := models.DeviceGroup{ID: "g-off", GroupType: models.GroupTypeManual, Active: false,
+		Policies: &models.DevicePolicy{Apps: &models.AppPolicy{Block: []string{"com.browser"}}}}
+
+	got := ResolveEffectivePolicy("dev1", []models.DeviceGroup{labGroup, noPolicy, tenantWide, inactive, schoolGroup}, now)
+
+	var order []string
+	for _, s := range got.Sources {
+		order = append(order, s.GroupID)
+	}
+	if want := []string{"g-tenant", "g-school", "g-lab"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("source order = %v, want %v", order, want)
+	}
+
+	p := got.Policy
+	if !reflect.DeepEqual(p.Apps.Block, []string{"com.game"}) || !reflect.DeepEqual(p.Apps.Allow, []string{"com.browser"}) {
+		t.Errorf("apps = %+v", p.Apps)
+	}
+	if len(p.WiFi) != 1 || p.WiFi[0].Security != models.WiFiWPA3 {
+		t.Errorf("wifi = %+v, want lab profile", p.WiFi)
+	}
+	if p.ScreenTime.DailyLimitMinutes != 120 || len(p.ScreenTime.Downtime) != 1 {
+		t.Errorf("screenTime = %+v", p.ScreenTime)
+	}
+	if p.ExamMode == nil || !reflect.DeepEqual(p.ExamMode.Windows, []models.ExamWindow{upcoming}) {
+		t.Fatalf("examMode = %+v", p.ExamMode)
+	}
+	if !p.ExamMode.BlockInternet || !reflect.DeepEqual(p.ExamMode.AllowedApps, []string{"com.exam", "com.calc"}) {
+		t.Errorf("examMode = %+v", p.ExamMode)
+	}
+
+	conflicts := map[string]models.PolicyConflict{}
+	for _, c := range got.Conflicts {
+		conflicts[c.Field+"/"+c.Key] = c
+	}
+	if c := conflicts["apps/com.game"]; c.Resolution != "block_wins" || c.Winner != "g-school" {
+		t.Errorf("apps conflict = %+v", c)
+	}
+	if c := conflicts["wifi/edu"]; c.Resolution != "precedence" || c.Winner != "g-lab" {
+		t.Errorf("wifi conflict = %+v", c)
+	}
+	if c := conflicts["screenTime.dailyLimitMinutes/"]; c.Resolution != "most_restrictive" || c.Winner != "g-school" {
+		t.Errorf("screen time conflict = %+v", c)
+	}
+	if c := conflicts["examMode.allowedApps/"]; c.Winner != "g-lab" {
+		t.Errorf("exam conflict = %+v", c)
+	}
+	if len(got.Conflicts) != 4 {
+		t.Errorf("conflicts = %+v", got.Conflicts)
+	}
+
+	again := ResolveEffectivePolicy("dev1", []models.DeviceGroup{schoolGroup, tenantWide, labGroup}, now)
+	if again.Hash != got.Hash {
+		t.Errorf("hash depends on group order")
+	}
+	later := ResolveEffectivePolicy("dev1", []models.DeviceGroup{schoolGroup, tenantWide, labGroup}, now.Add(72*time.Hour))
+	if later.Policy.ExamMode != nil || later.Hash == got.Hash {
+		t.Errorf("expired exam windows should be dropped: %+v", later.Policy.ExamMode)
+	}
+}
+
+func TestResolveEffectivePolicyEmpty(t *testing.T) {
+	got := ResolveEffectivePolicy("dev1", nil, time.Now())
+	if !got.Policy.IsEmpty() || len(got.Sources) != 0 || got.Hash == "" {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// DeviceRegistry is the system of record for devices (ssot-devices).
+type DeviceRegistry interface {
+	RegisterDevice(ctx context.Context, reg models.DeviceRegistration) (models.DeviceSnapshot, error)
+}
+
+// RegistrationRejection is returned by a DeviceRegistry that refused a
+// registration outright; such registrations are not retried.
+type RegistrationRejection interface {
+	error
+	RejectionCode() string
+	RejectionMessage() string
+}
+
+// RegistrationStore records the outcome of a submitted registration.
+type RegistrationStore interface {
+	ConfirmDeviceRegistration(ctx context.Context, reg models.DeviceRegistration, device models.DeviceSnapshot, now time.Time) (models.DeviceRegistration, error)
+	RejectDeviceRegistration(ctx context.Context, reg models.DeviceRegistration, code, reason string, now time.Time) (models.DeviceRegistration, error)
+	DeferDeviceRegistration(ctx context.Context, reg models.DeviceRegistration, lastError string, next, now time.Time) (models.DeviceRegistration, error)
+}
+
+// Retry delays for registrations ssot-devices could not be reached for:
+// doubling from one minute, capped at an hour.
+const (
+	registrationRetryBase = time.Minute
+	registrationRetryMax  = time.Hour
+)
+
+// RegistrationRetryDelay returns how long to wait before the next attempt
+// after the given number of failed attempts.
+func RegistrationRetryDelay(attempts int) time.Duration {
+	d := registrationRetryBase
+	for i := 1; i < attempts && d < registrationRetryMax; i++ {
+		d *= 2
+	}
+	if d > registrationRetryMax {
+		d = registrationRetryMax
+	}
+	return d
+}
+
+// SubmitDeviceRegistration sends a pending registration to the registry and
+// records the outcome: confirmed with the registry's device ID, rejected
+// with its reason, or left pending with a retry time when the registry
+// could not be reached. The returned error is only set when recording the
+// outcome failed.
+func SubmitDeviceRegistration(ctx context.Context, st RegistrationStore, registry DeviceRegistry, reg models.DeviceRegistration, now time.Time) (models.DeviceRegistration, error) {
+	device, err := registry.RegisterDevice(ctx, reg)
+	if err == nil {
+		device.TenantID = reg.TenantID
+		if device.SchoolID == "" {
+			device.SchoolID = reg.SchoolID
+		}
+		device.UpdatedAt = now
+		return st.ConfirmDeviceRegistration(ctx, reg, device, now)
+	}
+	var rejection RegistrationRejection
+	if errors.As(err, &rejection) {
+		return st.RejectDeviceRegistration(ctx, reg, rejection.RejectionCode(), rejection.RejectionMessage(), now)
+	}
+	return st.DeferDeviceRegistration(ctx, reg, err.Error(), now.Add(RegistrationRetryDelay(reg.Attempts+1)), now)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+type fakeRegistry struct {
+	device models.DeviceSnapshot
+	err    error
+}
+
+func (f fakeRegistry) RegisterDevice(context.Context, models.DeviceRegistration) (models.DeviceSnapshot, error) {
+	return f.device, f.err
+}
+
+type fakeRejection struct{ code string }
+
+func (e fakeRejection) Error() string            { return "rejected: " + e.code }
+func (e fakeRejection) RejectionCode() string    { return e.code }
+func (e fakeRejection) RejectionMessage() string { return "serial already registered" }
+
+type fakeRegistrationStore struct {
+	confirmed models.DeviceSnapshot
+	nextAt    time.Time
+}
+
+func (f *fakeRegistrationStore) ConfirmDeviceRegistration(_ context.Context, reg models.DeviceRegistration, d models.DeviceSnapshot, now time.Time) (models.DeviceRegistration, error) {
+	f.confirmed = d
+	reg.Status, reg.DeviceID, reg.ConfirmedAt = models.DeviceRegistrationConfirmed, d.DeviceID, &now
+	return reg, nil
+}
+
+func (f *fakeRegistrationStore) RejectDeviceRegistration(_ context.Context, reg models.DeviceRegistration, code, reason string, _ time.Time) (models.DeviceRegistration, error) {
+	reg.Status, reg.RejectionCode, reg.RejectionReason = models.DeviceRegistrationRejected, code, reason
+	return reg, nil
+}
+
+func (f *fakeRegistrationStore) DeferDeviceRegistration(_ context.Context, reg models.DeviceRegistration, lastError string, next, _ time.Time) (models.DeviceRegistration, error) {
+	f.nextAt = next
+	reg.Attempts++
+	reg.LastError = lastError
+	return reg, nil
+}
+
+func TestSubmitDeviceRegistration(t *testing.T) {
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	reg := models.DeviceRegistration{ID: "reg1", TenantID: "t1", SchoolID: "sch1", Serial: "SN1", Model: "HP 11",
+		Status: models.DeviceRegistrationPending, Attempts: 2}
+
+	t.Run("confirmed", func(t *testing.T) {
+		st := &fakeRegistrationStore{}
+		got, err := SubmitDeviceRegistration(context.Background(), st, fakeRegistry{device: models.DeviceSnapshot{DeviceID: "dev_ssot"}}, reg, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != models.DeviceRegistrationConfirmed || got.DeviceID != "dev_ssot" {
+			t.Errorf("got %+v", got)
+		}
+		if st.confirmed.TenantID != "t1" || st.confirmed.SchoolID != "sch1" || !st.confirmed.UpdatedAt.Equal(now) {
+			t.Errorf("snapshot = %+v", st.confirmed)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		got, err := SubmitDeviceRegistration(context.Background(), &fakeRegistrationStore{}, fakeRegistry{err: fakeRejection{"duplicate_serial"}}, reg, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != models.DeviceRegistrationRejected || got.RejectionCode != "duplicate_serial" {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		st := &fakeRegistrationStore{}
+		got, err := SubmitDeviceRegistration(context.Background(), st, fakeRegistry{err: errors.New("connection refused")}, reg, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != models.DeviceRegistrationPending || got.Attempts != 3 || got.LastError == "" {
+			t.Errorf("got %+v", got)
+		}
+		if want := now.Add(4 * time.Minute); !st.nextAt.Equal(want) {
+			t.Errorf("next attempt = %v, want %v", st.nextAt, want)
+		}
+	})
+}
+
+func TestRegistrationRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := RegistrationRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("RegistrationRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// ValidateFieldMutation checks a queued mutation's envelope and payload
+// before it is applied.
+func ValidateFieldMutation(m models.FieldMutation) error {
+	if strings.TrimSpace(m.IdempotencyKey) == "" {
+		return errors.New("idempotencyKey required")
+	}
+	if strings.TrimSpace(m.WorkOrderID) == "" {
+		return errors.New("workOrderId required")
+	}
+	if m.ClientTimestamp.IsZero() {
+		return errors.New("clientTimestamp required")
+	}
+	switch m.Type {
+	case models.FieldMutationWorkOrderStatus:
+		var p models.FieldStatusPayload
+		if err := json.Unmarshal(m.Payload, &p); err != nil || p.Status == "" {
+			return errors.New("payload.status required")
+		}
+	case models.FieldMutationBOMConsume:
+		var p models.FieldConsumePayload
+		if err := json.Unmarshal(m.Payload, &p); err != nil || strings.TrimSpace(p.ItemID) == "" {
+			return errors.New("payload.itemId required")
+		}
+		if p.QtyUsed <= 0 {
+			return errors.New("payload.qtyUsed>0 required")
+		}
+	case models.FieldMutationDeliverableSubmit:
+		var p models.FieldDeliverablePayload
+		if err := json.Unmarshal(m.Payload, &p); err != nil || strings.TrimSpace(p.DeliverableID) == "" {
+			return errors.New("payload.deliverableId required")
+		}
+		if strings.TrimSpace(p.EvidenceAttachmentID) == "" {
+			return errors.New("payload.evidenceAttachmentId required")
+		}
+	default:
+		return errors.New("unknown mutation type")
+	}
+	return nil
+}
+
+// FieldSyncConflict reports whether the server copy of an entity changed
+// after the version the device based its mutation on. Without a base
+// version the mutation is applied as last writer wins. Timestamps are
+// compared at the database's microsecond precision.
+func FieldSyncConflict(base *time.Time, current time.Time) bool {
+	if base == nil {
+		return false
+	}
+	return current.Truncate(time.Microsecond).After(base.Truncate(time.Microsecond))
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestValidateFieldMutation(t *testing.T) {
+	now := time.Now()
+	mut := func(typ models.FieldMutationType, payload string) models.FieldMutation {
+		return models.FieldMutation{IdempotencyKey: "k1", Type: typ, WorkOrderID: "wo1", ClientTimestamp: now, Payload: json.RawMessage(payload)}
+	}
+	tests := []struct {
+		name    string
+		m       models.FieldMutation
+		wantErr bool
+	}{
+		{"status", mut(models.FieldMutationWorkOrderStatus, `{"status":"in_repair"}`), false},
+		{"status missing", mut(models.FieldMutationWorkOrderStatus, `{}`), true},
+		{"consume", mut(models.FieldMutationBOMConsume, `{"itemId":"b1","qtyUsed":2}`), false},
+		{"consume zero qty", mut(models.FieldMutationBOMConsume, `{"itemId":"b1","qtyUsed":0}`), true},
+		{"deliverable", mut(models.FieldMutationDeliverableSubmit, `{"deliverableId":"d1","evidenceAttachmentId":"a1"}`), false},
+		{"deliverable without evidence", mut(models.FieldMutationDeliverableSubmit, `{"deliverableId":"d1"}`), true},
+		{"unknown type", mut("work_order.delete", `{}`), true},
+		{"bad payload", mut(models.FieldMutationBOMConsume, `[`), true},
+		{"missing key", models.FieldMutation{Type: models.FieldMutationWorkOrderStatus, WorkOrderID: "wo1", ClientTimestamp: now, Payload: json.RawMessage(`{"status":"qa"}`)}, true},
+		{"missing client timestamp", models.FieldMutation{IdempotencyKey: "k1", Type: models.FieldMutationWorkOrderStatus, WorkOrderID: "wo1", Payload: json.RawMessage(`{"status":"qa"}`)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateFieldMutation(tt.m); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFieldMutation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFieldSyncConflict(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	if FieldSyncConflict(nil, base) {
+		t.Error("no base version should never conflict")
+	}
+	if FieldSyncConflict(&base, base.Truncate(time.Microsecond)) {
+		t.Error("same version at database precision should not conflict")
+	}
+	later := base.Add(time.Second)
+	if !FieldSyncConflict(&base, later) {
+		t.Error("newer server copy should conflict")
+	}
+	earlier := base.Add(-time.Second)
+	if FieldSyncConflict(&base, earlier) {
+		t.Error("older server copy should not conflict")
+	}
+}
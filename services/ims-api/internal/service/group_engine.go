@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// GroupStore is the storage the group membership engine works against.
+type GroupStore interface {
+	GroupCandidates(ctx context.Context, tenantID, schoolID string, deviceIDs []string) ([]models.GroupCandidate, error)
+	SyncMembers(ctx context.Context, tenantID, groupID string, desired, scope []string, reason string, now time.Time) (added, removed []string, err error)
+}
+
+// EvaluateGroup recomputes the full membership of an automatic group.
+func EvaluateGroup(ctx context.Context, st GroupStore, g models.DeviceGroup, reason string, now time.Time) (added, removed []string, err error) {
+	schoolID := ""
+	if g.SchoolID != nil {
+		schoolID = *g.SchoolID
+	}
+	candidates, err := st.GroupCandidates(ctx, g.TenantID, schoolID, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	desired := []string{}
+	for _, c := range ResolveGroupMembers(g, candidates) {
+		desired = append(desired, c.DeviceID)
+	}
+	return st.SyncMembers(ctx, g.TenantID, g.ID, desired, nil, reason, now)
+}
+
+// EvaluateDevice re-checks one device against the given automatic groups,
+// adding or removing only that device. A device no longer in the snapshot
+// leaves every group. It returns the number of membership changes.
+func EvaluateDevice(ctx context.Context, st GroupStore, groups []models.DeviceGroup, tenantID, deviceID, reason string, now time.Time) (int, error) {
+	candidates, err := st.GroupCandidates(ctx, tenantID, "", []string{deviceID})
+	if err != nil {
+		return 0, err
+	}
+	changes := 0
+	for _, g := range groups {
+		if !g.IsAutomatic() || !g.Active {
+			continue
+		}
+		desired := []string{}
+		if len(candidates) == 1 && MatchesGroup(g, candidates[0]) {
+			desired = append(desired, deviceID)
+		}
+		added, removed, err := st.SyncMembers(ctx, tenantID, g.ID, desired, []string{deviceID}, reason, now)
+		if err != nil {
+			return changes, err
+		}
+		changes += len(added) + len(removed)
+	}
+	return changes, nil
+}
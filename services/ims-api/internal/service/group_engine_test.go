@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// fakeGroupStore keeps memberships in memory and applies SyncMembers with
+// the same scope rules as the Postgres store.
+type fakeGroupStore struct {
+	candidates []models.GroupCandidate
+	members    map[string]map[string]bool
+}
+
+func (f *fakeGroupStore) GroupCandidates(_ context.Context, _, schoolID string, deviceIDs []string) ([]models.GroupCandidate, error) {
+	want := map[string]bool{}
+	for _, id := range deviceIDs {
+		want[id] = true
+	}
+	out := []models.GroupCandidate{}
+	for _, c := range f.candidates {
+		if schoolID != "" && c.SchoolID != schoolID {
+			continue
+		}
+		if deviceIDs != nil && !want[c.DeviceID] {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (f *fakeGroupStore) SyncMembers(_ context.Context, _, groupID string, desired, scope []string, _ string, _ time.Time) ([]string, []string, error) {
+	cur := f.members[groupID]
+	if cur == nil {
+		cur = map[string]bool{}
+		f.members[groupID] = cur
+	}
+	want := map[string]bool{}
+	var added, removed []string
+	for _, d := range desired {
+		want[d] = true
+		if !cur[d] {
+			added = append(added, d)
+		}
+	}
+	check := scope
+	if scope == nil {
+		for d := range cur {
+			check = append(check, d)
+		}
+	}
+	for _, d := range check {
+		if cur[d] && !want[d] {
+			removed = append(removed, d)
+		}
+	}
+	for _, d := range added {
+		cur[d] = true
+	}
+	for _, d := range removed {
+		delete(cur, d)
+	}
+	return added, removed, nil
+}
+
+func TestEvaluateGroup(t *testing.T) {
+	school := "sch1"
+	st := &fakeGroupStore{
+		candidates: []models.GroupCandidate{
+			{DeviceID: "d1", SchoolID: school, Model: "Chromebook"},
+			{DeviceID: "d2", SchoolID: school, Model: "Latitude"},
+			{DeviceID: "d3", SchoolID: "sch2", Model: "Chromebook"},
+		},
+		members: map[string]map[string]bool{"g1": {"d2": true}},
+	}
+	g := models.DeviceGroup{ID: "g1", SchoolID: &school, GroupType: models.GroupTypeDynamic, Active: true,
+		Selector: &models.GroupSelector{Model: "chromebook"}}
+
+	added, removed, err := EvaluateGroup(context.Background(), st, g, "test", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0] != "d1" {
+		t.Errorf("added = %v, want [d1]", added)
+	}
+	if len(removed) != 1 || removed[0] != "d2" {
+		t.Errorf("removed = %v, want [d2]", removed)
+	}
+}
+
+func TestEvaluateDevice(t *testing.T) {
+	lab := "lab1"
+	st := &fakeGroupStore{
+		candidates: []models.GroupCandidate{{DeviceID: "d1", Model: "Chromebook", LocationPath: []string{lab}}},
+		members:    map[string]map[string]bool{"other": {"d9": true}, "loc": {}},
+	}
+	groups := []models.DeviceGroup{
+		{ID: "loc", GroupType: models.GroupTypeLocation, LocationID: &lab, Active: true},
+		{ID: "other", GroupType: models.GroupTypeDynamic, Selector: &models.GroupSelector{Model: "Latitude"}, Active: true},
+		{ID: "manual", GroupType: models.GroupTypeManual, Active: true},
+	}
+
+	n, err := EvaluateDevice(context.Background(), st, groups, "t1", "d1", "test", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || !st.members["loc"]["d1"] {
+		t.Errorf("changes = %d, loc members = %v; want d1 added to loc only", n, st.members["loc"])
+	}
+	if !st.members["other"]["d9"] {
+		t.Error("devices outside the evaluated one must be left alone")
+	}
+
+	// The device moves out of the lab.
+	st.candidates[0].LocationPath = nil
+	if n, _ := EvaluateDevice(context.Background(), st, groups, "t1", "d1", "test", time.Now()); n != 1 || st.members["loc"]["d1"] {
+		t.Errorf("changes = %d; want d1 removed from loc", n)
+	}
+}
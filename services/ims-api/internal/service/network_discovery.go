@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// MACDirectory resolves MAC addresses against the system of record for
+// devices (ssot-devices). found is false when no device has the MAC; err is
+// reserved for lookups that could not be answered.
+type MACDirectory interface {
+	LookupMAC(ctx context.Context, tenantID, mac string) (snap models.DeviceNetworkSnapshot, found bool, err error)
+}
+
+// NormalizeMAC converts a MAC address written with colons, dashes, dots or
+// no separators to the lowercase colon-separated form ssot-devices stores.
+// ok is false unless the input holds exactly 12 hex digits.
+func NormalizeMAC(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer(":", "", "-", "", ".", "").Replace(s)
+	if len(s) != 12 {
+		return "", false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", false
+		}
+	}
+	return s[0:2] + ":" + s[2:4] + ":" + s[4:6] + ":" + s[6:8] + ":" + s[8:10] + ":" + s[10:12], true
+}
+
+var (
+	macPattern  = regexp.MustCompile(`(?i)\b[0-9a-f]{1,2}(?:[:-][0-9a-f]{1,2}){5}\b|\b[0-9a-f]{4}\.[0-9a-f]{4}\.[0-9a-f]{4}\b`)
+	ipv4Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+)
+
+// ParseNetworkScanText extracts sightings from a pasted DHCP lease table or
+// ARP table: `arp -a`, `ip neigh`, router CSV exports and ISC dhcpd.leases
+// all work. Each line contributes its first MAC address and IPv4 address. A
+// line with an IP address but no MAC, such as "lease 10.0.0.5 {", lends its
+// IP to the next MAC-only line. Single-digit octets ("0:1b:...") are padded.
+// Broadcast and all-zero addresses are counted in invalid and skipped.
+func ParseNetworkScanText(text string, seenAt time.Time) (out []models.NetworkSighting, invalid int) {
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	pendingIP := ""
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ip := firstIPv4(line)
+		m := macPattern.FindString(line)
+		if m == "" {
+			if ip != "" {
+				pendingIP = ip
+			}
+			continue
+		}
+		mac, ok := NormalizeMAC(padMACOctets(m))
+		if !ok || mac == "ff:ff:ff:ff:ff:ff" || mac == "00:00:00:00:00:00" {
+			invalid++
+			continue
+		}
+		if ip == "" {
+			ip = pendingIP
+		}
+		pendingIP = ""
+		out = append(out, models.NetworkSighting{MACAddress: mac, IPAddress: ip, SeenAt: seenAt})
+	}
+	return out, invalid
+}
+
+func firstIPv4(line string) string {
+	for _, c := range ipv4Pattern.FindAllString(line, -1) {
+		if ip := net.ParseIP(c); ip != nil && ip.To4() != nil {
+			return c
+		}
+	}
+	return ""
+}
+
+// padMACOctets turns "0:1b:2:..." into "00:1b:02:..."; other forms are
+// returned unchanged.
+func padMACOctets(m string) string {
+	sep := ""
+	switch {
+	case strings.Contains(m, ":"):
+		sep = ":"
+	case strings.Contains(m, "-"):
+		sep = "-"
+	default:
+		return m
+	}
+	parts := strings.Split(m, sep)
+	for i, p := range parts {
+		if len(p) == 1 {
+			parts[i] = "0" + p
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// MergeSightings keeps one sighting per MAC address: the latest, with the
+// IP address and hostname of the latest sighting that had them. The result
+// is ordered by MAC address.
+func MergeSightings(in []models.NetworkSighting) []models.NetworkSighting {
+	byMAC := map[string]models.NetworkSighting{}
+	for _, s := range in {
+		prev, ok := byMAC[s.MACAddress]
+		if !ok {
+			byMAC[s.MACAddress] = s
+			continue
+		}
+		latest, other := s, prev
+		if prev.SeenAt.After(s.SeenAt) {
+			latest, other = prev, s
+		}
+		if latest.IPAddress == "" {
+			latest.IPAddress = other.IPAddress
+		}
+		if latest.Hostname == "" {
+			latest.Hostname = other.Hostname
+		}
+		byMAC[s.MACAddress] = latest
+	}
+	out := make([]models.NetworkSighting, 0, len(byMAC))
+	for _, s := range byMAC {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].MACAddress < out[j].MACAddress })
+	return out
+}
+
+// DaysUnseen returns the whole days between lastSeen and now, or nil when
+// the device was never seen.
+func DaysUnseen(lastSeen *time.Time, now time.Time) *int {
+	if lastSeen == nil {
+		return nil
+	}
+	d := int(now.Sub(*lastSeen) / (24 * time.Hour))
+	if d < 0 {
+		d = 0
+	}
+	return &d
+}
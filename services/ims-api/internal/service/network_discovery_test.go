@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestNormalizeMAC(t *testing.T) {
+	for in, want := range map[string]string{
+		"AA:BB:CC:DD:EE:FF": "aa:bb:cc:dd:ee:ff",
+		"aa-bb-cc-dd-ee-ff": "aa:bb:cc:dd:ee:ff",
+		"aabb.ccdd.eeff":    "aa:bb:cc:dd:ee:ff",
+		" aabbccddeeff ":    "aa:bb:cc:dd:ee:ff",
+	} {
+		if got, ok := NormalizeMAC(in); !ok || got != want {
+			t.Errorf("NormalizeMAC(%q) = %q, %v", in, got, ok)
+		}
+	}
+	for _, in := range []string{"", "aa:bb:cc:dd:ee", "gg:bb:cc:dd:ee:ff", "aa:bb:cc:dd:ee:ff:00"} {
+		if got, ok := NormalizeMAC(in); ok {
+			t.Errorf("NormalizeMAC(%q) = %q, want invalid", in, got)
+		}
+	}
+}
+
+func TestParseNetworkScanText(t *testing.T) {
+	at := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	text := `# arp -a
+? (10.0.0.12) at 0:1b:2c:3d:4e:5f on en0 ifscope [ethernet]
+? (10.0.0.255) at ff:ff:ff:ff:ff:ff on en0 ifscope [ethernet]
+10.0.0.13 dev eth0 lladdr AA-BB-CC-DD-EE-01 REACHABLE
+lease 10.0.0.14 {
+  starts 1 2026/03/02 07:55:00;
+  hardware ethernet aa:bb:cc:dd:ee:02;
+}
+aabb.ccdd.ee03,10.0.0.15,lab-pc-3
+`
+	got, invalid := ParseNetworkScanText(text, at)
+	want := []models.NetworkSighting{
+		{MACAddress: "00:1b:2c:3d:4e:5f", IPAddress: "10.0.0.12", SeenAt: at},
+		{MACAddress: "aa:bb:cc:dd:ee:01", IPAddress: "10.0.0.13", SeenAt: at},
+		{MACAddress: "aa:bb:cc:dd:ee:02", IPAddress: "10.0.0.14", SeenAt: at},
+		{MACAddress: "aa:bb:cc:dd:ee:03", IPAddress: "10.0.0.15", SeenAt: at},
+	}
+	if invalid != 1 {
+		t.Errorf("invalid = %d, want 1 (broadcast)", invalid)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d sightings: %+v", len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sighting %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMergeSightings(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	got := MergeSightings([]models.NetworkSighting{
+		{MACAddress: "aa:bb:cc:dd:ee:02", SeenAt: t0},
+		{MACAddress: "aa:bb:cc:dd:ee:01", IPAddress: "10.0.0.1", Hostname: "pc-1", SeenAt: t0},
+		{MACAddress: "aa:bb:cc:dd:ee:01", IPAddress: "", SeenAt: t0.Add(time.Hour)},
+	})
+	if len(got) != 2 || got[0].MACAddress != "aa:bb:cc:dd:ee:01" {
+		t.Fatalf("merged = %+v", got)
+	}
+	if !got[0].SeenAt.Equal(t0.Add(time.Hour)) || got[0].IPAddress != "10.0.0.1" || got[0].Hostname != "pc-1" {
+		t.Errorf("merged sighting = %+v", got[0])
+	}
+}
+
+func TestDaysUnseen(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	if DaysUnseen(nil, now) != nil {
+		t.Error("never seen should be nil")
+	}
+	seen := now.Add(-(10*24 + 5) * time.Hour)
+	if d := DaysUnseen(&seen, now); d == nil || *d != 10 {
+		t.Errorf("days = %v", d)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// ChecklistItemsForPhase copies the templates that apply to a phase onto it:
+// those for its phase type and the project's type. Required items come
+// first, then template creation order. IDs are left for the caller to set.
+func ChecklistItemsForPhase(templates []models.PhaseChecklistTemplate, projectType models.ProjectType, phase models.ServicePhase, now time.Time) []models.PhaseChecklistItem {
+	var matched []models.PhaseChecklistTemplate
+	for _, t := range templates {
+		if t.PhaseType == phase.PhaseType && (t.ProjectType == "" || t.ProjectType == projectType) {
+			matched = append(matched, t)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].Required != matched[j].Required {
+			return matched[i].Required
+		}
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+
+	out := make([]models.PhaseChecklistItem, 0, len(matched))
+	for i, t := range matched {
+		out = append(out, models.PhaseChecklistItem{
+			TenantID:    phase.TenantID,
+			ProjectID:   phase.ProjectID,
+			PhaseID:     phase.ID,
+			TemplateID:  t.ID,
+			Title:       t.Title,
+			Description: t.Description,
+			Required:    t.Required,
+			Position:    i + 1,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+	return out
+}
+
+// OutstandingRequired returns the required items not yet completed; a phase
+// cannot move to done while any remain.
+func OutstandingRequired(items []models.PhaseChecklistItem) []models.PhaseChecklistItem {
+	var out []models.PhaseChecklistItem
+	for _, it := range items {
+		if it.Required && !it.Done() {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// SummarizeChecklist counts a phase's checklist items.
+func SummarizeChecklist(phase models.ServicePhase, items []models.PhaseChecklistItem) models.PhaseChecklistProgress {
+	p := models.PhaseChecklistProgress{PhaseID: phase.ID, PhaseType: phase.PhaseType, Status: phase.Status, Total: len(items)}
+	for _, it := range items {
+		if it.Required {
+			p.RequiredTotal++
+		}
+		if it.Done() {
+			p.Completed++
+			if it.Required {
+				p.RequiredCompleted++
+			}
+		}
+	}
+	p.Percent = 100
+	if p.Total > 0 {
+		p.Percent = p.Completed * 100 / p.Total
+	}
+	p.CanComplete = p.RequiredCompleted == p.RequiredTotal
+	return p
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestChecklistItemsForPhase(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	templates := []models.PhaseChecklistTemplate{
+		{ID: "t1", ProjectType: models.ProjectTypeFullInstallation, PhaseType: models.PhaseSurvey, Title: "Photos", Required: false, CreatedAt: t0},
+		{ID: "t2", ProjectType: models.ProjectTypeFullInstallation, PhaseType: models.PhaseSurvey, Title: "Power", Required: true, CreatedAt: t0.Add(time.Hour)},
+		{ID: "t3", ProjectType: models.ProjectTypeFullInstallation, PhaseType: models.PhaseInstall, Title: "Cabling", Required: true, CreatedAt: t0},
+		{ID: "t4", ProjectType: models.ProjectTypeDeviceRefresh, PhaseType: models.PhaseSurvey, Title: "Other type", Required: true, CreatedAt: t0},
+		{ID: "t5", PhaseType: models.PhaseSurvey, Title: "Any type", Required: true, CreatedAt: t0.Add(2 * time.Hour)},
+	}
+	phase := models.ServicePhase{ID: "ph1", TenantID: "ten", ProjectID: "prj", PhaseType: models.PhaseSurvey}
+
+	items := ChecklistItemsForPhase(templates, models.ProjectTypeFullInstallation, phase, t0)
+	var got []string
+	for _, it := range items {
+		got = append(got, it.TemplateID)
+		if it.PhaseID != "ph1" || it.ProjectID != "prj" || it.TenantID != "ten" {
+			t.Errorf("item not bound to phase: %+v", it)
+		}
+	}
+	want := []string{"t2", "t5", "t1"}
+	if len(got) != len(want) {
+		t.Fatalf("templates = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] || items[i].Position != i+1 {
+			t.Errorf("item %d = %s at %d, want %s at %d", i, got[i], items[i].Position, want[i], i+1)
+		}
+	}
+}
+
+func TestSummarizeChecklist(t *testing.T) {
+	done := time.Now()
+	items := []models.PhaseChecklistItem{
+		{ID: "a", Required: true, CompletedAt: &done},
+		{ID: "b", Required: true},
+		{ID: "c", Required: false, CompletedAt: &done},
+		{ID: "d", Required: false},
+	}
+	p := SummarizeChecklist(models.ServicePhase{ID: "ph"}, items)
+	if p.Total != 4 || p.Completed != 2 || p.RequiredTotal != 2 || p.RequiredCompleted != 1 || p.Percent != 50 || p.CanComplete {
+		t.Errorf("progress = %+v", p)
+	}
+	if out := OutstandingRequired(items); len(out) != 1 || out[0].ID != "b" {
+		t.Errorf("outstanding = %+v", out)
+	}
+
+	empty := SummarizeChecklist(models.ServicePhase{ID: "ph"}, nil)
+	if empty.Percent != 100 || !empty.CanComplete {
+		t.Errorf("empty checklist = %+v", empty)
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// ErrDependencyCycle is returned when phase dependencies loop back on
+// themselves.
+var ErrDependencyCycle = errors.New("phase dependencies form a cycle")
+
+// ErrInvalidSchedule is returned for planned dates that cannot be reconciled.
+var ErrInvalidSchedule = errors.New("invalid phase schedule")
+
+// PlanPhaseDates completes a phase's planned dates from whichever two of
+// start, end and duration are given. Duration counts both ends: a phase
+// planned for one day starts and ends on the same date. Empty values are
+// left empty when they cannot be derived.
+func PlanPhaseDates(start, end string, durationDays int) (string, string, int, error) {
+	if durationDays < 0 {
+		return "", "", 0, ErrInvalidSchedule
+	}
+	var s, e time.Time
+	var err error
+	if start != "" {
+		if s, err = time.Parse(dateLayout, start); err != nil {
+			return "", "", 0, ErrInvalidSchedule
+		}
+	}
+	if end != "" {
+		if e, err = time.Parse(dateLayout, end); err != nil {
+			return "", "", 0, ErrInvalidSchedule
+		}
+	}
+	switch {
+	case start != "" && end != "":
+		if e.Before(s) {
+			return "", "", 0, ErrInvalidSchedule
+		}
+		span := daysBetween(s, e) + 1
+		if durationDays != 0 && durationDays != span {
+			return "", "", 0, ErrInvalidSchedule
+		}
+		durationDays = span
+	case start != "" && durationDays > 0:
+		end = s.AddDate(0, 0, durationDays-1).Format(dateLayout)
+	case end != "" && durationDays > 0:
+		start = e.AddDate(0, 0, -(durationDays - 1)).Format(dateLayout)
+	}
+	return start, end, durationDays, nil
+}
+
+// PhaseDuration is the number of days a phase is expected to take: its
+// duration, else the span of its planned dates, else one day.
+func PhaseDuration(p models.ServicePhase) int {
+	if p.DurationDays > 0 {
+		return p.DurationDays
+	}
+	s, okS := parseDate(p.PlannedStart)
+	e, okE := parseDate(p.PlannedEnd)
+	if okS && okE && !e.Before(s) {
+		return daysBetween(s, e) + 1
+	}
+	return 1
+}
+
+// DependencyStatus returns the status a phase should have given whether any
+// of its predecessors are incomplete. Pending and in-progress phases are
+// blocked automatically; a phase blocked that way is released, back to
+// in_progress if it had started, once its predecessors are done. Phases
+// blocked by hand and done phases are left alone. changed reports whether
+// the status or the automatic flag differs from the phase's.
+func DependencyStatus(p models.ServicePhase, predecessorsIncomplete bool) (status models.PhaseStatus, dependencyBlocked, changed bool) {
+	status, dependencyBlocked = p.Status, p.DependencyBlocked
+	switch {
+	case predecessorsIncomplete && (p.Status == models.PhasePending || p.Status == models.PhaseInProgress):
+		status, dependencyBlocked = models.PhaseBlocked, true
+	case !predecessorsIncomplete && p.DependencyBlocked && p.Status == models.PhaseBlocked:
+		status, dependencyBlocked = models.PhasePending, false
+		if p.ActualStart != "" {
+			status = models.PhaseInProgress
+		}
+	case p.Status != models.PhaseBlocked:
+		dependencyBlocked = false
+	}
+	return status, dependencyBlocked, status != p.Status || dependencyBlocked != p.DependencyBlocked
+}
+
+type scheduleNode struct {
+	phase    models.ServicePhase
+	external bool
+	preds    []models.PhaseDependency
+	succs    []models.PhaseDependency
+	start    time.Time
+	end      time.Time
+	lateEnd  time.Time
+	rank     int
+	indegree int
+}
+
+// BuildSchedule computes a project's schedule as of today. phases are the
+// project's phases; external are predecessors from other projects, which
+// are scheduled from their own dates only. order is the project type's
+// phase order, used to lay out the chart.
+//
+// Forecasts run forward through the dependencies: a done phase keeps its
+// actual dates, a started phase runs its duration from its actual start
+// and cannot finish before today, and an unstarted phase starts on the
+// latest of its planned start, today and each predecessor's forecast
+// finish plus lag. The critical path is the chain of project phases with
+// no float against the project's forecast finish.
+func BuildSchedule(projectID string, phases, external []models.ServicePhase, deps []models.PhaseDependency, order []models.PhaseType, today time.Time) (models.ProjectSchedule, error) {
+	today = truncateDay(today)
+	rankOf := map[models.PhaseType]int{}
+	for i, t := range order {
+		rankOf[t] = i
+	}
+
+	nodes := map[string]*scheduleNode{}
+	for _, p := range phases {
+		r, ok := rankOf[p.PhaseType]
+		if !ok {
+			r = len(order)
+		}
+		nodes[p.ID] = &scheduleNode{phase: p, rank: r}
+	}
+	for _, p := range external {
+		if _, ok := nodes[p.ID]; !ok {
+			nodes[p.ID] = &scheduleNode{phase: p, external: true, rank: -1}
+		}
+	}
+	var used []models.PhaseDependency
+	for _, d := range deps {
+		pred, succ := nodes[d.PredecessorPhaseID], nodes[d.SuccessorPhaseID]
+		if pred == nil || succ == nil || succ.external {
+			continue
+		}
+		pred.succs = append(pred.succs, d)
+		succ.preds = append(succ.preds, d)
+		succ.indegree++
+		used = append(used, d)
+	}
+
+	topo, err := topoOrder(nodes)
+	if err != nil {
+		return models.ProjectSchedule{}, err
+	}
+
+	// Forward pass.
+	for _, n := range topo {
+		p := n.phase
+		dur := PhaseDuration(p)
+		actualStart, started := parseDate(p.ActualStart)
+		switch {
+		case p.Status == models.PhaseDone:
+			n.end = today
+			if e, ok := parseDate(p.ActualEnd); ok {
+				n.end = e
+			}
+			n.start = n.end.AddDate(0, 0, -(dur - 1))
+			if started && !actualStart.After(n.end) {
+				n.start = actualStart
+			}
+		case started:
+			n.start = actualStart
+			n.end = maxDate(n.start.AddDate(0, 0, dur-1), today)
+		default:
+			n.start = today
+			if s, ok := parseDate(p.PlannedStart); ok {
+				n.start = maxDate(n.start, s)
+			}
+			if !n.external {
+				for _, d := range n.preds {
+					n.start = maxDate(n.start, nodes[d.PredecessorPhaseID].end.AddDate(0, 0, 1+d.LagDays))
+				}
+			}
+			n.end = n.start.AddDate(0, 0, dur-1)
+		}
+	}
+
+	sched := models.ProjectSchedule{
+		ProjectID:    projectID,
+		AsOf:         today.Format(dateLayout),
+		CriticalPath: []string{},
+		Phases:       []models.ScheduledPhase{},
+		External:     []models.ScheduledPhase{},
+		Dependencies: used,
+	}
+	if sched.Dependencies == nil {
+		sched.Dependencies = []models.PhaseDependency{}
+	}
+
+	var projectEnd, plannedEnd time.Time
+	for _, n := range topo {
+		if n.external {
+			continue
+		}
+		projectEnd = maxDate(projectEnd, n.end)
+		if e, ok := plannedFinish(n.phase); ok {
+			plannedEnd = maxDate(plannedEnd, e)
+		}
+	}
+
+	// Backward pass over the project's own phases.
+	for i := len(topo) - 1; i >= 0; i-- {
+		n := topo[i]
+		if n.external {
+			continue
+		}
+		n.lateEnd = projectEnd
+		for _, d := range n.succs {
+			s := nodes[d.SuccessorPhaseID]
+			lateStart := s.lateEnd.AddDate(0, 0, -daysBetween(s.start, s.end))
+			n.lateEnd = minDate(n.lateEnd, lateStart.AddDate(0, 0, -(1+d.LagDays)))
+		}
+	}
+
+	for _, n := range topo {
+		sp := scheduledPhase(n)
+		if n.external {
+			sched.External = append(sched.External, sp)
+			continue
+		}
+		sp.TotalFloatDays = daysBetween(n.end, n.lateEnd)
+		sp.Critical = sp.TotalFloatDays <= 0
+		if sp.Critical {
+			sched.CriticalPath = append(sched.CriticalPath, sp.PhaseID)
+		}
+		sched.Phases = append(sched.Phases, sp)
+	}
+	sort.SliceStable(sched.Phases, func(i, j int) bool {
+		a, b := nodes[sched.Phases[i].PhaseID], nodes[sched.Phases[j].PhaseID]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if !a.start.Equal(b.start) {
+			return a.start.Before(b.start)
+		}
+		return a.phase.CreatedAt.Before(b.phase.CreatedAt)
+	})
+
+	if !projectEnd.IsZero() {
+		sched.ForecastEnd = projectEnd.Format(dateLayout)
+	}
+	if !plannedEnd.IsZero() {
+		sched.PlannedEnd = plannedEnd.Format(dateLayout)
+		sched.SlipDays = daysBetween(plannedEnd, projectEnd)
+	}
+	return sched, nil
+}
+
+// topoOrder orders nodes so every predecessor precedes its successors,
+// breaking ties by phase order, then creation time.
+func topoOrder(nodes map[string]*scheduleNode) ([]*scheduleNode, error) {
+	var ready, out []*scheduleNode
+	indegree := map[string]int{}
+	for id, n := range nodes {
+		indegree[id] = n.indegree
+		if n.indegree == 0 {
+			ready = append(ready, n)
+		}
+	}
+	less := func(a, b *scheduleNode) bool {
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if !a.phase.CreatedAt.Equal(b.phase.CreatedAt) {
+			return a.phase.CreatedAt.Before(b.phase.CreatedAt)
+		}
+		return a.phase.ID < b.phase.ID
+	}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return less(ready[i], ready[j]) })
+		n := ready[0]
+		ready = ready[1:]
+		out = append(out, n)
+		for _, d := range n.succs {
+			indegree[d.SuccessorPhaseID]--
+			if indegree[d.SuccessorPhaseID] == 0 {
+				ready = append(ready, nodes[d.SuccessorPhaseID])
+			}
+		}
+	}
+	if len(out) != len(nodes) {
+		return nil, ErrDependencyCycle
+	}
+	return out, nil
+}
+
+func scheduledPhase(n *scheduleNode) models.ScheduledPhase {
+	p := n.phase
+	sp := models.ScheduledPhase{
+		PhaseID:           p.ID,
+		ProjectID:         p.ProjectID,
+		PhaseType:         p.PhaseType,
+		Status:            p.Status,
+		DependencyBlocked: p.DependencyBlocked,
+		PlannedStart:      p.PlannedStart,
+		PlannedEnd:        p.PlannedEnd,
+		DurationDays:      PhaseDuration(p),
+		ActualStart:       p.ActualStart,
+		ActualEnd:         p.ActualEnd,
+		ForecastStart:     n.start.Format(dateLayout),
+		ForecastEnd:       n.end.Format(dateLayout),
+		Predecessors:      []string{},
+		External:          n.external,
+	}
+	for _, d := range n.preds {
+		sp.Predecessors = append(sp.Predecessors, d.PredecessorPhaseID)
+	}
+	if e, ok := plannedFinish(p); ok {
+		sp.SlipDays = daysBetween(e, n.end)
+	}
+	return sp
+}
+
+// plannedFinish is the planned end, or the planned start plus duration.
+func plannedFinish(p models.ServicePhase) (time.Time, bool) {
+	if e, ok := parseDate(p.PlannedEnd); ok {
+		return e, true
+	}
+	if s, ok := parseDate(p.PlannedStart); ok {
+		return s.AddDate(0, 0, PhaseDuration(p)-1), true
+	}
+	return time.Time{}, false
+}
+
+func parseDate(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(dateLayout, s)
+	return t, err == nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func maxDate(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func minDate(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestPlanPhaseDates(t *testing.T) {
+	s, e, d, err := PlanPhaseDates("2026-03-02", "", 5)
+	if err != nil || s != "2026-03-02" || e != "2026-03-06" || d != 5 {
+		t.Errorf("start+duration = %s %s %d %v", s, e, d, err)
+	}
+	s, e, d, err = PlanPhaseDates("", "2026-03-06", 5)
+	if err != nil || s != "2026-03-02" || e != "2026-03-06" || d != 5 {
+		t.Errorf("end+duration = %s %s %d %v", s, e, d, err)
+	}
+	if _, _, d, err = PlanPhaseDates("2026-03-02", "2026-03-02", 0); err != nil || d != 1 {
+		t.Errorf("same-day duration = %d %v", d, err)
+	}
+	for _, c := range [][3]any{
+		{"2026-03-06", "2026-03-02", 0},
+		{"2026-03-02", "2026-03-06", 3},
+		{"03/02/2026", "", 1},
+		{"", "", -1},
+	} {
+		if _, _, _, err := PlanPhaseDates(c[0].(string), c[1].(string), c[2].(int)); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("PlanPhaseDates%v err = %v", c, err)
+		}
+	}
+}
+
+func TestDependencyStatus(t *testing.T) {
+	st, blocked, changed := DependencyStatus(models.ServicePhase{Status: models.PhasePending}, true)
+	if st != models.PhaseBlocked || !blocked || !changed {
+		t.Errorf("pending with open predecessor = %s %v %v", st, blocked, changed)
+	}
+	st, blocked, changed = DependencyStatus(models.ServicePhase{Status: models.PhaseBlocked, DependencyBlocked: true, ActualStart: "2026-03-01"}, false)
+	if st != models.PhaseInProgress || blocked || !changed {
+		t.Errorf("released started phase = %s %v %v", st, blocked, changed)
+	}
+	if _, _, changed = DependencyStatus(models.ServicePhase{Status: models.PhaseBlocked}, false); changed {
+		t.Error("manually blocked phase should stay blocked")
+	}
+	if _, _, changed = DependencyStatus(models.ServicePhase{Status: models.PhaseDone}, true); changed {
+		t.Error("done phase should not be blocked")
+	}
+}
+
+func TestBuildSchedule(t *testing.T) {
+	today := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	phases := []models.ServicePhase{
+		{ID: "survey", ProjectID: "p1", PhaseType: models.PhaseSurvey, Status: models.PhaseDone,
+			PlannedStart: "2026-03-01", PlannedEnd: "2026-03-03", ActualStart: "2026-03-01", ActualEnd: "2026-03-05"},
+		{ID: "install", ProjectID: "p1", PhaseType: models.PhaseInstall, Status: models.PhaseBlocked, DependencyBlocked: true,
+			PlannedStart: "2026-03-12", PlannedEnd: "2026-03-16", DurationDays: 5},
+		{ID: "integrate", ProjectID: "p1", PhaseType: models.PhaseIntegrate, Status: models.PhasePending,
+			PlannedStart: "2026-03-13", DurationDays: 2},
+		{ID: "commission", ProjectID: "p1", PhaseType: models.PhaseCommission, Status: models.PhasePending,
+			PlannedStart: "2026-03-17", PlannedEnd: "2026-03-17"},
+	}
+	external := []models.ServicePhase{
+		{ID: "proc", ProjectID: "p0", PhaseType: models.PhaseProcurement, Status: models.PhaseInProgress,
+			ActualStart: "2026-03-01", DurationDays: 14},
+	}
+	deps := []models.PhaseDependency{
+		{PredecessorPhaseID: "proc", SuccessorPhaseID: "install"},
+		{PredecessorPhaseID: "survey", SuccessorPhaseID: "install"},
+		{PredecessorPhaseID: "survey", SuccessorPhaseID: "integrate"},
+		{PredecessorPhaseID: "install", SuccessorPhaseID: "commission", LagDays: 1},
+		{PredecessorPhaseID: "integrate", SuccessorPhaseID: "commission"},
+	}
+	order := models.ProjectTypeConfigs[models.ProjectTypeFullInstallation].Phases
+
+	s, err := BuildSchedule("p1", phases, external, deps, order, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]models.ScheduledPhase{}
+	for _, p := range s.Phases {
+		byID[p.PhaseID] = p
+	}
+	// proc finishes 2026-03-14, so install runs 15-19 and commission,
+	// after a day's lag, on the 21st.
+	if p := byID["install"]; p.ForecastStart != "2026-03-15" || p.ForecastEnd != "2026-03-19" || p.SlipDays != 3 || !p.Critical {
+		t.Errorf("install = %+v", p)
+	}
+	if p := byID["integrate"]; p.ForecastStart != "2026-03-13" || p.ForecastEnd != "2026-03-14" || p.Critical || p.TotalFloatDays != 6 {
+		t.Errorf("integrate = %+v", p)
+	}
+	if p := byID["survey"]; p.ForecastEnd != "2026-03-05" || p.SlipDays != 2 {
+		t.Errorf("survey = %+v", p)
+	}
+	if s.ForecastEnd != "2026-03-21" || s.PlannedEnd != "2026-03-17" || s.SlipDays != 4 {
+		t.Errorf("project end = %s planned %s slip %d", s.ForecastEnd, s.PlannedEnd, s.SlipDays)
+	}
+	if len(s.CriticalPath) != 2 || s.CriticalPath[0] != "install" || s.CriticalPath[1] != "commission" {
+		t.Errorf("critical path = %v", s.CriticalPath)
+	}
+	if len(s.External) != 1 || s.External[0].ForecastEnd != "2026-03-14" {
+		t.Errorf("external = %+v", s.External)
+	}
+	if s.Phases[0].PhaseID != "survey" || s.Phases[3].PhaseID != "commission" {
+		t.Errorf("phase order = %v, %v", s.Phases[0].PhaseID, s.Phases[3].PhaseID)
+	}
+
+	deps = append(deps, models.PhaseDependency{PredecessorPhaseID: "commission", SuccessorPhaseID: "install"})
+	if _, err := BuildSchedule("p1", phases, external, deps, order, today); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("cycle err = %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"github.com/edvirons/ssp/ims/internal/lookups"
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// ReorderQty returns how many units to order for a candidate, or 0 if its
+// projected stock is above the reorder point. Stock is brought back up to
+// reorder point + safety stock, ordering at least the policy minimum.
+func ReorderQty(p models.ReorderProposal) int64 {
+	projected := p.Available + p.InTransit + p.OnOrder
+	if projected > p.ReorderPoint {
+		return 0
+	}
+	qty := p.ReorderPoint + p.SafetyStock - projected
+	if qty < p.MinOrderQty {
+		qty = p.MinOrderQty
+	}
+	if qty < 1 {
+		qty = 1
+	}
+	return qty
+}
+
+// PickVendorSKU chooses a vendor SKU by strategy. Ties fall back to the other
+// criterion, then to SKU ID so the choice is stable.
+func PickVendorSKU(skus []lookups.VendorSKU, strategy models.VendorStrategy) (lookups.VendorSKU, bool) {
+	if len(skus) == 0 {
+		return lookups.VendorSKU{}, false
+	}
+	better := func(a, b lookups.VendorSKU) bool {
+		if strategy == models.VendorFastest {
+			if a.LeadTimeDays != b.LeadTimeDays {
+				return a.LeadTimeDays < b.LeadTimeDays
+			}
+			if a.UnitPriceCents != b.UnitPriceCents {
+				return a.UnitPriceCents < b.UnitPriceCents
+			}
+		} else {
+			if a.UnitPriceCents != b.UnitPriceCents {
+				return a.UnitPriceCents < b.UnitPriceCents
+			}
+			if a.LeadTimeDays != b.LeadTimeDays {
+				return a.LeadTimeDays < b.LeadTimeDays
+			}
+		}
+		return a.ID < b.ID
+	}
+	best := skus[0]
+	for _, s := range skus[1:] {
+		if better(s, best) {
+			best = s
+		}
+	}
+	return best, true
+}
+
+// ProposeReorders keeps the candidates that need reordering and fills in the
+// quantity and chosen vendor SKU. Parts with no vendor SKU are still
+// proposed, flagged NoVendor.
+func ProposeReorders(candidates []models.ReorderProposal, skusByPart map[string][]lookups.VendorSKU, strategy models.VendorStrategy) []models.ReorderProposal {
+	out := []models.ReorderProposal{}
+	for _, p := range candidates {
+		p.ProposedQty = ReorderQty(p)
+		if p.ProposedQty == 0 {
+			continue
+		}
+		sku, ok := PickVendorSKU(skusByPart[p.PartID], strategy)
+		if !ok {
+			p.NoVendor = true
+		} else {
+			p.VendorID = sku.VendorID
+			p.VendorSKUID = sku.ID
+			p.SKU = sku.SKU
+			p.UnitPriceCents = sku.UnitPriceCents
+			p.Currency = sku.Currency
+			p.LeadTimeDays = sku.LeadTimeDays
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/edvirons/ssp/ims/internal/lookups"
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestReorderQty(t *testing.T) {
+	tests := []struct {
+		name string
+		p    models.ReorderProposal
+		want int64
+	}{
+		{"above reorder point", models.ReorderProposal{Available: 6, ReorderPoint: 5, SafetyStock: 2}, 0},
+		{"at reorder point tops up to point plus safety", models.ReorderProposal{Available: 5, ReorderPoint: 5, SafetyStock: 2}, 2},
+		{"in transit and on order count", models.ReorderProposal{Available: 1, InTransit: 1, OnOrder: 1, ReorderPoint: 5}, 2},
+		{"on order lifts stock above the point", models.ReorderProposal{Available: 1, OnOrder: 5, ReorderPoint: 5}, 0},
+		{"minimum order quantity", models.ReorderProposal{Available: 4, ReorderPoint: 5, SafetyStock: 1, MinOrderQty: 10}, 10},
+		{"empty shop", models.ReorderProposal{ReorderPoint: 5, SafetyStock: 5}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReorderQty(tt.p); got != tt.want {
+				t.Errorf("ReorderQty() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickVendorSKU(t *testing.T) {
+	skus := []lookups.VendorSKU{
+		{ID: "a", VendorID: "slow-cheap", UnitPriceCents: 100, LeadTimeDays: 14},
+		{ID: "b", VendorID: "fast-dear", UnitPriceCents: 300, LeadTimeDays: 2},
+		{ID: "c", VendorID: "fast-mid", UnitPriceCents: 200, LeadTimeDays: 2},
+	}
+	if got, _ := PickVendorSKU(skus, models.VendorCheapest); got.VendorID != "slow-cheap" {
+		t.Errorf("cheapest picked %s", got.VendorID)
+	}
+	if got, _ := PickVendorSKU(skus, models.VendorFastest); got.VendorID != "fast-mid" {
+		t.Errorf("fastest picked %s, want the cheaper of the fastest", got.VendorID)
+	}
+	if _, ok := PickVendorSKU(nil, models.VendorCheapest); ok {
+		t.Error("expected no pick from an empty list")
+	}
+}
+
+func TestProposeReorders(t *testing.T) {
+	candidates := []models.ReorderProposal{
+		{ServiceShopID: "s1", PartID: "p1", Available: 1, ReorderPoint: 3},
+		{ServiceShopID: "s1", PartID: "p2", Available: 10, ReorderPoint: 3},
+		{ServiceShopID: "s1", PartID: "p3", Available: 0, ReorderPoint: 3},
+	}
+	skus := map[string][]lookups.VendorSKU{
+		"p1": {{ID: "v1", VendorID: "acme", SKU: "ACME-1", UnitPriceCents: 500, Currency: "KES", LeadTimeDays: 5}},
+	}
+	got := ProposeReorders(candidates, skus, models.VendorCheapest)
+	if len(got) != 2 {
+		t.Fatalf("got %d proposals, want 2", len(got))
+	}
+	if got[0].PartID != "p1" || got[0].VendorID != "acme" || got[0].ProposedQty != 2 || got[0].UnitPriceCents != 500 {
+		t.Errorf("unexpected p1 proposal: %+v", got[0])
+	}
+	if got[1].PartID != "p3" || !got[1].NoVendor {
+		t.Errorf("p3 should be proposed without a vendor: %+v", got[1])
+	}
+}
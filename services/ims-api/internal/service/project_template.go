@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+var templateKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// NormalizeProjectTemplate validates a project type definition and returns
+// it trimmed, with the default phase set to the first phase when omitted
+// and nil lists made empty. Keys for project and phase types are lowercase
+// snake_case, so new offerings such as "solar_install" need no code change.
+func NormalizeProjectTemplate(t models.ProjectTemplate) (models.ProjectTemplate, error) {
+	t.ProjectType = models.ProjectType(strings.TrimSpace(string(t.ProjectType)))
+	if !templateKeyPattern.MatchString(string(t.ProjectType)) {
+		return t, fmt.Errorf("projectType must be lowercase letters, digits and underscores")
+	}
+	t.Label = strings.TrimSpace(t.Label)
+	if t.Label == "" {
+		return t, fmt.Errorf("label required")
+	}
+	t.Description = strings.TrimSpace(t.Description)
+	if len(t.Phases) == 0 {
+		return t, fmt.Errorf("at least one phase required")
+	}
+
+	phases := make([]models.ProjectTemplatePhase, 0, len(t.Phases))
+	seen := map[models.PhaseType]bool{}
+	for i, p := range t.Phases {
+		p.PhaseType = models.PhaseType(strings.TrimSpace(string(p.PhaseType)))
+		if !templateKeyPattern.MatchString(string(p.PhaseType)) {
+			return t, fmt.Errorf("phase %d: phaseType must be lowercase letters, digits and underscores", i)
+		}
+		if seen[p.PhaseType] {
+			return t, fmt.Errorf("phase %d: %s listed twice", i, p.PhaseType)
+		}
+		seen[p.PhaseType] = true
+		if p.DurationDays < 0 {
+			return t, fmt.Errorf("phase %d: durationDays must not be negative", i)
+		}
+		p.Label = strings.TrimSpace(p.Label)
+		if p.Label == "" {
+			p.Label = string(p.PhaseType)
+		}
+		p.OwnerRole = strings.TrimSpace(p.OwnerRole)
+		if p.OwnerRole == "" {
+			p.OwnerRole = models.DefaultPhaseOwnerRole(p.PhaseType)
+		}
+		phases = append(phases, p)
+	}
+	t.Phases = phases
+
+	t.DefaultPhase = models.PhaseType(strings.TrimSpace(string(t.DefaultPhase)))
+	if t.DefaultPhase == "" {
+		t.DefaultPhase = t.Phases[0].PhaseType
+	} else if !seen[t.DefaultPhase] {
+		return t, fmt.Errorf("defaultPhase %s is not one of the phases", t.DefaultPhase)
+	}
+
+	checklist := make([]models.ProjectTemplateChecklistItem, 0, len(t.Checklist))
+	for i, c := range t.Checklist {
+		c.PhaseType = models.PhaseType(strings.TrimSpace(string(c.PhaseType)))
+		if !seen[c.PhaseType] {
+			return t, fmt.Errorf("checklist %d: phaseType %q is not one of the phases", i, c.PhaseType)
+		}
+		c.Title = strings.TrimSpace(c.Title)
+		if c.Title == "" {
+			return t, fmt.Errorf("checklist %d: title required", i)
+		}
+		c.Description = strings.TrimSpace(c.Description)
+		checklist = append(checklist, c)
+	}
+	t.Checklist = checklist
+
+	boq := make([]models.ProjectTemplateBOQItem, 0, len(t.BOQItems))
+	for i, b := range t.BOQItems {
+		b.Category = strings.TrimSpace(b.Category)
+		b.Description = strings.TrimSpace(b.Description)
+		b.PartID = strings.TrimSpace(b.PartID)
+		b.Unit = strings.TrimSpace(b.Unit)
+		if b.Category == "" || b.Description == "" {
+			return t, fmt.Errorf("boqItems %d: category and description required", i)
+		}
+		if b.Qty <= 0 {
+			return t, fmt.Errorf("boqItems %d: qty must be positive", i)
+		}
+		if b.EstimatedCostCents < 0 {
+			return t, fmt.Errorf("boqItems %d: estimatedCostCents must not be negative", i)
+		}
+		if b.Unit == "" {
+			b.Unit = "pcs"
+		}
+		boq = append(boq, b)
+	}
+	t.BOQItems = boq
+
+	roles := make([]models.ProjectTemplateTeamRole, 0, len(t.TeamRoles))
+	for i, r := range t.TeamRoles {
+		if !models.IsValidTeamMemberRole(string(r.Role)) {
+			return t, fmt.Errorf("teamRoles %d: role must be owner, collaborator or viewer", i)
+		}
+		r.Responsibility = strings.TrimSpace(r.Responsibility)
+		if r.Phases == nil {
+			r.Phases = []models.PhaseType{}
+		}
+		for _, p := range r.Phases {
+			if !seen[p] {
+				return t, fmt.Errorf("teamRoles %d: phase %q is not one of the phases", i, p)
+			}
+		}
+		roles = append(roles, r)
+	}
+	t.TeamRoles = roles
+	return t, nil
+}
+
+// TemplateChecklist returns a tenant-defined template's checklist as phase
+// checklist templates, for ChecklistItemsForPhase. Template IDs are the
+// template version's ID and the item's index, so checklist items can be
+// traced back to the version they came from.
+func TemplateChecklist(t models.ProjectTemplate) []models.PhaseChecklistTemplate {
+	out := make([]models.PhaseChecklistTemplate, 0, len(t.Checklist))
+	for i, c := range t.Checklist {
+		out = append(out, models.PhaseChecklistTemplate{
+			ID:          fmt.Sprintf("%s#%d", t.ID, i+1),
+			TenantID:    t.TenantID,
+			ProjectType: t.ProjectType,
+			PhaseType:   c.PhaseType,
+			Title:       c.Title,
+			Description: c.Description,
+			Required:    c.Required,
+			// Keep the template's order when ChecklistItemsForPhase sorts.
+			CreatedAt: t.CreatedAt.Add(time.Duration(i) * time.Microsecond),
+		})
+	}
+	return out
+}
+
+// TemplateBOQItems returns the BOQ lines a new project starts with. IDs are
+// left for the caller to set.
+func TemplateBOQItems(t models.ProjectTemplate, project models.SchoolServiceProject, now time.Time) []models.BOQItem {
+	out := make([]models.BOQItem, 0, len(t.BOQItems))
+	for _, b := range t.BOQItems {
+		out = append(out, models.BOQItem{
+			TenantID:           project.TenantID,
+			ProjectID:          project.ID,
+			Category:           b.Category,
+			Description:        b.Description,
+			PartID:             b.PartID,
+			Qty:                b.Qty,
+			Unit:               b.Unit,
+			EstimatedCostCents: b.EstimatedCostCents,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+		})
+	}
+	return out
+}
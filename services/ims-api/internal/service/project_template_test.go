@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func solarTemplate() models.ProjectTemplate {
+	return models.ProjectTemplate{
+		ProjectType: " solar_install ",
+		Label:       "Solar Install",
+		Phases: []models.ProjectTemplatePhase{
+			{PhaseType: "site_survey", Label: "Site survey"},
+			{PhaseType: "install", DurationDays: 3},
+		},
+		Checklist: []models.ProjectTemplateChecklistItem{
+			{PhaseType: "site_survey", Title: "Roof load assessed", Required: true},
+			{PhaseType: "install", Title: "Inverter photo"},
+		},
+		BOQItems:  []models.ProjectTemplateBOQItem{{Category: "power", Description: "Panel 400W", Qty: 8}},
+		TeamRoles: []models.ProjectTemplateTeamRole{{Role: models.TeamRoleOwner, Responsibility: "Site lead", Phases: []models.PhaseType{"install"}}},
+	}
+}
+
+func TestNormalizeProjectTemplate(t *testing.T) {
+	got, err := NormalizeProjectTemplate(solarTemplate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ProjectType != "solar_install" || got.DefaultPhase != "site_survey" {
+		t.Errorf("type %q default %q", got.ProjectType, got.DefaultPhase)
+	}
+	if got.Phases[1].Label != "install" || got.Phases[1].OwnerRole != "ssp_lead_tech" {
+		t.Errorf("install phase defaults = %+v", got.Phases[1])
+	}
+	if got.BOQItems[0].Unit != "pcs" {
+		t.Errorf("boq unit = %q", got.BOQItems[0].Unit)
+	}
+
+	bad := map[string]func(*models.ProjectTemplate){
+		"key":              func(t *models.ProjectTemplate) { t.ProjectType = "Solar Install" },
+		"no phases":        func(t *models.ProjectTemplate) { t.Phases = nil },
+		"duplicate phase":  func(t *models.ProjectTemplate) { t.Phases = append(t.Phases, t.Phases[0]) },
+		"default phase":    func(t *models.ProjectTemplate) { t.DefaultPhase = "commission" },
+		"checklist phase":  func(t *models.ProjectTemplate) { t.Checklist[0].PhaseType = "commission" },
+		"boq qty":          func(t *models.ProjectTemplate) { t.BOQItems[0].Qty = 0 },
+		"team role":        func(t *models.ProjectTemplate) { t.TeamRoles[0].Role = "manager" },
+		"team role phases": func(t *models.ProjectTemplate) { t.TeamRoles[0].Phases = []models.PhaseType{"ops"} },
+	}
+	for name, mutate := range bad {
+		tpl := solarTemplate()
+		mutate(&tpl)
+		if _, err := NormalizeProjectTemplate(tpl); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestTemplateChecklistKeepsOrder(t *testing.T) {
+	tpl, err := NormalizeProjectTemplate(solarTemplate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.ID = "ptt_1"
+	tpl.Checklist = append(tpl.Checklist, models.ProjectTemplateChecklistItem{PhaseType: "install", Title: "Meter reading"})
+	phase := models.ServicePhase{ID: "phase_1", PhaseType: "install"}
+	items := ChecklistItemsForPhase(TemplateChecklist(tpl), tpl.ProjectType, phase, time.Now())
+	if len(items) != 2 || items[0].Title != "Inverter photo" || items[1].Title != "Meter reading" {
+		t.Fatalf("items = %+v", items)
+	}
+	if items[0].TemplateID != "ptt_1#2" {
+		t.Errorf("template id = %q", items[0].TemplateID)
+	}
+}
+
+func TestBuiltinProjectTemplate(t *testing.T) {
+	tpl, ok := models.BuiltinProjectTemplate(models.ProjectTypeRepair)
+	if !ok || !tpl.Builtin || tpl.Version != 0 {
+		t.Fatalf("builtin = %+v, %v", tpl, ok)
+	}
+	if _, ok := tpl.Phase(models.PhaseDiagnosis); !ok {
+		t.Error("repair should include diagnosis")
+	}
+	if _, ok := models.BuiltinProjectTemplate("solar_install"); ok {
+		t.Error("solar_install is not built in")
+	}
+}
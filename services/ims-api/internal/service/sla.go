@@ -1,20 +1,236 @@
 package service
 
 import (
+	"sort"
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/models"
 )
 
-func SLADue(sev models.Severity, now time.Time) time.Time {
+// DefaultSLAResolution returns the built-in resolution window for a severity.
+// It applies when a tenant has no matching SLA policy.
+func DefaultSLAResolution(sev models.Severity) time.Duration {
 	switch sev {
 	case models.SeverityCritical:
-		return now.Add(4 * time.Hour)
+		return 4 * time.Hour
 	case models.SeverityHigh:
-		return now.Add(24 * time.Hour)
+		return 24 * time.Hour
 	case models.SeverityMedium:
-		return now.Add(48 * time.Hour)
+		return 48 * time.Hour
 	default:
-		return now.Add(72 * time.Hour)
+		return 72 * time.Hour
+	}
+}
+
+func SLADue(sev models.Severity, now time.Time) time.Time {
+	return now.Add(DefaultSLAResolution(sev))
+}
+
+// SLADueForPolicy computes the resolution deadline for an incident opened at now.
+// A nil policy falls back to SLADue; a nil calendar counts wall-clock time.
+func SLADueForPolicy(p *models.SLAPolicy, cal *models.SLACalendar, sev models.Severity, now time.Time) time.Time {
+	if p == nil || p.ResolutionMinutes <= 0 {
+		return SLADue(sev, now)
+	}
+	d := time.Duration(p.ResolutionMinutes) * time.Minute
+	if cal == nil {
+		return now.Add(d)
+	}
+	return AddBusinessTime(cal, now, d)
+}
+
+// SLAResponseDueForPolicy computes the response (acknowledgement) deadline.
+// Returns nil when the policy does not define a response target.
+func SLAResponseDueForPolicy(p *models.SLAPolicy, cal *models.SLACalendar, now time.Time) *time.Time {
+	if p == nil || p.ResponseMinutes <= 0 {
+		return nil
+	}
+	d := time.Duration(p.ResponseMinutes) * time.Minute
+	due := now.Add(d)
+	if cal != nil {
+		due = AddBusinessTime(cal, now, d)
+	}
+	return &due
+}
+
+// MatchSLAPolicy picks the most specific active policy matching the context.
+// Ties are broken by higher priority. Returns nil when nothing matches.
+func MatchSLAPolicy(policies []models.SLAPolicy, c models.SLAContext) *models.SLAPolicy {
+	var best *models.SLAPolicy
+	for i := range policies {
+		p := &policies[i]
+		if !p.Active || p.Severity != c.Severity {
+			continue
+		}
+		if p.Category != "" && p.Category != c.Category {
+			continue
+		}
+		if p.CountyID != "" && p.CountyID != c.CountyID {
+			continue
+		}
+		if p.SchoolLevel != "" && p.SchoolLevel != c.SchoolLevel {
+			continue
+		}
+		if best == nil ||
+			p.Specificity() > best.Specificity() ||
+			(p.Specificity() == best.Specificity() && p.Priority > best.Priority) {
+			best = p
+		}
+	}
+	return best
+}
+
+// maxCalendarScanDays bounds the business-hours walk so a calendar with no
+// usable windows can never loop forever.
+const maxCalendarScanDays = 730
+
+type clockWindow struct {
+	start, end time.Time
+}
+
+// businessWindows returns the working windows for the calendar day starting at day.
+func businessWindows(cal *models.SLACalendar, holidays map[string]bool, day time.Time) []clockWindow {
+	if holidays[day.Format("2006-01-02")] {
+		return nil
+	}
+	out := []clockWindow{}
+	for _, h := range cal.Hours {
+		if h.Weekday != day.Weekday() {
+			continue
+		}
+		s, ok1 := ParseClock(h.Start)
+		e, ok2 := ParseClock(h.End)
+		if !ok1 || !ok2 || e <= s {
+			continue
+		}
+		out = append(out, clockWindow{start: day.Add(s), end: day.Add(e)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].start.Before(out[j].start) })
+	return out
+}
+
+func calendarLocation(cal *models.SLACalendar) *time.Location {
+	if cal.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(cal.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func holidaySet(cal *models.SLACalendar) map[string]bool {
+	out := make(map[string]bool, len(cal.Holidays))
+	for _, h := range cal.Holidays {
+		out[h.Date] = true
+	}
+	return out
+}
+
+// AddBusinessTime advances start by d, counting only time inside the
+// calendar's business hours and skipping its holidays. A calendar without
+// any hours counts wall-clock time.
+func AddBusinessTime(cal *models.SLACalendar, start time.Time, d time.Duration) time.Time {
+	if len(cal.Hours) == 0 {
+		return start.Add(d)
+	}
+	loc := calendarLocation(cal)
+	holidays := holidaySet(cal)
+
+	y, m, dd := start.In(loc).Date()
+	day := time.Date(y, m, dd, 0, 0, 0, 0, loc)
+	cursor := start
+	remaining := d
+	for i := 0; i < maxCalendarScanDays; i++ {
+		for _, w := range businessWindows(cal, holidays, day) {
+			if !cursor.Before(w.end) {
+				continue
+			}
+			from := w.start
+			if cursor.After(from) {
+				from = cursor
+			}
+			avail := w.end.Sub(from)
+			if remaining <= avail {
+				return from.Add(remaining).UTC()
+			}
+			remaining -= avail
+			cursor = w.end
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return cursor.Add(remaining).UTC()
+}
+
+// ParseClock parses "HH:MM" into an offset from midnight. "24:00" marks end of day.
+func ParseClock(s string) (time.Duration, bool) {
+	if s == "24:00" {
+		return 24 * time.Hour, true
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// BusinessDuration returns how much SLA time elapses between from and to.
+// A nil calendar, or one without hours, counts wall-clock time.
+func BusinessDuration(cal *models.SLACalendar, from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	if cal == nil || len(cal.Hours) == 0 {
+		return to.Sub(from)
+	}
+	loc := calendarLocation(cal)
+	holidays := holidaySet(cal)
+
+	y, m, dd := from.In(loc).Date()
+	day := time.Date(y, m, dd, 0, 0, 0, 0, loc)
+	var total time.Duration
+	for i := 0; i < maxCalendarScanDays && day.Before(to); i++ {
+		for _, w := range businessWindows(cal, holidays, day) {
+			s, e := w.start, w.end
+			if s.Before(from) {
+				s = from
+			}
+			if e.After(to) {
+				e = to
+			}
+			if e.After(s) {
+				total += e.Sub(s)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// SLAElapsedPct returns how much of the SLA budget has been used, 0-100+.
+func SLAElapsedPct(budget, remaining time.Duration) int {
+	if budget <= 0 {
+		return 0
+	}
+	used := budget - remaining
+	if used <= 0 {
+		return 0
+	}
+	return int(used * 100 / budget)
+}
+
+// NextSLAWarning returns the highest threshold that elapsedPct has crossed and
+// that has not been warned yet, or 0 when no new warning is due.
+func NextSLAWarning(thresholds []int, warnedPct, elapsedPct int) int {
+	next := 0
+	for _, t := range thresholds {
+		if t <= 0 || t >= 100 {
+			continue
+		}
+		if t > warnedPct && t <= elapsedPct && t > next {
+			next = t
+		}
 	}
+	return next
 }
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func weekdayCalendar() *models.SLACalendar {
+	cal := &models.SLACalendar{Timezone: "UTC"}
+	for wd := time.Monday; wd <= time.Friday; wd++ {
+		cal.Hours = append(cal.Hours, models.BusinessHours{Weekday: wd, Start: "08:00", End: "17:00"})
+	}
+	return cal
+}
+
+func TestAddBusinessTime(t *testing.T) {
+	cal := weekdayCalendar()
+	cal.Holidays = []models.SLAHoliday{{Date: "2026-10-20", Name: "Mashujaa Day"}}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{
+			name:  "within same day",
+			start: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), // Wednesday
+			d:     4 * time.Hour,
+			want:  time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "friday evening rolls to monday",
+			start: time.Date(2026, 10, 16, 19, 0, 0, 0, time.UTC), // Friday after hours
+			d:     4 * time.Hour,
+			want:  time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "spans into next day",
+			start: time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC),
+			d:     4 * time.Hour,
+			want:  time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "skips holiday",
+			start: time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC), // Monday, Tuesday is a holiday
+			d:     2 * time.Hour,
+			want:  time.Date(2026, 10, 21, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AddBusinessTime(cal, tt.start, tt.d)
+			if !got.Equal(tt.want) {
+				t.Errorf("AddBusinessTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddBusinessTime_NoHoursIsWallClock(t *testing.T) {
+	start := time.Date(2026, 10, 16, 19, 0, 0, 0, time.UTC)
+	got := AddBusinessTime(&models.SLACalendar{}, start, 4*time.Hour)
+	if !got.Equal(start.Add(4 * time.Hour)) {
+		t.Errorf("AddBusinessTime() = %v, want wall-clock %v", got, start.Add(4*time.Hour))
+	}
+}
+
+func TestMatchSLAPolicy(t *testing.T) {
+	policies := []models.SLAPolicy{
+		{ID: "generic", Severity: models.SeverityCritical, ResolutionMinutes: 240, Active: true},
+		{ID: "county", Severity: models.SeverityCritical, CountyID: "c1", ResolutionMinutes: 480, Active: true},
+		{ID: "county-hw", Severity: models.SeverityCritical, CountyID: "c1", Category: "hardware", ResolutionMinutes: 600, Active: true},
+		{ID: "inactive", Severity: models.SeverityCritical, CountyID: "c1", Category: "hardware", SchoolLevel: "primary", Active: false},
+		{ID: "county-hi", Severity: models.SeverityCritical, CountyID: "c2", Priority: 10, Active: true},
+		{ID: "county-lo", Severity: models.SeverityCritical, CountyID: "c2", Priority: 1, Active: true},
+	}
+
+	tests := []struct {
+		name string
+		ctx  models.SLAContext
+		want string
+	}{
+		{"most specific wins", models.SLAContext{Severity: models.SeverityCritical, CountyID: "c1", Category: "hardware", SchoolLevel: "primary"}, "county-hw"},
+		{"county only", models.SLAContext{Severity: models.SeverityCritical, CountyID: "c1", Category: "software"}, "county"},
+		{"fallback to generic", models.SLAContext{Severity: models.SeverityCritical, CountyID: "c9"}, "generic"},
+		{"priority breaks ties", models.SLAContext{Severity: models.SeverityCritical, CountyID: "c2"}, "county-hi"},
+		{"no severity match", models.SLAContext{Severity: models.SeverityLow}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchSLAPolicy(policies, tt.ctx)
+			gotID := ""
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.want {
+				t.Errorf("MatchSLAPolicy() = %q, want %q", gotID, tt.want)
+			}
+		})
+	}
+}
+
+func TestSLADueForPolicy_NilPolicyUsesDefaults(t *testing.T) {
+	now := time.Date(2026, 10, 16, 19, 0, 0, 0, time.UTC)
+	got := SLADueForPolicy(nil, nil, models.SeverityCritical, now)
+	if !got.Equal(now.Add(4 * time.Hour)) {
+		t.Errorf("SLADueForPolicy() = %v, want %v", got, now.Add(4*time.Hour))
+	}
+}
+
+func TestBusinessDuration_RoundTrip(t *testing.T) {
+	cal := weekdayCalendar()
+	start := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC) // Friday
+	due := AddBusinessTime(cal, start, 5*time.Hour)
+
+	if got := BusinessDuration(cal, start, due); got != 5*time.Hour {
+		t.Errorf("BusinessDuration() = %v, want 5h", got)
+	}
+	// Weekend contributes nothing
+	sat := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	sun := time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)
+	if got := BusinessDuration(cal, sat, sun); got != 0 {
+		t.Errorf("BusinessDuration(weekend) = %v, want 0", got)
+	}
+}
+
+func TestNextSLAWarning(t *testing.T) {
+	thresholds := []int{50, 75, 90}
+	tests := []struct {
+		name      string
+		warned    int
+		elapsed   int
+		wantLevel int
+	}{
+		{"nothing yet", 0, 40, 0},
+		{"first threshold", 0, 55, 50},
+		{"skips to highest crossed", 0, 80, 75},
+		{"already warned", 75, 80, 0},
+		{"next after warned", 50, 91, 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextSLAWarning(thresholds, tt.warned, tt.elapsed); got != tt.wantLevel {
+				t.Errorf("NextSLAWarning() = %d, want %d", got, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"08:30", 8*time.Hour + 30*time.Minute, true},
+		{"9:00", 9 * time.Hour, true},
+		{"24:00", 24 * time.Hour, true},
+		{"25:00", 0, false},
+		{"noon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseClock(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseClock(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// ParseSeverity accepts a severity name in any case, plus the short forms
+// and typos MDM senders are known to use. Unknown values are reported as
+// not ok.
+func ParseSeverity(s string) (models.Severity, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "critical", "critial", "crit":
+		return models.SeverityCritical, true
+	case "high":
+		return models.SeverityHigh, true
+	case "medium", "med":
+		return models.SeverityMedium, true
+	case "low":
+		return models.SeverityLow, true
+	}
+	return "", false
+}
+
+// DefaultTelemetryRule applies to event types without a tenant rule:
+// incidents use the event's own severity, or low, and recover on a
+// "recovered" event.
+func DefaultTelemetryRule(eventType string) models.TelemetryRule {
+	return models.TelemetryRule{EventType: eventType, AutoResolve: true, CreateIncident: true}
+}
+
+// MatchTelemetryRule returns the rule for an event type: its own rule, else
+// the tenant's wildcard rule. ok is false when neither exists.
+func MatchTelemetryRule(rules []models.TelemetryRule, eventType string) (models.TelemetryRule, bool) {
+	var wildcard *models.TelemetryRule
+	for i := range rules {
+		switch rules[i].EventType {
+		case eventType:
+			return rules[i], true
+		case models.TelemetryRuleWildcard:
+			wildcard = &rules[i]
+		}
+	}
+	if wildcard != nil {
+		r := *wildcard
+		r.EventType = eventType
+		return r, true
+	}
+	return DefaultTelemetryRule(eventType), false
+}
+
+// TelemetrySeverity picks the incident severity for an event: the rule's
+// when a tenant rule matched, else the severity the sender reported, else
+// low.
+func TelemetrySeverity(rule models.TelemetryRule, matched bool, reported string) models.Severity {
+	if matched {
+		if sev, ok := ParseSeverity(string(rule.Severity)); ok {
+			return sev
+		}
+	}
+	if sev, ok := ParseSeverity(reported); ok {
+		return sev
+	}
+	return models.SeverityLow
+}
+
+// RecoveredTypes lists the problem types a recovery event clears. An event
+// with state "recovered" clears its own type; any other event clears the
+// types whose rule names it as their recovery event.
+func RecoveredTypes(rules []models.TelemetryRule, eventType string, recovered bool) []string {
+	if recovered {
+		return []string{eventType}
+	}
+	var out []string
+	for _, r := range rules {
+		if r.RecoveryEventType == eventType && r.EventType != models.TelemetryRuleWildcard {
+			out = append(out, r.EventType)
+		}
+	}
+	return out
+}
+
+// TelemetryDedupeWindow returns the rule's window, or def.
+func TelemetryDedupeWindow(rule models.TelemetryRule, def time.Duration) time.Duration {
+	if rule.DedupeWindowSeconds != nil && *rule.DedupeWindowSeconds >= 0 {
+		return time.Duration(*rule.DedupeWindowSeconds) * time.Second
+	}
+	return def
+}
+
+// DecideTelemetryProblem decides what a problem event does, given the open
+// correlation for its device and type (nil when none). Events less than
+// window after the last counted occurrence, or older than it, are
+// duplicates.
+func DecideTelemetryProblem(rule models.TelemetryRule, open *models.TelemetryCorrelation, occurredAt time.Time, window time.Duration) models.TelemetryOutcome {
+	switch {
+	case !rule.CreateIncident:
+		return models.TelemetryIgnored
+	case open == nil:
+		return models.TelemetryOpened
+	case occurredAt.Before(open.LastOccurredAt.Add(window)):
+		return models.TelemetryDuplicate
+	default:
+		return models.TelemetryCorrelated
+	}
+}
+
+// DecideTelemetryRecovery decides what a recovery does to the open
+// correlation of the problem type it clears.
+func DecideTelemetryRecovery(rule models.TelemetryRule, open *models.TelemetryCorrelation) models.TelemetryOutcome {
+	switch {
+	case open == nil:
+		return models.TelemetryUnmatched
+	case !rule.AutoResolve:
+		return models.TelemetryIgnored
+	default:
+		return models.TelemetryResolved
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestMatchTelemetryRule(t *testing.T) {
+	rules := []models.TelemetryRule{
+		{EventType: "*", Severity: models.SeverityMedium, CreateIncident: true},
+		{EventType: "offline", Severity: models.SeverityHigh, CreateIncident: true},
+	}
+	if r, ok := MatchTelemetryRule(rules, "offline"); !ok || r.Severity != models.SeverityHigh {
+		t.Errorf("offline matched %+v, %v", r, ok)
+	}
+	if r, ok := MatchTelemetryRule(rules, "crash"); !ok || r.Severity != models.SeverityMedium || r.EventType != "crash" {
+		t.Errorf("crash matched %+v, %v", r, ok)
+	}
+	if r, ok := MatchTelemetryRule(nil, "crash"); ok || !r.CreateIncident || !r.AutoResolve {
+		t.Errorf("default rule = %+v, %v", r, ok)
+	}
+}
+
+func TestTelemetrySeverity(t *testing.T) {
+	rule := models.TelemetryRule{Severity: models.SeverityCritical}
+	if got := TelemetrySeverity(rule, true, "low"); got != models.SeverityCritical {
+		t.Errorf("rule severity = %s", got)
+	}
+	if got := TelemetrySeverity(rule, false, "CRIT"); got != models.SeverityCritical {
+		t.Errorf("reported severity = %s", got)
+	}
+	if got := TelemetrySeverity(rule, false, "bogus"); got != models.SeverityLow {
+		t.Errorf("fallback severity = %s", got)
+	}
+}
+
+func TestRecoveredTypes(t *testing.T) {
+	rules := []models.TelemetryRule{
+		{EventType: "offline", RecoveryEventType: "online"},
+		{EventType: "agent_down", RecoveryEventType: "online"},
+		{EventType: "*", RecoveryEventType: "online"},
+	}
+	if got := RecoveredTypes(rules, "online", false); len(got) != 2 {
+		t.Errorf("online recovers %v", got)
+	}
+	if got := RecoveredTypes(rules, "crash", true); len(got) != 1 || got[0] != "crash" {
+		t.Errorf("recovered crash clears %v", got)
+	}
+	if got := RecoveredTypes(rules, "crash", false); len(got) != 0 {
+		t.Errorf("crash clears %v", got)
+	}
+}
+
+func TestDecideTelemetryProblem(t *testing.T) {
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	rule := DefaultTelemetryRule("offline")
+	open := &models.TelemetryCorrelation{LastOccurredAt: now}
+	window := 5 * time.Minute
+
+	tests := []struct {
+		name string
+		rule models.TelemetryRule
+		open *models.TelemetryCorrelation
+		at   time.Time
+		want models.TelemetryOutcome
+	}{
+		{"first occurrence", rule, nil, now, models.TelemetryOpened},
+		{"within window", rule, open, now.Add(4 * time.Minute), models.TelemetryDuplicate},
+		{"out of order", rule, open, now.Add(-time.Hour), models.TelemetryDuplicate},
+		{"after window", rule, open, now.Add(5 * time.Minute), models.TelemetryCorrelated},
+		{"no incident rule", models.TelemetryRule{EventType: "heartbeat"}, nil, now, models.TelemetryIgnored},
+	}
+	for _, tt := range tests {
+		if got := DecideTelemetryProblem(tt.rule, tt.open, tt.at, window); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecideTelemetryRecovery(t *testing.T) {
+	open := &models.TelemetryCorrelation{}
+	if got := DecideTelemetryRecovery(DefaultTelemetryRule("offline"), open); got != models.TelemetryResolved {
+		t.Errorf("got %s", got)
+	}
+	if got := DecideTelemetryRecovery(DefaultTelemetryRule("offline"), nil); got != models.TelemetryUnmatched {
+		t.Errorf("got %s", got)
+	}
+	if got := DecideTelemetryRecovery(models.TelemetryRule{CreateIncident: true}, open); got != models.TelemetryIgnored {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestTelemetryDedupeWindow(t *testing.T) {
+	zero := 0
+	if got := TelemetryDedupeWindow(models.TelemetryRule{DedupeWindowSeconds: &zero}, time.Minute); got != 0 {
+		t.Errorf("override = %v", got)
+	}
+	if got := TelemetryDedupeWindow(models.TelemetryRule{}, time.Minute); got != time.Minute {
+		t.Errorf("default = %v", got)
+	}
+}
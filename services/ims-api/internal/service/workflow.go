@@ -0,0 +1,292 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edvirons/ssp/ims/internal/auth"
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// Transition error codes, shared by single-item and bulk status updates.
+const (
+	TransitionInvalid   = "invalid_transition"
+	TransitionForbidden = "forbidden"
+	TransitionGuard     = "guard_failed"
+)
+
+// TransitionError explains why a workflow rejected a status change.
+type TransitionError struct {
+	Code    string
+	Message string
+}
+
+func (e *TransitionError) Error() string { return e.Message }
+
+// GuardFunc evaluates a named guard for the record being transitioned.
+type GuardFunc func(g models.WorkflowGuard) (bool, error)
+
+var guardsByEntity = map[models.WorkflowEntity][]models.WorkflowGuard{
+	models.WorkflowIncident:  {},
+	models.WorkflowWorkOrder: {models.GuardDeliverablesApproved, models.GuardApprovalGranted, models.GuardAssigned},
+}
+
+func wfState(key, label string) models.WorkflowState {
+	return models.WorkflowState{Key: key, Label: label}
+}
+
+func wfMove(from, to string) models.WorkflowTransition {
+	return models.WorkflowTransition{From: from, To: to}
+}
+
+// DefaultIncidentWorkflow is the built-in incident state machine used when a
+// tenant has not configured its own.
+func DefaultIncidentWorkflow() models.Workflow {
+	newS := wfState(string(models.IncidentNew), "New")
+	newS.Initial = true
+	awaiting := wfState(string(models.IncidentAwaiting), "Awaiting customer")
+	awaiting.PausesSLA = true
+	closed := wfState(string(models.IncidentClosed), "Closed")
+	closed.Terminal = true
+
+	const (
+		n  = string(models.IncidentNew)
+		a  = string(models.IncidentAcknowledged)
+		ip = string(models.IncidentInProgress)
+		e  = string(models.IncidentEscalated)
+		aw = string(models.IncidentAwaiting)
+		r  = string(models.IncidentResolved)
+		c  = string(models.IncidentClosed)
+	)
+	return models.Workflow{
+		Entity:    models.WorkflowIncident,
+		Name:      "Default incident workflow",
+		Version:   1,
+		IsDefault: true,
+		States: []models.WorkflowState{
+			newS,
+			wfState(a, "Acknowledged"),
+			wfState(ip, "In progress"),
+			wfState(e, "Escalated"),
+			awaiting,
+			wfState(r, "Resolved"),
+			closed,
+		},
+		Transitions: []models.WorkflowTransition{
+			wfMove(n, a), wfMove(n, e),
+			wfMove(a, ip), wfMove(a, e), wfMove(a, aw),
+			wfMove(ip, r), wfMove(ip, e), wfMove(ip, aw),
+			wfMove(e, ip), wfMove(e, r),
+			wfMove(aw, ip), wfMove(aw, r),
+			wfMove(r, c),
+		},
+	}
+}
+
+// DefaultWorkOrderWorkflow is the built-in work order state machine used when
+// a tenant has not configured its own. Skipping QA is not allowed in bulk.
+func DefaultWorkOrderWorkflow() models.Workflow {
+	draft := wfState(string(models.WorkOrderDraft), "Draft")
+	draft.Initial = true
+	approved := wfState(string(models.WorkOrderApproved), "Approved")
+	approved.Terminal = true
+
+	const (
+		d  = string(models.WorkOrderDraft)
+		as = string(models.WorkOrderAssigned)
+		ir = string(models.WorkOrderInRepair)
+		qa = string(models.WorkOrderQA)
+		c  = string(models.WorkOrderCompleted)
+		ap = string(models.WorkOrderApproved)
+	)
+	skipQA := wfMove(ir, c)
+	noBulk := false
+	skipQA.Bulk = &noBulk
+
+	return models.Workflow{
+		Entity:    models.WorkflowWorkOrder,
+		Name:      "Default work order workflow",
+		Version:   1,
+		IsDefault: true,
+		States: []models.WorkflowState{
+			draft,
+			wfState(as, "Assigned"),
+			wfState(ir, "In repair"),
+			wfState(qa, "QA"),
+			wfState(c, "Completed"),
+			approved,
+		},
+		Transitions: []models.WorkflowTransition{
+			wfMove(d, as),
+			wfMove(as, ir),
+			wfMove(ir, qa), skipQA,
+			wfMove(qa, c),
+			wfMove(c, ap),
+		},
+	}
+}
+
+// DefaultWorkflow returns the built-in workflow for an entity.
+func DefaultWorkflow(entity models.WorkflowEntity) (models.Workflow, bool) {
+	switch entity {
+	case models.WorkflowIncident:
+		return DefaultIncidentWorkflow(), true
+	case models.WorkflowWorkOrder:
+		return DefaultWorkOrderWorkflow(), true
+	}
+	return models.Workflow{}, false
+}
+
+// ValidateWorkflow checks a tenant workflow definition before it is saved.
+func ValidateWorkflow(wf models.Workflow) error {
+	known, ok := guardsByEntity[wf.Entity]
+	if !ok {
+		return fmt.Errorf("unknown workflow entity %q", wf.Entity)
+	}
+	if len(wf.States) == 0 {
+		return errors.New("at least one state is required")
+	}
+
+	states := map[string]bool{}
+	initial := 0
+	for _, s := range wf.States {
+		if strings.TrimSpace(s.Key) == "" {
+			return errors.New("state key is required")
+		}
+		if states[s.Key] {
+			return fmt.Errorf("duplicate state %q", s.Key)
+		}
+		states[s.Key] = true
+		if s.Initial {
+			initial++
+		}
+		if s.PausesSLA && wf.Entity != models.WorkflowIncident {
+			return fmt.Errorf("state %q: pausesSla only applies to incidents", s.Key)
+		}
+	}
+	if initial != 1 {
+		return errors.New("exactly one initial state is required")
+	}
+
+	seen := map[string]bool{}
+	for _, t := range wf.Transitions {
+		if !states[t.From] || !states[t.To] {
+			return fmt.Errorf("transition %s -> %s references an unknown state", t.From, t.To)
+		}
+		if t.From == t.To {
+			return fmt.Errorf("transition %s -> %s must change state", t.From, t.To)
+		}
+		key := t.From + "->" + t.To
+		if seen[key] {
+			return fmt.Errorf("duplicate transition %s -> %s", t.From, t.To)
+		}
+		seen[key] = true
+		for _, g := range t.Guards {
+			if !containsGuard(known, g) {
+				return fmt.Errorf("transition %s -> %s: unknown guard %q", t.From, t.To, g)
+			}
+		}
+		for _, role := range t.Roles {
+			if _, ok := auth.RolePermissions[role]; !ok {
+				return fmt.Errorf("transition %s -> %s: unknown role %q", t.From, t.To, role)
+			}
+		}
+	}
+
+	// Built-in states are referenced by code (SLA, reports), so tenants may
+	// add states but not drop these.
+	def, _ := DefaultWorkflow(wf.Entity)
+	for _, s := range def.States {
+		if !states[s.Key] {
+			return fmt.Errorf("built-in state %q cannot be removed", s.Key)
+		}
+	}
+	return nil
+}
+
+func containsGuard(gs []models.WorkflowGuard, g models.WorkflowGuard) bool {
+	for _, x := range gs {
+		if x == g {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition enforces a workflow for a status change by a user with the
+// given roles. Guards are evaluated last and only when everything else passes.
+func CheckTransition(wf models.Workflow, from, to string, roles []string, bulk bool, guard GuardFunc) error {
+	t, ok := wf.Transition(from, to)
+	if !ok || (bulk && !t.AllowsBulk()) {
+		return &TransitionError{Code: TransitionInvalid, Message: "invalid status transition from " + from + " to " + to}
+	}
+
+	if len(t.Roles) > 0 && !hasAnyRole(roles, t.Roles) {
+		return &TransitionError{Code: TransitionForbidden, Message: "transition to " + to + " requires role " + strings.Join(t.Roles, " or ")}
+	}
+	for _, p := range t.Permissions {
+		if !auth.UserHasPermission(roles, p) {
+			return &TransitionError{Code: TransitionForbidden, Message: "transition to " + to + " requires permission " + p}
+		}
+	}
+
+	for _, g := range t.Guards {
+		if guard == nil {
+			return &TransitionError{Code: TransitionGuard, Message: "guard " + string(g) + " cannot be evaluated"}
+		}
+		passed, err := guard(g)
+		if err != nil {
+			return err
+		}
+		if !passed {
+			return &TransitionError{Code: TransitionGuard, Message: "transition to " + to + " blocked: " + string(g)}
+		}
+	}
+	return nil
+}
+
+// TransitionPath returns the shortest run of states that takes an entity
+// from one status to another, each step allowed by CheckTransition for the
+// given roles. Guarded steps are never taken. It returns nil when there is
+// no such path.
+func TransitionPath(wf models.Workflow, from, to string, roles []string) []string {
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == to && cur != from {
+			break
+		}
+		for _, t := range wf.Next(cur) {
+			if _, seen := prev[t.To]; seen {
+				continue
+			}
+			if CheckTransition(wf, cur, t.To, roles, false, nil) != nil {
+				continue
+			}
+			prev[t.To] = cur
+			queue = append(queue, t.To)
+		}
+	}
+	if _, ok := prev[to]; !ok || to == from {
+		return nil
+	}
+	var path []string
+	for s := to; s != from; s = prev[s] {
+		path = append([]string{s}, path...)
+	}
+	return path
+}
+
+func hasAnyRole(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w || h == "ssp_admin" {
+				return true
+			}
+		}
+	}
+	return false
+}
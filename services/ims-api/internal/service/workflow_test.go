@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+func TestDefaultWorkflowsAreValid(t *testing.T) {
+	for _, wf := range []models.Workflow{DefaultIncidentWorkflow(), DefaultWorkOrderWorkflow()} {
+		if err := ValidateWorkflow(wf); err != nil {
+			t.Errorf("ValidateWorkflow(%s) = %v", wf.Entity, err)
+		}
+	}
+}
+
+func TestValidateWorkflow(t *testing.T) {
+	partsPending := DefaultWorkOrderWorkflow()
+	partsPending.States = append(partsPending.States, models.WorkflowState{Key: "parts_pending", Label: "Parts pending"})
+	partsPending.Transitions = append(partsPending.Transitions,
+		models.WorkflowTransition{From: "in_repair", To: "parts_pending"},
+		models.WorkflowTransition{From: "parts_pending", To: "in_repair"},
+	)
+
+	unknownGuard := DefaultWorkOrderWorkflow()
+	unknownGuard.Transitions[0].Guards = []models.WorkflowGuard{"moon_phase"}
+
+	droppedState := DefaultWorkOrderWorkflow()
+	droppedState.States = droppedState.States[:len(droppedState.States)-1]
+
+	badRef := DefaultIncidentWorkflow()
+	badRef.Transitions = append(badRef.Transitions, models.WorkflowTransition{From: "new", To: "nowhere"})
+
+	tests := []struct {
+		name    string
+		wf      models.Workflow
+		wantErr bool
+	}{
+		{"parts pending hold", partsPending, false},
+		{"unknown guard", unknownGuard, true},
+		{"built-in state removed", droppedState, true},
+		{"unknown target state", badRef, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateWorkflow(tt.wf); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWorkflow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	wf := DefaultWorkOrderWorkflow()
+	for i := range wf.Transitions {
+		if wf.Transitions[i].To == "approved" {
+			wf.Transitions[i].Roles = []string{"ssp_lead_tech"}
+			wf.Transitions[i].Guards = []models.WorkflowGuard{models.GuardDeliverablesApproved}
+		}
+	}
+	pass := func(models.WorkflowGuard) (bool, error) { return true, nil }
+	fail := func(models.WorkflowGuard) (bool, error) { return false, nil }
+
+	tests := []struct {
+		name     string
+		from, to string
+		roles    []string
+		bulk     bool
+		guard    GuardFunc
+		wantCode string
+	}{
+		{"allowed", "draft", "assigned", []string{"ssp_field_tech"}, false, nil, ""},
+		{"not in graph", "draft", "completed", []string{"ssp_field_tech"}, false, nil, TransitionInvalid},
+		{"skip qa single", "in_repair", "completed", nil, false, nil, ""},
+		{"skip qa bulk", "in_repair", "completed", nil, true, nil, TransitionInvalid},
+		{"wrong role", "completed", "approved", []string{"ssp_field_tech"}, false, pass, TransitionForbidden},
+		{"admin bypasses role", "completed", "approved", []string{"ssp_admin"}, false, pass, ""},
+		{"guard blocks", "completed", "approved", []string{"ssp_lead_tech"}, false, fail, TransitionGuard},
+		{"guard passes", "completed", "approved", []string{"ssp_lead_tech"}, false, pass, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTransition(wf, tt.from, tt.to, tt.roles, tt.bulk, tt.guard)
+			code := ""
+			var te *TransitionError
+			if errors.As(err, &te) {
+				code = te.Code
+			} else if err != nil {
+				t.Fatalf("unexpected error type: %v", err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("CheckTransition() code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTransitionPath(t *testing.T) {
+	wf := DefaultIncidentWorkflow()
+	guarded := DefaultIncidentWorkflow()
+	for i := range guarded.Transitions {
+		if guarded.Transitions[i].To == "closed" {
+			guarded.Transitions[i].Guards = []models.WorkflowGuard{models.GuardAssigned}
+		}
+	}
+	admin := []string{"ssp_admin"}
+
+	tests := []struct {
+		name     string
+		wf       models.Workflow
+		from, to string
+		want     string
+	}{
+		{"one step", wf, "resolved", "closed", "closed"},
+		{"shortest route", wf, "new", "closed", "escalated,resolved,closed"},
+		{"paused", wf, "awaiting_customer", "closed", "resolved,closed"},
+		{"already there", wf, "closed", "closed", ""},
+		{"guard never taken", guarded, "resolved", "closed", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(TransitionPath(tt.wf, tt.from, tt.to, admin), ",")
+			if got != tt.want {
+				t.Errorf("TransitionPath(%s, %s) = %q, want %q", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
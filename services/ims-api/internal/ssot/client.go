@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
 )
 
 type Client struct {
@@ -31,20 +32,13 @@ func (c *Client) WithTenant(tenantID string) *Client {
 	}
 }
 
-type SchoolsPage struct {
-	Items []models.SchoolSnapshot `json:"items"`
-	Next  string                  `json:"nextCursor"`
-}
-
-type DevicesPage struct {
-	Items []models.DeviceSnapshot `json:"items"`
-	Next  string                  `json:"nextCursor"`
-}
-
-type PartsPage struct {
-	Items []models.PartSnapshot `json:"items"`
-	Next  string                `json:"nextCursor"`
-}
+// Pages come from the SSOT change feeds (GET /v1/changes/{resource}). Deleted
+// lists tombstones for rows removed since the requested position.
+type (
+	SchoolsPage = changefeed.Page[models.SchoolSnapshot]
+	DevicesPage = changefeed.Page[models.DeviceSnapshot]
+	PartsPage   = changefeed.Page[models.PartSnapshot]
+)
 
 func (c *Client) fetch(path string, q url.Values, out any) error {
 	if c.BaseURL == "" {
@@ -79,7 +73,7 @@ func (c *Client) ListSchools(updatedSince time.Time, cursor string, limit int) (
 	var out SchoolsPage
 	q := url.Values{}
 	if !updatedSince.IsZero() {
-		q.Set("updatedSince", updatedSince.Format(time.RFC3339))
+		q.Set("updatedSince", updatedSince.UTC().Format(time.RFC3339Nano))
 	}
 	if cursor != "" {
 		q.Set("cursor", cursor)
@@ -87,7 +81,7 @@ func (c *Client) ListSchools(updatedSince time.Time, cursor string, limit int) (
 	if limit > 0 {
 		q.Set("limit", fmt.Sprintf("%d", limit))
 	}
-	err := c.fetch("/v1/schools", q, &out)
+	err := c.fetch("/v1/changes/schools", q, &out)
 	return out, err
 }
 
@@ -95,7 +89,7 @@ func (c *Client) ListDevices(updatedSince time.Time, cursor string, limit int) (
 	var out DevicesPage
 	q := url.Values{}
 	if !updatedSince.IsZero() {
-		q.Set("updatedSince", updatedSince.Format(time.RFC3339))
+		q.Set("updatedSince", updatedSince.UTC().Format(time.RFC3339Nano))
 	}
 	if cursor != "" {
 		q.Set("cursor", cursor)
@@ -103,7 +97,7 @@ func (c *Client) ListDevices(updatedSince time.Time, cursor string, limit int) (
 	if limit > 0 {
 		q.Set("limit", fmt.Sprintf("%d", limit))
 	}
-	err := c.fetch("/v1/devices", q, &out)
+	err := c.fetch("/v1/changes/devices", q, &out)
 	return out, err
 }
 
@@ -111,7 +105,7 @@ func (c *Client) ListParts(updatedSince time.Time, cursor string, limit int) (Pa
 	var out PartsPage
 	q := url.Values{}
 	if !updatedSince.IsZero() {
-		q.Set("updatedSince", updatedSince.Format(time.RFC3339))
+		q.Set("updatedSince", updatedSince.UTC().Format(time.RFC3339Nano))
 	}
 	if cursor != "" {
 		q.Set("cursor", cursor)
@@ -119,7 +113,7 @@ func (c *Client) ListParts(updatedSince time.Time, cursor string, limit int) (Pa
 	if limit > 0 {
 		q.Set("limit", fmt.Sprintf("%d", limit))
 	}
-	err := c.fetch("/v1/parts", q, &out)
+	err := c.fetch("/v1/changes/parts", q, &out)
 	return out, err
 }
 
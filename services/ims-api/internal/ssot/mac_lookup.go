@@ -0,0 +1,72 @@
+package ssot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// LookupMAC asks ssot-devices which device owns a MAC address
+// (GET /v1/lookup/mac/{mac}). found is false on 404. It implements
+// service.MACDirectory.
+func (c *Client) LookupMAC(ctx context.Context, tenantID, mac string) (models.DeviceNetworkSnapshot, bool, error) {
+	if c.BaseURL == "" {
+		return models.DeviceNetworkSnapshot{}, false, fmt.Errorf("base url not set")
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return models.DeviceNetworkSnapshot{}, false, err
+	}
+	u.Path = "/v1/lookup/mac/" + url.PathEscape(mac)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return models.DeviceNetworkSnapshot{}, false, err
+	}
+	req.Header.Set("X-Tenant-Id", tenantID)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return models.DeviceNetworkSnapshot{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return models.DeviceNetworkSnapshot{}, false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return models.DeviceNetworkSnapshot{}, false, fmt.Errorf("ssot mac lookup failed: %s", resp.Status)
+	}
+	var out struct {
+		NetworkIdentity struct {
+			DeviceID      string    `json:"deviceId"`
+			MACAddress    string    `json:"macAddress"`
+			InterfaceType string    `json:"interfaceType"`
+			IsPrimary     bool      `json:"isPrimary"`
+			LastSeenAt    time.Time `json:"lastSeenAt"`
+		} `json:"networkIdentity"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return models.DeviceNetworkSnapshot{}, false, err
+	}
+	n := out.NetworkIdentity
+	if n.DeviceID == "" {
+		return models.DeviceNetworkSnapshot{}, false, nil
+	}
+	snap := models.DeviceNetworkSnapshot{
+		TenantID:      tenantID,
+		DeviceID:      n.DeviceID,
+		MACAddress:    n.MACAddress,
+		InterfaceType: n.InterfaceType,
+		IsPrimary:     n.IsPrimary,
+	}
+	if !n.LastSeenAt.IsZero() {
+		snap.LastSeenAt = &n.LastSeenAt
+	}
+	return snap, true, nil
+}
@@ -0,0 +1,92 @@
+package ssot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+)
+
+// RejectedError is a registration ssot-devices refused. Retrying the same
+// request will not help; the registration has to be corrected.
+type RejectedError struct {
+	Code    string
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return "ssot rejected registration: " + e.Code + ": " + e.Message
+}
+
+// RejectionCode implements service.RegistrationRejection.
+func (e *RejectedError) RejectionCode() string { return e.Code }
+
+// RejectionMessage implements service.RegistrationRejection.
+func (e *RejectedError) RejectionMessage() string { return e.Message }
+
+// RegisterDevice forwards a school registration to ssot-devices
+// (POST /v1/devices/registrations) and returns the confirmed device in
+// change feed form. The registration ID makes the call safe to repeat.
+// 4xx responses are returned as *RejectedError; anything else is transient.
+func (c *Client) RegisterDevice(ctx context.Context, reg models.DeviceRegistration) (models.DeviceSnapshot, error) {
+	if c.BaseURL == "" {
+		return models.DeviceSnapshot{}, fmt.Errorf("base url not set")
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return models.DeviceSnapshot{}, err
+	}
+	u.Path = "/v1/devices/registrations"
+
+	body, err := json.Marshal(map[string]any{
+		"registrationId": reg.ID,
+		"schoolId":       reg.SchoolID,
+		"serial":         reg.Serial,
+		"assetTag":       reg.AssetTag,
+		"make":           reg.Make,
+		"model":          reg.Model,
+		"requestedBy":    reg.RequestedBy,
+	})
+	if err != nil {
+		return models.DeviceSnapshot{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return models.DeviceSnapshot{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-Id", reg.TenantID)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return models.DeviceSnapshot{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		var e struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		if e.Code == "" {
+			e.Code = "invalid"
+		}
+		return models.DeviceSnapshot{}, &RejectedError{Code: e.Code, Message: e.Error}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return models.DeviceSnapshot{}, fmt.Errorf("ssot register failed: %s", resp.Status)
+	}
+	var d models.DeviceSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return models.DeviceSnapshot{}, err
+	}
+	if d.DeviceID == "" {
+		return models.DeviceSnapshot{}, fmt.Errorf("ssot register returned no device id")
+	}
+	return d, nil
+}
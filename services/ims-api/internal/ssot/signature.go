@@ -0,0 +1,102 @@
+package ssot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook deliveries are signed with a per-tenant secret:
+//
+//	X-SSOT-Signature: v1=hex(HMAC-SHA256(secret, timestamp + "." + nonce + "." + body))
+//
+// The timestamp is unix seconds and the nonce is unique per delivery.
+const (
+	HeaderSignature = "X-SSOT-Signature"
+	HeaderTimestamp = "X-SSOT-Timestamp"
+	HeaderNonce     = "X-SSOT-Nonce"
+	HeaderKeyID     = "X-SSOT-Key-Id" // optional; selects the secret when several are active
+
+	signatureVersion = "v1"
+	minNonceLen      = 16
+	maxNonceLen      = 128
+)
+
+var (
+	ErrMissingSignature = errors.New("missing signature headers")
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	ErrStaleTimestamp   = errors.New("timestamp outside tolerance")
+	ErrInvalidNonce     = errors.New("invalid nonce")
+	ErrBadSignature     = errors.New("signature mismatch")
+)
+
+// Signature is the signing metadata sent with a webhook delivery.
+type Signature struct {
+	Timestamp time.Time
+	Nonce     string
+	KeyID     string
+	Value     string // hex digest without the version prefix
+}
+
+// Sign returns the X-SSOT-Signature header value for body.
+func Sign(secret string, ts time.Time, nonce string, body []byte) string {
+	return signatureVersion + "=" + hex.EncodeToString(digest(secret, ts.Unix(), nonce, body))
+}
+
+// ParseSignature reads the signing headers from a request.
+func ParseSignature(h http.Header) (Signature, error) {
+	raw := strings.TrimSpace(h.Get(HeaderSignature))
+	tsRaw := strings.TrimSpace(h.Get(HeaderTimestamp))
+	nonce := strings.TrimSpace(h.Get(HeaderNonce))
+	if raw == "" || tsRaw == "" || nonce == "" {
+		return Signature{}, ErrMissingSignature
+	}
+	secs, err := strconv.ParseInt(tsRaw, 10, 64)
+	if err != nil {
+		return Signature{}, ErrInvalidTimestamp
+	}
+	if len(nonce) < minNonceLen || len(nonce) > maxNonceLen {
+		return Signature{}, ErrInvalidNonce
+	}
+	version, value, ok := strings.Cut(raw, "=")
+	if !ok || version != signatureVersion || value == "" {
+		return Signature{}, ErrBadSignature
+	}
+	return Signature{
+		Timestamp: time.Unix(secs, 0).UTC(),
+		Nonce:     nonce,
+		KeyID:     strings.TrimSpace(h.Get(HeaderKeyID)),
+		Value:     value,
+	}, nil
+}
+
+// Verify checks that the signature was made with secret over body and that
+// its timestamp is within tolerance of now.
+func (s Signature) Verify(secret string, body []byte, now time.Time, tolerance time.Duration) error {
+	if d := now.Sub(s.Timestamp); d > tolerance || d < -tolerance {
+		return ErrStaleTimestamp
+	}
+	got, err := hex.DecodeString(s.Value)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if !hmac.Equal(got, digest(secret, s.Timestamp.Unix(), s.Nonce, body)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+func digest(secret string, ts int64, nonce string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,79 @@
+package ssot
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signedHeader(secret string, ts time.Time, nonce string, body []byte) http.Header {
+	h := http.Header{}
+	h.Set(HeaderSignature, Sign(secret, ts, nonce, body))
+	h.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	h.Set(HeaderNonce, nonce)
+	return h
+}
+
+func TestSignatureVerify(t *testing.T) {
+	const secret = "whsec_test"
+	const nonce = "0123456789abcdef"
+	body := []byte(`{"items":[{"schoolId":"sch_1"}]}`)
+	now := time.Unix(1_700_000_000, 0).UTC()
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		secret  string
+		body    []byte
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", signedHeader(secret, now, nonce, body), secret, body, now, nil},
+		{"within tolerance", signedHeader(secret, now.Add(-4*time.Minute), nonce, body), secret, body, now, nil},
+		{"wrong secret", signedHeader("other", now, nonce, body), secret, body, now, ErrBadSignature},
+		{"tampered body", signedHeader(secret, now, nonce, body), secret, []byte(`{"items":[]}`), now, ErrBadSignature},
+		{"stale", signedHeader(secret, now.Add(-10*time.Minute), nonce, body), secret, body, now, ErrStaleTimestamp},
+		{"future", signedHeader(secret, now.Add(10*time.Minute), nonce, body), secret, body, now, ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := ParseSignature(tt.header)
+			if err != nil {
+				t.Fatalf("ParseSignature: %v", err)
+			}
+			if err := sig.Verify(tt.secret, tt.body, tt.now, 5*time.Minute); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSignatureRejectsMalformedHeaders(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0).UTC()
+	valid := func() http.Header { return signedHeader("s", now, "0123456789abcdef", nil) }
+
+	tests := []struct {
+		name    string
+		mutate  func(http.Header)
+		wantErr error
+	}{
+		{"missing signature", func(h http.Header) { h.Del(HeaderSignature) }, ErrMissingSignature},
+		{"missing nonce", func(h http.Header) { h.Del(HeaderNonce) }, ErrMissingSignature},
+		{"bad timestamp", func(h http.Header) { h.Set(HeaderTimestamp, "yesterday") }, ErrInvalidTimestamp},
+		{"short nonce", func(h http.Header) { h.Set(HeaderNonce, "abc") }, ErrInvalidNonce},
+		{"unknown version", func(h http.Header) { h.Set(HeaderSignature, "v0=abcd") }, ErrBadSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := valid()
+			tt.mutate(h)
+			if _, err := ParseSignature(h); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseSignature() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/edvirons/ssp/ims/internal/models"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
@@ -20,6 +21,10 @@ type Config struct {
 	DevicesURL   string
 	PartsURL     string
 	FetchTimeout time.Duration
+
+	// OnDeviceTransitions, when set, receives the lifecycle transitions
+	// carried by ssot.devices.changed events.
+	OnDeviceTransitions func(ctx context.Context, tenantID string, transitions []models.DeviceLifecycleTransition)
 }
 
 func ConfigFromEnv() Config {
@@ -64,6 +69,11 @@ func Start(ctx context.Context, log *zap.Logger, db *pgxpool.Pool, c Config) err
 					log.Error("ssot snapshot fetch failed", zap.String("kind", kind), zap.String("tenantId", tenant), zap.Error(err))
 				}
 			}()
+			if kind == "devices" && c.OnDeviceTransitions != nil {
+				if ts := transitionsFromEvent(m.Data); len(ts) > 0 {
+					go c.OnDeviceTransitions(ctx, tenant, ts)
+				}
+			}
 		})
 		return err
 	}
@@ -150,6 +160,16 @@ func tenantFromEvent(b []byte) string {
 	return ""
 }
 
+func transitionsFromEvent(b []byte) []models.DeviceLifecycleTransition {
+	var ev struct {
+		Transitions []models.DeviceLifecycleTransition `json:"transitions"`
+	}
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return nil
+	}
+	return ev.Transitions
+}
+
 func env(k, d string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
@@ -2,21 +2,32 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type BOQRepo struct{ pool *pgxpool.Pool }
 
+const insertBOQItemSQL = `
+	INSERT INTO boq_items (
+		id, tenant_id, project_id, category, description, part_id, qty, unit, estimated_cost_cents, approved, created_at, updated_at
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`
+
 func (r *BOQRepo) Create(ctx context.Context, b models.BOQItem) error {
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO boq_items (
-			id, tenant_id, project_id, category, description, part_id, qty, unit, estimated_cost_cents, approved, created_at, updated_at
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
-	`, b.ID, b.TenantID, b.ProjectID, b.Category, b.Description, b.PartID, b.Qty, b.Unit, b.EstimatedCostCents, b.Approved, b.CreatedAt, b.UpdatedAt)
+	_, err := r.pool.Exec(ctx, insertBOQItemSQL,
+		b.ID, b.TenantID, b.ProjectID, b.Category, b.Description, b.PartID, b.Qty, b.Unit, b.EstimatedCostCents, b.Approved, b.CreatedAt, b.UpdatedAt)
+	return err
+}
+
+func CreateBOQItemTx(ctx context.Context, tx Tx, b models.BOQItem) error {
+	_, err := tx.Exec(ctx, insertBOQItemSQL,
+		b.ID, b.TenantID, b.ProjectID, b.Category, b.Description, b.PartID, b.Qty, b.Unit, b.EstimatedCostCents, b.Approved, b.CreatedAt, b.UpdatedAt)
 	return err
 }
 
@@ -75,3 +86,183 @@ func (r *BOQRepo) List(ctx context.Context, p BOQListParams) ([]models.BOQItem,
 	}
 	return out, next, nil
 }
+
+const boqItemColumns = `id, tenant_id, project_id, category, description, part_id, qty, unit, estimated_cost_cents, approved, created_at, updated_at`
+
+func scanBOQItem(row pgx.Row) (models.BOQItem, error) {
+	var x models.BOQItem
+	err := row.Scan(&x.ID, &x.TenantID, &x.ProjectID, &x.Category, &x.Description, &x.PartID, &x.Qty, &x.Unit, &x.EstimatedCostCents, &x.Approved, &x.CreatedAt, &x.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return x, errors.New("not found")
+	}
+	return x, err
+}
+
+// Items returns all lines of a project's BOQ, oldest first.
+func (r *BOQRepo) Items(ctx context.Context, tenantID, projectID string) ([]models.BOQItem, error) {
+	return listBOQItems(ctx, r.pool, tenantID, projectID)
+}
+
+// ListBOQItemsTx returns all lines of a project's BOQ, oldest first.
+func ListBOQItemsTx(ctx context.Context, tx Tx, tenantID, projectID string) ([]models.BOQItem, error) {
+	return listBOQItems(ctx, tx, tenantID, projectID)
+}
+
+func listBOQItems(ctx context.Context, q Tx, tenantID, projectID string) ([]models.BOQItem, error) {
+	rows, err := q.Query(ctx, `
+		SELECT `+boqItemColumns+` FROM boq_items
+		WHERE tenant_id=$1 AND project_id=$2
+		ORDER BY created_at, id
+	`, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.BOQItem{}
+	for rows.Next() {
+		x, err := scanBOQItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+func GetBOQItemTx(ctx context.Context, tx Tx, tenantID, projectID, id string) (models.BOQItem, error) {
+	return scanBOQItem(tx.QueryRow(ctx, `
+		SELECT `+boqItemColumns+` FROM boq_items WHERE tenant_id=$1 AND project_id=$2 AND id=$3
+	`, tenantID, projectID, id))
+}
+
+func UpdateBOQItemTx(ctx context.Context, tx Tx, b models.BOQItem) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE boq_items SET category=$3, description=$4, part_id=$5, qty=$6, unit=$7, estimated_cost_cents=$8, updated_at=$9
+		WHERE tenant_id=$1 AND id=$2
+	`, b.TenantID, b.ID, b.Category, b.Description, b.PartID, b.Qty, b.Unit, b.EstimatedCostCents, b.UpdatedAt)
+	return err
+}
+
+func DeleteBOQItemTx(ctx context.Context, tx Tx, tenantID, projectID, id string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM boq_items WHERE tenant_id=$1 AND project_id=$2 AND id=$3`, tenantID, projectID, id)
+	return err
+}
+
+// SetBOQItemsApprovedTx marks every line of a project's BOQ approved or not.
+func SetBOQItemsApprovedTx(ctx context.Context, tx Tx, tenantID, projectID string, approved bool, now time.Time) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE boq_items SET approved=$3, updated_at=$4 WHERE tenant_id=$1 AND project_id=$2
+	`, tenantID, projectID, approved, now)
+	return err
+}
+
+const boqVersionColumns = `id, tenant_id, project_id, version, status, items, cost, notes, decision_notes,
+	created_by, submitted_by, approved_by, approver_role, work_order_id,
+	submitted_at, approved_at, revised_at, converted_at, created_at, updated_at`
+
+func scanBOQVersion(row pgx.Row) (models.BOQVersion, error) {
+	var v models.BOQVersion
+	var items, cost []byte
+	err := row.Scan(&v.ID, &v.TenantID, &v.ProjectID, &v.Version, &v.Status, &items, &cost, &v.Notes, &v.DecisionNotes,
+		&v.CreatedBy, &v.SubmittedBy, &v.ApprovedBy, &v.ApproverRole, &v.WorkOrderID,
+		&v.SubmittedAt, &v.ApprovedAt, &v.RevisedAt, &v.ConvertedAt, &v.CreatedAt, &v.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return v, errors.New("not found")
+	}
+	if err != nil {
+		return v, err
+	}
+	_ = json.Unmarshal(items, &v.Items)
+	if v.Items == nil {
+		v.Items = []models.BOQItem{}
+	}
+	if len(cost) > 0 {
+		v.Cost = &models.BOQCostSummary{}
+		_ = json.Unmarshal(cost, v.Cost)
+	}
+	return v, nil
+}
+
+// CurrentBOQVersionTx returns a project's latest BOQ version, locked for
+// the rest of the transaction. The implicit draft version 1 is stored first
+// if the project has no versions yet.
+func CurrentBOQVersionTx(ctx context.Context, tx Tx, tenantID, projectID, actor string, now time.Time) (models.BOQVersion, error) {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO boq_versions (id, tenant_id, project_id, version, status, created_by, created_at, updated_at)
+		SELECT $1, $2, $3, 1, $4, $5, $6, $6
+		WHERE NOT EXISTS (SELECT 1 FROM boq_versions WHERE tenant_id=$2 AND project_id=$3)
+		ON CONFLICT (tenant_id, project_id, version) DO NOTHING
+	`, NewID("boqv"), tenantID, projectID, models.BOQDraft, actor, now); err != nil {
+		return models.BOQVersion{}, err
+	}
+	return scanBOQVersion(tx.QueryRow(ctx, `
+		SELECT `+boqVersionColumns+` FROM boq_versions
+		WHERE tenant_id=$1 AND project_id=$2
+		ORDER BY version DESC LIMIT 1 FOR UPDATE
+	`, tenantID, projectID))
+}
+
+func CreateBOQVersionTx(ctx context.Context, tx Tx, v models.BOQVersion) error {
+	items, _ := json.Marshal(v.Items)
+	_, err := tx.Exec(ctx, `
+		INSERT INTO boq_versions (id, tenant_id, project_id, version, status, items, notes, created_by, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+	`, v.ID, v.TenantID, v.ProjectID, v.Version, v.Status, items, v.Notes, v.CreatedBy, v.CreatedAt, v.UpdatedAt)
+	return err
+}
+
+// UpdateBOQVersionTx saves a version's state, copied lines and costing.
+func UpdateBOQVersionTx(ctx context.Context, tx Tx, v models.BOQVersion) error {
+	items, _ := json.Marshal(v.Items)
+	var cost []byte
+	if v.Cost != nil {
+		cost, _ = json.Marshal(v.Cost)
+	}
+	_, err := tx.Exec(ctx, `
+		UPDATE boq_versions SET status=$3, items=$4, cost=$5, notes=$6, decision_notes=$7, submitted_by=$8,
+			approved_by=$9, approver_role=$10, work_order_id=$11, submitted_at=$12, approved_at=$13,
+			revised_at=$14, converted_at=$15, updated_at=$16
+		WHERE tenant_id=$1 AND id=$2
+	`, v.TenantID, v.ID, v.Status, items, cost, v.Notes, v.DecisionNotes, v.SubmittedBy,
+		v.ApprovedBy, v.ApproverRole, v.WorkOrderID, v.SubmittedAt, v.ApprovedAt,
+		v.RevisedAt, v.ConvertedAt, v.UpdatedAt)
+	return err
+}
+
+// CurrentVersion returns a project's latest BOQ version.
+func (r *BOQRepo) CurrentVersion(ctx context.Context, tenantID, projectID string) (models.BOQVersion, error) {
+	return scanBOQVersion(r.pool.QueryRow(ctx, `
+		SELECT `+boqVersionColumns+` FROM boq_versions
+		WHERE tenant_id=$1 AND project_id=$2
+		ORDER BY version DESC LIMIT 1
+	`, tenantID, projectID))
+}
+
+func (r *BOQRepo) GetVersion(ctx context.Context, tenantID, projectID string, version int) (models.BOQVersion, error) {
+	return scanBOQVersion(r.pool.QueryRow(ctx, `
+		SELECT `+boqVersionColumns+` FROM boq_versions
+		WHERE tenant_id=$1 AND project_id=$2 AND version=$3
+	`, tenantID, projectID, version))
+}
+
+// ListVersions returns a project's BOQ versions, newest first.
+func (r *BOQRepo) ListVersions(ctx context.Context, tenantID, projectID string) ([]models.BOQVersion, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+boqVersionColumns+` FROM boq_versions
+		WHERE tenant_id=$1 AND project_id=$2
+		ORDER BY version DESC
+	`, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.BOQVersion{}
+	for rows.Next() {
+		v, err := scanBOQVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrCannedShortcutTaken is returned when another canned response of the
+// tenant already uses the shortcut.
+var ErrCannedShortcutTaken = errors.New("shortcut already in use")
+
+// CannedResponsesRepo handles tenant canned responses for livechat agents
+type CannedResponsesRepo struct {
+	pool *pgxpool.Pool
+}
+
+// CannedResponseListParams contains parameters for listing canned responses
+type CannedResponseListParams struct {
+	TenantID string
+	Query    string // matches title, shortcut or content
+	Category string // also returns responses for any category
+	Active   *bool
+	Limit    int
+}
+
+const cannedResponseColumns = `id, tenant_id, title, shortcut, category, content, active,
+			   use_count, last_used_at, created_by, created_at, updated_at`
+
+func scanCannedResponse(row pgx.Row) (models.CannedResponse, error) {
+	var c models.CannedResponse
+	err := row.Scan(&c.ID, &c.TenantID, &c.Title, &c.Shortcut, &c.Category, &c.Content, &c.Active,
+		&c.UseCount, &c.LastUsedAt, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.CannedResponse{}, errors.New("not found")
+	}
+	return c, err
+}
+
+func cannedWriteErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCannedShortcutTaken
+	}
+	return err
+}
+
+// Create creates a canned response
+func (r *CannedResponsesRepo) Create(ctx context.Context, c models.CannedResponse) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO canned_responses (id, tenant_id, title, shortcut, category, content, active,
+			use_count, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $9, $10)
+	`, c.ID, c.TenantID, c.Title, c.Shortcut, c.Category, c.Content, c.Active,
+		c.CreatedBy, c.CreatedAt, c.UpdatedAt)
+	return cannedWriteErr(err)
+}
+
+// GetByID gets a canned response
+func (r *CannedResponsesRepo) GetByID(ctx context.Context, tenantID, id string) (models.CannedResponse, error) {
+	return scanCannedResponse(r.pool.QueryRow(ctx, `
+		SELECT `+cannedResponseColumns+`
+		FROM canned_responses
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id))
+}
+
+// List lists canned responses, most used first
+func (r *CannedResponsesRepo) List(ctx context.Context, p CannedResponseListParams) ([]models.CannedResponse, error) {
+	conds := []string{"tenant_id = $1"}
+	args := []any{p.TenantID}
+	argN := 2
+
+	if q := strings.TrimSpace(p.Query); q != "" {
+		conds = append(conds, fmt.Sprintf("(title ILIKE $%d OR shortcut ILIKE $%d OR content ILIKE $%d)", argN, argN, argN))
+		args = append(args, "%"+q+"%")
+		argN++
+	}
+	if p.Category != "" {
+		conds = append(conds, fmt.Sprintf("(category = $%d OR category = '')", argN))
+		args = append(args, p.Category)
+		argN++
+	}
+	if p.Active != nil {
+		conds = append(conds, fmt.Sprintf("active = $%d", argN))
+		args = append(args, *p.Active)
+		argN++
+	}
+	limit := p.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+cannedResponseColumns+`
+		FROM canned_responses
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY use_count DESC, title ASC
+		LIMIT `+fmt.Sprintf("$%d", argN), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.CannedResponse{}
+	for rows.Next() {
+		c, err := scanCannedResponse(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Update saves a canned response's editable fields
+func (r *CannedResponsesRepo) Update(ctx context.Context, c models.CannedResponse) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE canned_responses
+		SET title = $3, shortcut = $4, category = $5, content = $6, active = $7, updated_at = $8
+		WHERE tenant_id = $1 AND id = $2
+	`, c.TenantID, c.ID, c.Title, c.Shortcut, c.Category, c.Content, c.Active, c.UpdatedAt)
+	if err != nil {
+		return cannedWriteErr(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// Delete deletes a canned response
+func (r *CannedResponsesRepo) Delete(ctx context.Context, tenantID, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM canned_responses WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// RecordUse counts a canned response being inserted into a chat
+func (r *CannedResponsesRepo) RecordUse(ctx context.Context, tenantID, id string, at time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE canned_responses
+		SET use_count = use_count + 1, last_used_at = $3
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id, at)
+	return err
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ChatRoutingRepo handles livechat routing rules and the routing decision log
+type ChatRoutingRepo struct {
+	pool *pgxpool.Pool
+}
+
+// ChatRoutingDecisionListParams contains parameters for listing routing decisions
+type ChatRoutingDecisionListParams struct {
+	TenantID  string
+	SessionID string
+	AgentID   string
+	Outcome   string
+	Limit     int
+}
+
+// GetSettings gets a tenant's routing rules, or the defaults if it has none
+func (r *ChatRoutingRepo) GetSettings(ctx context.Context, tenantID string) (models.ChatRoutingSettings, error) {
+	var s models.ChatRoutingSettings
+	err := r.pool.QueryRow(ctx, `
+		SELECT tenant_id, require_skill_match, overflow_after_seconds, overflow_extra_chats,
+			   affinity_days, updated_by, updated_at
+		FROM chat_routing_settings
+		WHERE tenant_id = $1
+	`, tenantID).Scan(&s.TenantID, &s.RequireSkillMatch, &s.OverflowAfterSeconds, &s.OverflowExtraChats,
+		&s.AffinityDays, &s.UpdatedBy, &s.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.DefaultChatRoutingSettings(tenantID), nil
+	}
+	return s, err
+}
+
+// UpsertSettings saves a tenant's routing rules
+func (r *ChatRoutingRepo) UpsertSettings(ctx context.Context, s models.ChatRoutingSettings) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO chat_routing_settings (tenant_id, require_skill_match, overflow_after_seconds,
+			overflow_extra_chats, affinity_days, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant_id)
+		DO UPDATE SET require_skill_match = $2, overflow_after_seconds = $3, overflow_extra_chats = $4,
+			affinity_days = $5, updated_by = $6, updated_at = $7
+	`, s.TenantID, s.RequireSkillMatch, s.OverflowAfterSeconds, s.OverflowExtraChats,
+		s.AffinityDays, s.UpdatedBy, s.UpdatedAt)
+	return err
+}
+
+// RecordDecision logs how a session was routed
+func (r *ChatRoutingRepo) RecordDecision(ctx context.Context, d models.ChatRoutingDecision) error {
+	candidates := d.Candidates
+	if candidates == nil {
+		candidates = []models.ChatRouteCandidate{}
+	}
+	candidatesJSON, err := json.Marshal(candidates)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `
+		INSERT INTO chat_routing_decisions (id, tenant_id, session_id, school_id, issue_category,
+			outcome, agent_id, agent_name, score, reason, wait_seconds, candidates, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, d.ID, d.TenantID, d.SessionID, d.SchoolID, d.IssueCategory,
+		string(d.Outcome), d.AgentID, d.AgentName, d.Score, d.Reason, d.WaitSeconds, candidatesJSON, d.CreatedAt)
+	return err
+}
+
+// ListDecisions lists routing decisions, newest first
+func (r *ChatRoutingRepo) ListDecisions(ctx context.Context, p ChatRoutingDecisionListParams) ([]models.ChatRoutingDecision, error) {
+	conds := []string{"tenant_id = $1"}
+	args := []any{p.TenantID}
+	argN := 2
+
+	if p.SessionID != "" {
+		conds = append(conds, fmt.Sprintf("session_id = $%d", argN))
+		args = append(args, p.SessionID)
+		argN++
+	}
+	if p.AgentID != "" {
+		conds = append(conds, fmt.Sprintf("agent_id = $%d", argN))
+		args = append(args, p.AgentID)
+		argN++
+	}
+	if p.Outcome != "" {
+		conds = append(conds, fmt.Sprintf("outcome = $%d", argN))
+		args = append(args, p.Outcome)
+		argN++
+	}
+	limit := p.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, session_id, school_id, issue_category, outcome, agent_id, agent_name,
+			   score, reason, wait_seconds, candidates, created_at
+		FROM chat_routing_decisions
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT `+fmt.Sprintf("$%d", argN), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ChatRoutingDecision{}
+	for rows.Next() {
+		var d models.ChatRoutingDecision
+		var outcome string
+		var candidatesJSON []byte
+		if err := rows.Scan(&d.ID, &d.TenantID, &d.SessionID, &d.SchoolID, &d.IssueCategory, &outcome,
+			&d.AgentID, &d.AgentName, &d.Score, &d.Reason, &d.WaitSeconds, &candidatesJSON, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Outcome = models.ChatRouteOutcome(outcome)
+		if len(candidatesJSON) > 0 {
+			_ = json.Unmarshal(candidatesJSON, &d.Candidates)
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
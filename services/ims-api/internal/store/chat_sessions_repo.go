@@ -29,6 +29,39 @@ type ChatSessionListParams struct {
 	CursorID        string
 }
 
+// chatSessionColumns are the columns scanChatSession reads, including the
+// AI support fields.
+const chatSessionColumns = `id, tenant_id, school_id, thread_id, school_contact_id, school_contact_name,
+			   assigned_agent_id, assigned_agent_name, status, queue_position,
+			   started_at, agent_joined_at, ended_at, first_response_seconds,
+			   total_messages, rating, feedback, created_at, updated_at,
+			   COALESCE(ai_handled, false), ai_resolved, COALESCE(ai_turns, 0),
+			   escalation_reason, COALESCE(escalation_summary, '{}'),
+			   issue_category, issue_severity, COALESCE(collected_info, '{}'), queued_at`
+
+func scanChatSession(row pgx.Row) (models.ChatSession, error) {
+	var s models.ChatSession
+	var escalationSummaryJSON, collectedInfoJSON []byte
+	err := row.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.ThreadID, &s.SchoolContactID, &s.SchoolContactName,
+		&s.AssignedAgentID, &s.AssignedAgentName, &s.Status, &s.QueuePosition,
+		&s.StartedAt, &s.AgentJoinedAt, &s.EndedAt, &s.FirstResponseSeconds,
+		&s.TotalMessages, &s.Rating, &s.Feedback, &s.CreatedAt, &s.UpdatedAt,
+		&s.AIHandled, &s.AIResolved, &s.AITurns,
+		&s.EscalationReason, &escalationSummaryJSON,
+		&s.IssueCategory, &s.IssueSeverity, &collectedInfoJSON, &s.QueuedAt)
+	if err != nil {
+		return models.ChatSession{}, err
+	}
+	// Parse JSON fields
+	if len(escalationSummaryJSON) > 0 {
+		_ = json.Unmarshal(escalationSummaryJSON, &s.EscalationSummary)
+	}
+	if len(collectedInfoJSON) > 0 {
+		_ = json.Unmarshal(collectedInfoJSON, &s.CollectedInfo)
+	}
+	return s, nil
+}
+
 // CreateSession creates a new chat session
 func (r *ChatSessionsRepo) CreateSession(ctx context.Context, s models.ChatSession) error {
 	_, err := r.pool.Exec(ctx, `
@@ -52,20 +85,13 @@ func (r *ChatSessionsRepo) CreateSession(ctx context.Context, s models.ChatSessi
 
 // GetSessionByID retrieves a session by ID
 func (r *ChatSessionsRepo) GetSessionByID(ctx context.Context, tenantID, sessionID string) (models.ChatSession, error) {
-	var s models.ChatSession
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_id, school_id, thread_id, school_contact_id, school_contact_name,
-			   assigned_agent_id, assigned_agent_name, status, queue_position,
-			   started_at, agent_joined_at, ended_at, first_response_seconds,
-			   total_messages, rating, feedback, created_at, updated_at
+		SELECT `+chatSessionColumns+`
 		FROM chat_sessions
 		WHERE tenant_id = $1 AND id = $2
 	`, tenantID, sessionID)
 
-	err := row.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.ThreadID, &s.SchoolContactID, &s.SchoolContactName,
-		&s.AssignedAgentID, &s.AssignedAgentName, &s.Status, &s.QueuePosition,
-		&s.StartedAt, &s.AgentJoinedAt, &s.EndedAt, &s.FirstResponseSeconds,
-		&s.TotalMessages, &s.Rating, &s.Feedback, &s.CreatedAt, &s.UpdatedAt)
+	s, err := scanChatSession(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.ChatSession{}, errors.New("session not found")
@@ -77,22 +103,15 @@ func (r *ChatSessionsRepo) GetSessionByID(ctx context.Context, tenantID, session
 
 // GetSessionByThreadID retrieves a session by thread ID
 func (r *ChatSessionsRepo) GetSessionByThreadID(ctx context.Context, tenantID, threadID string) (models.ChatSession, error) {
-	var s models.ChatSession
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_id, school_id, thread_id, school_contact_id, school_contact_name,
-			   assigned_agent_id, assigned_agent_name, status, queue_position,
-			   started_at, agent_joined_at, ended_at, first_response_seconds,
-			   total_messages, rating, feedback, created_at, updated_at
+		SELECT `+chatSessionColumns+`
 		FROM chat_sessions
 		WHERE tenant_id = $1 AND thread_id = $2
 		ORDER BY created_at DESC
 		LIMIT 1
 	`, tenantID, threadID)
 
-	err := row.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.ThreadID, &s.SchoolContactID, &s.SchoolContactName,
-		&s.AssignedAgentID, &s.AssignedAgentName, &s.Status, &s.QueuePosition,
-		&s.StartedAt, &s.AgentJoinedAt, &s.EndedAt, &s.FirstResponseSeconds,
-		&s.TotalMessages, &s.Rating, &s.Feedback, &s.CreatedAt, &s.UpdatedAt)
+	s, err := scanChatSession(row)
 	if err != nil {
 		return models.ChatSession{}, err
 	}
@@ -101,52 +120,28 @@ func (r *ChatSessionsRepo) GetSessionByThreadID(ctx context.Context, tenantID, t
 
 // GetActiveSessionForUser gets the active session for a school contact
 func (r *ChatSessionsRepo) GetActiveSessionForUser(ctx context.Context, tenantID, userID string) (models.ChatSession, error) {
-	var s models.ChatSession
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_id, school_id, thread_id, school_contact_id, school_contact_name,
-			   assigned_agent_id, assigned_agent_name, status, queue_position,
-			   started_at, agent_joined_at, ended_at, first_response_seconds,
-			   total_messages, rating, feedback, created_at, updated_at,
-			   COALESCE(ai_handled, false), ai_resolved, COALESCE(ai_turns, 0),
-			   escalation_reason, COALESCE(escalation_summary, '{}'),
-			   issue_category, issue_severity, COALESCE(collected_info, '{}')
+		SELECT `+chatSessionColumns+`
 		FROM chat_sessions
 		WHERE tenant_id = $1 AND school_contact_id = $2 AND status IN ('ai_active', 'waiting', 'active')
 		ORDER BY created_at DESC
 		LIMIT 1
 	`, tenantID, userID)
 
-	var escalationSummaryJSON, collectedInfoJSON []byte
-	err := row.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.ThreadID, &s.SchoolContactID, &s.SchoolContactName,
-		&s.AssignedAgentID, &s.AssignedAgentName, &s.Status, &s.QueuePosition,
-		&s.StartedAt, &s.AgentJoinedAt, &s.EndedAt, &s.FirstResponseSeconds,
-		&s.TotalMessages, &s.Rating, &s.Feedback, &s.CreatedAt, &s.UpdatedAt,
-		&s.AIHandled, &s.AIResolved, &s.AITurns,
-		&s.EscalationReason, &escalationSummaryJSON,
-		&s.IssueCategory, &s.IssueSeverity, &collectedInfoJSON)
+	s, err := scanChatSession(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.ChatSession{}, nil // No active session
 		}
 		return models.ChatSession{}, err
 	}
-	// Parse JSON fields
-	if len(escalationSummaryJSON) > 0 {
-		_ = json.Unmarshal(escalationSummaryJSON, &s.EscalationSummary)
-	}
-	if len(collectedInfoJSON) > 0 {
-		_ = json.Unmarshal(collectedInfoJSON, &s.CollectedInfo)
-	}
 	return s, nil
 }
 
 // GetWaitingSessions gets all waiting sessions (queue)
 func (r *ChatSessionsRepo) GetWaitingSessions(ctx context.Context, tenantID string) ([]models.ChatSession, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, tenant_id, school_id, thread_id, school_contact_id, school_contact_name,
-			   assigned_agent_id, assigned_agent_name, status, queue_position,
-			   started_at, agent_joined_at, ended_at, first_response_seconds,
-			   total_messages, rating, feedback, created_at, updated_at
+		SELECT `+chatSessionColumns+`
 		FROM chat_sessions
 		WHERE tenant_id = $1 AND status = 'waiting'
 		ORDER BY started_at ASC
@@ -158,11 +153,8 @@ func (r *ChatSessionsRepo) GetWaitingSessions(ctx context.Context, tenantID stri
 
 	var sessions []models.ChatSession
 	for rows.Next() {
-		var s models.ChatSession
-		if err := rows.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.ThreadID, &s.SchoolContactID, &s.SchoolContactName,
-			&s.AssignedAgentID, &s.AssignedAgentName, &s.Status, &s.QueuePosition,
-			&s.StartedAt, &s.AgentJoinedAt, &s.EndedAt, &s.FirstResponseSeconds,
-			&s.TotalMessages, &s.Rating, &s.Feedback, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		s, err := scanChatSession(rows)
+		if err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, s)
@@ -171,13 +163,31 @@ func (r *ChatSessionsRepo) GetWaitingSessions(ctx context.Context, tenantID stri
 	return sessions, nil
 }
 
+// ListTenantsWithWaitingSessions lists tenants with sessions in the agent queue
+func (r *ChatSessionsRepo) ListTenantsWithWaitingSessions(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT tenant_id FROM chat_sessions WHERE status = 'waiting'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, id)
+	}
+	return tenants, rows.Err()
+}
+
 // GetActiveSessionsForAgent gets all active sessions for an agent
 func (r *ChatSessionsRepo) GetActiveSessionsForAgent(ctx context.Context, tenantID, agentID string) ([]models.ChatSession, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, tenant_id, school_id, thread_id, school_contact_id, school_contact_name,
-			   assigned_agent_id, assigned_agent_name, status, queue_position,
-			   started_at, agent_joined_at, ended_at, first_response_seconds,
-			   total_messages, rating, feedback, created_at, updated_at
+		SELECT `+chatSessionColumns+`
 		FROM chat_sessions
 		WHERE tenant_id = $1 AND assigned_agent_id = $2 AND status = 'active'
 		ORDER BY started_at DESC
@@ -189,11 +199,8 @@ func (r *ChatSessionsRepo) GetActiveSessionsForAgent(ctx context.Context, tenant
 
 	var sessions []models.ChatSession
 	for rows.Next() {
-		var s models.ChatSession
-		if err := rows.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.ThreadID, &s.SchoolContactID, &s.SchoolContactName,
-			&s.AssignedAgentID, &s.AssignedAgentName, &s.Status, &s.QueuePosition,
-			&s.StartedAt, &s.AgentJoinedAt, &s.EndedAt, &s.FirstResponseSeconds,
-			&s.TotalMessages, &s.Rating, &s.Feedback, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		s, err := scanChatSession(rows)
+		if err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, s)
@@ -202,17 +209,21 @@ func (r *ChatSessionsRepo) GetActiveSessionsForAgent(ctx context.Context, tenant
 	return sessions, nil
 }
 
-// AssignAgent assigns an agent to a session
-func (r *ChatSessionsRepo) AssignAgent(ctx context.Context, tenantID, sessionID, agentID, agentName string) error {
+// AssignAgent assigns an agent to a waiting session. It reports false when
+// the session is no longer waiting, e.g. another agent took it first.
+func (r *ChatSessionsRepo) AssignAgent(ctx context.Context, tenantID, sessionID, agentID, agentName string) (bool, error) {
 	now := time.Now().UTC()
-	_, err := r.pool.Exec(ctx, `
+	tag, err := r.pool.Exec(ctx, `
 		UPDATE chat_sessions
 		SET assigned_agent_id = $3, assigned_agent_name = $4,
 			status = 'active', queue_position = NULL,
 			agent_joined_at = $5, updated_at = $5
 		WHERE tenant_id = $1 AND id = $2 AND status = 'waiting'
 	`, tenantID, sessionID, agentID, agentName, now)
-	return err
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
 }
 
 // EndSession ends a chat session
@@ -290,22 +301,15 @@ func (r *ChatSessionsRepo) UpdateQueuePositions(ctx context.Context, tenantID st
 
 // GetNextInQueue gets the next session in the queue
 func (r *ChatSessionsRepo) GetNextInQueue(ctx context.Context, tenantID string) (models.ChatSession, error) {
-	var s models.ChatSession
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_id, school_id, thread_id, school_contact_id, school_contact_name,
-			   assigned_agent_id, assigned_agent_name, status, queue_position,
-			   started_at, agent_joined_at, ended_at, first_response_seconds,
-			   total_messages, rating, feedback, created_at, updated_at
+		SELECT `+chatSessionColumns+`
 		FROM chat_sessions
 		WHERE tenant_id = $1 AND status = 'waiting'
 		ORDER BY started_at ASC
 		LIMIT 1
 	`, tenantID)
 
-	err := row.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.ThreadID, &s.SchoolContactID, &s.SchoolContactName,
-		&s.AssignedAgentID, &s.AssignedAgentName, &s.Status, &s.QueuePosition,
-		&s.StartedAt, &s.AgentJoinedAt, &s.EndedAt, &s.FirstResponseSeconds,
-		&s.TotalMessages, &s.Rating, &s.Feedback, &s.CreatedAt, &s.UpdatedAt)
+	s, err := scanChatSession(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.ChatSession{}, nil
@@ -317,18 +321,29 @@ func (r *ChatSessionsRepo) GetNextInQueue(ctx context.Context, tenantID string)
 
 // Agent Availability
 
+// agentAvailabilityColumns are the columns scanAgentAvailability reads.
+const agentAvailabilityColumns = `tenant_id, user_id, is_available, max_concurrent_chats,
+			   current_chat_count, skills, last_seen_at, last_assigned_at, updated_at`
+
+func scanAgentAvailability(row pgx.Row) (models.AgentAvailability, error) {
+	var a models.AgentAvailability
+	err := row.Scan(&a.TenantID, &a.UserID, &a.IsAvailable, &a.MaxConcurrentChats,
+		&a.CurrentChatCount, &a.Skills, &a.LastSeenAt, &a.LastAssignedAt, &a.UpdatedAt)
+	if a.Skills == nil {
+		a.Skills = []string{}
+	}
+	return a, err
+}
+
 // GetAgentAvailability gets an agent's availability
 func (r *ChatSessionsRepo) GetAgentAvailability(ctx context.Context, tenantID, userID string) (models.AgentAvailability, error) {
-	var a models.AgentAvailability
 	row := r.pool.QueryRow(ctx, `
-		SELECT tenant_id, user_id, is_available, max_concurrent_chats,
-			   current_chat_count, last_seen_at, updated_at
+		SELECT `+agentAvailabilityColumns+`
 		FROM agent_availability
 		WHERE tenant_id = $1 AND user_id = $2
 	`, tenantID, userID)
 
-	err := row.Scan(&a.TenantID, &a.UserID, &a.IsAvailable, &a.MaxConcurrentChats,
-		&a.CurrentChatCount, &a.LastSeenAt, &a.UpdatedAt)
+	a, err := scanAgentAvailability(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			// Return default availability
@@ -338,6 +353,7 @@ func (r *ChatSessionsRepo) GetAgentAvailability(ctx context.Context, tenantID, u
 				IsAvailable:        false,
 				MaxConcurrentChats: 3,
 				CurrentChatCount:   0,
+				Skills:             []string{},
 				LastSeenAt:         time.Now().UTC(),
 				UpdatedAt:          time.Now().UTC(),
 			}, nil
@@ -347,24 +363,28 @@ func (r *ChatSessionsRepo) GetAgentAvailability(ctx context.Context, tenantID, u
 	return a, nil
 }
 
-// SetAgentAvailability sets an agent's availability
-func (r *ChatSessionsRepo) SetAgentAvailability(ctx context.Context, tenantID, userID string, available bool, maxChats int) error {
+// SetAgentAvailability sets an agent's availability and skill tags
+func (r *ChatSessionsRepo) SetAgentAvailability(ctx context.Context, tenantID, userID string, available bool, maxChats int, skills []string) error {
 	now := time.Now().UTC()
+	if skills == nil {
+		skills = []string{}
+	}
 	_, err := r.pool.Exec(ctx, `
-		INSERT INTO agent_availability (tenant_id, user_id, is_available, max_concurrent_chats, current_chat_count, last_seen_at, updated_at)
-		VALUES ($1, $2, $3, $4, 0, $5, $5)
+		INSERT INTO agent_availability (tenant_id, user_id, is_available, max_concurrent_chats, current_chat_count, skills, last_seen_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, $5, $6, $6)
 		ON CONFLICT (tenant_id, user_id)
-		DO UPDATE SET is_available = $3, max_concurrent_chats = $4, last_seen_at = $5, updated_at = $5
-	`, tenantID, userID, available, maxChats, now)
+		DO UPDATE SET is_available = $3, max_concurrent_chats = $4, skills = $5, last_seen_at = $6, updated_at = $6
+	`, tenantID, userID, available, maxChats, skills, now)
 	return err
 }
 
 // IncrementAgentChatCount increments the current chat count for an agent
+// and stamps when it was last given a chat
 func (r *ChatSessionsRepo) IncrementAgentChatCount(ctx context.Context, tenantID, userID string) error {
 	now := time.Now().UTC()
 	_, err := r.pool.Exec(ctx, `
 		UPDATE agent_availability
-		SET current_chat_count = current_chat_count + 1, last_seen_at = $3, updated_at = $3
+		SET current_chat_count = current_chat_count + 1, last_assigned_at = $3, last_seen_at = $3, updated_at = $3
 		WHERE tenant_id = $1 AND user_id = $2
 	`, tenantID, userID, now)
 	return err
@@ -383,14 +403,28 @@ func (r *ChatSessionsRepo) DecrementAgentChatCount(ctx context.Context, tenantID
 
 // GetAvailableAgents gets all available agents
 func (r *ChatSessionsRepo) GetAvailableAgents(ctx context.Context, tenantID string) ([]models.AgentAvailability, error) {
-	rows, err := r.pool.Query(ctx, `
-		SELECT tenant_id, user_id, is_available, max_concurrent_chats,
-			   current_chat_count, last_seen_at, updated_at
+	return r.listAgents(ctx, `
+		SELECT `+agentAvailabilityColumns+`
 		FROM agent_availability
 		WHERE tenant_id = $1 AND is_available = true
 		AND current_chat_count < max_concurrent_chats
 		ORDER BY current_chat_count ASC, last_seen_at ASC
 	`, tenantID)
+}
+
+// ListRoutableAgents gets all agents marked available, including those at
+// capacity, so routing can apply overflow rules
+func (r *ChatSessionsRepo) ListRoutableAgents(ctx context.Context, tenantID string) ([]models.AgentAvailability, error) {
+	return r.listAgents(ctx, `
+		SELECT `+agentAvailabilityColumns+`
+		FROM agent_availability
+		WHERE tenant_id = $1 AND is_available = true
+		ORDER BY user_id ASC
+	`, tenantID)
+}
+
+func (r *ChatSessionsRepo) listAgents(ctx context.Context, query string, args ...any) ([]models.AgentAvailability, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -398,15 +432,41 @@ func (r *ChatSessionsRepo) GetAvailableAgents(ctx context.Context, tenantID stri
 
 	var agents []models.AgentAvailability
 	for rows.Next() {
-		var a models.AgentAvailability
-		if err := rows.Scan(&a.TenantID, &a.UserID, &a.IsAvailable, &a.MaxConcurrentChats,
-			&a.CurrentChatCount, &a.LastSeenAt, &a.UpdatedAt); err != nil {
+		a, err := scanAgentAvailability(rows)
+		if err != nil {
 			return nil, err
 		}
 		agents = append(agents, a)
 	}
 
-	return agents, nil
+	return agents, rows.Err()
+}
+
+// CountSchoolSessionsByAgent counts each agent's sessions with a school
+// started since the given time
+func (r *ChatSessionsRepo) CountSchoolSessionsByAgent(ctx context.Context, tenantID, schoolID string, since time.Time) (map[string]int, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT assigned_agent_id, COUNT(*)
+		FROM chat_sessions
+		WHERE tenant_id = $1 AND school_id = $2 AND started_at >= $3
+		AND assigned_agent_id IS NOT NULL
+		GROUP BY assigned_agent_id
+	`, tenantID, schoolID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var agentID string
+		var n int
+		if err := rows.Scan(&agentID, &n); err != nil {
+			return nil, err
+		}
+		counts[agentID] = n
+	}
+	return counts, rows.Err()
 }
 
 // GetChatMetrics gets chat metrics for analytics
@@ -430,10 +490,99 @@ func (r *ChatSessionsRepo) GetChatMetrics(ctx context.Context, tenantID string,
 	err := row.Scan(&m.TotalSessions, &m.AverageWaitTime, &m.AverageResponseTime,
 		&m.AverageRating, &m.SessionsWithRating, &m.ActiveSessions,
 		&m.WaitingSessions, &m.EndedSessions)
+	if err != nil {
+		return m, err
+	}
+
+	// Survey results for sessions in the range. A resolved answer counts
+	// towards first-contact resolution only if the contact did not start
+	// another chat within chatFCRRepeatDays of the end.
+	row = r.pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE sv.status = 'completed'),
+			COALESCE(100.0 * COUNT(*) FILTER (WHERE sv.status = 'completed') / NULLIF(COUNT(*), 0), 0),
+			COALESCE(100.0 * COUNT(*) FILTER (WHERE sv.csat_score >= 4) / NULLIF(COUNT(sv.csat_score), 0), 0),
+			COALESCE(AVG(sv.csat_score), 0),
+			COUNT(sv.resolved),
+			COALESCE(100.0 * COUNT(*) FILTER (WHERE sv.resolved AND NOT EXISTS (
+				SELECT 1 FROM chat_sessions n
+				WHERE n.tenant_id = cs.tenant_id AND n.school_contact_id = cs.school_contact_id
+				AND n.id <> cs.id AND n.started_at > COALESCE(cs.ended_at, sv.sent_at)
+				AND n.started_at <= COALESCE(cs.ended_at, sv.sent_at) + make_interval(days => $4)
+			)) / NULLIF(COUNT(sv.resolved), 0), 0)
+		FROM chat_surveys sv
+		JOIN chat_sessions cs ON cs.id = sv.session_id
+		WHERE sv.tenant_id = $1 AND cs.created_at BETWEEN $2 AND $3
+	`, tenantID, from, to, chatFCRRepeatDays)
+
+	err = row.Scan(&m.SurveysSent, &m.SurveysCompleted, &m.SurveyResponseRate,
+		&m.CSATScore, &m.AverageCSAT, &m.FCRResponses, &m.FirstContactResolutionRate)
 
 	return m, err
 }
 
+// Post-chat surveys
+
+// chatFCRRepeatDays is how long after a chat a new chat from the same
+// contact means the issue was not resolved on first contact.
+const chatFCRRepeatDays = 7
+
+const chatSurveyColumns = `id, tenant_id, session_id, school_id, school_contact_id, agent_id, status,
+			   csat_score, resolved, comment, sent_at, expires_at, responded_at`
+
+// CreateSurvey records the survey sent for a session. It reports false if
+// the session already has one.
+func (r *ChatSessionsRepo) CreateSurvey(ctx context.Context, sv models.ChatSurvey) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO chat_surveys (`+chatSurveyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (session_id) DO NOTHING
+	`, sv.ID, sv.TenantID, sv.SessionID, sv.SchoolID, sv.SchoolContactID, sv.AgentID, sv.Status,
+		sv.CSATScore, sv.Resolved, sv.Comment, sv.SentAt, sv.ExpiresAt, sv.RespondedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetSurveyBySession gets the survey sent for a session
+func (r *ChatSessionsRepo) GetSurveyBySession(ctx context.Context, tenantID, sessionID string) (models.ChatSurvey, error) {
+	var sv models.ChatSurvey
+	err := r.pool.QueryRow(ctx, `
+		SELECT `+chatSurveyColumns+`
+		FROM chat_surveys
+		WHERE tenant_id = $1 AND session_id = $2
+	`, tenantID, sessionID).Scan(&sv.ID, &sv.TenantID, &sv.SessionID, &sv.SchoolID, &sv.SchoolContactID,
+		&sv.AgentID, &sv.Status, &sv.CSATScore, &sv.Resolved, &sv.Comment, &sv.SentAt, &sv.ExpiresAt, &sv.RespondedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ChatSurvey{}, errors.New("not found")
+	}
+	return sv, err
+}
+
+// CompleteSurvey records the contact's answers on a pending, unexpired
+// survey and copies the score and comment to the session's rating and
+// feedback. It reports false if the survey was not open.
+func (r *ChatSessionsRepo) CompleteSurvey(ctx context.Context, tenantID, surveyID string, score int, resolved *bool, comment string, at time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		WITH sv AS (
+			UPDATE chat_surveys
+			SET status = 'completed', csat_score = $3, resolved = $4, comment = $5, responded_at = $6
+			WHERE tenant_id = $1 AND id = $2 AND status = 'pending' AND expires_at > $6
+			RETURNING session_id
+		)
+		UPDATE chat_sessions cs
+		SET rating = $3, feedback = COALESCE(NULLIF($5, ''), cs.feedback), updated_at = $6
+		FROM sv
+		WHERE cs.id = sv.session_id
+	`, tenantID, surveyID, score, resolved, comment, at)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // AI Support Methods
 
 // EscalateToHuman escalates a session from AI to human queue
@@ -443,7 +592,7 @@ func (r *ChatSessionsRepo) EscalateToHuman(ctx context.Context, tenantID, sessio
 	_, err := r.pool.Exec(ctx, `
 		UPDATE chat_sessions
 		SET status = 'waiting', escalation_reason = $3, escalation_summary = $4,
-			ai_resolved = false, updated_at = $5
+			ai_resolved = false, queued_at = $5, updated_at = $5
 		WHERE tenant_id = $1 AND id = $2 AND status = 'ai_active'
 	`, tenantID, sessionID, reason, summaryJSON, now)
 	return err
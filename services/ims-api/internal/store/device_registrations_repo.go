@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrRegistrationPending is returned when a serial already has a
+// registration waiting for ssot-devices.
+var ErrRegistrationPending = errors.New("registration already pending for serial")
+
+// DeviceRegistrationsRepo tracks school-registered devices until
+// ssot-devices confirms or rejects them.
+type DeviceRegistrationsRepo struct{ pool *pgxpool.Pool }
+
+const deviceRegistrationColumns = `id, tenant_id, school_id, serial, asset_tag, make, model, location_id, notes, status, device_id,
+	rejection_code, rejection_reason, attempts, last_error, next_attempt_at, requested_by, created_at, updated_at, confirmed_at`
+
+func scanDeviceRegistration(row pgx.Row) (models.DeviceRegistration, error) {
+	var reg models.DeviceRegistration
+	err := row.Scan(&reg.ID, &reg.TenantID, &reg.SchoolID, &reg.Serial, &reg.AssetTag, &reg.Make, &reg.Model, &reg.LocationID, &reg.Notes, &reg.Status, &reg.DeviceID,
+		&reg.RejectionCode, &reg.RejectionReason, &reg.Attempts, &reg.LastError, &reg.NextAttemptAt, &reg.RequestedBy, &reg.CreatedAt, &reg.UpdatedAt, &reg.ConfirmedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return reg, errors.New("not found")
+	}
+	return reg, err
+}
+
+func (r *DeviceRegistrationsRepo) Create(ctx context.Context, reg models.DeviceRegistration) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO device_registrations (id, tenant_id, school_id, serial, asset_tag, make, model, location_id, notes, status,
+			next_attempt_at, requested_by, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$13)
+	`, reg.ID, reg.TenantID, reg.SchoolID, reg.Serial, reg.AssetTag, reg.Make, reg.Model, reg.LocationID, reg.Notes, reg.Status,
+		reg.NextAttemptAt, reg.RequestedBy, reg.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrRegistrationPending
+	}
+	return err
+}
+
+func (r *DeviceRegistrationsRepo) Get(ctx context.Context, tenantID, id string) (models.DeviceRegistration, error) {
+	return scanDeviceRegistration(r.pool.QueryRow(ctx, `
+		SELECT `+deviceRegistrationColumns+` FROM device_registrations WHERE tenant_id=$1 AND id=$2
+	`, tenantID, id))
+}
+
+// ListBySchool returns a school's registrations, newest first, optionally
+// filtered by status.
+func (r *DeviceRegistrationsRepo) ListBySchool(ctx context.Context, tenantID, schoolID, status string, limit, offset int) ([]models.DeviceRegistration, error) {
+	where := "tenant_id=$1 AND school_id=$2"
+	args := []any{tenantID, schoolID}
+	if status != "" {
+		args = append(args, status)
+		where += " AND status=$" + itoa(len(args))
+	}
+	args = append(args, limit, offset)
+	return r.query(ctx, `
+		SELECT `+deviceRegistrationColumns+` FROM device_registrations
+		WHERE `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+itoa(len(args)-1)+` OFFSET $`+itoa(len(args)), args...)
+}
+
+// ListDue returns pending registrations across tenants whose next attempt
+// is due.
+func (r *DeviceRegistrationsRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]models.DeviceRegistration, error) {
+	return r.query(ctx, `
+		SELECT `+deviceRegistrationColumns+` FROM device_registrations
+		WHERE status='pending' AND (next_attempt_at IS NULL OR next_attempt_at <= $1)
+		ORDER BY next_attempt_at NULLS FIRST, id
+		LIMIT $2
+	`, now, limit)
+}
+
+func (r *DeviceRegistrationsRepo) query(ctx context.Context, sql string, args ...any) ([]models.DeviceRegistration, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.DeviceRegistration{}
+	for rows.Next() {
+		reg, err := scanDeviceRegistration(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, reg)
+	}
+	return out, rows.Err()
+}
+
+// ConfirmDeviceRegistration records the SSOT device: it adds it to
+// devices_snapshot, assigns it to the registration's location and marks the
+// registration confirmed, all in one transaction. Only pending
+// registrations are confirmed; a registration confirmed concurrently is
+// returned as it is.
+func (r *DeviceRegistrationsRepo) ConfirmDeviceRegistration(ctx context.Context, reg models.DeviceRegistration, d models.DeviceSnapshot, now time.Time) (models.DeviceRegistration, error) {
+	var out models.DeviceRegistration
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		out, err = scanDeviceRegistration(tx.QueryRow(ctx, `
+			UPDATE device_registrations SET status='confirmed', device_id=$3, last_error='', next_attempt_at=NULL,
+				attempts=attempts+1, confirmed_at=$4, updated_at=$4
+			WHERE tenant_id=$1 AND id=$2 AND status='pending'
+			RETURNING `+deviceRegistrationColumns, reg.TenantID, reg.ID, d.DeviceID, now))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO devices_snapshot (tenant_id, device_id, school_id, model, serial, asset_tag, status, updated_at)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+			ON CONFLICT (tenant_id, device_id) DO UPDATE SET
+			  school_id=EXCLUDED.school_id, model=EXCLUDED.model, serial=EXCLUDED.serial,
+			  asset_tag=EXCLUDED.asset_tag, status=EXCLUDED.status, updated_at=EXCLUDED.updated_at
+		`, d.TenantID, d.DeviceID, d.SchoolID, d.Model, d.Serial, d.AssetTag, d.Status, d.UpdatedAt); err != nil {
+			return err
+		}
+		if out.LocationID != nil && *out.LocationID != "" {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO device_assignments (id, tenant_id, device_id, location_id, assignment_type, effective_from, notes, created_by, created_at)
+				VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$6)
+			`, NewID("asn"), out.TenantID, d.DeviceID, out.LocationID, models.AssignmentTypePermanent, now, out.Notes, out.RequestedBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && err.Error() == "not found" {
+		return r.Get(ctx, reg.TenantID, reg.ID)
+	}
+	return out, err
+}
+
+// RejectDeviceRegistration marks a pending registration rejected.
+func (r *DeviceRegistrationsRepo) RejectDeviceRegistration(ctx context.Context, reg models.DeviceRegistration, code, reason string, now time.Time) (models.DeviceRegistration, error) {
+	out, err := scanDeviceRegistration(r.pool.QueryRow(ctx, `
+		UPDATE device_registrations SET status='rejected', rejection_code=$3, rejection_reason=$4, last_error='',
+			next_attempt_at=NULL, attempts=attempts+1, updated_at=$5
+		WHERE tenant_id=$1 AND id=$2 AND status='pending'
+		RETURNING `+deviceRegistrationColumns, reg.TenantID, reg.ID, code, reason, now))
+	if err != nil && err.Error() == "not found" {
+		return r.Get(ctx, reg.TenantID, reg.ID)
+	}
+	return out, err
+}
+
+// DeferDeviceRegistration records a failed attempt and when to retry.
+func (r *DeviceRegistrationsRepo) DeferDeviceRegistration(ctx context.Context, reg models.DeviceRegistration, lastError string, next, now time.Time) (models.DeviceRegistration, error) {
+	out, err := scanDeviceRegistration(r.pool.QueryRow(ctx, `
+		UPDATE device_registrations SET attempts=attempts+1, last_error=$3, next_attempt_at=$4, updated_at=$5
+		WHERE tenant_id=$1 AND id=$2 AND status='pending'
+		RETURNING `+deviceRegistrationColumns, reg.TenantID, reg.ID, lastError, next, now))
+	if err != nil && err.Error() == "not found" {
+		return r.Get(ctx, reg.TenantID, reg.ID)
+	}
+	return out, err
+}
+
+// Resubmit puts a rejected registration back in the queue with corrected
+// details.
+func (r *DeviceRegistrationsRepo) Resubmit(ctx context.Context, reg models.DeviceRegistration, now time.Time) (models.DeviceRegistration, error) {
+	out, err := scanDeviceRegistration(r.pool.QueryRow(ctx, `
+		UPDATE device_registrations SET status='pending', serial=$3, asset_tag=$4, make=$5, model=$6,
+			rejection_code='', rejection_reason='', next_attempt_at=NULL, updated_at=$7
+		WHERE tenant_id=$1 AND id=$2 AND status='rejected'
+		RETURNING `+deviceRegistrationColumns, reg.TenantID, reg.ID, reg.Serial, reg.AssetTag, reg.Make, reg.Model, now))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return out, ErrRegistrationPending
+	}
+	return out, err
+}
@@ -13,7 +13,12 @@ import (
 type DevicesSnapshotRepo struct{ pool *pgxpool.Pool }
 
 func (r *DevicesSnapshotRepo) Upsert(ctx context.Context, d models.DeviceSnapshot) error {
-	_, err := r.pool.Exec(ctx, `
+	return UpsertDeviceSnapshotTx(ctx, r.pool, d)
+}
+
+// UpsertDeviceSnapshotTx upserts a device snapshot within a transaction.
+func UpsertDeviceSnapshotTx(ctx context.Context, tx Tx, d models.DeviceSnapshot) error {
+	_, err := tx.Exec(ctx, `
 		INSERT INTO devices_snapshot (
 			tenant_id, device_id, school_id, model, serial, asset_tag, status, updated_at
 		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
@@ -29,6 +34,16 @@ func (r *DevicesSnapshotRepo) Upsert(ctx context.Context, d models.DeviceSnapsho
 	return err
 }
 
+func (r *DevicesSnapshotRepo) Delete(ctx context.Context, tenantID, deviceID string) error {
+	return DeleteDeviceSnapshotTx(ctx, r.pool, tenantID, deviceID)
+}
+
+// DeleteDeviceSnapshotTx deletes a device snapshot within a transaction.
+func DeleteDeviceSnapshotTx(ctx context.Context, tx Tx, tenantID, deviceID string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM devices_snapshot WHERE tenant_id = $1 AND device_id = $2`, tenantID, deviceID)
+	return err
+}
+
 func (r *DevicesSnapshotRepo) Get(ctx context.Context, tenantID, deviceID string) (models.DeviceSnapshot, error) {
 	var d models.DeviceSnapshot
 	row := r.pool.QueryRow(ctx, `
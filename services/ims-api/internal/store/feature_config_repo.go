@@ -86,6 +86,21 @@ func (r *FeatureConfigRepo) GetReworkConfig(ctx context.Context, tenantID string
 	return models.ParseReworkConfig(cfg.ConfigValue), nil
 }
 
+// GetSLAWarningConfig returns the SLA warning configuration for a tenant.
+func (r *FeatureConfigRepo) GetSLAWarningConfig(ctx context.Context, tenantID string) (models.SLAWarningConfig, error) {
+	cfg, err := r.GetFeature(ctx, tenantID, models.FeatureSLAWarnings)
+	if err != nil {
+		if errors.Is(err, models.ErrFeatureNotFound) {
+			return models.DefaultSLAWarningConfig(), nil
+		}
+		return models.SLAWarningConfig{}, err
+	}
+	if !cfg.Enabled {
+		return models.SLAWarningConfig{}, nil
+	}
+	return models.ParseSLAWarningConfig(cfg.ConfigValue), nil
+}
+
 // UpsertFeature creates or updates a feature configuration.
 func (r *FeatureConfigRepo) UpsertFeature(ctx context.Context, cfg models.FeatureConfig) error {
 	configJSON, err := json.Marshal(cfg.ConfigValue)
@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FieldSyncRepo serves the offline field-tech sync: the work orders assigned
+// to a technician across schools, their children, and the idempotency log of
+// pushed mutations.
+type FieldSyncRepo struct{ pool *pgxpool.Pool }
+
+const fieldWorkOrderColumns = `wo.id, wo.incident_id, wo.tenant_id, wo.school_id, wo.device_id, wo.status, wo.service_shop_id,
+	wo.assigned_staff_id, wo.repair_location, wo.assigned_to, wo.task_type, wo.cost_estimate_cents, wo.notes,
+	wo.approval_status, wo.created_at, wo.updated_at`
+
+func scanFieldWorkOrder(row pgx.Row, wo *models.WorkOrder) error {
+	return row.Scan(&wo.ID, &wo.IncidentID, &wo.TenantID, &wo.SchoolID, &wo.DeviceID, &wo.Status, &wo.ServiceShopID,
+		&wo.AssignedStaffID, &wo.RepairLocation, &wo.AssignedTo, &wo.TaskType, &wo.CostEstimateCents, &wo.Notes,
+		&wo.ApprovalStatus, &wo.CreatedAt, &wo.UpdatedAt)
+}
+
+// AssignedWorkOrders returns every open work order assigned to the service
+// staff records of a user, in any school.
+func (r *FieldSyncRepo) AssignedWorkOrders(ctx context.Context, tenantID, userID string) ([]models.WorkOrder, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+fieldWorkOrderColumns+`
+		FROM work_orders wo
+		JOIN service_staff st ON st.tenant_id=wo.tenant_id AND st.id=wo.assigned_staff_id
+		WHERE wo.tenant_id=$1 AND st.user_id=$2 AND wo.status <> $3
+		ORDER BY wo.updated_at, wo.id
+	`, tenantID, userID, models.WorkOrderApproved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.WorkOrder{}
+	for rows.Next() {
+		var wo models.WorkOrder
+		if err := scanFieldWorkOrder(rows, &wo); err != nil {
+			return nil, err
+		}
+		out = append(out, wo)
+	}
+	return out, rows.Err()
+}
+
+// GetAssignedWorkOrderTx loads and locks a work order, provided it is
+// assigned to one of the user's service staff records.
+func GetAssignedWorkOrderTx(ctx context.Context, tx Tx, tenantID, userID, id string) (models.WorkOrder, error) {
+	var wo models.WorkOrder
+	err := scanFieldWorkOrder(tx.QueryRow(ctx, `
+		SELECT `+fieldWorkOrderColumns+`
+		FROM work_orders wo
+		JOIN service_staff st ON st.tenant_id=wo.tenant_id AND st.id=wo.assigned_staff_id
+		WHERE wo.tenant_id=$1 AND st.user_id=$2 AND wo.id=$3
+		FOR UPDATE OF wo
+	`, tenantID, userID, id), &wo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.WorkOrder{}, errors.New("not found")
+		}
+		return models.WorkOrder{}, err
+	}
+	return wo, nil
+}
+
+// PartsByWorkOrders returns the BOM lines of the given work orders changed
+// after since, and all lines of the work orders in refreshIDs.
+func (r *FieldSyncRepo) PartsByWorkOrders(ctx context.Context, tenantID string, workOrderIDs, refreshIDs []string, since time.Time) ([]models.WorkOrderPart, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, school_id, work_order_id, service_shop_id, part_id,
+		       part_name, part_puk, part_category, device_model_id, is_compatible,
+		       qty_planned, qty_used, created_at, updated_at
+		FROM work_order_parts
+		WHERE tenant_id=$1 AND work_order_id = ANY($2) AND (updated_at > $3 OR work_order_id = ANY($4))
+		ORDER BY work_order_id, created_at, id
+	`, tenantID, workOrderIDs, since, refreshIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.WorkOrderPart{}
+	for rows.Next() {
+		var p models.WorkOrderPart
+		if err := rows.Scan(&p.ID, &p.TenantID, &p.SchoolID, &p.WorkOrderID, &p.ServiceShopID, &p.PartID,
+			&p.PartName, &p.PartPUK, &p.PartCategory, &p.DeviceModelID, &p.IsCompatible,
+			&p.QtyPlanned, &p.QtyUsed, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SchedulesByWorkOrders returns the schedules of the given work orders
+// created after since, and all schedules of the work orders in refreshIDs.
+// Schedules are append-only.
+func (r *FieldSyncRepo) SchedulesByWorkOrders(ctx context.Context, tenantID string, workOrderIDs, refreshIDs []string, since time.Time) ([]models.WorkOrderSchedule, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, school_id, work_order_id, scheduled_start, scheduled_end, timezone, notes, created_by_user_id, created_at
+		FROM work_order_schedules
+		WHERE tenant_id=$1 AND work_order_id = ANY($2) AND (created_at > $3 OR work_order_id = ANY($4))
+		ORDER BY work_order_id, created_at, id
+	`, tenantID, workOrderIDs, since, refreshIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.WorkOrderSchedule{}
+	for rows.Next() {
+		var x models.WorkOrderSchedule
+		if err := rows.Scan(&x.ID, &x.TenantID, &x.SchoolID, &x.WorkOrderID, &x.ScheduledStart, &x.ScheduledEnd, &x.Timezone, &x.Notes, &x.CreatedByUserID, &x.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+const fieldDeliverableColumns = `id, tenant_id, school_id, work_order_id, phase_id, title, description, status, evidence_attachment_id,
+	submitted_by_user_id, submitted_at, reviewed_by_user_id, reviewed_at, review_notes, created_at, updated_at`
+
+func scanFieldDeliverable(row pgx.Row, d *models.WorkOrderDeliverable) error {
+	return row.Scan(&d.ID, &d.TenantID, &d.SchoolID, &d.WorkOrderID, &d.PhaseID, &d.Title, &d.Description, &d.Status, &d.EvidenceAttachmentID,
+		&d.SubmittedByUserID, &d.SubmittedAt, &d.ReviewedByUserID, &d.ReviewedAt, &d.ReviewNotes, &d.CreatedAt, &d.UpdatedAt)
+}
+
+// DeliverablesByWorkOrders returns the deliverables of the given work orders
+// changed after since, and all deliverables of the work orders in refreshIDs.
+func (r *FieldSyncRepo) DeliverablesByWorkOrders(ctx context.Context, tenantID string, workOrderIDs, refreshIDs []string, since time.Time) ([]models.WorkOrderDeliverable, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+fieldDeliverableColumns+`
+		FROM work_order_deliverables
+		WHERE tenant_id=$1 AND work_order_id = ANY($2) AND (updated_at > $3 OR work_order_id = ANY($4))
+		ORDER BY work_order_id, created_at, id
+	`, tenantID, workOrderIDs, since, refreshIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.WorkOrderDeliverable{}
+	for rows.Next() {
+		var d models.WorkOrderDeliverable
+		if err := scanFieldDeliverable(rows, &d); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// SchoolsByIDs returns the school snapshots among ids changed after since,
+// and those in refreshIDs regardless of age.
+func (r *FieldSyncRepo) SchoolsByIDs(ctx context.Context, tenantID string, ids, refreshIDs []string, since time.Time) ([]models.SchoolSnapshot, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT tenant_id, school_id, name, county_code, county_name, sub_county_code, sub_county_name,
+			level, type, knec_code, uic, sex, cluster, accommodation, latitude, longitude, updated_at
+		FROM schools_snapshot
+		WHERE tenant_id=$1 AND school_id = ANY($2) AND (updated_at > $3 OR school_id = ANY($4))
+		ORDER BY school_id
+	`, tenantID, ids, since, refreshIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SchoolSnapshot{}
+	for rows.Next() {
+		var s models.SchoolSnapshot
+		if err := rows.Scan(&s.TenantID, &s.SchoolID, &s.Name, &s.CountyCode, &s.CountyName, &s.SubCountyCode, &s.SubCountyName,
+			&s.Level, &s.Type, &s.KnecCode, &s.Uic, &s.Sex, &s.Cluster, &s.Accommodation, &s.Latitude, &s.Longitude, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DevicesByIDs returns the device snapshots among ids changed after since,
+// and those in refreshIDs regardless of age.
+func (r *FieldSyncRepo) DevicesByIDs(ctx context.Context, tenantID string, ids, refreshIDs []string, since time.Time) ([]models.DeviceSnapshot, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT tenant_id, device_id, school_id, model, serial, asset_tag, status, updated_at
+		FROM devices_snapshot
+		WHERE tenant_id=$1 AND device_id = ANY($2) AND (updated_at > $3 OR device_id = ANY($4))
+		ORDER BY device_id
+	`, tenantID, ids, since, refreshIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.DeviceSnapshot{}
+	for rows.Next() {
+		var d models.DeviceSnapshot
+		if err := rows.Scan(&d.TenantID, &d.DeviceID, &d.SchoolID, &d.Model, &d.Serial, &d.AssetTag, &d.Status, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// GetWorkOrderPartTx loads and locks a BOM line.
+func GetWorkOrderPartTx(ctx context.Context, tx Tx, tenantID, schoolID, id string) (models.WorkOrderPart, error) {
+	var p models.WorkOrderPart
+	err := tx.QueryRow(ctx, `
+		SELECT id, tenant_id, school_id, work_order_id, service_shop_id, part_id,
+		       part_name, part_puk, part_category, device_model_id, is_compatible,
+		       qty_planned, qty_used, created_at, updated_at
+		FROM work_order_parts
+		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
+		FOR UPDATE
+	`, tenantID, schoolID, id).Scan(&p.ID, &p.TenantID, &p.SchoolID, &p.WorkOrderID, &p.ServiceShopID, &p.PartID,
+		&p.PartName, &p.PartPUK, &p.PartCategory, &p.DeviceModelID, &p.IsCompatible,
+		&p.QtyPlanned, &p.QtyUsed, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.WorkOrderPart{}, errors.New("not found")
+		}
+		return models.WorkOrderPart{}, err
+	}
+	return p, nil
+}
+
+// GetDeliverableTx loads and locks a deliverable.
+func GetDeliverableTx(ctx context.Context, tx Tx, tenantID, schoolID, id string) (models.WorkOrderDeliverable, error) {
+	var d models.WorkOrderDeliverable
+	err := scanFieldDeliverable(tx.QueryRow(ctx, `
+		SELECT `+fieldDeliverableColumns+`
+		FROM work_order_deliverables
+		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
+		FOR UPDATE
+	`, tenantID, schoolID, id), &d)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.WorkOrderDeliverable{}, errors.New("not found")
+		}
+		return models.WorkOrderDeliverable{}, err
+	}
+	return d, nil
+}
+
+// GetFieldMutationResult returns the stored result for a user's idempotency
+// key.
+func (r *FieldSyncRepo) GetFieldMutationResult(ctx context.Context, tenantID, userID, key string) (models.FieldMutationResult, error) {
+	var raw []byte
+	err := r.pool.QueryRow(ctx, `
+		SELECT result FROM field_sync_mutations WHERE tenant_id=$1 AND user_id=$2 AND idempotency_key=$3
+	`, tenantID, userID, key).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.FieldMutationResult{}, errors.New("not found")
+		}
+		return models.FieldMutationResult{}, err
+	}
+	var res struct {
+		models.FieldMutationResult
+		Entity json.RawMessage `json:"entity,omitempty"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return models.FieldMutationResult{}, err
+	}
+	out := res.FieldMutationResult
+	if len(res.Entity) > 0 {
+		out.Entity = res.Entity
+	}
+	return out, nil
+}
+
+// RecordFieldMutationTx stores the result of a mutation under the user's
+// idempotency key. It reports false, writing nothing, when the user's key has
+// already been recorded.
+func RecordFieldMutationTx(ctx context.Context, tx Tx, tenantID, userID, deviceID string, m models.FieldMutation, res models.FieldMutationResult, now time.Time) (bool, error) {
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO field_sync_mutations (tenant_id, idempotency_key, user_id, device_id, mutation_type, work_order_id,
+			client_ts, status, result, received_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		ON CONFLICT (tenant_id, user_id, idempotency_key) DO NOTHING
+	`, tenantID, m.IdempotencyKey, userID, deviceID, m.Type, m.WorkOrderID, m.ClientTimestamp, res.Status, raw, now)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Membership engine support for dynamic and location groups. Matching
+// itself lives in service.MatchesGroup; the repo resolves candidates and
+// applies the resulting membership with history.
+
+// GroupCandidates returns devices with their current location and its
+// ancestors. schoolID and deviceIDs narrow the set when given.
+func (r *GroupsRepo) GroupCandidates(ctx context.Context, tenantID, schoolID string, deviceIDs []string) ([]models.GroupCandidate, error) {
+	where := "d.tenant_id=$1"
+	args := []any{tenantID}
+	if schoolID != "" {
+		args = append(args, schoolID)
+		where += " AND d.school_id=$" + itoa(len(args))
+	}
+	if deviceIDs != nil {
+		args = append(args, deviceIDs)
+		where += " AND d.device_id = ANY($" + itoa(len(args)) + ")"
+	}
+	rows, err := r.pool.Query(ctx, `
+		WITH RECURSIVE loc_path AS (
+			SELECT id AS location_id, id AS ancestor_id, parent_id, 0 AS depth
+			FROM locations WHERE tenant_id=$1
+			UNION ALL
+			SELECT lp.location_id, l.id, l.parent_id, lp.depth + 1
+			FROM loc_path lp JOIN locations l ON l.id = lp.parent_id
+			WHERE lp.depth < 16
+		)
+		SELECT d.device_id, d.school_id, d.model, d.serial, d.asset_tag, d.status,
+		       COALESCE(a.location_id, ''), COALESCE(l.location_type, ''),
+		       COALESCE((SELECT array_agg(lp.ancestor_id) FROM loc_path lp WHERE lp.location_id = a.location_id), '{}')
+		FROM devices_snapshot d
+		LEFT JOIN device_assignments a ON a.tenant_id=d.tenant_id AND a.device_id=d.device_id AND a.effective_to IS NULL
+		LEFT JOIN locations l ON l.id = a.location_id
+		WHERE `+where+`
+		ORDER BY d.device_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.GroupCandidate{}
+	for rows.Next() {
+		var c models.GroupCandidate
+		if err := rows.Scan(&c.DeviceID, &c.SchoolID, &c.Model, &c.Serial, &c.AssetTag, &c.Lifecycle,
+			&c.LocationID, &c.LocationType, &c.LocationPath); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ListAutomatic returns active dynamic and location groups that can contain
+// devices of the school: the school's own groups and tenant-wide ones. An
+// empty schoolID returns all of the tenant's automatic groups.
+func (r *GroupsRepo) ListAutomatic(ctx context.Context, tenantID, schoolID string) ([]models.DeviceGroup, error) {
+	where := "tenant_id=$1 AND active=true AND group_type IN ('dynamic','location')"
+	args := []any{tenantID}
+	if schoolID != "" {
+		args = append(args, schoolID)
+		where += " AND (school_id=$2 OR school_id IS NULL)"
+	}
+	return r.queryGroups(ctx, `
+		SELECT `+groupColumns+` FROM device_groups WHERE `+where+` ORDER BY created_at, id`, args...)
+}
+
+// ListStale returns automatic groups whose inputs may have changed since
+// they were last evaluated: devices synced from SSOT, assignments started or
+// ended, locations edited, or members whose device has been deleted.
+func (r *GroupsRepo) ListStale(ctx context.Context, limit int) ([]models.DeviceGroup, error) {
+	return r.queryGroups(ctx, `
+		SELECT `+groupColumns+`
+		FROM device_groups g
+		WHERE g.active=true AND g.group_type IN ('dynamic','location')
+		  AND (
+			g.evaluated_at IS NULL
+			OR EXISTS (SELECT 1 FROM devices_snapshot d WHERE d.tenant_id=g.tenant_id AND d.updated_at > g.evaluated_at)
+			OR EXISTS (SELECT 1 FROM device_assignments a WHERE a.tenant_id=g.tenant_id
+				AND (a.created_at > g.evaluated_at OR a.effective_to > g.evaluated_at))
+			OR EXISTS (SELECT 1 FROM locations l WHERE l.tenant_id=g.tenant_id AND l.updated_at > g.evaluated_at)
+			OR EXISTS (SELECT 1 FROM group_members m WHERE m.group_id=g.id
+				AND NOT EXISTS (SELECT 1 FROM devices_snapshot d WHERE d.tenant_id=m.tenant_id AND d.device_id=m.device_id))
+		  )
+		ORDER BY g.evaluated_at NULLS FIRST, g.id
+		LIMIT $1
+	`, limit)
+}
+
+func (r *GroupsRepo) queryGroups(ctx context.Context, sql string, args ...any) ([]models.DeviceGroup, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.DeviceGroup{}
+	for rows.Next() {
+		var g models.DeviceGroup
+		if err := scanGroup(rows, &g); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// SyncMembers makes the group's membership within scope equal to desired and
+// records each change as a membership event. A nil scope covers the whole
+// group and also stamps it as evaluated at now; otherwise only members whose
+// device is in scope can be removed.
+func (r *GroupsRepo) SyncMembers(ctx context.Context, tenantID, groupID string, desired, scope []string, reason string, now time.Time) (added, removed []string, err error) {
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		// Lock the group so concurrent evaluations apply one at a time.
+		var id string
+		if err := tx.QueryRow(ctx, `SELECT id FROM device_groups WHERE tenant_id=$1 AND id=$2 FOR UPDATE`, tenantID, groupID).Scan(&id); err != nil {
+			return err
+		}
+
+		current := map[string]bool{}
+		rows, err := tx.Query(ctx, `SELECT device_id FROM group_members WHERE tenant_id=$1 AND group_id=$2`, tenantID, groupID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var d string
+			if err := rows.Scan(&d); err != nil {
+				rows.Close()
+				return err
+			}
+			current[d] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		want := map[string]bool{}
+		for _, d := range desired {
+			want[d] = true
+			if !current[d] {
+				added = append(added, d)
+			}
+		}
+		if scope == nil {
+			for d := range current {
+				if !want[d] {
+					removed = append(removed, d)
+				}
+			}
+		} else {
+			for _, d := range scope {
+				if current[d] && !want[d] {
+					removed = append(removed, d)
+				}
+			}
+		}
+
+		for _, d := range added {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO group_members (id, tenant_id, group_id, device_id, added_at, added_by)
+				VALUES ($1,$2,$3,$4,$5,$6)
+				ON CONFLICT (group_id, device_id) DO NOTHING
+			`, NewID("gm"), tenantID, groupID, d, now, "system:"+reason); err != nil {
+				return err
+			}
+			if err := insertMembershipEvent(ctx, tx, tenantID, groupID, d, "added", reason, now); err != nil {
+				return err
+			}
+		}
+		if len(removed) > 0 {
+			if _, err := tx.Exec(ctx, `
+				DELETE FROM group_members WHERE tenant_id=$1 AND group_id=$2 AND device_id = ANY($3)
+			`, tenantID, groupID, removed); err != nil {
+				return err
+			}
+			for _, d := range removed {
+				if err := insertMembershipEvent(ctx, tx, tenantID, groupID, d, "removed", reason, now); err != nil {
+					return err
+				}
+			}
+		}
+		if scope == nil {
+			if _, err := tx.Exec(ctx, `UPDATE device_groups SET evaluated_at=$3 WHERE tenant_id=$1 AND id=$2`, tenantID, groupID, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return added, removed, nil
+}
+
+func insertMembershipEvent(ctx context.Context, tx Tx, tenantID, groupID, deviceID, action, reason string, now time.Time) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO group_membership_events (id, tenant_id, group_id, device_id, action, reason, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+	`, NewID("gme"), tenantID, groupID, deviceID, action, reason, now)
+	return err
+}
+
+// ListMembershipEvents returns a group's membership history, newest first.
+func (r *GroupsRepo) ListMembershipEvents(ctx context.Context, tenantID, groupID string, limit, offset int) ([]models.GroupMembershipEvent, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, group_id, device_id, action, reason, created_at
+		FROM group_membership_events
+		WHERE tenant_id=$1 AND group_id=$2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, tenantID, groupID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.GroupMembershipEvent{}
+	for rows.Next() {
+		var e models.GroupMembershipEvent
+		if err := rows.Scan(&e.ID, &e.TenantID, &e.GroupID, &e.DeviceID, &e.Action, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// SetPolicy replaces a group's policy, or clears it when p is nil, bumps the
+// group's policy version and keeps the new revision. It returns the updated
+// group.
+func (r *GroupsRepo) SetPolicy(ctx context.Context, tenantID, groupID string, p *models.DevicePolicy, changedBy string, now time.Time) (models.DeviceGroup, error) {
+	var policyJSON []byte
+	if p != nil {
+		var err error
+		if policyJSON, err = json.Marshal(p); err != nil {
+			return models.DeviceGroup{}, err
+		}
+	}
+
+	var g models.DeviceGroup
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, `
+			UPDATE device_groups SET policies=COALESCE($3::jsonb, '{}'::jsonb), policy_version=policy_version+1, updated_at=$4
+			WHERE tenant_id=$1 AND id=$2
+			RETURNING `+groupColumns, tenantID, groupID, policyJSON, now)
+		if err := scanGroup(row, &g); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errors.New("not found")
+			}
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO device_group_policy_versions (tenant_id, group_id, version, policy, changed_by, created_at)
+			VALUES ($1,$2,$3,$4,$5,$6)
+		`, tenantID, groupID, g.PolicyVersion, policyJSON, changedBy, now)
+		return err
+	})
+	if err != nil {
+		return models.DeviceGroup{}, err
+	}
+	return g, nil
+}
+
+// ListPolicyVersions returns a group's policy revisions, newest first.
+func (r *GroupsRepo) ListPolicyVersions(ctx context.Context, tenantID, groupID string, limit, offset int) ([]models.GroupPolicyVersion, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT group_id, version, policy, changed_by, created_at
+		FROM device_group_policy_versions
+		WHERE tenant_id=$1 AND group_id=$2
+		ORDER BY version DESC
+		LIMIT $3 OFFSET $4
+	`, tenantID, groupID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.GroupPolicyVersion{}
+	for rows.Next() {
+		var v models.GroupPolicyVersion
+		var policyJSON []byte
+		if err := rows.Scan(&v.GroupID, &v.Version, &policyJSON, &v.ChangedBy, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(policyJSON) > 0 {
+			var p models.DevicePolicy
+			if err := json.Unmarshal(policyJSON, &p); err != nil {
+				return nil, err
+			}
+			v.Policy = &p
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
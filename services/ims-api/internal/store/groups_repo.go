@@ -14,11 +14,32 @@ import (
 
 type GroupsRepo struct{ pool *pgxpool.Pool }
 
-func (r *GroupsRepo) Create(ctx context.Context, g models.DeviceGroup) error {
-	policies := g.Policies
-	if len(policies) == 0 {
-		policies = []byte("{}")
+const groupColumns = `id, tenant_id, school_id, name, description, group_type, location_id, selector, policies, policy_version, active, evaluated_at, created_at, updated_at`
+
+func scanGroup(row pgx.Row, g *models.DeviceGroup) error {
+	var policies, selectorJSON []byte
+	if err := row.Scan(&g.ID, &g.TenantID, &g.SchoolID, &g.Name, &g.Description, &g.GroupType, &g.LocationID, &selectorJSON, &policies,
+		&g.PolicyVersion, &g.Active, &g.EvaluatedAt, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		var p models.DevicePolicy
+		if err := json.Unmarshal(policies, &p); err == nil && !p.IsEmpty() {
+			g.Policies = &p
+		}
+	}
+	if len(selectorJSON) > 0 {
+		var sel models.GroupSelector
+		if err := json.Unmarshal(selectorJSON, &sel); err == nil {
+			g.Selector = &sel
+		}
 	}
+	return nil
+}
+
+// Create inserts a group without a policy; policies are set through
+// SetPolicy so that every one is versioned.
+func (r *GroupsRepo) Create(ctx context.Context, g models.DeviceGroup) error {
 	var selectorJSON []byte
 	if g.Selector != nil {
 		var err error
@@ -28,17 +49,15 @@ func (r *GroupsRepo) Create(ctx context.Context, g models.DeviceGroup) error {
 		}
 	}
 	_, err := r.pool.Exec(ctx, `
-		INSERT INTO device_groups (id, tenant_id, school_id, name, description, group_type, location_id, selector, policies, active, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
-	`, g.ID, g.TenantID, g.SchoolID, g.Name, g.Description, g.GroupType, g.LocationID, selectorJSON, policies, g.Active, g.CreatedAt, g.UpdatedAt)
+		INSERT INTO device_groups (id, tenant_id, school_id, name, description, group_type, location_id, selector, active, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+	`, g.ID, g.TenantID, g.SchoolID, g.Name, g.Description, g.GroupType, g.LocationID, selectorJSON, g.Active, g.CreatedAt, g.UpdatedAt)
 	return err
 }
 
+// Update saves a group's definition. Policies are versioned and only change
+// through SetPolicy.
 func (r *GroupsRepo) Update(ctx context.Context, g models.DeviceGroup) error {
-	policies := g.Policies
-	if len(policies) == 0 {
-		policies = []byte("{}")
-	}
 	var selectorJSON []byte
 	if g.Selector != nil {
 		var err error
@@ -49,9 +68,9 @@ func (r *GroupsRepo) Update(ctx context.Context, g models.DeviceGroup) error {
 	}
 	result, err := r.pool.Exec(ctx, `
 		UPDATE device_groups SET
-			school_id=$3, name=$4, description=$5, group_type=$6, location_id=$7, selector=$8, policies=$9, active=$10, updated_at=$11
+			school_id=$3, name=$4, description=$5, group_type=$6, location_id=$7, selector=$8, active=$9, updated_at=$10
 		WHERE tenant_id=$1 AND id=$2
-	`, g.TenantID, g.ID, g.SchoolID, g.Name, g.Description, g.GroupType, g.LocationID, selectorJSON, policies, g.Active, g.UpdatedAt)
+	`, g.TenantID, g.ID, g.SchoolID, g.Name, g.Description, g.GroupType, g.LocationID, selectorJSON, g.Active, g.UpdatedAt)
 	if err != nil {
 		return err
 	}
@@ -63,24 +82,16 @@ func (r *GroupsRepo) Update(ctx context.Context, g models.DeviceGroup) error {
 
 func (r *GroupsRepo) Get(ctx context.Context, tenantID, id string) (models.DeviceGroup, error) {
 	var g models.DeviceGroup
-	var policies, selectorJSON []byte
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_id, school_id, name, description, group_type, location_id, selector, policies, active, created_at, updated_at
+		SELECT `+groupColumns+`
 		FROM device_groups WHERE tenant_id=$1 AND id=$2
 	`, tenantID, id)
-	if err := row.Scan(&g.ID, &g.TenantID, &g.SchoolID, &g.Name, &g.Description, &g.GroupType, &g.LocationID, &selectorJSON, &policies, &g.Active, &g.CreatedAt, &g.UpdatedAt); err != nil {
+	if err := scanGroup(row, &g); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.DeviceGroup{}, errors.New("not found")
 		}
 		return models.DeviceGroup{}, err
 	}
-	g.Policies = policies
-	if len(selectorJSON) > 0 {
-		var sel models.GroupSelector
-		if err := json.Unmarshal(selectorJSON, &sel); err == nil {
-			g.Selector = &sel
-		}
-	}
 	return g, nil
 }
 
@@ -140,7 +151,7 @@ func (r *GroupsRepo) List(ctx context.Context, p GroupListParams) ([]models.Devi
 	args = append(args, limitPlus)
 
 	sql := `
-		SELECT id, tenant_id, school_id, name, description, group_type, location_id, selector, policies, active, created_at, updated_at
+		SELECT ` + groupColumns + `
 		FROM device_groups
 		WHERE ` + strings.Join(conds, " AND ") + `
 		ORDER BY created_at DESC, id DESC
@@ -155,17 +166,9 @@ func (r *GroupsRepo) List(ctx context.Context, p GroupListParams) ([]models.Devi
 	out := []models.DeviceGroup{}
 	for rows.Next() {
 		var g models.DeviceGroup
-		var policies, selectorJSON []byte
-		if err := rows.Scan(&g.ID, &g.TenantID, &g.SchoolID, &g.Name, &g.Description, &g.GroupType, &g.LocationID, &selectorJSON, &policies, &g.Active, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		if err := scanGroup(rows, &g); err != nil {
 			return nil, "", err
 		}
-		g.Policies = policies
-		if len(selectorJSON) > 0 {
-			var sel models.GroupSelector
-			if err := json.Unmarshal(selectorJSON, &sel); err == nil {
-				g.Selector = &sel
-			}
-		}
 		out = append(out, g)
 	}
 
@@ -181,7 +184,7 @@ func (r *GroupsRepo) List(ctx context.Context, p GroupListParams) ([]models.Devi
 // ListBySchool returns all active groups for a school (including tenant-wide)
 func (r *GroupsRepo) ListBySchool(ctx context.Context, tenantID, schoolID string) ([]models.DeviceGroup, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, tenant_id, school_id, name, description, group_type, location_id, selector, policies, active, created_at, updated_at
+		SELECT `+groupColumns+`
 		FROM device_groups
 		WHERE tenant_id=$1 AND (school_id=$2 OR school_id IS NULL) AND active=true
 		ORDER BY name
@@ -194,17 +197,9 @@ func (r *GroupsRepo) ListBySchool(ctx context.Context, tenantID, schoolID string
 	out := []models.DeviceGroup{}
 	for rows.Next() {
 		var g models.DeviceGroup
-		var policies, selectorJSON []byte
-		if err := rows.Scan(&g.ID, &g.TenantID, &g.SchoolID, &g.Name, &g.Description, &g.GroupType, &g.LocationID, &selectorJSON, &policies, &g.Active, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		if err := scanGroup(rows, &g); err != nil {
 			return nil, err
 		}
-		g.Policies = policies
-		if len(selectorJSON) > 0 {
-			var sel models.GroupSelector
-			if err := json.Unmarshal(selectorJSON, &sel); err == nil {
-				g.Selector = &sel
-			}
-		}
 		out = append(out, g)
 	}
 	return out, nil
@@ -264,7 +259,7 @@ func (r *GroupsRepo) GetMemberCount(ctx context.Context, tenantID, groupID strin
 // GetGroupsForDevice returns all groups a device belongs to
 func (r *GroupsRepo) GetGroupsForDevice(ctx context.Context, tenantID, deviceID string) ([]models.DeviceGroup, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT g.id, g.tenant_id, g.school_id, g.name, g.description, g.group_type, g.location_id, g.selector, g.policies, g.active, g.created_at, g.updated_at
+		SELECT g.id, g.tenant_id, g.school_id, g.name, g.description, g.group_type, g.location_id, g.selector, g.policies, g.policy_version, g.active, g.evaluated_at, g.created_at, g.updated_at
 		FROM device_groups g
 		JOIN group_members gm ON gm.group_id = g.id
 		WHERE g.tenant_id=$1 AND gm.device_id=$2 AND g.active=true
@@ -278,17 +273,9 @@ func (r *GroupsRepo) GetGroupsForDevice(ctx context.Context, tenantID, deviceID
 	out := []models.DeviceGroup{}
 	for rows.Next() {
 		var g models.DeviceGroup
-		var policies, selectorJSON []byte
-		if err := rows.Scan(&g.ID, &g.TenantID, &g.SchoolID, &g.Name, &g.Description, &g.GroupType, &g.LocationID, &selectorJSON, &policies, &g.Active, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		if err := scanGroup(rows, &g); err != nil {
 			return nil, err
 		}
-		g.Policies = policies
-		if len(selectorJSON) > 0 {
-			var sel models.GroupSelector
-			if err := json.Unmarshal(selectorJSON, &sel); err == nil {
-				g.Selector = &sel
-			}
-		}
 		out = append(out, g)
 	}
 	return out, nil
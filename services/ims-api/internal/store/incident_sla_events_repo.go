@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IncidentSLAEventsRepo stores the SLA timeline of incidents.
+type IncidentSLAEventsRepo struct{ pool *pgxpool.Pool }
+
+func (r *IncidentSLAEventsRepo) Create(ctx context.Context, e models.IncidentSLAEvent) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO incident_sla_events (
+			id, tenant_id, incident_id, event_type, threshold_pct, due_at, actor_user_id, note, created_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+	`, e.ID, e.TenantID, e.IncidentID, e.EventType, e.ThresholdPct, e.DueAt, e.ActorUserID, e.Note, e.CreatedAt)
+	return err
+}
+
+// ListByIncident returns an incident's SLA events, oldest first.
+func (r *IncidentSLAEventsRepo) ListByIncident(ctx context.Context, tenantID, incidentID string) ([]models.IncidentSLAEvent, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, incident_id, event_type, threshold_pct, due_at, actor_user_id, note, created_at
+		FROM incident_sla_events
+		WHERE tenant_id=$1 AND incident_id=$2
+		ORDER BY created_at ASC, id ASC
+	`, tenantID, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.IncidentSLAEvent{}
+	for rows.Next() {
+		var e models.IncidentSLAEvent
+		if err := rows.Scan(&e.ID, &e.TenantID, &e.IncidentID, &e.EventType, &e.ThresholdPct, &e.DueAt,
+			&e.ActorUserID, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -15,28 +15,36 @@ type IncidentRepo struct {
 }
 
 func (r *IncidentRepo) Create(ctx context.Context, inc models.Incident) error {
-	_, err := r.pool.Exec(ctx, `
+	return CreateIncidentTx(ctx, r.pool, inc)
+}
+
+// CreateIncidentTx inserts an incident within a transaction.
+func CreateIncidentTx(ctx context.Context, tx Tx, inc models.Incident) error {
+	_, err := tx.Exec(ctx, `
 		INSERT INTO incidents (
 			id, tenant_id, school_id, device_id,
 			school_name, county_id, county_name, sub_county_id, sub_county_name,
 			contact_name, contact_phone, contact_email,
 			device_serial, device_asset_tag, device_model_id, device_make, device_model, device_category,
 			category, severity, status,
-			title, description, reported_by, sla_due_at, sla_breached, created_at, updated_at
+			title, description, reported_by, sla_due_at, sla_breached, created_at, updated_at,
+			sla_policy_id, sla_response_due_at, sla_response_breached, sla_budget_seconds
 		) VALUES (
 			$1,$2,$3,$4,
 			$5,$6,$7,$8,$9,
 			$10,$11,$12,
 			$13,$14,$15,$16,$17,$18,
 			$19,$20,$21,
-			$22,$23,$24,$25,$26,$27,$28
+			$22,$23,$24,$25,$26,$27,$28,
+			$29,$30,$31,$32
 		)
 	`, inc.ID, inc.TenantID, inc.SchoolID, inc.DeviceID,
 		inc.SchoolName, inc.CountyID, inc.CountyName, inc.SubCountyID, inc.SubCountyName,
 		inc.ContactName, inc.ContactPhone, inc.ContactEmail,
 		inc.DeviceSerial, inc.DeviceAssetTag, inc.DeviceModelID, inc.DeviceMake, inc.DeviceModel, inc.DeviceCategory,
 		inc.Category, inc.Severity, inc.Status,
-		inc.Title, inc.Description, inc.ReportedBy, inc.SLADueAt, inc.SLABreached, inc.CreatedAt, inc.UpdatedAt)
+		inc.Title, inc.Description, inc.ReportedBy, inc.SLADueAt, inc.SLABreached, inc.CreatedAt, inc.UpdatedAt,
+		inc.SLAPolicyID, inc.SLAResponseDueAt, inc.SLAResponseBreached, inc.SLABudgetSeconds)
 	return err
 }
 
@@ -44,13 +52,17 @@ func (r *IncidentRepo) GetByID(ctx context.Context, tenantID, schoolID, id strin
 	var inc models.Incident
 	row := r.pool.QueryRow(ctx, `
 		SELECT id, tenant_id, school_id, device_id, category, severity, status,
-		       title, description, reported_by, sla_due_at, sla_breached, created_at, updated_at
+		       title, description, reported_by, sla_due_at, sla_breached, sla_policy_id,
+		       sla_response_due_at, sla_response_breached,
+		       sla_paused_at, sla_remaining_seconds, sla_budget_seconds, sla_warned_pct, created_at, updated_at
 		FROM incidents
 		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
 	`, tenantID, schoolID, id)
 
 	err := row.Scan(&inc.ID, &inc.TenantID, &inc.SchoolID, &inc.DeviceID, &inc.Category, &inc.Severity, &inc.Status,
-		&inc.Title, &inc.Description, &inc.ReportedBy, &inc.SLADueAt, &inc.SLABreached, &inc.CreatedAt, &inc.UpdatedAt)
+		&inc.Title, &inc.Description, &inc.ReportedBy, &inc.SLADueAt, &inc.SLABreached, &inc.SLAPolicyID,
+		&inc.SLAResponseDueAt, &inc.SLAResponseBreached,
+		&inc.SLAPausedAt, &inc.SLARemainingSeconds, &inc.SLABudgetSeconds, &inc.SLAWarnedPct, &inc.CreatedAt, &inc.UpdatedAt)
 	if err != nil {
 		return models.Incident{}, errors.New("not found")
 	}
@@ -102,7 +114,9 @@ func (r *IncidentRepo) List(ctx context.Context, p IncidentListParams) ([]models
 
 	sql := `
 		SELECT id, tenant_id, school_id, device_id, category, severity, status,
-		       title, description, reported_by, sla_due_at, sla_breached, created_at, updated_at
+		       title, description, reported_by, sla_due_at, sla_breached, sla_policy_id,
+		       sla_response_due_at, sla_response_breached,
+		       sla_paused_at, sla_remaining_seconds, sla_budget_seconds, sla_warned_pct, created_at, updated_at
 		FROM incidents
 		WHERE ` + strings.Join(conds, " AND ") + `
 		ORDER BY created_at DESC, id DESC
@@ -118,7 +132,9 @@ func (r *IncidentRepo) List(ctx context.Context, p IncidentListParams) ([]models
 	for rows.Next() {
 		var inc models.Incident
 		if err := rows.Scan(&inc.ID, &inc.TenantID, &inc.SchoolID, &inc.DeviceID, &inc.Category, &inc.Severity, &inc.Status,
-			&inc.Title, &inc.Description, &inc.ReportedBy, &inc.SLADueAt, &inc.SLABreached, &inc.CreatedAt, &inc.UpdatedAt); err != nil {
+			&inc.Title, &inc.Description, &inc.ReportedBy, &inc.SLADueAt, &inc.SLABreached, &inc.SLAPolicyID,
+			&inc.SLAResponseDueAt, &inc.SLAResponseBreached,
+			&inc.SLAPausedAt, &inc.SLARemainingSeconds, &inc.SLABudgetSeconds, &inc.SLAWarnedPct, &inc.CreatedAt, &inc.UpdatedAt); err != nil {
 			return nil, "", err
 		}
 		out = append(out, inc)
@@ -134,25 +150,193 @@ func (r *IncidentRepo) List(ctx context.Context, p IncidentListParams) ([]models
 }
 
 func (r *IncidentRepo) UpdateStatus(ctx context.Context, tenantID, schoolID, id string, status models.IncidentStatus, now time.Time) (models.Incident, error) {
-	_, err := r.pool.Exec(ctx, `
+	if err := UpdateIncidentStatusTx(ctx, r.pool, tenantID, schoolID, id, status, now); err != nil {
+		return models.Incident{}, err
+	}
+	return r.GetByID(ctx, tenantID, schoolID, id)
+}
+
+// ListOpenForDevice returns a device's incidents that are not resolved or
+// closed, oldest first.
+func (r *IncidentRepo) ListOpenForDevice(ctx context.Context, tenantID, deviceID string) ([]models.Incident, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, school_id, device_id, category, severity, status,
+		       title, description, reported_by, sla_due_at, sla_breached, sla_policy_id,
+		       sla_response_due_at, sla_response_breached,
+		       sla_paused_at, sla_remaining_seconds, sla_budget_seconds, sla_warned_pct, created_at, updated_at
+		FROM incidents
+		WHERE tenant_id=$1 AND device_id=$2 AND status NOT IN ('resolved', 'closed')
+		ORDER BY created_at, id
+	`, tenantID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.Incident{}
+	for rows.Next() {
+		var inc models.Incident
+		if err := rows.Scan(&inc.ID, &inc.TenantID, &inc.SchoolID, &inc.DeviceID, &inc.Category, &inc.Severity, &inc.Status,
+			&inc.Title, &inc.Description, &inc.ReportedBy, &inc.SLADueAt, &inc.SLABreached, &inc.SLAPolicyID,
+			&inc.SLAResponseDueAt, &inc.SLAResponseBreached,
+			&inc.SLAPausedAt, &inc.SLARemainingSeconds, &inc.SLABudgetSeconds, &inc.SLAWarnedPct, &inc.CreatedAt, &inc.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, inc)
+	}
+	return out, rows.Err()
+}
+
+// ChangeIncidentStatusFromTx sets an incident's status only if it is still
+// from. It reports false if the status changed in the meantime.
+func ChangeIncidentStatusFromTx(ctx context.Context, tx Tx, tenantID, id string, from, to models.IncidentStatus, now time.Time) (bool, error) {
+	tag, err := tx.Exec(ctx, `
+		UPDATE incidents
+		SET status=$4, updated_at=$5
+		WHERE tenant_id=$1 AND id=$2 AND status=$3
+	`, tenantID, id, from, to, now)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdateIncidentStatusTx sets an incident's status within a transaction.
+func UpdateIncidentStatusTx(ctx context.Context, tx Tx, tenantID, schoolID, id string, status models.IncidentStatus, now time.Time) error {
+	_, err := tx.Exec(ctx, `
 		UPDATE incidents
 		SET status=$4, updated_at=$5
 		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
 	`, tenantID, schoolID, id, status, now)
+	return err
+}
+
+// MarkSLABreaches flags incidents whose resolution deadline has passed, and
+// incidents still unacknowledged past their policy's response deadline.
+// Deadlines are fixed at creation from the tenant SLA policy and calendar, so
+// comparing against now is already business-hours aware. Paused clocks are
+// skipped.
+func (r *IncidentRepo) MarkSLABreaches(ctx context.Context, now time.Time) (int, error) {
+	breached, err := r.BreachOverdue(ctx, now)
 	if err != nil {
-		return models.Incident{}, err
+		return 0, err
 	}
-	return r.GetByID(ctx, tenantID, schoolID, id)
+	responded, err := r.MarkResponseBreaches(ctx, now)
+	return len(breached) + len(responded), err
 }
 
-func (r *IncidentRepo) MarkSLABreaches(ctx context.Context, now time.Time) (int, error) {
-	tag, err := r.pool.Exec(ctx, `
+// BreachOverdue flags running SLA clocks past their resolution deadline and
+// returns the incidents it flagged.
+func (r *IncidentRepo) BreachOverdue(ctx context.Context, now time.Time) ([]models.Incident, error) {
+	rows, err := r.pool.Query(ctx, `
 		UPDATE incidents
 		SET sla_breached=true, updated_at=$1
-		WHERE sla_breached=false AND sla_due_at < $1 AND status NOT IN ('resolved','closed')
+		WHERE sla_breached=false AND sla_paused_at IS NULL AND sla_due_at < $1
+		  AND status NOT IN ('resolved','closed')
+		RETURNING id, tenant_id, school_id, severity, status, title, sla_due_at
 	`, now)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Incident{}
+	for rows.Next() {
+		var inc models.Incident
+		if err := rows.Scan(&inc.ID, &inc.TenantID, &inc.SchoolID, &inc.Severity, &inc.Status, &inc.Title, &inc.SLADueAt); err != nil {
+			return nil, err
+		}
+		inc.SLABreached = true
+		out = append(out, inc)
+	}
+	return out, rows.Err()
+}
+
+// MarkResponseBreaches flags new incidents past their response deadline and
+// returns the incidents it flagged.
+func (r *IncidentRepo) MarkResponseBreaches(ctx context.Context, now time.Time) ([]models.Incident, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE incidents
+		SET sla_response_breached=true, updated_at=$1
+		WHERE sla_response_breached=false AND sla_response_due_at < $1 AND status='new'
+		RETURNING id, tenant_id, school_id, severity, status, title, sla_due_at
+	`, now)
+	if err != nil {
+		return nil, err
 	}
-	return int(tag.RowsAffected()), nil
+	defer rows.Close()
+
+	out := []models.Incident{}
+	for rows.Next() {
+		var inc models.Incident
+		if err := rows.Scan(&inc.ID, &inc.TenantID, &inc.SchoolID, &inc.Severity, &inc.Status, &inc.Title, &inc.SLADueAt); err != nil {
+			return nil, err
+		}
+		inc.SLAResponseBreached = true
+		out = append(out, inc)
+	}
+	return out, rows.Err()
+}
+
+// ListSLARunning returns open incidents whose SLA clock is running and not yet
+// breached, for warning evaluation.
+func (r *IncidentRepo) ListSLARunning(ctx context.Context, limit int) ([]models.Incident, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, school_id, severity, status, title, sla_due_at, sla_policy_id,
+		       sla_budget_seconds, sla_warned_pct, created_at
+		FROM incidents
+		WHERE sla_breached=false AND sla_paused_at IS NULL AND sla_budget_seconds > 0
+		  AND status NOT IN ('resolved','closed')
+		ORDER BY sla_due_at ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Incident{}
+	for rows.Next() {
+		var inc models.Incident
+		if err := rows.Scan(&inc.ID, &inc.TenantID, &inc.SchoolID, &inc.Severity, &inc.Status, &inc.Title,
+			&inc.SLADueAt, &inc.SLAPolicyID, &inc.SLABudgetSeconds, &inc.SLAWarnedPct, &inc.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, inc)
+	}
+	return out, rows.Err()
+}
+
+// SetSLAWarnedPct records the highest warning threshold raised. It returns
+// false if an equal or higher warning was already recorded, so concurrent
+// schedulers never notify twice.
+func (r *IncidentRepo) SetSLAWarnedPct(ctx context.Context, tenantID, id string, pct int, now time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE incidents
+		SET sla_warned_pct=$3, updated_at=$4
+		WHERE tenant_id=$1 AND id=$2 AND sla_warned_pct < $3
+	`, tenantID, id, pct, now)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// PauseSLA stops the SLA clock, remembering how much SLA time was left.
+func (r *IncidentRepo) PauseSLA(ctx context.Context, tenantID, id string, remaining time.Duration, now time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE incidents
+		SET sla_paused_at=$3, sla_remaining_seconds=$4, updated_at=$3
+		WHERE tenant_id=$1 AND id=$2 AND sla_paused_at IS NULL
+	`, tenantID, id, now, int64(remaining.Seconds()))
+	return err
+}
+
+// ResumeSLA restarts the SLA clock with a new resolution deadline.
+func (r *IncidentRepo) ResumeSLA(ctx context.Context, tenantID, id string, dueAt, now time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE incidents
+		SET sla_paused_at=NULL, sla_remaining_seconds=0, sla_due_at=$3, updated_at=$4
+		WHERE tenant_id=$1 AND id=$2 AND sla_paused_at IS NOT NULL
+	`, tenantID, id, dueAt, now)
+	return err
 }
@@ -12,14 +12,39 @@ import (
 
 type InventoryRepo struct{ pool *pgxpool.Pool }
 
-func (r *InventoryRepo) Upsert(ctx context.Context, i models.InventoryItem) error {
-	_, err := r.pool.Exec(ctx, `
+// SetStockLevelTx brings a shop's on-hand quantity to onHand by posting a
+// correction adjustment for the difference, and sets the reorder threshold.
+// Stock quantities are never written directly.
+func SetStockLevelTx(ctx context.Context, tx Tx, tenantID, shopID, partID string, onHand, reorderThreshold int64, actorID string) (models.InventoryItem, error) {
+	bal, err := StockBalanceTx(ctx, tx, tenantID, shopID, partID)
+	if err != nil {
+		return models.InventoryItem{}, err
+	}
+	if delta := onHand - bal.OnHand; delta != 0 {
+		if _, err := PostStockMovementTx(ctx, tx, models.StockMovement{
+			TenantID:      tenantID,
+			Type:          models.StockAdjustment,
+			ServiceShopID: shopID,
+			PartID:        partID,
+			Qty:           delta,
+			ReasonCode:    string(models.ReasonCorrection),
+			Notes:         "stock level set",
+			ActorID:       actorID,
+		}); err != nil {
+			return models.InventoryItem{}, err
+		}
+	}
+
+	var i models.InventoryItem
+	err = tx.QueryRow(ctx, `
 		INSERT INTO inventory (id, tenant_id, service_shop_id, part_id, qty_available, qty_reserved, reorder_threshold, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		VALUES ($1,$2,$3,$4,0,0,$5,$6)
 		ON CONFLICT (tenant_id, service_shop_id, part_id)
-		DO UPDATE SET qty_available=EXCLUDED.qty_available, qty_reserved=EXCLUDED.qty_reserved, reorder_threshold=EXCLUDED.reorder_threshold, updated_at=EXCLUDED.updated_at
-	`, i.ID, i.TenantID, i.ServiceShopID, i.PartID, i.QtyAvailable, i.QtyReserved, i.ReorderThreshold, i.UpdatedAt)
-	return err
+		DO UPDATE SET reorder_threshold=EXCLUDED.reorder_threshold, updated_at=EXCLUDED.updated_at
+		RETURNING id, tenant_id, service_shop_id, part_id, qty_available, qty_reserved, reorder_threshold, updated_at
+	`, NewID("inv"), tenantID, shopID, partID, reorderThreshold, time.Now().UTC()).Scan(
+		&i.ID, &i.TenantID, &i.ServiceShopID, &i.PartID, &i.QtyAvailable, &i.QtyReserved, &i.ReorderThreshold, &i.UpdatedAt)
+	return i, err
 }
 
 func (r *InventoryRepo) Get(ctx context.Context, tenantID, shopID, partID string) (models.InventoryItem, error) {
@@ -94,34 +119,3 @@ func (r *InventoryRepo) List(ctx context.Context, p InventoryListParams) ([]mode
 	}
 	return out, next, nil
 }
-func (r *InventoryRepo) Reserve(ctx context.Context, tenantID, shopID, partID string, qty int64) error {
-	// Ensures (qty_available - qty_reserved) >= qty
-	_, err := r.pool.Exec(ctx, `
-		UPDATE inventory
-		SET qty_reserved = qty_reserved + $4, updated_at = $5
-		WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
-		  AND (qty_available - qty_reserved) >= $4
-	`, tenantID, shopID, partID, qty, time.Now().UTC())
-	return err
-}
-
-func (r *InventoryRepo) Release(ctx context.Context, tenantID, shopID, partID string, qty int64) error {
-	_, err := r.pool.Exec(ctx, `
-		UPDATE inventory
-		SET qty_reserved = GREATEST(qty_reserved - $4, 0), updated_at = $5
-		WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
-	`, tenantID, shopID, partID, qty, time.Now().UTC())
-	return err
-}
-
-func (r *InventoryRepo) Consume(ctx context.Context, tenantID, shopID, partID string, qty int64) error {
-	// Decrease both reserved and available (on-hand)
-	_, err := r.pool.Exec(ctx, `
-		UPDATE inventory
-		SET qty_reserved = GREATEST(qty_reserved - $4, 0),
-		    qty_available = GREATEST(qty_available - $4, 0),
-		    updated_at = $5
-		WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
-	`, tenantID, shopID, partID, qty, time.Now().UTC())
-	return err
-}
@@ -350,3 +350,100 @@ func (r *KBArticleRepo) SlugExists(ctx context.Context, tenantID, slug, excludeI
 	err := r.pool.QueryRow(ctx, query, args...).Scan(&exists)
 	return exists, err
 }
+
+// Retrieve ranks published articles against search terms for the AI
+// assistant. An article matches if it contains any term.
+func (r *KBArticleRepo) Retrieve(ctx context.Context, p models.KBRetrievalParams) ([]models.KBArticleMatch, error) {
+	if len(p.Terms) == 0 || p.Limit <= 0 {
+		return []models.KBArticleMatch{}, nil
+	}
+	modules := p.Modules
+	if modules == nil {
+		modules = []string{}
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, title, slug, summary, content, content_type,
+			module, lifecycle_stage, tags, version, status,
+			created_by_id, created_by_name, updated_by_id, updated_by_name,
+			published_at, created_at, updated_at,
+			ts_rank(to_tsvector('english', title || ' ' || summary || ' ' || content), q)
+				* CASE WHEN $4 <> '' AND EXISTS (SELECT 1 FROM unnest(tags) t WHERE lower(t) = $4) THEN 1.5 ELSE 1 END AS score
+		FROM kb_articles, to_tsquery('english', $2) q
+		WHERE tenant_id = $1
+			AND status = 'published'
+			AND (cardinality($3::text[]) = 0 OR module = ANY($3::text[]))
+			AND to_tsvector('english', title || ' ' || summary || ' ' || content) @@ q
+		ORDER BY score DESC, updated_at DESC
+		LIMIT $5
+	`, p.TenantID, strings.Join(p.Terms, " | "), modules, strings.ToLower(strings.TrimSpace(p.DeviceCategory)), p.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.KBArticleMatch{}
+	for rows.Next() {
+		var m models.KBArticleMatch
+		a := &m.Article
+		if err := rows.Scan(
+			&a.ID, &a.TenantID, &a.Title, &a.Slug, &a.Summary, &a.Content, &a.ContentType,
+			&a.Module, &a.LifecycleStage, &a.Tags, &a.Version, &a.Status,
+			&a.CreatedByID, &a.CreatedByName, &a.UpdatedByID, &a.UpdatedByName,
+			&a.PublishedAt, &a.CreatedAt, &a.UpdatedAt, &m.Score,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// LogAIUsage records the articles given to the AI assistant on a turn.
+func (r *KBArticleRepo) LogAIUsage(ctx context.Context, usage []models.KBAIUsage) error {
+	for _, u := range usage {
+		if _, err := r.pool.Exec(ctx, `
+			INSERT INTO ai_kb_usage (id, tenant_id, session_id, turn_id, article_id, rank, score, cited, created_at)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		`, u.ID, u.TenantID, u.SessionID, u.TurnID, u.ArticleID, u.Rank, u.Score, u.Cited, u.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AIStats summarises AI usage per article since a time, most cited first.
+// Resolved and escalated counts are of sessions where the article was cited.
+func (r *KBArticleRepo) AIStats(ctx context.Context, tenantID string, since time.Time, limit int) ([]models.KBArticleAIStats, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT a.id, a.title, a.slug, a.content_type, a.module,
+			COUNT(DISTINCT u.turn_id),
+			COUNT(DISTINCT u.turn_id) FILTER (WHERE u.cited),
+			COUNT(DISTINCT u.session_id) FILTER (WHERE u.cited),
+			COUNT(DISTINCT u.session_id) FILTER (WHERE u.cited AND s.ai_resolved IS TRUE),
+			COUNT(DISTINCT u.session_id) FILTER (WHERE u.cited AND s.escalation_reason IS NOT NULL),
+			MAX(u.created_at) FILTER (WHERE u.cited)
+		FROM ai_kb_usage u
+		JOIN kb_articles a ON a.tenant_id = u.tenant_id AND a.id = u.article_id
+		JOIN chat_sessions s ON s.id = u.session_id
+		WHERE u.tenant_id = $1 AND u.created_at >= $2
+		GROUP BY a.id, a.title, a.slug, a.content_type, a.module
+		ORDER BY 7 DESC, 6 DESC, a.title
+		LIMIT $3
+	`, tenantID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.KBArticleAIStats{}
+	for rows.Next() {
+		var s models.KBArticleAIStats
+		if err := rows.Scan(&s.ArticleID, &s.Title, &s.Slug, &s.ContentType, &s.Module,
+			&s.RetrievedTurns, &s.CitedTurns, &s.CitedSessions, &s.ResolvedSessions, &s.EscalatedSessions,
+			&s.LastCitedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
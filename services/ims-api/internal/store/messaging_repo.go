@@ -35,8 +35,9 @@ type ThreadListParams struct {
 
 // MessageListParams contains parameters for listing messages
 type MessageListParams struct {
-	ThreadID string
-	Limit    int
+	ThreadID        string
+	Limit           int
+	IncludeInternal bool // include agent-only notes
 
 	HasCursor       bool
 	CursorTimestamp time.Time
@@ -192,6 +193,20 @@ func (r *MessagingRepo) UpdateThreadStatus(ctx context.Context, tenantID, thread
 	return err
 }
 
+// LinkThreadIncidentTx links a thread to an incident unless it is already
+// linked to one. It reports whether the link was made.
+func LinkThreadIncidentTx(ctx context.Context, tx Tx, tenantID, threadID, incidentID string) (bool, error) {
+	tag, err := tx.Exec(ctx, `
+		UPDATE message_threads
+		SET incident_id = $3, updated_at = $4
+		WHERE tenant_id = $1 AND id = $2 AND incident_id IS NULL
+	`, tenantID, threadID, incidentID, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // UpdateThreadLastMessage updates the thread's last message timestamp and counts
 func (r *MessagingRepo) UpdateThreadLastMessage(ctx context.Context, tenantID, threadID string, isSchoolSender bool) error {
 	now := time.Now().UTC()
@@ -228,17 +243,21 @@ func (r *MessagingRepo) CreateMessage(ctx context.Context, m models.Message) err
 	if m.Metadata == nil {
 		metadataBytes = []byte("{}")
 	}
+	visibility := m.Visibility
+	if visibility == "" {
+		visibility = models.MessageVisibilityPublic
+	}
 
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO messages (
 			id, tenant_id, thread_id, sender_id, sender_name, sender_role,
-			content, content_type, metadata, edited_at, deleted_at, created_at
+			content, content_type, visibility, metadata, edited_at, deleted_at, created_at
 		) VALUES (
 			$1, $2, $3, $4, $5, $6,
-			$7, $8, $9, $10, $11, $12
+			$7, $8, $9, $10, $11, $12, $13
 		)
 	`, m.ID, m.TenantID, m.ThreadID, m.SenderID, m.SenderName, m.SenderRole,
-		m.Content, m.ContentType, metadataBytes, m.EditedAt, m.DeletedAt, m.CreatedAt)
+		m.Content, m.ContentType, visibility, metadataBytes, m.EditedAt, m.DeletedAt, m.CreatedAt)
 	return err
 }
 
@@ -249,13 +268,13 @@ func (r *MessagingRepo) GetMessageByID(ctx context.Context, tenantID, messageID
 
 	row := r.pool.QueryRow(ctx, `
 		SELECT id, tenant_id, thread_id, sender_id, sender_name, sender_role,
-			   content, content_type, metadata, edited_at, deleted_at, created_at
+			   content, content_type, visibility, metadata, edited_at, deleted_at, created_at
 		FROM messages
 		WHERE tenant_id = $1 AND id = $2
 	`, tenantID, messageID)
 
 	err := row.Scan(&m.ID, &m.TenantID, &m.ThreadID, &m.SenderID, &m.SenderName, &m.SenderRole,
-		&m.Content, &m.ContentType, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt)
+		&m.Content, &m.ContentType, &m.Visibility, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Message{}, errors.New("message not found")
@@ -275,6 +294,9 @@ func (r *MessagingRepo) ListMessages(ctx context.Context, p MessageListParams) (
 	conds := []string{"thread_id = $1", "deleted_at IS NULL"}
 	args := []any{p.ThreadID}
 	argN := 2
+	if !p.IncludeInternal {
+		conds = append(conds, "visibility = 'public'")
+	}
 
 	// Cursor pagination (for messages, we paginate in ascending order)
 	if p.HasCursor {
@@ -288,7 +310,7 @@ func (r *MessagingRepo) ListMessages(ctx context.Context, p MessageListParams) (
 
 	sql := `
 		SELECT id, tenant_id, thread_id, sender_id, sender_name, sender_role,
-			   content, content_type, metadata, edited_at, deleted_at, created_at
+			   content, content_type, visibility, metadata, edited_at, deleted_at, created_at
 		FROM messages
 		WHERE ` + strings.Join(conds, " AND ") + `
 		ORDER BY created_at ASC, id ASC
@@ -305,7 +327,7 @@ func (r *MessagingRepo) ListMessages(ctx context.Context, p MessageListParams) (
 		var m models.Message
 		var metadataBytes []byte
 		if err := rows.Scan(&m.ID, &m.TenantID, &m.ThreadID, &m.SenderID, &m.SenderName, &m.SenderRole,
-			&m.Content, &m.ContentType, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt); err != nil {
+			&m.Content, &m.ContentType, &m.Visibility, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt); err != nil {
 			return nil, "", err
 		}
 		if len(metadataBytes) > 0 {
@@ -324,22 +346,24 @@ func (r *MessagingRepo) ListMessages(ctx context.Context, p MessageListParams) (
 	return messages, next, nil
 }
 
-// GetLastMessage gets the last message in a thread
-func (r *MessagingRepo) GetLastMessage(ctx context.Context, threadID string) (models.Message, error) {
+// GetLastMessage gets the last message in a thread, skipping agent-only
+// notes unless includeInternal is set
+func (r *MessagingRepo) GetLastMessage(ctx context.Context, threadID string, includeInternal bool) (models.Message, error) {
 	var m models.Message
 	var metadataBytes []byte
 
 	row := r.pool.QueryRow(ctx, `
 		SELECT id, tenant_id, thread_id, sender_id, sender_name, sender_role,
-			   content, content_type, metadata, edited_at, deleted_at, created_at
+			   content, content_type, visibility, metadata, edited_at, deleted_at, created_at
 		FROM messages
 		WHERE thread_id = $1 AND deleted_at IS NULL
+		AND ($2 OR visibility = 'public')
 		ORDER BY created_at DESC
 		LIMIT 1
-	`, threadID)
+	`, threadID, includeInternal)
 
 	err := row.Scan(&m.ID, &m.TenantID, &m.ThreadID, &m.SenderID, &m.SenderName, &m.SenderRole,
-		&m.Content, &m.ContentType, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt)
+		&m.Content, &m.ContentType, &m.Visibility, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt)
 	if err != nil {
 		return models.Message{}, err
 	}
@@ -514,12 +538,17 @@ func (r *MessagingRepo) GetThreadParticipants(ctx context.Context, threadID stri
 	return participants, nil
 }
 
-// SearchMessages searches for messages
-func (r *MessagingRepo) SearchMessages(ctx context.Context, tenantID, schoolID, query string, limit int) ([]models.MessageSearchResult, error) {
+// SearchMessages searches for messages. Agent-only notes are matched only
+// when includeInternal is set.
+func (r *MessagingRepo) SearchMessages(ctx context.Context, tenantID, schoolID, query string, limit int, includeInternal bool) ([]models.MessageSearchResult, error) {
 	conds := []string{"m.tenant_id = $1"}
 	args := []any{tenantID}
 	argN := 2
 
+	if !includeInternal {
+		conds = append(conds, "m.visibility = 'public'")
+	}
+
 	if schoolID != "" {
 		conds = append(conds, "t.school_id = $"+itoa(argN))
 		args = append(args, schoolID)
@@ -534,7 +563,7 @@ func (r *MessagingRepo) SearchMessages(ctx context.Context, tenantID, schoolID,
 
 	sql := `
 		SELECT m.id, m.tenant_id, m.thread_id, m.sender_id, m.sender_name, m.sender_role,
-			   m.content, m.content_type, m.metadata, m.edited_at, m.deleted_at, m.created_at,
+			   m.content, m.content_type, m.visibility, m.metadata, m.edited_at, m.deleted_at, m.created_at,
 			   t.id, t.tenant_id, t.school_id, t.subject, t.thread_type, t.status,
 			   t.incident_id, t.created_by, t.created_by_role, t.created_by_name,
 			   t.message_count, t.unread_count_school, t.unread_count_support,
@@ -559,7 +588,7 @@ func (r *MessagingRepo) SearchMessages(ctx context.Context, tenantID, schoolID,
 
 		if err := rows.Scan(
 			&m.ID, &m.TenantID, &m.ThreadID, &m.SenderID, &m.SenderName, &m.SenderRole,
-			&m.Content, &m.ContentType, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt,
+			&m.Content, &m.ContentType, &m.Visibility, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt,
 			&t.ID, &t.TenantID, &t.SchoolID, &t.Subject, &t.ThreadType, &t.Status,
 			&t.IncidentID, &t.CreatedBy, &t.CreatedByRole, &t.CreatedByName,
 			&t.MessageCount, &t.UnreadCountSchool, &t.UnreadCountSupport,
@@ -581,13 +610,16 @@ func (r *MessagingRepo) SearchMessages(ctx context.Context, tenantID, schoolID,
 	return results, nil
 }
 
-// GetThreadMessages retrieves messages from a thread by offset/limit
+// GetThreadMessages retrieves the public messages of a thread by
+// offset/limit, newest first. Agent-only notes are left out, so they never
+// reach the AI assistant's prompt.
 func (r *MessagingRepo) GetThreadMessages(ctx context.Context, tenantID, threadID string, offset, limit int) ([]models.Message, error) {
 	rows, err := r.pool.Query(ctx, `
 		SELECT id, tenant_id, thread_id, sender_id, sender_name, sender_role,
-			   content, content_type, metadata, edited_at, deleted_at, created_at
+			   content, content_type, visibility, metadata, edited_at, deleted_at, created_at
 		FROM messages
 		WHERE tenant_id = $1 AND thread_id = $2 AND deleted_at IS NULL
+		AND visibility = 'public'
 		ORDER BY created_at DESC
 		LIMIT $3 OFFSET $4
 	`, tenantID, threadID, limit, offset)
@@ -601,7 +633,7 @@ func (r *MessagingRepo) GetThreadMessages(ctx context.Context, tenantID, threadI
 		var m models.Message
 		var metadataBytes []byte
 		if err := rows.Scan(&m.ID, &m.TenantID, &m.ThreadID, &m.SenderID, &m.SenderName, &m.SenderRole,
-			&m.Content, &m.ContentType, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt); err != nil {
+			&m.Content, &m.ContentType, &m.Visibility, &metadataBytes, &m.EditedAt, &m.DeletedAt, &m.CreatedAt); err != nil {
 			return nil, err
 		}
 		if len(metadataBytes) > 0 {
@@ -0,0 +1,223 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NetworkDiscoveryRepo tracks when devices were last seen on school
+// networks, uploaded network scans, and MAC addresses that match no device.
+type NetworkDiscoveryRepo struct{ pool *pgxpool.Pool }
+
+// offNetworkLifecycles are lifecycle states in which a device is not
+// expected on a school network, so the unseen report skips them.
+var offNetworkLifecycles = []string{
+	models.DeviceLifecycleProcured,
+	models.DeviceLifecycleInStock,
+	models.DeviceLifecycleInRepair,
+	models.DeviceLifecycleLost,
+	models.DeviceLifecycleRetired,
+	models.DeviceLifecycleDisposed,
+}
+
+// LookupMACs maps each cached MAC address to its device.
+func (r *NetworkDiscoveryRepo) LookupMACs(ctx context.Context, tenantID string, macs []string) (map[string]string, error) {
+	out := map[string]string{}
+	if len(macs) == 0 {
+		return out, nil
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT mac_address, device_id FROM device_network_snapshot
+		WHERE tenant_id=$1 AND mac_address = ANY($2)
+	`, tenantID, macs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var mac, deviceID string
+		if err := rows.Scan(&mac, &deviceID); err != nil {
+			return nil, err
+		}
+		out[mac] = deviceID
+	}
+	return out, rows.Err()
+}
+
+// MarkSeen records that a device was seen at the given time, optionally by
+// one of its MAC addresses. Older sightings never move last_seen_at back.
+// A MAC previously flagged as unknown is marked resolved to the device.
+func (r *NetworkDiscoveryRepo) MarkSeen(ctx context.Context, tenantID, deviceID, mac string, at time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE devices_snapshot SET last_seen_at=GREATEST(last_seen_at, $3)
+		WHERE tenant_id=$1 AND device_id=$2
+	`, tenantID, deviceID, at); err != nil {
+		return err
+	}
+	if mac != "" {
+		if _, err := tx.Exec(ctx, `
+			UPDATE device_network_snapshot SET last_seen_at=GREATEST(last_seen_at, $4)
+			WHERE tenant_id=$1 AND device_id=$2 AND mac_address=$3
+		`, tenantID, deviceID, mac, at); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE network_unknown_macs SET status='resolved', device_id=$3, resolved_at=$4
+			WHERE tenant_id=$1 AND mac_address=$2 AND status<>'resolved'
+		`, tenantID, mac, deviceID, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// RecordUnknown flags a MAC address that matched no device. Repeat sightings
+// update the candidate; an ignored MAC stays ignored, and a MAC that had
+// resolved but no longer does is reopened.
+func (r *NetworkDiscoveryRepo) RecordUnknown(ctx context.Context, tenantID, schoolID, source string, s models.NetworkSighting) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO network_unknown_macs (tenant_id, mac_address, school_id, status, first_seen_at, last_seen_at, sightings,
+			last_ip, last_hostname, last_source)
+		VALUES ($1,$2,$3,'open',$4,$4,1,$5,$6,$7)
+		ON CONFLICT (tenant_id, mac_address) DO UPDATE SET
+			school_id=CASE WHEN EXCLUDED.school_id<>'' THEN EXCLUDED.school_id ELSE network_unknown_macs.school_id END,
+			status=CASE WHEN network_unknown_macs.status='ignored' THEN 'ignored' ELSE 'open' END,
+			device_id=CASE WHEN network_unknown_macs.status='resolved' THEN '' ELSE network_unknown_macs.device_id END,
+			resolved_at=CASE WHEN network_unknown_macs.status='resolved' THEN NULL ELSE network_unknown_macs.resolved_at END,
+			first_seen_at=LEAST(network_unknown_macs.first_seen_at, EXCLUDED.first_seen_at),
+			last_seen_at=GREATEST(network_unknown_macs.last_seen_at, EXCLUDED.last_seen_at),
+			sightings=network_unknown_macs.sightings+1,
+			last_ip=CASE WHEN EXCLUDED.last_ip<>'' THEN EXCLUDED.last_ip ELSE network_unknown_macs.last_ip END,
+			last_hostname=CASE WHEN EXCLUDED.last_hostname<>'' THEN EXCLUDED.last_hostname ELSE network_unknown_macs.last_hostname END,
+			last_source=EXCLUDED.last_source
+	`, tenantID, s.MACAddress, schoolID, s.SeenAt, s.IPAddress, s.Hostname, source)
+	return err
+}
+
+const unknownMACColumns = `tenant_id, mac_address, school_id, status, device_id, first_seen_at, last_seen_at, sightings,
+	last_ip, last_hostname, last_source, resolved_at`
+
+func scanUnknownMAC(row pgx.Row) (models.UnknownMAC, error) {
+	var x models.UnknownMAC
+	err := row.Scan(&x.TenantID, &x.MACAddress, &x.SchoolID, &x.Status, &x.DeviceID, &x.FirstSeenAt, &x.LastSeenAt, &x.Sightings,
+		&x.LastIP, &x.LastHostname, &x.LastSource, &x.ResolvedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return x, errors.New("not found")
+	}
+	return x, err
+}
+
+// ListUnknown returns a school's unknown MACs, most recently seen first.
+// An empty status matches every status.
+func (r *NetworkDiscoveryRepo) ListUnknown(ctx context.Context, tenantID, schoolID string, status models.UnknownMACStatus, limit, offset int) ([]models.UnknownMAC, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+unknownMACColumns+` FROM network_unknown_macs
+		WHERE tenant_id=$1 AND school_id=$2 AND ($3='' OR status=$3)
+		ORDER BY last_seen_at DESC, mac_address
+		LIMIT $4 OFFSET $5
+	`, tenantID, schoolID, string(status), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.UnknownMAC{}
+	for rows.Next() {
+		x, err := scanUnknownMAC(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+// SetUnknownStatus moves an unresolved MAC between open and ignored.
+func (r *NetworkDiscoveryRepo) SetUnknownStatus(ctx context.Context, tenantID, mac string, status models.UnknownMACStatus) (models.UnknownMAC, error) {
+	return scanUnknownMAC(r.pool.QueryRow(ctx, `
+		UPDATE network_unknown_macs SET status=$3
+		WHERE tenant_id=$1 AND mac_address=$2 AND status<>'resolved'
+		RETURNING `+unknownMACColumns,
+		tenantID, mac, string(status)))
+}
+
+func (r *NetworkDiscoveryRepo) CreateScan(ctx context.Context, s models.NetworkScan) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO network_scans (id, tenant_id, school_id, source, scanned_at, entries, invalid, resolved, unknown,
+			lookup_failed, elsewhere, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+	`, s.ID, s.TenantID, s.SchoolID, s.Source, s.ScannedAt, s.Entries, s.Invalid, s.Resolved, s.Unknown,
+		s.LookupFailed, s.Elsewhere, s.CreatedBy, s.CreatedAt)
+	return err
+}
+
+// ListScans returns a school's uploaded scans, newest first.
+func (r *NetworkDiscoveryRepo) ListScans(ctx context.Context, tenantID, schoolID string, limit, offset int) ([]models.NetworkScan, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, school_id, source, scanned_at, entries, invalid, resolved, unknown,
+			lookup_failed, elsewhere, created_by, created_at
+		FROM network_scans
+		WHERE tenant_id=$1 AND school_id=$2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, tenantID, schoolID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.NetworkScan{}
+	for rows.Next() {
+		var s models.NetworkScan
+		if err := rows.Scan(&s.ID, &s.TenantID, &s.SchoolID, &s.Source, &s.ScannedAt, &s.Entries, &s.Invalid, &s.Resolved, &s.Unknown,
+			&s.LookupFailed, &s.Elsewhere, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// ListUnseen returns a school's devices that should be on the network but
+// have not been seen since cutoff, never-seen devices first, then the
+// longest unseen. total counts every matching device.
+func (r *NetworkDiscoveryRepo) ListUnseen(ctx context.Context, tenantID, schoolID string, cutoff time.Time, limit, offset int) ([]models.UnseenDevice, int, error) {
+	const where = `
+		WHERE tenant_id=$1 AND school_id=$2 AND NOT (status = ANY($3))
+		  AND (last_seen_at IS NULL OR last_seen_at < $4)`
+
+	var total int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM devices_snapshot`+where,
+		tenantID, schoolID, offNetworkLifecycles, cutoff).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT device_id, school_id, serial, asset_tag, model, status, last_seen_at
+		FROM devices_snapshot`+where+`
+		ORDER BY last_seen_at ASC NULLS FIRST, device_id
+		LIMIT $5 OFFSET $6
+	`, tenantID, schoolID, offNetworkLifecycles, cutoff, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []models.UnseenDevice{}
+	for rows.Next() {
+		var d models.UnseenDevice
+		if err := rows.Scan(&d.DeviceID, &d.SchoolID, &d.Serial, &d.AssetTag, &d.Model, &d.Status, &d.LastSeenAt); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, d)
+	}
+	return out, total, rows.Err()
+}
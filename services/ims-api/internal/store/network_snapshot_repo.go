@@ -16,7 +16,8 @@ func (r *NetworkSnapshotRepo) Upsert(ctx context.Context, snap models.DeviceNetw
 		INSERT INTO device_network_snapshot (tenant_id, device_id, mac_address, interface_type, is_primary, last_seen_at, synced_at)
 		VALUES ($1,$2,$3,$4,$5,$6,$7)
 		ON CONFLICT (tenant_id, device_id, mac_address) DO UPDATE SET
-			interface_type=EXCLUDED.interface_type, is_primary=EXCLUDED.is_primary, last_seen_at=EXCLUDED.last_seen_at, synced_at=EXCLUDED.synced_at
+			interface_type=EXCLUDED.interface_type, is_primary=EXCLUDED.is_primary,
+			last_seen_at=GREATEST(device_network_snapshot.last_seen_at, EXCLUDED.last_seen_at), synced_at=EXCLUDED.synced_at
 	`, snap.TenantID, snap.DeviceID, snap.MACAddress, snap.InterfaceType, snap.IsPrimary, snap.LastSeenAt, snap.SyncedAt)
 	return err
 }
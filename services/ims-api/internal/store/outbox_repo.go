@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EnqueueEventTx writes a domain event to the outbox in the caller's
+// transaction, so the event exists if and only if the state change commits.
+func EnqueueEventTx(ctx context.Context, tx Tx, ev models.DomainEvent) error {
+	if ev.ID == "" {
+		ev.ID = NewID("evt")
+	}
+	if ev.Version == 0 {
+		ev.Version = models.EventSchemaVersion
+	}
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO event_outbox (id, tenant_id, subject, payload, status, next_attempt_at, created_at)
+		VALUES ($1,$2,$3,$4,'pending',$5,$5)
+	`, ev.ID, ev.TenantID, string(ev.Type), payload, ev.OccurredAt)
+	return err
+}
+
+// OutboxRepo is used by the relay worker to deliver outbox events.
+type OutboxRepo struct{ pool *pgxpool.Pool }
+
+// OutboxRetryPolicy decides when a failed event is retried.
+type OutboxRetryPolicy struct {
+	MaxAttempts int
+	Backoff     func(attempts int) time.Duration
+}
+
+// ProcessPending locks up to limit due events (skipping rows held by other
+// relays), hands each to publish in creation order and records the outcome.
+// Events are marked published only after publish returns nil, so delivery is
+// at-least-once.
+func (r *OutboxRepo) ProcessPending(ctx context.Context, limit int, retry OutboxRetryPolicy, publish func(models.OutboxEvent) error) (published, failed int, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now().UTC()
+	rows, err := tx.Query(ctx, `
+		SELECT id, tenant_id, subject, payload, status, attempts, next_attempt_at, last_error, created_at
+		FROM event_outbox
+		WHERE status='pending' AND next_attempt_at <= $1
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, now, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+	events := []models.OutboxEvent{}
+	for rows.Next() {
+		var e models.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.TenantID, &e.Subject, &e.Payload, &e.Status, &e.Attempts,
+			&e.NextAttemptAt, &e.LastError, &e.CreatedAt); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	for _, e := range events {
+		if perr := publish(e); perr != nil {
+			failed++
+			attempts := e.Attempts + 1
+			status := models.OutboxPending
+			if retry.MaxAttempts > 0 && attempts >= retry.MaxAttempts {
+				status = models.OutboxFailed
+			}
+			next := now
+			if retry.Backoff != nil {
+				next = now.Add(retry.Backoff(attempts))
+			}
+			if _, err := tx.Exec(ctx, `
+				UPDATE event_outbox SET status=$2, attempts=$3, next_attempt_at=$4, last_error=$5
+				WHERE id=$1
+			`, e.ID, status, attempts, next, perr.Error()); err != nil {
+				return published, failed, err
+			}
+			continue
+		}
+		published++
+		if _, err := tx.Exec(ctx, `
+			UPDATE event_outbox SET status='published', attempts=attempts+1, published_at=$2, last_error=''
+			WHERE id=$1
+		`, e.ID, time.Now().UTC()); err != nil {
+			return published, failed, err
+		}
+	}
+	return published, failed, tx.Commit(ctx)
+}
+
+// PurgePublished deletes events published before the cutoff.
+func (r *OutboxRepo) PurgePublished(ctx context.Context, before time.Time) (int, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM event_outbox WHERE status='published' AND published_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// CountPending returns how many events are waiting to be published.
+func (r *OutboxRepo) CountPending(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(1) FROM event_outbox WHERE status='pending'`).Scan(&n)
+	return n, err
+}
@@ -13,7 +13,12 @@ import (
 type PartsSnapshotRepo struct{ pool *pgxpool.Pool }
 
 func (r *PartsSnapshotRepo) Upsert(ctx context.Context, p models.PartSnapshot) error {
-	_, err := r.pool.Exec(ctx, `
+	return UpsertPartSnapshotTx(ctx, r.pool, p)
+}
+
+// UpsertPartSnapshotTx upserts a part snapshot within a transaction.
+func UpsertPartSnapshotTx(ctx context.Context, tx Tx, p models.PartSnapshot) error {
+	_, err := tx.Exec(ctx, `
 		INSERT INTO parts_snapshot (
 			tenant_id, part_id, puk, name, category, unit, updated_at
 		) VALUES ($1,$2,$3,$4,$5,$6,$7)
@@ -28,6 +33,16 @@ func (r *PartsSnapshotRepo) Upsert(ctx context.Context, p models.PartSnapshot) e
 	return err
 }
 
+func (r *PartsSnapshotRepo) Delete(ctx context.Context, tenantID, partID string) error {
+	return DeletePartSnapshotTx(ctx, r.pool, tenantID, partID)
+}
+
+// DeletePartSnapshotTx deletes a part snapshot within a transaction.
+func DeletePartSnapshotTx(ctx context.Context, tx Tx, tenantID, partID string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM parts_snapshot WHERE tenant_id = $1 AND part_id = $2`, tenantID, partID)
+	return err
+}
+
 func (r *PartsSnapshotRepo) Get(ctx context.Context, tenantID, partID string) (models.PartSnapshot, error) {
 	var p models.PartSnapshot
 	row := r.pool.QueryRow(ctx, `
@@ -2,21 +2,26 @@ package store
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PhaseChecklistsRepo struct{ pool *pgxpool.Pool }
 
 func (r *PhaseChecklistsRepo) Create(ctx context.Context, t models.PhaseChecklistTemplate) error {
+	if t.ProjectType == "" {
+		t.ProjectType = models.ProjectTypeFullInstallation
+	}
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO phase_checklist_templates (
-			id, tenant_id, phase_type, title, description, required, created_at, updated_at
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-	`, t.ID, t.TenantID, t.PhaseType, t.Title, t.Description, t.Required, t.CreatedAt, t.UpdatedAt)
+			id, tenant_id, project_type, phase_type, title, description, required, created_at, updated_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+	`, t.ID, t.TenantID, t.ProjectType, t.PhaseType, t.Title, t.Description, t.Required, t.CreatedAt, t.UpdatedAt)
 	return err
 }
 
@@ -29,7 +34,7 @@ func (r *PhaseChecklistsRepo) List(ctx context.Context, tenantID string, phaseTy
 		args = append(args, strings.TrimSpace(phaseType))
 	}
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, tenant_id, phase_type, title, description, required, created_at, updated_at
+		SELECT id, tenant_id, project_type, phase_type, title, description, required, created_at, updated_at
 		FROM phase_checklist_templates
 		WHERE `+conds+`
 		ORDER BY phase_type ASC, required DESC, created_at ASC
@@ -42,7 +47,7 @@ func (r *PhaseChecklistsRepo) List(ctx context.Context, tenantID string, phaseTy
 	out := []models.PhaseChecklistTemplate{}
 	for rows.Next() {
 		var x models.PhaseChecklistTemplate
-		if err := rows.Scan(&x.ID, &x.TenantID, &x.PhaseType, &x.Title, &x.Description, &x.Required, &x.CreatedAt, &x.UpdatedAt); err != nil {
+		if err := rows.Scan(&x.ID, &x.TenantID, &x.ProjectType, &x.PhaseType, &x.Title, &x.Description, &x.Required, &x.CreatedAt, &x.UpdatedAt); err != nil {
 			return nil, err
 		}
 		out = append(out, x)
@@ -64,3 +69,118 @@ func (r *PhaseChecklistsRepo) SeedDefaults(ctx context.Context, tenantID string)
 	}
 	return nil
 }
+
+const checklistItemColumns = `id, tenant_id, project_id, phase_id, template_id, title, description, required, position,
+	completed_at, completed_by_user_id, completed_by_user_name, evidence_attachment_id, notes, created_at, updated_at`
+
+func scanChecklistItem(row pgx.Row) (models.PhaseChecklistItem, error) {
+	var x models.PhaseChecklistItem
+	err := row.Scan(&x.ID, &x.TenantID, &x.ProjectID, &x.PhaseID, &x.TemplateID, &x.Title, &x.Description, &x.Required, &x.Position,
+		&x.CompletedAt, &x.CompletedByUserID, &x.CompletedByUserName, &x.EvidenceAttachmentID, &x.Notes, &x.CreatedAt, &x.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return x, errors.New("not found")
+	}
+	return x, err
+}
+
+// CreatePhaseChecklistItemsTx adds checklist items to a phase.
+func CreatePhaseChecklistItemsTx(ctx context.Context, tx Tx, items []models.PhaseChecklistItem) error {
+	for _, it := range items {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO phase_checklist_items (
+				id, tenant_id, project_id, phase_id, template_id, title, description, required, position, notes, created_at, updated_at
+			) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+		`, it.ID, it.TenantID, it.ProjectID, it.PhaseID, it.TemplateID, it.Title, it.Description, it.Required, it.Position,
+			it.Notes, it.CreatedAt, it.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddItem appends an item to the end of a phase's checklist.
+func (r *PhaseChecklistsRepo) AddItem(ctx context.Context, it models.PhaseChecklistItem) (models.PhaseChecklistItem, error) {
+	return scanChecklistItem(r.pool.QueryRow(ctx, `
+		INSERT INTO phase_checklist_items (
+			id, tenant_id, project_id, phase_id, template_id, title, description, required, position, notes, created_at, updated_at
+		) VALUES ($1,$2,$3,$4,'',$5,$6,$7,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM phase_checklist_items WHERE tenant_id=$2 AND phase_id=$4),
+			'',$8,$8)
+		RETURNING `+checklistItemColumns,
+		it.ID, it.TenantID, it.ProjectID, it.PhaseID, it.Title, it.Description, it.Required, it.CreatedAt))
+}
+
+// ListItems returns a phase's checklist in order.
+func (r *PhaseChecklistsRepo) ListItems(ctx context.Context, tenantID, phaseID string) ([]models.PhaseChecklistItem, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+checklistItemColumns+`
+		FROM phase_checklist_items
+		WHERE tenant_id=$1 AND phase_id=$2
+		ORDER BY position, created_at, id
+	`, tenantID, phaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.PhaseChecklistItem{}
+	for rows.Next() {
+		x, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+// ListItemsByProject returns the checklist items of every phase in a
+// project.
+func (r *PhaseChecklistsRepo) ListItemsByProject(ctx context.Context, tenantID, projectID string) ([]models.PhaseChecklistItem, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+checklistItemColumns+`
+		FROM phase_checklist_items
+		WHERE tenant_id=$1 AND project_id=$2
+		ORDER BY phase_id, position, created_at, id
+	`, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.PhaseChecklistItem{}
+	for rows.Next() {
+		x, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+func (r *PhaseChecklistsRepo) GetItem(ctx context.Context, tenantID, itemID string) (models.PhaseChecklistItem, error) {
+	return scanChecklistItem(r.pool.QueryRow(ctx, `
+		SELECT `+checklistItemColumns+` FROM phase_checklist_items WHERE tenant_id=$1 AND id=$2
+	`, tenantID, itemID))
+}
+
+// CompleteItem marks an item done by a user, with optional evidence. An
+// item already completed is updated with the new evidence and notes.
+func (r *PhaseChecklistsRepo) CompleteItem(ctx context.Context, tenantID, itemID, userID, userName, evidenceID, notes string, at time.Time) (models.PhaseChecklistItem, error) {
+	return scanChecklistItem(r.pool.QueryRow(ctx, `
+		UPDATE phase_checklist_items
+		SET completed_at=COALESCE(completed_at, $6), completed_by_user_id=$3, completed_by_user_name=$4,
+			evidence_attachment_id=$5, notes=$7, updated_at=$6
+		WHERE tenant_id=$1 AND id=$2
+		RETURNING `+checklistItemColumns,
+		tenantID, itemID, userID, userName, evidenceID, at, notes))
+}
+
+// ReopenItem clears an item's completion.
+func (r *PhaseChecklistsRepo) ReopenItem(ctx context.Context, tenantID, itemID string, at time.Time) (models.PhaseChecklistItem, error) {
+	return scanChecklistItem(r.pool.QueryRow(ctx, `
+		UPDATE phase_checklist_items
+		SET completed_at=NULL, completed_by_user_id='', completed_by_user_name='', evidence_attachment_id='', updated_at=$3
+		WHERE tenant_id=$1 AND id=$2
+		RETURNING `+checklistItemColumns,
+		tenantID, itemID, at))
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrDependencyExists is returned when two phases are already linked.
+var ErrDependencyExists = errors.New("dependency already exists")
+
+// PhaseDependenciesRepo stores finish-to-start links between phases, which
+// may span projects.
+type PhaseDependenciesRepo struct{ pool *pgxpool.Pool }
+
+// dependencyColumns selects a dependency joined with both of its phases, as
+// pred and succ.
+const dependencyColumns = `d.id, d.tenant_id, d.predecessor_phase_id, d.successor_phase_id, d.lag_days, d.created_by, d.created_at,
+	pred.project_id, pred.phase_type, pred.status, succ.project_id`
+
+const dependencyJoins = `
+	FROM phase_dependencies d
+	JOIN service_phases pred ON pred.tenant_id = d.tenant_id AND pred.id = d.predecessor_phase_id
+	JOIN service_phases succ ON succ.tenant_id = d.tenant_id AND succ.id = d.successor_phase_id`
+
+func scanDependency(row pgx.Row) (models.PhaseDependency, error) {
+	var d models.PhaseDependency
+	err := row.Scan(&d.ID, &d.TenantID, &d.PredecessorPhaseID, &d.SuccessorPhaseID, &d.LagDays, &d.CreatedBy, &d.CreatedAt,
+		&d.PredecessorProjectID, &d.PredecessorPhaseType, &d.PredecessorStatus, &d.SuccessorProjectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return d, errors.New("not found")
+	}
+	return d, err
+}
+
+func (r *PhaseDependenciesRepo) Create(ctx context.Context, d models.PhaseDependency) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO phase_dependencies (id, tenant_id, predecessor_phase_id, successor_phase_id, lag_days, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+	`, d.ID, d.TenantID, d.PredecessorPhaseID, d.SuccessorPhaseID, d.LagDays, d.CreatedBy, d.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDependencyExists
+	}
+	return err
+}
+
+func (r *PhaseDependenciesRepo) Get(ctx context.Context, tenantID, id string) (models.PhaseDependency, error) {
+	return scanDependency(r.pool.QueryRow(ctx, `
+		SELECT `+dependencyColumns+dependencyJoins+`
+		WHERE d.tenant_id = $1 AND d.id = $2
+	`, tenantID, id))
+}
+
+func (r *PhaseDependenciesRepo) Delete(ctx context.Context, tenantID, id string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM phase_dependencies WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	return err
+}
+
+// WouldCycle reports whether linking predecessor to successor would close a
+// loop, i.e. whether predecessor already follows successor.
+func (r *PhaseDependenciesRepo) WouldCycle(ctx context.Context, tenantID, predecessorID, successorID string) (bool, error) {
+	var cycle bool
+	err := r.pool.QueryRow(ctx, `
+		WITH RECURSIVE downstream(phase_id) AS (
+			SELECT $3::text
+			UNION
+			SELECT d.successor_phase_id
+			FROM phase_dependencies d
+			JOIN downstream ds ON d.predecessor_phase_id = ds.phase_id
+			WHERE d.tenant_id = $1
+		)
+		SELECT EXISTS (SELECT 1 FROM downstream WHERE phase_id = $2)
+	`, tenantID, predecessorID, successorID).Scan(&cycle)
+	return cycle, err
+}
+
+// ListForPhase returns the phase's predecessors and successors.
+func (r *PhaseDependenciesRepo) ListForPhase(ctx context.Context, tenantID, phaseID string) (predecessors, successors []models.PhaseDependency, err error) {
+	deps, err := r.list(ctx, `d.tenant_id = $1 AND (d.predecessor_phase_id = $2 OR d.successor_phase_id = $2)`, tenantID, phaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	predecessors, successors = []models.PhaseDependency{}, []models.PhaseDependency{}
+	for _, d := range deps {
+		if d.SuccessorPhaseID == phaseID {
+			predecessors = append(predecessors, d)
+		} else {
+			successors = append(successors, d)
+		}
+	}
+	return predecessors, successors, nil
+}
+
+// ListForProject returns every dependency with either phase in the project.
+func (r *PhaseDependenciesRepo) ListForProject(ctx context.Context, tenantID, projectID string) ([]models.PhaseDependency, error) {
+	return r.list(ctx, `d.tenant_id = $1 AND (pred.project_id = $2 OR succ.project_id = $2)`, tenantID, projectID)
+}
+
+func (r *PhaseDependenciesRepo) list(ctx context.Context, where string, args ...any) ([]models.PhaseDependency, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+dependencyColumns+dependencyJoins+`
+		WHERE `+where+`
+		ORDER BY d.created_at, d.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.PhaseDependency{}
+	for rows.Next() {
+		d, err := scanDependency(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
@@ -6,21 +6,60 @@ import (
 	"time"
 
 	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PhasesRepo struct{ pool *pgxpool.Pool }
 
-func (r *PhasesRepo) Create(ctx context.Context, p models.ServicePhase) error {
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO service_phases (
-			id, tenant_id, project_id, phase_type, status, owner_role, owner_user_id, owner_user_name,
-			start_date, end_date, notes, status_changed_at, status_changed_by_user_id, status_changed_by_user_name,
-			created_at, updated_at
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,NULLIF($9,''),NULLIF($10,''),$11,$12,$13,$14,$15,$16)
-	`, p.ID, p.TenantID, p.ProjectID, p.PhaseType, p.Status, p.OwnerRole, p.OwnerUserID, p.OwnerUserName,
+const insertPhaseSQL = `
+	INSERT INTO service_phases (
+		id, tenant_id, project_id, phase_type, status, owner_role, owner_user_id, owner_user_name,
+		start_date, end_date, notes, status_changed_at, status_changed_by_user_id, status_changed_by_user_name,
+		created_at, updated_at, planned_start, planned_end, duration_days
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,NULLIF($9,''),NULLIF($10,''),$11,$12,$13,$14,$15,$16,
+		NULLIF($17,'')::date,NULLIF($18,'')::date,$19)`
+
+func phaseArgs(p models.ServicePhase) []any {
+	return []any{p.ID, p.TenantID, p.ProjectID, p.PhaseType, p.Status, p.OwnerRole, p.OwnerUserID, p.OwnerUserName,
 		p.StartDate, p.EndDate, p.Notes, p.StatusChangedAt, p.StatusChangedByUserID, p.StatusChangedByUserName,
-		p.CreatedAt, p.UpdatedAt)
+		p.CreatedAt, p.UpdatedAt, p.PlannedStart, p.PlannedEnd, p.DurationDays}
+}
+
+const phaseColumns = `id, tenant_id, project_id, phase_type, status, owner_role, owner_user_id, owner_user_name,
+	start_date, end_date, notes, status_changed_at, status_changed_by_user_id, status_changed_by_user_name,
+	created_at, updated_at, planned_start, planned_end, duration_days, actual_start, actual_end, dependency_blocked`
+
+func scanPhase(row pgx.Row) (models.ServicePhase, error) {
+	var p models.ServicePhase
+	var sd, ed, ps, pe, as, ae *time.Time
+	if err := row.Scan(&p.ID, &p.TenantID, &p.ProjectID, &p.PhaseType, &p.Status, &p.OwnerRole, &p.OwnerUserID, &p.OwnerUserName,
+		&sd, &ed, &p.Notes, &p.StatusChangedAt, &p.StatusChangedByUserID, &p.StatusChangedByUserName,
+		&p.CreatedAt, &p.UpdatedAt, &ps, &pe, &p.DurationDays, &as, &ae, &p.DependencyBlocked); err != nil {
+		return models.ServicePhase{}, err
+	}
+	p.StartDate, p.EndDate = formatDate(sd), formatDate(ed)
+	p.PlannedStart, p.PlannedEnd = formatDate(ps), formatDate(pe)
+	p.ActualStart, p.ActualEnd = formatDate(as), formatDate(ae)
+	return p, nil
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func (r *PhasesRepo) Create(ctx context.Context, p models.ServicePhase) error {
+	_, err := r.pool.Exec(ctx, insertPhaseSQL, phaseArgs(p)...)
+	return err
+}
+
+// CreatePhaseTx creates a phase inside a transaction, so its checklist can
+// be created with it.
+func CreatePhaseTx(ctx context.Context, tx Tx, p models.ServicePhase) error {
+	_, err := tx.Exec(ctx, insertPhaseSQL, phaseArgs(p)...)
 	return err
 }
 
@@ -29,12 +68,86 @@ func (r *PhasesRepo) UpdateStatus(ctx context.Context, tenantID, phaseID string,
 	now := time.Now()
 	_, err := r.pool.Exec(ctx, `
 		UPDATE service_phases
-		SET status = $3, status_changed_at = $4, status_changed_by_user_id = $5, status_changed_by_user_name = $6, updated_at = $4
+		SET status = $3, status_changed_at = $4, status_changed_by_user_id = $5, status_changed_by_user_name = $6, updated_at = $4,
+			dependency_blocked = FALSE,
+			actual_start = CASE WHEN $3 IN ('in_progress', 'done') THEN COALESCE(actual_start, $7::date) ELSE actual_start END,
+			actual_end = CASE WHEN $3 = 'done' THEN COALESCE(actual_end, $7::date) ELSE NULL END
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, phaseID, status, now, changedByUserID, changedByUserName, now.UTC().Format("2006-01-02"))
+	return err
+}
+
+// SetDependencyStatus applies a status change made automatically because of
+// the phase's predecessors.
+func (r *PhasesRepo) SetDependencyStatus(ctx context.Context, tenantID, phaseID string, status models.PhaseStatus, dependencyBlocked bool) error {
+	now := time.Now()
+	_, err := r.pool.Exec(ctx, `
+		UPDATE service_phases
+		SET status = $3, dependency_blocked = $4, status_changed_at = $5,
+			status_changed_by_user_id = '', status_changed_by_user_name = 'system', updated_at = $5
 		WHERE tenant_id = $1 AND id = $2
-	`, tenantID, phaseID, status, now, changedByUserID, changedByUserName)
+	`, tenantID, phaseID, status, dependencyBlocked, now)
 	return err
 }
 
+// UpdateSchedule sets a phase's planned dates and duration.
+func (r *PhasesRepo) UpdateSchedule(ctx context.Context, tenantID, phaseID, plannedStart, plannedEnd string, durationDays int) (models.ServicePhase, error) {
+	return scanPhase(r.pool.QueryRow(ctx, `
+		UPDATE service_phases
+		SET planned_start = NULLIF($3,'')::date, planned_end = NULLIF($4,'')::date, duration_days = $5, updated_at = $6
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+phaseColumns,
+		tenantID, phaseID, plannedStart, plannedEnd, durationDays, time.Now()))
+}
+
+// ListByIDs returns the given phases, in any project.
+func (r *PhasesRepo) ListByIDs(ctx context.Context, tenantID string, ids []string) ([]models.ServicePhase, error) {
+	out := []models.ServicePhase{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+phaseColumns+`
+		FROM service_phases
+		WHERE tenant_id = $1 AND id = ANY($2)
+	`, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		x, err := scanPhase(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+// ListAllForProject returns every phase of a project, oldest first.
+func (r *PhasesRepo) ListAllForProject(ctx context.Context, tenantID, projectID string) ([]models.ServicePhase, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+phaseColumns+`
+		FROM service_phases
+		WHERE tenant_id = $1 AND project_id = $2
+		ORDER BY created_at, id
+	`, tenantID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.ServicePhase{}
+	for rows.Next() {
+		x, err := scanPhase(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
 // UpdateOwner updates the phase owner user.
 func (r *PhasesRepo) UpdateOwner(ctx context.Context, tenantID, phaseID, ownerUserID, ownerUserName string) error {
 	now := time.Now()
@@ -48,26 +161,15 @@ func (r *PhasesRepo) UpdateOwner(ctx context.Context, tenantID, phaseID, ownerUs
 
 // GetByID retrieves a phase by ID.
 func (r *PhasesRepo) GetByID(ctx context.Context, tenantID, phaseID string) (models.ServicePhase, error) {
-	var p models.ServicePhase
-	var sd, ed *time.Time
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_id, project_id, phase_type, status, owner_role, owner_user_id, owner_user_name,
-			start_date, end_date, notes, status_changed_at, status_changed_by_user_id, status_changed_by_user_name,
-			created_at, updated_at
+		SELECT `+phaseColumns+`
 		FROM service_phases
 		WHERE tenant_id = $1 AND id = $2
 	`, tenantID, phaseID)
-	if err := row.Scan(&p.ID, &p.TenantID, &p.ProjectID, &p.PhaseType, &p.Status, &p.OwnerRole, &p.OwnerUserID, &p.OwnerUserName,
-		&sd, &ed, &p.Notes, &p.StatusChangedAt, &p.StatusChangedByUserID, &p.StatusChangedByUserName,
-		&p.CreatedAt, &p.UpdatedAt); err != nil {
+	p, err := scanPhase(row)
+	if err != nil {
 		return models.ServicePhase{}, err
 	}
-	if sd != nil {
-		p.StartDate = sd.Format("2006-01-02")
-	}
-	if ed != nil {
-		p.EndDate = ed.Format("2006-01-02")
-	}
 	return p, nil
 }
 
@@ -105,9 +207,7 @@ func (r *PhasesRepo) List(ctx context.Context, p PhaseListParams) ([]models.Serv
 	args = append(args, limitPlus)
 
 	sql := `
-		SELECT id, tenant_id, project_id, phase_type, status, owner_role, owner_user_id, owner_user_name,
-			start_date, end_date, notes, status_changed_at, status_changed_by_user_id, status_changed_by_user_name,
-			created_at, updated_at
+		SELECT ` + phaseColumns + `
 		FROM service_phases
 		WHERE ` + strings.Join(conds, " AND ") + `
 		ORDER BY created_at DESC, id DESC
@@ -121,19 +221,10 @@ func (r *PhasesRepo) List(ctx context.Context, p PhaseListParams) ([]models.Serv
 
 	out := []models.ServicePhase{}
 	for rows.Next() {
-		var x models.ServicePhase
-		var sd, ed *time.Time
-		if err := rows.Scan(&x.ID, &x.TenantID, &x.ProjectID, &x.PhaseType, &x.Status, &x.OwnerRole, &x.OwnerUserID, &x.OwnerUserName,
-			&sd, &ed, &x.Notes, &x.StatusChangedAt, &x.StatusChangedByUserID, &x.StatusChangedByUserName,
-			&x.CreatedAt, &x.UpdatedAt); err != nil {
+		x, err := scanPhase(rows)
+		if err != nil {
 			return nil, "", err
 		}
-		if sd != nil {
-			x.StartDate = sd.Format("2006-01-02")
-		}
-		if ed != nil {
-			x.EndDate = ed.Format("2006-01-02")
-		}
 		out = append(out, x)
 	}
 	next := ""
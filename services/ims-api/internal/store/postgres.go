@@ -33,9 +33,13 @@ type Postgres struct {
 	deliverablesRepo         *WorkOrderDeliverablesRepo
 	approvalsRepo            *WorkOrderApprovalsRepo
 	phaseChecklistsRepo      *PhaseChecklistsRepo
+	phaseDependenciesRepo    *PhaseDependenciesRepo
+	projectTemplatesRepo     *ProjectTemplatesRepo
 	auditStore               *AuditStoreRef
 	messagingRepo            *MessagingRepo
 	chatSessionsRepo         *ChatSessionsRepo
+	chatRoutingRepo          *ChatRoutingRepo
+	cannedResponsesRepo      *CannedResponsesRepo
 	projectTeamRepo          *ProjectTeamRepo
 	projectActivitiesRepo    *ProjectActivitiesRepo
 	userNotificationsRepo    *UserNotificationsRepo
@@ -60,6 +64,24 @@ type Postgres struct {
 	assignmentsRepo *AssignmentsRepo
 	groupsRepo      *GroupsRepo
 	networkSnapRepo *NetworkSnapshotRepo
+	networkDisc     *NetworkDiscoveryRepo
+
+	// SLA policies
+	slaPoliciesRepo  *SLAPoliciesRepo
+	slaCalendarsRepo *SLACalendarsRepo
+	slaEventsRepo    *IncidentSLAEventsRepo
+	workflowsRepo    *WorkflowsRepo
+	outboxRepo       *OutboxRepo
+	ssotWebhooks     *SSOTWebhooksRepo
+	stockLedger      *StockLedgerRepo
+	stockTransfers   *StockTransfersRepo
+	stockCounts      *StockCountsRepo
+	reorderPolicies  *ReorderPoliciesRepo
+	purchaseOrders   *PurchaseOrdersRepo
+	fieldSync        *FieldSyncRepo
+	deviceRegs       *DeviceRegistrationsRepo
+	telemetry        *TelemetryRepo
+	userRoles        *UserRolesRepo
 
 	// HR SSOT snapshots
 	peopleSnap          *PeopleSnapshotRepo
@@ -103,9 +125,13 @@ func NewPostgres(ctx context.Context, dsn string) (*Postgres, error) {
 	s.deliverablesRepo = &WorkOrderDeliverablesRepo{pool: pool}
 	s.approvalsRepo = &WorkOrderApprovalsRepo{pool: pool}
 	s.phaseChecklistsRepo = &PhaseChecklistsRepo{pool: pool}
+	s.phaseDependenciesRepo = &PhaseDependenciesRepo{pool: pool}
+	s.projectTemplatesRepo = &ProjectTemplatesRepo{pool: pool}
 	s.auditStore = &AuditStoreRef{pool: pool}
 	s.messagingRepo = &MessagingRepo{pool: pool}
 	s.chatSessionsRepo = &ChatSessionsRepo{pool: pool}
+	s.chatRoutingRepo = &ChatRoutingRepo{pool: pool}
+	s.cannedResponsesRepo = &CannedResponsesRepo{pool: pool}
 	s.projectTeamRepo = &ProjectTeamRepo{pool: pool}
 	s.projectActivitiesRepo = &ProjectActivitiesRepo{pool: pool}
 	s.userNotificationsRepo = &UserNotificationsRepo{pool: pool}
@@ -130,6 +156,36 @@ func NewPostgres(ctx context.Context, dsn string) (*Postgres, error) {
 	s.assignmentsRepo = &AssignmentsRepo{pool: pool}
 	s.groupsRepo = &GroupsRepo{pool: pool}
 	s.networkSnapRepo = &NetworkSnapshotRepo{pool: pool}
+	s.networkDisc = &NetworkDiscoveryRepo{pool: pool}
+
+	// SLA policies
+	s.slaPoliciesRepo = &SLAPoliciesRepo{pool: pool}
+	s.slaCalendarsRepo = &SLACalendarsRepo{pool: pool}
+	s.slaEventsRepo = &IncidentSLAEventsRepo{pool: pool}
+	s.workflowsRepo = &WorkflowsRepo{pool: pool}
+	s.outboxRepo = &OutboxRepo{pool: pool}
+	s.ssotWebhooks = &SSOTWebhooksRepo{pool: pool}
+
+	// Stock ledger
+	s.stockLedger = &StockLedgerRepo{pool: pool}
+	s.stockTransfers = &StockTransfersRepo{pool: pool}
+	s.stockCounts = &StockCountsRepo{pool: pool}
+
+	// Procurement
+	s.reorderPolicies = &ReorderPoliciesRepo{pool: pool}
+	s.purchaseOrders = &PurchaseOrdersRepo{pool: pool}
+
+	// Field-tech offline sync
+	s.fieldSync = &FieldSyncRepo{pool: pool}
+
+	// School device registrations written back to ssot-devices
+	s.deviceRegs = &DeviceRegistrationsRepo{pool: pool}
+
+	// Telemetry pipeline
+	s.telemetry = &TelemetryRepo{pool: pool}
+
+	// Roles users were last seen with
+	s.userRoles = &UserRolesRepo{pool: pool}
 
 	// HR SSOT snapshots
 	s.peopleSnap = &PeopleSnapshotRepo{pool: pool}
@@ -143,6 +199,20 @@ func (p *Postgres) Close()                         { p.pool.Close() }
 func (p *Postgres) Ping(ctx context.Context) error { return p.pool.Ping(ctx) }
 func (p *Postgres) RawPool() *pgxpool.Pool         { return p.pool }
 
+// WithTx runs fn in a transaction, committing if it returns nil.
+func (p *Postgres) WithTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 func (p *Postgres) Incidents() *IncidentRepo                          { return p.incidents }
 func (p *Postgres) WorkOrders() *WorkOrderRepo                        { return p.workOrders }
 func (p *Postgres) Attachments() *AttachmentRepo                      { return p.attachments }
@@ -167,9 +237,13 @@ func (p *Postgres) WorkOrderSchedules() *WorkOrderScheduleRepo        { return p
 func (p *Postgres) WorkOrderDeliverables() *WorkOrderDeliverablesRepo { return p.deliverablesRepo }
 func (p *Postgres) WorkOrderApprovals() *WorkOrderApprovalsRepo       { return p.approvalsRepo }
 func (p *Postgres) PhaseChecklists() *PhaseChecklistsRepo             { return p.phaseChecklistsRepo }
+func (p *Postgres) PhaseDependencies() *PhaseDependenciesRepo         { return p.phaseDependenciesRepo }
+func (p *Postgres) ProjectTemplates() *ProjectTemplatesRepo           { return p.projectTemplatesRepo }
 func (p *Postgres) AuditStorePool() *pgxpool.Pool                     { return p.auditStore.pool }
 func (p *Postgres) Messaging() *MessagingRepo                         { return p.messagingRepo }
 func (p *Postgres) ChatSessions() *ChatSessionsRepo                   { return p.chatSessionsRepo }
+func (p *Postgres) ChatRouting() *ChatRoutingRepo                     { return p.chatRoutingRepo }
+func (p *Postgres) CannedResponses() *CannedResponsesRepo             { return p.cannedResponsesRepo }
 func (p *Postgres) ProjectTeam() *ProjectTeamRepo                     { return p.projectTeamRepo }
 func (p *Postgres) ProjectActivities() *ProjectActivitiesRepo         { return p.projectActivitiesRepo }
 func (p *Postgres) UserNotifications() *UserNotificationsRepo         { return p.userNotificationsRepo }
@@ -192,10 +266,28 @@ func (p *Postgres) KBArticles() *KBArticleRepo                  { return p.kbArt
 func (p *Postgres) MarketingKB() *MarketingKBRepo               { return p.marketingKBRepo }
 
 // Device inventory
-func (p *Postgres) Locations() *LocationsRepo             { return p.locationsRepo }
-func (p *Postgres) Assignments() *AssignmentsRepo         { return p.assignmentsRepo }
-func (p *Postgres) Groups() *GroupsRepo                   { return p.groupsRepo }
-func (p *Postgres) NetworkSnapshot() *NetworkSnapshotRepo { return p.networkSnapRepo }
+func (p *Postgres) Locations() *LocationsRepo               { return p.locationsRepo }
+func (p *Postgres) Assignments() *AssignmentsRepo           { return p.assignmentsRepo }
+func (p *Postgres) Groups() *GroupsRepo                     { return p.groupsRepo }
+func (p *Postgres) NetworkSnapshot() *NetworkSnapshotRepo   { return p.networkSnapRepo }
+func (p *Postgres) NetworkDiscovery() *NetworkDiscoveryRepo { return p.networkDisc }
+
+// SLA policies
+func (p *Postgres) SLAPolicies() *SLAPoliciesRepo                 { return p.slaPoliciesRepo }
+func (p *Postgres) SLACalendars() *SLACalendarsRepo               { return p.slaCalendarsRepo }
+func (p *Postgres) SLAEvents() *IncidentSLAEventsRepo             { return p.slaEventsRepo }
+func (p *Postgres) Workflows() *WorkflowsRepo                     { return p.workflowsRepo }
+func (p *Postgres) Outbox() *OutboxRepo                           { return p.outboxRepo }
+func (p *Postgres) SSOTWebhooks() *SSOTWebhooksRepo               { return p.ssotWebhooks }
+func (p *Postgres) StockLedger() *StockLedgerRepo                 { return p.stockLedger }
+func (p *Postgres) StockTransfers() *StockTransfersRepo           { return p.stockTransfers }
+func (p *Postgres) StockCounts() *StockCountsRepo                 { return p.stockCounts }
+func (p *Postgres) ReorderPolicies() *ReorderPoliciesRepo         { return p.reorderPolicies }
+func (p *Postgres) PurchaseOrders() *PurchaseOrdersRepo           { return p.purchaseOrders }
+func (p *Postgres) FieldSync() *FieldSyncRepo                     { return p.fieldSync }
+func (p *Postgres) DeviceRegistrations() *DeviceRegistrationsRepo { return p.deviceRegs }
+func (p *Postgres) Telemetry() *TelemetryRepo                     { return p.telemetry }
+func (p *Postgres) UserRoles() *UserRolesRepo                     { return p.userRoles }
 
 // HR SSOT snapshots
 func (p *Postgres) PeopleSnapshot() *PeopleSnapshotRepo     { return p.peopleSnap }
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrTemplateVersionConflict is returned when two edits of a project type
+// race for the same version number.
+var ErrTemplateVersionConflict = errors.New("project type was changed concurrently")
+
+// ProjectTemplatesRepo stores versioned, tenant-defined project types.
+type ProjectTemplatesRepo struct{ pool *pgxpool.Pool }
+
+const projectTemplateColumns = `id, tenant_id, project_type, version, label, description, default_phase,
+	phases, checklist, boq_items, team_roles, retired_at, created_by, created_at`
+
+func scanProjectTemplate(row pgx.Row) (models.ProjectTemplate, error) {
+	var t models.ProjectTemplate
+	var phases, checklist, boq, roles []byte
+	err := row.Scan(&t.ID, &t.TenantID, &t.ProjectType, &t.Version, &t.Label, &t.Description, &t.DefaultPhase,
+		&phases, &checklist, &boq, &roles, &t.RetiredAt, &t.CreatedBy, &t.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return t, errors.New("not found")
+	}
+	if err != nil {
+		return t, err
+	}
+	for _, f := range []struct {
+		name string
+		raw  []byte
+		dst  any
+	}{
+		{"phases", phases, &t.Phases},
+		{"checklist", checklist, &t.Checklist},
+		{"boq_items", boq, &t.BOQItems},
+		{"team_roles", roles, &t.TeamRoles},
+	} {
+		if err := json.Unmarshal(f.raw, f.dst); err != nil {
+			return models.ProjectTemplate{}, fmt.Errorf("project template %s: decode %s: %w", t.ID, f.name, err)
+		}
+	}
+	return t, nil
+}
+
+// CreateVersion stores t as the next version of its project type and
+// returns it with its version set. Storing a version of a retired type
+// brings the type back.
+func (r *ProjectTemplatesRepo) CreateVersion(ctx context.Context, t models.ProjectTemplate) (models.ProjectTemplate, error) {
+	phases, _ := json.Marshal(t.Phases)
+	checklist, _ := json.Marshal(t.Checklist)
+	boq, _ := json.Marshal(t.BOQItems)
+	roles, _ := json.Marshal(t.TeamRoles)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return models.ProjectTemplate{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE project_type_templates SET retired_at = NULL
+		WHERE tenant_id = $1 AND project_type = $2 AND retired_at IS NOT NULL
+	`, t.TenantID, t.ProjectType); err != nil {
+		return models.ProjectTemplate{}, err
+	}
+	out, err := scanProjectTemplate(tx.QueryRow(ctx, `
+		INSERT INTO project_type_templates (id, tenant_id, project_type, version, label, description, default_phase,
+			phases, checklist, boq_items, team_roles, created_by, created_at)
+		SELECT $1, $2, $3, COALESCE(MAX(version), 0) + 1, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		FROM project_type_templates WHERE tenant_id = $2 AND project_type = $3
+		RETURNING `+projectTemplateColumns,
+		t.ID, t.TenantID, t.ProjectType, t.Label, t.Description, t.DefaultPhase,
+		phases, checklist, boq, roles, t.CreatedBy, t.CreatedAt))
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return models.ProjectTemplate{}, ErrTemplateVersionConflict
+	}
+	return out, err
+}
+
+// Latest returns the newest version of a project type, retired or not.
+func (r *ProjectTemplatesRepo) Latest(ctx context.Context, tenantID string, projectType models.ProjectType) (models.ProjectTemplate, error) {
+	return scanProjectTemplate(r.pool.QueryRow(ctx, `
+		SELECT `+projectTemplateColumns+` FROM project_type_templates
+		WHERE tenant_id = $1 AND project_type = $2
+		ORDER BY version DESC LIMIT 1
+	`, tenantID, projectType))
+}
+
+func (r *ProjectTemplatesRepo) GetVersion(ctx context.Context, tenantID string, projectType models.ProjectType, version int) (models.ProjectTemplate, error) {
+	return scanProjectTemplate(r.pool.QueryRow(ctx, `
+		SELECT `+projectTemplateColumns+` FROM project_type_templates
+		WHERE tenant_id = $1 AND project_type = $2 AND version = $3
+	`, tenantID, projectType, version))
+}
+
+func (r *ProjectTemplatesRepo) GetByID(ctx context.Context, tenantID, id string) (models.ProjectTemplate, error) {
+	return scanProjectTemplate(r.pool.QueryRow(ctx, `
+		SELECT `+projectTemplateColumns+` FROM project_type_templates
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id))
+}
+
+// ListLatest returns the newest version of each of the tenant's project
+// types, ordered by key.
+func (r *ProjectTemplatesRepo) ListLatest(ctx context.Context, tenantID string) ([]models.ProjectTemplate, error) {
+	return r.list(ctx, `
+		SELECT DISTINCT ON (project_type) `+projectTemplateColumns+` FROM project_type_templates
+		WHERE tenant_id = $1
+		ORDER BY project_type, version DESC
+	`, tenantID)
+}
+
+// ListVersions returns every version of a project type, newest first.
+func (r *ProjectTemplatesRepo) ListVersions(ctx context.Context, tenantID string, projectType models.ProjectType) ([]models.ProjectTemplate, error) {
+	return r.list(ctx, `
+		SELECT `+projectTemplateColumns+` FROM project_type_templates
+		WHERE tenant_id = $1 AND project_type = $2
+		ORDER BY version DESC
+	`, tenantID, projectType)
+}
+
+// Retire stops a project type being offered for new projects. Projects
+// already created from it are unaffected. It reports whether any active
+// version was found.
+func (r *ProjectTemplatesRepo) Retire(ctx context.Context, tenantID string, projectType models.ProjectType, at time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE project_type_templates SET retired_at = $3
+		WHERE tenant_id = $1 AND project_type = $2 AND retired_at IS NULL
+	`, tenantID, projectType, at)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *ProjectTemplatesRepo) list(ctx context.Context, sql string, args ...any) ([]models.ProjectTemplate, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.ProjectTemplate{}
+	for rows.Next() {
+		t, err := scanProjectTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -12,13 +12,26 @@ import (
 
 type ProjectsRepo struct{ pool *pgxpool.Pool }
 
+const insertProjectSQL = `
+	INSERT INTO school_service_projects (
+		id, tenant_id, school_id, project_type, status, current_phase, start_date, go_live_date,
+		account_manager_user_id, notes, created_at, updated_at, template_id, template_version
+	) VALUES ($1,$2,$3,$4,$5,$6,NULLIF($7,''),NULLIF($8,''),$9,$10,$11,$12,$13,$14)`
+
+func projectArgs(p models.SchoolServiceProject) []any {
+	return []any{p.ID, p.TenantID, p.SchoolID, p.ProjectType, p.Status, p.CurrentPhase, p.StartDate, p.GoLiveDate,
+		p.AccountManagerUserID, p.Notes, p.CreatedAt, p.UpdatedAt, p.TemplateID, p.TemplateVersion}
+}
+
 func (r *ProjectsRepo) Create(ctx context.Context, p models.SchoolServiceProject) error {
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO school_service_projects (
-			id, tenant_id, school_id, project_type, status, current_phase, start_date, go_live_date,
-			account_manager_user_id, notes, created_at, updated_at
-		) VALUES ($1,$2,$3,$4,$5,$6,NULLIF($7,''),NULLIF($8,''),$9,$10,$11,$12)
-	`, p.ID, p.TenantID, p.SchoolID, p.ProjectType, p.Status, p.CurrentPhase, p.StartDate, p.GoLiveDate, p.AccountManagerUserID, p.Notes, p.CreatedAt, p.UpdatedAt)
+	_, err := r.pool.Exec(ctx, insertProjectSQL, projectArgs(p)...)
+	return err
+}
+
+// CreateProjectTx creates a project inside a transaction, so the BOQ lines
+// from its template can be created with it.
+func CreateProjectTx(ctx context.Context, tx Tx, p models.SchoolServiceProject) error {
+	_, err := tx.Exec(ctx, insertProjectSQL, projectArgs(p)...)
 	return err
 }
 
@@ -27,11 +40,11 @@ func (r *ProjectsRepo) GetByID(ctx context.Context, tenantID, id string) (models
 	var sd, gd *time.Time
 	row := r.pool.QueryRow(ctx, `
 		SELECT id, tenant_id, school_id, project_type, status, current_phase, start_date, go_live_date,
-			account_manager_user_id, notes, created_at, updated_at
+			account_manager_user_id, notes, created_at, updated_at, template_id, template_version
 		FROM school_service_projects
 		WHERE tenant_id=$1 AND id=$2
 	`, tenantID, id)
-	if err := row.Scan(&p.ID, &p.TenantID, &p.SchoolID, &p.ProjectType, &p.Status, &p.CurrentPhase, &sd, &gd, &p.AccountManagerUserID, &p.Notes, &p.CreatedAt, &p.UpdatedAt); err != nil {
+	if err := row.Scan(&p.ID, &p.TenantID, &p.SchoolID, &p.ProjectType, &p.Status, &p.CurrentPhase, &sd, &gd, &p.AccountManagerUserID, &p.Notes, &p.CreatedAt, &p.UpdatedAt, &p.TemplateID, &p.TemplateVersion); err != nil {
 		return models.SchoolServiceProject{}, errors.New("not found")
 	}
 	if sd != nil {
@@ -83,7 +96,7 @@ func (r *ProjectsRepo) List(ctx context.Context, p ProjectListParams) ([]models.
 
 	sql := `
 		SELECT id, tenant_id, school_id, project_type, status, current_phase, start_date, go_live_date,
-			account_manager_user_id, notes, created_at, updated_at
+			account_manager_user_id, notes, created_at, updated_at, template_id, template_version
 		FROM school_service_projects
 		WHERE ` + strings.Join(conds, " AND ") + `
 		ORDER BY created_at DESC, id DESC
@@ -99,7 +112,7 @@ func (r *ProjectsRepo) List(ctx context.Context, p ProjectListParams) ([]models.
 	for rows.Next() {
 		var x models.SchoolServiceProject
 		var sd, gd *time.Time
-		if err := rows.Scan(&x.ID, &x.TenantID, &x.SchoolID, &x.ProjectType, &x.Status, &x.CurrentPhase, &sd, &gd, &x.AccountManagerUserID, &x.Notes, &x.CreatedAt, &x.UpdatedAt); err != nil {
+		if err := rows.Scan(&x.ID, &x.TenantID, &x.SchoolID, &x.ProjectType, &x.Status, &x.CurrentPhase, &sd, &gd, &x.AccountManagerUserID, &x.Notes, &x.CreatedAt, &x.UpdatedAt, &x.TemplateID, &x.TemplateVersion); err != nil {
 			return nil, "", err
 		}
 		if sd != nil {
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PurchaseOrdersRepo reads purchase orders. Writes go through the *Tx helpers
+// so receipts commit together with their ledger postings.
+type PurchaseOrdersRepo struct{ pool *pgxpool.Pool }
+
+const purchaseOrderColumns = `id, tenant_id, service_shop_id, vendor_id, status, currency, total_cents, notes, created_by, approved_by,
+	expected_at, approved_at, sent_at, closed_at, created_at, updated_at`
+
+func scanPurchaseOrder(row pgx.Row, po *models.PurchaseOrder) error {
+	return row.Scan(&po.ID, &po.TenantID, &po.ServiceShopID, &po.VendorID, &po.Status, &po.Currency, &po.TotalCents,
+		&po.Notes, &po.CreatedBy, &po.ApprovedBy, &po.ExpectedAt, &po.ApprovedAt, &po.SentAt, &po.ClosedAt,
+		&po.CreatedAt, &po.UpdatedAt)
+}
+
+// PurchaseOrderTotal sums ordered quantity times unit price over the lines.
+func PurchaseOrderTotal(lines []models.PurchaseOrderLine) int64 {
+	var total int64
+	for _, l := range lines {
+		total += l.QtyOrdered * l.UnitPriceCents
+	}
+	return total
+}
+
+func CreatePurchaseOrderTx(ctx context.Context, tx Tx, po models.PurchaseOrder) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO purchase_orders (id, tenant_id, service_shop_id, vendor_id, status, currency, total_cents, notes,
+			created_by, expected_at, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+	`, po.ID, po.TenantID, po.ServiceShopID, po.VendorID, po.Status, po.Currency, po.TotalCents, po.Notes,
+		po.CreatedBy, po.ExpectedAt, po.CreatedAt, po.UpdatedAt); err != nil {
+		return err
+	}
+	return insertPurchaseOrderLines(ctx, tx, po)
+}
+
+func insertPurchaseOrderLines(ctx context.Context, tx Tx, po models.PurchaseOrder) error {
+	for _, l := range po.Lines {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO purchase_order_lines (po_id, part_id, vendor_sku_id, sku, qty_ordered, qty_received, unit_price_cents)
+			VALUES ($1,$2,$3,$4,$5,$6,$7)
+		`, po.ID, l.PartID, l.VendorSKUID, l.SKU, l.QtyOrdered, l.QtyReceived, l.UnitPriceCents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPurchaseOrderTx loads a purchase order and locks it for the rest of the
+// transaction.
+func GetPurchaseOrderTx(ctx context.Context, tx Tx, tenantID, id string) (models.PurchaseOrder, error) {
+	return getPurchaseOrder(ctx, tx, tenantID, id, " FOR UPDATE")
+}
+
+// UpdatePurchaseOrderTx saves a purchase order's header and received
+// quantities.
+func UpdatePurchaseOrderTx(ctx context.Context, tx Tx, po models.PurchaseOrder) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE purchase_orders SET status=$3, total_cents=$4, notes=$5, approved_by=$6, expected_at=$7,
+			approved_at=$8, sent_at=$9, closed_at=$10, updated_at=$11
+		WHERE tenant_id=$1 AND id=$2
+	`, po.TenantID, po.ID, po.Status, po.TotalCents, po.Notes, po.ApprovedBy, po.ExpectedAt,
+		po.ApprovedAt, po.SentAt, po.ClosedAt, po.UpdatedAt); err != nil {
+		return err
+	}
+	for _, l := range po.Lines {
+		if _, err := tx.Exec(ctx, `
+			UPDATE purchase_order_lines SET qty_received=$3 WHERE po_id=$1 AND part_id=$2
+		`, po.ID, l.PartID, l.QtyReceived); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplacePurchaseOrderLinesTx swaps all lines of a draft purchase order.
+func ReplacePurchaseOrderLinesTx(ctx context.Context, tx Tx, po models.PurchaseOrder) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM purchase_order_lines WHERE po_id=$1`, po.ID); err != nil {
+		return err
+	}
+	return insertPurchaseOrderLines(ctx, tx, po)
+}
+
+func (r *PurchaseOrdersRepo) Get(ctx context.Context, tenantID, id string) (models.PurchaseOrder, error) {
+	return getPurchaseOrder(ctx, r.pool, tenantID, id, "")
+}
+
+func getPurchaseOrder(ctx context.Context, q Tx, tenantID, id, lock string) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := scanPurchaseOrder(q.QueryRow(ctx, `
+		SELECT `+purchaseOrderColumns+` FROM purchase_orders WHERE tenant_id=$1 AND id=$2`+lock,
+		tenantID, id), &po)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PurchaseOrder{}, errors.New("not found")
+		}
+		return models.PurchaseOrder{}, err
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT part_id, vendor_sku_id, sku, qty_ordered, qty_received, unit_price_cents
+		FROM purchase_order_lines WHERE po_id=$1 ORDER BY part_id
+	`, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer rows.Close()
+	po.Lines = []models.PurchaseOrderLine{}
+	for rows.Next() {
+		var l models.PurchaseOrderLine
+		if err := rows.Scan(&l.PartID, &l.VendorSKUID, &l.SKU, &l.QtyOrdered, &l.QtyReceived, &l.UnitPriceCents); err != nil {
+			return models.PurchaseOrder{}, err
+		}
+		po.Lines = append(po.Lines, l)
+	}
+	return po, rows.Err()
+}
+
+type PurchaseOrderListParams struct {
+	TenantID string
+	ShopID   string
+	VendorID string
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+// List returns purchase orders newest first, without lines.
+func (r *PurchaseOrdersRepo) List(ctx context.Context, p PurchaseOrderListParams) ([]models.PurchaseOrder, error) {
+	where := "tenant_id=$1"
+	args := []any{p.TenantID}
+	if p.ShopID != "" {
+		args = append(args, p.ShopID)
+		where += " AND service_shop_id=$" + itoa(len(args))
+	}
+	if p.VendorID != "" {
+		args = append(args, p.VendorID)
+		where += " AND vendor_id=$" + itoa(len(args))
+	}
+	if p.Status != "" {
+		args = append(args, p.Status)
+		where += " AND status=$" + itoa(len(args))
+	}
+	args = append(args, p.Limit, p.Offset)
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+purchaseOrderColumns+` FROM purchase_orders WHERE `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+itoa(len(args)-1)+` OFFSET $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.PurchaseOrder{}
+	for rows.Next() {
+		var po models.PurchaseOrder
+		if err := scanPurchaseOrder(rows, &po); err != nil {
+			return nil, err
+		}
+		out = append(out, po)
+	}
+	return out, rows.Err()
+}
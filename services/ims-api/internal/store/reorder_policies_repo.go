@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReorderPoliciesRepo struct{ pool *pgxpool.Pool }
+
+// Upsert saves a policy and mirrors its reorder point into
+// inventory.reorder_threshold so the low-stock dashboard agrees with it.
+func (r *ReorderPoliciesRepo) Upsert(ctx context.Context, p models.ReorderPolicy) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO reorder_policies (tenant_id, service_shop_id, part_id, reorder_point, safety_stock, min_order_qty, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (tenant_id, service_shop_id, part_id)
+		DO UPDATE SET reorder_point=EXCLUDED.reorder_point, safety_stock=EXCLUDED.safety_stock,
+			min_order_qty=EXCLUDED.min_order_qty, updated_at=EXCLUDED.updated_at
+	`, p.TenantID, p.ServiceShopID, p.PartID, p.ReorderPoint, p.SafetyStock, p.MinOrderQty, p.UpdatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE inventory SET reorder_threshold=$4
+		WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
+	`, p.TenantID, p.ServiceShopID, p.PartID, p.ReorderPoint); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes a policy and clears the inventory.reorder_threshold Upsert
+// copied from it, so the threshold fallback does not keep reordering.
+func (r *ReorderPoliciesRepo) Delete(ctx context.Context, tenantID, shopID, partID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM reorder_policies WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
+	`, tenantID, shopID, partID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE inventory SET reorder_threshold=0
+		WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
+	`, tenantID, shopID, partID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *ReorderPoliciesRepo) List(ctx context.Context, tenantID, shopID string) ([]models.ReorderPolicy, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT tenant_id, service_shop_id, part_id, reorder_point, safety_stock, min_order_qty, updated_at
+		FROM reorder_policies
+		WHERE tenant_id=$1 AND ($2='' OR service_shop_id=$2)
+		ORDER BY service_shop_id, part_id
+	`, tenantID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ReorderPolicy{}
+	for rows.Next() {
+		var p models.ReorderPolicy
+		if err := rows.Scan(&p.TenantID, &p.ServiceShopID, &p.PartID, &p.ReorderPoint, &p.SafetyStock, &p.MinOrderQty, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ReorderCandidates returns every shop/part with a reorder point together
+// with its projected stock. Parts without a policy fall back to
+// inventory.reorder_threshold. Open purchase orders, including drafts, count
+// as stock on order so proposals are not repeated.
+func (r *ReorderPoliciesRepo) ReorderCandidates(ctx context.Context, tenantID, shopID string) ([]models.ReorderProposal, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH pol AS (
+			SELECT service_shop_id, part_id, reorder_point, safety_stock, min_order_qty
+			FROM reorder_policies WHERE tenant_id=$1
+			UNION ALL
+			SELECT i.service_shop_id, i.part_id, i.reorder_threshold, 0, 0
+			FROM inventory i
+			WHERE i.tenant_id=$1 AND i.reorder_threshold > 0
+			  AND NOT EXISTS (
+				SELECT 1 FROM reorder_policies p
+				WHERE p.tenant_id=i.tenant_id AND p.service_shop_id=i.service_shop_id AND p.part_id=i.part_id)
+		), on_order AS (
+			SELECT po.service_shop_id, l.part_id, SUM(GREATEST(l.qty_ordered - l.qty_received, 0)) AS qty
+			FROM purchase_orders po
+			JOIN purchase_order_lines l ON l.po_id = po.id
+			WHERE po.tenant_id=$1 AND po.status IN ('draft','approved','sent','partially_received')
+			GROUP BY po.service_shop_id, l.part_id
+		)
+		SELECT pol.service_shop_id, pol.part_id, COALESCE(pt.name, ''),
+			COALESCE(b.available, 0), COALESCE(b.in_transit, 0), COALESCE(o.qty, 0),
+			pol.reorder_point, pol.safety_stock, pol.min_order_qty
+		FROM pol
+		LEFT JOIN stock_balances b ON b.tenant_id=$1 AND b.service_shop_id=pol.service_shop_id AND b.part_id=pol.part_id
+		LEFT JOIN on_order o ON o.service_shop_id=pol.service_shop_id AND o.part_id=pol.part_id
+		LEFT JOIN parts pt ON pt.id=pol.part_id
+		WHERE $2='' OR pol.service_shop_id=$2
+		ORDER BY pol.service_shop_id, pol.part_id
+	`, tenantID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.ReorderProposal{}
+	for rows.Next() {
+		var p models.ReorderProposal
+		if err := rows.Scan(&p.ServiceShopID, &p.PartID, &p.PartName, &p.Available, &p.InTransit, &p.OnOrder,
+			&p.ReorderPoint, &p.SafetyStock, &p.MinOrderQty); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
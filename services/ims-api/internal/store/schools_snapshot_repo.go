@@ -13,10 +13,15 @@ import (
 type SchoolsSnapshotRepo struct{ pool *pgxpool.Pool }
 
 func (r *SchoolsSnapshotRepo) Upsert(ctx context.Context, s models.SchoolSnapshot) error {
+	return UpsertSchoolSnapshotTx(ctx, r.pool, s)
+}
+
+// UpsertSchoolSnapshotTx upserts a school snapshot within a transaction.
+func UpsertSchoolSnapshotTx(ctx context.Context, tx Tx, s models.SchoolSnapshot) error {
 	// Auto-lookup county/sub-county codes if missing but names are provided
-	s = r.enrichWithCodes(ctx, s)
+	s = enrichSchoolCodes(ctx, tx, s)
 
-	_, err := r.pool.Exec(ctx, `
+	_, err := tx.Exec(ctx, `
 		INSERT INTO schools_snapshot (
 			tenant_id, school_id, name, county_code, county_name, sub_county_code, sub_county_name,
 			level, type, knec_code, uic, sex, cluster, accommodation, latitude, longitude, updated_at
@@ -43,12 +48,12 @@ func (r *SchoolsSnapshotRepo) Upsert(ctx context.Context, s models.SchoolSnapsho
 	return err
 }
 
-// enrichWithCodes looks up county/sub-county codes from SSOT tables if codes are missing but names exist.
-func (r *SchoolsSnapshotRepo) enrichWithCodes(ctx context.Context, s models.SchoolSnapshot) models.SchoolSnapshot {
+// enrichSchoolCodes looks up county/sub-county codes from SSOT tables if codes are missing but names exist.
+func enrichSchoolCodes(ctx context.Context, tx Tx, s models.SchoolSnapshot) models.SchoolSnapshot {
 	// If county code is missing but name exists, look it up
 	if s.CountyCode == "" && s.CountyName != "" {
 		var code string
-		_ = r.pool.QueryRow(ctx, `SELECT code FROM ssot_counties WHERE LOWER(name) = LOWER($1)`, s.CountyName).Scan(&code)
+		_ = tx.QueryRow(ctx, `SELECT code FROM ssot_counties WHERE LOWER(name) = LOWER($1)`, s.CountyName).Scan(&code)
 		if code != "" {
 			s.CountyCode = code
 		}
@@ -57,7 +62,7 @@ func (r *SchoolsSnapshotRepo) enrichWithCodes(ctx context.Context, s models.Scho
 	// If sub-county code is missing but name and county code exist, look it up
 	if s.SubCountyCode == "" && s.SubCountyName != "" && s.CountyCode != "" {
 		var code string
-		_ = r.pool.QueryRow(ctx, `SELECT code FROM ssot_sub_counties WHERE LOWER(name) = LOWER($1) AND county_code = $2`, s.SubCountyName, s.CountyCode).Scan(&code)
+		_ = tx.QueryRow(ctx, `SELECT code FROM ssot_sub_counties WHERE LOWER(name) = LOWER($1) AND county_code = $2`, s.SubCountyName, s.CountyCode).Scan(&code)
 		if code != "" {
 			s.SubCountyCode = code
 		}
@@ -66,6 +71,16 @@ func (r *SchoolsSnapshotRepo) enrichWithCodes(ctx context.Context, s models.Scho
 	return s
 }
 
+func (r *SchoolsSnapshotRepo) Delete(ctx context.Context, tenantID, schoolID string) error {
+	return DeleteSchoolSnapshotTx(ctx, r.pool, tenantID, schoolID)
+}
+
+// DeleteSchoolSnapshotTx deletes a school snapshot within a transaction.
+func DeleteSchoolSnapshotTx(ctx context.Context, tx Tx, tenantID, schoolID string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM schools_snapshot WHERE tenant_id = $1 AND school_id = $2`, tenantID, schoolID)
+	return err
+}
+
 func (r *SchoolsSnapshotRepo) Get(ctx context.Context, tenantID, schoolID string) (models.SchoolSnapshot, error) {
 	var s models.SchoolSnapshot
 	row := r.pool.QueryRow(ctx, `
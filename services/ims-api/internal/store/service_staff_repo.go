@@ -48,6 +48,25 @@ func (r *ServiceStaffRepo) GetLeadByShop(ctx context.Context, tenantID, shopID s
 	return s, nil
 }
 
+// GetLeadByIncident returns the lead technician of the service shop handling
+// the incident's most recent work order.
+func (r *ServiceStaffRepo) GetLeadByIncident(ctx context.Context, tenantID, incidentID string) (models.ServiceStaff, error) {
+	var s models.ServiceStaff
+	row := r.pool.QueryRow(ctx, `
+		SELECT st.id, st.tenant_id, st.service_shop_id, st.user_id, st.role, st.phone, st.active, st.created_at, st.updated_at
+		FROM work_orders wo
+		JOIN service_staff st ON st.tenant_id=wo.tenant_id AND st.service_shop_id=wo.service_shop_id
+		WHERE wo.tenant_id=$1 AND wo.incident_id=$2 AND wo.service_shop_id <> ''
+		  AND st.role='lead_technician' AND st.active=true
+		ORDER BY wo.created_at DESC, st.created_at ASC
+		LIMIT 1
+	`, tenantID, incidentID)
+	if err := row.Scan(&s.ID, &s.TenantID, &s.ServiceShopID, &s.UserID, &s.Role, &s.Phone, &s.Active, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return models.ServiceStaff{}, errors.New("not found")
+	}
+	return s, nil
+}
+
 type StaffListParams struct {
 	TenantID        string
 	ShopID          string
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SLAPoliciesRepo handles tenant SLA policy persistence.
+type SLAPoliciesRepo struct{ pool *pgxpool.Pool }
+
+const slaPolicyColumns = `id, tenant_id, name, severity, category, county_id, school_level,
+	response_minutes, resolution_minutes, calendar_id, priority, active, created_at, updated_at`
+
+func scanSLAPolicy(row pgx.Row) (models.SLAPolicy, error) {
+	var p models.SLAPolicy
+	err := row.Scan(&p.ID, &p.TenantID, &p.Name, &p.Severity, &p.Category, &p.CountyID, &p.SchoolLevel,
+		&p.ResponseMinutes, &p.ResolutionMinutes, &p.CalendarID, &p.Priority, &p.Active, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+func (r *SLAPoliciesRepo) Create(ctx context.Context, p models.SLAPolicy) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO sla_policies (`+slaPolicyColumns+`)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+	`, p.ID, p.TenantID, p.Name, p.Severity, p.Category, p.CountyID, p.SchoolLevel,
+		p.ResponseMinutes, p.ResolutionMinutes, p.CalendarID, p.Priority, p.Active, p.CreatedAt, p.UpdatedAt)
+	return err
+}
+
+func (r *SLAPoliciesRepo) Update(ctx context.Context, p models.SLAPolicy) error {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE sla_policies SET
+			name=$3, severity=$4, category=$5, county_id=$6, school_level=$7,
+			response_minutes=$8, resolution_minutes=$9, calendar_id=$10, priority=$11, active=$12, updated_at=$13
+		WHERE tenant_id=$1 AND id=$2
+	`, p.TenantID, p.ID, p.Name, p.Severity, p.Category, p.CountyID, p.SchoolLevel,
+		p.ResponseMinutes, p.ResolutionMinutes, p.CalendarID, p.Priority, p.Active, p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *SLAPoliciesRepo) Get(ctx context.Context, tenantID, id string) (models.SLAPolicy, error) {
+	p, err := scanSLAPolicy(r.pool.QueryRow(ctx, `
+		SELECT `+slaPolicyColumns+`
+		FROM sla_policies WHERE tenant_id=$1 AND id=$2
+	`, tenantID, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SLAPolicy{}, errors.New("not found")
+		}
+		return models.SLAPolicy{}, err
+	}
+	return p, nil
+}
+
+func (r *SLAPoliciesRepo) Delete(ctx context.Context, tenantID, id string) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM sla_policies WHERE tenant_id=$1 AND id=$2`, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// List returns all policies for a tenant, optionally filtered by severity.
+func (r *SLAPoliciesRepo) List(ctx context.Context, tenantID string, severity models.Severity) ([]models.SLAPolicy, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+slaPolicyColumns+`
+		FROM sla_policies
+		WHERE tenant_id=$1 AND ($2='' OR severity=$2)
+		ORDER BY severity, priority DESC, name
+	`, tenantID, string(severity))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SLAPolicy{}
+	for rows.Next() {
+		p, err := scanSLAPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ListActive returns active policies for a tenant and severity, for policy matching.
+func (r *SLAPoliciesRepo) ListActive(ctx context.Context, tenantID string, severity models.Severity) ([]models.SLAPolicy, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+slaPolicyColumns+`
+		FROM sla_policies
+		WHERE tenant_id=$1 AND severity=$2 AND active=true
+	`, tenantID, severity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SLAPolicy{}
+	for rows.Next() {
+		p, err := scanSLAPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SLACalendarsRepo handles business-hours calendars and their holidays.
+type SLACalendarsRepo struct{ pool *pgxpool.Pool }
+
+func (r *SLACalendarsRepo) Create(ctx context.Context, c models.SLACalendar) error {
+	hours, err := json.Marshal(c.Hours)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `
+		INSERT INTO sla_calendars (id, tenant_id, name, timezone, hours, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+	`, c.ID, c.TenantID, c.Name, c.Timezone, hours, c.CreatedAt, c.UpdatedAt)
+	return err
+}
+
+func (r *SLACalendarsRepo) Update(ctx context.Context, c models.SLACalendar) error {
+	hours, err := json.Marshal(c.Hours)
+	if err != nil {
+		return err
+	}
+	result, err := r.pool.Exec(ctx, `
+		UPDATE sla_calendars SET name=$3, timezone=$4, hours=$5, updated_at=$6
+		WHERE tenant_id=$1 AND id=$2
+	`, c.TenantID, c.ID, c.Name, c.Timezone, hours, c.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// Get returns a calendar with its holidays loaded.
+func (r *SLACalendarsRepo) Get(ctx context.Context, tenantID, id string) (models.SLACalendar, error) {
+	var c models.SLACalendar
+	var hours []byte
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, tenant_id, name, timezone, hours, created_at, updated_at
+		FROM sla_calendars WHERE tenant_id=$1 AND id=$2
+	`, tenantID, id).Scan(&c.ID, &c.TenantID, &c.Name, &c.Timezone, &hours, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SLACalendar{}, errors.New("not found")
+		}
+		return models.SLACalendar{}, err
+	}
+	c.Hours = []models.BusinessHours{}
+	if len(hours) > 0 {
+		_ = json.Unmarshal(hours, &c.Hours)
+	}
+	c.Holidays, err = r.ListHolidays(ctx, tenantID, id)
+	if err != nil {
+		return models.SLACalendar{}, err
+	}
+	return c, nil
+}
+
+// ForPolicy returns the calendar attached to an SLA policy, or nil when the
+// incident has no policy or the policy runs 24x7.
+func (r *SLACalendarsRepo) ForPolicy(ctx context.Context, tenantID, policyID string) (*models.SLACalendar, error) {
+	if policyID == "" {
+		return nil, nil
+	}
+	var calendarID *string
+	err := r.pool.QueryRow(ctx, `
+		SELECT calendar_id FROM sla_policies WHERE tenant_id=$1 AND id=$2
+	`, tenantID, policyID).Scan(&calendarID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if calendarID == nil || *calendarID == "" {
+		return nil, nil
+	}
+	c, err := r.Get(ctx, tenantID, *calendarID)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *SLACalendarsRepo) List(ctx context.Context, tenantID string) ([]models.SLACalendar, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, name, timezone, hours, created_at, updated_at
+		FROM sla_calendars WHERE tenant_id=$1
+		ORDER BY name
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SLACalendar{}
+	for rows.Next() {
+		var c models.SLACalendar
+		var hours []byte
+		if err := rows.Scan(&c.ID, &c.TenantID, &c.Name, &c.Timezone, &hours, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		c.Hours = []models.BusinessHours{}
+		if len(hours) > 0 {
+			_ = json.Unmarshal(hours, &c.Hours)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *SLACalendarsRepo) Delete(ctx context.Context, tenantID, id string) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM sla_calendars WHERE tenant_id=$1 AND id=$2`, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// AddHoliday stores a holiday, renaming the calendar's existing holiday on
+// that date if there is one, and returns the stored row.
+func (r *SLACalendarsRepo) AddHoliday(ctx context.Context, h models.SLAHoliday, date time.Time) (models.SLAHoliday, error) {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO sla_holidays (id, tenant_id, calendar_id, holiday_date, name, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		ON CONFLICT (calendar_id, holiday_date) DO UPDATE SET name=EXCLUDED.name
+		RETURNING id, created_at
+	`, h.ID, h.TenantID, h.CalendarID, date, h.Name, h.CreatedAt).Scan(&h.ID, &h.CreatedAt)
+	return h, err
+}
+
+func (r *SLACalendarsRepo) DeleteHoliday(ctx context.Context, tenantID, calendarID, id string) error {
+	result, err := r.pool.Exec(ctx, `
+		DELETE FROM sla_holidays WHERE tenant_id=$1 AND calendar_id=$2 AND id=$3
+	`, tenantID, calendarID, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (r *SLACalendarsRepo) ListHolidays(ctx context.Context, tenantID, calendarID string) ([]models.SLAHoliday, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, calendar_id, holiday_date, name, created_at
+		FROM sla_holidays
+		WHERE tenant_id=$1 AND calendar_id=$2
+		ORDER BY holiday_date
+	`, tenantID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SLAHoliday{}
+	for rows.Next() {
+		var h models.SLAHoliday
+		var date time.Time
+		if err := rows.Scan(&h.ID, &h.TenantID, &h.CalendarID, &date, &h.Name, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		h.Date = date.Format("2006-01-02")
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
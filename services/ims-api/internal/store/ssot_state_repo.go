@@ -44,7 +44,13 @@ func (r *SSOTStateRepo) Get(ctx context.Context, tenantID string, res SSOTResour
 }
 
 func (r *SSOTStateRepo) Upsert(ctx context.Context, s SSOTSyncState) error {
-	_, err := r.pool.Exec(ctx, `
+	return UpsertSSOTStateTx(ctx, r.pool, s)
+}
+
+// UpsertSSOTStateTx saves a sync checkpoint within a transaction, so it
+// commits together with the rows it covers.
+func UpsertSSOTStateTx(ctx context.Context, tx Tx, s SSOTSyncState) error {
+	_, err := tx.Exec(ctx, `
 		INSERT INTO ssot_sync_state (tenant_id, resource, last_updated_since, last_cursor, updated_at)
 		VALUES ($1,$2,$3,$4,$5)
 		ON CONFLICT (tenant_id, resource)
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrDuplicateNonce is returned when a delivery reuses a nonce already seen
+// for the tenant.
+var ErrDuplicateNonce = errors.New("duplicate nonce")
+
+// SSOTWebhooksRepo stores webhook signing secrets and received deliveries.
+type SSOTWebhooksRepo struct{ pool *pgxpool.Pool }
+
+func (r *SSOTWebhooksRepo) CreateSecret(ctx context.Context, s models.SSOTWebhookSecret) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO ssot_webhook_secrets (id, tenant_id, label, secret, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6)
+	`, s.ID, s.TenantID, s.Label, s.Secret, s.CreatedBy, s.CreatedAt)
+	return err
+}
+
+// ActiveSecrets returns the tenant's non-revoked secrets, including their values.
+func (r *SSOTWebhooksRepo) ActiveSecrets(ctx context.Context, tenantID string) ([]models.SSOTWebhookSecret, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, label, secret, created_by, created_at
+		FROM ssot_webhook_secrets
+		WHERE tenant_id=$1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SSOTWebhookSecret{}
+	for rows.Next() {
+		var s models.SSOTWebhookSecret
+		if err := rows.Scan(&s.ID, &s.TenantID, &s.Label, &s.Secret, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// ListSecrets returns all of the tenant's secrets without their values.
+func (r *SSOTWebhooksRepo) ListSecrets(ctx context.Context, tenantID string) ([]models.SSOTWebhookSecret, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, label, created_by, created_at, revoked_at
+		FROM ssot_webhook_secrets
+		WHERE tenant_id=$1
+		ORDER BY created_at DESC
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SSOTWebhookSecret{}
+	for rows.Next() {
+		var s models.SSOTWebhookSecret
+		if err := rows.Scan(&s.ID, &s.TenantID, &s.Label, &s.CreatedBy, &s.CreatedAt, &s.RevokedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *SSOTWebhooksRepo) RevokeSecret(ctx context.Context, tenantID, id string, at time.Time) error {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE ssot_webhook_secrets SET revoked_at=$3
+		WHERE tenant_id=$1 AND id=$2 AND revoked_at IS NULL
+	`, tenantID, id, at)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// CreateDelivery records a verified delivery before its items are applied.
+// It returns ErrDuplicateNonce if the nonce was already used.
+func (r *SSOTWebhooksRepo) CreateDelivery(ctx context.Context, d models.SSOTWebhookDelivery) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO ssot_webhook_deliveries (id, tenant_id, resource, nonce, key_id, signed_at, status, payload, received_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+	`, d.ID, d.TenantID, d.Resource, d.Nonce, d.KeyID, d.SignedAt, d.Status, []byte(d.Payload), d.ReceivedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicateNonce
+	}
+	return err
+}
+
+// SaveResults stores the delivery's status and per-item results.
+func (r *SSOTWebhooksRepo) SaveResults(ctx context.Context, d models.SSOTWebhookDelivery) error {
+	results, err := json.Marshal(d.Results)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `
+		UPDATE ssot_webhook_deliveries
+		SET status=$3, results=$4, accepted=$5, rejected=$6, rolled_back_at=$7, rolled_back_by=$8
+		WHERE tenant_id=$1 AND id=$2
+	`, d.TenantID, d.ID, d.Status, results, d.Accepted, d.Rejected, d.RolledBackAt, d.RolledBackBy)
+	return err
+}
+
+// ClaimRollback marks an applied or partial delivery as rolled back so only
+// one caller restores it. It returns "not found" if the delivery does not
+// exist or cannot be rolled back.
+func (r *SSOTWebhooksRepo) ClaimRollback(ctx context.Context, tenantID, id, by string, at time.Time) error {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE ssot_webhook_deliveries SET status='rolled_back', rolled_back_at=$3, rolled_back_by=$4
+		WHERE tenant_id=$1 AND id=$2 AND status IN ('applied','partial')
+	`, tenantID, id, at, by)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+const ssotDeliveryColumns = `id, tenant_id, resource, nonce, key_id, signed_at, status, accepted, rejected,
+	received_at, rolled_back_at, rolled_back_by`
+
+func scanSSOTDelivery(row pgx.Row, extra ...any) (models.SSOTWebhookDelivery, error) {
+	var d models.SSOTWebhookDelivery
+	dest := []any{&d.ID, &d.TenantID, &d.Resource, &d.Nonce, &d.KeyID, &d.SignedAt, &d.Status, &d.Accepted, &d.Rejected,
+		&d.ReceivedAt, &d.RolledBackAt, &d.RolledBackBy}
+	err := row.Scan(append(dest, extra...)...)
+	return d, err
+}
+
+// GetDelivery returns a delivery with its payload and results.
+func (r *SSOTWebhooksRepo) GetDelivery(ctx context.Context, tenantID, id string) (models.SSOTWebhookDelivery, error) {
+	var payload, results []byte
+	d, err := scanSSOTDelivery(r.pool.QueryRow(ctx, `
+		SELECT `+ssotDeliveryColumns+`, payload, results
+		FROM ssot_webhook_deliveries WHERE tenant_id=$1 AND id=$2
+	`, tenantID, id), &payload, &results)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SSOTWebhookDelivery{}, errors.New("not found")
+		}
+		return models.SSOTWebhookDelivery{}, err
+	}
+	d.Payload = payload
+	if err := json.Unmarshal(results, &d.Results); err != nil {
+		return models.SSOTWebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+type SSOTDeliveryListParams struct {
+	TenantID string
+	Resource string
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+// ListDeliveries returns deliveries newest first, without payloads.
+func (r *SSOTWebhooksRepo) ListDeliveries(ctx context.Context, p SSOTDeliveryListParams) ([]models.SSOTWebhookDelivery, int, error) {
+	if p.Limit <= 0 || p.Limit > 200 {
+		p.Limit = 50
+	}
+	where := ` WHERE tenant_id=$1`
+	args := []any{p.TenantID}
+	if p.Resource != "" {
+		args = append(args, p.Resource)
+		where += ` AND resource=$` + itoa(len(args))
+	}
+	if p.Status != "" {
+		args = append(args, p.Status)
+		where += ` AND status=$` + itoa(len(args))
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM ssot_webhook_deliveries`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, p.Limit, p.Offset)
+	rows, err := r.pool.Query(ctx, `SELECT `+ssotDeliveryColumns+` FROM ssot_webhook_deliveries`+where+
+		` ORDER BY received_at DESC, id DESC LIMIT $`+itoa(len(args)-1)+` OFFSET $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := []models.SSOTWebhookDelivery{}
+	for rows.Next() {
+		d, err := scanSSOTDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, d)
+	}
+	return out, total, rows.Err()
+}
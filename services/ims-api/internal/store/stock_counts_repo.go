@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StockCountsRepo reads cycle counts. Writes go through the *Tx helpers.
+type StockCountsRepo struct{ pool *pgxpool.Pool }
+
+func CreateStockCountTx(ctx context.Context, tx Tx, c models.StockCount) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO stock_counts (id, tenant_id, service_shop_id, status, notes, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+	`, c.ID, c.TenantID, c.ServiceShopID, c.Status, c.Notes, c.CreatedBy, c.CreatedAt); err != nil {
+		return err
+	}
+	for _, l := range c.Lines {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO stock_count_lines (count_id, part_id, qty_expected, qty_counted, variance)
+			VALUES ($1,$2,$3,$4,$5)
+		`, c.ID, l.PartID, l.QtyExpected, l.QtyCounted, l.Variance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStockCountTx loads a count and locks it for the rest of the transaction.
+func GetStockCountTx(ctx context.Context, tx Tx, tenantID, id string) (models.StockCount, error) {
+	return getStockCount(ctx, tx, tenantID, id, " FOR UPDATE")
+}
+
+// UpdateStockCountTx saves a count's status and all of its lines.
+func UpdateStockCountTx(ctx context.Context, tx Tx, c models.StockCount) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE stock_counts SET status=$3, submitted_by=$4, submitted_at=$5, decided_by=$6, decided_at=$7
+		WHERE tenant_id=$1 AND id=$2
+	`, c.TenantID, c.ID, c.Status, c.SubmittedBy, c.SubmittedAt, c.DecidedBy, c.DecidedAt); err != nil {
+		return err
+	}
+	for _, l := range c.Lines {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO stock_count_lines (count_id, part_id, qty_expected, qty_counted, variance)
+			VALUES ($1,$2,$3,$4,$5)
+			ON CONFLICT (count_id, part_id) DO UPDATE SET
+				qty_expected=EXCLUDED.qty_expected, qty_counted=EXCLUDED.qty_counted, variance=EXCLUDED.variance
+		`, c.ID, l.PartID, l.QtyExpected, l.QtyCounted, l.Variance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *StockCountsRepo) Get(ctx context.Context, tenantID, id string) (models.StockCount, error) {
+	return getStockCount(ctx, r.pool, tenantID, id, "")
+}
+
+func getStockCount(ctx context.Context, q Tx, tenantID, id, lock string) (models.StockCount, error) {
+	var c models.StockCount
+	err := q.QueryRow(ctx, `
+		SELECT id, tenant_id, service_shop_id, status, notes, created_by, submitted_by, decided_by, created_at, submitted_at, decided_at
+		FROM stock_counts WHERE tenant_id=$1 AND id=$2`+lock,
+		tenantID, id).Scan(&c.ID, &c.TenantID, &c.ServiceShopID, &c.Status, &c.Notes, &c.CreatedBy, &c.SubmittedBy, &c.DecidedBy,
+		&c.CreatedAt, &c.SubmittedAt, &c.DecidedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.StockCount{}, errors.New("not found")
+		}
+		return models.StockCount{}, err
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT part_id, qty_expected, qty_counted, variance FROM stock_count_lines WHERE count_id=$1 ORDER BY part_id
+	`, id)
+	if err != nil {
+		return models.StockCount{}, err
+	}
+	defer rows.Close()
+	c.Lines = []models.StockCountLine{}
+	for rows.Next() {
+		var l models.StockCountLine
+		if err := rows.Scan(&l.PartID, &l.QtyExpected, &l.QtyCounted, &l.Variance); err != nil {
+			return models.StockCount{}, err
+		}
+		c.Lines = append(c.Lines, l)
+	}
+	return c, rows.Err()
+}
+
+// ShopPartIDsTx returns the parts a shop holds or has held, for counting.
+func ShopPartIDsTx(ctx context.Context, tx Tx, tenantID, shopID string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT part_id FROM inventory WHERE tenant_id=$1 AND service_shop_id=$2 ORDER BY part_id
+	`, tenantID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+type StockCountListParams struct {
+	TenantID string
+	ShopID   string
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+// List returns counts newest first, without lines.
+func (r *StockCountsRepo) List(ctx context.Context, p StockCountListParams) ([]models.StockCount, error) {
+	where := "tenant_id=$1"
+	args := []any{p.TenantID}
+	if p.ShopID != "" {
+		args = append(args, p.ShopID)
+		where += " AND service_shop_id=$" + itoa(len(args))
+	}
+	if p.Status != "" {
+		args = append(args, p.Status)
+		where += " AND status=$" + itoa(len(args))
+	}
+	args = append(args, p.Limit, p.Offset)
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, service_shop_id, status, notes, created_by, submitted_by, decided_by, created_at, submitted_at, decided_at
+		FROM stock_counts WHERE `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+itoa(len(args)-1)+` OFFSET $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.StockCount{}
+	for rows.Next() {
+		var c models.StockCount
+		if err := rows.Scan(&c.ID, &c.TenantID, &c.ServiceShopID, &c.Status, &c.Notes, &c.CreatedBy, &c.SubmittedBy, &c.DecidedBy,
+			&c.CreatedAt, &c.SubmittedAt, &c.DecidedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrInsufficientStock is returned when a posting would take available or
+	// reserved stock below zero.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidMovement is returned for movements with a bad quantity or
+	// missing shop.
+	ErrInvalidMovement = errors.New("invalid stock movement")
+)
+
+// LedgerEntries returns the balanced entries for a movement. Stock held by a
+// shop lives in the available, reserved and in_transit accounts; each movement
+// moves it between those or to an outside account.
+func LedgerEntries(m models.StockMovement) ([]models.StockLedgerEntry, error) {
+	shop, part, q := m.ServiceShopID, m.PartID, m.Qty
+	if shop == "" || part == "" {
+		return nil, ErrInvalidMovement
+	}
+	switch m.Type {
+	case models.StockAdjustment, models.StockCycleCount:
+		if q == 0 {
+			return nil, ErrInvalidMovement
+		}
+	default:
+		if q <= 0 {
+			return nil, ErrInvalidMovement
+		}
+	}
+	// move takes q out of one account and puts it into another.
+	move := func(fromShop string, from models.StockAccount, toShop string, to models.StockAccount) []models.StockLedgerEntry {
+		return []models.StockLedgerEntry{
+			{ServiceShopID: fromShop, PartID: part, Account: from, Qty: -q},
+			{ServiceShopID: toShop, PartID: part, Account: to, Qty: q},
+		}
+	}
+
+	switch m.Type {
+	case models.StockOpening:
+		return move(shop, models.AccountOpening, shop, models.AccountAvailable), nil
+	case models.StockReceipt:
+		return move(shop, models.AccountSupplier, shop, models.AccountAvailable), nil
+	case models.StockReservation:
+		return move(shop, models.AccountAvailable, shop, models.AccountReserved), nil
+	case models.StockRelease:
+		return move(shop, models.AccountReserved, shop, models.AccountAvailable), nil
+	case models.StockIssue:
+		return move(shop, models.AccountReserved, shop, models.AccountConsumed), nil
+	case models.StockAdjustment, models.StockCycleCount:
+		return move(shop, models.AccountAdjustment, shop, models.AccountAvailable), nil
+	}
+
+	// Transfers: in-transit stock is held against the destination shop.
+	counter := m.CounterShopID
+	if counter == "" || counter == shop {
+		return nil, ErrInvalidMovement
+	}
+	switch m.Type {
+	case models.StockTransferOut: // shop = origin, counter = destination
+		return move(shop, models.AccountAvailable, counter, models.AccountInTransit), nil
+	case models.StockTransferIn: // shop = destination, counter = origin
+		return move(shop, models.AccountInTransit, shop, models.AccountAvailable), nil
+	case models.StockTransferLoss: // shop = destination, counter = origin
+		return move(shop, models.AccountInTransit, shop, models.AccountAdjustment), nil
+	case models.StockTransferCancel: // shop = origin, counter = destination
+		return move(counter, models.AccountInTransit, shop, models.AccountAvailable), nil
+	}
+	return nil, ErrInvalidMovement
+}
+
+// PostStockMovementTx writes a movement and its entries in the caller's
+// transaction and applies them to the inventory projection. It returns
+// ErrInsufficientStock, leaving the transaction to be rolled back, if
+// available or reserved stock would go negative.
+func PostStockMovementTx(ctx context.Context, tx Tx, m models.StockMovement) (models.StockMovement, error) {
+	entries, err := LedgerEntries(m)
+	if err != nil {
+		return m, err
+	}
+	if m.ID == "" {
+		m.ID = NewID("mv")
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().UTC()
+	}
+	m.Entries = entries
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO stock_movements (id, tenant_id, movement_type, service_shop_id, counter_shop_id, part_id, qty,
+			reason_code, reference_type, reference_id, notes, actor_id, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+	`, m.ID, m.TenantID, m.Type, m.ServiceShopID, m.CounterShopID, m.PartID, m.Qty,
+		m.ReasonCode, m.ReferenceType, m.ReferenceID, m.Notes, m.ActorID, m.CreatedAt); err != nil {
+		return m, err
+	}
+
+	type key struct{ shop, part string }
+	type delta struct{ onHand, reserved int64 }
+	deltas := map[key]delta{}
+	for _, e := range entries {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO stock_ledger_entries (tenant_id, movement_id, service_shop_id, part_id, account, qty, created_at)
+			VALUES ($1,$2,$3,$4,$5,$6,$7)
+		`, m.TenantID, m.ID, e.ServiceShopID, e.PartID, e.Account, e.Qty, m.CreatedAt); err != nil {
+			return m, err
+		}
+		k := key{e.ServiceShopID, e.PartID}
+		d := deltas[k]
+		switch e.Account {
+		case models.AccountAvailable:
+			d.onHand += e.Qty
+		case models.AccountReserved:
+			d.onHand += e.Qty
+			d.reserved += e.Qty
+		default:
+			continue
+		}
+		deltas[k] = d
+	}
+
+	// inventory.qty_available is on-hand stock (available + reserved).
+	for k, d := range deltas {
+		if d.onHand == 0 && d.reserved == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO inventory (id, tenant_id, service_shop_id, part_id, qty_available, qty_reserved, reorder_threshold, updated_at)
+			VALUES ($1,$2,$3,$4,0,0,0,$5)
+			ON CONFLICT (tenant_id, service_shop_id, part_id) DO NOTHING
+		`, NewID("inv"), m.TenantID, k.shop, k.part, m.CreatedAt); err != nil {
+			return m, err
+		}
+		tag, err := tx.Exec(ctx, `
+			UPDATE inventory
+			SET qty_available = qty_available + $4, qty_reserved = qty_reserved + $5, updated_at = $6
+			WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
+			  AND qty_reserved + $5 >= 0
+			  AND (qty_available + $4) - (qty_reserved + $5) >= 0
+		`, m.TenantID, k.shop, k.part, d.onHand, d.reserved, m.CreatedAt)
+		if err != nil {
+			return m, err
+		}
+		if tag.RowsAffected() == 0 {
+			return m, ErrInsufficientStock
+		}
+	}
+	return m, nil
+}
+
+// StockBalanceTx sums a shop's position in a part from the ledger.
+func StockBalanceTx(ctx context.Context, tx Tx, tenantID, shopID, partID string) (models.StockBalance, error) {
+	b := models.StockBalance{ServiceShopID: shopID, PartID: partID}
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(on_hand),0), COALESCE(SUM(reserved),0), COALESCE(SUM(available),0), COALESCE(SUM(in_transit),0)
+		FROM stock_balances WHERE tenant_id=$1 AND service_shop_id=$2 AND part_id=$3
+	`, tenantID, shopID, partID).Scan(&b.OnHand, &b.Reserved, &b.Available, &b.InTransit)
+	return b, err
+}
+
+// StockLedgerRepo reads the stock ledger.
+type StockLedgerRepo struct{ pool *pgxpool.Pool }
+
+type StockMovementListParams struct {
+	TenantID        string
+	ShopID          string
+	PartID          string
+	Type            string
+	ReferenceType   string
+	ReferenceID     string
+	Since           time.Time
+	Limit           int
+	HasCursor       bool
+	CursorCreatedAt time.Time
+	CursorID        string
+}
+
+// ListMovements returns movements newest first. A movement involving the
+// shop as either side of a transfer is included when filtering by shop.
+func (r *StockLedgerRepo) ListMovements(ctx context.Context, p StockMovementListParams) ([]models.StockMovement, string, error) {
+	conds := []string{"m.tenant_id=$1"}
+	args := []any{p.TenantID}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, strings.ReplaceAll(cond, "?", "$"+itoa(len(args))))
+	}
+	if p.ShopID != "" {
+		add("(m.service_shop_id=? OR m.counter_shop_id=?)", p.ShopID)
+	}
+	if p.PartID != "" {
+		add("m.part_id=?", p.PartID)
+	}
+	if p.Type != "" {
+		add("m.movement_type=?", p.Type)
+	}
+	if p.ReferenceType != "" {
+		add("m.reference_type=?", p.ReferenceType)
+	}
+	if p.ReferenceID != "" {
+		add("m.reference_id=?", p.ReferenceID)
+	}
+	if !p.Since.IsZero() {
+		add("m.created_at>=?", p.Since)
+	}
+	if p.HasCursor {
+		args = append(args, p.CursorCreatedAt, p.CursorID)
+		conds = append(conds, "(m.created_at, m.id) < ($"+itoa(len(args)-1)+", $"+itoa(len(args))+")")
+	}
+	args = append(args, p.Limit+1)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT m.id, m.tenant_id, m.movement_type, m.service_shop_id, m.counter_shop_id, m.part_id, COALESCE(pt.name, ''),
+			m.qty, m.reason_code, m.reference_type, m.reference_id, m.notes, m.actor_id, m.created_at
+		FROM stock_movements m
+		LEFT JOIN parts pt ON pt.id = m.part_id
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	out := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.Type, &m.ServiceShopID, &m.CounterShopID, &m.PartID, &m.PartName,
+			&m.Qty, &m.ReasonCode, &m.ReferenceType, &m.ReferenceID, &m.Notes, &m.ActorID, &m.CreatedAt); err != nil {
+			return nil, "", err
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(out) > p.Limit {
+		last := out[p.Limit-1]
+		next = EncodeCursor(last.CreatedAt, last.ID)
+		out = out[:p.Limit]
+	}
+	return out, next, nil
+}
+
+// CountMovements returns how many movements were posted since a time.
+func (r *StockLedgerRepo) CountMovements(ctx context.Context, tenantID string, since time.Time) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM stock_movements WHERE tenant_id=$1 AND created_at>=$2
+	`, tenantID, since).Scan(&n)
+	return n, err
+}
+
+// Balances returns ledger balances, optionally filtered by shop and part.
+func (r *StockLedgerRepo) Balances(ctx context.Context, tenantID, shopID, partID string) ([]models.StockBalance, error) {
+	conds := []string{"tenant_id=$1"}
+	args := []any{tenantID}
+	if shopID != "" {
+		args = append(args, shopID)
+		conds = append(conds, "service_shop_id=$"+itoa(len(args)))
+	}
+	if partID != "" {
+		args = append(args, partID)
+		conds = append(conds, "part_id=$"+itoa(len(args)))
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT service_shop_id, part_id, on_hand, reserved, available, in_transit
+		FROM stock_balances
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY service_shop_id, part_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.StockBalance{}
+	for rows.Next() {
+		var b models.StockBalance
+		if err := rows.Scan(&b.ServiceShopID, &b.PartID, &b.OnHand, &b.Reserved, &b.Available, &b.InTransit); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
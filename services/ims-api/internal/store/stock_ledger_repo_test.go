@@ -0,0 +1,105 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerEntries(t *testing.T) {
+	type acct struct {
+		shop    string
+		account models.StockAccount
+	}
+	tests := []struct {
+		name    string
+		m       models.StockMovement
+		want    map[acct]int64
+		wantErr bool
+	}{
+		{
+			name: "receipt adds available stock",
+			m:    models.StockMovement{Type: models.StockReceipt, ServiceShopID: "shopA", PartID: "p1", Qty: 5},
+			want: map[acct]int64{{"shopA", models.AccountSupplier}: -5, {"shopA", models.AccountAvailable}: 5},
+		},
+		{
+			name: "reservation moves available to reserved",
+			m:    models.StockMovement{Type: models.StockReservation, ServiceShopID: "shopA", PartID: "p1", Qty: 2},
+			want: map[acct]int64{{"shopA", models.AccountAvailable}: -2, {"shopA", models.AccountReserved}: 2},
+		},
+		{
+			name: "issue consumes reserved stock",
+			m:    models.StockMovement{Type: models.StockIssue, ServiceShopID: "shopA", PartID: "p1", Qty: 2},
+			want: map[acct]int64{{"shopA", models.AccountReserved}: -2, {"shopA", models.AccountConsumed}: 2},
+		},
+		{
+			name: "transfer out holds stock in transit at the destination",
+			m:    models.StockMovement{Type: models.StockTransferOut, ServiceShopID: "shopA", CounterShopID: "shopB", PartID: "p1", Qty: 3},
+			want: map[acct]int64{{"shopA", models.AccountAvailable}: -3, {"shopB", models.AccountInTransit}: 3},
+		},
+		{
+			name: "transfer in releases in-transit stock",
+			m:    models.StockMovement{Type: models.StockTransferIn, ServiceShopID: "shopB", CounterShopID: "shopA", PartID: "p1", Qty: 3},
+			want: map[acct]int64{{"shopB", models.AccountInTransit}: -3, {"shopB", models.AccountAvailable}: 3},
+		},
+		{
+			name: "transfer cancel returns stock to the origin",
+			m:    models.StockMovement{Type: models.StockTransferCancel, ServiceShopID: "shopA", CounterShopID: "shopB", PartID: "p1", Qty: 3},
+			want: map[acct]int64{{"shopB", models.AccountInTransit}: -3, {"shopA", models.AccountAvailable}: 3},
+		},
+		{
+			name: "negative adjustment removes available stock",
+			m:    models.StockMovement{Type: models.StockAdjustment, ServiceShopID: "shopA", PartID: "p1", Qty: -4},
+			want: map[acct]int64{{"shopA", models.AccountAdjustment}: 4, {"shopA", models.AccountAvailable}: -4},
+		},
+		{
+			name:    "zero adjustment is rejected",
+			m:       models.StockMovement{Type: models.StockAdjustment, ServiceShopID: "shopA", PartID: "p1", Qty: 0},
+			wantErr: true,
+		},
+		{
+			name:    "negative receipt is rejected",
+			m:       models.StockMovement{Type: models.StockReceipt, ServiceShopID: "shopA", PartID: "p1", Qty: -1},
+			wantErr: true,
+		},
+		{
+			name:    "transfer to the same shop is rejected",
+			m:       models.StockMovement{Type: models.StockTransferOut, ServiceShopID: "shopA", CounterShopID: "shopA", PartID: "p1", Qty: 1},
+			wantErr: true,
+		},
+		{
+			name:    "missing shop is rejected",
+			m:       models.StockMovement{Type: models.StockReceipt, PartID: "p1", Qty: 1},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type is rejected",
+			m:       models.StockMovement{Type: "teleport", ServiceShopID: "shopA", CounterShopID: "shopB", PartID: "p1", Qty: 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := store.LedgerEntries(tt.m)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, store.ErrInvalidMovement)
+				return
+			}
+			require.NoError(t, err)
+
+			var sum int64
+			got := map[acct]int64{}
+			for _, e := range entries {
+				assert.Equal(t, tt.m.PartID, e.PartID)
+				sum += e.Qty
+				got[acct{e.ServiceShopID, e.Account}] += e.Qty
+			}
+			assert.Zero(t, sum, "entries must balance")
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StockTransfersRepo reads inter-shop transfers. Writes go through the *Tx
+// helpers so they commit with their ledger postings.
+type StockTransfersRepo struct{ pool *pgxpool.Pool }
+
+func CreateStockTransferTx(ctx context.Context, tx Tx, t models.StockTransfer) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO stock_transfers (id, tenant_id, from_shop_id, to_shop_id, status, notes, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	`, t.ID, t.TenantID, t.FromShopID, t.ToShopID, t.Status, t.Notes, t.CreatedBy, t.CreatedAt); err != nil {
+		return err
+	}
+	for _, l := range t.Lines {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO stock_transfer_lines (transfer_id, part_id, qty_shipped, qty_received)
+			VALUES ($1,$2,$3,$4)
+		`, t.ID, l.PartID, l.QtyShipped, l.QtyReceived); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStockTransferTx loads a transfer and locks it for the rest of the
+// transaction.
+func GetStockTransferTx(ctx context.Context, tx Tx, tenantID, id string) (models.StockTransfer, error) {
+	return getStockTransfer(ctx, tx, tenantID, id, " FOR UPDATE")
+}
+
+// UpdateStockTransferTx saves a transfer's status and received quantities.
+func UpdateStockTransferTx(ctx context.Context, tx Tx, t models.StockTransfer) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE stock_transfers SET status=$3, received_by=$4, received_at=$5, cancelled_at=$6
+		WHERE tenant_id=$1 AND id=$2
+	`, t.TenantID, t.ID, t.Status, t.ReceivedBy, t.ReceivedAt, t.CancelledAt); err != nil {
+		return err
+	}
+	for _, l := range t.Lines {
+		if _, err := tx.Exec(ctx, `
+			UPDATE stock_transfer_lines SET qty_received=$3 WHERE transfer_id=$1 AND part_id=$2
+		`, t.ID, l.PartID, l.QtyReceived); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *StockTransfersRepo) Get(ctx context.Context, tenantID, id string) (models.StockTransfer, error) {
+	return getStockTransfer(ctx, r.pool, tenantID, id, "")
+}
+
+func getStockTransfer(ctx context.Context, q Tx, tenantID, id, lock string) (models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := q.QueryRow(ctx, `
+		SELECT id, tenant_id, from_shop_id, to_shop_id, status, notes, created_by, received_by, created_at, received_at, cancelled_at
+		FROM stock_transfers WHERE tenant_id=$1 AND id=$2`+lock,
+		tenantID, id).Scan(&t.ID, &t.TenantID, &t.FromShopID, &t.ToShopID, &t.Status, &t.Notes, &t.CreatedBy, &t.ReceivedBy,
+		&t.CreatedAt, &t.ReceivedAt, &t.CancelledAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.StockTransfer{}, errors.New("not found")
+		}
+		return models.StockTransfer{}, err
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT part_id, qty_shipped, qty_received FROM stock_transfer_lines WHERE transfer_id=$1 ORDER BY part_id
+	`, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer rows.Close()
+	t.Lines = []models.StockTransferLine{}
+	for rows.Next() {
+		var l models.StockTransferLine
+		if err := rows.Scan(&l.PartID, &l.QtyShipped, &l.QtyReceived); err != nil {
+			return models.StockTransfer{}, err
+		}
+		t.Lines = append(t.Lines, l)
+	}
+	return t, rows.Err()
+}
+
+type StockTransferListParams struct {
+	TenantID string
+	ShopID   string // either side
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+// List returns transfers newest first, without lines.
+func (r *StockTransfersRepo) List(ctx context.Context, p StockTransferListParams) ([]models.StockTransfer, error) {
+	where := "tenant_id=$1"
+	args := []any{p.TenantID}
+	if p.ShopID != "" {
+		args = append(args, p.ShopID)
+		where += " AND (from_shop_id=$" + itoa(len(args)) + " OR to_shop_id=$" + itoa(len(args)) + ")"
+	}
+	if p.Status != "" {
+		args = append(args, p.Status)
+		where += " AND status=$" + itoa(len(args))
+	}
+	args = append(args, p.Limit, p.Offset)
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, from_shop_id, to_shop_id, status, notes, created_by, received_by, created_at, received_at, cancelled_at
+		FROM stock_transfers WHERE `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+itoa(len(args)-1)+` OFFSET $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.StockTransfer{}
+	for rows.Next() {
+		var t models.StockTransfer
+		if err := rows.Scan(&t.ID, &t.TenantID, &t.FromShopID, &t.ToShopID, &t.Status, &t.Notes, &t.CreatedBy, &t.ReceivedBy,
+			&t.CreatedAt, &t.ReceivedAt, &t.CancelledAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
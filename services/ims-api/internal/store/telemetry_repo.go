@@ -0,0 +1,256 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrTelemetryReplay is returned when a sender's event ID was already
+// ingested.
+var ErrTelemetryReplay = errors.New("telemetry event already ingested")
+
+// TelemetryRepo stores raw telemetry events, their correlations into
+// incidents, and the tenant's per-type rules.
+type TelemetryRepo struct{ pool *pgxpool.Pool }
+
+const telemetryRuleColumns = `id, tenant_id, event_type, severity, dedupe_window_seconds, recovery_event_type,
+	auto_resolve, create_incident, created_at, updated_at`
+
+func scanTelemetryRule(row pgx.Row) (models.TelemetryRule, error) {
+	var x models.TelemetryRule
+	err := row.Scan(&x.ID, &x.TenantID, &x.EventType, &x.Severity, &x.DedupeWindowSeconds, &x.RecoveryEventType,
+		&x.AutoResolve, &x.CreateIncident, &x.CreatedAt, &x.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return x, errors.New("not found")
+	}
+	return x, err
+}
+
+func (r *TelemetryRepo) ListRules(ctx context.Context, tenantID string) ([]models.TelemetryRule, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+telemetryRuleColumns+` FROM telemetry_rules WHERE tenant_id=$1 ORDER BY event_type
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.TelemetryRule{}
+	for rows.Next() {
+		x, err := scanTelemetryRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+// UpsertRule creates or replaces the rule for rule.EventType.
+func (r *TelemetryRepo) UpsertRule(ctx context.Context, rule models.TelemetryRule) (models.TelemetryRule, error) {
+	return scanTelemetryRule(r.pool.QueryRow(ctx, `
+		INSERT INTO telemetry_rules (id, tenant_id, event_type, severity, dedupe_window_seconds, recovery_event_type,
+			auto_resolve, create_incident, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$9)
+		ON CONFLICT (tenant_id, event_type) DO UPDATE SET severity=EXCLUDED.severity,
+			dedupe_window_seconds=EXCLUDED.dedupe_window_seconds, recovery_event_type=EXCLUDED.recovery_event_type,
+			auto_resolve=EXCLUDED.auto_resolve, create_incident=EXCLUDED.create_incident, updated_at=EXCLUDED.updated_at
+		RETURNING `+telemetryRuleColumns,
+		rule.ID, rule.TenantID, rule.EventType, rule.Severity, rule.DedupeWindowSeconds, rule.RecoveryEventType,
+		rule.AutoResolve, rule.CreateIncident, rule.UpdatedAt))
+}
+
+func (r *TelemetryRepo) DeleteRule(ctx context.Context, tenantID, eventType string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM telemetry_rules WHERE tenant_id=$1 AND event_type=$2`, tenantID, eventType)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+const telemetryEventColumns = `id, tenant_id, school_id, device_id, mac_address, event_type, recovery, severity, message, reported_by,
+	external_id, attributes, outcome, correlation_id, incident_id, occurred_at, received_at`
+
+func scanTelemetryEvent(row pgx.Row) (models.TelemetryEvent, error) {
+	var x models.TelemetryEvent
+	var attrs []byte
+	err := row.Scan(&x.ID, &x.TenantID, &x.SchoolID, &x.DeviceID, &x.MACAddress, &x.EventType, &x.Recovery, &x.Severity, &x.Message, &x.ReportedBy,
+		&x.ExternalID, &attrs, &x.Outcome, &x.CorrelationID, &x.IncidentID, &x.OccurredAt, &x.ReceivedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return x, errors.New("not found")
+	}
+	if err == nil && len(attrs) > 0 {
+		_ = json.Unmarshal(attrs, &x.Attributes)
+	}
+	return x, err
+}
+
+// GetEventByExternalID returns the event a sender already delivered.
+func (r *TelemetryRepo) GetEventByExternalID(ctx context.Context, tenantID, externalID string) (models.TelemetryEvent, error) {
+	return scanTelemetryEvent(r.pool.QueryRow(ctx, `
+		SELECT `+telemetryEventColumns+` FROM telemetry_events WHERE tenant_id=$1 AND external_id=$2
+	`, tenantID, externalID))
+}
+
+// TelemetryEventFilter narrows ListEvents. Empty fields match everything.
+type TelemetryEventFilter struct {
+	DeviceID   string
+	EventType  string
+	IncidentID string
+	Limit      int
+	Offset     int
+}
+
+// ListEvents returns raw events, newest first.
+func (r *TelemetryRepo) ListEvents(ctx context.Context, tenantID string, f TelemetryEventFilter) ([]models.TelemetryEvent, error) {
+	where := "tenant_id=$1"
+	args := []any{tenantID}
+	add := func(col, v string) {
+		if v != "" {
+			args = append(args, v)
+			where += " AND " + col + "=$" + itoa(len(args))
+		}
+	}
+	add("device_id", f.DeviceID)
+	add("event_type", f.EventType)
+	add("incident_id", f.IncidentID)
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+telemetryEventColumns+` FROM telemetry_events
+		WHERE `+where+`
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $`+itoa(len(args)-1)+` OFFSET $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.TelemetryEvent{}
+	for rows.Next() {
+		x, err := scanTelemetryEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+const telemetryCorrelationColumns = `id, tenant_id, device_id, event_type, incident_id, occurrences, duplicates,
+	first_occurred_at, last_occurred_at, resolved_at`
+
+func scanTelemetryCorrelation(row pgx.Row) (models.TelemetryCorrelation, error) {
+	var x models.TelemetryCorrelation
+	err := row.Scan(&x.ID, &x.TenantID, &x.DeviceID, &x.EventType, &x.IncidentID, &x.Occurrences, &x.Duplicates,
+		&x.FirstOccurredAt, &x.LastOccurredAt, &x.ResolvedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return x, errors.New("not found")
+	}
+	return x, err
+}
+
+// GetCorrelationByIncident returns the correlation that opened an incident.
+func (r *TelemetryRepo) GetCorrelationByIncident(ctx context.Context, tenantID, incidentID string) (models.TelemetryCorrelation, error) {
+	return scanTelemetryCorrelation(r.pool.QueryRow(ctx, `
+		SELECT `+telemetryCorrelationColumns+` FROM telemetry_correlations WHERE tenant_id=$1 AND incident_id=$2
+	`, tenantID, incidentID))
+}
+
+// LockTelemetryKeyTx serializes ingestion for one device and event type
+// until the transaction ends.
+func LockTelemetryKeyTx(ctx context.Context, tx Tx, tenantID, deviceID, eventType string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "telemetry|"+tenantID+"|"+deviceID+"|"+eventType)
+	return err
+}
+
+// GetOpenTelemetryCorrelationTx returns the open correlation for a device
+// and event type, or nil when there is none.
+func GetOpenTelemetryCorrelationTx(ctx context.Context, tx Tx, tenantID, deviceID, eventType string) (*models.TelemetryCorrelation, error) {
+	c, err := scanTelemetryCorrelation(tx.QueryRow(ctx, `
+		SELECT `+telemetryCorrelationColumns+` FROM telemetry_correlations
+		WHERE tenant_id=$1 AND device_id=$2 AND event_type=$3 AND resolved_at IS NULL
+		FOR UPDATE
+	`, tenantID, deviceID, eventType))
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func CreateTelemetryCorrelationTx(ctx context.Context, tx Tx, c models.TelemetryCorrelation) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO telemetry_correlations (id, tenant_id, device_id, event_type, incident_id, occurrences, duplicates,
+			first_occurred_at, last_occurred_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+	`, c.ID, c.TenantID, c.DeviceID, c.EventType, c.IncidentID, c.Occurrences, c.Duplicates, c.FirstOccurredAt, c.LastOccurredAt)
+	return err
+}
+
+// CountTelemetryOccurrenceTx records another occurrence on an open
+// correlation.
+func CountTelemetryOccurrenceTx(ctx context.Context, tx Tx, tenantID, id string, at time.Time) (int, error) {
+	var n int
+	err := tx.QueryRow(ctx, `
+		UPDATE telemetry_correlations SET occurrences=occurrences+1, last_occurred_at=GREATEST(last_occurred_at, $3)
+		WHERE tenant_id=$1 AND id=$2
+		RETURNING occurrences
+	`, tenantID, id, at).Scan(&n)
+	return n, err
+}
+
+// CountTelemetryDuplicateTx records a suppressed duplicate on an open
+// correlation.
+func CountTelemetryDuplicateTx(ctx context.Context, tx Tx, tenantID, id string) error {
+	_, err := tx.Exec(ctx, `UPDATE telemetry_correlations SET duplicates=duplicates+1 WHERE tenant_id=$1 AND id=$2`, tenantID, id)
+	return err
+}
+
+func ResolveTelemetryCorrelationTx(ctx context.Context, tx Tx, tenantID, id string, at time.Time) error {
+	_, err := tx.Exec(ctx, `UPDATE telemetry_correlations SET resolved_at=$3 WHERE tenant_id=$1 AND id=$2`, tenantID, id, at)
+	return err
+}
+
+func InsertTelemetryEventTx(ctx context.Context, tx Tx, ev models.TelemetryEvent) error {
+	attrs := ev.Attributes
+	if attrs == nil {
+		attrs = map[string]any{}
+	}
+	b, err := json.Marshal(attrs)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO telemetry_events (id, tenant_id, school_id, device_id, mac_address, event_type, recovery, severity, message, reported_by,
+			external_id, attributes, outcome, correlation_id, incident_id, occurred_at, received_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12::jsonb,$13,$14,$15,$16,$17)
+	`, ev.ID, ev.TenantID, ev.SchoolID, ev.DeviceID, ev.MACAddress, ev.EventType, ev.Recovery, ev.Severity, ev.Message, ev.ReportedBy,
+		ev.ExternalID, string(b), ev.Outcome, ev.CorrelationID, ev.IncidentID, ev.OccurredAt, ev.ReceivedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrTelemetryReplay
+	}
+	return err
+}
+
+// IncidentStatusForUpdateTx locks an incident and returns its school and
+// status.
+func IncidentStatusForUpdateTx(ctx context.Context, tx Tx, tenantID, id string) (schoolID string, status models.IncidentStatus, err error) {
+	err = tx.QueryRow(ctx, `SELECT school_id, status FROM incidents WHERE tenant_id=$1 AND id=$2 FOR UPDATE`, tenantID, id).
+		Scan(&schoolID, &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = errors.New("not found")
+	}
+	return schoolID, status, err
+}
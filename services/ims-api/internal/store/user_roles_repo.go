@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UserRolesRepo records the roles users were last seen with.
+type UserRolesRepo struct{ pool *pgxpool.Pool }
+
+// Record replaces a user's recorded roles with roles, seen at now.
+func (r *UserRolesRepo) Record(ctx context.Context, tenantID, userID string, roles []string, now time.Time) error {
+	if roles == nil {
+		roles = []string{}
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM user_roles WHERE tenant_id=$1 AND user_id=$2 AND NOT (role = ANY($3))
+	`, tenantID, userID, roles); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO user_roles (tenant_id, user_id, role, seen_at)
+		SELECT $1, $2, role, $4 FROM unnest($3::text[]) AS role
+		ON CONFLICT (tenant_id, user_id, role) DO UPDATE SET seen_at=EXCLUDED.seen_at
+	`, tenantID, userID, roles, now); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListUserIDsByRole returns the users recorded with role and seen since
+// the cutoff, most recently seen first.
+func (r *UserRolesRepo) ListUserIDsByRole(ctx context.Context, tenantID, role string, since time.Time) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT user_id FROM user_roles
+		WHERE tenant_id=$1 AND role=$2 AND seen_at >= $3
+		ORDER BY seen_at DESC, user_id
+	`, tenantID, role, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
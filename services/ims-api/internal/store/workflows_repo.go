@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WorkflowsRepo stores tenant-defined incident and work order workflows.
+type WorkflowsRepo struct{ pool *pgxpool.Pool }
+
+type workflowDefinition struct {
+	States      []models.WorkflowState      `json:"states"`
+	Transitions []models.WorkflowTransition `json:"transitions"`
+}
+
+// Get returns the tenant's workflow for an entity.
+func (r *WorkflowsRepo) Get(ctx context.Context, tenantID string, entity models.WorkflowEntity) (models.Workflow, error) {
+	var wf models.Workflow
+	var def []byte
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, tenant_id, entity, name, version, definition, updated_by, created_at, updated_at
+		FROM workflows WHERE tenant_id=$1 AND entity=$2
+	`, tenantID, entity).Scan(&wf.ID, &wf.TenantID, &wf.Entity, &wf.Name, &wf.Version, &def, &wf.UpdatedBy, &wf.CreatedAt, &wf.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Workflow{}, errors.New("not found")
+		}
+		return models.Workflow{}, err
+	}
+	var d workflowDefinition
+	if err := json.Unmarshal(def, &d); err != nil {
+		return models.Workflow{}, err
+	}
+	wf.States = d.States
+	wf.Transitions = d.Transitions
+	return wf, nil
+}
+
+// Upsert saves the tenant's workflow, bumping its version, and returns the
+// stored version number.
+func (r *WorkflowsRepo) Upsert(ctx context.Context, wf models.Workflow) (int, error) {
+	def, err := json.Marshal(workflowDefinition{States: wf.States, Transitions: wf.Transitions})
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	err = r.pool.QueryRow(ctx, `
+		INSERT INTO workflows (id, tenant_id, entity, name, version, definition, updated_by, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,1,$5,$6,$7,$8)
+		ON CONFLICT (tenant_id, entity) DO UPDATE SET
+			name=EXCLUDED.name,
+			version=workflows.version+1,
+			definition=EXCLUDED.definition,
+			updated_by=EXCLUDED.updated_by,
+			updated_at=EXCLUDED.updated_at
+		RETURNING version
+	`, wf.ID, wf.TenantID, wf.Entity, wf.Name, def, wf.UpdatedBy, wf.CreatedAt, wf.UpdatedAt).Scan(&version)
+	return version, err
+}
+
+// Delete removes the tenant's workflow so the built-in one applies again.
+func (r *WorkflowsRepo) Delete(ctx context.Context, tenantID string, entity models.WorkflowEntity) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM workflows WHERE tenant_id=$1 AND entity=$2`, tenantID, entity)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
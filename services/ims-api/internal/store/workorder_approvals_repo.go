@@ -13,7 +13,12 @@ import (
 type WorkOrderApprovalsRepo struct{ pool *pgxpool.Pool }
 
 func (r *WorkOrderApprovalsRepo) Request(ctx context.Context, a models.WorkOrderApproval) error {
-	_, err := r.pool.Exec(ctx, `
+	return RequestWorkOrderApprovalTx(ctx, r.pool, a)
+}
+
+// RequestWorkOrderApprovalTx inserts an approval request within a transaction.
+func RequestWorkOrderApprovalTx(ctx context.Context, tx Tx, a models.WorkOrderApproval) error {
+	_, err := tx.Exec(ctx, `
 		INSERT INTO work_order_approvals (
 			id, tenant_id, school_id, work_order_id, approval_type, requested_by_user_id, requested_at, status,
 			decided_by_user_id, decided_at, decision_notes
@@ -23,12 +28,17 @@ func (r *WorkOrderApprovalsRepo) Request(ctx context.Context, a models.WorkOrder
 }
 
 func (r *WorkOrderApprovalsRepo) Decide(ctx context.Context, tenantID, schoolID, approvalID, deciderID, status, notes string) error {
+	return DecideWorkOrderApprovalTx(ctx, r.pool, tenantID, schoolID, approvalID, deciderID, status, notes)
+}
+
+// DecideWorkOrderApprovalTx records an approval decision within a transaction.
+func DecideWorkOrderApprovalTx(ctx context.Context, tx Tx, tenantID, schoolID, approvalID, deciderID, status, notes string) error {
 	now := time.Now().UTC()
 	status = strings.TrimSpace(status)
 	if status != "approved" && status != "rejected" {
 		return errors.New("invalid status")
 	}
-	_, err := r.pool.Exec(ctx, `
+	_, err := tx.Exec(ctx, `
 		UPDATE work_order_approvals
 		SET status=$4, decided_by_user_id=$5, decided_at=$6, decision_notes=$7
 		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
@@ -99,8 +99,13 @@ func (r *WorkOrderDeliverablesRepo) List(ctx context.Context, p DeliverableListP
 }
 
 func (r *WorkOrderDeliverablesRepo) MarkSubmitted(ctx context.Context, tenantID, schoolID, id, userID, evidence, notes string) error {
-	now := time.Now().UTC()
-	_, err := r.pool.Exec(ctx, `
+	return MarkDeliverableSubmittedTx(ctx, r.pool, tenantID, schoolID, id, userID, evidence, notes, time.Now().UTC())
+}
+
+// MarkDeliverableSubmittedTx records submitted evidence for a deliverable
+// within a transaction.
+func MarkDeliverableSubmittedTx(ctx context.Context, tx Tx, tenantID, schoolID, id, userID, evidence, notes string, now time.Time) error {
+	_, err := tx.Exec(ctx, `
 		UPDATE work_order_deliverables
 		SET status='submitted', evidence_attachment_id=$4, submitted_by_user_id=$5, submitted_at=$6, updated_at=$6, description=$7
 		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
@@ -108,6 +108,7 @@ func (r *WorkOrderPartRepo) List(ctx context.Context, p WorkOrderPartListParams)
 type Tx interface {
 	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
 func CreateWorkOrderPartTx(ctx context.Context, tx Tx, p models.WorkOrderPart) error {
@@ -15,7 +15,12 @@ type WorkOrderRepo struct {
 }
 
 func (r *WorkOrderRepo) Create(ctx context.Context, wo models.WorkOrder) error {
-	_, err := r.pool.Exec(ctx, `
+	return CreateWorkOrderTx(ctx, r.pool, wo)
+}
+
+// CreateWorkOrderTx inserts a work order within a transaction.
+func CreateWorkOrderTx(ctx context.Context, tx Tx, wo models.WorkOrder) error {
+	_, err := tx.Exec(ctx, `
 		INSERT INTO work_orders (
 			id, incident_id, tenant_id, school_id, device_id, status, service_shop_id, assigned_staff_id, repair_location,
 			assigned_to, task_type, project_id, phase_id, cost_estimate_cents, notes,
@@ -123,19 +128,29 @@ func (r *WorkOrderRepo) List(ctx context.Context, p WorkOrderListParams) ([]mode
 }
 
 func (r *WorkOrderRepo) UpdateStatus(ctx context.Context, tenantID, schoolID, id string, status models.WorkOrderStatus, now time.Time) (models.WorkOrder, error) {
-	_, err := r.pool.Exec(ctx, `
+	if err := UpdateWorkOrderStatusTx(ctx, r.pool, tenantID, schoolID, id, status, now); err != nil {
+		return models.WorkOrder{}, err
+	}
+	return r.GetByID(ctx, tenantID, schoolID, id)
+}
+
+// UpdateWorkOrderStatusTx sets a work order's status within a transaction.
+func UpdateWorkOrderStatusTx(ctx context.Context, tx Tx, tenantID, schoolID, id string, status models.WorkOrderStatus, now time.Time) error {
+	_, err := tx.Exec(ctx, `
 		UPDATE work_orders
 		SET status=$4, updated_at=$5
 		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
 	`, tenantID, schoolID, id, status, now)
-	if err != nil {
-		return models.WorkOrder{}, err
-	}
-	return r.GetByID(ctx, tenantID, schoolID, id)
+	return err
 }
 
 func (r *WorkOrderRepo) SetApprovalStatus(ctx context.Context, tenantID, schoolID, workOrderID, status string) error {
-	_, err := r.pool.Exec(ctx, `
+	return SetWorkOrderApprovalStatusTx(ctx, r.pool, tenantID, schoolID, workOrderID, status)
+}
+
+// SetWorkOrderApprovalStatusTx is SetApprovalStatus within a transaction.
+func SetWorkOrderApprovalStatusTx(ctx context.Context, tx Tx, tenantID, schoolID, workOrderID, status string) error {
+	_, err := tx.Exec(ctx, `
 		UPDATE work_orders SET approval_status=$4, updated_at=$5
 		WHERE tenant_id=$1 AND school_id=$2 AND id=$3
 	`, tenantID, schoolID, workOrderID, status, time.Now().UTC())
@@ -285,7 +300,15 @@ func (r *WorkOrderRepo) UpdateFields(ctx context.Context, tenantID, schoolID, id
 
 // UpdateStatusWithRework updates status and increments rework count.
 func (r *WorkOrderRepo) UpdateStatusWithRework(ctx context.Context, tenantID, schoolID, id string, status models.WorkOrderStatus, reason string, now time.Time) (models.WorkOrder, error) {
-	_, err := r.pool.Exec(ctx, `
+	if err := UpdateWorkOrderStatusWithReworkTx(ctx, r.pool, tenantID, schoolID, id, status, reason, now); err != nil {
+		return models.WorkOrder{}, err
+	}
+	return r.GetByID(ctx, tenantID, schoolID, id)
+}
+
+// UpdateWorkOrderStatusWithReworkTx is UpdateStatusWithRework within a transaction.
+func UpdateWorkOrderStatusWithReworkTx(ctx context.Context, tx Tx, tenantID, schoolID, id string, status models.WorkOrderStatus, reason string, now time.Time) error {
+	_, err := tx.Exec(ctx, `
 		UPDATE work_orders
 		SET status = $4,
 			rework_count = COALESCE(rework_count, 0) + 1,
@@ -294,10 +317,7 @@ func (r *WorkOrderRepo) UpdateStatusWithRework(ctx context.Context, tenantID, sc
 			updated_at = $5
 		WHERE tenant_id = $1 AND school_id = $2 AND id = $3
 	`, tenantID, schoolID, id, status, now, reason)
-	if err != nil {
-		return models.WorkOrder{}, err
-	}
-	return r.GetByID(ctx, tenantID, schoolID, id)
+	return err
 }
 
 // GetByIDs fetches multiple work orders by their IDs for bulk operations.
@@ -348,6 +368,11 @@ func (r *WorkOrderRepo) GetByIDs(ctx context.Context, tenantID, schoolID string,
 
 // BulkUpdateStatus updates status for multiple work orders in a single transaction.
 func (r *WorkOrderRepo) BulkUpdateStatus(ctx context.Context, tenantID, schoolID string, ids []string, status models.WorkOrderStatus, now time.Time) error {
+	return BulkUpdateWorkOrderStatusTx(ctx, r.pool, tenantID, schoolID, ids, status, now)
+}
+
+// BulkUpdateWorkOrderStatusTx is BulkUpdateStatus within a transaction.
+func BulkUpdateWorkOrderStatusTx(ctx context.Context, tx Tx, tenantID, schoolID string, ids []string, status models.WorkOrderStatus, now time.Time) error {
 	if len(ids) == 0 {
 		return nil
 	}
@@ -366,7 +391,7 @@ func (r *WorkOrderRepo) BulkUpdateStatus(ctx context.Context, tenantID, schoolID
 		WHERE tenant_id = $1 AND school_id = $2 AND id IN (` + strings.Join(placeholders, ", ") + `)
 	`
 
-	_, err := r.pool.Exec(ctx, query, args...)
+	_, err := tx.Exec(ctx, query, args...)
 	return err
 }
 
@@ -70,6 +70,8 @@ type Hub struct {
 type BroadcastMessage struct {
 	TenantID string
 	Message  *Message
+	// UserIDs, when set, limits delivery to these users' clients
+	UserIDs map[string]bool
 }
 
 // NewHub creates a new Hub
@@ -128,6 +130,9 @@ func (h *Hub) Run() {
 			}
 
 			for client := range clients {
+				if msg.UserIDs != nil && !msg.UserIDs[client.userID] {
+					continue
+				}
 				select {
 				case client.send <- data:
 				default:
@@ -153,6 +158,22 @@ func (h *Hub) Broadcast(tenantID string, msg *Message) {
 	}
 }
 
+// BroadcastToUsers sends a message to the given users' clients in a tenant
+func (h *Hub) BroadcastToUsers(tenantID string, userIDs []string, msg *Message) {
+	if msg.Timestamp == "" {
+		msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	users := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		users[id] = true
+	}
+	h.broadcast <- &BroadcastMessage{
+		TenantID: tenantID,
+		Message:  msg,
+		UserIDs:  users,
+	}
+}
+
 // BroadcastNotification sends a notification to all clients of a tenant
 func (h *Hub) BroadcastNotification(tenantID string, payload NotificationPayload) {
 	h.Broadcast(tenantID, &Message{
@@ -0,0 +1,74 @@
+-- +goose Up
+-- Tenant-scoped SLA policies with business-hours calendars and public holidays
+
+-- ============================================
+-- SLA Calendars (business hours per tenant)
+-- ============================================
+CREATE TABLE IF NOT EXISTS sla_calendars (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'Africa/Nairobi',
+    hours JSONB NOT NULL DEFAULT '[]',           -- [{"weekday":1,"start":"08:00","end":"17:00"}, ...]
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sla_calendars_tenant ON sla_calendars(tenant_id);
+
+-- ============================================
+-- SLA Holidays (public holidays per calendar)
+-- ============================================
+CREATE TABLE IF NOT EXISTS sla_holidays (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    calendar_id TEXT NOT NULL REFERENCES sla_calendars(id) ON DELETE CASCADE,
+    holiday_date DATE NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(calendar_id, holiday_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sla_holidays_calendar ON sla_holidays(tenant_id, calendar_id, holiday_date);
+
+-- ============================================
+-- SLA Policies
+-- ============================================
+-- Empty category/county_id/school_level act as wildcards; the most specific
+-- active policy wins, ties broken by priority.
+CREATE TABLE IF NOT EXISTS sla_policies (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    county_id TEXT NOT NULL DEFAULT '',
+    school_level TEXT NOT NULL DEFAULT '',
+    response_minutes INTEGER NOT NULL DEFAULT 0,
+    resolution_minutes INTEGER NOT NULL,
+    calendar_id TEXT REFERENCES sla_calendars(id) ON DELETE SET NULL,  -- NULL = 24x7 wall clock
+    priority INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sla_policies_lookup ON sla_policies(tenant_id, severity, active);
+
+-- Incidents remember which policy produced their due dates so later policy
+-- edits never move an existing deadline.
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS sla_policy_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS sla_response_due_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS sla_response_breached BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_sla_response_open ON incidents(sla_response_due_at)
+    WHERE sla_response_breached = FALSE AND status = 'new';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_incidents_sla_response_open;
+ALTER TABLE incidents DROP COLUMN IF EXISTS sla_response_breached;
+ALTER TABLE incidents DROP COLUMN IF EXISTS sla_response_due_at;
+ALTER TABLE incidents DROP COLUMN IF EXISTS sla_policy_id;
+DROP TABLE IF EXISTS sla_policies;
+DROP TABLE IF EXISTS sla_holidays;
+DROP TABLE IF EXISTS sla_calendars;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- SLA clock pause/resume, breach warnings and per-incident SLA timeline
+
+-- sla_paused_at: set while the incident waits on the school (clock stopped)
+-- sla_remaining_seconds: SLA time left when the clock was paused
+-- sla_budget_seconds: total SLA time granted at creation (for warning percentages)
+-- sla_warned_pct: highest warning threshold already raised
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS sla_paused_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS sla_remaining_seconds BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS sla_budget_seconds BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS sla_warned_pct INTEGER NOT NULL DEFAULT 0;
+
+-- Existing incidents: budget is the wall-clock window they were created with
+UPDATE incidents
+SET sla_budget_seconds = GREATEST(EXTRACT(EPOCH FROM (sla_due_at - created_at))::BIGINT, 0)
+WHERE sla_budget_seconds = 0;
+
+-- Open, running SLA clocks scanned by the jobs scheduler
+CREATE INDEX IF NOT EXISTS idx_incidents_sla_running ON incidents(sla_due_at)
+    WHERE sla_breached = FALSE AND sla_paused_at IS NULL AND status NOT IN ('resolved', 'closed');
+
+-- ============================================
+-- Incident SLA events (timeline)
+-- ============================================
+CREATE TABLE IF NOT EXISTS incident_sla_events (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    incident_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,                    -- paused, resumed, warning, breached, response_breached
+    threshold_pct INTEGER NOT NULL DEFAULT 0,    -- for warnings
+    due_at TIMESTAMPTZ,                          -- resolution due date after the event
+    actor_user_id TEXT NOT NULL DEFAULT '',      -- empty for scheduler events
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_sla_events_incident ON incident_sla_events(tenant_id, incident_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS incident_sla_events;
+DROP INDEX IF EXISTS idx_incidents_sla_running;
+ALTER TABLE incidents DROP COLUMN IF EXISTS sla_warned_pct;
+ALTER TABLE incidents DROP COLUMN IF EXISTS sla_budget_seconds;
+ALTER TABLE incidents DROP COLUMN IF EXISTS sla_remaining_seconds;
+ALTER TABLE incidents DROP COLUMN IF EXISTS sla_paused_at;
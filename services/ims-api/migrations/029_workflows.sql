@@ -0,0 +1,20 @@
+-- +goose Up
+-- Tenant-configurable incident and work order state machines
+
+-- definition holds {"states":[...],"transitions":[...]}; tenants without a row
+-- use the built-in workflow.
+CREATE TABLE IF NOT EXISTS workflows (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    entity TEXT NOT NULL,                        -- incident, work_order
+    name TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    definition JSONB NOT NULL,
+    updated_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(tenant_id, entity)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS workflows;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Transactional outbox for IMS domain events relayed to NATS
+
+-- Rows are written in the same transaction as the state change they describe
+-- and published by the relay worker with at-least-once delivery.
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id TEXT PRIMARY KEY,                         -- also the event id / Nats-Msg-Id
+    tenant_id TEXT NOT NULL,
+    subject TEXT NOT NULL,                       -- ims.incident.created, ...
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',      -- pending, published, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(next_attempt_at, created_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_event_outbox_published ON event_outbox(published_at)
+    WHERE status = 'published';
+
+-- +goose Down
+DROP TABLE IF EXISTS event_outbox;
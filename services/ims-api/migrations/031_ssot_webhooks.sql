@@ -0,0 +1,44 @@
+-- +goose Up
+-- Signed SSOT webhook ingestion: per-tenant secrets and persisted deliveries
+
+-- Active (non-revoked) secrets verify incoming deliveries; keeping two active
+-- lets a sender rotate without downtime.
+CREATE TABLE IF NOT EXISTS ssot_webhook_secrets (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    label TEXT NOT NULL DEFAULT '',
+    secret TEXT NOT NULL,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_ssot_webhook_secrets_active ON ssot_webhook_secrets(tenant_id)
+    WHERE revoked_at IS NULL;
+
+-- Every verified delivery is kept with its payload and per-item results. The
+-- (tenant_id, nonce) key rejects replays; results hold each item's prior
+-- snapshot so a delivery can be rolled back.
+CREATE TABLE IF NOT EXISTS ssot_webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    resource TEXT NOT NULL,                      -- schools, devices, parts
+    nonce TEXT NOT NULL,
+    key_id TEXT NOT NULL,
+    signed_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'received',     -- received, applied, partial, rejected, rolled_back
+    payload JSONB NOT NULL,
+    results JSONB NOT NULL DEFAULT '[]',
+    accepted INTEGER NOT NULL DEFAULT 0,
+    rejected INTEGER NOT NULL DEFAULT 0,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rolled_back_at TIMESTAMPTZ,
+    rolled_back_by TEXT NOT NULL DEFAULT '',
+    UNIQUE (tenant_id, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ssot_webhook_deliveries_tenant ON ssot_webhook_deliveries(tenant_id, received_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS ssot_webhook_deliveries;
+DROP TABLE IF EXISTS ssot_webhook_secrets;
@@ -0,0 +1,131 @@
+-- +goose Up
+-- Double-entry stock ledger. inventory.qty_available (on hand) and
+-- inventory.qty_reserved become a projection maintained by ledger postings.
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    movement_type TEXT NOT NULL,                 -- receipt, reservation, release, issue, transfer_*, adjustment, cycle_count, opening
+    service_shop_id TEXT NOT NULL,
+    counter_shop_id TEXT NOT NULL DEFAULT '',    -- other shop of a transfer
+    part_id TEXT NOT NULL,
+    qty BIGINT NOT NULL,
+    reason_code TEXT NOT NULL DEFAULT '',
+    reference_type TEXT NOT NULL DEFAULT '',     -- receipt, transfer, stock_count, work_order_part
+    reference_id TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    actor_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_shop ON stock_movements(tenant_id, service_shop_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_part ON stock_movements(tenant_id, part_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_ref ON stock_movements(tenant_id, reference_type, reference_id);
+
+-- Entries of one movement sum to zero.
+CREATE TABLE IF NOT EXISTS stock_ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    movement_id TEXT NOT NULL REFERENCES stock_movements(id),
+    service_shop_id TEXT NOT NULL,
+    part_id TEXT NOT NULL,
+    account TEXT NOT NULL,                       -- available, reserved, in_transit, supplier, consumed, adjustment, opening
+    qty BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_ledger_entries_balance ON stock_ledger_entries(tenant_id, service_shop_id, part_id, account);
+CREATE INDEX IF NOT EXISTS idx_stock_ledger_entries_movement ON stock_ledger_entries(movement_id);
+
+CREATE OR REPLACE VIEW stock_balances AS
+SELECT tenant_id, service_shop_id, part_id,
+    COALESCE(SUM(qty) FILTER (WHERE account IN ('available', 'reserved')), 0) AS on_hand,
+    COALESCE(SUM(qty) FILTER (WHERE account = 'reserved'), 0) AS reserved,
+    COALESCE(SUM(qty) FILTER (WHERE account = 'available'), 0) AS available,
+    COALESCE(SUM(qty) FILTER (WHERE account = 'in_transit'), 0) AS in_transit
+FROM stock_ledger_entries
+WHERE account IN ('available', 'reserved', 'in_transit')
+GROUP BY tenant_id, service_shop_id, part_id;
+
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    from_shop_id TEXT NOT NULL,
+    to_shop_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'in_transit',   -- in_transit, received, cancelled
+    notes TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    received_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    received_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_tenant ON stock_transfers(tenant_id, status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS stock_transfer_lines (
+    transfer_id TEXT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    part_id TEXT NOT NULL,
+    qty_shipped BIGINT NOT NULL,
+    qty_received BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (transfer_id, part_id)
+);
+
+CREATE TABLE IF NOT EXISTS stock_counts (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    service_shop_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',         -- open, submitted, approved, rejected
+    notes TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    submitted_by TEXT NOT NULL DEFAULT '',
+    decided_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    submitted_at TIMESTAMPTZ,
+    decided_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_counts_tenant ON stock_counts(tenant_id, status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS stock_count_lines (
+    count_id TEXT NOT NULL REFERENCES stock_counts(id) ON DELETE CASCADE,
+    part_id TEXT NOT NULL,
+    qty_expected BIGINT NOT NULL DEFAULT 0,
+    qty_counted BIGINT,
+    variance BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (count_id, part_id)
+);
+
+-- Carry existing balances into the ledger so it agrees with the projection.
+-- Migrations are re-applied on every run, so stock already in the ledger
+-- (opened here or posted at runtime) is skipped.
+INSERT INTO stock_movements (id, tenant_id, movement_type, service_shop_id, part_id, qty, reason_code, created_at)
+SELECT 'mv_open_' || i.id, i.tenant_id, 'opening', i.service_shop_id, i.part_id, i.qty_available, 'migration', NOW()
+FROM inventory i
+WHERE (i.qty_available <> 0 OR i.qty_reserved <> 0)
+  AND NOT EXISTS (
+    SELECT 1 FROM stock_movements m
+    WHERE m.tenant_id = i.tenant_id AND m.service_shop_id = i.service_shop_id AND m.part_id = i.part_id
+  )
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO stock_ledger_entries (tenant_id, movement_id, service_shop_id, part_id, account, qty, created_at)
+SELECT i.tenant_id, m.id, i.service_shop_id, i.part_id, e.account, e.qty, m.created_at
+FROM inventory i
+JOIN stock_movements m ON m.id = 'mv_open_' || i.id
+CROSS JOIN LATERAL (VALUES
+    ('opening', -i.qty_available),
+    ('available', i.qty_available - i.qty_reserved),
+    ('reserved', i.qty_reserved)
+) AS e(account, qty)
+WHERE e.qty <> 0
+  AND NOT EXISTS (SELECT 1 FROM stock_ledger_entries x WHERE x.movement_id = m.id);
+
+-- +goose Down
+DROP TABLE IF EXISTS stock_count_lines;
+DROP TABLE IF EXISTS stock_counts;
+DROP TABLE IF EXISTS stock_transfer_lines;
+DROP TABLE IF EXISTS stock_transfers;
+DROP VIEW IF EXISTS stock_balances;
+DROP TABLE IF EXISTS stock_ledger_entries;
+DROP TABLE IF EXISTS stock_movements;
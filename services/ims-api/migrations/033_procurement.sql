@@ -0,0 +1,52 @@
+-- +goose Up
+-- Reorder policies and purchase orders. PO receipts are posted to the stock
+-- ledger as receipt movements referencing the PO.
+
+CREATE TABLE IF NOT EXISTS reorder_policies (
+    tenant_id TEXT NOT NULL,
+    service_shop_id TEXT NOT NULL,
+    part_id TEXT NOT NULL,
+    reorder_point BIGINT NOT NULL DEFAULT 0,
+    safety_stock BIGINT NOT NULL DEFAULT 0,
+    min_order_qty BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, service_shop_id, part_id)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    service_shop_id TEXT NOT NULL,
+    vendor_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'draft',        -- draft, approved, sent, partially_received, closed, cancelled
+    currency TEXT NOT NULL DEFAULT 'KES',
+    total_cents BIGINT NOT NULL DEFAULT 0,
+    notes TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    approved_by TEXT NOT NULL DEFAULT '',
+    expected_at TIMESTAMPTZ,
+    approved_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    closed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_tenant ON purchase_orders(tenant_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_shop ON purchase_orders(tenant_id, service_shop_id, status);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    po_id TEXT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    part_id TEXT NOT NULL,
+    vendor_sku_id TEXT NOT NULL DEFAULT '',
+    sku TEXT NOT NULL DEFAULT '',
+    qty_ordered BIGINT NOT NULL,
+    qty_received BIGINT NOT NULL DEFAULT 0,
+    unit_price_cents BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (po_id, part_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS reorder_policies;
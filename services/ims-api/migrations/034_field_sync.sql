@@ -0,0 +1,23 @@
+-- +goose Up
+-- Offline field-tech sync. Every mutation pushed by a device is recorded by
+-- its client-generated idempotency key so a retried batch replays the stored
+-- result instead of applying the change twice.
+
+CREATE TABLE IF NOT EXISTS field_sync_mutations (
+    tenant_id TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    device_id TEXT NOT NULL DEFAULT '',
+    mutation_type TEXT NOT NULL,                 -- work_order.status, bom.consume, deliverable.submit
+    work_order_id TEXT NOT NULL,
+    client_ts TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL,                        -- applied, conflict, rejected
+    result JSONB NOT NULL DEFAULT '{}'::jsonb,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_field_sync_mutations_user ON field_sync_mutations(tenant_id, user_id, received_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS field_sync_mutations;
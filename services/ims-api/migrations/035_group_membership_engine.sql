@@ -0,0 +1,24 @@
+-- +goose Up
+-- Dynamic and location groups are evaluated by the membership engine.
+-- evaluated_at is the watermark the scheduler compares device, assignment
+-- and location changes against; every engine change is kept as history.
+
+ALTER TABLE device_groups
+  ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS group_membership_events (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    group_id TEXT NOT NULL REFERENCES device_groups(id) ON DELETE CASCADE,
+    device_id TEXT NOT NULL,
+    action TEXT NOT NULL,                        -- added, removed
+    reason TEXT NOT NULL DEFAULT '',             -- group_created, device_assigned, device_registered, scheduled, manual_evaluate
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_membership_events_group ON group_membership_events(tenant_id, group_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_group_membership_events_device ON group_membership_events(tenant_id, device_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS group_membership_events;
+ALTER TABLE device_groups DROP COLUMN IF EXISTS evaluated_at;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Group policies follow a typed, versioned schema (models.DevicePolicy).
+-- policy_version counts changes to a group's policy; every change is kept so
+-- the policy a device received can be traced back.
+
+ALTER TABLE device_groups
+  ADD COLUMN IF NOT EXISTS policy_version INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS device_group_policy_versions (
+    tenant_id TEXT NOT NULL,
+    group_id TEXT NOT NULL REFERENCES device_groups(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    policy JSONB,                                -- NULL when the policy was cleared
+    changed_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_group_policy_versions_tenant ON device_group_policy_versions(tenant_id, group_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS device_group_policy_versions;
+ALTER TABLE device_groups DROP COLUMN IF EXISTS policy_version;
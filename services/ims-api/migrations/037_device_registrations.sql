@@ -0,0 +1,35 @@
+-- +goose Up
+-- School-registered devices are written back to ssot-devices, which assigns
+-- the device ID. Registrations are tracked until ssot-devices confirms or
+-- rejects them; unreachable SSOT calls are retried with backoff.
+
+CREATE TABLE IF NOT EXISTS device_registrations (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    school_id TEXT NOT NULL,
+    serial TEXT NOT NULL,
+    asset_tag TEXT NOT NULL DEFAULT '',
+    make TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL,
+    location_id TEXT,
+    notes TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',      -- pending, confirmed, rejected
+    device_id TEXT NOT NULL DEFAULT '',          -- SSOT device ID once confirmed
+    rejection_code TEXT NOT NULL DEFAULT '',
+    rejection_reason TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ,
+    requested_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    confirmed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_registrations_school ON device_registrations(tenant_id, school_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_device_registrations_due ON device_registrations(next_attempt_at) WHERE status = 'pending';
+-- One registration in flight per serial
+CREATE UNIQUE INDEX IF NOT EXISTS ux_device_registrations_pending_serial ON device_registrations(tenant_id, serial) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS device_registrations;
@@ -0,0 +1,65 @@
+-- +goose Up
+-- Telemetry is stored as raw events, de-duplicated per device and type, and
+-- correlated into one open incident per device and type. Recovery events
+-- resolve the incident.
+
+CREATE TABLE IF NOT EXISTS telemetry_rules (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,                    -- '*' matches any type without its own rule
+    severity TEXT NOT NULL DEFAULT 'low',
+    dedupe_window_seconds INT,                   -- NULL uses TELEMETRY_DEDUPE_WINDOW_SECONDS
+    recovery_event_type TEXT NOT NULL DEFAULT '', -- e.g. 'online' recovers 'offline'
+    auto_resolve BOOLEAN NOT NULL DEFAULT TRUE,
+    create_incident BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, event_type)
+);
+
+-- One row per device and type while a problem is open; kept once resolved.
+CREATE TABLE IF NOT EXISTS telemetry_correlations (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    incident_id TEXT NOT NULL DEFAULT '',
+    occurrences INT NOT NULL DEFAULT 1,
+    duplicates INT NOT NULL DEFAULT 0,
+    first_occurred_at TIMESTAMPTZ NOT NULL,
+    last_occurred_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_telemetry_correlations_open
+    ON telemetry_correlations(tenant_id, device_id, event_type) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_telemetry_correlations_incident ON telemetry_correlations(tenant_id, incident_id);
+
+CREATE TABLE IF NOT EXISTS telemetry_events (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    school_id TEXT NOT NULL DEFAULT '',
+    device_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    recovery BOOLEAN NOT NULL DEFAULT FALSE,
+    severity TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL DEFAULT '',
+    reported_by TEXT NOT NULL DEFAULT '',
+    external_id TEXT NOT NULL DEFAULT '',        -- sender's event ID; replays are ignored
+    attributes JSONB NOT NULL DEFAULT '{}',
+    outcome TEXT NOT NULL,                       -- opened, correlated, duplicate, resolved, unmatched, ignored
+    correlation_id TEXT NOT NULL DEFAULT '',
+    incident_id TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMPTZ NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_telemetry_events_device ON telemetry_events(tenant_id, device_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_telemetry_events_incident ON telemetry_events(tenant_id, incident_id) WHERE incident_id <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS ux_telemetry_events_external
+    ON telemetry_events(tenant_id, external_id) WHERE external_id <> '';
+
+-- +goose Down
+DROP TABLE IF EXISTS telemetry_events;
+DROP TABLE IF EXISTS telemetry_correlations;
+DROP TABLE IF EXISTS telemetry_rules;
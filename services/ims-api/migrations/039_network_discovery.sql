@@ -0,0 +1,54 @@
+-- +goose Up
+-- Devices are resolved by MAC address from telemetry and from network scans
+-- (DHCP leases, ARP tables) uploaded per school. Sightings update when a
+-- device was last seen; MACs that match no device are kept as candidate
+-- unregistered devices.
+
+ALTER TABLE devices_snapshot ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_devices_snapshot_last_seen ON devices_snapshot(tenant_id, school_id, last_seen_at);
+
+-- Telemetry sent by MAC only; device_id is empty when the MAC is unknown.
+ALTER TABLE telemetry_events ADD COLUMN IF NOT EXISTS mac_address TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS network_scans (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    school_id TEXT NOT NULL,
+    source TEXT NOT NULL,                        -- dhcp, arp, other
+    scanned_at TIMESTAMPTZ NOT NULL,
+    entries INT NOT NULL DEFAULT 0,
+    invalid INT NOT NULL DEFAULT 0,
+    resolved INT NOT NULL DEFAULT 0,
+    unknown INT NOT NULL DEFAULT 0,
+    lookup_failed INT NOT NULL DEFAULT 0,
+    elsewhere INT NOT NULL DEFAULT 0,            -- resolved to a device registered at another school
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_network_scans_school ON network_scans(tenant_id, school_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS network_unknown_macs (
+    tenant_id TEXT NOT NULL,
+    mac_address TEXT NOT NULL,
+    school_id TEXT NOT NULL DEFAULT '',          -- school of the latest sighting
+    status TEXT NOT NULL DEFAULT 'open',         -- open, ignored, resolved
+    device_id TEXT NOT NULL DEFAULT '',          -- set once the MAC resolves
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    sightings INT NOT NULL DEFAULT 1,
+    last_ip TEXT NOT NULL DEFAULT '',
+    last_hostname TEXT NOT NULL DEFAULT '',
+    last_source TEXT NOT NULL DEFAULT '',        -- dhcp, arp, other, telemetry
+    resolved_at TIMESTAMPTZ,
+    PRIMARY KEY (tenant_id, mac_address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_network_unknown_macs_school ON network_unknown_macs(tenant_id, school_id, status, last_seen_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS network_unknown_macs;
+DROP TABLE IF EXISTS network_scans;
+ALTER TABLE telemetry_events DROP COLUMN IF EXISTS mac_address;
+DROP INDEX IF EXISTS idx_devices_snapshot_last_seen;
+ALTER TABLE devices_snapshot DROP COLUMN IF EXISTS last_seen_at;
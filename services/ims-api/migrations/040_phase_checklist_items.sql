@@ -0,0 +1,53 @@
+-- +goose Up
+-- Checklist templates are copied onto each phase when it is created. Items
+-- are completed per user, may cite a project attachment as evidence, and
+-- required items gate moving the phase to done.
+
+-- Migrations are re-applied on every run, so the table and its backfill are
+-- created together, once. Phases created later get their items at runtime.
+-- +goose StatementBegin
+DO $$
+BEGIN
+  IF to_regclass('phase_checklist_items') IS NULL THEN
+    CREATE TABLE phase_checklist_items (
+      id TEXT PRIMARY KEY,
+      tenant_id TEXT NOT NULL,
+      project_id TEXT NOT NULL,
+      phase_id TEXT NOT NULL,
+      template_id TEXT NOT NULL DEFAULT '',       -- '' for items added to the phase directly
+      title TEXT NOT NULL,
+      description TEXT NOT NULL DEFAULT '',
+      required BOOLEAN NOT NULL DEFAULT TRUE,
+      position INT NOT NULL DEFAULT 0,
+      completed_at TIMESTAMPTZ,
+      completed_by_user_id TEXT NOT NULL DEFAULT '',
+      completed_by_user_name TEXT NOT NULL DEFAULT '',
+      evidence_attachment_id TEXT NOT NULL DEFAULT '', -- project_attachments.id
+      notes TEXT NOT NULL DEFAULT '',
+      created_at TIMESTAMPTZ NOT NULL,
+      updated_at TIMESTAMPTZ NOT NULL
+    );
+
+    -- Phases that are still open get their checklist from the current templates.
+    INSERT INTO phase_checklist_items (
+      id, tenant_id, project_id, phase_id, template_id, title, description, required, position, created_at, updated_at
+    )
+    SELECT 'pci_' || md5(p.id || '|' || t.id), p.tenant_id, p.project_id, p.id, t.id, t.title, t.description, t.required,
+           (ROW_NUMBER() OVER (PARTITION BY p.id ORDER BY t.required DESC, t.created_at, t.id))::int, NOW(), NOW()
+    FROM service_phases p
+    JOIN school_service_projects proj ON proj.tenant_id = p.tenant_id AND proj.id = p.project_id
+    JOIN phase_checklist_templates t
+      ON t.tenant_id = p.tenant_id AND t.phase_type = p.phase_type AND t.project_type = proj.project_type
+    WHERE p.status <> 'done'
+      AND NOT EXISTS (SELECT 1 FROM phase_checklist_items i WHERE i.phase_id = p.id AND i.template_id = t.id);
+  END IF;
+END $$;
+-- +goose StatementEnd
+
+CREATE INDEX IF NOT EXISTS idx_phase_checklist_items_phase
+  ON phase_checklist_items (tenant_id, phase_id, position, created_at);
+CREATE INDEX IF NOT EXISTS idx_phase_checklist_items_project
+  ON phase_checklist_items (tenant_id, project_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS phase_checklist_items;
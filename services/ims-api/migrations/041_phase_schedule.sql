@@ -0,0 +1,61 @@
+-- +goose Up
+-- Planned and actual phase dates, and finish-to-start dependencies between
+-- phases, which may belong to different projects (one procurement phase can
+-- feed several installs).
+
+-- Migrations are re-applied on every run, so the planned dates are added
+-- and filled from start_date/end_date (entered as the intended dates) once.
+-- Later edits, including cleared dates, are left alone.
+-- +goose StatementBegin
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_schema = current_schema() AND table_name = 'service_phases' AND column_name = 'planned_start'
+  ) THEN
+    ALTER TABLE service_phases ADD COLUMN planned_start DATE;
+    ALTER TABLE service_phases ADD COLUMN IF NOT EXISTS planned_end DATE;
+    ALTER TABLE service_phases ADD COLUMN IF NOT EXISTS duration_days INT NOT NULL DEFAULT 0;
+
+    UPDATE service_phases
+    SET planned_start = start_date,
+        planned_end = end_date,
+        duration_days = CASE
+          WHEN start_date IS NOT NULL AND end_date IS NOT NULL AND end_date >= start_date
+            THEN (end_date - start_date) + 1
+          ELSE 0 END;
+  END IF;
+END $$;
+-- +goose StatementEnd
+
+ALTER TABLE service_phases ADD COLUMN IF NOT EXISTS actual_start DATE;
+ALTER TABLE service_phases ADD COLUMN IF NOT EXISTS actual_end DATE;
+-- TRUE when status is blocked because predecessors are incomplete, so the
+-- phase can be released automatically once they finish.
+ALTER TABLE service_phases ADD COLUMN IF NOT EXISTS dependency_blocked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS phase_dependencies (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  predecessor_phase_id TEXT NOT NULL,
+  successor_phase_id TEXT NOT NULL,
+  lag_days INT NOT NULL DEFAULT 0,
+  created_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  UNIQUE (tenant_id, predecessor_phase_id, successor_phase_id),
+  CHECK (predecessor_phase_id <> successor_phase_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_phase_dependencies_successor
+  ON phase_dependencies (tenant_id, successor_phase_id);
+CREATE INDEX IF NOT EXISTS idx_phase_dependencies_predecessor
+  ON phase_dependencies (tenant_id, predecessor_phase_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS phase_dependencies;
+ALTER TABLE service_phases DROP COLUMN IF EXISTS dependency_blocked;
+ALTER TABLE service_phases DROP COLUMN IF EXISTS actual_end;
+ALTER TABLE service_phases DROP COLUMN IF EXISTS actual_start;
+ALTER TABLE service_phases DROP COLUMN IF EXISTS duration_days;
+ALTER TABLE service_phases DROP COLUMN IF EXISTS planned_end;
+ALTER TABLE service_phases DROP COLUMN IF EXISTS planned_start;
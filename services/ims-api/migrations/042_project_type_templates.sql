@@ -0,0 +1,34 @@
+-- +goose Up
+-- Tenant-defined project types. Each edit adds a version; projects record
+-- the version they were created from and keep it. Built-in types from code
+-- apply to tenants that have not defined a type of the same key.
+
+CREATE TABLE IF NOT EXISTS project_type_templates (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  project_type TEXT NOT NULL,
+  version INT NOT NULL,
+  label TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  default_phase TEXT NOT NULL,
+  phases JSONB NOT NULL DEFAULT '[]',      -- [{phaseType, label, ownerRole, durationDays}] in order
+  checklist JSONB NOT NULL DEFAULT '[]',   -- [{phaseType, title, description, required}]
+  boq_items JSONB NOT NULL DEFAULT '[]',   -- [{category, description, partId, qty, unit, estimatedCostCents}]
+  team_roles JSONB NOT NULL DEFAULT '[]',  -- [{role, responsibility, phases}]
+  retired_at TIMESTAMPTZ,                  -- set on every version when the type is retired
+  created_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  UNIQUE (tenant_id, project_type, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_type_templates_latest
+  ON project_type_templates (tenant_id, project_type, version DESC);
+
+-- template_id is '' for projects created from a built-in type.
+ALTER TABLE school_service_projects ADD COLUMN IF NOT EXISTS template_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE school_service_projects ADD COLUMN IF NOT EXISTS template_version INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE school_service_projects DROP COLUMN IF EXISTS template_version;
+ALTER TABLE school_service_projects DROP COLUMN IF EXISTS template_id;
+DROP TABLE IF EXISTS project_type_templates;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Versions of a project's bill of quantities. boq_items holds the lines of
+-- the latest version; each version keeps a copy of its lines and costing
+-- from when it was submitted. A project with no row is on an implicit
+-- draft version 1.
+
+CREATE TABLE IF NOT EXISTS boq_versions (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  project_id TEXT NOT NULL,
+  version INT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'draft',      -- draft|submitted|approved|revised
+  items JSONB NOT NULL DEFAULT '[]',         -- lines as submitted
+  cost JSONB,                                -- costing rollup as submitted
+  notes TEXT NOT NULL DEFAULT '',
+  decision_notes TEXT NOT NULL DEFAULT '',   -- approver's notes, or reason for sending back
+  created_by TEXT NOT NULL DEFAULT '',
+  submitted_by TEXT NOT NULL DEFAULT '',
+  approved_by TEXT NOT NULL DEFAULT '',
+  approver_role TEXT NOT NULL DEFAULT '',
+  work_order_id TEXT NOT NULL DEFAULT '',
+  submitted_at TIMESTAMPTZ,
+  approved_at TIMESTAMPTZ,
+  revised_at TIMESTAMPTZ,
+  converted_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  UNIQUE (tenant_id, project_id, version)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS boq_versions;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- KB articles given to the AI assistant on each turn, and whether its reply
+-- cited them. Lets KB authors see which articles resolve chats.
+
+CREATE TABLE IF NOT EXISTS ai_kb_usage (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  session_id TEXT NOT NULL REFERENCES chat_sessions(id) ON DELETE CASCADE,
+  turn_id TEXT NOT NULL,               -- ai_conversation_logs.id
+  article_id TEXT NOT NULL,
+  rank INT NOT NULL,                   -- 1 = best match
+  score DOUBLE PRECISION NOT NULL DEFAULT 0,
+  cited BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_kb_usage_article
+  ON ai_kb_usage (tenant_id, article_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ai_kb_usage_session
+  ON ai_kb_usage (session_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS ai_kb_usage;
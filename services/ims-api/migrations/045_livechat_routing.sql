@@ -0,0 +1,52 @@
+-- +goose Up
+-- Livechat routing: agent skill tags, per-tenant queue overflow rules and a
+-- log of every routing decision for analysis.
+
+ALTER TABLE agent_availability
+  ADD COLUMN IF NOT EXISTS skills TEXT[] NOT NULL DEFAULT '{}',  -- issue categories the agent handles
+  ADD COLUMN IF NOT EXISTS last_assigned_at TIMESTAMPTZ;
+
+ALTER TABLE chat_sessions
+  ADD COLUMN IF NOT EXISTS queued_at TIMESTAMPTZ;  -- when the session joined the agent queue
+
+CREATE TABLE IF NOT EXISTS chat_routing_settings (
+  tenant_id TEXT PRIMARY KEY,
+  require_skill_match BOOLEAN NOT NULL DEFAULT FALSE,
+  overflow_after_seconds INT NOT NULL DEFAULT 120,  -- wait before skill and capacity limits relax
+  overflow_extra_chats INT NOT NULL DEFAULT 1,      -- chats an agent may take over their max on overflow
+  affinity_days INT NOT NULL DEFAULT 90,            -- window for prior sessions with the school
+  updated_by TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS chat_routing_decisions (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  session_id TEXT NOT NULL REFERENCES chat_sessions(id) ON DELETE CASCADE,
+  school_id TEXT NOT NULL DEFAULT '',
+  issue_category TEXT NOT NULL DEFAULT '',
+  outcome TEXT NOT NULL,               -- assigned | overflow | queued
+  agent_id TEXT NOT NULL DEFAULT '',
+  agent_name TEXT NOT NULL DEFAULT '',
+  score DOUBLE PRECISION NOT NULL DEFAULT 0,
+  reason TEXT NOT NULL DEFAULT '',
+  wait_seconds INT NOT NULL DEFAULT 0,
+  candidates JSONB NOT NULL DEFAULT '[]'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_routing_decisions_tenant
+  ON chat_routing_decisions (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_chat_routing_decisions_session
+  ON chat_routing_decisions (session_id);
+CREATE INDEX IF NOT EXISTS idx_chat_sessions_agent_school
+  ON chat_sessions (tenant_id, assigned_agent_id, school_id, started_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chat_sessions_agent_school;
+DROP TABLE IF EXISTS chat_routing_decisions;
+DROP TABLE IF EXISTS chat_routing_settings;
+ALTER TABLE chat_sessions DROP COLUMN IF EXISTS queued_at;
+ALTER TABLE agent_availability
+  DROP COLUMN IF EXISTS last_assigned_at,
+  DROP COLUMN IF EXISTS skills;
@@ -0,0 +1,51 @@
+-- +goose Up
+-- Livechat agent tools: agent-only notes in chat threads, tenant canned
+-- responses and post-chat satisfaction surveys.
+
+ALTER TABLE messages
+  ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'internal'));  -- internal: agents only
+
+CREATE TABLE IF NOT EXISTS canned_responses (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  title TEXT NOT NULL,
+  shortcut TEXT NOT NULL DEFAULT '',   -- e.g. "reset-password", typed by agents as /reset-password
+  category TEXT NOT NULL DEFAULT '',   -- issue category it suits; '' for any
+  content TEXT NOT NULL,               -- may contain {{school.name}}-style variables
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  use_count INT NOT NULL DEFAULT 0,
+  last_used_at TIMESTAMPTZ,
+  created_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_canned_responses_shortcut
+  ON canned_responses (tenant_id, lower(shortcut)) WHERE shortcut <> '';
+CREATE INDEX IF NOT EXISTS idx_canned_responses_tenant
+  ON canned_responses (tenant_id, category);
+
+CREATE TABLE IF NOT EXISTS chat_surveys (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  session_id TEXT NOT NULL UNIQUE REFERENCES chat_sessions(id) ON DELETE CASCADE,
+  school_id TEXT NOT NULL DEFAULT '',
+  school_contact_id TEXT NOT NULL DEFAULT '',
+  agent_id TEXT NOT NULL DEFAULT '',   -- '' when the assistant handled the chat alone
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed')),
+  csat_score INT CHECK (csat_score BETWEEN 1 AND 5),
+  resolved BOOLEAN,                    -- contact says the issue was resolved in this chat
+  comment TEXT NOT NULL DEFAULT '',
+  sent_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  responded_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_surveys_tenant
+  ON chat_surveys (tenant_id, sent_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS chat_surveys;
+DROP TABLE IF EXISTS canned_responses;
+ALTER TABLE messages DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Roles each user last made a request with. Roles live in the identity provider's
+-- tokens, so IMS records them as users make requests; jobs use this to find
+-- users by role, such as the ops managers SLA escalations go to.
+CREATE TABLE IF NOT EXISTS user_roles (
+  tenant_id TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  role TEXT NOT NULL,
+  seen_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (tenant_id, user_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role ON user_roles(tenant_id, role, seen_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_roles;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Idempotency keys are generated by each client, so two technicians may send the
+-- same key; scope stored mutation results by user as well.
+-- +goose StatementBegin
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.key_column_usage
+    WHERE table_schema = current_schema() AND table_name = 'field_sync_mutations'
+      AND constraint_name = 'field_sync_mutations_pkey' AND column_name = 'user_id'
+  ) THEN
+    ALTER TABLE field_sync_mutations DROP CONSTRAINT IF EXISTS field_sync_mutations_pkey;
+    ALTER TABLE field_sync_mutations ADD PRIMARY KEY (tenant_id, user_id, idempotency_key);
+  END IF;
+END $$;
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE field_sync_mutations DROP CONSTRAINT IF EXISTS field_sync_mutations_pkey;
+ALTER TABLE field_sync_mutations ADD PRIMARY KEY (tenant_id, idempotency_key);
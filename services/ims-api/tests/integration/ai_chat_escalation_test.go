@@ -0,0 +1,110 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/config"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/testutil"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// aiChatScript drives the chat with the stub provider: the first message is
+// triaged, the second is escalated with the details collected so far.
+const aiChatScript = `# AI chat escalation flow
+{"match":"screen","reply":"Sorry to hear that. What is the serial number on the back of the laptop?","decision":{"category":"hardware","severity":"high","collected_info":{"issue_description":"Laptop screen cracked"},"needs_more_info":true}}
+{"match":"SN-","reply":"Thanks, a technician needs to look at this.","decision":{"category":"hardware","severity":"high","escalate":true,"escalate_reason":"cracked screen needs physical repair","collected_info":{"issue_description":"Laptop screen cracked","device_serial":"SN-404"}}}
+`
+
+func TestAIChat_EscalationFilesIncident(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db, fx, cleanup := setupTestWithFixtures(t)
+	defer cleanup()
+	testutil.TruncateTables(t, db.RawPool(), "ai_conversation_logs", "chat_sessions", "messages", "message_threads")
+
+	ctx := context.Background()
+	script := filepath.Join(t.TempDir(), "chat.jsonl")
+	require.NoError(t, os.WriteFile(script, []byte(aiChatScript), 0o644))
+
+	cfg := config.Config{
+		AIEnabled:              true,
+		AIProvider:             "stub",
+		AIStubScript:           script,
+		AIMaxTurns:             10,
+		AIFrustrationThreshold: 0.7,
+		AIAutoIncidents:        true,
+	}
+	h := handlers.NewAIChatHandler(zap.NewNop(), db, nil, cfg)
+
+	now := time.Now().UTC()
+	thread := models.MessageThread{
+		ID: store.NewID("thr"), TenantID: fx.TenantID, SchoolID: fx.SchoolID, Subject: "Live chat",
+		ThreadType: models.ThreadTypeLivechat, Status: models.ThreadStatusOpen,
+		CreatedBy: "contact-1", CreatedByRole: "ssp_school_contact", CreatedByName: "Jane",
+		CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, db.Messaging().CreateThread(ctx, thread))
+	session := models.ChatSession{
+		ID: store.NewID("chs"), TenantID: fx.TenantID, SchoolID: fx.SchoolID, ThreadID: thread.ID,
+		SchoolContactID: "contact-1", SchoolContactName: "Jane", Status: models.ChatStatusAIActive,
+		StartedAt: now, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, db.ChatSessions().CreateSession(ctx, session))
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			c := middleware.WithTenantID(req.Context(), fx.TenantID)
+			c = middleware.WithUserID(c, "contact-1")
+			c = middleware.WithUserName(c, "Jane")
+			next.ServeHTTP(w, req.WithContext(c))
+		})
+	})
+	r.Post("/sessions/{id}/message", h.HandleAIMessage)
+	client := testutil.NewHTTPTestClient(t, r)
+
+	var first models.AIChatMessageResponse
+	client.Post("/sessions/"+session.ID+"/message", models.AIChatMessageRequest{Content: "My laptop screen is cracked"}).
+		AssertStatus(http.StatusOK).GetJSON(&first)
+	assert.False(t, first.ShouldEscalate)
+	assert.Nil(t, first.Ticket)
+	assert.Equal(t, models.ChatStatusAIActive, first.SessionStatus)
+
+	var second models.AIChatMessageResponse
+	client.Post("/sessions/"+session.ID+"/message", models.AIChatMessageRequest{Content: "The serial is SN-404"}).
+		AssertStatus(http.StatusOK).GetJSON(&second)
+	assert.True(t, second.ShouldEscalate)
+	assert.Equal(t, models.ChatStatusWaiting, second.SessionStatus)
+	assert.Equal(t, "Thanks, a technician needs to look at this.", second.Message.Content)
+	require.NotNil(t, second.Ticket)
+
+	inc, err := db.Incidents().GetByID(ctx, fx.TenantID, fx.SchoolID, second.Ticket.IncidentID)
+	require.NoError(t, err)
+	assert.Equal(t, "hardware", inc.Category)
+	assert.Equal(t, models.SeverityHigh, inc.Severity)
+	assert.Equal(t, "Laptop screen cracked", inc.Title)
+	assert.Contains(t, inc.Description, "SN-404")
+	assert.Equal(t, "Jane", inc.ReportedBy)
+
+	linked, err := db.Messaging().GetThreadByID(ctx, fx.TenantID, thread.ID)
+	require.NoError(t, err)
+	require.NotNil(t, linked.IncidentID)
+	assert.Equal(t, inc.ID, *linked.IncidentID)
+}
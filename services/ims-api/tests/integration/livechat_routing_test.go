@@ -0,0 +1,103 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/config"
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/testutil"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLivechatRouting_EscalationGoesToSkilledAgent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db, fx, cleanup := setupTestWithFixtures(t)
+	defer cleanup()
+	testutil.TruncateTables(t, db.RawPool(), "chat_routing_decisions", "chat_routing_settings", "agent_availability",
+		"people_snapshot", "ai_conversation_logs", "chat_sessions", "messages", "message_threads")
+
+	ctx := context.Background()
+	script := filepath.Join(t.TempDir(), "chat.jsonl")
+	require.NoError(t, os.WriteFile(script, []byte(aiChatScript), 0o644))
+
+	// An idle network specialist and a busier hardware specialist
+	require.NoError(t, db.ChatSessions().SetAgentAvailability(ctx, fx.TenantID, "agent-net", true, 3, []string{"network"}))
+	require.NoError(t, db.ChatSessions().SetAgentAvailability(ctx, fx.TenantID, "agent-hw", true, 3, []string{"hardware"}))
+	require.NoError(t, db.ChatSessions().IncrementAgentChatCount(ctx, fx.TenantID, "agent-hw"))
+	require.NoError(t, db.PeopleSnapshot().Upsert(ctx, store.PersonSnapshot{
+		TenantID: fx.TenantID, PersonID: "agent-hw", Status: "active",
+		GivenName: "Wanjiru", FamilyName: "Kamau", FullName: "Wanjiru Kamau", UpdatedAt: time.Now().UTC(),
+	}))
+
+	cfg := config.Config{AIEnabled: true, AIProvider: "stub", AIStubScript: script, AIMaxTurns: 10, AIFrustrationThreshold: 0.7}
+	h := handlers.NewAIChatHandler(zap.NewNop(), db, nil, cfg)
+
+	now := time.Now().UTC()
+	thread := models.MessageThread{
+		ID: store.NewID("thr"), TenantID: fx.TenantID, SchoolID: fx.SchoolID, Subject: "Live chat",
+		ThreadType: models.ThreadTypeLivechat, Status: models.ThreadStatusOpen,
+		CreatedBy: "contact-1", CreatedByRole: "ssp_school_contact", CreatedByName: "Jane",
+		CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, db.Messaging().CreateThread(ctx, thread))
+	session := models.ChatSession{
+		ID: store.NewID("chs"), TenantID: fx.TenantID, SchoolID: fx.SchoolID, ThreadID: thread.ID,
+		SchoolContactID: "contact-1", SchoolContactName: "Jane", Status: models.ChatStatusAIActive,
+		StartedAt: now, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, db.ChatSessions().CreateSession(ctx, session))
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			c := middleware.WithTenantID(req.Context(), fx.TenantID)
+			c = middleware.WithUserID(c, "contact-1")
+			next.ServeHTTP(w, req.WithContext(c))
+		})
+	})
+	r.Post("/sessions/{id}/message", h.HandleAIMessage)
+	client := testutil.NewHTTPTestClient(t, r)
+
+	client.Post("/sessions/"+session.ID+"/message", models.AIChatMessageRequest{Content: "My laptop screen is cracked"}).
+		AssertStatus(http.StatusOK)
+	var resp models.AIChatMessageResponse
+	client.Post("/sessions/"+session.ID+"/message", models.AIChatMessageRequest{Content: "The serial is SN-404"}).
+		AssertStatus(http.StatusOK).GetJSON(&resp)
+	assert.True(t, resp.ShouldEscalate)
+	assert.Equal(t, models.ChatStatusActive, resp.SessionStatus)
+
+	routed, err := db.ChatSessions().GetSessionByID(ctx, fx.TenantID, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, routed.AssignedAgentID)
+	assert.Equal(t, "agent-hw", *routed.AssignedAgentID)
+	assert.Equal(t, "Wanjiru Kamau", *routed.AssignedAgentName)
+
+	decisions, err := db.ChatRouting().ListDecisions(ctx, store.ChatRoutingDecisionListParams{TenantID: fx.TenantID, SessionID: session.ID})
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, models.ChatRouteAssigned, decisions[0].Outcome)
+	assert.Equal(t, "hardware", decisions[0].IssueCategory)
+	assert.Len(t, decisions[0].Candidates, 2)
+
+	agent, err := db.ChatSessions().GetAgentAvailability(ctx, fx.TenantID, "agent-hw")
+	require.NoError(t, err)
+	assert.Equal(t, 2, agent.CurrentChatCount)
+	assert.NotNil(t, agent.LastAssignedAt)
+}
@@ -0,0 +1,113 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/ims/internal/handlers"
+	"github.com/edvirons/ssp/ims/internal/middleware"
+	"github.com/edvirons/ssp/ims/internal/models"
+	"github.com/edvirons/ssp/ims/internal/store"
+	"github.com/edvirons/ssp/ims/internal/testutil"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLivechatSurvey_EndSessionToMetrics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db, fx, cleanup := setupTestWithFixtures(t)
+	defer cleanup()
+	testutil.TruncateTables(t, db.RawPool(), "chat_surveys", "agent_availability", "chat_sessions", "messages", "message_threads")
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	thread := models.MessageThread{
+		ID: store.NewID("thr"), TenantID: fx.TenantID, SchoolID: fx.SchoolID, Subject: "Live chat",
+		ThreadType: models.ThreadTypeLivechat, Status: models.ThreadStatusOpen,
+		CreatedBy: "contact-1", CreatedByRole: "ssp_school_contact", CreatedByName: "Jane",
+		CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, db.Messaging().CreateThread(ctx, thread))
+	agentID := "agent-1"
+	agentName := "Wanjiru Kamau"
+	session := models.ChatSession{
+		ID: store.NewID("chs"), TenantID: fx.TenantID, SchoolID: fx.SchoolID, ThreadID: thread.ID,
+		SchoolContactID: "contact-1", SchoolContactName: "Jane", Status: models.ChatStatusActive,
+		AssignedAgentID: &agentID, AssignedAgentName: &agentName,
+		StartedAt: now, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, db.ChatSessions().CreateSession(ctx, session))
+
+	h := handlers.NewLivechatHandler(zap.NewNop(), db, nil)
+	client := func(userID string, roles ...string) *testutil.HTTPTestClient {
+		r := chi.NewRouter()
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				c := middleware.WithTenantID(req.Context(), fx.TenantID)
+				c = middleware.WithUserID(c, userID)
+				c = middleware.WithRoles(c, roles)
+				next.ServeHTTP(w, req.WithContext(c))
+			})
+		})
+		r.Post("/sessions/{id}/end", h.EndSession)
+		r.Get("/sessions/{id}/survey", h.GetSurvey)
+		r.Post("/sessions/{id}/survey", h.RespondToSurvey)
+		r.Get("/metrics", h.GetChatMetrics)
+		return testutil.NewHTTPTestClient(t, r)
+	}
+	contact := client("contact-1", "ssp_school_contact")
+
+	// The agent ends the chat, so the contact is sent a pending survey
+	client(agentID, "ssp_support_agent").Post("/sessions/"+session.ID+"/end", models.EndSessionRequest{}).
+		AssertStatus(http.StatusOK)
+
+	var survey models.ChatSurvey
+	contact.Get("/sessions/" + session.ID + "/survey").AssertStatus(http.StatusOK).GetJSON(&survey)
+	assert.Equal(t, models.ChatSurveyPending, survey.Status)
+	assert.Equal(t, agentID, survey.AgentID)
+
+	messages, _, err := db.Messaging().ListMessages(ctx, store.MessageListParams{ThreadID: thread.ID, Limit: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, messages)
+	assert.Equal(t, "chat_survey", messages[len(messages)-1].Metadata["kind"])
+
+	// Only the contact may answer, once
+	client("someone-else", "ssp_school_contact").Post("/sessions/"+session.ID+"/survey",
+		models.ChatSurveyResponseRequest{CSATScore: 5}).AssertStatus(http.StatusForbidden)
+	contact.Post("/sessions/"+session.ID+"/survey", models.ChatSurveyResponseRequest{CSATScore: 9}).
+		AssertStatus(http.StatusBadRequest)
+
+	resolved := true
+	comment := "Sorted in minutes"
+	contact.Post("/sessions/"+session.ID+"/survey", models.ChatSurveyResponseRequest{CSATScore: 4, Resolved: &resolved, Comment: &comment}).
+		AssertStatus(http.StatusOK).GetJSON(&survey)
+	assert.Equal(t, models.ChatSurveyCompleted, survey.Status)
+	require.NotNil(t, survey.CSATScore)
+	assert.Equal(t, 4, *survey.CSATScore)
+	contact.Post("/sessions/"+session.ID+"/survey", models.ChatSurveyResponseRequest{CSATScore: 1}).
+		AssertStatus(http.StatusConflict)
+
+	ended, err := db.ChatSessions().GetSessionByID(ctx, fx.TenantID, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, ended.Rating)
+	assert.Equal(t, 4, *ended.Rating)
+
+	var metrics models.ChatMetrics
+	client("admin-1", "ssp_admin").Get("/metrics").AssertStatus(http.StatusOK).GetJSON(&metrics)
+	assert.Equal(t, 1, metrics.SurveysSent)
+	assert.Equal(t, 1, metrics.SurveysCompleted)
+	assert.InDelta(t, 100, metrics.CSATScore, 0.01)
+	assert.InDelta(t, 4, metrics.AverageCSAT, 0.01)
+	assert.Equal(t, 1, metrics.FCRResponses)
+	assert.InDelta(t, 100, metrics.FirstContactResolutionRate, 0.01)
+}
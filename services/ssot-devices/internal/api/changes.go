@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
+	"github.com/edvirons/ssp/shared/pkg/httpx"
+	"go.uber.org/zap"
+)
+
+// DeviceItem is the change feed format for devices (matches IMS DeviceSnapshot).
+type DeviceItem struct {
+	TenantID string `json:"tenantId"`
+	DeviceID string `json:"deviceId"`
+	SchoolID string `json:"schoolId"`
+	ModelID  string `json:"modelId"`
+	Make     string `json:"make"`
+	// Model is the display name, "<make> <model>".
+	Model     string    `json:"model"`
+	Category  string    `json:"category"`
+	Serial    string    `json:"serial"`
+	AssetTag  string    `json:"assetTag"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListDeviceChanges serves GET /v1/changes/devices, the incremental feed of
+// devices changed or deleted since updatedSince (or after cursor).
+func (s *Server) ListDeviceChanges(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	q, err := changefeed.ParseQuery(r)
+	if err != nil {
+		httpx.Error(w, 400, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT d.id, d.tenant_id, d.school_id, d.device_model_id,
+			COALESCE(m.make, ''), TRIM(COALESCE(m.make, '') || ' ' || COALESCE(m.model, '')), COALESCE(m.category, ''),
+			d.serial, d.asset_tag, d.lifecycle, d.updated_at
+		FROM devices d
+		LEFT JOIN device_models m ON m.id = d.device_model_id AND m.tenant_id = d.tenant_id
+		WHERE d.tenant_id = $1 AND (d.updated_at, d.id) > ($2, $3)
+		ORDER BY d.updated_at, d.id
+		LIMIT $4
+	`, tenant, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		s.log.Error("failed to query device changes", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	defer rows.Close()
+
+	items := []DeviceItem{}
+	for rows.Next() {
+		var it DeviceItem
+		if err := rows.Scan(&it.DeviceID, &it.TenantID, &it.SchoolID, &it.ModelID,
+			&it.Make, &it.Model, &it.Category,
+			&it.Serial, &it.AssetTag, &it.Status, &it.UpdatedAt); err != nil {
+			s.log.Error("failed to scan device", zap.Error(err))
+			httpx.Error(w, 500, "scan failed")
+			return
+		}
+		items = append(items, it)
+	}
+
+	deleted, err := changefeed.LoadTombstones(r.Context(), s.db, tenant, "devices", q)
+	if err != nil {
+		s.log.Error("failed to query device tombstones", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+
+	httpx.WriteJSON(w, 200, changefeed.Build(q, items, func(it DeviceItem) (time.Time, string) {
+		return it.UpdatedAt, it.DeviceID
+	}, deleted))
+}
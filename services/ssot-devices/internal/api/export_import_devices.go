@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/edvirons/ssp/ssot_devices/internal/models"
@@ -26,13 +27,13 @@ func exportAll(ctx context.Context, db *pgxpool.Pool, tenant string) (models.Exp
 	}
 	rows.Close()
 
-	rows, err = db.Query(ctx, `SELECT id, tenant_id, serial, asset_tag, device_model_id, school_id, assigned_to, lifecycle, enrolled, created_at, updated_at FROM devices WHERE tenant_id=$1 ORDER BY created_at DESC`, tenant)
+	rows, err = db.Query(ctx, `SELECT `+deviceColumns+` FROM devices WHERE tenant_id=$1 ORDER BY created_at DESC`, tenant)
 	if err != nil {
 		return p, err
 	}
 	for rows.Next() {
-		var x models.Device
-		if err := rows.Scan(&x.ID, &x.TenantID, &x.Serial, &x.AssetTag, &x.DeviceModelID, &x.SchoolID, &x.AssignedTo, &x.Lifecycle, &x.Enrolled, &x.CreatedAt, &x.UpdatedAt); err != nil {
+		x, err := scanDevice(rows)
+		if err != nil {
 			rows.Close()
 			return p, err
 		}
@@ -59,14 +60,21 @@ func exportAll(ctx context.Context, db *pgxpool.Pool, tenant string) (models.Exp
 	return p, nil
 }
 
-func importAll(ctx context.Context, db *pgxpool.Pool, tenant string, body map[string]any) (map[string]any, error) {
+// importAll upserts models, devices and network identities. It returns the
+// counts, and the lifecycle transitions it recorded on behalf of actor.
+func importAll(ctx context.Context, db *pgxpool.Pool, tenant, actor string, body map[string]any) (map[string]any, []models.DeviceLifecycleEvent, error) {
 	modelsList, _ := body["models"].([]any)
 	devices, _ := body["devices"].([]any)
 	networkIds, _ := body["networkIdentities"].([]any)
 	if len(modelsList) == 0 && len(devices) == 0 && len(networkIds) == 0 {
-		return nil, errors.New("no ssot data provided")
+		return nil, nil, errors.New("no ssot data provided")
+	}
+	if actor == "" {
+		actor = "import"
 	}
 	res := map[string]any{"models": 0, "devices": 0, "networkIdentities": 0}
+	var transitions []models.DeviceLifecycleEvent
+	rejected := []map[string]any{}
 
 	err := withTx(ctx, db, func(tx pgx.Tx) error {
 		now := time.Now().UTC()
@@ -120,15 +128,38 @@ func importAll(ctx context.Context, db *pgxpool.Pool, tenant string, body map[st
 			dmid := trim(m["deviceModelId"])
 			school := trim(m["schoolId"])
 			assigned := trim(m["assignedTo"])
-			life := trim(m["lifecycle"])
-			if life == "" {
-				life = "in_stock"
-			}
 			enrolled := false
 			if v, ok := m["enrolled"].(bool); ok {
 				enrolled = v
 			}
-			_, err := tx.Exec(ctx, `
+
+			// Lifecycle changes go through the state machine. An import
+			// without a lifecycle keeps the current one; a refused change,
+			// including one into lost/retired/disposed without a reason,
+			// keeps it too and is reported, while the rest of the row is
+			// still applied.
+			var cur models.Lifecycle
+			err := tx.QueryRow(ctx, `SELECT lifecycle FROM devices WHERE tenant_id=$1 AND id=$2 FOR UPDATE`, tenant, id).Scan(&cur)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			life := models.Lifecycle(trim(m["lifecycle"]))
+			reason := trim(m["lifecycleReason"])
+			switch {
+			case life == "" && cur == "":
+				life = models.LifecycleInStock
+			case life == "":
+				life = cur
+			case !life.Valid() || (cur != "" && life != cur && !cur.CanTransition(life)),
+				life != cur && life.RequiresReason() && reason == "":
+				rejected = append(rejected, map[string]any{"deviceId": id, "from": cur, "to": life})
+				life = cur
+				if life == "" {
+					life = models.LifecycleInStock
+				}
+			}
+
+			_, err = tx.Exec(ctx, `
 			INSERT INTO devices (id, tenant_id, serial, asset_tag, device_model_id, school_id, assigned_to, lifecycle, enrolled, created_at, updated_at)
 			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$10)
 			ON CONFLICT (id) DO UPDATE SET serial=EXCLUDED.serial, asset_tag=EXCLUDED.asset_tag, device_model_id=EXCLUDED.device_model_id,
@@ -137,6 +168,36 @@ func importAll(ctx context.Context, db *pgxpool.Pool, tenant string, body map[st
 			if err != nil {
 				return err
 			}
+			if life != cur {
+				if _, err := tx.Exec(ctx, `UPDATE devices SET lifecycle_changed_at=$3 WHERE tenant_id=$1 AND id=$2`, tenant, id, now); err != nil {
+					return err
+				}
+				ev := newLifecycleEvent(tenant, id, cur, life, actor, reason, models.LifecycleSourceImport, now)
+				if err := insertLifecycleEvent(ctx, tx, ev); err != nil {
+					return err
+				}
+				transitions = append(transitions, ev)
+			}
+
+			if pm, ok := m["purchase"].(map[string]any); ok {
+				p := models.Purchase{
+					PurchaseDate:      trim(pm["purchaseDate"]),
+					Currency:          trim(pm["currency"]),
+					Supplier:          trim(pm["supplier"]),
+					PurchaseOrderRef:  trim(pm["purchaseOrderRef"]),
+					WarrantyProvider:  trim(pm["warrantyProvider"]),
+					WarrantyExpiresAt: trim(pm["warrantyExpiresAt"]),
+				}
+				if v, ok := pm["costCents"].(float64); ok {
+					p.CostCents = int64(v)
+				}
+				if p, err = normalizePurchase(p); err != nil {
+					return fmt.Errorf("device %s: %w", id, err)
+				}
+				if _, err := updatePurchase(ctx, tx, tenant, id, p, now); err != nil {
+					return err
+				}
+			}
 			if c, ok := res["devices"].(int); ok {
 				res["devices"] = c + 1
 			}
@@ -181,5 +242,9 @@ func importAll(ctx context.Context, db *pgxpool.Pool, tenant string, body map[st
 		}
 		return nil
 	})
-	return res, err
+	if err != nil {
+		return res, nil, err
+	}
+	res["lifecycleRejected"] = rejected
+	return res, transitions, nil
 }
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/httpx"
+	"github.com/edvirons/ssp/ssot_devices/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// deviceColumns matches scanDevice. Dates are read as YYYY-MM-DD text.
+const deviceColumns = `id, tenant_id, serial, asset_tag, device_model_id, school_id, assigned_to, lifecycle, enrolled,
+	COALESCE(to_char(purchase_date, 'YYYY-MM-DD'), ''), purchase_cost_cents, currency, supplier, purchase_order_ref,
+	warranty_provider, COALESCE(to_char(warranty_expires_at, 'YYYY-MM-DD'), ''), created_at, updated_at`
+
+func scanDevice(row pgx.Row) (models.Device, error) {
+	var x models.Device
+	err := row.Scan(&x.ID, &x.TenantID, &x.Serial, &x.AssetTag, &x.DeviceModelID, &x.SchoolID, &x.AssignedTo, &x.Lifecycle, &x.Enrolled,
+		&x.Purchase.PurchaseDate, &x.Purchase.CostCents, &x.Purchase.Currency, &x.Purchase.Supplier, &x.Purchase.PurchaseOrderRef,
+		&x.Purchase.WarrantyProvider, &x.Purchase.WarrantyExpiresAt, &x.CreatedAt, &x.UpdatedAt)
+	return x, err
+}
+
+// insertLifecycleEvent records a transition; the device row must already
+// carry the new state.
+func insertLifecycleEvent(ctx context.Context, tx pgx.Tx, ev models.DeviceLifecycleEvent) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO device_lifecycle_events (id, tenant_id, device_id, from_state, to_state, actor, reason, source, occurred_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+	`, ev.ID, ev.TenantID, ev.DeviceID, ev.From, ev.To, ev.Actor, ev.Reason, ev.Source, ev.OccurredAt)
+	return err
+}
+
+func newLifecycleEvent(tenant, deviceID string, from, to models.Lifecycle, actor, reason, source string, at time.Time) models.DeviceLifecycleEvent {
+	return models.DeviceLifecycleEvent{
+		ID: newID("dlev"), TenantID: tenant, DeviceID: deviceID, From: from, To: to,
+		Actor: actor, Reason: reason, Source: source, OccurredAt: at,
+	}
+}
+
+// publishDevicesChanged announces device changes. transitions lists every
+// lifecycle change so consumers can react without diffing snapshots.
+func (s *Server) publishDevicesChanged(tenant string, transitions []models.DeviceLifecycleEvent) {
+	if transitions == nil {
+		transitions = []models.DeviceLifecycleEvent{}
+	}
+	_ = s.pub.PublishJSON("ssot.devices.changed", map[string]any{
+		"tenantId": tenant, "at": time.Now().UTC(), "transitions": transitions,
+	})
+}
+
+// actorFrom returns the acting user: the explicit value, else the
+// X-User-Id header set by the caller.
+func actorFrom(r *http.Request, explicit string) string {
+	if a := strings.TrimSpace(explicit); a != "" {
+		return a
+	}
+	return strings.TrimSpace(r.Header.Get("X-User-Id"))
+}
+
+func (s *Server) GetDevice(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	d, err := scanDevice(s.db.QueryRow(r.Context(), `SELECT `+deviceColumns+` FROM devices WHERE tenant_id=$1 AND id=$2`,
+		tenant, chi.URLParam(r, "deviceId")))
+	if errors.Is(err, pgx.ErrNoRows) {
+		httpx.Error(w, 404, "not found")
+		return
+	}
+	if err != nil {
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	httpx.WriteJSON(w, 200, d)
+}
+
+type transitionReq struct {
+	To     models.Lifecycle `json:"to"`
+	Reason string           `json:"reason"`
+	Actor  string           `json:"actor"`
+}
+
+// transitionError is a refused lifecycle change.
+type transitionError struct {
+	from, to models.Lifecycle
+}
+
+func (e *transitionError) Error() string {
+	return "cannot move device from " + string(e.from) + " to " + string(e.to)
+}
+
+// TransitionLifecycle serves POST /v1/devices/{deviceId}/lifecycle. It
+// moves a device to another lifecycle state when the state machine allows
+// it and records who did it and why. Moving to the current state is a
+// no-op and returns no event.
+func (s *Server) TransitionLifecycle(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	deviceID := chi.URLParam(r, "deviceId")
+	var req transitionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, 400, "invalid json")
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	actor := actorFrom(r, req.Actor)
+	if !req.To.Valid() {
+		httpx.Error(w, 400, "unknown lifecycle state")
+		return
+	}
+	if actor == "" {
+		httpx.Error(w, 400, "actor or X-User-Id required")
+		return
+	}
+	if req.To.RequiresReason() && req.Reason == "" {
+		httpx.Error(w, 400, "reason required for "+string(req.To))
+		return
+	}
+
+	var event *models.DeviceLifecycleEvent
+	err := withTx(r.Context(), s.db, func(tx pgx.Tx) error {
+		var from models.Lifecycle
+		if err := tx.QueryRow(r.Context(), `SELECT lifecycle FROM devices WHERE tenant_id=$1 AND id=$2 FOR UPDATE`,
+			tenant, deviceID).Scan(&from); err != nil {
+			return err
+		}
+		if from == req.To {
+			return nil
+		}
+		if !from.CanTransition(req.To) {
+			return &transitionError{from, req.To}
+		}
+		now := time.Now().UTC()
+		if _, err := tx.Exec(r.Context(), `
+			UPDATE devices SET lifecycle=$3, lifecycle_changed_at=$4, updated_at=$4 WHERE tenant_id=$1 AND id=$2
+		`, tenant, deviceID, req.To, now); err != nil {
+			return err
+		}
+		ev := newLifecycleEvent(tenant, deviceID, from, req.To, actor, req.Reason, models.LifecycleSourceAPI, now)
+		event = &ev
+		return insertLifecycleEvent(r.Context(), tx, ev)
+	})
+	var te *transitionError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		httpx.Error(w, 404, "not found")
+		return
+	case errors.As(err, &te):
+		httpx.WriteJSON(w, 409, map[string]any{
+			"error": te.Error(), "code": "invalid_transition",
+			"from": te.from, "to": te.to, "allowed": te.from.AllowedTransitions(),
+		})
+		return
+	case err != nil:
+		s.log.Error("lifecycle transition failed", zap.Error(err))
+		httpx.Error(w, 500, "transition failed")
+		return
+	}
+
+	d, err := scanDevice(s.db.QueryRow(r.Context(), `SELECT `+deviceColumns+` FROM devices WHERE tenant_id=$1 AND id=$2`, tenant, deviceID))
+	if err != nil {
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	if event != nil {
+		s.publishDevicesChanged(tenant, []models.DeviceLifecycleEvent{*event})
+	}
+	httpx.WriteJSON(w, 200, map[string]any{"device": d, "event": event})
+}
+
+// ListLifecycleEvents serves GET /v1/devices/{deviceId}/lifecycle-events,
+// newest first.
+func (s *Server) ListLifecycleEvents(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id, tenant_id, device_id, from_state, to_state, actor, reason, source, occurred_at
+		FROM device_lifecycle_events
+		WHERE tenant_id=$1 AND device_id=$2
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $3
+	`, tenant, chi.URLParam(r, "deviceId"), limit)
+	if err != nil {
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	defer rows.Close()
+
+	items := []models.DeviceLifecycleEvent{}
+	for rows.Next() {
+		var x models.DeviceLifecycleEvent
+		if err := rows.Scan(&x.ID, &x.TenantID, &x.DeviceID, &x.From, &x.To, &x.Actor, &x.Reason, &x.Source, &x.OccurredAt); err != nil {
+			httpx.Error(w, 500, "scan failed")
+			return
+		}
+		items = append(items, x)
+	}
+	httpx.WriteJSON(w, 200, map[string]any{"items": items})
+}
+
+// normalizePurchase validates dates and fills the default currency.
+func normalizePurchase(p models.Purchase) (models.Purchase, error) {
+	p.PurchaseDate = strings.TrimSpace(p.PurchaseDate)
+	p.WarrantyExpiresAt = strings.TrimSpace(p.WarrantyExpiresAt)
+	for _, d := range []string{p.PurchaseDate, p.WarrantyExpiresAt} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return p, errors.New("dates must be YYYY-MM-DD")
+		}
+	}
+	if p.CostCents < 0 {
+		return p, errors.New("costCents must not be negative")
+	}
+	p.Currency = strings.ToUpper(strings.TrimSpace(p.Currency))
+	if p.Currency == "" {
+		p.Currency = "KES"
+	}
+	p.Supplier = strings.TrimSpace(p.Supplier)
+	p.PurchaseOrderRef = strings.TrimSpace(p.PurchaseOrderRef)
+	p.WarrantyProvider = strings.TrimSpace(p.WarrantyProvider)
+	return p, nil
+}
+
+// execer is satisfied by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// updatePurchase replaces a device's purchase and warranty record.
+func updatePurchase(ctx context.Context, q execer, tenant, deviceID string, p models.Purchase, now time.Time) (int64, error) {
+	tag, err := q.Exec(ctx, `
+		UPDATE devices SET purchase_date=NULLIF($3,'')::date, purchase_cost_cents=$4, currency=$5, supplier=$6,
+			purchase_order_ref=$7, warranty_provider=$8, warranty_expires_at=NULLIF($9,'')::date, updated_at=$10
+		WHERE tenant_id=$1 AND id=$2
+	`, tenant, deviceID, p.PurchaseDate, p.CostCents, p.Currency, p.Supplier, p.PurchaseOrderRef,
+		p.WarrantyProvider, p.WarrantyExpiresAt, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// UpdatePurchase serves PUT /v1/devices/{deviceId}/purchase.
+func (s *Server) UpdatePurchase(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	deviceID := chi.URLParam(r, "deviceId")
+	var p models.Purchase
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		httpx.Error(w, 400, "invalid json")
+		return
+	}
+	p, err := normalizePurchase(p)
+	if err != nil {
+		httpx.Error(w, 400, err.Error())
+		return
+	}
+	n, err := updatePurchase(r.Context(), s.db, tenant, deviceID, p, time.Now().UTC())
+	if err != nil {
+		s.log.Error("update purchase failed", zap.Error(err))
+		httpx.Error(w, 500, "update failed")
+		return
+	}
+	if n == 0 {
+		httpx.Error(w, 404, "not found")
+		return
+	}
+	d, err := scanDevice(s.db.QueryRow(r.Context(), `SELECT `+deviceColumns+` FROM devices WHERE tenant_id=$1 AND id=$2`, tenant, deviceID))
+	if err != nil {
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	s.publishDevicesChanged(tenant, nil)
+	httpx.WriteJSON(w, 200, d)
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/httpx"
+	"github.com/edvirons/ssp/ssot_devices/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// registrationReq is a device registered by a school through IMS.
+// RegistrationID is the IMS registration ID; repeating a request with the
+// same ID returns the device created the first time.
+type registrationReq struct {
+	RegistrationID string `json:"registrationId"`
+	SchoolID       string `json:"schoolId"`
+	Serial         string `json:"serial"`
+	AssetTag       string `json:"assetTag"`
+	DeviceModelID  string `json:"deviceModelId"`
+	Make           string `json:"make"`
+	Model          string `json:"model"`
+	RequestedBy    string `json:"requestedBy"`
+}
+
+// registrationError is a rejection the caller should show to the user
+// rather than retry.
+type registrationError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *registrationError) Error() string { return e.msg }
+
+// RegisterDevice serves POST /v1/devices/registrations. It resolves the
+// device model, enforces serial uniqueness and creates the device. It
+// returns the device in change feed format: 201 when created, or 200 when
+// the registration was already processed. Rejections return 409
+// (duplicate_serial) or 422 (unknown_model, ambiguous_model) with a code.
+func (s *Server) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	var req registrationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, 400, "invalid json")
+		return
+	}
+	req.RegistrationID = strings.TrimSpace(req.RegistrationID)
+	req.SchoolID = strings.TrimSpace(req.SchoolID)
+	req.Serial = strings.TrimSpace(req.Serial)
+	req.AssetTag = strings.TrimSpace(req.AssetTag)
+	if req.RegistrationID == "" || req.SchoolID == "" || req.Serial == "" {
+		httpx.Error(w, 400, "registrationId, schoolId and serial required")
+		return
+	}
+
+	if item, err := s.deviceByRegistration(r.Context(), tenant, req.RegistrationID); err == nil {
+		httpx.WriteJSON(w, 200, item)
+		return
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		s.log.Error("registration lookup failed", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+
+	var event models.DeviceLifecycleEvent
+	err := withTx(r.Context(), s.db, func(tx pgx.Tx) error {
+		modelID, err := resolveDeviceModel(r.Context(), tx, tenant, req)
+		if err != nil {
+			return err
+		}
+		var existing string
+		err = tx.QueryRow(r.Context(), `SELECT id FROM devices WHERE tenant_id=$1 AND serial=$2`, tenant, req.Serial).Scan(&existing)
+		if err == nil {
+			return &registrationError{409, "duplicate_serial", "serial already registered to device " + existing}
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		// A device a school registers is already in use there.
+		deviceID := newID("dev")
+		now := time.Now().UTC()
+		_, err = tx.Exec(r.Context(), `
+			INSERT INTO devices (id, tenant_id, serial, asset_tag, device_model_id, school_id, lifecycle, lifecycle_changed_at, registration_ref, created_at, updated_at)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$8,$8)
+		`, deviceID, tenant, req.Serial, req.AssetTag, modelID, req.SchoolID, models.LifecycleDeployed, now, req.RegistrationID)
+		if err != nil {
+			return err
+		}
+		event = newLifecycleEvent(tenant, deviceID, "", models.LifecycleDeployed, actorFrom(r, req.RequestedBy),
+			"registered by school", models.LifecycleSourceRegistration, now)
+		return insertLifecycleEvent(r.Context(), tx, event)
+	})
+	if err != nil {
+		var re *registrationError
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &re):
+			httpx.WriteJSON(w, re.status, map[string]any{"error": re.msg, "code": re.code})
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			// Lost a race with a concurrent insert. If it was this
+			// registration, it succeeded; otherwise the serial is taken.
+			if item, lerr := s.deviceByRegistration(r.Context(), tenant, req.RegistrationID); lerr == nil {
+				httpx.WriteJSON(w, 200, item)
+			} else if errors.Is(lerr, pgx.ErrNoRows) {
+				httpx.WriteJSON(w, 409, map[string]any{"error": "serial already registered", "code": "duplicate_serial"})
+			} else {
+				s.log.Error("registration lookup failed", zap.Error(lerr))
+				httpx.Error(w, 500, "query failed")
+			}
+		default:
+			s.log.Error("device registration failed", zap.Error(err))
+			httpx.Error(w, 500, "registration failed")
+		}
+		return
+	}
+
+	item, err := s.deviceByRegistration(r.Context(), tenant, req.RegistrationID)
+	if err != nil {
+		s.log.Error("registered device lookup failed", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	s.publishDevicesChanged(tenant, []models.DeviceLifecycleEvent{event})
+	httpx.WriteJSON(w, 201, item)
+}
+
+// resolveDeviceModel finds the catalog model for a registration: by ID when
+// given, otherwise by make and model, or by the "<make> <model>" display
+// name when only a model is given. Matching ignores case.
+func resolveDeviceModel(ctx context.Context, tx pgx.Tx, tenant string, req registrationReq) (string, error) {
+	var rows pgx.Rows
+	var err error
+	make, model := strings.TrimSpace(req.Make), strings.TrimSpace(req.Model)
+	switch {
+	case strings.TrimSpace(req.DeviceModelID) != "":
+		rows, err = tx.Query(ctx, `SELECT id FROM device_models WHERE tenant_id=$1 AND id=$2`, tenant, strings.TrimSpace(req.DeviceModelID))
+	case make != "" && model != "":
+		rows, err = tx.Query(ctx, `
+			SELECT id FROM device_models WHERE tenant_id=$1 AND lower(make)=lower($2) AND lower(model)=lower($3) ORDER BY id LIMIT 2
+		`, tenant, make, model)
+	case model != "":
+		rows, err = tx.Query(ctx, `
+			SELECT id FROM device_models
+			WHERE tenant_id=$1 AND (lower(model)=lower($2) OR lower(make || ' ' || model)=lower($2))
+			ORDER BY id LIMIT 2
+		`, tenant, model)
+	default:
+		return "", &registrationError{422, "unknown_model", "deviceModelId or model required"}
+	}
+	if err != nil {
+		return "", err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", err
+	}
+	switch len(ids) {
+	case 0:
+		return "", &registrationError{422, "unknown_model", "device model not in catalog"}
+	case 1:
+		return ids[0], nil
+	}
+	return "", &registrationError{422, "ambiguous_model", "several catalog models match; specify make or deviceModelId"}
+}
+
+func (s *Server) deviceByRegistration(ctx context.Context, tenant, registrationID string) (DeviceItem, error) {
+	var it DeviceItem
+	err := s.db.QueryRow(ctx, `
+		SELECT d.id, d.tenant_id, d.school_id, d.device_model_id,
+			COALESCE(m.make, ''), TRIM(COALESCE(m.make, '') || ' ' || COALESCE(m.model, '')), COALESCE(m.category, ''),
+			d.serial, d.asset_tag, d.lifecycle, d.updated_at
+		FROM devices d
+		LEFT JOIN device_models m ON m.id = d.device_model_id AND m.tenant_id = d.tenant_id
+		WHERE d.tenant_id=$1 AND d.registration_ref=$2
+	`, tenant, registrationID).Scan(&it.DeviceID, &it.TenantID, &it.SchoolID, &it.ModelID,
+		&it.Make, &it.Model, &it.Category, &it.Serial, &it.AssetTag, &it.Status, &it.UpdatedAt)
+	return it, err
+}
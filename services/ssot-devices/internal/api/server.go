@@ -51,6 +51,16 @@ func NewServer(log *zap.Logger) http.Handler {
 	r.Route("/v1", func(r chi.Router) {
 		r.Get("/export", s.Export)
 		r.Post("/import", s.Import)
+		r.Get("/changes/devices", s.ListDeviceChanges)
+
+		// School registrations forwarded by IMS
+		r.Post("/devices/registrations", s.RegisterDevice)
+
+		// Lifecycle and purchase records
+		r.Get("/devices/{deviceId}", s.GetDevice)
+		r.Post("/devices/{deviceId}/lifecycle", s.TransitionLifecycle)
+		r.Get("/devices/{deviceId}/lifecycle-events", s.ListLifecycleEvents)
+		r.Put("/devices/{deviceId}/purchase", s.UpdatePurchase)
 
 		// Network identity (MAC address) endpoints
 		r.Get("/devices/{deviceId}/network-identities", s.ListNetworkIdentities)
@@ -93,7 +103,7 @@ func (s *Server) Import(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := importAll(r.Context(), s.db, tenant, body)
+	res, transitions, err := importAll(r.Context(), s.db, tenant, actorFrom(r, ""), body)
 	if err != nil {
 		httpx.Error(w, 400, err.Error())
 		return
@@ -106,7 +116,7 @@ func (s *Server) Import(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// Also publish a lightweight "changed" signal
-	_ = s.pub.PublishJSON("ssot.devices.changed", map[string]any{"tenantId": tenant, "at": time.Now().UTC()})
+	s.publishDevicesChanged(tenant, transitions)
 
 	httpx.WriteJSON(w, 200, map[string]any{"ok": true, "imported": res})
 }
@@ -280,11 +290,7 @@ func (s *Server) LookupByMAC(w http.ResponseWriter, r *http.Request) {
 	netId.InterfaceType = models.InterfaceType(ifType)
 
 	// Also fetch the device
-	var device models.Device
-	err = s.db.QueryRow(r.Context(), `
-		SELECT id, tenant_id, serial, asset_tag, device_model_id, school_id, assigned_to, lifecycle, enrolled, created_at, updated_at
-		FROM devices WHERE tenant_id=$1 AND id=$2
-	`, tenant, netId.DeviceID).Scan(&device.ID, &device.TenantID, &device.Serial, &device.AssetTag, &device.DeviceModelID, &device.SchoolID, &device.AssignedTo, &device.Lifecycle, &device.Enrolled, &device.CreatedAt, &device.UpdatedAt)
+	device, err := scanDevice(s.db.QueryRow(r.Context(), `SELECT `+deviceColumns+` FROM devices WHERE tenant_id=$1 AND id=$2`, tenant, netId.DeviceID))
 	if err != nil && err != pgx.ErrNoRows {
 		httpx.Error(w, 500, "device query failed")
 		return
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Lifecycle is a device's lifecycle state.
+type Lifecycle string
+
+const (
+	LifecycleProcured Lifecycle = "procured"  // ordered or received, not yet in stock
+	LifecycleInStock  Lifecycle = "in_stock"  // in a store, available to deploy
+	LifecycleDeployed Lifecycle = "deployed"  // in use at a school
+	LifecycleInRepair Lifecycle = "in_repair" // with a technician or vendor
+	LifecycleLoaned   Lifecycle = "loaned"    // temporarily lent out
+	LifecycleLost     Lifecycle = "lost"      // missing or stolen
+	LifecycleRetired  Lifecycle = "retired"   // out of service, awaiting disposal
+	LifecycleDisposed Lifecycle = "disposed"  // recycled, sold or destroyed; final
+)
+
+// lifecycleTransitions lists the states each state may move to.
+var lifecycleTransitions = map[Lifecycle][]Lifecycle{
+	LifecycleProcured: {LifecycleInStock, LifecycleDeployed, LifecycleLost, LifecycleRetired},
+	LifecycleInStock:  {LifecycleDeployed, LifecycleInRepair, LifecycleLoaned, LifecycleLost, LifecycleRetired},
+	LifecycleDeployed: {LifecycleInStock, LifecycleInRepair, LifecycleLoaned, LifecycleLost, LifecycleRetired},
+	LifecycleInRepair: {LifecycleInStock, LifecycleDeployed, LifecycleLost, LifecycleRetired},
+	LifecycleLoaned:   {LifecycleInStock, LifecycleDeployed, LifecycleInRepair, LifecycleLost},
+	LifecycleLost:     {LifecycleInStock, LifecycleDeployed, LifecycleRetired},
+	LifecycleRetired:  {LifecycleDisposed},
+	LifecycleDisposed: {},
+}
+
+// Valid reports whether l is a known lifecycle state.
+func (l Lifecycle) Valid() bool {
+	_, ok := lifecycleTransitions[l]
+	return ok
+}
+
+// AllowedTransitions returns the states l may move to, sorted.
+func (l Lifecycle) AllowedTransitions() []Lifecycle {
+	out := append([]Lifecycle{}, lifecycleTransitions[l]...)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// CanTransition reports whether a device may move from l to to.
+func (l Lifecycle) CanTransition(to Lifecycle) bool {
+	for _, s := range lifecycleTransitions[l] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresReason reports whether moving into l must be explained.
+func (l Lifecycle) RequiresReason() bool {
+	return l == LifecycleLost || l == LifecycleRetired || l == LifecycleDisposed
+}
+
+// Lifecycle event sources.
+const (
+	LifecycleSourceAPI          = "api"
+	LifecycleSourceImport       = "import"
+	LifecycleSourceRegistration = "registration"
+)
+
+// DeviceLifecycleEvent is one recorded lifecycle transition. From is empty
+// for the state a device was created in.
+type DeviceLifecycleEvent struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenantId"`
+	DeviceID   string    `json:"deviceId"`
+	From       Lifecycle `json:"from"`
+	To         Lifecycle `json:"to"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason"`
+	Source     string    `json:"source"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "testing"
+
+var allLifecycles = []Lifecycle{
+	LifecycleProcured, LifecycleInStock, LifecycleDeployed, LifecycleInRepair,
+	LifecycleLoaned, LifecycleLost, LifecycleRetired, LifecycleDisposed,
+}
+
+func TestCanTransition(t *testing.T) {
+	// Every legal move; any pair not listed must be refused
+	legal := map[Lifecycle][]Lifecycle{
+		LifecycleProcured: {LifecycleInStock, LifecycleDeployed, LifecycleLost, LifecycleRetired},
+		LifecycleInStock:  {LifecycleDeployed, LifecycleInRepair, LifecycleLoaned, LifecycleLost, LifecycleRetired},
+		LifecycleDeployed: {LifecycleInStock, LifecycleInRepair, LifecycleLoaned, LifecycleLost, LifecycleRetired},
+		LifecycleInRepair: {LifecycleInStock, LifecycleDeployed, LifecycleLost, LifecycleRetired},
+		LifecycleLoaned:   {LifecycleInStock, LifecycleDeployed, LifecycleInRepair, LifecycleLost},
+		LifecycleLost:     {LifecycleInStock, LifecycleDeployed, LifecycleRetired},
+		LifecycleRetired:  {LifecycleDisposed},
+		LifecycleDisposed: {},
+	}
+
+	for _, from := range allLifecycles {
+		allowed := map[Lifecycle]bool{}
+		for _, to := range legal[from] {
+			allowed[to] = true
+		}
+		for _, to := range allLifecycles {
+			if got := from.CanTransition(to); got != allowed[to] {
+				t.Errorf("%s.CanTransition(%s) = %v, want %v", from, to, got, allowed[to])
+			}
+		}
+		if got := from.CanTransition("scrapped"); got {
+			t.Errorf("%s.CanTransition(scrapped) = true, want false", from)
+		}
+	}
+
+	if Lifecycle("scrapped").CanTransition(LifecycleInStock) {
+		t.Error("unknown state may transition")
+	}
+}
+
+func TestRequiresReason(t *testing.T) {
+	tests := []struct {
+		to   Lifecycle
+		want bool
+	}{
+		{LifecycleProcured, false},
+		{LifecycleInStock, false},
+		{LifecycleDeployed, false},
+		{LifecycleInRepair, false},
+		{LifecycleLoaned, false},
+		{LifecycleLost, true},
+		{LifecycleRetired, true},
+		{LifecycleDisposed, true},
+	}
+	for _, tt := range tests {
+		if got := tt.to.RequiresReason(); got != tt.want {
+			t.Errorf("%s.RequiresReason() = %v, want %v", tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestLifecycleValid(t *testing.T) {
+	for _, l := range allLifecycles {
+		if !l.Valid() {
+			t.Errorf("%s.Valid() = false", l)
+		}
+	}
+	for _, l := range []Lifecycle{"", "scrapped", "IN_STOCK"} {
+		if l.Valid() {
+			t.Errorf("%q.Valid() = true", l)
+		}
+	}
+}
@@ -24,12 +24,25 @@ type Device struct {
 	DeviceModelID string    `json:"deviceModelId"`
 	SchoolID      string    `json:"schoolId"`
 	AssignedTo    string    `json:"assignedTo"`
-	Lifecycle     string    `json:"lifecycle"`
+	Lifecycle     Lifecycle `json:"lifecycle"`
 	Enrolled      bool      `json:"enrolled"`
+	Purchase      Purchase  `json:"purchase"`
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
 }
 
+// Purchase is a device's procurement and warranty record. Dates are
+// calendar days (YYYY-MM-DD).
+type Purchase struct {
+	PurchaseDate      string `json:"purchaseDate,omitempty"`
+	CostCents         int64  `json:"costCents"`
+	Currency          string `json:"currency"`
+	Supplier          string `json:"supplier,omitempty"`
+	PurchaseOrderRef  string `json:"purchaseOrderRef,omitempty"`
+	WarrantyProvider  string `json:"warrantyProvider,omitempty"`
+	WarrantyExpiresAt string `json:"warrantyExpiresAt,omitempty"`
+}
+
 // InterfaceType represents the type of network interface
 type InterfaceType string
 
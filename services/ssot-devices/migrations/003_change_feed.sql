@@ -0,0 +1,25 @@
+-- Change feed support: tombstones for deleted rows and keyset indexes for
+-- GET /v1/changes/{resource}?updatedSince=&cursor=
+CREATE TABLE IF NOT EXISTS tombstones (
+  tenant_id TEXT NOT NULL,
+  resource TEXT NOT NULL,
+  entity_id TEXT NOT NULL,
+  deleted_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (tenant_id, resource, entity_id)
+);
+CREATE INDEX IF NOT EXISTS idx_tombstones_feed ON tombstones(tenant_id, resource, deleted_at, entity_id);
+
+-- Record a tombstone for every deleted row, whichever code path deletes it.
+CREATE OR REPLACE FUNCTION record_tombstone() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO tombstones (tenant_id, resource, entity_id, deleted_at)
+  VALUES (OLD.tenant_id, TG_ARGV[0], OLD.id, clock_timestamp())
+  ON CONFLICT (tenant_id, resource, entity_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at;
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_devices_tombstone ON devices;
+CREATE TRIGGER trg_devices_tombstone AFTER DELETE ON devices
+  FOR EACH ROW EXECUTE FUNCTION record_tombstone('devices');
+CREATE INDEX IF NOT EXISTS idx_devices_changes ON devices(tenant_id, updated_at, id);
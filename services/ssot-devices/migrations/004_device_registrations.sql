@@ -0,0 +1,4 @@
+-- Devices registered by schools through IMS. registration_ref is the IMS
+-- registration ID; it makes retried registrations idempotent.
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS registration_ref TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS ux_devices_registration_ref ON devices(tenant_id, registration_ref) WHERE registration_ref <> '';
@@ -0,0 +1,44 @@
+-- Enforced device lifecycle with transition history, plus purchase and
+-- warranty metadata. The migrator re-runs every file, so each statement is
+-- idempotent.
+
+-- Map legacy free-text values onto the lifecycle states.
+UPDATE devices SET lifecycle = 'deployed' WHERE lifecycle IN ('active', 'in_use', 'assigned');
+UPDATE devices SET lifecycle = 'in_repair' WHERE lifecycle IN ('repair', 'under_repair');
+UPDATE devices SET lifecycle = 'in_stock'
+  WHERE lifecycle NOT IN ('procured', 'in_stock', 'deployed', 'in_repair', 'loaned', 'lost', 'retired', 'disposed');
+
+ALTER TABLE devices DROP CONSTRAINT IF EXISTS ck_devices_lifecycle;
+ALTER TABLE devices ADD CONSTRAINT ck_devices_lifecycle
+  CHECK (lifecycle IN ('procured', 'in_stock', 'deployed', 'in_repair', 'loaned', 'lost', 'retired', 'disposed'));
+
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS lifecycle_changed_at TIMESTAMPTZ;
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS purchase_date DATE;
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS purchase_cost_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'KES';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS supplier TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS purchase_order_ref TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS warranty_provider TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS warranty_expires_at DATE;
+CREATE INDEX IF NOT EXISTS idx_devices_warranty ON devices(tenant_id, warranty_expires_at) WHERE warranty_expires_at IS NOT NULL;
+
+-- One row per transition. No foreign key: history outlives deleted devices.
+CREATE TABLE IF NOT EXISTS device_lifecycle_events (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  device_id TEXT NOT NULL,
+  from_state TEXT NOT NULL DEFAULT '',   -- '' for the state a device was created in
+  to_state TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  reason TEXT NOT NULL DEFAULT '',
+  source TEXT NOT NULL DEFAULT 'api',    -- api, import, registration
+  occurred_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_device_lifecycle_events_device ON device_lifecycle_events(tenant_id, device_id, occurred_at DESC);
+
+-- Baseline event for devices that predate the history.
+INSERT INTO device_lifecycle_events (id, tenant_id, device_id, from_state, to_state, actor, reason, source, occurred_at)
+SELECT 'dlev_' || d.id, d.tenant_id, d.id, '', d.lifecycle, 'system', 'lifecycle history started', 'import', d.updated_at
+FROM devices d
+WHERE NOT EXISTS (SELECT 1 FROM device_lifecycle_events e WHERE e.tenant_id = d.tenant_id AND e.device_id = d.id)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
+	"github.com/edvirons/ssp/shared/pkg/httpx"
+	"github.com/edvirons/ssp/ssot_hr/internal/models"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// ListChanges serves GET /v1/changes/{resource}, the incremental feed of
+// people, teams, org-units or team-memberships changed or deleted since
+// updatedSince (or after cursor).
+func (s *Server) ListChanges(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	q, err := changefeed.ParseQuery(r)
+	if err != nil {
+		httpx.Error(w, 400, err.Error())
+		return
+	}
+
+	resource := chi.URLParam(r, "resource")
+	var page any
+	switch resource {
+	case "people":
+		page, err = s.peopleChanges(r.Context(), tenant, q)
+	case "teams":
+		page, err = s.teamChanges(r.Context(), tenant, q)
+	case "org-units":
+		page, err = s.orgUnitChanges(r.Context(), tenant, q)
+	case "team-memberships":
+		page, err = s.membershipChanges(r.Context(), tenant, q)
+	default:
+		httpx.Error(w, 404, "unknown resource")
+		return
+	}
+	if err != nil {
+		s.log.Error("change feed query failed", zap.String("resource", resource), zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	httpx.WriteJSON(w, 200, page)
+}
+
+func (s *Server) peopleChanges(ctx context.Context, tenant string, q changefeed.Query) (changefeed.Page[models.Person], error) {
+	var page changefeed.Page[models.Person]
+	rows, err := s.db.Query(ctx, `
+		SELECT id, tenant_id, org_unit_id, status, given_name, family_name, email, phone, title, avatar_url, spec_json, created_at, updated_at
+		FROM people
+		WHERE tenant_id=$1 AND (updated_at, id) > ($2, $3)
+		ORDER BY updated_at, id
+		LIMIT $4
+	`, tenant, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	items := []models.Person{}
+	for rows.Next() {
+		var p models.Person
+		var specJSON string
+		if err := rows.Scan(&p.ID, &p.TenantID, &p.OrgUnitID, &p.Status, &p.GivenName, &p.FamilyName, &p.Email, &p.Phone, &p.Title, &p.AvatarURL, &specJSON, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return page, err
+		}
+		p.SpecJSON = parseJSON(specJSON)
+		items = append(items, p)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	deleted, err := changefeed.LoadTombstones(ctx, s.db, tenant, "people", q)
+	if err != nil {
+		return page, err
+	}
+	return changefeed.Build(q, items, func(p models.Person) (time.Time, string) { return p.UpdatedAt, p.ID }, deleted), nil
+}
+
+func (s *Server) teamChanges(ctx context.Context, tenant string, q changefeed.Query) (changefeed.Page[models.Team], error) {
+	var page changefeed.Page[models.Team]
+	rows, err := s.db.Query(ctx, `
+		SELECT id, tenant_id, org_unit_id, key, name, description, spec_json, created_at, updated_at
+		FROM teams
+		WHERE tenant_id=$1 AND (updated_at, id) > ($2, $3)
+		ORDER BY updated_at, id
+		LIMIT $4
+	`, tenant, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	items := []models.Team{}
+	for rows.Next() {
+		var t models.Team
+		var specJSON string
+		if err := rows.Scan(&t.ID, &t.TenantID, &t.OrgUnitID, &t.Key, &t.Name, &t.Description, &specJSON, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return page, err
+		}
+		t.SpecJSON = parseJSON(specJSON)
+		items = append(items, t)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	deleted, err := changefeed.LoadTombstones(ctx, s.db, tenant, "teams", q)
+	if err != nil {
+		return page, err
+	}
+	return changefeed.Build(q, items, func(t models.Team) (time.Time, string) { return t.UpdatedAt, t.ID }, deleted), nil
+}
+
+func (s *Server) orgUnitChanges(ctx context.Context, tenant string, q changefeed.Query) (changefeed.Page[models.OrgUnit], error) {
+	var page changefeed.Page[models.OrgUnit]
+	rows, err := s.db.Query(ctx, `
+		SELECT id, tenant_id, parent_id, code, name, kind, spec_json, created_at, updated_at
+		FROM org_units
+		WHERE tenant_id=$1 AND (updated_at, id) > ($2, $3)
+		ORDER BY updated_at, id
+		LIMIT $4
+	`, tenant, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	items := []models.OrgUnit{}
+	for rows.Next() {
+		var o models.OrgUnit
+		var specJSON string
+		if err := rows.Scan(&o.ID, &o.TenantID, &o.ParentID, &o.Code, &o.Name, &o.Kind, &specJSON, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return page, err
+		}
+		o.SpecJSON = parseJSON(specJSON)
+		items = append(items, o)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	deleted, err := changefeed.LoadTombstones(ctx, s.db, tenant, "org-units", q)
+	if err != nil {
+		return page, err
+	}
+	return changefeed.Build(q, items, func(o models.OrgUnit) (time.Time, string) { return o.UpdatedAt, o.ID }, deleted), nil
+}
+
+func (s *Server) membershipChanges(ctx context.Context, tenant string, q changefeed.Query) (changefeed.Page[models.TeamMembership], error) {
+	var page changefeed.Page[models.TeamMembership]
+	rows, err := s.db.Query(ctx, `
+		SELECT id, tenant_id, team_id, person_id, role, status, started_at, ended_at, spec_json, created_at, updated_at
+		FROM team_memberships
+		WHERE tenant_id=$1 AND (updated_at, id) > ($2, $3)
+		ORDER BY updated_at, id
+		LIMIT $4
+	`, tenant, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	items := []models.TeamMembership{}
+	for rows.Next() {
+		var m models.TeamMembership
+		var specJSON string
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.TeamID, &m.PersonID, &m.Role, &m.Status, &m.StartedAt, &m.EndedAt, &specJSON, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return page, err
+		}
+		m.SpecJSON = parseJSON(specJSON)
+		items = append(items, m)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	deleted, err := changefeed.LoadTombstones(ctx, s.db, tenant, "team-memberships", q)
+	if err != nil {
+		return page, err
+	}
+	return changefeed.Build(q, items, func(m models.TeamMembership) (time.Time, string) { return m.UpdatedAt, m.ID }, deleted), nil
+}
@@ -64,6 +64,9 @@ func NewServer(log *zap.Logger) http.Handler {
 		r.Get("/export", s.Export)
 		r.Post("/import", s.Import)
 
+		// Incremental change feeds with tombstones
+		r.Get("/changes/{resource}", s.ListChanges)
+
 		// People CRUD
 		r.Route("/people", func(r chi.Router) {
 			r.Post("/", s.CreatePerson)
@@ -0,0 +1,40 @@
+-- Change feed support: tombstones for deleted rows and keyset indexes for
+-- GET /v1/changes/{resource}?updatedSince=&cursor=
+CREATE TABLE IF NOT EXISTS tombstones (
+  tenant_id TEXT NOT NULL,
+  resource TEXT NOT NULL,
+  entity_id TEXT NOT NULL,
+  deleted_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (tenant_id, resource, entity_id)
+);
+CREATE INDEX IF NOT EXISTS idx_tombstones_feed ON tombstones(tenant_id, resource, deleted_at, entity_id);
+
+-- Record a tombstone for every deleted row, whichever code path deletes it.
+CREATE OR REPLACE FUNCTION record_tombstone() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO tombstones (tenant_id, resource, entity_id, deleted_at)
+  VALUES (OLD.tenant_id, TG_ARGV[0], OLD.id, clock_timestamp())
+  ON CONFLICT (tenant_id, resource, entity_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at;
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_people_tombstone ON people;
+CREATE TRIGGER trg_people_tombstone AFTER DELETE ON people
+  FOR EACH ROW EXECUTE FUNCTION record_tombstone('people');
+CREATE INDEX IF NOT EXISTS idx_people_changes ON people(tenant_id, updated_at, id);
+
+DROP TRIGGER IF EXISTS trg_teams_tombstone ON teams;
+CREATE TRIGGER trg_teams_tombstone AFTER DELETE ON teams
+  FOR EACH ROW EXECUTE FUNCTION record_tombstone('teams');
+CREATE INDEX IF NOT EXISTS idx_teams_changes ON teams(tenant_id, updated_at, id);
+
+DROP TRIGGER IF EXISTS trg_org_units_tombstone ON org_units;
+CREATE TRIGGER trg_org_units_tombstone AFTER DELETE ON org_units
+  FOR EACH ROW EXECUTE FUNCTION record_tombstone('org-units');
+CREATE INDEX IF NOT EXISTS idx_org_units_changes ON org_units(tenant_id, updated_at, id);
+
+DROP TRIGGER IF EXISTS trg_team_memberships_tombstone ON team_memberships;
+CREATE TRIGGER trg_team_memberships_tombstone AFTER DELETE ON team_memberships
+  FOR EACH ROW EXECUTE FUNCTION record_tombstone('team-memberships');
+CREATE INDEX IF NOT EXISTS idx_team_memberships_changes ON team_memberships(tenant_id, updated_at, id);
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
+	"github.com/edvirons/ssp/shared/pkg/httpx"
+	"go.uber.org/zap"
+)
+
+// PartItem is the change feed format for parts (matches IMS PartSnapshot).
+type PartItem struct {
+	TenantID  string    `json:"tenantId"`
+	PartID    string    `json:"partId"`
+	PUK       string    `json:"puk"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListPartChanges serves GET /v1/changes/parts, the incremental feed of
+// parts changed or deleted since updatedSince (or after cursor).
+func (s *Server) ListPartChanges(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	q, err := changefeed.ParseQuery(r)
+	if err != nil {
+		httpx.Error(w, 400, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id, tenant_id, puk, name, category, updated_at
+		FROM parts
+		WHERE tenant_id = $1 AND (updated_at, id) > ($2, $3)
+		ORDER BY updated_at, id
+		LIMIT $4
+	`, tenant, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		s.log.Error("failed to query part changes", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	defer rows.Close()
+
+	items := []PartItem{}
+	for rows.Next() {
+		var it PartItem
+		if err := rows.Scan(&it.PartID, &it.TenantID, &it.PUK, &it.Name, &it.Category, &it.UpdatedAt); err != nil {
+			s.log.Error("failed to scan part", zap.Error(err))
+			httpx.Error(w, 500, "scan failed")
+			return
+		}
+		items = append(items, it)
+	}
+
+	deleted, err := changefeed.LoadTombstones(r.Context(), s.db, tenant, "parts", q)
+	if err != nil {
+		s.log.Error("failed to query part tombstones", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+
+	httpx.WriteJSON(w, 200, changefeed.Build(q, items, func(it PartItem) (time.Time, string) {
+		return it.UpdatedAt, it.PartID
+	}, deleted))
+}
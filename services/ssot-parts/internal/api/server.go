@@ -51,6 +51,7 @@ func NewServer(log *zap.Logger) http.Handler {
 	r.Route("/v1", func(r chi.Router) {
 		r.Get("/export", s.Export)
 		r.Post("/import", s.Import)
+		r.Get("/changes/parts", s.ListPartChanges)
 	})
 
 	log.Info("routes ready")
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
+	"github.com/edvirons/ssp/shared/pkg/httpx"
+	"go.uber.org/zap"
+)
+
+// ListSchoolChanges serves GET /v1/changes/schools, the incremental feed of
+// schools changed or deleted since updatedSince (or after cursor).
+func (s *Server) ListSchoolChanges(w http.ResponseWriter, r *http.Request) {
+	tenant := httpx.TenantID(r)
+	if tenant == "" {
+		httpx.Error(w, 400, "X-Tenant-Id required")
+		return
+	}
+	q, err := changefeed.ParseQuery(r)
+	if err != nil {
+		httpx.Error(w, 400, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT
+			s.id, s.tenant_id, s.name,
+			COALESCE(c.code, ''), COALESCE(c.name, ''),
+			COALESCE(sc.code, ''), COALESCE(sc.name, ''),
+			s.level, s.type, s.knec_code, s.uic, s.sex, s.cluster, s.accommodation,
+			s.latitude, s.longitude, s.updated_at
+		FROM schools s
+		LEFT JOIN counties c ON s.county_id = c.id AND c.tenant_id = s.tenant_id
+		LEFT JOIN sub_counties sc ON s.sub_county_id = sc.id AND sc.tenant_id = s.tenant_id
+		WHERE s.tenant_id = $1 AND (s.updated_at, s.id) > ($2, $3)
+		ORDER BY s.updated_at, s.id
+		LIMIT $4
+	`, tenant, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		s.log.Error("failed to query school changes", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+	defer rows.Close()
+
+	items := []SchoolItem{}
+	for rows.Next() {
+		var it SchoolItem
+		if err := rows.Scan(&it.SchoolID, &it.TenantID, &it.Name,
+			&it.CountyCode, &it.CountyName, &it.SubCountyCode, &it.SubCountyName,
+			&it.Level, &it.Type, &it.KnecCode, &it.Uic, &it.Sex, &it.Cluster, &it.Accommodation,
+			&it.Latitude, &it.Longitude, &it.UpdatedAt); err != nil {
+			s.log.Error("failed to scan school", zap.Error(err))
+			httpx.Error(w, 500, "scan failed")
+			return
+		}
+		items = append(items, it)
+	}
+
+	deleted, err := changefeed.LoadTombstones(r.Context(), s.db, tenant, "schools", q)
+	if err != nil {
+		s.log.Error("failed to query school tombstones", zap.Error(err))
+		httpx.Error(w, 500, "query failed")
+		return
+	}
+
+	httpx.WriteJSON(w, 200, changefeed.Build(q, items, func(it SchoolItem) (time.Time, string) {
+		return it.UpdatedAt, it.SchoolID
+	}, deleted))
+}
@@ -52,6 +52,7 @@ func NewServer(log *zap.Logger) http.Handler {
 		r.Get("/export", s.Export)
 		r.Post("/import", s.Import)
 		r.Get("/schools", s.ListSchools)
+		r.Get("/changes/schools", s.ListSchoolChanges)
 	})
 
 	log.Info("routes ready")
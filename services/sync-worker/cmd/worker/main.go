@@ -2,15 +2,16 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/edvirons/ssp/sync_worker/internal/admin"
 	"github.com/edvirons/ssp/sync_worker/internal/config"
 	"github.com/edvirons/ssp/sync_worker/internal/health"
 	"github.com/edvirons/ssp/sync_worker/internal/worker"
+	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
@@ -46,18 +47,19 @@ func main() {
 	// Create sync worker
 	sw := worker.New(log, db, cfg)
 
-	// Subscribe to SSOT change events
-	for _, kind := range []string{"school", "devices", "parts"} {
-		subject := fmt.Sprintf("ssot.%s.changed", kind)
-		_, err := nc.Subscribe(subject, sw.HandleEvent(kind))
-		if err != nil {
-			log.Fatal("subscribe failed", zap.String("subject", subject), zap.Error(err))
-		}
-		log.Info("subscribed to NATS subject", zap.String("subject", subject))
+	// Bind durable JetStream consumers for SSOT change events
+	if err := sw.Start(nc); err != nil {
+		log.Fatal("jetstream setup failed", zap.Error(err))
 	}
 
-	// Start health check server
-	go health.StartServer(log, db, cfg.HealthPort)
+	// Start health check server, with the admin API when a token is configured
+	var mounts []func(chi.Router)
+	if cfg.AdminToken != "" {
+		mounts = append(mounts, admin.Routes(log, sw, cfg.AdminToken))
+	} else {
+		log.Warn("ADMIN_TOKEN not set; dead-letter admin endpoints disabled")
+	}
+	go health.StartServer(log, db, cfg.HealthPort, mounts...)
 
 	log.Info("sync-worker running", zap.String("nats", cfg.NATSURL), zap.String("health_port", cfg.HealthPort))
 
@@ -69,6 +71,8 @@ func main() {
 	log.Info("shutting down...")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_ = ctx
+	// Let in-flight syncs settle their messages; anything left unacked is
+	// redelivered once its ack wait expires.
+	sw.Wait(ctx)
 	log.Info("shutdown complete")
 }
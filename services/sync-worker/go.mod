@@ -3,6 +3,7 @@ module github.com/edvirons/ssp/sync_worker
 go 1.22
 
 require (
+	github.com/edvirons/ssp/shared v0.0.0-00010101000000-000000000000
 	github.com/go-chi/chi/v5 v5.0.12
 	github.com/jackc/pgx/v5 v5.6.0
 	github.com/nats-io/nats.go v1.35.0
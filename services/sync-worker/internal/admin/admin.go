@@ -0,0 +1,83 @@
+// Package admin provides operator endpoints for dead-lettered sync jobs.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/edvirons/ssp/sync_worker/internal/worker"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Routes returns a mount function for the admin endpoints. Requests must carry
+// "Authorization: Bearer <token>".
+func Routes(log *zap.Logger, sw *worker.SyncWorker, token string) func(chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/admin/dead-letters", func(r chi.Router) {
+			r.Use(requireToken(token))
+			r.Get("/", listHandler(log, sw))
+			r.Post("/{id}/replay", replayHandler(log, sw))
+		})
+	}
+}
+
+func requireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// listHandler serves GET /admin/dead-letters?kind=&tenantId=&pending=true&limit=
+func listHandler(log *zap.Logger, sw *worker.SyncWorker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		items, err := sw.ListDeadLetters(r.Context(), worker.DeadLetterFilter{
+			Kind:     q.Get("kind"),
+			TenantID: q.Get("tenantId"),
+			Pending:  q.Get("pending") == "true",
+			Limit:    limit,
+		})
+		if err != nil {
+			log.Error("list dead letters failed", zap.Error(err))
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list dead letters"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+// replayHandler serves POST /admin/dead-letters/{id}/replay
+func replayHandler(log *zap.Logger, sw *worker.SyncWorker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dl, err := sw.ReplayDeadLetter(r.Context(), chi.URLParam(r, "id"))
+		if errors.Is(err, worker.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "dead letter not found"})
+			return
+		}
+		if err != nil {
+			log.Error("replay dead letter failed", zap.Error(err))
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to replay dead letter"})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, dl)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
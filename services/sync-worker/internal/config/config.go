@@ -18,6 +18,15 @@ type Config struct {
 	HealthPort     string
 	MaxRetries     int
 	InitialBackoff time.Duration
+	SyncPageSize   int
+
+	// KindConcurrency bounds how many syncs of one kind run at once across
+	// all tenants. A single tenant never has more than one sync per kind.
+	KindConcurrency int
+	AckWait         time.Duration
+	StreamMaxAge    time.Duration
+	// AdminToken enables the dead-letter admin endpoints when set.
+	AdminToken string
 }
 
 // Load reads configuration from environment variables with defaults.
@@ -32,6 +41,12 @@ func Load() Config {
 		HealthPort:     env("HEALTH_PORT", "8084"),
 		MaxRetries:     envInt("MAX_RETRIES", 3),
 		InitialBackoff: envDuration("INITIAL_BACKOFF_SECONDS", 1*time.Second),
+		SyncPageSize:   envInt("SSOT_SYNC_PAGE_SIZE", 500),
+
+		KindConcurrency: envInt("KIND_CONCURRENCY", 4),
+		AckWait:         envDuration("ACK_WAIT_SECONDS", 2*time.Minute),
+		StreamMaxAge:    envDuration("STREAM_MAX_AGE_SECONDS", 72*time.Hour),
+		AdminToken:      env("ADMIN_TOKEN", ""),
 	}
 }
 
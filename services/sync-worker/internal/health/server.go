@@ -13,10 +13,14 @@ import (
 )
 
 // StartServer starts the health check HTTP server on the given port.
-func StartServer(log *zap.Logger, db *pgxpool.Pool, port string) {
+// Additional routes, such as the admin API, are attached through mounts.
+func StartServer(log *zap.Logger, db *pgxpool.Pool, port string, mounts ...func(chi.Router)) {
 	r := chi.NewRouter()
 
 	r.Get("/health", healthHandler(log, db))
+	for _, m := range mounts {
+		m(r)
+	}
 
 	addr := ":" + port
 	log.Info("health check server starting", zap.String("addr", addr))
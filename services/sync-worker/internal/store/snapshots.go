@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// School is a row of the ssot-school change feed.
+type School struct {
+	SchoolID      string    `json:"schoolId"`
+	Name          string    `json:"name"`
+	CountyCode    string    `json:"countyCode"`
+	CountyName    string    `json:"countyName"`
+	SubCountyCode string    `json:"subCountyCode"`
+	SubCountyName string    `json:"subCountyName"`
+	Level         string    `json:"level"`
+	Type          string    `json:"type"`
+	KnecCode      string    `json:"knecCode"`
+	Uic           string    `json:"uic"`
+	Sex           string    `json:"sex"`
+	Cluster       string    `json:"cluster"`
+	Accommodation string    `json:"accommodation"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Device is a row of the ssot-devices change feed.
+type Device struct {
+	DeviceID  string    `json:"deviceId"`
+	SchoolID  string    `json:"schoolId"`
+	Model     string    `json:"model"`
+	Serial    string    `json:"serial"`
+	AssetTag  string    `json:"assetTag"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Part is a row of the ssot-parts change feed.
+type Part struct {
+	PartID    string    `json:"partId"`
+	PUK       string    `json:"puk"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`
+	Unit      string    `json:"unit"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func UpsertSchool(ctx context.Context, db DBTX, tenantID string, s School) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO schools_snapshot (
+			tenant_id, school_id, name, county_code, county_name, sub_county_code, sub_county_name,
+			level, type, knec_code, uic, sex, cluster, accommodation, latitude, longitude, updated_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
+		ON CONFLICT (tenant_id, school_id)
+		DO UPDATE SET
+		  name=EXCLUDED.name,
+		  county_code=EXCLUDED.county_code,
+		  county_name=EXCLUDED.county_name,
+		  sub_county_code=EXCLUDED.sub_county_code,
+		  sub_county_name=EXCLUDED.sub_county_name,
+		  level=EXCLUDED.level,
+		  type=EXCLUDED.type,
+		  knec_code=EXCLUDED.knec_code,
+		  uic=EXCLUDED.uic,
+		  sex=EXCLUDED.sex,
+		  cluster=EXCLUDED.cluster,
+		  accommodation=EXCLUDED.accommodation,
+		  latitude=EXCLUDED.latitude,
+		  longitude=EXCLUDED.longitude,
+		  updated_at=EXCLUDED.updated_at
+	`, tenantID, s.SchoolID, s.Name, s.CountyCode, s.CountyName, s.SubCountyCode, s.SubCountyName,
+		s.Level, s.Type, s.KnecCode, s.Uic, s.Sex, s.Cluster, s.Accommodation, s.Latitude, s.Longitude, s.UpdatedAt)
+	return err
+}
+
+func DeleteSchool(ctx context.Context, db DBTX, tenantID, schoolID string) error {
+	_, err := db.Exec(ctx, `DELETE FROM schools_snapshot WHERE tenant_id=$1 AND school_id=$2`, tenantID, schoolID)
+	return err
+}
+
+func UpsertDevice(ctx context.Context, db DBTX, tenantID string, d Device) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO devices_snapshot (
+			tenant_id, device_id, school_id, model, serial, asset_tag, status, updated_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		ON CONFLICT (tenant_id, device_id)
+		DO UPDATE SET
+		  school_id=EXCLUDED.school_id,
+		  model=EXCLUDED.model,
+		  serial=EXCLUDED.serial,
+		  asset_tag=EXCLUDED.asset_tag,
+		  status=EXCLUDED.status,
+		  updated_at=EXCLUDED.updated_at
+	`, tenantID, d.DeviceID, d.SchoolID, d.Model, d.Serial, d.AssetTag, d.Status, d.UpdatedAt)
+	return err
+}
+
+func DeleteDevice(ctx context.Context, db DBTX, tenantID, deviceID string) error {
+	_, err := db.Exec(ctx, `DELETE FROM devices_snapshot WHERE tenant_id=$1 AND device_id=$2`, tenantID, deviceID)
+	return err
+}
+
+func UpsertPart(ctx context.Context, db DBTX, tenantID string, p Part) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO parts_snapshot (
+			tenant_id, part_id, puk, name, category, unit, updated_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (tenant_id, part_id)
+		DO UPDATE SET
+		  puk=EXCLUDED.puk,
+		  name=EXCLUDED.name,
+		  category=EXCLUDED.category,
+		  unit=EXCLUDED.unit,
+		  updated_at=EXCLUDED.updated_at
+	`, tenantID, p.PartID, p.PUK, p.Name, p.Category, p.Unit, p.UpdatedAt)
+	return err
+}
+
+func DeletePart(ctx context.Context, db DBTX, tenantID, partID string) error {
+	_, err := db.Exec(ctx, `DELETE FROM parts_snapshot WHERE tenant_id=$1 AND part_id=$2`, tenantID, partID)
+	return err
+}
@@ -0,0 +1,69 @@
+// Package store holds the sync worker's writes to the IMS database: the typed
+// SSOT snapshot tables and their sync checkpoints.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX is satisfied by *pgxpool.Pool and pgx.Tx, so the same writes can run
+// standalone or inside a transaction.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// SSOTResource names a checkpointed feed. Values match the IMS API's
+// SSOTResource so both share rows in ssot_sync_state.
+type SSOTResource string
+
+const (
+	SSOTSchools SSOTResource = "schools"
+	SSOTDevices SSOTResource = "devices"
+	SSOTParts   SSOTResource = "parts"
+)
+
+// SSOTSyncState is the checkpoint of a tenant's change feed. A non-empty
+// LastCursor means the previous run stopped part-way through a feed.
+type SSOTSyncState struct {
+	TenantID         string
+	Resource         SSOTResource
+	LastUpdatedSince time.Time
+	LastCursor       string
+	UpdatedAt        time.Time
+}
+
+// SSOTStateRepo reads and writes ssot_sync_state, the same checkpoint table
+// the IMS API's SSOTStateRepo uses for manual syncs.
+type SSOTStateRepo struct{ db DBTX }
+
+func NewSSOTStateRepo(db DBTX) *SSOTStateRepo { return &SSOTStateRepo{db: db} }
+
+// Get returns the checkpoint, or a fresh one starting at the epoch.
+func (r *SSOTStateRepo) Get(ctx context.Context, tenantID string, res SSOTResource) (SSOTSyncState, error) {
+	s := SSOTSyncState{TenantID: tenantID, Resource: res, LastUpdatedSince: time.Unix(0, 0).UTC()}
+	err := r.db.QueryRow(ctx, `
+		SELECT last_updated_since, last_cursor, updated_at
+		FROM ssot_sync_state
+		WHERE tenant_id=$1 AND resource=$2
+	`, tenantID, string(res)).Scan(&s.LastUpdatedSince, &s.LastCursor, &s.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s, nil
+	}
+	return s, err
+}
+
+func (r *SSOTStateRepo) Upsert(ctx context.Context, s SSOTSyncState) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO ssot_sync_state (tenant_id, resource, last_updated_since, last_cursor, updated_at)
+		VALUES ($1,$2,$3,$4,$5)
+		ON CONFLICT (tenant_id, resource)
+		DO UPDATE SET last_updated_since=EXCLUDED.last_updated_since, last_cursor=EXCLUDED.last_cursor, updated_at=EXCLUDED.updated_at
+	`, s.TenantID, string(s.Resource), s.LastUpdatedSince, s.LastCursor, s.UpdatedAt)
+	return err
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/ids"
+	"github.com/jackc/pgx/v5"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// ErrNotFound is returned when a dead-lettered job does not exist.
+var ErrNotFound = errors.New("not found")
+
+// DeadLetter is a sync job that exhausted its retries.
+type DeadLetter struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	TenantID    string          `json:"tenantId"`
+	Subject     string          `json:"subject"`
+	Payload     json.RawMessage `json:"payload"`
+	Error       string          `json:"error"`
+	Attempts    int             `json:"attempts"`
+	FailedAt    time.Time       `json:"failedAt"`
+	ReplayCount int             `json:"replayCount"`
+	ReplayedAt  *time.Time      `json:"replayedAt,omitempty"`
+}
+
+// DeadLetterFilter narrows ListDeadLetters. Empty fields match everything.
+type DeadLetterFilter struct {
+	Kind     string
+	TenantID string
+	// Pending limits results to jobs that were never replayed.
+	Pending bool
+	Limit   int
+}
+
+// deadLetter records the failed job and publishes it to the dead-letter stream.
+func (sw *SyncWorker) deadLetter(ctx context.Context, kind, tenant string, m signal, attempts int, syncErr error) error {
+	payload := m.data()
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(map[string]any{"tenantId": tenant})
+	}
+	dl := DeadLetter{
+		ID:       ids.New("dlq"),
+		Kind:     kind,
+		TenantID: tenant,
+		Subject:  m.subject(),
+		Payload:  payload,
+		Error:    syncErr.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+	}
+
+	_, err := sw.db.Exec(ctx, `
+		INSERT INTO sync_dead_letters (id, kind, tenant_id, subject, payload, error, attempts, failed_at)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6, $7, $8)
+	`, dl.ID, dl.Kind, dl.TenantID, dl.Subject, string(dl.Payload), dl.Error, dl.Attempts, dl.FailedAt)
+	if err != nil {
+		return fmt.Errorf("insert dead letter: %w", err)
+	}
+
+	b, _ := json.Marshal(dl)
+	out := nats.NewMsg(deadLetterSubjectPrefix + kind)
+	out.Data = b
+	out.Header.Set(nats.MsgIdHdr, dl.ID)
+	if _, err := sw.js.PublishMsg(out, nats.Context(ctx)); err != nil {
+		// The row is the record of truth; the stream is for alerting.
+		sw.log.Warn("dead-letter publish failed", zap.String("id", dl.ID), zap.Error(err))
+	}
+	return nil
+}
+
+// ListDeadLetters returns dead-lettered jobs, newest first.
+func (sw *SyncWorker) ListDeadLetters(ctx context.Context, f DeadLetterFilter) ([]DeadLetter, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	rows, err := sw.db.Query(ctx, `
+		SELECT id, kind, tenant_id, subject, payload, error, attempts, failed_at, replay_count, replayed_at
+		FROM sync_dead_letters
+		WHERE ($1 = '' OR kind = $1)
+		  AND ($2 = '' OR tenant_id = $2)
+		  AND (NOT $3 OR replayed_at IS NULL)
+		ORDER BY failed_at DESC
+		LIMIT $4
+	`, f.Kind, f.TenantID, f.Pending, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DeadLetter{}
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dl)
+	}
+	return out, rows.Err()
+}
+
+// ReplayDeadLetter republishes the original signal to the change stream so the
+// durable consumer picks it up with a fresh retry budget.
+func (sw *SyncWorker) ReplayDeadLetter(ctx context.Context, id string) (DeadLetter, error) {
+	row := sw.db.QueryRow(ctx, `
+		SELECT id, kind, tenant_id, subject, payload, error, attempts, failed_at, replay_count, replayed_at
+		FROM sync_dead_letters WHERE id = $1
+	`, id)
+	dl, err := scanDeadLetter(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DeadLetter{}, ErrNotFound
+	}
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	if _, err := sw.js.Publish(dl.Subject, dl.Payload, nats.Context(ctx)); err != nil {
+		return DeadLetter{}, fmt.Errorf("republish: %w", err)
+	}
+
+	now := time.Now().UTC()
+	_, err = sw.db.Exec(ctx, `
+		UPDATE sync_dead_letters SET replay_count = replay_count + 1, replayed_at = $2 WHERE id = $1
+	`, dl.ID, now)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	dl.ReplayCount++
+	dl.ReplayedAt = &now
+
+	sw.log.Info("dead letter replayed",
+		zap.String("id", dl.ID),
+		zap.String("kind", dl.Kind),
+		zap.String("tenantId", dl.TenantID))
+	return dl, nil
+}
+
+func scanDeadLetter(row pgx.Row) (DeadLetter, error) {
+	var dl DeadLetter
+	var payload []byte
+	err := row.Scan(&dl.ID, &dl.Kind, &dl.TenantID, &dl.Subject, &payload, &dl.Error,
+		&dl.Attempts, &dl.FailedAt, &dl.ReplayCount, &dl.ReplayedAt)
+	dl.Payload = payload
+	return dl, err
+}
@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
+	"github.com/edvirons/ssp/shared/pkg/cursor"
+	"github.com/edvirons/ssp/sync_worker/internal/store"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// feedSpec describes how one SSOT change feed maps onto a snapshot table.
+type feedSpec[T any] struct {
+	resource store.SSOTResource
+	key      func(T) (time.Time, string)
+	upsert   func(ctx context.Context, db store.DBTX, tenantID string, item T) error
+	remove   func(ctx context.Context, db store.DBTX, tenantID, id string) error
+}
+
+var (
+	schoolFeed = feedSpec[store.School]{
+		resource: store.SSOTSchools,
+		key:      func(s store.School) (time.Time, string) { return s.UpdatedAt, s.SchoolID },
+		upsert:   store.UpsertSchool,
+		remove:   store.DeleteSchool,
+	}
+	deviceFeed = feedSpec[store.Device]{
+		resource: store.SSOTDevices,
+		key:      func(d store.Device) (time.Time, string) { return d.UpdatedAt, d.DeviceID },
+		upsert:   store.UpsertDevice,
+		remove:   store.DeleteDevice,
+	}
+	partFeed = feedSpec[store.Part]{
+		resource: store.SSOTParts,
+		key:      func(p store.Part) (time.Time, string) { return p.UpdatedAt, p.PartID },
+		upsert:   store.UpsertPart,
+		remove:   store.DeletePart,
+	}
+)
+
+// syncResult counts what one sync applied.
+type syncResult struct {
+	pages, upserted, deleted int
+}
+
+// fetchAndUpsert pulls the tenant's change feed for kind from its checkpoint
+// and applies it to the typed snapshot table.
+func (sw *SyncWorker) fetchAndUpsert(ctx context.Context, kind, tenant string) error {
+	base := sw.getURLForKind(kind)
+	if base == "" {
+		return fmt.Errorf("unknown kind: %s", kind)
+	}
+
+	var res syncResult
+	var err error
+	switch kind {
+	case "school":
+		res, err = syncFeed(ctx, sw, schoolFeed, base, tenant)
+	case "devices":
+		res, err = syncFeed(ctx, sw, deviceFeed, base, tenant)
+	case "parts":
+		res, err = syncFeed(ctx, sw, partFeed, base, tenant)
+	default:
+		return fmt.Errorf("unknown kind: %s", kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	sw.log.Info("ssot delta synced",
+		zap.String("kind", kind),
+		zap.String("tenantId", tenant),
+		zap.Int("pages", res.pages),
+		zap.Int("upserted", res.upserted),
+		zap.Int("deleted", res.deleted))
+	return nil
+}
+
+// syncFeed pages through a change feed. Each page is applied together with
+// its checkpoint in one transaction, so a crash resumes from the last page.
+func syncFeed[T any](ctx context.Context, sw *SyncWorker, spec feedSpec[T], base, tenant string) (syncResult, error) {
+	var res syncResult
+	state, err := store.NewSSOTStateRepo(sw.db).Get(ctx, tenant, spec.resource)
+	if err != nil {
+		return res, fmt.Errorf("load checkpoint: %w", err)
+	}
+
+	maxSeen := state.LastUpdatedSince
+	if at, _, ok := cursor.Decode(state.LastCursor); ok && at.After(maxSeen) {
+		maxSeen = at
+	}
+
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, sw.config.FetchTimeout)
+		page, err := fetchPage[T](fetchCtx, base, string(spec.resource), tenant, state.LastUpdatedSince, state.LastCursor, sw.config.SyncPageSize)
+		cancel()
+		if err != nil {
+			return res, err
+		}
+
+		err = withTx(ctx, sw.db, func(tx pgx.Tx) error {
+			err := changefeed.Walk(page, spec.key,
+				func(it T) error {
+					if at, _ := spec.key(it); at.After(maxSeen) {
+						maxSeen = at
+					}
+					return spec.upsert(ctx, tx, tenant, it)
+				},
+				func(d changefeed.Tombstone) error {
+					if d.DeletedAt.After(maxSeen) {
+						maxSeen = d.DeletedAt
+					}
+					return spec.remove(ctx, tx, tenant, d.ID)
+				})
+			if err != nil {
+				return err
+			}
+
+			if page.NextCursor != "" {
+				state.LastCursor = page.NextCursor
+			} else {
+				state.LastUpdatedSince = maxSeen
+				state.LastCursor = ""
+			}
+			state.UpdatedAt = time.Now().UTC()
+			return store.NewSSOTStateRepo(tx).Upsert(ctx, state)
+		})
+		if err != nil {
+			return res, fmt.Errorf("apply %s page: %w", spec.resource, err)
+		}
+
+		res.pages++
+		res.upserted += len(page.Items)
+		res.deleted += len(page.Deleted)
+		if page.NextCursor == "" {
+			return res, nil
+		}
+	}
+}
+
+// fetchPage requests one page of GET {base}/v1/changes/{resource}.
+func fetchPage[T any](ctx context.Context, base, resource, tenant string, since time.Time, cur string, limit int) (changefeed.Page[T], error) {
+	var page changefeed.Page[T]
+
+	q := url.Values{}
+	q.Set("updatedSince", since.UTC().Format(time.RFC3339Nano))
+	if cur != "" {
+		q.Set("cursor", cur)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/changes/"+resource+"?"+q.Encode(), nil)
+	if err != nil {
+		return page, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("X-Tenant-Id", tenant)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return page, fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return page, fmt.Errorf("ssot change feed failed: status=%d body=%s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("invalid change feed response: %w", err)
+	}
+	return page, nil
+}
+
+func withTx(ctx context.Context, db *pgxpool.Pool, fn func(pgx.Tx) error) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,135 @@
+//go:build integration
+// +build integration
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/sync_worker/internal/config"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// TestJetStreamRetryAndDeadLetter runs the worker's durable consumers against
+// a real JetStream server (TEST_NATS_URL). It deletes the worker's streams,
+// so point it at a throwaway server.
+func TestJetStreamRetryAndDeadLetter(t *testing.T) {
+	url := os.Getenv("TEST_NATS_URL")
+	if url == "" {
+		url = "nats://localhost:4222"
+	}
+	nc, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	resetStreams := func() {
+		for _, s := range []string{ChangeStream, DeadLetterStream} {
+			if err := js.DeleteStream(s); err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+				t.Fatalf("delete stream %s: %v", s, err)
+			}
+		}
+	}
+	resetStreams()
+
+	sw := New(zap.NewNop(), nil, config.Config{
+		MaxRetries:      2,
+		InitialBackoff:  100 * time.Millisecond,
+		KindConcurrency: 1,
+		AckWait:         5 * time.Second,
+		StreamMaxAge:    time.Hour,
+	})
+	var mu sync.Mutex
+	syncs := map[string]int{}
+	deadLettered := map[string]int{}
+	sw.syncTenant = func(_ context.Context, kind, tenant string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		syncs[tenant]++
+		switch {
+		case tenant == "flaky" && syncs[tenant] == 1, tenant == "broken":
+			return errors.New("ssot unavailable")
+		}
+		return nil
+	}
+	sw.recordDeadLetter = func(_ context.Context, kind, tenant string, m signal, attempts int, _ error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deadLettered[tenant] = attempts
+		return nil
+	}
+
+	if err := sw.Start(nc); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = nc.Drain()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		sw.Wait(ctx)
+		if nc2, err := nats.Connect(url); err == nil {
+			js2, _ := nc2.JetStream()
+			for _, s := range []string{ChangeStream, DeadLetterStream} {
+				_ = js2.DeleteStream(s)
+			}
+			nc2.Close()
+		}
+	})
+
+	for _, tenant := range []string{"ok", "flaky", "broken"} {
+		if _, err := js.Publish("ssot.school.changed", []byte(`{"tenantId":"`+tenant+`"}`)); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	// ok syncs once, flaky is redelivered after a nak and succeeds, broken
+	// is dead-lettered on the delivery after MaxRetries
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		mu.Lock()
+		done := syncs["ok"] == 1 && syncs["flaky"] == 2 && deadLettered["broken"] == 3
+		got := map[string]int{"ok": syncs["ok"], "flaky": syncs["flaky"], "broken": syncs["broken"], "dlq": deadLettered["broken"]}
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out: %v", got)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Every signal is settled: acked, or terminated once dead-lettered
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		ci, err := js.ConsumerInfo(ChangeStream, "sync-worker-school")
+		if err != nil {
+			t.Fatalf("consumer info: %v", err)
+		}
+		if ci.NumAckPending == 0 && ci.NumPending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("consumer not drained: ackPending=%d pending=%d", ci.NumAckPending, ci.NumPending)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if syncs["broken"] != 3 {
+		t.Errorf("broken synced %d times, want 3", syncs["broken"])
+	}
+	if syncs["ok"] != 1 {
+		t.Errorf("ok synced %d times, want 1", syncs["ok"])
+	}
+}
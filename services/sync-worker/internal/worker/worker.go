@@ -5,161 +5,308 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math"
-	"net/http"
+	"sync"
 	"time"
 
+	"github.com/edvirons/ssp/shared/pkg/eventbus"
 	"github.com/edvirons/ssp/sync_worker/internal/config"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
 )
 
+// Stream and consumer names used by the worker.
+const (
+	// ChangeStream captures ssot.<kind>.changed signals published by the
+	// SSOT services so they survive worker restarts.
+	ChangeStream = "SSOT_CHANGES"
+	// DeadLetterStream holds sync jobs that exhausted their retries.
+	DeadLetterStream = "SSOT_SYNC_DLQ"
+
+	deadLetterSubjectPrefix = "ssot.sync.dlq."
+	consumerQueue           = "sync-worker"
+)
+
+// Kinds lists the SSOT kinds the worker keeps in sync.
+var Kinds = []string{"school", "devices", "parts"}
+
 // SyncWorker handles SSOT synchronization events.
 type SyncWorker struct {
 	log    *zap.Logger
 	db     *pgxpool.Pool
 	config config.Config
+	js     nats.JetStreamContext
+
+	// slots bounds concurrent syncs per kind.
+	slots map[string]chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*syncJob // in-flight syncs keyed by kind/tenant
+	wg   sync.WaitGroup
+
+	// syncTenant and recordDeadLetter default to fetchAndUpsert and
+	// deadLetter; tests replace them.
+	syncTenant       func(ctx context.Context, kind, tenant string) error
+	recordDeadLetter func(ctx context.Context, kind, tenant string, m signal, attempts int, syncErr error) error
+}
+
+// syncJob collects signals that arrive while a sync for the same kind and
+// tenant is already running; they are settled by one follow-up sync.
+type syncJob struct {
+	pending []signal
+}
+
+// signal is one ssot.<kind>.changed delivery awaiting settlement.
+type signal interface {
+	Ack(opts ...nats.AckOpt) error
+	NakWithDelay(delay time.Duration, opts ...nats.AckOpt) error
+	Term(opts ...nats.AckOpt) error
+	InProgress(opts ...nats.AckOpt) error
+	Metadata() (*nats.MsgMetadata, error)
+	subject() string
+	data() []byte
 }
 
+// natsSignal adapts a JetStream message to signal.
+type natsSignal struct{ *nats.Msg }
+
+func (m natsSignal) subject() string { return m.Subject }
+func (m natsSignal) data() []byte    { return m.Data }
+
 // New creates a new SyncWorker.
 func New(log *zap.Logger, db *pgxpool.Pool, cfg config.Config) *SyncWorker {
-	return &SyncWorker{
+	n := cfg.KindConcurrency
+	if n <= 0 {
+		n = 1
+	}
+	slots := make(map[string]chan struct{}, len(Kinds))
+	for _, k := range Kinds {
+		slots[k] = make(chan struct{}, n)
+	}
+	sw := &SyncWorker{
 		log:    log,
 		db:     db,
 		config: cfg,
+		slots:  slots,
+		jobs:   make(map[string]*syncJob),
 	}
+	sw.syncTenant = sw.fetchAndUpsert
+	sw.recordDeadLetter = sw.deadLetter
+	return sw
 }
 
-// HandleEvent returns a NATS message handler for the given kind.
-func (sw *SyncWorker) HandleEvent(kind string) nats.MsgHandler {
-	return func(m *nats.Msg) {
-		tenant := extractTenantID(m.Data)
-		if tenant == "" {
-			sw.log.Warn("event missing tenantId", zap.String("subject", m.Subject))
-			return
-		}
-
-		sw.log.Info("event received",
-			zap.String("subject", m.Subject),
-			zap.String("kind", kind),
-			zap.String("tenantId", tenant))
+// Start ensures the change and dead-letter streams exist and binds a durable
+// consumer per kind. Replicas share each consumer through a queue group.
+func (sw *SyncWorker) Start(nc *nats.Conn) error {
+	js, err := nc.JetStream()
+	if err != nil {
+		return fmt.Errorf("jetstream: %w", err)
+	}
+	sw.js = js
 
-		// Process in background with retry logic
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), sw.config.FetchTimeout)
-			defer cancel()
+	if err := eventbus.EnsureStream(js, eventbus.StreamConfig{
+		Name:     ChangeStream,
+		Subjects: []string{"ssot.*.changed"},
+		MaxAge:   sw.config.StreamMaxAge,
+	}); err != nil {
+		return fmt.Errorf("ensure stream %s: %w", ChangeStream, err)
+	}
+	if err := eventbus.EnsureStream(js, eventbus.StreamConfig{
+		Name:       DeadLetterStream,
+		Subjects:   []string{deadLetterSubjectPrefix + ">"},
+		Duplicates: 2 * time.Minute,
+	}); err != nil {
+		return fmt.Errorf("ensure stream %s: %w", DeadLetterStream, err)
+	}
 
-			if err := sw.fetchAndUpsertWithRetry(ctx, kind, tenant); err != nil {
-				sw.log.Error("failed to sync after retries",
-					zap.String("kind", kind),
-					zap.String("tenantId", tenant),
-					zap.Error(err))
-			}
-		}()
+	for _, kind := range Kinds {
+		subject := fmt.Sprintf("ssot.%s.changed", kind)
+		_, err := js.QueueSubscribe(subject, consumerQueue, sw.HandleEvent(kind),
+			nats.Durable("sync-worker-"+kind),
+			nats.BindStream(ChangeStream),
+			nats.ManualAck(),
+			nats.AckExplicit(),
+			nats.AckWait(sw.config.AckWait),
+			// Redelivery is unlimited on the server; the worker decides when a
+			// job is dead-lettered so it can record it before terminating.
+			nats.MaxDeliver(-1),
+		)
+		if err != nil {
+			return fmt.Errorf("subscribe %s: %w", subject, err)
+		}
+		sw.log.Info("durable consumer bound", zap.String("subject", subject))
 	}
+	return nil
 }
 
-// fetchAndUpsertWithRetry attempts to sync with exponential backoff retry.
-func (sw *SyncWorker) fetchAndUpsertWithRetry(ctx context.Context, kind, tenant string) error {
-	var lastErr error
-
-	for attempt := 0; attempt <= sw.config.MaxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s, 8s...
-			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * sw.config.InitialBackoff
-			sw.log.Info("retrying after backoff",
-				zap.String("kind", kind),
-				zap.String("tenantId", tenant),
-				zap.Int("attempt", attempt),
-				zap.Duration("backoff", backoff))
+// Wait blocks until in-flight syncs finish or ctx is done.
+func (sw *SyncWorker) Wait(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		sw.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
 
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				return fmt.Errorf("context cancelled during backoff: %w", ctx.Err())
-			}
-		}
+// HandleEvent returns a JetStream message handler for the given kind.
+// Signals for a kind and tenant that already has a sync running are
+// coalesced into a single follow-up sync.
+func (sw *SyncWorker) HandleEvent(kind string) nats.MsgHandler {
+	return func(m *nats.Msg) { sw.handle(kind, natsSignal{m}) }
+}
 
-		err := sw.fetchAndUpsert(ctx, kind, tenant)
-		if err == nil {
-			if attempt > 0 {
-				sw.log.Info("sync succeeded after retry",
-					zap.String("kind", kind),
-					zap.String("tenantId", tenant),
-					zap.Int("attempt", attempt))
-			}
-			return nil
-		}
+// handle starts a sync for the signal's tenant, or adds the signal to the
+// one already running.
+func (sw *SyncWorker) handle(kind string, m signal) {
+	tenant := extractTenantID(m.data())
+	if tenant == "" {
+		sw.log.Warn("event missing tenantId", zap.String("subject", m.subject()))
+		_ = m.Term()
+		return
+	}
 
-		lastErr = err
-		sw.log.Warn("sync attempt failed",
+	key := kind + "/" + tenant
+	sw.mu.Lock()
+	if j, ok := sw.jobs[key]; ok {
+		j.pending = append(j.pending, m)
+		sw.mu.Unlock()
+		sw.log.Debug("event coalesced",
 			zap.String("kind", kind),
-			zap.String("tenantId", tenant),
-			zap.Int("attempt", attempt),
-			zap.Error(err))
+			zap.String("tenantId", tenant))
+		return
 	}
+	sw.jobs[key] = &syncJob{}
+	sw.mu.Unlock()
+
+	sw.log.Info("event received",
+		zap.String("subject", m.subject()),
+		zap.String("kind", kind),
+		zap.String("tenantId", tenant))
 
-	return fmt.Errorf("max retries exceeded: %w", lastErr)
+	sw.wg.Add(1)
+	go sw.run(kind, tenant, []signal{m})
 }
 
-// fetchAndUpsert fetches data from SSOT and upserts into the database.
-func (sw *SyncWorker) fetchAndUpsert(ctx context.Context, kind, tenant string) error {
-	url := sw.getURLForKind(kind)
-	if url == "" {
-		return fmt.Errorf("unknown kind: %s", kind)
-	}
+// run syncs kind for tenant until no coalesced signals remain.
+func (sw *SyncWorker) run(kind, tenant string, batch []signal) {
+	defer sw.wg.Done()
+	key := kind + "/" + tenant
 
-	// Create request to SSOT export endpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/v1/export", nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-	req.Header.Set("X-Tenant-Id", tenant)
+	for len(batch) > 0 {
+		slot := sw.slots[kind]
+		slot <- struct{}{}
+		stop := keepAlive(batch, sw.config.AckWait/2)
+		err := sw.syncTenant(context.Background(), kind, tenant)
+		stop()
+		<-slot
 
-	// Fetch export from SSOT service
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("http request failed: %w", err)
-	}
-	defer resp.Body.Close()
+		sw.settle(kind, tenant, batch, err)
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return fmt.Errorf("ssot export failed: status=%d body=%s", resp.StatusCode, string(body))
+		sw.mu.Lock()
+		j := sw.jobs[key]
+		batch, j.pending = j.pending, nil
+		if len(batch) == 0 {
+			delete(sw.jobs, key)
+		}
+		sw.mu.Unlock()
 	}
+}
 
-	// Read response body
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("read response body: %w", err)
+// settle acks a batch after a successful sync. On failure it naks with
+// exponential backoff, or dead-letters the job once MaxRetries is exceeded.
+func (sw *SyncWorker) settle(kind, tenant string, batch []signal, syncErr error) {
+	if syncErr == nil {
+		for _, m := range batch {
+			_ = m.Ack()
+		}
+		return
 	}
 
-	// Validate JSON
-	var js any
-	if err := json.Unmarshal(data, &js); err != nil {
-		return fmt.Errorf("invalid json response: %w", err)
+	attempts := 1
+	for _, m := range batch {
+		if md, err := m.Metadata(); err == nil && int(md.NumDelivered) > attempts {
+			attempts = int(md.NumDelivered)
+		}
 	}
 
-	// Upsert into ims_ssot_snapshots table
-	_, err = sw.db.Exec(ctx, `
-		INSERT INTO ims_ssot_snapshots (tenant_id, kind, version, payload, updated_at)
-		VALUES ($1, $2, '1', $3::jsonb, $4)
-		ON CONFLICT (tenant_id, kind)
-		DO UPDATE SET payload = EXCLUDED.payload, updated_at = EXCLUDED.updated_at
-	`, tenant, kind, string(data), time.Now().UTC())
-
-	if err != nil {
-		return fmt.Errorf("database upsert failed: %w", err)
+	if attempts > sw.config.MaxRetries {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := sw.recordDeadLetter(ctx, kind, tenant, batch[0], attempts, syncErr); err != nil {
+			// Keep the job alive so it is dead-lettered on the next delivery.
+			sw.log.Error("dead-letter failed",
+				zap.String("kind", kind),
+				zap.String("tenantId", tenant),
+				zap.Error(err))
+			sw.nakAll(batch, sw.backoff(attempts))
+			return
+		}
+		for _, m := range batch {
+			_ = m.Term()
+		}
+		sw.log.Error("sync dead-lettered",
+			zap.String("kind", kind),
+			zap.String("tenantId", tenant),
+			zap.Int("attempts", attempts),
+			zap.Error(syncErr))
+		return
 	}
 
-	sw.log.Info("ssot snapshot synced",
+	delay := sw.backoff(attempts)
+	sw.log.Warn("sync attempt failed",
 		zap.String("kind", kind),
 		zap.String("tenantId", tenant),
-		zap.Int("bytes", len(data)))
+		zap.Int("attempt", attempts),
+		zap.Duration("retryIn", delay),
+		zap.Error(syncErr))
+	sw.nakAll(batch, delay)
+}
 
-	return nil
+// keepAlive marks the batch in progress now and every interval until stopped,
+// so a long multi-page sync is not redelivered mid-flight.
+func keepAlive(batch []signal, interval time.Duration) (stop func()) {
+	touch := func() {
+		for _, m := range batch {
+			_ = m.InProgress()
+		}
+	}
+	touch()
+	if interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				touch()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (sw *SyncWorker) nakAll(batch []signal, delay time.Duration) {
+	for _, m := range batch {
+		_ = m.NakWithDelay(delay)
+	}
+}
+
+// backoff returns the redelivery delay after the given attempt: 1s, 2s, 4s...
+func (sw *SyncWorker) backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return time.Duration(math.Pow(2, float64(attempt-1))) * sw.config.InitialBackoff
 }
 
 // getURLForKind returns the SSOT service URL for the given kind.
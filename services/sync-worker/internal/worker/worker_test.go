@@ -0,0 +1,199 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/sync_worker/internal/config"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// fakeSignal records how a delivery was settled.
+type fakeSignal struct {
+	payload   string
+	delivered uint64
+
+	mu    sync.Mutex
+	acked bool
+	termd bool
+	naks  []time.Duration
+}
+
+func newSignal(tenant string, delivered uint64) *fakeSignal {
+	return &fakeSignal{payload: `{"tenantId":"` + tenant + `"}`, delivered: delivered}
+}
+
+func (f *fakeSignal) Ack(...nats.AckOpt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = true
+	return nil
+}
+
+func (f *fakeSignal) NakWithDelay(d time.Duration, _ ...nats.AckOpt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.naks = append(f.naks, d)
+	return nil
+}
+
+func (f *fakeSignal) Term(...nats.AckOpt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.termd = true
+	return nil
+}
+
+func (f *fakeSignal) InProgress(...nats.AckOpt) error { return nil }
+
+func (f *fakeSignal) Metadata() (*nats.MsgMetadata, error) {
+	return &nats.MsgMetadata{NumDelivered: f.delivered}, nil
+}
+
+func (f *fakeSignal) subject() string { return "ssot.school.changed" }
+func (f *fakeSignal) data() []byte    { return []byte(f.payload) }
+
+func (f *fakeSignal) settled() (acked, termd bool, naks []time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.acked, f.termd, append([]time.Duration{}, f.naks...)
+}
+
+func testWorker() *SyncWorker {
+	return New(zap.NewNop(), nil, config.Config{
+		MaxRetries:      3,
+		InitialBackoff:  time.Second,
+		KindConcurrency: 2,
+	})
+}
+
+func TestSettle(t *testing.T) {
+	syncErr := errors.New("ssot unavailable")
+	tests := []struct {
+		name      string
+		delivered []uint64
+		syncErr   error
+		dlErr     error
+		wantAck   bool
+		wantTerm  bool
+		wantNak   time.Duration // 0 means no nak
+		wantDL    int           // attempts passed to the dead-letter, 0 if none
+	}{
+		{name: "success acks", delivered: []uint64{1, 2}, wantAck: true},
+		{name: "first failure naks 1s", delivered: []uint64{1}, syncErr: syncErr, wantNak: time.Second},
+		{name: "backoff doubles", delivered: []uint64{2}, syncErr: syncErr, wantNak: 2 * time.Second},
+		{name: "most delivered signal counts", delivered: []uint64{1, 3}, syncErr: syncErr, wantNak: 4 * time.Second},
+		{name: "dead-letters past max retries", delivered: []uint64{4, 1}, syncErr: syncErr, wantTerm: true, wantDL: 4},
+		{name: "failed dead-letter naks", delivered: []uint64{4}, syncErr: syncErr, dlErr: errors.New("db down"), wantNak: 8 * time.Second, wantDL: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := testWorker()
+			var dlAttempts int
+			var dlSignal signal
+			sw.recordDeadLetter = func(_ context.Context, kind, tenant string, m signal, attempts int, err error) error {
+				if kind != "school" || tenant != "t1" || !errors.Is(err, syncErr) {
+					t.Errorf("dead-letter(%s, %s, %v)", kind, tenant, err)
+				}
+				dlAttempts, dlSignal = attempts, m
+				return tt.dlErr
+			}
+
+			var batch []signal
+			var fakes []*fakeSignal
+			for _, d := range tt.delivered {
+				f := newSignal("t1", d)
+				fakes = append(fakes, f)
+				batch = append(batch, f)
+			}
+			sw.settle("school", "t1", batch, tt.syncErr)
+
+			if dlAttempts != tt.wantDL {
+				t.Errorf("dead-letter attempts = %d, want %d", dlAttempts, tt.wantDL)
+			}
+			if tt.wantDL > 0 && dlSignal != batch[0] {
+				t.Error("dead-letter did not record the first signal")
+			}
+			for i, f := range fakes {
+				acked, termd, naks := f.settled()
+				if acked != tt.wantAck || termd != tt.wantTerm {
+					t.Errorf("signal %d: acked=%v termed=%v, want %v %v", i, acked, termd, tt.wantAck, tt.wantTerm)
+				}
+				switch {
+				case tt.wantNak == 0 && len(naks) > 0:
+					t.Errorf("signal %d: nak %v, want none", i, naks)
+				case tt.wantNak > 0 && (len(naks) != 1 || naks[0] != tt.wantNak):
+					t.Errorf("signal %d: naks %v, want [%v]", i, naks, tt.wantNak)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleCoalescesPerTenant(t *testing.T) {
+	sw := testWorker()
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var mu sync.Mutex
+	calls := map[string]int{}
+	sw.syncTenant = func(_ context.Context, kind, tenant string) error {
+		mu.Lock()
+		calls[tenant]++
+		first := tenant == "t1" && calls[tenant] == 1
+		mu.Unlock()
+		if first {
+			started <- struct{}{}
+			<-release
+		}
+		return nil
+	}
+
+	first := newSignal("t1", 1)
+	sw.handle("school", first)
+	<-started
+
+	// Signals for t1 wait for the running sync; t2 syncs on its own
+	queued := []*fakeSignal{newSignal("t1", 1), newSignal("t1", 1)}
+	for _, f := range queued {
+		sw.handle("school", f)
+	}
+	other := newSignal("t2", 1)
+	sw.handle("school", other)
+	noTenant := &fakeSignal{payload: `{}`}
+	sw.handle("school", noTenant)
+
+	sw.mu.Lock()
+	pending := len(sw.jobs["school/t1"].pending)
+	sw.mu.Unlock()
+	if pending != len(queued) {
+		t.Errorf("pending for t1 = %d, want %d", pending, len(queued))
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sw.Wait(ctx)
+
+	mu.Lock()
+	if calls["t1"] != 2 || calls["t2"] != 1 {
+		t.Errorf("syncs = %v, want t1:2 t2:1", calls)
+	}
+	mu.Unlock()
+	for i, f := range append([]*fakeSignal{first, other}, queued...) {
+		if acked, _, _ := f.settled(); !acked {
+			t.Errorf("signal %d not acked", i)
+		}
+	}
+	if _, termd, _ := noTenant.settled(); !termd {
+		t.Error("signal without tenantId not terminated")
+	}
+	sw.mu.Lock()
+	if len(sw.jobs) != 0 {
+		t.Errorf("jobs left after sync: %v", sw.jobs)
+	}
+	sw.mu.Unlock()
+}
@@ -0,0 +1,18 @@
+-- Sync jobs that exhausted their retries. Listed and replayed via the admin API.
+CREATE TABLE IF NOT EXISTS sync_dead_letters (
+  id           TEXT PRIMARY KEY,
+  kind         TEXT NOT NULL,
+  tenant_id    TEXT NOT NULL,
+  subject      TEXT NOT NULL,
+  payload      JSONB NOT NULL,
+  error        TEXT NOT NULL,
+  attempts     INT NOT NULL,
+  failed_at    TIMESTAMPTZ NOT NULL,
+  replay_count INT NOT NULL DEFAULT 0,
+  replayed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_dead_letters_failed
+  ON sync_dead_letters (failed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sync_dead_letters_tenant_kind
+  ON sync_dead_letters (tenant_id, kind);
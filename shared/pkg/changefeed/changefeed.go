@@ -0,0 +1,178 @@
+// Package changefeed implements the updatedSince + cursor change feed exposed
+// by the SSOT services. A feed returns rows changed after a position together
+// with tombstones for rows deleted after it, both ordered by (time, id).
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/cursor"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	DefaultLimit = 500
+	MaxLimit     = 2000
+)
+
+// Tombstone records that an entity was deleted.
+type Tombstone struct {
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Page is one page of a change feed. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T         `json:"items"`
+	Deleted    []Tombstone `json:"deleted"`
+	NextCursor string      `json:"nextCursor"`
+}
+
+// Query selects changes strictly after (AfterAt, AfterID).
+type Query struct {
+	AfterAt time.Time
+	AfterID string
+	Limit   int
+}
+
+// ParseQuery reads updatedSince (RFC3339, inclusive), cursor and limit from r.
+// A cursor takes precedence over updatedSince.
+func ParseQuery(r *http.Request) (Query, error) {
+	v := r.URL.Query()
+	q := Query{AfterAt: time.Unix(0, 0).UTC(), Limit: DefaultLimit}
+
+	if s := v.Get("updatedSince"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return Query{}, errors.New("updatedSince must be RFC3339")
+		}
+		q.AfterAt = t.UTC()
+	}
+	if c := v.Get("cursor"); c != "" {
+		t, id, ok := cursor.Decode(c)
+		if !ok {
+			return Query{}, errors.New("invalid cursor")
+		}
+		q.AfterAt, q.AfterID = t, id
+	}
+	if l := v.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return Query{}, errors.New("limit must be a positive integer")
+		}
+		if n > MaxLimit {
+			n = MaxLimit
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// Querier is satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// LoadTombstones reads up to q.Limit tombstones for resource from the
+// service's tombstones table.
+func LoadTombstones(ctx context.Context, db Querier, tenant, resource string, q Query) ([]Tombstone, error) {
+	rows, err := db.Query(ctx, `
+		SELECT entity_id, deleted_at
+		FROM tombstones
+		WHERE tenant_id=$1 AND resource=$2 AND (deleted_at, entity_id) > ($3, $4)
+		ORDER BY deleted_at, entity_id
+		LIMIT $5
+	`, tenant, resource, q.AfterAt, q.AfterID, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Tombstone{}
+	for rows.Next() {
+		var t Tombstone
+		if err := rows.Scan(&t.ID, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Build merges items and tombstones, each fetched with q, into a page of at
+// most q.Limit changes. key returns an item's (updatedAt, id).
+func Build[T any](q Query, items []T, key func(T) (time.Time, string), deleted []Tombstone) Page[T] {
+	p := Page[T]{Items: []T{}, Deleted: []Tombstone{}}
+	var lastAt time.Time
+	var lastID string
+
+	merge(items, key, deleted, func(it *T, t *Tombstone) bool {
+		if len(p.Items)+len(p.Deleted) >= q.Limit {
+			return false
+		}
+		if it != nil {
+			lastAt, lastID = key(*it)
+			p.Items = append(p.Items, *it)
+		} else {
+			lastAt, lastID = t.DeletedAt, t.ID
+			p.Deleted = append(p.Deleted, *t)
+		}
+		return true
+	})
+
+	taken := len(p.Items) + len(p.Deleted)
+	more := taken < len(items)+len(deleted) || len(items) == q.Limit || len(deleted) == q.Limit
+	if taken == q.Limit && more {
+		p.NextCursor = cursor.Encode(lastAt, lastID)
+	}
+	return p
+}
+
+// Walk applies a page in feed order, so an entity deleted and re-created (or
+// updated then deleted) within one page ends up in its latest state.
+func Walk[T any](p Page[T], key func(T) (time.Time, string), upsert func(T) error, remove func(Tombstone) error) error {
+	var err error
+	merge(p.Items, key, p.Deleted, func(it *T, t *Tombstone) bool {
+		if it != nil {
+			err = upsert(*it)
+		} else {
+			err = remove(*t)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// merge visits items and tombstones in (time, id) order until visit returns false.
+func merge[T any](items []T, key func(T) (time.Time, string), deleted []Tombstone, visit func(*T, *Tombstone) bool) {
+	i, j := 0, 0
+	for i < len(items) || j < len(deleted) {
+		takeItem := j >= len(deleted)
+		if i < len(items) && j < len(deleted) {
+			at, id := key(items[i])
+			takeItem = before(at, id, deleted[j].DeletedAt, deleted[j].ID)
+		}
+		if takeItem {
+			if !visit(&items[i], nil) {
+				return
+			}
+			i++
+		} else {
+			if !visit(nil, &deleted[j]) {
+				return
+			}
+			j++
+		}
+	}
+}
+
+func before(at1 time.Time, id1 string, at2 time.Time, id2 string) bool {
+	if !at1.Equal(at2) {
+		return at1.Before(at2)
+	}
+	return id1 < id2
+}
@@ -0,0 +1,107 @@
+package changefeed_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edvirons/ssp/shared/pkg/changefeed"
+	"github.com/edvirons/ssp/shared/pkg/cursor"
+)
+
+type row struct {
+	ID        string
+	UpdatedAt time.Time
+}
+
+func rowKey(r row) (time.Time, string) { return r.UpdatedAt, r.ID }
+
+var t0 = time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+func TestBuild_MergesInOrder(t *testing.T) {
+	items := []row{{"a", t0}, {"c", t0.Add(2 * time.Second)}}
+	deleted := []changefeed.Tombstone{{ID: "b", DeletedAt: t0.Add(time.Second)}}
+
+	p := changefeed.Build(changefeed.Query{Limit: 10}, items, rowKey, deleted)
+
+	if len(p.Items) != 2 || len(p.Deleted) != 1 {
+		t.Fatalf("expected 2 items and 1 tombstone, got %d and %d", len(p.Items), len(p.Deleted))
+	}
+	if p.NextCursor != "" {
+		t.Errorf("expected no next cursor, got %q", p.NextCursor)
+	}
+}
+
+func TestBuild_TruncatesAndSetsCursor(t *testing.T) {
+	items := []row{{"a", t0}, {"c", t0.Add(2 * time.Second)}}
+	deleted := []changefeed.Tombstone{{ID: "b", DeletedAt: t0.Add(time.Second)}, {ID: "d", DeletedAt: t0.Add(3 * time.Second)}}
+
+	p := changefeed.Build(changefeed.Query{Limit: 2}, items, rowKey, deleted)
+
+	if len(p.Items) != 1 || p.Items[0].ID != "a" {
+		t.Fatalf("expected item a, got %+v", p.Items)
+	}
+	if len(p.Deleted) != 1 || p.Deleted[0].ID != "b" {
+		t.Fatalf("expected tombstone b, got %+v", p.Deleted)
+	}
+	at, id, ok := cursor.Decode(p.NextCursor)
+	if !ok || id != "b" || !at.Equal(t0.Add(time.Second)) {
+		t.Errorf("expected cursor at tombstone b, got %v %q %v", at, id, ok)
+	}
+}
+
+func TestBuild_FullPageHasCursor(t *testing.T) {
+	items := []row{{"a", t0}, {"b", t0}}
+
+	p := changefeed.Build(changefeed.Query{Limit: 2}, items, rowKey, nil)
+
+	if p.NextCursor == "" {
+		t.Error("expected a next cursor when the item query filled the limit")
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/changes/schools?updatedSince=2026-10-01T08:00:00Z&limit=5000", nil)
+	q, err := changefeed.ParseQuery(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.AfterAt.Equal(t0) || q.AfterID != "" {
+		t.Errorf("expected position at updatedSince, got %v %q", q.AfterAt, q.AfterID)
+	}
+	if q.Limit != changefeed.MaxLimit {
+		t.Errorf("expected limit capped at %d, got %d", changefeed.MaxLimit, q.Limit)
+	}
+
+	c := cursor.Encode(t0, "school_1")
+	r = httptest.NewRequest("GET", "/v1/changes/schools?updatedSince=2020-01-01T00:00:00Z&cursor="+c, nil)
+	q, err = changefeed.ParseQuery(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.AfterID != "school_1" {
+		t.Errorf("expected cursor to take precedence, got %q", q.AfterID)
+	}
+
+	r = httptest.NewRequest("GET", "/v1/changes/schools?updatedSince=yesterday", nil)
+	if _, err := changefeed.ParseQuery(r); err == nil {
+		t.Error("expected error for invalid updatedSince")
+	}
+}
+
+func TestWalk_AppliesInFeedOrder(t *testing.T) {
+	p := changefeed.Page[row]{
+		Items:   []row{{"a", t0.Add(2 * time.Second)}},
+		Deleted: []changefeed.Tombstone{{ID: "a", DeletedAt: t0.Add(time.Second)}},
+	}
+	var ops []string
+	err := changefeed.Walk(p, rowKey,
+		func(r row) error { ops = append(ops, "upsert "+r.ID); return nil },
+		func(d changefeed.Tombstone) error { ops = append(ops, "delete "+d.ID); return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ops) != 2 || ops[0] != "delete a" || ops[1] != "upsert a" {
+		t.Errorf("expected delete then re-create, got %v", ops)
+	}
+}
@@ -0,0 +1,62 @@
+package eventbus
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// StreamConfig describes a JetStream stream a publisher or consumer relies on.
+type StreamConfig struct {
+	Name       string
+	Subjects   []string
+	MaxAge     time.Duration
+	Duplicates time.Duration // window for Nats-Msg-Id de-duplication
+}
+
+// EnsureStream creates the stream if it does not exist yet.
+func EnsureStream(js nats.JetStreamContext, c StreamConfig) error {
+	_, err := js.StreamInfo(c.Name)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return err
+	}
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:       c.Name,
+		Subjects:   c.Subjects,
+		Storage:    nats.FileStorage,
+		MaxAge:     c.MaxAge,
+		Duplicates: c.Duplicates,
+	})
+	return err
+}
+
+// JetStreamPublisher publishes messages and waits for the stream to store
+// them, so a nil error means the message is durable.
+type JetStreamPublisher struct{ js nats.JetStreamContext }
+
+func NewJetStreamPublisher(nc *nats.Conn) (*JetStreamPublisher, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	return &JetStreamPublisher{js: js}, nil
+}
+
+// JetStream returns the underlying JetStream context.
+func (p *JetStreamPublisher) JetStream() nats.JetStreamContext { return p.js }
+
+// Publish sends data with msgID as the Nats-Msg-Id header; re-publishing the
+// same id inside the stream's duplicate window is a no-op.
+func (p *JetStreamPublisher) Publish(subject, msgID string, data []byte) error {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	if msgID != "" {
+		msg.Header.Set(nats.MsgIdHdr, msgID)
+	}
+	_, err := p.js.PublishMsg(msg)
+	return err
+}
@@ -0,0 +1,71 @@
+//go:build integration
+// +build integration
+
+package eventbus
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// TestJetStreamPublisher needs a JetStream server at TEST_NATS_URL. It uses
+// its own stream and removes it afterwards.
+func TestJetStreamPublisher(t *testing.T) {
+	url := os.Getenv("TEST_NATS_URL")
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	nc, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	pub, err := NewJetStreamPublisher(nc)
+	if err != nil {
+		t.Fatalf("publisher: %v", err)
+	}
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	subject := "test.eventbus." + suffix + ".created"
+
+	// Without a stream nothing stores the message, so the outbox relay must
+	// see an error and keep the row pending
+	if err := pub.Publish(subject, "evt-1", []byte(`{}`)); err == nil {
+		t.Fatal("publish without a stream succeeded")
+	}
+
+	cfg := StreamConfig{
+		Name:       "TEST_EVENTBUS_" + suffix,
+		Subjects:   []string{"test.eventbus." + suffix + ".>"},
+		MaxAge:     time.Hour,
+		Duplicates: time.Minute,
+	}
+	if err := EnsureStream(pub.JetStream(), cfg); err != nil {
+		t.Fatalf("ensure stream: %v", err)
+	}
+	t.Cleanup(func() { _ = pub.JetStream().DeleteStream(cfg.Name) })
+	if err := EnsureStream(pub.JetStream(), cfg); err != nil {
+		t.Fatalf("ensure existing stream: %v", err)
+	}
+
+	// A retried publish reuses the event id and is stored once
+	for i := 0; i < 2; i++ {
+		if err := pub.Publish(subject, "evt-1", []byte(`{}`)); err != nil {
+			t.Fatalf("publish %d: %v", i, err)
+		}
+	}
+	if err := pub.Publish(subject, "evt-2", []byte(`{}`)); err != nil {
+		t.Fatalf("publish evt-2: %v", err)
+	}
+	info, err := pub.JetStream().StreamInfo(cfg.Name)
+	if err != nil {
+		t.Fatalf("stream info: %v", err)
+	}
+	if info.State.Msgs != 2 {
+		t.Errorf("stream holds %d messages, want 2", info.State.Msgs)
+	}
+}